@@ -0,0 +1,176 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	accountrepo "github.com/anglesson/simple-web-server/internal/account/repository"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/money"
+)
+
+const usage = "uso: fee-plans list | create -name ... -kind ... -percent ... | assign -creator ... -plan ... [-starts AAAA-MM-DD] [-ends AAAA-MM-DD]"
+
+// run executa o subcomando informado em args e escreve o resultado em out
+func run(args []string, out io.Writer, feePlans salesvc.FeePlanService, creators accountrepo.CreatorRepository) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	switch args[0] {
+	case "list":
+		return listPlans(out, feePlans)
+	case "create":
+		return createPlan(args[1:], out, feePlans)
+	case "assign":
+		return assignPlan(args[1:], out, feePlans, creators)
+	default:
+		return fmt.Errorf("subcomando %q desconhecido\n%s", args[0], usage)
+	}
+}
+
+func listPlans(out io.Writer, feePlans salesvc.FeePlanService) error {
+	plans, err := feePlans.ListPlans()
+	if err != nil {
+		return fmt.Errorf("erro ao listar planos: %v", err)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNOME\tTIPO\tTAXA\tVOLUME MÍNIMO\tATIVO")
+	for _, plan := range plans {
+		minVolume := "-"
+		if plan.Kind == salesmodel.FeePlanKindVolume {
+			minVolume = money.FromCents(plan.MinMonthlyVolume).String()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%t\n", plan.PublicID, plan.Name, plan.Kind, plan.Display(), minVolume, plan.Active)
+	}
+	return w.Flush()
+}
+
+func createPlan(args []string, out io.Writer, feePlans salesvc.FeePlanService) error {
+	flags := flag.NewFlagSet("create", flag.ContinueOnError)
+	flags.SetOutput(out)
+	name := flags.String("name", "", "nome do plano")
+	kind := flags.String("kind", string(salesmodel.FeePlanKindCustom), "default, subscriber, volume ou custom")
+	percent := flags.String("percent", "", "percentual sobre a venda (ex.: 2,91)")
+	fixed := flags.String("fixed", "", "parcela fixa em reais (ex.: 1,00)")
+	minVolume := flags.String("min-volume", "", "volume mínimo em reais nos últimos 30 dias (faixas de volume)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	percentage, err := parsePercent(*percent)
+	if err != nil {
+		return err
+	}
+	fixedFee, err := money.ParseBRL(*fixed)
+	if err != nil {
+		return fmt.Errorf("parcela fixa inválida: %v", err)
+	}
+	volume, err := money.ParseBRL(*minVolume)
+	if err != nil {
+		return fmt.Errorf("volume mínimo inválido: %v", err)
+	}
+
+	plan, err := feePlans.CreatePlan(salesmodel.FeePlanInput{
+		Name:             *name,
+		Kind:             salesmodel.FeePlanKind(*kind),
+		Percentage:       percentage,
+		FixedFeeCents:    fixedFee.Amount,
+		MinMonthlyVolume: volume.Amount,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Plano %s cadastrado: %s (%s)\n", plan.PublicID, plan.Name, plan.Display())
+	return nil
+}
+
+func assignPlan(args []string, out io.Writer, feePlans salesvc.FeePlanService, creators accountrepo.CreatorRepository) error {
+	flags := flag.NewFlagSet("assign", flag.ContinueOnError)
+	flags.SetOutput(out)
+	creatorRef := flags.String("creator", "", "email ou ID público do criador")
+	planID := flags.String("plan", "", "ID público do plano (fee_...)")
+	starts := flags.String("starts", "", "início da vigência, AAAA-MM-DD (padrão: agora)")
+	ends := flags.String("ends", "", "fim da vigência, AAAA-MM-DD (vazio: sem prazo)")
+	note := flags.String("note", "", "motivo da atribuição")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *creatorRef == "" || *planID == "" {
+		return errors.New("informe -creator e -plan")
+	}
+
+	creator, err := findCreator(creators, *creatorRef)
+	if err != nil {
+		return err
+	}
+	plan, err := feePlans.FindPlanByPublicID(*planID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar plano: %v", err)
+	}
+	if plan == nil {
+		return fmt.Errorf("plano %s não encontrado", *planID)
+	}
+
+	startsAt := time.Now()
+	if *starts != "" {
+		if startsAt, err = time.ParseInLocation("2006-01-02", *starts, time.Local); err != nil {
+			return fmt.Errorf("data de início inválida: %s", *starts)
+		}
+	}
+	var endsAt *time.Time
+	if *ends != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", *ends, time.Local)
+		if err != nil {
+			return fmt.Errorf("data de fim inválida: %s", *ends)
+		}
+		endsAt = &parsed
+	}
+
+	if err := feePlans.AssignToCreator(creator.ID, plan.ID, startsAt, endsAt, strings.TrimSpace(*note)); err != nil {
+		return err
+	}
+	validity := "sem prazo"
+	if endsAt != nil {
+		validity = "até " + endsAt.Format("02/01/2006")
+	}
+	fmt.Fprintf(out, "Plano %s (%s) atribuído a %s a partir de %s, %s\n",
+		plan.PublicID, plan.Display(), creator.PublicID, startsAt.Format("02/01/2006"), validity)
+	return nil
+}
+
+// findCreator aceita o ID público do criador ou o email da conta dele
+func findCreator(creators accountrepo.CreatorRepository, ref string) (*accountmodel.Creator, error) {
+	var creator *accountmodel.Creator
+	var err error
+	if strings.Contains(ref, "@") {
+		creator, err = creators.FindCreatorByUserEmail(strings.ToLower(strings.TrimSpace(ref)))
+	} else {
+		creator, err = creators.FindByPublicID(ref)
+	}
+	if err != nil || creator == nil {
+		return nil, fmt.Errorf("criador %s não encontrado", ref)
+	}
+	return creator, nil
+}
+
+// parsePercent converte "2,91" ou "2.91" para a fração usada no plano (0.0291)
+func parsePercent(value string) (float64, error) {
+	value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "%"))
+	if value == "" {
+		return 0, errors.New("informe o percentual com -percent")
+	}
+	percent, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+	if err != nil {
+		return 0, fmt.Errorf("percentual inválido: %s", value)
+	}
+	return percent / 100, nil
+}
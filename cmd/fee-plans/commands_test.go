@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	accountrepo "github.com/anglesson/simple-web-server/internal/account/repository"
+	authmodel "github.com/anglesson/simple-web-server/internal/auth/model"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/database"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupCommand(t *testing.T) (func(args ...string) (string, error), *accountmodel.Creator) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&authmodel.User{}, &accountmodel.Creator{}, &salesmodel.FeePlan{}, &salesmodel.CreatorFeePlan{}, &salesmodel.Transaction{}))
+	database.DB = db
+
+	user := &authmodel.User{Username: "autora", Email: "autora@exemplo.com", Password: "x"}
+	require.NoError(t, db.Create(user).Error)
+	creator := &accountmodel.Creator{Name: "Autora", Email: user.Email, UserID: user.ID}
+	require.NoError(t, db.Create(creator).Error)
+
+	feePlans := salesvc.NewFeePlanService(salesrepo.NewFeePlanRepository(db), nil)
+	creators := accountrepo.NewGormCreatorRepository(db)
+	exec := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		err := run(args, &out, feePlans, creators)
+		return out.String(), err
+	}
+	return exec, creator
+}
+
+func TestRun_CreateAndAssignPlan(t *testing.T) {
+	exec, creator := setupCommand(t)
+
+	out, err := exec("create", "-name", "Black Friday", "-kind", "custom", "-percent", "1,99", "-fixed", "0,50")
+	require.NoError(t, err)
+	assert.Contains(t, out, "1,99% + R$ 0,50")
+
+	var plan salesmodel.FeePlan
+	require.NoError(t, database.DB.First(&plan).Error)
+	assert.InDelta(t, 0.0199, plan.Percentage, 1e-9)
+	assert.Equal(t, int64(50), plan.FixedFeeCents)
+
+	out, err = exec("list")
+	require.NoError(t, err)
+	assert.Contains(t, out, plan.PublicID)
+
+	ends := time.Now().AddDate(0, 1, 0).Format("2006-01-02")
+	out, err = exec("assign", "-creator", "autora@exemplo.com", "-plan", plan.PublicID, "-ends", ends, "-note", "negociação")
+	require.NoError(t, err)
+	assert.Contains(t, out, creator.PublicID)
+
+	assignment, err := salesrepo.NewFeePlanRepository(database.DB).FindActiveAssignment(creator.ID, time.Now())
+	require.NoError(t, err)
+	require.NotNil(t, assignment)
+	assert.Equal(t, plan.ID, assignment.FeePlanID)
+	assert.Equal(t, "negociação", assignment.Note)
+	require.NotNil(t, assignment.EndsAt)
+
+	effective := salesvc.NewFeePlanService(salesrepo.NewFeePlanRepository(database.DB), nil).EffectivePlan(creator, money.FromCents(10000))
+	assert.Equal(t, "Black Friday", effective.Name)
+
+	// Pelo ID público do criador, sem prazo
+	_, err = exec("assign", "-creator", creator.PublicID, "-plan", plan.PublicID)
+	require.NoError(t, err)
+}
+
+func TestRun_RejectsInvalidInput(t *testing.T) {
+	exec, creator := setupCommand(t)
+
+	_, err := exec()
+	assert.Error(t, err)
+	_, err = exec("delete")
+	assert.Error(t, err)
+	_, err = exec("create", "-name", "Sem percentual")
+	assert.Error(t, err)
+	_, err = exec("create", "-name", "Faixa", "-kind", "volume", "-percent", "2")
+	assert.ErrorIs(t, err, salesvc.ErrFeePlanInvalid)
+
+	_, err = exec("assign", "-creator", creator.PublicID, "-plan", "fee_inexistente")
+	assert.ErrorContains(t, err, "não encontrado")
+	_, err = exec("assign", "-creator", "ninguem@exemplo.com", "-plan", "fee_inexistente")
+	assert.ErrorContains(t, err, "criador")
+
+	var count int64
+	database.DB.Model(&salesmodel.CreatorFeePlan{}).Count(&count)
+	assert.Zero(t, count)
+}
@@ -0,0 +1,32 @@
+// Comando fee-plans cadastra planos de taxas e os atribui a criadores (promoções e negociações).
+//
+//	fee-plans list
+//	fee-plans create -name "Promo lançamento" -kind custom -percent 1,99 -fixed 0,50
+//	fee-plans create -name "Faixa 10k" -kind volume -percent 2 -fixed 1,00 -min-volume 10.000,00
+//	fee-plans assign -creator criador@exemplo.com -plan fee_xxx -starts 2026-11-01 -ends 2026-12-01 -note "Black Friday"
+package main
+
+import (
+	"log"
+	"os"
+
+	accountrepo "github.com/anglesson/simple-web-server/internal/account/repository"
+	"github.com/anglesson/simple-web-server/internal/config"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/database"
+)
+
+func main() {
+	config.LoadConfigs()
+	database.Connect()
+	defer database.Close()
+
+	// Sem o repositório de assinaturas: o comando não calcula o plano efetivo
+	feePlans := salesvc.NewFeePlanService(salesrepo.NewFeePlanRepository(database.DB), nil)
+	creators := accountrepo.NewGormCreatorRepository(database.DB)
+
+	if err := run(os.Args[1:], os.Stdout, feePlans, creators); err != nil {
+		log.Fatal(err)
+	}
+}
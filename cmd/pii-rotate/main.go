@@ -0,0 +1,56 @@
+// Comando pii-rotate regrava com a chave atual de PII_ENCRYPTION_KEYS os dados pessoais de
+// clientes, criadores, tentativas de checkout e consultas de CPF ainda em texto puro ou cifrados com chaves antigas, recalculando os
+// índices cegos. Pode ser interrompido e executado de novo: linhas já rotacionadas são puladas.
+package main
+
+import (
+	"flag"
+	"log"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	"github.com/anglesson/simple-web-server/internal/config"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"github.com/anglesson/simple-web-server/pkg/database"
+	"github.com/anglesson/simple-web-server/pkg/pii"
+)
+
+func main() {
+	batchSize := flag.Int("batch", 500, "linhas lidas por lote")
+	force := flag.Bool("force", false, "regrava todas as linhas (necessário após trocar PII_BLIND_INDEX_KEY)")
+	flag.Parse()
+
+	config.LoadConfigs()
+	database.Connect()
+	defer database.Close()
+
+	if !pii.Default().Enabled() {
+		log.Fatal("PII_ENCRYPTION_KEYS não configurada, nada a rotacionar")
+	}
+	log.Printf("Rotacionando dados pessoais para a chave %q", pii.Default().CurrentKeyID())
+
+	opts := pii.RotateOptions{BatchSize: *batchSize, Force: *force}
+
+	clients, err := pii.Rotate[salesmodel.Client](database.DB, opts)
+	if err != nil {
+		log.Fatalf("Falha ao rotacionar clientes após %d regravados: %v", clients.Rewritten, err)
+	}
+	log.Printf("Clientes: %d lidos, %d regravados", clients.Scanned, clients.Rewritten)
+
+	creators, err := pii.Rotate[accountmodel.Creator](database.DB, opts)
+	if err != nil {
+		log.Fatalf("Falha ao rotacionar criadores após %d regravados: %v", creators.Rewritten, err)
+	}
+	log.Printf("Criadores: %d lidos, %d regravados", creators.Scanned, creators.Rewritten)
+
+	attempts, err := pii.Rotate[salesmodel.CheckoutAttempt](database.DB, opts)
+	if err != nil {
+		log.Fatalf("Falha ao rotacionar tentativas de checkout após %d regravadas: %v", attempts.Rewritten, err)
+	}
+	log.Printf("Tentativas de checkout: %d lidas, %d regravadas", attempts.Scanned, attempts.Rewritten)
+
+	verifications, err := pii.Rotate[salesmodel.CPFVerification](database.DB, opts)
+	if err != nil {
+		log.Fatalf("Falha ao rotacionar consultas de CPF após %d regravadas: %v", verifications.Rewritten, err)
+	}
+	log.Printf("Consultas de CPF: %d lidas, %d regravadas", verifications.Scanned, verifications.Rewritten)
+}
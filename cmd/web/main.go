@@ -101,9 +101,24 @@ func main() {
 	userRepository := authrepo.NewGormUserRepository(database.DB)
 	ebookRepository := libraryrepo.NewGormEbookRepository(database.DB)
 	fileRepository := libraryrepo.NewGormFileRepository(database.DB)
+	coAuthorRepository := libraryrepo.NewGormCoAuthorRepository(database.DB)
 	purchaseRepository := salesrepo.NewPurchaseRepository()
 	transactionRepository := salesrepo.NewTransactionRepository(database.DB)
 	downloadRepository := deliveryrepo.NewGormDownloadRepository()
+	checkoutRecoveryRepository := salesrepo.NewCheckoutRecoveryRepository(database.DB)
+	stockRepository := salesrepo.NewStockRepository(database.DB)
+	waitlistRepository := salesrepo.NewWaitlistRepository(database.DB)
+	feePlanRepository := salesrepo.NewFeePlanRepository(database.DB)
+	affiliateRepository := salesrepo.NewAffiliateRepository(database.DB)
+	checkoutAttemptRepository := salesrepo.NewCheckoutAttemptRepository(database.DB)
+	cpfVerificationRepository := salesrepo.NewCPFVerificationRepository(database.DB)
+	statementRepository := salesrepo.NewStatementRepository(database.DB)
+	payoutRepository := salesrepo.NewPayoutRepository(database.DB)
+	reconciliationRepository := salesrepo.NewReconciliationRepository(database.DB)
+	clientImportRepository := salesrepo.NewClientImportRepository(database.DB)
+	clientCRMRepository := salesrepo.NewClientCRMRepository(database.DB)
+	broadcastRepository := salesrepo.NewBroadcastRepository(database.DB)
+	privacyRepository := salesrepo.NewPrivacyRepository(database.DB)
 
 	// Variáveis para o Mailer
 	var mailPort int
@@ -115,7 +130,14 @@ func main() {
 	var transactionService salesvc.TransactionService
 
 	// Services
-	commonRFService := gov.NewHubDevService()
+	commonRFService := gov.NewResilientReceitaFederalService(gov.NewHubDevService(), gov.ResilienceOptions{
+		MaxRetries:       config.AppConfig.ReceitaFederalMaxRetries,
+		RetryBackoff:     500 * time.Millisecond,
+		FailureThreshold: config.AppConfig.ReceitaFederalBreakerThreshold,
+		Cooldown:         config.AppConfig.ReceitaFederalBreakerCooldown,
+	})
+	cpfVerificationService := salesvc.NewCPFVerificationService(cpfVerificationRepository, commonRFService, config.AppConfig.ReceitaFederalCacheTTL)
+	cpfVerificationService.StartScheduler(5 * time.Minute)
 	userService := authsvc.NewUserService(userRepository, encrypter)
 	subscriptionRepository := subscriptionrepository.NewSubscriptionGormRepository()
 	subscriptionService := subscriptionservice.NewSubscriptionService(subscriptionRepository, commonRFService)
@@ -126,6 +148,7 @@ func main() {
 	s3Storage := storage.NewS3Storage()
 	fileService := librarysvc.NewFileService(fileRepository, s3Storage)
 	ebookService := librarysvc.NewEbookService(ebookRepository, s3Storage)
+	coAuthorService := librarysvc.NewCoAuthorService(coAuthorRepository, creatorService)
 
 	// Mailer para o EmailService
 	mailPort, _ = strconv.Atoi(config.AppConfig.MailPort)
@@ -135,24 +158,91 @@ func main() {
 		config.AppConfig.MailUsername,
 		config.AppConfig.MailPassword)
 	authEmailService = authsvc.NewEmailService(mailer)
-	salesEmailService = salesvc.NewEmailService(mailer)
+	receiptService := salesvc.NewReceiptService(transactionRepository)
+	salesEmailService = salesvc.NewEmailService(mailer, receiptService)
 	resendDownloadLinkService := salesvc.NewResendDownloadLinkService(transactionRepository, purchaseRepository, salesEmailService)
-	downloadService := deliverysvc.NewDownloadService(purchaseRepository, downloadRepository)
+	downloadService := deliverysvc.NewDownloadService(purchaseRepository, downloadRepository, receiptService)
 	stripeConnectService = accountsvc.NewStripeConnectService(creatorService)
 
 	// Serviços adicionais - Purchase e Transaction
 	purchaseService = salesvc.NewPurchaseService(purchaseRepository, salesEmailService)
+	manualSaleService := salesvc.NewManualSaleService(purchaseService, transactionRepository, clientRepository, salesEmailService)
+
+	// Importação de clientes por planilha, processada em segundo plano
+	clientImportService := salesvc.NewClientImportService(clientImportRepository, clientRepository, purchaseService, salesEmailService)
+	salesvc.StartClientImportJob(clientImportService, 15*time.Second)
+
+	// Etiquetas, anotações e segmentos de clientes
+	clientCRMService := salesvc.NewClientCRMService(clientCRMRepository, clientRepository, purchaseService, salesEmailService)
+
+	// Emails em massa: conexão própria com o provedor e envio limitado por minuto
+	broadcastMailer := mail.NewThrottledMailer(mail.NewGoMailer(
+		config.AppConfig.MailHost,
+		mailPort,
+		config.AppConfig.MailUsername,
+		config.AppConfig.MailPassword), config.AppConfig.BroadcastMailsPerMinute)
+	broadcastService := salesvc.NewBroadcastService(broadcastRepository, clientCRMService, salesvc.NewEmailService(broadcastMailer, nil))
+	salesvc.StartBroadcastJob(broadcastService, 30*time.Second)
+	privacyService := salesvc.NewPrivacyService(privacyRepository, clientRepository, salesEmailService, config.AppConfig.PrivacyResponseDays)
+
+	// Planos de taxas por criador
+	feePlanService := salesvc.NewFeePlanService(feePlanRepository, subscriptionRepository)
 
 	// Transaction Service
 	transactionService = salesvc.NewTransactionService(
 		transactionRepository,
 		purchaseService,
 		creatorService,
-		stripeService)
+		stripeService,
+		feePlanService,
+		salesvc.NewStripeTransferGateway())
+
+	// Comissões de afiliados que saíram da carência
+	salesvc.StartSplitTransferJob(transactionService, time.Hour)
+
+	// Extrato do mês anterior enviado aos criadores no início de cada mês
+	statementService := salesvc.NewStatementService(statementRepository, creatorService, salesEmailService)
+	salesvc.StartMonthlyStatementJob(statementService, time.Hour)
+
+	// Repasses das contas Stripe Connect; os webhooks payout.* mantêm o status em dia entre as rodadas
+	payoutService := salesvc.NewPayoutService(payoutRepository, salesvc.NewStripePayoutGateway())
+	salesvc.StartPayoutSyncJob(payoutService, 6*time.Hour)
+
+	// Liberação das pré-vendas na data de lançamento
+	salesvc.StartPreOrderReleaseJob(purchaseService, 5*time.Minute)
+
+	// Recuperação de checkout abandonado
+	checkoutRecoveryService := salesvc.NewCheckoutRecoveryService(
+		checkoutRecoveryRepository,
+		purchaseService,
+		salesEmailService,
+		config.AppConfig.CheckoutRecoverySchedule,
+		config.AppConfig.CheckoutRecoveryLinkValidity,
+		config.AppConfig.CheckoutRecoveryDiscountPercent)
+	checkoutRecoveryService.StartScheduler(10 * time.Minute)
+
+	// Estoque das ofertas limitadas
+	stockService := salesvc.NewStockService(stockRepository, purchaseService)
+
+	// Lista de espera
+	waitlistService := salesvc.NewWaitlistService(waitlistRepository, salesEmailService)
+	affiliateService := salesvc.NewAffiliateService(affiliateRepository, ebookService)
+	fraudService := salesvc.NewFraudService(checkoutAttemptRepository, cpfVerificationRepository, purchaseRepository, purchaseService, transactionRepository, salesvc.NewStripeRefundGateway(), salesEmailService, config.AppConfig.FraudReviewScore, config.AppConfig.FraudBlockScore)
+
+	// Entrega das compras pagas, pelo webhook ou pela conciliação
+	paymentConfirmationService := salesvc.NewPaymentConfirmationService(purchaseService, transactionService, checkoutRecoveryService, waitlistService, salesEmailService)
+
+	// Conciliação diária das transações com os pagamentos do Stripe
+	reconciliationService := salesvc.NewReconciliationService(reconciliationRepository, transactionRepository, checkoutRecoveryRepository, salesvc.NewStripeReconciliationGateway(), paymentConfirmationService, salesEmailService)
+	salesvc.StartReconciliationJob(reconciliationService, 24*time.Hour, config.AppConfig.ReconciliationLookback)
 
 	// Handlers
 	authHandler := authhandler.NewAuthHandler(userService, sessionService, authEmailService, templateRenderer)
-	clientHandler := saleshandler.NewClientHandler(clientService, creatorService, sessionService, templateRenderer)
+	clientHandler := saleshandler.NewClientHandler(clientService, clientCRMService, creatorService, sessionService, templateRenderer)
+	clientImportHandler := saleshandler.NewClientImportHandler(clientImportService, ebookService, creatorService, sessionService, templateRenderer)
+	clientCRMHandler := saleshandler.NewClientCRMHandler(clientCRMService, ebookService, creatorService, sessionService, templateRenderer)
+	broadcastHandler := saleshandler.NewBroadcastHandler(broadcastService, clientCRMService, ebookService, creatorService, sessionService, templateRenderer)
+	privacyHandler := saleshandler.NewPrivacyHandler(privacyService, clientCRMService, creatorService, sessionService, templateRenderer)
 	creatorHandler := accounthandler.NewCreatorHandler(creatorService, stripeConnectService, sessionService, templateRenderer, userService, authEmailService)
 	settingsHandler := accounthandler.NewSettingsHandler(sessionService, creatorService, templateRenderer)
 	fileHandler := libraryhandler.NewFileHandler(fileService, sessionService, templateRenderer)
@@ -163,19 +253,29 @@ func main() {
 	homeHandler := sharedhandler.NewHomeHandler(templateRenderer, errorHandler)
 	downloadHandler := deliveryhandler.NewDownloadHandler(downloadService, templateRenderer)
 	purchaseHandler := saleshandler.NewPurchaseHandler(templateRenderer, ebookService)
-	checkoutHandler := saleshandler.NewCheckoutHandler(templateRenderer, ebookService, clientService, clientRepository, creatorService, commonRFService, salesEmailService, transactionService, purchaseService)
+	checkoutHandler := saleshandler.NewCheckoutHandler(templateRenderer, ebookService, clientService, clientRepository, creatorService, cpfVerificationService, salesEmailService, transactionService, purchaseService, checkoutRecoveryService, stockService, waitlistService, fraudService, feePlanService, coAuthorService, affiliateService)
 	// versionHandler := handler.NewVersionHandler()
 	purchaseSalesHandler := saleshandler.NewPurchaseSalesHandler(templateRenderer, purchaseService, sessionService, creatorService, ebookService, resendDownloadLinkService, transactionService)
 
-	stripeHandler := saleshandler.NewStripeHandler(userRepository, subscriptionService, purchaseRepository, purchaseService, salesEmailService, transactionService, creatorService)
+	stripeHandler := saleshandler.NewStripeHandler(userRepository, subscriptionService, purchaseRepository, purchaseService, salesEmailService, transactionService, creatorService, checkoutRecoveryService, stockService, fraudService, payoutService, paymentConfirmationService)
 	stripeConnectHandler := accounthandler.NewStripeConnectHandler(stripeConnectService, creatorService, sessionService, templateRenderer)
-	transactionHandler := saleshandler.NewTransactionHandler(transactionService, sessionService, creatorService, resendDownloadLinkService, templateRenderer)
+	checkoutRecoveryHandler := saleshandler.NewCheckoutRecoveryHandler(templateRenderer, checkoutRecoveryService, sessionService, creatorService)
+	waitlistHandler := saleshandler.NewWaitlistHandler(templateRenderer, waitlistService, ebookService, sessionService, creatorService)
+	affiliateHandler := saleshandler.NewAffiliateHandler(templateRenderer, affiliateService, ebookService, transactionService, sessionService, creatorService)
+	coAuthorHandler := saleshandler.NewCoAuthorHandler(templateRenderer, coAuthorService, ebookService, transactionService, sessionService, creatorService)
+	fraudHandler := saleshandler.NewFraudHandler(templateRenderer, fraudService, sessionService, creatorService)
+	transactionHandler := saleshandler.NewTransactionHandler(transactionService, sessionService, creatorService, resendDownloadLinkService, receiptService, templateRenderer)
+	statementHandler := saleshandler.NewStatementHandler(statementService, sessionService, creatorService, templateRenderer)
+	payoutHandler := saleshandler.NewPayoutHandler(payoutService, sessionService, creatorService, templateRenderer)
+	manualSaleHandler := saleshandler.NewManualSaleHandler(manualSaleService, ebookService, sessionService, creatorService, templateRenderer)
+	accessRecoveryHandler := saleshandler.NewAccessRecoveryHandler(templateRenderer, resendDownloadLinkService)
 
 	// Initialize rate limiters
 	authRateLimiter := middleware.NewRateLimiter(10, time.Minute)
 	resetPasswordRateLimiter := middleware.NewRateLimiter(5, time.Minute)
 	resendConfirmationRateLimiter := middleware.NewRateLimiter(5, time.Minute)
 	apiRateLimiter := middleware.NewRateLimiter(100, time.Minute)
+	accessRecoveryRateLimiter := middleware.NewRateLimiter(5, 15*time.Minute)
 	// uploadRateLimiter := middleware.NewRateLimiter(10, time.Minute)
 
 	// Start cleanup goroutines
@@ -183,6 +283,7 @@ func main() {
 	resetPasswordRateLimiter.CleanupRateLimiter()
 	resendConfirmationRateLimiter.CleanupRateLimiter()
 	apiRateLimiter.CleanupRateLimiter()
+	accessRecoveryRateLimiter.CleanupRateLimiter()
 	// uploadRateLimiter.CleanupRateLimiter()
 
 	r := chi.NewRouter()
@@ -216,7 +317,7 @@ func main() {
 		r.Post("/login", authHandler.LoginSubmit)
 		r.Get("/register", creatorHandler.RegisterView)
 		r.Post("/register", creatorHandler.RegisterCreatorSSR)
-		r.Get("/sales/{id}", salesPageHandler.SalesPageView)
+		r.With(affiliateHandler.TrackReferral).Get("/sales/{id}", salesPageHandler.SalesPageView)
 	})
 
 	// Email confirmation routes — completely public (no AuthGuard to avoid redirect loops)
@@ -233,10 +334,24 @@ func main() {
 		r.Post("/resend-confirmation", authHandler.ResendConfirmationSubmit)
 	})
 
+	// Reenvio de acesso pelo comprador — apenas o envio é limitado para a página exibir o aviso
+	r.Get("/reenviar-acesso", accessRecoveryHandler.AccessRecoveryView)
+	r.Group(func(r chi.Router) {
+		r.Use(accessRecoveryRateLimiter.RateLimitMiddleware)
+		r.Post("/reenviar-acesso", accessRecoveryHandler.AccessRecoverySubmit)
+	})
+
 	// Completely public routes (no middleware)
 	r.Get("/purchase/download/{hash_id}", downloadHandler.PurchaseDownloadHandler)
+	r.Get("/purchase/download/{hash_id}/receipt", downloadHandler.PurchaseReceiptHandler)
 	r.Get("/checkout/{id}", checkoutHandler.CheckoutView)
 	r.Get("/purchase/success", checkoutHandler.PurchaseSuccessView)
+	r.Get("/checkout/recover/{token}", checkoutHandler.RecoverCheckout)
+	r.Get("/sales/{id}/availability", salesPageHandler.SalesAvailability)
+	r.Post("/sales/{id}/waitlist", waitlistHandler.JoinWaitlist)
+	r.Get("/waitlist/{token}", waitlistHandler.TrackClick)
+	r.Get("/unsubscribe/{token}", broadcastHandler.UnsubscribeView)
+	r.Post("/unsubscribe/{token}", broadcastHandler.Unsubscribe)
 
 	// Version routes
 	// r.Get("/version", versionHandler.VersionText)
@@ -276,6 +391,21 @@ func main() {
 		r.Get("/ebook/{id}/image", ebookHandler.ServeEbookImage)
 		r.Post("/ebook/delete/{id}", ebookHandler.RemoveEbook)
 		r.Post("/ebook/{id}/remove-file/{fileId}", ebookHandler.RemoveFileFromEbook)
+		r.Get("/ebook/{id}/waitlist", waitlistHandler.WaitlistView)
+		r.Post("/ebook/{id}/waitlist/notify", waitlistHandler.NotifyWaitlist)
+		r.Get("/ebook/{id}/coauthors", coAuthorHandler.CoAuthorsView)
+		r.Post("/ebook/{id}/coauthors", coAuthorHandler.InviteCoAuthor)
+		r.Post("/ebook/{id}/coauthors/{coauthorID}/remove", coAuthorHandler.RemoveCoAuthor)
+		r.Get("/coauthorship", coAuthorHandler.CoAuthorshipView)
+		r.Post("/coauthorship/{id}/accept", coAuthorHandler.AcceptCoAuthorship)
+		r.Post("/coauthorship/{id}/decline", coAuthorHandler.DeclineCoAuthorship)
+		r.Get("/ebook/{id}/affiliates", affiliateHandler.EbookAffiliatesView)
+		r.Post("/ebook/{id}/affiliates/commission", affiliateHandler.UpdateCommission)
+		r.Post("/ebook/{id}/affiliates/{affiliateID}/block", affiliateHandler.BlockAffiliate)
+		r.Post("/ebook/{id}/affiliates/{affiliateID}/unblock", affiliateHandler.UnblockAffiliate)
+		r.Get("/affiliate", affiliateHandler.AffiliateDashboard)
+		r.Get("/affiliate/join/{id}", affiliateHandler.JoinView)
+		r.Post("/affiliate/join/{id}", affiliateHandler.Join)
 
 		// File routes with upload rate limiting
 		r.Group(func(r chi.Router) {
@@ -289,13 +419,45 @@ func main() {
 		// Client routes
 		r.Get("/client", clientHandler.ClientIndexView)
 		r.Get("/client/export", clientHandler.ClientExportCSV)
+		r.Get("/client/import", clientImportHandler.ImportView)
+		r.Post("/client/import", clientImportHandler.ImportUpload)
+		r.Get("/client/import/{id}", clientImportHandler.ImportDetailView)
+		r.Post("/client/import/{id}/start", clientImportHandler.ImportStart)
+		r.Get("/client/import/{id}/errors", clientImportHandler.ImportErrorsCSV)
+		r.Get("/client/segments", clientCRMHandler.SegmentsView)
+		r.Post("/client/segments", clientCRMHandler.CreateSegment)
+		r.Post("/client/segments/{id}/delete", clientCRMHandler.DeleteSegment)
+		r.Get("/client/segments/{id}/export", clientCRMHandler.SegmentExportCSV)
+		r.Post("/client/segments/{id}/grant", clientCRMHandler.SegmentGrantEbook)
+		r.Post("/client/tags/{id}/delete", clientCRMHandler.DeleteTag)
+		r.Get("/client/privacy", privacyHandler.RequestsView)
+		r.Get("/client/privacy/{id}/export", privacyHandler.ExportRequest)
+		r.Post("/client/privacy/{id}/complete", privacyHandler.CompleteRequest)
+		r.Get("/client/{id}", clientCRMHandler.ClientDetailView)
+		r.Post("/client/{id}/tags", clientCRMHandler.AddTag)
+		r.Post("/client/{id}/tags/{tagID}/delete", clientCRMHandler.RemoveTag)
+		r.Post("/client/{id}/notes", clientCRMHandler.AddNote)
+		r.Post("/client/{id}/notes/{noteID}/delete", clientCRMHandler.DeleteNote)
+		r.Post("/client/{id}/privacy", privacyHandler.RegisterRequest)
+		r.Get("/broadcasts", broadcastHandler.BroadcastsView)
+		r.Post("/broadcasts", broadcastHandler.CreateBroadcast)
+		r.Get("/broadcasts/new", broadcastHandler.NewBroadcastView)
+		r.Get("/broadcasts/{id}", broadcastHandler.BroadcastDetailView)
 
 		// Purchase routes
 		r.Post("/purchase/ebook/{id}", purchaseHandler.PurchaseCreateHandler)
 		r.Get("/purchase/sales", purchaseSalesHandler.PurchaseSalesList)
+		r.Get("/purchase/manual", manualSaleHandler.ManualSaleView)
+		r.Post("/purchase/manual", manualSaleHandler.RegisterManualSale)
 		r.Post("/purchase/sales/block-download", purchaseSalesHandler.BlockDownload)
 		r.Post("/purchase/sales/unblock-download", purchaseSalesHandler.UnblockDownload)
+		r.Post("/purchase/sales/revoke-access", purchaseSalesHandler.RevokeAccess)
+		r.Get("/purchase/sales/{id}/history", purchaseSalesHandler.PurchaseStatusHistoryView)
 		r.Post("/purchase/sales/resend-link", purchaseSalesHandler.ResendDownloadLink)
+		r.Get("/purchase/recovery", checkoutRecoveryHandler.RecoveryReportView)
+		r.Get("/purchase/suspicious", fraudHandler.SuspiciousCheckoutsView)
+		r.Post("/purchase/suspicious/{id}/approve", fraudHandler.ApproveCheckout)
+		r.Post("/purchase/suspicious/{id}/reject", fraudHandler.RejectCheckout)
 
 		// Onboarding Stripe Routes
 		r.Get("/stripe-connect/welcome", stripeConnectHandler.OnboardingWelcome)
@@ -305,7 +467,26 @@ func main() {
 
 		// Transaction Routes (apenas detalhes acessíveis via vendas)
 		r.Get("/transactions/detail", transactionHandler.TransactionDetail)
+		r.Get("/transactions/receipt", transactionHandler.DownloadReceipt)
 		r.Post("/transactions/resend-download-link", transactionHandler.ResendDownloadLink)
+		r.Get("/transactions/statements", statementHandler.StatementView)
+		r.Get("/transactions/statements/csv", statementHandler.StatementCSV)
+		r.Get("/transactions/statements/pdf", statementHandler.StatementPDF)
+
+		// Recebimentos (saldo e repasses do Stripe Connect)
+		r.Get("/payouts", payoutHandler.PayoutsView)
+		r.Post("/payouts/sync", payoutHandler.SyncPayouts)
+
+		// Solicitações de titulares (LGPD) atendidas pelos operadores da plataforma
+		r.Group(func(r chi.Router) {
+			r.Use(privacyHandler.RequireOperator)
+			r.Get("/ops/privacy", privacyHandler.OperatorRequestsView)
+			r.Post("/ops/privacy", privacyHandler.OperatorRegisterRequest)
+			r.Get("/ops/privacy/{id}/export", privacyHandler.OperatorExportRequest)
+			r.Post("/ops/privacy/{id}/complete", privacyHandler.OperatorCompleteRequest)
+			r.Post("/ops/privacy/{id}/anonymize", privacyHandler.OperatorAnonymize)
+			r.Post("/ops/privacy/{id}/reject", privacyHandler.OperatorReject)
+		})
 	})
 
 	r.Get("/", homeHandler.HomeView)
@@ -84,6 +84,8 @@ func (ch *CreatorHandler) RegisterCreatorSSR(w http.ResponseWriter, r *http.Requ
 		PhoneNumber:          r.FormValue("phone"),
 		Email:                r.FormValue("email"),
 		CPF:                  r.FormValue("cpf"),
+		PersonType:           r.FormValue("person_type"),
+		CNPJ:                 r.FormValue("cnpj"),
 		Password:             r.FormValue("password"),
 		PasswordConfirmation: r.FormValue("password_confirmation"),
 		TermsAccepted:        r.FormValue("terms_accepted"),
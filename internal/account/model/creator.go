@@ -5,27 +5,40 @@ import (
 	"strings"
 	"time"
 
+	"github.com/anglesson/simple-web-server/pkg/pii"
 	"github.com/anglesson/simple-web-server/pkg/utils"
 	"gorm.io/gorm"
 )
 
+// PersonType indica se o criador vende como pessoa física (CPF) ou jurídica (CNPJ)
+type PersonType string
+
+const (
+	PersonTypeIndividual PersonType = "PF"
+	PersonTypeCompany    PersonType = "PJ"
+)
+
 type Creator struct {
 	gorm.Model
-	PublicID               string    `json:"public_id" gorm:"type:varchar(40);uniqueIndex"`
-	Name                   string    `json:"name"`
-	SocialName             string    `json:"social_name"`
-	CPF                    string    `json:"cpf"`
-	Email                  string    `json:"email"`
-	Phone                  string    `json:"phone"`
-	BirthDate              time.Time `json:"birth_date"`
-	UserID                 uint      `json:"user_id"`
-	StripeConnectAccountID string    `json:"stripe_connect_account_id"`
-	OnboardingCompleted    bool      `json:"onboarding_completed" gorm:"default:false"`
-	PayoutsEnabled         bool      `json:"payouts_enabled" gorm:"default:false"`
-	ChargesEnabled         bool      `json:"charges_enabled" gorm:"default:false"`
-	OnboardingRefreshURL   string    `json:"onboarding_refresh_url"`
-	OnboardingReturnURL    string    `json:"onboarding_return_url"`
-	FacebookPixelID        string    `json:"facebook_pixel_id"`
+	PublicID               string     `json:"public_id" gorm:"type:varchar(40);uniqueIndex"`
+	Name                   string     `json:"name"`
+	SocialName             string     `json:"social_name"`
+	CPF                    string     `json:"cpf" gorm:"serializer:pii"`
+	CPFIndex               string     `json:"-" gorm:"type:varchar(64);index" pii:"index"` // HMAC dos dígitos do CPF cifrado, usado em FindByCPF
+	PersonType             PersonType `json:"person_type" gorm:"type:varchar(2);default:'PF'"`
+	CompanyName            string     `json:"company_name"`
+	CNPJ                   string     `json:"cnpj" gorm:"type:varchar(14);index"`
+	Email                  string     `json:"email"`
+	Phone                  string     `json:"phone" gorm:"serializer:pii"`
+	BirthDate              time.Time  `json:"birth_date" gorm:"type:text;serializer:pii"`
+	UserID                 uint       `json:"user_id"`
+	StripeConnectAccountID string     `json:"stripe_connect_account_id"`
+	OnboardingCompleted    bool       `json:"onboarding_completed" gorm:"default:false"`
+	PayoutsEnabled         bool       `json:"payouts_enabled" gorm:"default:false"`
+	ChargesEnabled         bool       `json:"charges_enabled" gorm:"default:false"`
+	OnboardingRefreshURL   string     `json:"onboarding_refresh_url"`
+	OnboardingReturnURL    string     `json:"onboarding_return_url"`
+	FacebookPixelID        string     `json:"facebook_pixel_id"`
 }
 
 func NewCreator(name, socialName, email, phone, cpf string, birthDate time.Time, userID uint) *Creator {
@@ -35,11 +48,17 @@ func NewCreator(name, socialName, email, phone, cpf string, birthDate time.Time,
 		Email:      email,
 		Phone:      phone,
 		CPF:        cpf,
+		PersonType: PersonTypeIndividual,
 		BirthDate:  birthDate,
 		UserID:     userID,
 	}
 }
 
+// IsCompany indica se o criador é pessoa jurídica; o CPF passa a ser o do representante legal
+func (c *Creator) IsCompany() bool {
+	return c.PersonType == PersonTypeCompany
+}
+
 func (c *Creator) GetDisplayName() string {
 	if c.SocialName != "" {
 		return c.SocialName
@@ -68,6 +87,12 @@ func (c *Creator) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// BeforeSave recalcula o índice cego do CPF a cada gravação, inclusive na rotação de chaves
+func (c *Creator) BeforeSave(tx *gorm.DB) error {
+	c.CPFIndex = pii.BlindIndex(pii.Digits(c.CPF))
+	return nil
+}
+
 // ValidateFacebookPixelID valida o ID do Meta Pixel.
 // Aceita string vazia (remove o pixel) ou exatamente 10–20 dígitos numéricos.
 func ValidateFacebookPixelID(pixelID string) error {
@@ -2,6 +2,7 @@ package model_test
 
 import (
 	"testing"
+	"time"
 
 	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
 	"github.com/stretchr/testify/assert"
@@ -78,3 +79,12 @@ func TestCreator_GetDisplayName(t *testing.T) {
 		})
 	}
 }
+
+func TestCreator_IsCompany(t *testing.T) {
+	individual := accountmodel.NewCreator("Maria Silva", "", "maria@example.com", "11987654321", "12345678909", time.Now(), 1)
+	assert.Equal(t, accountmodel.PersonTypeIndividual, individual.PersonType)
+	assert.False(t, individual.IsCompany())
+
+	company := &accountmodel.Creator{PersonType: accountmodel.PersonTypeCompany, CNPJ: "11222333000181"}
+	assert.True(t, company.IsCompany())
+}
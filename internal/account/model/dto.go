@@ -5,6 +5,8 @@ type InputCreateCreator struct {
 	Name                 string
 	SocialName           string
 	CPF                  string
+	PersonType           string // "PF" ou "PJ"; vazio equivale a "PF"
+	CNPJ                 string
 	BirthDate            string
 	PhoneNumber          string
 	Email                string
@@ -9,6 +9,7 @@ import (
 
 	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
 	"github.com/anglesson/simple-web-server/pkg/database"
+	"github.com/anglesson/simple-web-server/pkg/pii"
 )
 
 type GormCreatorRepository struct {
@@ -68,7 +69,7 @@ func (cr *GormCreatorRepository) FindCreatorByUserEmail(email string) (*accountm
 func (cr *GormCreatorRepository) FindByCPF(cpf string) (*accountmodel.Creator, error) {
 	var creator accountmodel.Creator
 	err := cr.db.
-		First(&creator, "cpf = ?", cpf).Error
+		First(&creator, "cpf_index = ? OR cpf = ?", pii.BlindIndex(pii.Digits(cpf)), cpf).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil // Creator not found, but that's not an error
@@ -79,6 +80,20 @@ func (cr *GormCreatorRepository) FindByCPF(cpf string) (*accountmodel.Creator, e
 	return &creator, nil
 }
 
+func (cr *GormCreatorRepository) FindByCNPJ(cnpj string) (*accountmodel.Creator, error) {
+	var creator accountmodel.Creator
+	err := cr.db.
+		First(&creator, "cnpj = ?", cnpj).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Creator not found, but that's not an error
+		}
+		log.Printf("error finding creator by CNPJ: %s", err.Error())
+		return nil, errors.New("error finding creator")
+	}
+	return &creator, nil
+}
+
 func (cr *GormCreatorRepository) Update(creator *accountmodel.Creator) error {
 	err := cr.db.Save(creator).Error
 	if err != nil {
@@ -8,6 +8,7 @@ type CreatorRepository interface {
 	FindCreatorByUserID(userID uint) (*accountmodel.Creator, error)
 	FindCreatorByUserEmail(email string) (*accountmodel.Creator, error)
 	FindByCPF(cpf string) (*accountmodel.Creator, error)
+	FindByCNPJ(cnpj string) (*accountmodel.Creator, error)
 	Update(creator *accountmodel.Creator) error
 	FindByID(id uint) (*accountmodel.Creator, error)
 	FindByPublicID(publicID string) (*accountmodel.Creator, error)
@@ -83,6 +83,17 @@ func (cs *creatorServiceImpl) CreateCreator(input InputCreateCreator) (*accountm
 		return nil, err
 	}
 
+	// Companies also need an active CNPJ not yet registered
+	isCompany := accountmodel.PersonType(input.PersonType) == accountmodel.PersonTypeCompany
+	var cnpj, companyName string
+	if isCompany {
+		cnpj = cleanCNPJ(input.CNPJ)
+		companyName, err = cs.validateCompany(cnpj)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Create user
 	inputCreateUser := authsvc.InputCreateUser{
 		Username:             validatedName,
@@ -106,6 +117,11 @@ func (cs *creatorServiceImpl) CreateCreator(input InputCreateCreator) (*accountm
 		birthDate,
 		userID,
 	)
+	if isCompany {
+		creator.PersonType = accountmodel.PersonTypeCompany
+		creator.CNPJ = cnpj
+		creator.CompanyName = companyName
+	}
 
 	// Save creator
 	err = cs.creatorRepo.Create(creator)
@@ -201,3 +217,29 @@ func (cs *creatorServiceImpl) validateReceita(name, cpf string, birthDate time.T
 
 	return response.Result.NomeDaPF, nil
 }
+
+// validateCompany checks the CNPJ is not in use and is active on Receita Federal, returning the company name
+func (cs *creatorServiceImpl) validateCompany(cnpj string) (string, error) {
+	creatorExists, err := cs.creatorRepo.FindByCNPJ(cnpj)
+	if err != nil {
+		return "", err
+	}
+	if creatorExists != nil {
+		return "", errors.New("CNPJ já cadastrado")
+	}
+
+	if cs.rfService == nil {
+		return "", errors.New("serviço da receita federal não está disponível")
+	}
+
+	response, err := cs.rfService.ConsultaCNPJ(cnpj)
+	if err != nil {
+		return "", err
+	}
+
+	if !response.IsActive() {
+		return "", errors.New("CNPJ inativo ou não encontrado na receita federal")
+	}
+
+	return response.Result.RazaoSocial, nil
+}
@@ -8,6 +8,9 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	"github.com/anglesson/simple-web-server/pkg/gov"
 )
 
 // validateCreatorInput validates all creator input fields
@@ -32,6 +35,10 @@ func validateCreatorInput(input InputCreateCreator) error {
 		return err
 	}
 
+	if err := validatePersonType(input.PersonType, input.CNPJ); err != nil {
+		return err
+	}
+
 	if err := validateTermsAccepted(input.TermsAccepted); err != nil {
 		return err
 	}
@@ -148,6 +155,22 @@ func validateCPF(value string) error {
 	return nil
 }
 
+// validatePersonType validates the person type and, for companies, the CNPJ.
+// For PJ creators the CPF and birth date belong to the legal representative.
+func validatePersonType(personType, cnpj string) error {
+	switch accountmodel.PersonType(personType) {
+	case "", accountmodel.PersonTypeIndividual:
+		return nil
+	case accountmodel.PersonTypeCompany:
+		if !gov.IsValidCNPJ(cleanCNPJ(cnpj)) {
+			return gov.ErrInvalidCNPJ
+		}
+		return nil
+	default:
+		return errors.New("tipo de pessoa inválido")
+	}
+}
+
 // validateBirthDate validates the birth date
 func validateBirthDate(birthDateStr string) error {
 	// First try to parse as DD/MM/YYYY format (from jmask)
@@ -223,6 +246,11 @@ func calculateDigit(cpf string, factor int) int {
 	return 11 - remainder
 }
 
+func cleanCNPJ(cnpj string) string {
+	re := regexp.MustCompile(`[^\d]`)
+	return re.ReplaceAllString(cnpj, "")
+}
+
 // Helper functions for phone validation
 func cleanPhone(phone string) string {
 	re := regexp.MustCompile(`[^\d]`)
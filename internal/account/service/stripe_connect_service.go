@@ -75,8 +75,19 @@ func NewStripeConnectService(creatorSvc CreatorService) StripeConnectService {
 	}
 }
 
-// CreateConnectAccount creates a new Stripe Connect account for the creator
-func (s *stripeConnectServiceImpl) CreateConnectAccount(creator *accountmodel.Creator) (string, error) {
+// setBusinessParams preenche o tipo de negócio da conta: "company" com os dados do CNPJ
+// para criadores PJ e "individual" com os dados do CPF para PF.
+func setBusinessParams(params *stripe.AccountParams, creator *accountmodel.Creator) {
+	if creator.IsCompany() {
+		params.BusinessType = stripe.String(string(stripe.AccountBusinessTypeCompany))
+		params.Company = &stripe.AccountCompanyParams{
+			Name:  stripe.String(creator.CompanyName),
+			TaxID: stripe.String(creator.CNPJ),
+			Phone: stripe.String("+55" + creator.Phone),
+		}
+		return
+	}
+
 	// Split name into first and last name (basic approach)
 	names := strings.Fields(creator.Name)
 	firstName := names[0]
@@ -85,23 +96,27 @@ func (s *stripeConnectServiceImpl) CreateConnectAccount(creator *accountmodel.Cr
 		lastName = strings.Join(names[1:], " ")
 	}
 
-	params := &stripe.AccountParams{
-		Type:         stripe.String("express"),
-		Country:      stripe.String("BR"), // Brazil
-		Email:        stripe.String(creator.Email),
-		BusinessType: stripe.String("individual"),
-		Individual: &stripe.PersonParams{
-			FirstName: stripe.String(firstName),
-			LastName:  stripe.String(lastName),
-			Email:     stripe.String(creator.Email),
-			Phone:     stripe.String("+55" + creator.Phone),
-			IDNumber:  stripe.String(creator.CPF),
-			DOB: &stripe.PersonDOBParams{
-				Day:   stripe.Int64(int64(creator.BirthDate.Day())),
-				Month: stripe.Int64(int64(creator.BirthDate.Month())),
-				Year:  stripe.Int64(int64(creator.BirthDate.Year())),
-			},
+	params.BusinessType = stripe.String(string(stripe.AccountBusinessTypeIndividual))
+	params.Individual = &stripe.PersonParams{
+		FirstName: stripe.String(firstName),
+		LastName:  stripe.String(lastName),
+		Email:     stripe.String(creator.Email),
+		Phone:     stripe.String("+55" + creator.Phone),
+		IDNumber:  stripe.String(creator.CPF),
+		DOB: &stripe.PersonDOBParams{
+			Day:   stripe.Int64(int64(creator.BirthDate.Day())),
+			Month: stripe.Int64(int64(creator.BirthDate.Month())),
+			Year:  stripe.Int64(int64(creator.BirthDate.Year())),
 		},
+	}
+}
+
+// CreateConnectAccount creates a new Stripe Connect account for the creator
+func (s *stripeConnectServiceImpl) CreateConnectAccount(creator *accountmodel.Creator) (string, error) {
+	params := &stripe.AccountParams{
+		Type:    stripe.String("express"),
+		Country: stripe.String("BR"), // Brazil
+		Email:   stripe.String(creator.Email),
 		Capabilities: &stripe.AccountCapabilitiesParams{
 			CardPayments: &stripe.AccountCapabilitiesCardPaymentsParams{
 				Requested: stripe.Bool(true),
@@ -112,6 +127,8 @@ func (s *stripeConnectServiceImpl) CreateConnectAccount(creator *accountmodel.Cr
 		},
 	}
 
+	setBusinessParams(params, creator)
+
 	acc, err := account.New(params)
 	if err != nil {
 		log.Printf("Error creating Stripe Connect account: %v", err)
@@ -1,6 +1,16 @@
 package config
 
-import "math"
+import (
+	"math"
+
+	"github.com/anglesson/simple-web-server/pkg/money"
+)
+
+// CurrencyFees são as parcelas fixas cobradas em uma moeda, na menor unidade (centavos)
+type CurrencyFees struct {
+	PlatformFixedFee int64
+	StripeFixedFee   int64
+}
 
 // BusinessConfig contém todas as configurações e constantes de regras de negócio
 type BusinessConfig struct {
@@ -12,6 +22,9 @@ type BusinessConfig struct {
 	StripeProcessingPercentage float64 // Taxa percentual do Stripe (ex.: 3,99% -> 0.0399)
 	StripeProcessingFixedFee   int64   // Taxa fixa do Stripe em centavos (R$ 0,39)
 
+	// Parcelas fixas nas moedas estrangeiras; em reais valem PlatformFixedFeeCents e StripeProcessingFixedFee
+	ForeignCurrencyFees map[string]CurrencyFees
+
 	// Compras
 	PurchaseExpirationDays int // Dias de acesso após compra
 
@@ -29,6 +42,11 @@ var Business = BusinessConfig{
 	StripeProcessingPercentage: 0.0399, // 3,99%
 	StripeProcessingFixedFee:   39,     // R$ 0,39 em centavos
 
+	ForeignCurrencyFees: map[string]CurrencyFees{
+		money.EUR: {PlatformFixedFee: 20, StripeFixedFee: 25}, // € 0,20 + € 0,25
+		money.USD: {PlatformFixedFee: 20, StripeFixedFee: 30}, // US$ 0.20 + US$ 0.30
+	},
+
 	// 30 dias de acesso após compra
 	PurchaseExpirationDays: 30,
 
@@ -36,37 +54,53 @@ var Business = BusinessConfig{
 	PlatformFeePercentageDisplay: "2,91% + R$ 1,00",
 }
 
-// GetPlatformFeeAmount calcula o valor da taxa da plataforma (Docffy) em centavos
+// PlatformFixedFeeFor retorna a parcela fixa da Docffy na moeda informada
+func (bc *BusinessConfig) PlatformFixedFeeFor(currency string) int64 {
+	if fees, ok := bc.ForeignCurrencyFees[currency]; ok {
+		return fees.PlatformFixedFee
+	}
+	return bc.PlatformFixedFeeCents
+}
+
+// StripeFixedFeeFor retorna a parcela fixa do Stripe na moeda informada
+func (bc *BusinessConfig) StripeFixedFeeFor(currency string) int64 {
+	if fees, ok := bc.ForeignCurrencyFees[currency]; ok {
+		return fees.StripeFixedFee
+	}
+	return bc.StripeProcessingFixedFee
+}
+
+// GetPlatformFeeAmount calcula o valor da taxa da plataforma (Docffy) em centavos na moeda do total
 // Fórmula: (percentual sobre o valor total) + parcela fixa, com arredondamento para o centavo mais próximo
-func (bc *BusinessConfig) GetPlatformFeeAmount(totalAmount int64) int64 {
-	percent := int64(math.Round(float64(totalAmount) * bc.PlatformFeePercentage))
-	return percent + bc.PlatformFixedFeeCents
+func (bc *BusinessConfig) GetPlatformFeeAmount(total money.Money) int64 {
+	percent := int64(math.Round(float64(total.Amount) * bc.PlatformFeePercentage))
+	return percent + bc.PlatformFixedFeeFor(total.CurrencyOrDefault())
 }
 
 // GetCreatorAmount calcula o valor que vai para o criador em centavos (sem considerar taxa do Stripe)
-func (bc *BusinessConfig) GetCreatorAmount(totalAmount int64) int64 {
-	return totalAmount - bc.GetPlatformFeeAmount(totalAmount)
+func (bc *BusinessConfig) GetCreatorAmount(total money.Money) int64 {
+	return total.Amount - bc.GetPlatformFeeAmount(total)
 }
 
-// GetStripeProcessingFee calcula a taxa de processamento do Stripe
+// GetStripeProcessingFee calcula a taxa de processamento do Stripe na moeda do total
 // Fórmula: (percentual sobre o valor total) + parcela fixa, com arredondamento para o centavo mais próximo
-func (bc *BusinessConfig) GetStripeProcessingFee(totalAmount int64) int64 {
-	percent := int64(math.Round(float64(totalAmount) * bc.StripeProcessingPercentage))
-	return percent + bc.StripeProcessingFixedFee
+func (bc *BusinessConfig) GetStripeProcessingFee(total money.Money) int64 {
+	percent := int64(math.Round(float64(total.Amount) * bc.StripeProcessingPercentage))
+	return percent + bc.StripeFixedFeeFor(total.CurrencyOrDefault())
 }
 
 // GetPlatformFeeFromNetAmount calcula a taxa da plataforma após descontar a taxa do Stripe
 // Aplica o percentual sobre o valor líquido (total - taxa Stripe) e soma a parcela fixa da Docffy, com arredondamento
-func (bc *BusinessConfig) GetPlatformFeeFromNetAmount(totalAmount int64) int64 {
-	stripesFee := bc.GetStripeProcessingFee(totalAmount)
-	netAmount := totalAmount - stripesFee
+func (bc *BusinessConfig) GetPlatformFeeFromNetAmount(total money.Money) int64 {
+	stripesFee := bc.GetStripeProcessingFee(total)
+	netAmount := total.Amount - stripesFee
 	percent := int64(math.Round(float64(netAmount) * bc.PlatformFeePercentage))
-	return percent + bc.PlatformFixedFeeCents
+	return percent + bc.PlatformFixedFeeFor(total.CurrencyOrDefault())
 }
 
 // GetCreatorAmountFromNetAmount calcula o valor do criador após descontar Stripe e plataforma
-func (bc *BusinessConfig) GetCreatorAmountFromNetAmount(totalAmount int64) int64 {
-	stripesFee := bc.GetStripeProcessingFee(totalAmount)
-	platformFee := bc.GetPlatformFeeFromNetAmount(totalAmount)
-	return totalAmount - stripesFee - platformFee
+func (bc *BusinessConfig) GetCreatorAmountFromNetAmount(total money.Money) int64 {
+	stripesFee := bc.GetStripeProcessingFee(total)
+	platformFee := bc.GetPlatformFeeFromNetAmount(total)
+	return total.Amount - stripesFee - platformFee
 }
@@ -4,6 +4,8 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -36,12 +38,61 @@ type AppConfiguration struct {
 	HubDesenvolvedorActive bool
 	HideEbookAuthorField   bool
 	HideResendLink         bool
+
+	// Recuperação de checkout abandonado
+	CheckoutRecoverySchedule        []time.Duration // Atrasos após a expiração da sessão para cada email (ex.: 1h, 24h)
+	CheckoutRecoveryDiscountPercent int64           // Cupom percentual aplicado no link de recuperação (0 desativa)
+	CheckoutRecoveryLinkValidity    time.Duration   // Por quanto tempo o link continua valendo após o último email
+
+	// Consulta de CPF na Receita Federal
+	ReceitaFederalTimeout          time.Duration // Timeout de cada requisição ao provedor
+	ReceitaFederalMaxRetries       int           // Novas tentativas após falha transitória
+	ReceitaFederalBreakerThreshold int           // Falhas seguidas que abrem o circuito
+	ReceitaFederalBreakerCooldown  time.Duration // Tempo com o circuito aberto antes de testar o provedor novamente
+	ReceitaFederalCacheTTL         time.Duration // Validade do resultado de uma consulta
+
+	// Pontuação de risco do checkout
+	FraudReviewScore int // Pontuação a partir da qual a venda fica retida para revisão do criador
+	FraudBlockScore  int // Pontuação a partir da qual o checkout é bloqueado
+
+	// Programa de afiliados
+	AffiliateCookieWindow time.Duration // Por quanto tempo o clique no link do afiliado vale para atribuir a venda
+	AffiliateHoldPeriod   time.Duration // Carência após a confirmação antes de transferir a comissão (0 transfere na hora)
+
+	// Repasses a coautores e afiliados recusados pelo Stripe
+	SplitTransferMaxAttempts int // Tentativas antes de desistir do repasse (0 não tenta de novo)
+
+	// Conciliação diária com o Stripe
+	ReconciliationLookback   time.Duration // Janela de pagamentos conferida em cada execução
+	ReconciliationAlertEmail string        // Caixa dos operadores que recebe as divergências sem correção automática
+
+	// Emails em massa dos criadores para os clientes
+	BroadcastMailsPerMinute int // Limite de envios por minuto para não estourar a cota do provedor de email
+
+	// Solicitações de titulares (LGPD)
+	PrivacyOperatorEmails []string // Usuários da plataforma que atendem as solicitações e executam a anonimização
+	PrivacyResponseDays   int      // Prazo para atender a solicitação, contado do registro
+
+	// Criptografia de dados pessoais (CPF, telefone e data de nascimento)
+	PIIEncryptionKeys string // "id:chave_base64,..." com chaves de 32 bytes; a primeira é a atual
+	PIIBlindIndexKey  string // Segredo do HMAC usado nas buscas exatas por CPF e telefone
 }
 
 func (ac *AppConfiguration) IsProduction() bool {
 	return ac.AppMode == "production"
 }
 
+// IsPrivacyOperator indica se o email pertence a um operador das solicitações de titulares
+func (ac *AppConfiguration) IsPrivacyOperator(email string) bool {
+	email = strings.ToLower(strings.TrimSpace(email))
+	for _, operator := range ac.PrivacyOperatorEmails {
+		if email != "" && operator == email {
+			return true
+		}
+	}
+	return false
+}
+
 var AppConfig AppConfiguration
 
 func LoadConfigs() {
@@ -79,6 +130,32 @@ func LoadConfigs() {
 	AppConfig.HideEbookAuthorField = GetEnv("HIDE_EBOOK_AUTHOR_FIELD", "false") == "true"
 	AppConfig.HideResendLink = GetEnv("HIDE_RESEND_LINK", "false") == "true"
 
+	AppConfig.CheckoutRecoverySchedule = parseDurationList(GetEnv("CHECKOUT_RECOVERY_SCHEDULE", "1h,24h"))
+	AppConfig.CheckoutRecoveryLinkValidity = parseDuration("CHECKOUT_RECOVERY_LINK_VALIDITY", 72*time.Hour)
+	if discount, err := strconv.ParseInt(GetEnv("CHECKOUT_RECOVERY_DISCOUNT_PERCENT", "0"), 10, 64); err == nil && discount >= 0 && discount < 100 {
+		AppConfig.CheckoutRecoveryDiscountPercent = discount
+	} else {
+		log.Printf("Aviso: CHECKOUT_RECOVERY_DISCOUNT_PERCENT inválido, cupom de recuperação desativado")
+	}
+
+	AppConfig.ReceitaFederalTimeout = parseDuration("RECEITA_FEDERAL_TIMEOUT", 8*time.Second)
+	AppConfig.ReceitaFederalMaxRetries = parseNonNegativeInt("RECEITA_FEDERAL_MAX_RETRIES", 2)
+	AppConfig.ReceitaFederalBreakerThreshold = parseNonNegativeInt("RECEITA_FEDERAL_BREAKER_THRESHOLD", 5)
+	AppConfig.ReceitaFederalBreakerCooldown = parseDuration("RECEITA_FEDERAL_BREAKER_COOLDOWN", time.Minute)
+	AppConfig.ReceitaFederalCacheTTL = parseDuration("RECEITA_FEDERAL_CACHE_TTL", 30*24*time.Hour)
+	AppConfig.FraudReviewScore = parseNonNegativeInt("FRAUD_REVIEW_SCORE", 40)
+	AppConfig.FraudBlockScore = parseNonNegativeInt("FRAUD_BLOCK_SCORE", 80)
+	AppConfig.AffiliateCookieWindow = time.Duration(parseNonNegativeInt("AFFILIATE_COOKIE_DAYS", 30)) * 24 * time.Hour
+	AppConfig.AffiliateHoldPeriod = time.Duration(parseNonNegativeInt("AFFILIATE_HOLD_DAYS", 7)) * 24 * time.Hour
+	AppConfig.SplitTransferMaxAttempts = parseNonNegativeInt("SPLIT_TRANSFER_MAX_ATTEMPTS", 40)
+	AppConfig.ReconciliationLookback = time.Duration(parseNonNegativeInt("RECONCILIATION_LOOKBACK_DAYS", 3)) * 24 * time.Hour
+	AppConfig.ReconciliationAlertEmail = GetEnv("RECONCILIATION_ALERT_EMAIL", AppConfig.MailContactAddress)
+	AppConfig.BroadcastMailsPerMinute = parseNonNegativeInt("BROADCAST_MAILS_PER_MINUTE", 60)
+	AppConfig.PrivacyOperatorEmails = parseEmailList(GetEnv("PRIVACY_OPERATOR_EMAILS", ""))
+	AppConfig.PrivacyResponseDays = parseNonNegativeInt("PRIVACY_RESPONSE_DAYS", 15)
+	AppConfig.PIIEncryptionKeys = GetEnv("PII_ENCRYPTION_KEYS", "")
+	AppConfig.PIIBlindIndexKey = GetEnv("PII_BLIND_INDEX_KEY", "")
+
 	hubDevActiveStr := GetEnv("HUB_DEVSENVOLVEDOR_ACTIVE", "true")
 	if active, err := strconv.ParseBool(hubDevActiveStr); err == nil {
 		AppConfig.HubDesenvolvedorActive = active
@@ -100,6 +177,64 @@ func LoadConfigs() {
 	}
 }
 
+// parseDurationList converte uma lista separada por vírgulas (ex.: "1h,24h") em durações, ignorando itens inválidos
+func parseDurationList(value string) []time.Duration {
+	var durations []time.Duration
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		d, err := time.ParseDuration(item)
+		if err != nil || d < 0 {
+			log.Printf("Aviso: duração inválida ignorada: %q", item)
+			continue
+		}
+		durations = append(durations, d)
+	}
+	return durations
+}
+
+// parseEmailList converte uma lista de emails separada por vírgulas, em minúsculas e sem itens vazios
+func parseEmailList(value string) []string {
+	var emails []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item != "" {
+			emails = append(emails, item)
+		}
+	}
+	return emails
+}
+
+// parseDuration lê uma duração do ambiente, mantendo o padrão quando o valor é inválido
+func parseDuration(key string, fallback time.Duration) time.Duration {
+	value := GetEnv(key, "")
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Aviso: %s inválido, mantendo padrão %s", key, fallback)
+		return fallback
+	}
+	return d
+}
+
+// parseNonNegativeInt lê um inteiro não negativo do ambiente, mantendo o padrão quando o valor é inválido
+func parseNonNegativeInt(key string, fallback int) int {
+	value := GetEnv(key, "")
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Printf("Aviso: %s inválido, mantendo padrão %d", key, fallback)
+		return fallback
+	}
+	return n
+}
+
 func GetEnv(key, fallback string) string {
 	env, exists := os.LookupEnv(key)
 	if exists {
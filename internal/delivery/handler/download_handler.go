@@ -50,6 +50,21 @@ func (h *DownloadHandler) PurchaseDownloadHandler(w http.ResponseWriter, r *http
 		return
 	}
 
+	if purchase.IsAwaitingRelease() {
+		h.showPreOrderPage(w, r, purchase)
+		return
+	}
+
+	if purchase.IsHeldForReview() {
+		h.showReviewPendingPage(w, r, purchase)
+		return
+	}
+
+	if purchase.IsAccessRevoked() {
+		h.showAccessUnavailablePage(w, r, purchase)
+		return
+	}
+
 	outputPath, err := h.downloadService.GetEbookFile(hashID, fileIDStr)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -68,6 +83,22 @@ func (h *DownloadHandler) PurchaseDownloadHandler(w http.ResponseWriter, r *http
 	http.ServeFile(w, r, outputPath)
 }
 
+// PurchaseReceiptHandler entrega o recibo em PDF da compra ao comprador
+func (h *DownloadHandler) PurchaseReceiptHandler(w http.ResponseWriter, r *http.Request) {
+	hashID := chi.URLParam(r, "hash_id")
+
+	receipt, err := h.downloadService.GetReceipt(hashID)
+	if err != nil {
+		slog.Error("Erro ao gerar recibo", "hashID", hashID, "error", err)
+		http.Error(w, "Recibo indisponível para esta compra", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", "attachment; filename="+strconv.Quote(receipt.FileName))
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Write(receipt.Content)
+}
+
 func (h *DownloadHandler) showEbookFiles(w http.ResponseWriter, r *http.Request, hashID string) {
 	log.Printf("showEbookFiles chamado para purchase: %s", hashID)
 
@@ -86,6 +117,24 @@ func (h *DownloadHandler) showEbookFiles(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
+	if purchase.IsAwaitingRelease() {
+		log.Printf("Pré-venda aguardando lançamento para purchase: %s", hashID)
+		h.showPreOrderPage(w, r, purchase)
+		return
+	}
+
+	if purchase.IsHeldForReview() {
+		log.Printf("Compra retida para revisão do criador: %s", hashID)
+		h.showReviewPendingPage(w, r, purchase)
+		return
+	}
+
+	if purchase.IsAccessRevoked() {
+		log.Printf("Acesso indisponível (%s) para purchase: %s", purchase.Status, hashID)
+		h.showAccessUnavailablePage(w, r, purchase)
+		return
+	}
+
 	if purchase.IsExpired() {
 		log.Printf("Download expirado para purchase: %s", hashID)
 		h.showExpiredDownloadPage(w, r, purchase)
@@ -127,6 +176,39 @@ func (h *DownloadHandler) showPaymentPendingPage(w http.ResponseWriter, r *http.
 	h.templateRenderer.ViewWithoutLayout(w, r, "ebook/payment-pending", data)
 }
 
+func (h *DownloadHandler) showPreOrderPage(w http.ResponseWriter, r *http.Request, purchase *salesmodel.Purchase) {
+	log.Printf("Mostrando página de pré-venda para purchase ID: %d", purchase.ID)
+
+	data := map[string]interface{}{
+		"Purchase": purchase,
+		"Title":    "Pré-venda Confirmada",
+	}
+
+	h.templateRenderer.ViewWithoutLayout(w, r, "ebook/download-preorder", data)
+}
+
+func (h *DownloadHandler) showReviewPendingPage(w http.ResponseWriter, r *http.Request, purchase *salesmodel.Purchase) {
+	log.Printf("Mostrando página de compra em análise para purchase ID: %d", purchase.ID)
+
+	data := map[string]interface{}{
+		"Purchase": purchase,
+		"Title":    "Compra em Análise",
+	}
+
+	h.templateRenderer.ViewWithoutLayout(w, r, "ebook/download-review", data)
+}
+
+func (h *DownloadHandler) showAccessUnavailablePage(w http.ResponseWriter, r *http.Request, purchase *salesmodel.Purchase) {
+	log.Printf("Mostrando página de acesso indisponível para purchase ID: %d", purchase.ID)
+
+	data := map[string]interface{}{
+		"Purchase": purchase,
+		"Title":    "Acesso Indisponível",
+	}
+
+	h.templateRenderer.ViewWithoutLayout(w, r, "ebook/download-unavailable", data)
+}
+
 func (h *DownloadHandler) showLimitExceededPage(w http.ResponseWriter, r *http.Request, purchase *salesmodel.Purchase) {
 	log.Printf("Mostrando página de limite excedido para purchase ID: %d", purchase.ID)
 
@@ -9,6 +9,7 @@ import (
 	"github.com/anglesson/simple-web-server/internal/mocks"
 	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
 	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -41,6 +42,14 @@ func (m *MockDownloadService) GetEbookFiles(purchaseID int) ([]*librarymodel.Fil
 	return args.Get(0).([]*librarymodel.File), args.Error(1)
 }
 
+func (m *MockDownloadService) GetReceipt(hashID string) (*salesvc.Receipt, error) {
+	args := m.Called(hashID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*salesvc.Receipt), args.Error(1)
+}
+
 func TestShowLimitExceededPage(t *testing.T) {
 	purchase := &salesmodel.Purchase{
 		Model: gorm.Model{
@@ -321,3 +330,88 @@ func TestPurchaseDownloadHandler_PaymentPending_BlocksFileDownload(t *testing.T)
 	// Verify GetEbookFile was never called (blocked before serving file)
 	mockDownloadService.AssertNotCalled(t, "GetEbookFile", mock.Anything, mock.Anything)
 }
+
+// Pré-venda: compra confirmada aguardando lançamento → renderiza download-preorder
+func TestShowEbookFiles_AwaitingRelease_RendersPreOrderPage(t *testing.T) {
+	releaseAt := time.Now().Add(48 * time.Hour)
+	purchase := &salesmodel.Purchase{
+		Model:           gorm.Model{ID: 1},
+		EbookID:         1,
+		ClientID:        1,
+		DownloadLimit:   -1,
+		PaymentStatus:   salesmodel.PaymentStatusConfirmed,
+		AwaitingRelease: true,
+		Ebook:           librarymodel.Ebook{Title: "Test Ebook", PreOrder: true, ReleaseAt: &releaseAt},
+		Client:          salesmodel.Client{Name: "Test Client", Email: "client@test.com"},
+	}
+
+	req := httptest.NewRequest("GET", "/purchase/download/abc123", nil)
+	w := httptest.NewRecorder()
+
+	mockDownloadService := new(MockDownloadService)
+	mockDownloadService.On("FindPurchaseByHash", "abc123").Return(purchase, nil)
+
+	mockTemplateRenderer := new(mocks.MockTemplateRenderer)
+	mockTemplateRenderer.On("ViewWithoutLayout", w, req, "ebook/download-preorder", mock.AnythingOfType("map[string]interface {}")).Return()
+
+	handler := NewDownloadHandler(mockDownloadService, mockTemplateRenderer)
+	handler.showEbookFiles(w, req, "abc123")
+
+	mockDownloadService.AssertExpectations(t)
+	mockDownloadService.AssertNotCalled(t, "GetEbookFiles", mock.Anything)
+	mockTemplateRenderer.AssertExpectations(t)
+}
+
+func TestShowEbookFiles_BlockedPurchase_RendersUnavailablePage(t *testing.T) {
+	purchase := &salesmodel.Purchase{
+		Model:         gorm.Model{ID: 1},
+		EbookID:       1,
+		ClientID:      1,
+		DownloadLimit: -1,
+		PaymentStatus: salesmodel.PaymentStatusConfirmed,
+		Status:        salesmodel.PurchaseStatusBlocked,
+		Ebook:         librarymodel.Ebook{Title: "Test Ebook"},
+		Client:        salesmodel.Client{Name: "Test Client", Email: "client@test.com"},
+	}
+
+	req := httptest.NewRequest("GET", "/purchase/download/abc123", nil)
+	w := httptest.NewRecorder()
+
+	mockDownloadService := new(MockDownloadService)
+	mockDownloadService.On("FindPurchaseByHash", "abc123").Return(purchase, nil)
+
+	mockTemplateRenderer := new(mocks.MockTemplateRenderer)
+	mockTemplateRenderer.On("ViewWithoutLayout", w, req, "ebook/download-unavailable", mock.AnythingOfType("map[string]interface {}")).Return()
+
+	handler := NewDownloadHandler(mockDownloadService, mockTemplateRenderer)
+	handler.showEbookFiles(w, req, "abc123")
+
+	mockDownloadService.AssertNotCalled(t, "GetEbookFiles", mock.Anything)
+	mockTemplateRenderer.AssertExpectations(t)
+}
+
+func TestPurchaseReceiptHandler(t *testing.T) {
+	mockDownloadService := new(MockDownloadService)
+	mockDownloadService.On("GetReceipt", "hash-pago").Return(&salesvc.Receipt{FileName: "recibo-txn_1.pdf", Content: []byte("%PDF-1.7")}, nil).Once()
+	mockDownloadService.On("GetReceipt", "hash-pendente").Return(nil, salesvc.ErrReceiptUnavailable).Once()
+	handler := NewDownloadHandler(mockDownloadService, new(mocks.MockTemplateRenderer))
+
+	request := func(hashID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/purchase/download/"+hashID+"/receipt", nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("hash_id", hashID)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		w := httptest.NewRecorder()
+		handler.PurchaseReceiptHandler(w, req)
+		return w
+	}
+
+	w := request("hash-pago")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "recibo-txn_1.pdf")
+	assert.Equal(t, "%PDF-1.7", w.Body.String())
+
+	assert.Equal(t, 404, request("hash-pendente").Code)
+	mockDownloadService.AssertExpectations(t)
+}
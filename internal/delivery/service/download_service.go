@@ -17,17 +17,20 @@ type DownloadService interface {
 	FindPurchaseByHash(hashID string) (*salesmodel.Purchase, error)
 	GetEbookFile(hashID string, filePublicID string) (string, error)
 	GetEbookFiles(purchaseID int) ([]*librarymodel.File, error)
+	GetReceipt(hashID string) (*salesvc.Receipt, error)
 }
 
 type downloadServiceImpl struct {
-	purchaseRepo *salesrepo.PurchaseRepository
-	downloadRepo deliveryrepo.DownloadRepository
+	purchaseRepo   *salesrepo.PurchaseRepository
+	downloadRepo   deliveryrepo.DownloadRepository
+	receiptService salesvc.ReceiptService
 }
 
-func NewDownloadService(purchaseRepo *salesrepo.PurchaseRepository, downloadRepo deliveryrepo.DownloadRepository) DownloadService {
+func NewDownloadService(purchaseRepo *salesrepo.PurchaseRepository, downloadRepo deliveryrepo.DownloadRepository, receiptService salesvc.ReceiptService) DownloadService {
 	return &downloadServiceImpl{
-		purchaseRepo: purchaseRepo,
-		downloadRepo: downloadRepo,
+		purchaseRepo:   purchaseRepo,
+		downloadRepo:   downloadRepo,
+		receiptService: receiptService,
 	}
 }
 
@@ -45,6 +48,18 @@ func (s *downloadServiceImpl) GetEbookFile(hashID string, filePublicID string) (
 		return "", errors.New("Compra não localizada!")
 	}
 
+	if purchase.IsAwaitingRelease() {
+		return "", errors.New("não é possível realizar o download, o ebook ainda não foi lançado")
+	}
+
+	if purchase.IsHeldForReview() {
+		return "", errors.New("não é possível realizar o download, a compra está em análise")
+	}
+
+	if purchase.IsAccessRevoked() {
+		return "", errors.New("não é possível realizar o download, o acesso a esta compra está indisponível")
+	}
+
 	if !purchase.AvailableDownloads() {
 		return "", errors.New("não é possível realizar o download, limite de downloads atingido")
 	}
@@ -65,7 +80,7 @@ func (s *downloadServiceImpl) GetEbookFile(hashID string, filePublicID string) (
 		return "", errors.New("arquivo não encontrado neste ebook")
 	}
 
-	watermarkText := fmt.Sprintf("%s - %s - %s", purchase.Client.Name, purchase.Client.CPF, purchase.Client.Email)
+	watermarkText := fmt.Sprintf("%s - %s - %s", purchase.Client.Name, purchase.Client.IdentityDocument(), purchase.Client.Email)
 	outputFilePath, err := salesvc.ApplyWatermark(targetFile.S3Key, watermarkText)
 	if err != nil {
 		return "", err
@@ -84,6 +99,10 @@ func (s *downloadServiceImpl) GetEbookFiles(purchaseID int) ([]*librarymodel.Fil
 		return nil, errors.New(err.Error())
 	}
 
+	if purchase.IsAccessRevoked() {
+		return nil, errors.New("não é possível realizar o download, o acesso a esta compra está indisponível")
+	}
+
 	if !purchase.AvailableDownloads() {
 		return nil, errors.New("não é possível realizar o download, limite de downloads atingido")
 	}
@@ -98,3 +117,21 @@ func (s *downloadServiceImpl) GetEbookFiles(purchaseID int) ([]*librarymodel.Fil
 
 	return purchase.Ebook.Files, nil
 }
+
+// GetReceipt gera o recibo da compra paga; baixar o recibo não conta como download do ebook
+func (s *downloadServiceImpl) GetReceipt(hashID string) (*salesvc.Receipt, error) {
+	purchase, err := s.purchaseRepo.FindEbookByPurchaseHash(hashID)
+	if err != nil {
+		return nil, errors.New(err.Error())
+	}
+
+	if purchase == nil {
+		return nil, errors.New("Compra não localizada!")
+	}
+
+	if !purchase.IsPaymentConfirmed() {
+		return nil, salesvc.ErrReceiptUnavailable
+	}
+
+	return s.receiptService.GenerateForPurchase(purchase.ID)
+}
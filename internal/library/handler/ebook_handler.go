@@ -25,6 +25,7 @@ import (
 	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
 	salesrepogorm "github.com/anglesson/simple-web-server/internal/sales/repository/gorm"
 	"github.com/anglesson/simple-web-server/pkg/database"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/anglesson/simple-web-server/pkg/storage"
 	"github.com/anglesson/simple-web-server/pkg/template"
 	"github.com/anglesson/simple-web-server/pkg/utils"
@@ -215,31 +216,39 @@ func (h *EbookHandler) CreateSubmit(w http.ResponseWriter, r *http.Request) {
 		errors["upload"] = strings.Join(uploadErrors, "; ")
 	}
 
+	preOrder, releaseAt, err := parsePreOrderFields(r)
+	if err != nil {
+		errors["release_at"] = err.Error()
+	} else if preOrder && !releaseAt.After(time.Now()) {
+		errors["release_at"] = "A data de lançamento da pré-venda deve ser futura"
+	}
+
+	stockLimit, salesStartAt, salesEndAt, err := parseLimitedOfferFields(r)
+	if err != nil {
+		errors["stock_limit"] = err.Error()
+	}
+
+	// Em pré-venda os arquivos podem ser adicionados até a data de lançamento
 	selectedFiles := r.Form["new_files"]
-	if len(selectedFiles) == 0 && len(uploadedFiles) == 0 {
+	if len(selectedFiles) == 0 && len(uploadedFiles) == 0 && !preOrder {
 		errors["files"] = "Selecione pelo menos um arquivo da biblioteca ou faça upload de novos arquivos"
 	}
 
-	var value float64
-	valueStr := r.FormValue("value")
-	if valueStr != "" {
-		var err error
-		value, err = utils.BRLToFloat(valueStr)
-		if err != nil {
-			log.Println("Falha na conversão do valor do e-book")
-			errors["value"] = "Valor inválido. Use apenas números e vírgula (ex: 29,90)"
-		}
+	value, err := money.ParseBRL(r.FormValue("value"))
+	if err != nil || !value.IsPositive() {
+		log.Println("Falha na conversão do valor do e-book")
+		errors["value"] = "Valor inválido. Use apenas números e vírgula (ex: 29,90)"
 	}
 
-	var promotionalValue float64
-	promotionalValueStr := r.FormValue("value")
-	if valueStr != "" {
-		var err error
-		value, err = utils.BRLToFloat(promotionalValueStr)
-		if err != nil {
-			log.Println("Falha na conversão do valor promocional do e-book")
-			errors["promotional_value"] = "Valor inválido. Use apenas números e vírgula (ex: 29,90)"
-		}
+	promotionalValue, err := money.ParseBRL(r.FormValue("promotional_value"))
+	if err != nil {
+		log.Println("Falha na conversão do valor promocional do e-book")
+		errors["promotional_value"] = "Valor inválido. Use apenas números e vírgula (ex: 29,90)"
+	}
+
+	prices, err := parseForeignPrices(r)
+	if err != nil {
+		errors["prices"] = err.Error()
 	}
 
 	form := librarymodel.EbookRequest{
@@ -251,6 +260,13 @@ func (h *EbookHandler) CreateSubmit(w http.ResponseWriter, r *http.Request) {
 		Status:           true,
 		Statistics:       false,
 		AuthorName:       r.FormValue("author_name"),
+		PreOrder:         preOrder,
+		ReleaseAt:        releaseAt,
+		StockLimit:       stockLimit,
+		SalesStartAt:     salesStartAt,
+		SalesEndAt:       salesEndAt,
+		WaitlistEnabled:  r.FormValue("waitlist_enabled") != "",
+		Prices:           prices,
 	}
 
 	errForm := utils.ValidateForm(form)
@@ -277,6 +293,12 @@ func (h *EbookHandler) CreateSubmit(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ebook := librarymodel.NewEbook(form.Title, form.Description, form.SalesPage, form.Value, form.PromotionalValue, creator.ID, form.Statistics)
+	ebook.PreOrder = form.PreOrder
+	ebook.ReleaseAt = form.ReleaseAt
+	ebook.StockLimit = form.StockLimit
+	ebook.SalesStartAt = form.SalesStartAt
+	ebook.SalesEndAt = form.SalesEndAt
+	ebook.WaitlistEnabled = form.WaitlistEnabled
 
 	authorName := form.AuthorName
 	if authorName == "" {
@@ -314,10 +336,90 @@ func (h *EbookHandler) CreateSubmit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(form.Prices) > 0 {
+		if err := h.ebookService.SetPrices(ebook.ID, form.Prices); err != nil {
+			log.Printf("Falha ao salvar preços em outras moedas: %s", err)
+			h.FlashMessage(w, r, "E-book criado, mas não foi possível salvar os preços em outras moedas", "error")
+			http.Redirect(w, r, "/ebook", http.StatusSeeOther)
+			return
+		}
+	}
+
 	h.FlashMessage(w, r, "E-book criado com sucesso!", "success")
 	http.Redirect(w, r, "/ebook", http.StatusSeeOther)
 }
 
+// parseForeignPrices lê os preços opcionais em moedas estrangeiras (campos price_eur, price_usd...)
+func parseForeignPrices(r *http.Request) ([]librarymodel.EbookPrice, error) {
+	var prices []librarymodel.EbookPrice
+	for _, currency := range money.SupportedCurrencies[1:] {
+		raw := strings.TrimSpace(r.FormValue("price_" + strings.ToLower(currency)))
+		if raw == "" {
+			continue
+		}
+		parsed, err := money.ParseBRL(raw)
+		if err != nil || !parsed.IsPositive() {
+			return nil, fmt.Errorf("Preço em %s inválido. Use apenas números e vírgula (ex: 9,90)", currency)
+		}
+		prices = append(prices, librarymodel.NewEbookPrice(money.New(parsed.Amount, currency)))
+	}
+	return prices, nil
+}
+
+// parsePreOrderFields lê o modo pré-venda e a data de lançamento (input datetime-local) do formulário
+func parsePreOrderFields(r *http.Request) (bool, *time.Time, error) {
+	preOrder := r.FormValue("pre_order") != ""
+	releaseAtStr := strings.TrimSpace(r.FormValue("release_at"))
+
+	if releaseAtStr == "" {
+		if preOrder {
+			return false, nil, fmt.Errorf("Informe a data de lançamento da pré-venda")
+		}
+		return false, nil, nil
+	}
+
+	releaseAt, err := time.ParseInLocation("2006-01-02T15:04", releaseAtStr, time.Local)
+	if err != nil {
+		return false, nil, fmt.Errorf("Data de lançamento inválida")
+	}
+
+	return preOrder, &releaseAt, nil
+}
+
+// parseLimitedOfferFields lê a quantidade de cópias e a janela de vendas da oferta limitada
+func parseLimitedOfferFields(r *http.Request) (int, *time.Time, *time.Time, error) {
+	stockLimit := 0
+	if stockLimitStr := strings.TrimSpace(r.FormValue("stock_limit")); stockLimitStr != "" {
+		var err error
+		stockLimit, err = strconv.Atoi(stockLimitStr)
+		if err != nil || stockLimit < 0 {
+			return 0, nil, nil, fmt.Errorf("Quantidade de cópias inválida")
+		}
+	}
+
+	var salesStartAt, salesEndAt *time.Time
+	if value := strings.TrimSpace(r.FormValue("sales_start_at")); value != "" {
+		startAt, err := time.ParseInLocation("2006-01-02T15:04", value, time.Local)
+		if err != nil {
+			return 0, nil, nil, fmt.Errorf("Data de início das vendas inválida")
+		}
+		salesStartAt = &startAt
+	}
+	if value := strings.TrimSpace(r.FormValue("sales_end_at")); value != "" {
+		endAt, err := time.ParseInLocation("2006-01-02T15:04", value, time.Local)
+		if err != nil {
+			return 0, nil, nil, fmt.Errorf("Data de fim das vendas inválida")
+		}
+		salesEndAt = &endAt
+	}
+
+	if salesStartAt != nil && salesEndAt != nil && !salesEndAt.After(*salesStartAt) {
+		return 0, nil, nil, fmt.Errorf("O fim das vendas deve ser posterior ao início")
+	}
+
+	return stockLimit, salesStartAt, salesEndAt, nil
+}
+
 func (h *EbookHandler) SetFormToSession(w http.ResponseWriter, r *http.Request, form interface{}) {
 	formData, _ := json.Marshal(form)
 	err := h.sessionManager.Set(r, w, "form", formData)
@@ -409,20 +511,27 @@ func (h *EbookHandler) UpdateSubmit(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	value, err := utils.BRLToFloat(r.FormValue("value"))
-	if err != nil {
+	value, err := money.ParseBRL(r.FormValue("value"))
+	if err != nil || !value.IsPositive() {
 		h.FlashMessage(w, r, "Valor inválido", "form-error")
 		http.Redirect(w, r, r.Referer(), http.StatusSeeOther)
 		return
 	}
 
-	promotionalValue, err := utils.BRLToFloat(r.FormValue("promotional_value"))
+	promotionalValue, err := money.ParseBRL(r.FormValue("promotional_value"))
 	if err != nil {
 		h.FlashMessage(w, r, "Valor promocional inválido", "form-error")
 		http.Redirect(w, r, r.Referer(), http.StatusSeeOther)
 		return
 	}
 
+	prices, err := parseForeignPrices(r)
+	if err != nil {
+		h.FlashMessage(w, r, err.Error(), "form-error")
+		http.Redirect(w, r, r.Referer(), http.StatusSeeOther)
+		return
+	}
+
 	status := false
 	if r.FormValue("status") != "" {
 		status = true
@@ -433,6 +542,20 @@ func (h *EbookHandler) UpdateSubmit(w http.ResponseWriter, r *http.Request) {
 		statistics = true
 	}
 
+	preOrder, releaseAt, err := parsePreOrderFields(r)
+	if err != nil {
+		h.FlashMessage(w, r, err.Error(), "form-error")
+		http.Redirect(w, r, r.Referer(), http.StatusSeeOther)
+		return
+	}
+
+	stockLimit, salesStartAt, salesEndAt, err := parseLimitedOfferFields(r)
+	if err != nil {
+		h.FlashMessage(w, r, err.Error(), "form-error")
+		http.Redirect(w, r, r.Referer(), http.StatusSeeOther)
+		return
+	}
+
 	form := librarymodel.EbookRequest{
 		Title:            r.FormValue("title"),
 		Description:      r.FormValue("description"),
@@ -442,6 +565,13 @@ func (h *EbookHandler) UpdateSubmit(w http.ResponseWriter, r *http.Request) {
 		Status:           status,
 		Statistics:       statistics,
 		AuthorName:       r.FormValue("author_name"),
+		PreOrder:         preOrder,
+		ReleaseAt:        releaseAt,
+		StockLimit:       stockLimit,
+		SalesStartAt:     salesStartAt,
+		SalesEndAt:       salesEndAt,
+		WaitlistEnabled:  r.FormValue("waitlist_enabled") != "",
+		Prices:           prices,
 	}
 
 	errForm := utils.ValidateForm(form)
@@ -503,6 +633,18 @@ func (h *EbookHandler) UpdateSubmit(w http.ResponseWriter, r *http.Request) {
 	ebook.PromotionalValue = form.PromotionalValue
 	ebook.Status = form.Status
 	ebook.Statistics = form.Statistics
+	ebook.PreOrder = form.PreOrder
+	ebook.ReleaseAt = form.ReleaseAt
+	ebook.SalesStartAt = form.SalesStartAt
+	ebook.SalesEndAt = form.SalesEndAt
+	ebook.WaitlistEnabled = form.WaitlistEnabled
+
+	if form.StockLimit > 0 && form.StockLimit < ebook.StockUsed {
+		h.FlashMessage(w, r, fmt.Sprintf("A quantidade de cópias não pode ser menor que as %d já vendidas ou reservadas", ebook.StockUsed), "form-error")
+		http.Redirect(w, r, r.Referer(), http.StatusSeeOther)
+		return
+	}
+	ebook.StockLimit = form.StockLimit
 
 	authorName := form.AuthorName
 	if authorName == "" {
@@ -518,6 +660,13 @@ func (h *EbookHandler) UpdateSubmit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := h.ebookService.SetPrices(ebook.ID, form.Prices); err != nil {
+		log.Printf("Falha ao atualizar preços em outras moedas: %s", err)
+		h.FlashMessage(w, r, "Erro ao atualizar preços em outras moedas", "form-error")
+		http.Redirect(w, r, r.Referer(), http.StatusSeeOther)
+		return
+	}
+
 	// Adicionar novos arquivos via Association (sem tocar nos existentes)
 	var filesToAppend []*librarymodel.File
 	filesToAppend = append(filesToAppend, uploadedFiles...)
@@ -15,6 +15,7 @@ import (
 	libraryhandler "github.com/anglesson/simple-web-server/internal/library/handler"
 	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
 	"github.com/anglesson/simple-web-server/internal/mocks"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -432,6 +433,7 @@ func (suite *EbookHandlerTestSuite) TestUpdateSubmit_AuthorName_UsesFormValueWhe
 	suite.mockEbookService.On("Update", mock.MatchedBy(func(e *librarymodel.Ebook) bool {
 		return e.AuthorName == "Escritor Fantástico"
 	})).Return(nil)
+	suite.mockEbookService.On("SetPrices", ebook.ID, mock.Anything).Return(nil)
 	suite.mockSessionManager.On("AddFlash", mock.Anything, mock.Anything, "Dados do e-book foram atualizados!", "success").Return(nil)
 
 	suite.sut.UpdateSubmit(w, req)
@@ -462,6 +464,7 @@ func (suite *EbookHandlerTestSuite) TestUpdateSubmit_AuthorName_UsesSocialNameWh
 	suite.mockEbookService.On("Update", mock.MatchedBy(func(e *librarymodel.Ebook) bool {
 		return e.AuthorName == "Mari F."
 	})).Return(nil)
+	suite.mockEbookService.On("SetPrices", ebook.ID, mock.Anything).Return(nil)
 	suite.mockSessionManager.On("AddFlash", mock.Anything, mock.Anything, "Dados do e-book foram atualizados!", "success").Return(nil)
 
 	suite.sut.UpdateSubmit(w, req)
@@ -491,6 +494,7 @@ func (suite *EbookHandlerTestSuite) TestUpdateSubmit_AuthorName_UsesFirstLastNam
 	suite.mockEbookService.On("Update", mock.MatchedBy(func(e *librarymodel.Ebook) bool {
 		return e.AuthorName == "Carlos Pereira"
 	})).Return(nil)
+	suite.mockEbookService.On("SetPrices", ebook.ID, mock.Anything).Return(nil)
 	suite.mockSessionManager.On("AddFlash", mock.Anything, mock.Anything, "Dados do e-book foram atualizados!", "success").Return(nil)
 
 	suite.sut.UpdateSubmit(w, req)
@@ -502,3 +506,34 @@ func (suite *EbookHandlerTestSuite) TestUpdateSubmit_AuthorName_UsesFirstLastNam
 func TestEbookHandlerTestSuite(t *testing.T) {
 	suite.Run(t, new(EbookHandlerTestSuite))
 }
+
+func (suite *EbookHandlerTestSuite) TestUpdateSubmit_SavesForeignCurrencyPrices() {
+	const publicID = "ebk_test004"
+
+	formData := suite.baseUpdateFormData()
+	formData.Set("price_eur", "9,90")
+	formData.Set("price_usd", "")
+
+	req := suite.newUpdateSubmitRequest(publicID, formData)
+	w := httptest.NewRecorder()
+
+	creator := &accountmodel.Creator{SocialName: "Nome Social"}
+	creator.ID = 1
+	suite.mockCreatorService.On("FindCreatorByUserID", uint(1)).Return(creator, nil)
+
+	ebook := &librarymodel.Ebook{}
+	ebook.ID = 13
+	ebook.PublicID = publicID
+	ebook.CreatorID = 1
+	suite.mockEbookService.On("FindByPublicID", publicID).Return(ebook, nil)
+	suite.mockEbookService.On("Update", mock.Anything).Return(nil)
+	suite.mockEbookService.On("SetPrices", uint(13), []librarymodel.EbookPrice{
+		{Currency: money.EUR, Amount: 990},
+	}).Return(nil)
+	suite.mockSessionManager.On("AddFlash", mock.Anything, mock.Anything, "Dados do e-book foram atualizados!", "success").Return(nil)
+
+	suite.sut.UpdateSubmit(w, req)
+
+	assert.Equal(suite.T(), http.StatusSeeOther, w.Code)
+	suite.mockEbookService.AssertExpectations(suite.T())
+}
@@ -1,14 +1,17 @@
 package handler
 
 import (
+	"encoding/json"
 	"log"
 	"net/http"
+	"time"
 
 	accountsvc "github.com/anglesson/simple-web-server/internal/account/service"
 	authmw "github.com/anglesson/simple-web-server/internal/auth/handler/middleware"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
 	librarysvc "github.com/anglesson/simple-web-server/internal/library/service"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/anglesson/simple-web-server/pkg/template"
-	"github.com/anglesson/simple-web-server/pkg/utils"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -43,7 +46,8 @@ func (h *SalesPageHandler) SalesPageView(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if !ebook.Status {
+	// Ebook despublicado só exibe a página quando o criador ativou a lista de espera
+	if !ebook.Status && !ebook.WaitlistEnabled {
 		http.Error(w, "Ebook não disponível", http.StatusNotFound)
 		return
 	}
@@ -75,14 +79,40 @@ func (h *SalesPageHandler) SalesPageView(w http.ResponseWriter, r *http.Request)
 		})
 	}
 
+	currency, price := salesPagePrice(r, ebook)
+
 	data := map[string]any{
-		"Ebook":   ebook,
-		"Creator": creator,
+		"Ebook":          ebook,
+		"Creator":        creator,
+		"WaitlistStatus": r.URL.Query().Get("waitlist"),
+		"Currency":       currency,
+		"Price":          price,
 	}
 
 	h.templateRenderer.View(w, r, "purchase/sales-page", data, "guest")
 }
 
+// SalesAvailability retorna a disponibilidade atual da oferta para o contador da página de vendas
+func (h *SalesPageHandler) SalesAvailability(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	ebook, err := h.ebookService.FindByPublicID(chi.URLParam(r, "id"))
+	if err != nil || ebook == nil || !ebook.Status {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]any{
+			"status": "unavailable",
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"status":      ebook.Availability(time.Now()),
+		"remaining":   ebook.RemainingStock(),
+		"stock_limit": ebook.StockLimit,
+	})
+}
+
 // SalesPagePreviewView exibe a página de vendas em modo "preview" para o criador
 func (h *SalesPageHandler) SalesPagePreviewView(w http.ResponseWriter, r *http.Request) {
 	loggedUser := authmw.Auth(r)
@@ -114,16 +144,26 @@ func (h *SalesPageHandler) SalesPagePreviewView(w http.ResponseWriter, r *http.R
 		ebook.AuthorName = creator.GetDisplayName()
 	}
 
-	originalPrice := ebook.Value
-	savings := originalPrice - ebook.PromotionalValue
+	savings := ebook.Value.Sub(ebook.PromotionalValue)
+	currency, price := salesPagePrice(r, ebook)
 
 	data := map[string]any{
 		"Ebook":         ebook,
-		"OriginalPrice": utils.FloatToBRL(originalPrice),
-		"Savings":       utils.FloatToBRL(savings),
+		"OriginalPrice": ebook.Value.String(),
+		"Savings":       savings.String(),
 		"Creator":       creator,
 		"IsPreview":     true,
+		"Currency":      currency,
+		"Price":         price,
 	}
 
 	h.templateRenderer.View(w, r, "purchase/sales-page", data, "guest")
 }
+
+// salesPagePrice escolhe a moeda exibida: a selecionada pelo comprador (?currency=) ou a do
+// idioma do navegador, desde que o ebook tenha preço nela
+func salesPagePrice(r *http.Request, ebook *librarymodel.Ebook) (string, money.Money) {
+	currency := ebook.ResolveCurrency(r.URL.Query().Get("currency"), money.CurrencyForLocale(r.Header.Get("Accept-Language")))
+	price, _ := ebook.PriceIn(currency)
+	return currency, price
+}
@@ -1,12 +1,35 @@
 package model
 
+import (
+	"time"
+
+	"github.com/anglesson/simple-web-server/pkg/money"
+)
+
 type EbookRequest struct {
-	Title            string  `validate:"required,min=5,max=120" json:"title"`
-	Description      string  `validate:"required,max=120" json:"description"`
-	SalesPage        string  `validate:"required" json:"sales_page"`
-	Value            float64 `validate:"required,gt=0" json:"value"`
-	PromotionalValue float64 `json:"promotional_value"`
-	Status           bool    `json:"status"`
-	Statistics       bool    `json:"statistics"`
-	AuthorName       string  `json:"author_name"`
+	Title            string       `validate:"required,min=5,max=120" json:"title"`
+	Description      string       `validate:"required,max=120" json:"description"`
+	SalesPage        string       `validate:"required" json:"sales_page"`
+	Value            money.Money  `json:"value"`
+	PromotionalValue money.Money  `json:"promotional_value"`
+	Status           bool         `json:"status"`
+	Statistics       bool         `json:"statistics"`
+	AuthorName       string       `json:"author_name"`
+	PreOrder         bool         `json:"pre_order"`
+	ReleaseAt        *time.Time   `json:"release_at"`
+	StockLimit       int          `validate:"gte=0" json:"stock_limit"`
+	SalesStartAt     *time.Time   `json:"sales_start_at"`
+	SalesEndAt       *time.Time   `json:"sales_end_at"`
+	WaitlistEnabled  bool         `json:"waitlist_enabled"`
+	Prices           []EbookPrice `json:"prices"`
+}
+
+// PriceInput retorna o preço informado na moeda para repopular o formulário
+func (r EbookRequest) PriceInput(currency string) string {
+	for _, price := range r.Prices {
+		if price.Currency == currency {
+			return price.Money().Number()
+		}
+	}
+	return ""
 }
@@ -1,12 +1,25 @@
 package model
 
 import (
-	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/anglesson/simple-web-server/pkg/utils"
 	"gorm.io/gorm"
 )
 
+type EbookAvailability string
+
+const (
+	EbookAvailable       EbookAvailability = "available"
+	EbookSoldOut         EbookAvailability = "sold_out"
+	EbookSalesNotStarted EbookAvailability = "not_started"
+	EbookSalesEnded      EbookAvailability = "ended"
+)
+
 func (e *Ebook) BeforeCreate(tx *gorm.DB) error {
 	if e.PublicID == "" {
 		e.PublicID = utils.GeneratePublicID("ebk_")
@@ -16,22 +29,40 @@ func (e *Ebook) BeforeCreate(tx *gorm.DB) error {
 
 type Ebook struct {
 	gorm.Model
-	PublicID              string  `json:"public_id" gorm:"type:varchar(40);uniqueIndex"`
-	Title                 string  `json:"title"`
-	TitleNormalized       string  `json:"title_normalized" gorm:"type:text;index"`
-	Description           string  `json:"description"`
-	DescriptionNormalized string  `json:"description_normalized" gorm:"type:text;index"`
-	SalesPage             string  `json:"sales_page"` // Conteúdo da página de vendas
-	Value                 float64 `json:"value"`
-	PromotionalValue      float64 `json:"promotional_value"`
-	Status                bool    `json:"status"`
-	Image                 string  `json:"image"`
-	CreatorID             uint    `json:"creator_id"`
-	Files                 []*File `gorm:"many2many:ebook_files;"`
-	Statistics            bool    `json:"statistics" gorm:"default:false"`
+	PublicID              string       `json:"public_id" gorm:"type:varchar(40);uniqueIndex"`
+	Title                 string       `json:"title"`
+	TitleNormalized       string       `json:"title_normalized" gorm:"type:text;index"`
+	Description           string       `json:"description"`
+	DescriptionNormalized string       `json:"description_normalized" gorm:"type:text;index"`
+	SalesPage             string       `json:"sales_page"` // Conteúdo da página de vendas
+	Value                 money.Money  `json:"value" gorm:"embedded;embeddedPrefix:value_"`
+	PromotionalValue      money.Money  `json:"promotional_value" gorm:"embedded;embeddedPrefix:promotional_value_"`
+	Prices                []EbookPrice `json:"prices" gorm:"foreignKey:EbookID"` // Preços em outras moedas
+	Status                bool         `json:"status"`
+	Image                 string       `json:"image"`
+	CreatorID             uint         `json:"creator_id"`
+	Files                 []*File      `gorm:"many2many:ebook_files;"`
+	Statistics            bool         `json:"statistics" gorm:"default:false"`
 
 	AuthorName string `json:"author_name"`
 
+	// Pré-venda: o pagamento é aceito, mas o acesso aos arquivos só é liberado em ReleaseAt
+	PreOrder  bool       `json:"pre_order" gorm:"default:false"`
+	ReleaseAt *time.Time `json:"release_at"`
+
+	// Oferta limitada: StockLimit = 0 significa estoque ilimitado. StockUsed conta as unidades
+	// vendidas ou reservadas por checkouts em andamento e só é alterado de forma atômica no banco.
+	StockLimit   int        `json:"stock_limit" gorm:"default:0"`
+	StockUsed    int        `json:"stock_used" gorm:"default:0"`
+	SalesStartAt *time.Time `json:"sales_start_at"`
+	SalesEndAt   *time.Time `json:"sales_end_at"`
+
+	// Lista de espera: visitantes deixam o email quando o ebook está indisponível ou fora da janela de vendas
+	WaitlistEnabled bool `json:"waitlist_enabled" gorm:"default:false"`
+
+	// Programa de afiliados: fração do valor líquido paga ao afiliado que indicou a venda (0 desativa)
+	AffiliateCommission float64 `json:"affiliate_commission" gorm:"default:0"`
+
 	// Campos para SEO e marketing
 	MetaTitle       string `json:"meta_title"`
 	MetaDescription string `json:"meta_description"`
@@ -42,7 +73,7 @@ type Ebook struct {
 	Sales int `json:"sales" gorm:"default:0"`
 }
 
-func NewEbook(title, description, salesPage string, value, promotionalValue float64, creatorID uint, statistics bool) *Ebook {
+func NewEbook(title, description, salesPage string, value, promotionalValue money.Money, creatorID uint, statistics bool) *Ebook {
 	return &Ebook{
 		Title:            title,
 		Description:      description,
@@ -56,15 +87,20 @@ func NewEbook(title, description, salesPage string, value, promotionalValue floa
 }
 
 func (e *Ebook) GetValue() string {
-	return utils.FloatToBRL(e.Value)
+	return e.Value.String()
+}
+
+// GetValueInput retorna o valor no formato do input com máscara de dinheiro
+func (e *Ebook) GetValueInput() string {
+	return e.Value.Number()
 }
 
 func (e *Ebook) GetPromotionalValue() string {
-	return fmt.Sprintf("%.2f", e.PromotionalValue)
+	return e.PromotionalValue.Number()
 }
 
 func (e *Ebook) GetPromotionalValueBRL() string {
-	return utils.FloatToBRL(e.PromotionalValue)
+	return e.PromotionalValue.String()
 }
 
 func (e *Ebook) GetLastUpdate() string {
@@ -116,21 +152,194 @@ func (e *Ebook) GetPresignedImageURL() string {
 }
 
 func (e *Ebook) HasPromotion() bool {
-	return e.PromotionalValue > 0
+	return e.PromotionalValue.IsPositive()
 }
 
 func (e *Ebook) ShowStatistics() bool {
 	return e.Statistics
 }
 
-func (e *Ebook) GetFinalValue() float64 {
+// GetFinalValue retorna o preço cobrado no checkout: o promocional, quando houver
+func (e *Ebook) GetFinalValue() money.Money {
 	if e.HasPromotion() {
 		return e.PromotionalValue
 	}
 	return e.Value
 }
 
+// PriceIn retorna o preço cobrado na moeda informada. Em reais é o preço final (com promoção);
+// nas demais moedas só existe quando o criador cadastrou o preço.
+func (e *Ebook) PriceIn(currency string) (money.Money, bool) {
+	currency = strings.ToUpper(currency)
+	if currency == "" || currency == money.BRL {
+		return e.GetFinalValue(), true
+	}
+	for _, price := range e.Prices {
+		if price.Currency == currency && price.Amount > 0 {
+			return price.Money(), true
+		}
+	}
+	return money.Money{}, false
+}
+
+// PriceInput retorna o preço na moeda no formato do input com máscara (vazio sem preço cadastrado)
+func (e *Ebook) PriceInput(currency string) string {
+	if price, ok := e.PriceIn(currency); ok && currency != money.BRL {
+		return price.Number()
+	}
+	return ""
+}
+
+// Currencies lista as moedas em que o ebook pode ser comprado, começando por BRL
+func (e *Ebook) Currencies() []string {
+	currencies := []string{money.BRL}
+	for _, currency := range money.SupportedCurrencies[1:] {
+		if _, ok := e.PriceIn(currency); ok {
+			currencies = append(currencies, currency)
+		}
+	}
+	return currencies
+}
+
+func (e *Ebook) HasMultipleCurrencies() bool {
+	return len(e.Currencies()) > 1
+}
+
+// ResolveCurrency retorna a primeira moeda candidata em que o ebook tem preço, ou BRL.
+// Os candidatos vêm em ordem de preferência: seleção explícita do comprador e depois o idioma do navegador.
+func (e *Ebook) ResolveCurrency(candidates ...string) string {
+	for _, candidate := range candidates {
+		candidate = strings.ToUpper(candidate)
+		if !money.IsSupported(candidate) {
+			continue
+		}
+		if _, ok := e.PriceIn(candidate); ok {
+			return candidate
+		}
+	}
+	return money.BRL
+}
+
 func (e *Ebook) GetEconomy() string {
-	savings := e.Value - e.PromotionalValue
-	return utils.FloatToBRL(savings)
+	return e.Value.Sub(e.PromotionalValue).String()
+}
+
+// IsAwaitingRelease indica se o ebook está em pré-venda e a data de lançamento ainda não chegou
+func (e *Ebook) IsAwaitingRelease() bool {
+	return e.PreOrder && e.ReleaseAt != nil && e.ReleaseAt.After(time.Now())
+}
+
+func (e *Ebook) GetReleaseDateBR() string {
+	if e.ReleaseAt == nil {
+		return ""
+	}
+	return e.ReleaseAt.Format("02/01/2006 às 15:04")
+}
+
+// GetReleaseAtInput retorna a data de lançamento no formato do input datetime-local
+func (e *Ebook) GetReleaseAtInput() string {
+	if e.ReleaseAt == nil {
+		return ""
+	}
+	return e.ReleaseAt.Format("2006-01-02T15:04")
+}
+
+// HasAffiliateProgram indica que o ebook aceita afiliados
+func (e *Ebook) HasAffiliateProgram() bool {
+	return e.AffiliateCommission > 0
+}
+
+// GetAffiliateCommissionPercent retorna a comissão formatada (ex.: "30%" ou "12,5%")
+func (e *Ebook) GetAffiliateCommissionPercent() string {
+	percent := strconv.FormatFloat(math.Round(e.AffiliateCommission*10000)/100, 'f', -1, 64)
+	return strings.Replace(percent, ".", ",", 1) + "%"
+}
+
+func (e *Ebook) HasStockLimit() bool {
+	return e.StockLimit > 0
+}
+
+// RemainingStock retorna as unidades ainda disponíveis; -1 quando o estoque é ilimitado
+func (e *Ebook) RemainingStock() int {
+	if !e.HasStockLimit() {
+		return -1
+	}
+	if e.StockUsed >= e.StockLimit {
+		return 0
+	}
+	return e.StockLimit - e.StockUsed
+}
+
+func (e *Ebook) IsSoldOut() bool {
+	return e.HasStockLimit() && e.RemainingStock() == 0
+}
+
+func (e *Ebook) HasSalesWindow() bool {
+	return e.SalesStartAt != nil || e.SalesEndAt != nil
+}
+
+// Availability indica se o ebook pode ser vendido no momento informado,
+// considerando a janela de vendas e o estoque da oferta limitada
+func (e *Ebook) Availability(now time.Time) EbookAvailability {
+	if e.SalesStartAt != nil && now.Before(*e.SalesStartAt) {
+		return EbookSalesNotStarted
+	}
+	if e.SalesEndAt != nil && !now.Before(*e.SalesEndAt) {
+		return EbookSalesEnded
+	}
+	if e.IsSoldOut() {
+		return EbookSoldOut
+	}
+	return EbookAvailable
+}
+
+func (e *Ebook) IsAvailableForSale() bool {
+	return e.Availability(time.Now()) == EbookAvailable
+}
+
+// AvailabilityLabel retorna o status atual da oferta para exibição na página de vendas
+func (e *Ebook) AvailabilityLabel() string {
+	switch e.Availability(time.Now()) {
+	case EbookSoldOut:
+		return "Esgotado"
+	case EbookSalesNotStarted:
+		return "Vendas abrem em " + e.GetSalesStartDateBR()
+	case EbookSalesEnded:
+		return "Vendas encerradas"
+	default:
+		return ""
+	}
+}
+
+func (e *Ebook) GetSalesStartDateBR() string {
+	if e.SalesStartAt == nil {
+		return ""
+	}
+	return e.SalesStartAt.Format("02/01/2006 às 15:04")
+}
+
+func (e *Ebook) GetSalesEndDateBR() string {
+	if e.SalesEndAt == nil {
+		return ""
+	}
+	return e.SalesEndAt.Format("02/01/2006 às 15:04")
+}
+
+func (e *Ebook) GetSalesStartAtInput() string {
+	if e.SalesStartAt == nil {
+		return ""
+	}
+	return e.SalesStartAt.Format("2006-01-02T15:04")
+}
+
+func (e *Ebook) GetSalesEndAtInput() string {
+	if e.SalesEndAt == nil {
+		return ""
+	}
+	return e.SalesEndAt.Format("2006-01-02T15:04")
+}
+
+// AcceptsWaitlist indica se a página de vendas deve exibir o formulário da lista de espera
+func (e *Ebook) AcceptsWaitlist() bool {
+	return e.WaitlistEnabled && (!e.Status || !e.IsAvailableForSale())
 }
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	"github.com/anglesson/simple-web-server/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// CoAuthorStatus é a situação do convite de coautoria
+type CoAuthorStatus string
+
+const (
+	CoAuthorStatusInvited  CoAuthorStatus = "invited"
+	CoAuthorStatusAccepted CoAuthorStatus = "accepted"
+	CoAuthorStatusDeclined CoAuthorStatus = "declined"
+)
+
+// EbookCoAuthor liga um ebook a outro criador que recebe uma parte da receita de cada venda.
+// Share é a fração do valor líquido do dono do ebook (ex.: 30% -> 0.30), repassada por transferência no Stripe.
+type EbookCoAuthor struct {
+	gorm.Model
+	PublicID   string               `json:"public_id" gorm:"type:varchar(40);uniqueIndex"`
+	EbookID    uint                 `json:"ebook_id" gorm:"uniqueIndex:idx_ebook_coauthor"`
+	Ebook      Ebook                `json:"-" gorm:"foreignKey:EbookID"`
+	CreatorID  uint                 `json:"creator_id" gorm:"uniqueIndex:idx_ebook_coauthor"`
+	Creator    accountmodel.Creator `json:"-" gorm:"foreignKey:CreatorID"`
+	Share      float64              `json:"share"`
+	Status     CoAuthorStatus       `json:"status" gorm:"type:varchar(20);default:'invited'"`
+	AcceptedAt *time.Time           `json:"accepted_at"`
+}
+
+func (c *EbookCoAuthor) BeforeCreate(tx *gorm.DB) error {
+	if c.PublicID == "" {
+		c.PublicID = utils.GeneratePublicID("coa_")
+	}
+	return nil
+}
+
+func NewEbookCoAuthor(ebookID, creatorID uint, share float64) *EbookCoAuthor {
+	return &EbookCoAuthor{
+		EbookID:   ebookID,
+		CreatorID: creatorID,
+		Share:     share,
+		Status:    CoAuthorStatusInvited,
+	}
+}
+
+func (c *EbookCoAuthor) IsInvited() bool {
+	return c.Status == CoAuthorStatusInvited
+}
+
+func (c *EbookCoAuthor) IsAccepted() bool {
+	return c.Status == CoAuthorStatusAccepted
+}
+
+// Accept registra o aceite do convite
+func (c *EbookCoAuthor) Accept(at time.Time) {
+	c.Status = CoAuthorStatusAccepted
+	c.AcceptedAt = &at
+}
+
+func (c *EbookCoAuthor) Decline() {
+	c.Status = CoAuthorStatusDeclined
+}
+
+// GetSharePercent retorna a participação formatada (ex.: "30%" ou "12,5%")
+func (c *EbookCoAuthor) GetSharePercent() string {
+	percent := strconv.FormatFloat(math.Round(c.Share*10000)/100, 'f', -1, 64)
+	return strings.Replace(percent, ".", ",", 1) + "%"
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"gorm.io/gorm"
+)
+
+// EbookPrice é o preço do ebook em uma moeda estrangeira. O preço em reais continua em
+// Ebook.Value/PromotionalValue; a promoção vale apenas para o preço em reais.
+type EbookPrice struct {
+	gorm.Model
+	EbookID  uint   `json:"ebook_id" gorm:"uniqueIndex:idx_ebook_price_currency"`
+	Currency string `json:"currency" gorm:"type:varchar(3);uniqueIndex:idx_ebook_price_currency"`
+	Amount   int64  `json:"amount"`
+}
+
+func NewEbookPrice(price money.Money) EbookPrice {
+	return EbookPrice{Currency: price.CurrencyOrDefault(), Amount: price.Amount}
+}
+
+func (p EbookPrice) Money() money.Money {
+	return money.New(p.Amount, p.Currency)
+}
@@ -2,9 +2,11 @@ package model_test
 
 import (
 	"testing"
+	"time"
 
 	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
 	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -13,8 +15,8 @@ func TestNewEbook(t *testing.T) {
 	title := "Test Ebook"
 	description := "Test description"
 	salesPage := "This is a sales page content"
-	value := 29.90
-	promotionalValue := 19.90
+	value := money.FromFloat(29.90)
+	promotionalValue := money.FromFloat(19.90)
 	creator := accountmodel.Creator{
 		Name:  "Test Creator",
 		Email: "creator@test.com",
@@ -142,17 +144,17 @@ func TestEbook_GetValue(t *testing.T) {
 	}{
 		{
 			name:     "Zero value",
-			ebook:    &librarymodel.Ebook{Value: 0},
+			ebook:    &librarymodel.Ebook{Value: money.FromCents(0)},
 			expected: "R$ 0,00",
 		},
 		{
 			name:     "Positive value",
-			ebook:    &librarymodel.Ebook{Value: 29.90},
+			ebook:    &librarymodel.Ebook{Value: money.FromCents(2990)},
 			expected: "R$ 29,90",
 		},
 		{
 			name:     "Large value",
-			ebook:    &librarymodel.Ebook{Value: 199.99},
+			ebook:    &librarymodel.Ebook{Value: money.FromCents(19999)},
 			expected: "R$ 199,99",
 		},
 	}
@@ -191,3 +193,93 @@ func TestEbook_IncrementSales(t *testing.T) {
 	assert.Equal(t, 7, ebook.Sales)
 }
 
+
+func TestEbook_IsAwaitingRelease(t *testing.T) {
+	future := time.Now().Add(48 * time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	assert.True(t, (&librarymodel.Ebook{PreOrder: true, ReleaseAt: &future}).IsAwaitingRelease())
+	assert.False(t, (&librarymodel.Ebook{PreOrder: true, ReleaseAt: &past}).IsAwaitingRelease())
+	assert.False(t, (&librarymodel.Ebook{PreOrder: false, ReleaseAt: &future}).IsAwaitingRelease())
+	assert.False(t, (&librarymodel.Ebook{PreOrder: true}).IsAwaitingRelease())
+}
+
+func TestEbook_GetReleaseDateBR(t *testing.T) {
+	releaseAt := time.Date(2025, 12, 1, 9, 30, 0, 0, time.Local)
+	ebook := &librarymodel.Ebook{PreOrder: true, ReleaseAt: &releaseAt}
+
+	assert.Equal(t, "01/12/2025 às 09:30", ebook.GetReleaseDateBR())
+	assert.Equal(t, "2025-12-01T09:30", ebook.GetReleaseAtInput())
+	assert.Equal(t, "", (&librarymodel.Ebook{}).GetReleaseDateBR())
+}
+
+func TestEbook_RemainingStock(t *testing.T) {
+	assert.Equal(t, -1, (&librarymodel.Ebook{}).RemainingStock())
+	assert.Equal(t, 3, (&librarymodel.Ebook{StockLimit: 10, StockUsed: 7}).RemainingStock())
+	assert.Equal(t, 0, (&librarymodel.Ebook{StockLimit: 10, StockUsed: 12}).RemainingStock())
+	assert.True(t, (&librarymodel.Ebook{StockLimit: 10, StockUsed: 10}).IsSoldOut())
+	assert.False(t, (&librarymodel.Ebook{StockUsed: 10}).IsSoldOut())
+}
+
+func TestEbook_Availability(t *testing.T) {
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.Local)
+	before := now.Add(-time.Hour)
+	after := now.Add(time.Hour)
+
+	assert.Equal(t, librarymodel.EbookAvailable, (&librarymodel.Ebook{}).Availability(now))
+	assert.Equal(t, librarymodel.EbookSalesNotStarted, (&librarymodel.Ebook{SalesStartAt: &after}).Availability(now))
+	assert.Equal(t, librarymodel.EbookSalesEnded, (&librarymodel.Ebook{SalesEndAt: &before}).Availability(now))
+	assert.Equal(t, librarymodel.EbookAvailable, (&librarymodel.Ebook{SalesStartAt: &before, SalesEndAt: &after}).Availability(now))
+	assert.Equal(t, librarymodel.EbookSoldOut, (&librarymodel.Ebook{StockLimit: 5, StockUsed: 5, SalesEndAt: &after}).Availability(now))
+	assert.Equal(t, librarymodel.EbookSalesEnded, (&librarymodel.Ebook{StockLimit: 5, StockUsed: 5, SalesEndAt: &before}).Availability(now))
+}
+
+func TestEbook_AcceptsWaitlist(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+
+	assert.False(t, (&librarymodel.Ebook{Status: true, WaitlistEnabled: true}).AcceptsWaitlist())
+	assert.True(t, (&librarymodel.Ebook{Status: false, WaitlistEnabled: true}).AcceptsWaitlist())
+	assert.True(t, (&librarymodel.Ebook{Status: true, WaitlistEnabled: true, StockLimit: 1, StockUsed: 1}).AcceptsWaitlist())
+	assert.True(t, (&librarymodel.Ebook{Status: true, WaitlistEnabled: true, SalesEndAt: &past}).AcceptsWaitlist())
+	assert.False(t, (&librarymodel.Ebook{Status: false}).AcceptsWaitlist())
+}
+
+func TestEbook_PriceIn(t *testing.T) {
+	ebook := librarymodel.Ebook{
+		Value:            money.FromCents(2990),
+		PromotionalValue: money.FromCents(1990),
+		Prices: []librarymodel.EbookPrice{
+			librarymodel.NewEbookPrice(money.New(990, money.EUR)),
+		},
+	}
+
+	brl, ok := ebook.PriceIn(money.BRL)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1990), brl.Amount)
+
+	eur, ok := ebook.PriceIn("eur")
+	assert.True(t, ok)
+	assert.Equal(t, money.New(990, money.EUR), eur)
+
+	_, ok = ebook.PriceIn(money.USD)
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{money.BRL, money.EUR}, ebook.Currencies())
+	assert.True(t, ebook.HasMultipleCurrencies())
+	assert.Equal(t, "9,90", ebook.PriceInput(money.EUR))
+	assert.Equal(t, "", ebook.PriceInput(money.USD))
+}
+
+func TestEbook_ResolveCurrency(t *testing.T) {
+	ebook := librarymodel.Ebook{
+		Value: money.FromCents(2990),
+		Prices: []librarymodel.EbookPrice{
+			librarymodel.NewEbookPrice(money.New(600, money.USD)),
+		},
+	}
+
+	assert.Equal(t, money.USD, ebook.ResolveCurrency("usd"))
+	assert.Equal(t, money.USD, ebook.ResolveCurrency("", "USD"))
+	assert.Equal(t, money.BRL, ebook.ResolveCurrency(money.EUR), "sem preço em EUR deve cair para BRL")
+	assert.Equal(t, money.BRL, ebook.ResolveCurrency("JPY"))
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	"gorm.io/gorm"
+)
+
+type CoAuthorRepository interface {
+	Create(coAuthor *librarymodel.EbookCoAuthor) error
+	Update(coAuthor *librarymodel.EbookCoAuthor) error
+	Delete(id uint) error
+	FindByPublicID(publicID string) (*librarymodel.EbookCoAuthor, error)
+	FindByEbookID(ebookID uint) ([]*librarymodel.EbookCoAuthor, error)
+	FindAcceptedByEbookID(ebookID uint) ([]*librarymodel.EbookCoAuthor, error)
+	FindByCreatorID(creatorID uint) ([]*librarymodel.EbookCoAuthor, error)
+}
+
+type GormCoAuthorRepository struct {
+	db *gorm.DB
+}
+
+func NewGormCoAuthorRepository(db *gorm.DB) *GormCoAuthorRepository {
+	return &GormCoAuthorRepository{db: db}
+}
+
+func (r *GormCoAuthorRepository) Create(coAuthor *librarymodel.EbookCoAuthor) error {
+	return r.db.Omit("Ebook", "Creator").Create(coAuthor).Error
+}
+
+func (r *GormCoAuthorRepository) Update(coAuthor *librarymodel.EbookCoAuthor) error {
+	return r.db.Omit("Ebook", "Creator").Save(coAuthor).Error
+}
+
+// Delete remove a coautoria de vez para permitir um novo convite ao mesmo criador
+func (r *GormCoAuthorRepository) Delete(id uint) error {
+	return r.db.Unscoped().Delete(&librarymodel.EbookCoAuthor{}, id).Error
+}
+
+func (r *GormCoAuthorRepository) FindByPublicID(publicID string) (*librarymodel.EbookCoAuthor, error) {
+	var coAuthor librarymodel.EbookCoAuthor
+	err := r.db.Preload("Ebook").Preload("Creator").Where("public_id = ?", publicID).First(&coAuthor).Error
+	if err != nil {
+		return nil, err
+	}
+	return &coAuthor, nil
+}
+
+func (r *GormCoAuthorRepository) FindByEbookID(ebookID uint) ([]*librarymodel.EbookCoAuthor, error) {
+	var coAuthors []*librarymodel.EbookCoAuthor
+	err := r.db.Preload("Creator").Where("ebook_id = ?", ebookID).Order("created_at ASC").Find(&coAuthors).Error
+	return coAuthors, err
+}
+
+func (r *GormCoAuthorRepository) FindAcceptedByEbookID(ebookID uint) ([]*librarymodel.EbookCoAuthor, error) {
+	var coAuthors []*librarymodel.EbookCoAuthor
+	err := r.db.Preload("Creator").
+		Where("ebook_id = ? AND status = ?", ebookID, librarymodel.CoAuthorStatusAccepted).
+		Order("created_at ASC").
+		Find(&coAuthors).Error
+	return coAuthors, err
+}
+
+// FindByCreatorID lista as coautorias do criador convidado (convites e aceitas), com o ebook
+func (r *GormCoAuthorRepository) FindByCreatorID(creatorID uint) ([]*librarymodel.EbookCoAuthor, error) {
+	var coAuthors []*librarymodel.EbookCoAuthor
+	err := r.db.Preload("Ebook").
+		Where("creator_id = ? AND status <> ?", creatorID, librarymodel.CoAuthorStatusDeclined).
+		Order("created_at DESC").
+		Find(&coAuthors).Error
+	return coAuthors, err
+}
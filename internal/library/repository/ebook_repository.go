@@ -23,6 +23,7 @@ type EbookRepository interface {
 	ListEbooksForUser(userID uint, query EbookQuery) (*[]librarymodel.Ebook, error)
 	RemoveFileAssociation(ebookID, fileID uint) error
 	AppendFiles(ebookID uint, files []*librarymodel.File) error
+	ReplacePrices(ebookID uint, prices []librarymodel.EbookPrice) error
 }
 
 type GormEbookRepository struct {
@@ -33,13 +34,14 @@ func NewGormEbookRepository(db *gorm.DB) *GormEbookRepository {
 	return &GormEbookRepository{db: db}
 }
 
+// Create e Update não gravam Prices, que são substituídos de uma vez por ReplacePrices
 func (r *GormEbookRepository) Create(ebook *librarymodel.Ebook) error {
-	return r.db.Create(ebook).Error
+	return r.db.Omit("Prices").Create(ebook).Error
 }
 
 func (r *GormEbookRepository) FindByID(id uint) (*librarymodel.Ebook, error) {
 	var ebook librarymodel.Ebook
-	err := r.db.Preload("Files").First(&ebook, id).Error
+	err := r.db.Preload("Files").Preload("Prices").First(&ebook, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -53,7 +55,7 @@ func (r *GormEbookRepository) FindByID(id uint) (*librarymodel.Ebook, error) {
 
 func (r *GormEbookRepository) FindByPublicID(publicID string) (*librarymodel.Ebook, error) {
 	var ebook librarymodel.Ebook
-	err := r.db.Preload("Files").Where("public_id = ?", publicID).First(&ebook).Error
+	err := r.db.Preload("Files").Preload("Prices").Where("public_id = ?", publicID).First(&ebook).Error
 	if err != nil {
 		return nil, err
 	}
@@ -67,12 +69,30 @@ func (r *GormEbookRepository) FindByPublicID(publicID string) (*librarymodel.Ebo
 
 func (r *GormEbookRepository) FindByCreator(creatorID uint) ([]*librarymodel.Ebook, error) {
 	var ebooks []*librarymodel.Ebook
-	err := r.db.Where("creator_id = ?", creatorID).Preload("Files").Order("created_at DESC").Find(&ebooks).Error
+	err := r.db.Where("creator_id = ?", creatorID).Preload("Files").Preload("Prices").Order("created_at DESC").Find(&ebooks).Error
 	return ebooks, err
 }
 
+// Update não altera StockUsed, que é mantido apenas pelas reservas atômicas do checkout
 func (r *GormEbookRepository) Update(ebook *librarymodel.Ebook) error {
-	return r.db.Omit("Files").Save(ebook).Error
+	return r.db.Omit("Files", "Prices", "StockUsed").Save(ebook).Error
+}
+
+// ReplacePrices troca os preços em moeda estrangeira do ebook pelos informados
+func (r *GormEbookRepository) ReplacePrices(ebookID uint, prices []librarymodel.EbookPrice) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("ebook_id = ?", ebookID).Delete(&librarymodel.EbookPrice{}).Error; err != nil {
+			return err
+		}
+		for i := range prices {
+			prices[i].ID = 0
+			prices[i].EbookID = ebookID
+		}
+		if len(prices) == 0 {
+			return nil
+		}
+		return tx.Create(&prices).Error
+	})
 }
 
 func (r *GormEbookRepository) AppendFiles(ebookID uint, files []*librarymodel.File) error {
@@ -95,13 +115,13 @@ func (r *GormEbookRepository) Delete(id uint) error {
 
 func (r *GormEbookRepository) FindAll() ([]*librarymodel.Ebook, error) {
 	var ebooks []*librarymodel.Ebook
-	err := r.db.Preload("Files").Order("created_at DESC").Find(&ebooks).Error
+	err := r.db.Preload("Files").Preload("Prices").Order("created_at DESC").Find(&ebooks).Error
 	return ebooks, err
 }
 
 func (r *GormEbookRepository) FindActive() ([]*librarymodel.Ebook, error) {
 	var ebooks []*librarymodel.Ebook
-	err := r.db.Where("status = ?", true).Preload("Files").Order("created_at DESC").Find(&ebooks).Error
+	err := r.db.Where("status = ?", true).Preload("Files").Preload("Prices").Order("created_at DESC").Find(&ebooks).Error
 	return ebooks, err
 }
 
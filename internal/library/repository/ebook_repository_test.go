@@ -9,6 +9,7 @@ import (
 	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
 	libraryrepo "github.com/anglesson/simple-web-server/internal/library/repository"
 	"github.com/anglesson/simple-web-server/pkg/database"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"gorm.io/gorm"
@@ -76,7 +77,7 @@ func (suite *EbookRepositoryTestSuite) TestCreate() {
 		Title:       "Test Ebook",
 		Description: "Test description",
 		SalesPage:   "Sales page content",
-		Value:       29.90,
+		Value:       money.FromCents(2990),
 		Status:      true,
 		CreatorID:   suite.creator.ID,
 	}
@@ -100,7 +101,7 @@ func (suite *EbookRepositoryTestSuite) TestFindByID() {
 		Title:       "Test Ebook",
 		Description: "Test description",
 		SalesPage:   "Sales page content",
-		Value:       29.90,
+		Value:       money.FromCents(2990),
 		Status:      true,
 		CreatorID:   suite.creator.ID,
 	}
@@ -122,7 +123,7 @@ func (suite *EbookRepositoryTestSuite) TestFindByCreator() {
 		Title:       "First Ebook",
 		Description: "First description",
 		SalesPage:   "First sales page",
-		Value:       29.90,
+		Value:       money.FromCents(2990),
 		Status:      true,
 		CreatorID:   suite.creator.ID,
 	}
@@ -130,7 +131,7 @@ func (suite *EbookRepositoryTestSuite) TestFindByCreator() {
 		Title:       "Second Ebook",
 		Description: "Second description",
 		SalesPage:   "Second sales page",
-		Value:       39.90,
+		Value:       money.FromCents(3990),
 		Status:      true,
 		CreatorID:   suite.creator.ID,
 	}
@@ -153,7 +154,7 @@ func (suite *EbookRepositoryTestSuite) TestUpdate() {
 		Title:       "Original Title",
 		Description: "Original description",
 		SalesPage:   "Original sales page",
-		Value:       29.90,
+		Value:       money.FromCents(2990),
 		Status:      true,
 		CreatorID:   suite.creator.ID,
 	}
@@ -180,7 +181,7 @@ func (suite *EbookRepositoryTestSuite) TestDelete() {
 		Title:       "Test Ebook",
 		Description: "Test description",
 		SalesPage:   "Sales page content",
-		Value:       29.90,
+		Value:       money.FromCents(2990),
 		Status:      true,
 		CreatorID:   suite.creator.ID,
 	}
@@ -204,7 +205,7 @@ func (suite *EbookRepositoryTestSuite) TestFindAll() {
 		Title:       "First Ebook",
 		Description: "First description",
 		SalesPage:   "First sales page",
-		Value:       29.90,
+		Value:       money.FromCents(2990),
 		Status:      true,
 		CreatorID:   suite.creator.ID,
 	}
@@ -212,7 +213,7 @@ func (suite *EbookRepositoryTestSuite) TestFindAll() {
 		Title:       "Second Ebook",
 		Description: "Second description",
 		SalesPage:   "Second sales page",
-		Value:       39.90,
+		Value:       money.FromCents(3990),
 		Status:      true,
 		CreatorID:   suite.creator.ID,
 	}
@@ -233,7 +234,7 @@ func (suite *EbookRepositoryTestSuite) TestFindActive() {
 		Title:       "Active Ebook",
 		Description: "Active description",
 		SalesPage:   "Active sales page",
-		Value:       29.90,
+		Value:       money.FromCents(2990),
 		Status:      true,
 		CreatorID:   suite.creator.ID,
 	}
@@ -241,7 +242,7 @@ func (suite *EbookRepositoryTestSuite) TestFindActive() {
 		Title:       "Inactive Ebook",
 		Description: "Inactive description",
 		SalesPage:   "Inactive sales page",
-		Value:       39.90,
+		Value:       money.FromCents(3990),
 		Status:      false,
 		CreatorID:   suite.creator.ID,
 	}
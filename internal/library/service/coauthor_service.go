@@ -0,0 +1,160 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	accountsvc "github.com/anglesson/simple-web-server/internal/account/service"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	libraryrepo "github.com/anglesson/simple-web-server/internal/library/repository"
+)
+
+var ErrCoAuthorNotFound = errors.New("nenhum criador cadastrado com este e-mail")
+var ErrCoAuthorIsOwner = errors.New("o dono do e-book não pode ser coautor")
+var ErrCoAuthorWithoutStripe = errors.New("o coautor precisa concluir o cadastro no Stripe Connect para receber os repasses")
+var ErrCoAuthorAlreadyInvited = errors.New("este criador já é coautor ou já foi convidado")
+var ErrCoAuthorInvalidShare = errors.New("a participação deve estar entre 1% e 99%")
+var ErrCoAuthorSharesExceeded = errors.New("a soma das participações dos coautores deve ser menor que 100%")
+var ErrCoAuthorInviteNotFound = errors.New("convite de coautoria não encontrado")
+
+type CoAuthorService interface {
+	Invite(ebook *librarymodel.Ebook, email string, share float64) (*librarymodel.EbookCoAuthor, error)
+	Accept(publicID string, creatorID uint) error
+	Decline(publicID string, creatorID uint) error
+	Remove(ebook *librarymodel.Ebook, publicID string) error
+	ListByEbook(ebookID uint) ([]*librarymodel.EbookCoAuthor, error)
+	ListAccepted(ebookID uint) ([]*librarymodel.EbookCoAuthor, error)
+	ListForCreator(creatorID uint) ([]*librarymodel.EbookCoAuthor, error)
+}
+
+type coAuthorServiceImpl struct {
+	coAuthorRepo   libraryrepo.CoAuthorRepository
+	creatorService accountsvc.CreatorService
+}
+
+func NewCoAuthorService(coAuthorRepo libraryrepo.CoAuthorRepository, creatorService accountsvc.CreatorService) CoAuthorService {
+	return &coAuthorServiceImpl{
+		coAuthorRepo:   coAuthorRepo,
+		creatorService: creatorService,
+	}
+}
+
+// Invite convida outro criador, pelo e-mail da conta, para dividir a receita do ebook
+func (s *coAuthorServiceImpl) Invite(ebook *librarymodel.Ebook, email string, share float64) (*librarymodel.EbookCoAuthor, error) {
+	if share < 0.01 || share > 0.99 {
+		return nil, ErrCoAuthorInvalidShare
+	}
+
+	creator, err := s.creatorService.FindCreatorByEmail(strings.ToLower(strings.TrimSpace(email)))
+	if err != nil || creator == nil {
+		return nil, ErrCoAuthorNotFound
+	}
+	if creator.ID == ebook.CreatorID {
+		return nil, ErrCoAuthorIsOwner
+	}
+	if !canReceiveTransfers(creator) {
+		return nil, ErrCoAuthorWithoutStripe
+	}
+
+	coAuthors, err := s.coAuthorRepo.FindByEbookID(ebook.ID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar coautores: %v", err)
+	}
+
+	var existing *librarymodel.EbookCoAuthor
+	total := share
+	for _, coAuthor := range coAuthors {
+		if coAuthor.CreatorID == creator.ID {
+			existing = coAuthor
+			continue
+		}
+		if coAuthor.Status != librarymodel.CoAuthorStatusDeclined {
+			total += coAuthor.Share
+		}
+	}
+	if existing != nil && existing.Status != librarymodel.CoAuthorStatusDeclined {
+		return nil, ErrCoAuthorAlreadyInvited
+	}
+	if total >= 1 {
+		return nil, ErrCoAuthorSharesExceeded
+	}
+
+	// Um convite recusado pode ser refeito com outra participação
+	if existing != nil {
+		existing.Share = share
+		existing.Status = librarymodel.CoAuthorStatusInvited
+		existing.AcceptedAt = nil
+		if err := s.coAuthorRepo.Update(existing); err != nil {
+			return nil, fmt.Errorf("erro ao convidar coautor: %v", err)
+		}
+		return existing, nil
+	}
+
+	coAuthor := librarymodel.NewEbookCoAuthor(ebook.ID, creator.ID, share)
+	if err := s.coAuthorRepo.Create(coAuthor); err != nil {
+		return nil, fmt.Errorf("erro ao convidar coautor: %v", err)
+	}
+	coAuthor.Creator = *creator
+	return coAuthor, nil
+}
+
+// Accept confirma o convite; a partir daí as vendas do ebook repassam a participação ao coautor
+func (s *coAuthorServiceImpl) Accept(publicID string, creatorID uint) error {
+	coAuthor, err := s.findInvite(publicID, creatorID)
+	if err != nil {
+		return err
+	}
+	if !canReceiveTransfers(&coAuthor.Creator) {
+		return ErrCoAuthorWithoutStripe
+	}
+
+	coAuthor.Accept(time.Now())
+	return s.coAuthorRepo.Update(coAuthor)
+}
+
+func (s *coAuthorServiceImpl) Decline(publicID string, creatorID uint) error {
+	coAuthor, err := s.findInvite(publicID, creatorID)
+	if err != nil {
+		return err
+	}
+
+	coAuthor.Decline()
+	return s.coAuthorRepo.Update(coAuthor)
+}
+
+// Remove desfaz a coautoria; as vendas já realizadas mantêm os repasses registrados
+func (s *coAuthorServiceImpl) Remove(ebook *librarymodel.Ebook, publicID string) error {
+	coAuthor, err := s.coAuthorRepo.FindByPublicID(publicID)
+	if err != nil || coAuthor.EbookID != ebook.ID {
+		return ErrCoAuthorInviteNotFound
+	}
+	return s.coAuthorRepo.Delete(coAuthor.ID)
+}
+
+func (s *coAuthorServiceImpl) ListByEbook(ebookID uint) ([]*librarymodel.EbookCoAuthor, error) {
+	return s.coAuthorRepo.FindByEbookID(ebookID)
+}
+
+// ListAccepted retorna os coautores que participam das próximas vendas do ebook
+func (s *coAuthorServiceImpl) ListAccepted(ebookID uint) ([]*librarymodel.EbookCoAuthor, error) {
+	return s.coAuthorRepo.FindAcceptedByEbookID(ebookID)
+}
+
+func (s *coAuthorServiceImpl) ListForCreator(creatorID uint) ([]*librarymodel.EbookCoAuthor, error) {
+	return s.coAuthorRepo.FindByCreatorID(creatorID)
+}
+
+func (s *coAuthorServiceImpl) findInvite(publicID string, creatorID uint) (*librarymodel.EbookCoAuthor, error) {
+	coAuthor, err := s.coAuthorRepo.FindByPublicID(publicID)
+	if err != nil || coAuthor.CreatorID != creatorID || !coAuthor.IsInvited() {
+		return nil, ErrCoAuthorInviteNotFound
+	}
+	return coAuthor, nil
+}
+
+func canReceiveTransfers(creator *accountmodel.Creator) bool {
+	return creator.StripeConnectAccountID != "" && creator.OnboardingCompleted
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"testing"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	libraryrepo "github.com/anglesson/simple-web-server/internal/library/repository"
+	"github.com/anglesson/simple-web-server/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupCoAuthorService(t *testing.T) (CoAuthorService, *mocks.MockCreatorService, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&accountmodel.Creator{}, &librarymodel.Ebook{}, &librarymodel.EbookCoAuthor{}))
+
+	creatorService := new(mocks.MockCreatorService)
+	return NewCoAuthorService(libraryrepo.NewGormCoAuthorRepository(db), creatorService), creatorService, db
+}
+
+func createCoAuthorCreator(t *testing.T, db *gorm.DB, email string, connected bool) *accountmodel.Creator {
+	t.Helper()
+	creator := &accountmodel.Creator{Name: email, Email: email}
+	if connected {
+		creator.StripeConnectAccountID = "acct_" + email
+		creator.OnboardingCompleted = true
+	}
+	require.NoError(t, db.Create(creator).Error)
+	return creator
+}
+
+func TestCoAuthorService_InviteValidatesShares(t *testing.T) {
+	service, creatorService, db := setupCoAuthorService(t)
+	owner := createCoAuthorCreator(t, db, "dono@test.com", true)
+	ana := createCoAuthorCreator(t, db, "ana@test.com", true)
+	bia := createCoAuthorCreator(t, db, "bia@test.com", true)
+	semStripe := createCoAuthorCreator(t, db, "sem@test.com", false)
+	ebook := &librarymodel.Ebook{Title: "Ebook", CreatorID: owner.ID}
+	require.NoError(t, db.Create(ebook).Error)
+
+	creatorService.On("FindCreatorByEmail", "dono@test.com").Return(owner, nil)
+	creatorService.On("FindCreatorByEmail", "ana@test.com").Return(ana, nil)
+	creatorService.On("FindCreatorByEmail", "bia@test.com").Return(bia, nil)
+	creatorService.On("FindCreatorByEmail", "sem@test.com").Return(semStripe, nil)
+
+	_, err := service.Invite(ebook, "ana@test.com", 1.2)
+	assert.ErrorIs(t, err, ErrCoAuthorInvalidShare)
+
+	_, err = service.Invite(ebook, "dono@test.com", 0.3)
+	assert.ErrorIs(t, err, ErrCoAuthorIsOwner)
+
+	_, err = service.Invite(ebook, "sem@test.com", 0.3)
+	assert.ErrorIs(t, err, ErrCoAuthorWithoutStripe)
+
+	invite, err := service.Invite(ebook, " Ana@test.com ", 0.6)
+	require.NoError(t, err)
+	assert.True(t, invite.IsInvited())
+
+	_, err = service.Invite(ebook, "ana@test.com", 0.1)
+	assert.ErrorIs(t, err, ErrCoAuthorAlreadyInvited)
+
+	// O convite pendente já reserva a participação
+	_, err = service.Invite(ebook, "bia@test.com", 0.4)
+	assert.ErrorIs(t, err, ErrCoAuthorSharesExceeded)
+
+	_, err = service.Invite(ebook, "bia@test.com", 0.25)
+	assert.NoError(t, err)
+}
+
+func TestCoAuthorService_AcceptOnlyByInvitedCreator(t *testing.T) {
+	service, creatorService, db := setupCoAuthorService(t)
+	owner := createCoAuthorCreator(t, db, "dono@test.com", true)
+	ana := createCoAuthorCreator(t, db, "ana@test.com", true)
+	ebook := &librarymodel.Ebook{Title: "Ebook", CreatorID: owner.ID}
+	require.NoError(t, db.Create(ebook).Error)
+	creatorService.On("FindCreatorByEmail", "ana@test.com").Return(ana, nil)
+
+	invite, err := service.Invite(ebook, "ana@test.com", 0.3)
+	require.NoError(t, err)
+
+	accepted, err := service.ListAccepted(ebook.ID)
+	require.NoError(t, err)
+	assert.Empty(t, accepted)
+
+	assert.ErrorIs(t, service.Accept(invite.PublicID, owner.ID), ErrCoAuthorInviteNotFound)
+	require.NoError(t, service.Accept(invite.PublicID, ana.ID))
+
+	accepted, err = service.ListAccepted(ebook.ID)
+	require.NoError(t, err)
+	require.Len(t, accepted, 1)
+	assert.Equal(t, ana.ID, accepted[0].CreatorID)
+	assert.NotNil(t, accepted[0].AcceptedAt)
+
+	// Depois de aceito o convite não pode ser respondido de novo
+	assert.ErrorIs(t, service.Decline(invite.PublicID, ana.ID), ErrCoAuthorInviteNotFound)
+
+	require.NoError(t, service.Remove(ebook, invite.PublicID))
+	accepted, err = service.ListAccepted(ebook.ID)
+	require.NoError(t, err)
+	assert.Empty(t, accepted)
+}
@@ -2,11 +2,13 @@ package service
 
 import (
 	"errors"
+	"fmt"
 	"log/slog"
 	"strings"
 
 	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
 	libraryrepo "github.com/anglesson/simple-web-server/internal/library/repository"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/anglesson/simple-web-server/pkg/storage"
 	"github.com/anglesson/simple-web-server/pkg/utils"
 )
@@ -21,6 +23,7 @@ type EbookService interface {
 	GetEbooksByCreatorID(creatorID uint) ([]*librarymodel.Ebook, error)
 	RemoveFileAssociation(ebookID, fileID uint) error
 	AppendFiles(ebookID uint, files []*librarymodel.File) error
+	SetPrices(ebookID uint, prices []librarymodel.EbookPrice) error
 }
 
 type EbookServiceImpl struct {
@@ -88,6 +91,16 @@ func (s *EbookServiceImpl) AppendFiles(ebookID uint, files []*librarymodel.File)
 	return s.ebookRepository.AppendFiles(ebookID, files)
 }
 
+// SetPrices grava os preços do ebook em moedas estrangeiras; o preço em reais fica no próprio ebook
+func (s *EbookServiceImpl) SetPrices(ebookID uint, prices []librarymodel.EbookPrice) error {
+	for _, price := range prices {
+		if price.Currency == money.BRL || !money.IsSupported(price.Currency) || price.Amount <= 0 {
+			return fmt.Errorf("preço inválido na moeda %s", price.Currency)
+		}
+	}
+	return s.ebookRepository.ReplacePrices(ebookID, prices)
+}
+
 func (s *EbookServiceImpl) Create(ebook *librarymodel.Ebook) error {
 	ebook.TitleNormalized = utils.NormalizeText(ebook.Title)
 	ebook.DescriptionNormalized = utils.NormalizeText(ebook.Description)
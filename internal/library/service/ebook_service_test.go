@@ -91,6 +91,10 @@ func (m *MockEbookRepository) RemoveFileAssociation(ebookID, fileID uint) error
 	return nil
 }
 
+func (m *MockEbookRepository) ReplacePrices(ebookID uint, prices []librarymodel.EbookPrice) error {
+	return nil
+}
+
 func (m *MockEbookRepository) AppendFiles(ebookID uint, files []*librarymodel.File) error {
 	return nil
 }
@@ -0,0 +1,52 @@
+package mocks
+
+import (
+	"time"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCheckoutRecoveryService struct {
+	mock.Mock
+}
+
+func (m *MockCheckoutRecoveryService) RegisterExpiredSession(purchaseID uint, stripeSessionID string, expiredAt time.Time) error {
+	args := m.Called(purchaseID, stripeSessionID, expiredAt)
+	return args.Error(0)
+}
+
+func (m *MockCheckoutRecoveryService) ProcessDueRecoveries(now time.Time) (int, error) {
+	args := m.Called(now)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockCheckoutRecoveryService) MarkRecovered(purchaseID uint) error {
+	args := m.Called(purchaseID)
+	return args.Error(0)
+}
+
+func (m *MockCheckoutRecoveryService) FindByToken(token string) (*salesmodel.CheckoutRecovery, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*salesmodel.CheckoutRecovery), args.Error(1)
+}
+
+func (m *MockCheckoutRecoveryService) GetRecoveryStatsByCreatorID(creatorID uint) ([]*salesmodel.EbookRecoveryStats, error) {
+	args := m.Called(creatorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*salesmodel.EbookRecoveryStats), args.Error(1)
+}
+
+func (m *MockCheckoutRecoveryService) DiscountPercent() int64 {
+	args := m.Called()
+	return args.Get(0).(int64)
+}
+
+func (m *MockCheckoutRecoveryService) StartScheduler(interval time.Duration) {
+	m.Called(interval)
+}
@@ -0,0 +1,128 @@
+package mocks
+
+import (
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockClientCRMService struct {
+	mock.Mock
+}
+
+func (m *MockClientCRMService) FindClient(creatorID uint, publicID string) (*salesmodel.Client, error) {
+	args := m.Called(creatorID, publicID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*salesmodel.Client), args.Error(1)
+}
+
+func (m *MockClientCRMService) FindPurchases(creatorID, clientID uint) ([]*salesmodel.Purchase, error) {
+	args := m.Called(creatorID, clientID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*salesmodel.Purchase), args.Error(1)
+}
+
+func (m *MockClientCRMService) TotalSpent(creatorID, clientID uint) (int64, error) {
+	args := m.Called(creatorID, clientID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockClientCRMService) ListTags(creatorID uint) ([]*salesmodel.ClientTag, error) {
+	args := m.Called(creatorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*salesmodel.ClientTag), args.Error(1)
+}
+
+func (m *MockClientCRMService) TagsByClient(creatorID uint, clientIDs []uint) (map[uint][]*salesmodel.ClientTag, error) {
+	args := m.Called(creatorID, clientIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uint][]*salesmodel.ClientTag), args.Error(1)
+}
+
+func (m *MockClientCRMService) TagClient(creatorID, clientID uint, tagName string) (*salesmodel.ClientTag, error) {
+	args := m.Called(creatorID, clientID, tagName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*salesmodel.ClientTag), args.Error(1)
+}
+
+func (m *MockClientCRMService) UntagClient(creatorID, clientID, tagID uint) error {
+	args := m.Called(creatorID, clientID, tagID)
+	return args.Error(0)
+}
+
+func (m *MockClientCRMService) DeleteTag(creatorID, tagID uint) error {
+	args := m.Called(creatorID, tagID)
+	return args.Error(0)
+}
+
+func (m *MockClientCRMService) ListNotes(creatorID, clientID uint) ([]*salesmodel.ClientNote, error) {
+	args := m.Called(creatorID, clientID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*salesmodel.ClientNote), args.Error(1)
+}
+
+func (m *MockClientCRMService) AddNote(creatorID, clientID uint, body string) (*salesmodel.ClientNote, error) {
+	args := m.Called(creatorID, clientID, body)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*salesmodel.ClientNote), args.Error(1)
+}
+
+func (m *MockClientCRMService) DeleteNote(creatorID, clientID, noteID uint) error {
+	args := m.Called(creatorID, clientID, noteID)
+	return args.Error(0)
+}
+
+func (m *MockClientCRMService) ListSegments(creatorID uint) ([]*salesmodel.ClientSegment, error) {
+	args := m.Called(creatorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*salesmodel.ClientSegment), args.Error(1)
+}
+
+func (m *MockClientCRMService) FindSegment(creatorID uint, publicID string) (*salesmodel.ClientSegment, error) {
+	args := m.Called(creatorID, publicID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*salesmodel.ClientSegment), args.Error(1)
+}
+
+func (m *MockClientCRMService) CreateSegment(creatorID uint, name string, rules salesmodel.SegmentRules) (*salesmodel.ClientSegment, error) {
+	args := m.Called(creatorID, name, rules)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*salesmodel.ClientSegment), args.Error(1)
+}
+
+func (m *MockClientCRMService) DeleteSegment(creatorID uint, publicID string) error {
+	args := m.Called(creatorID, publicID)
+	return args.Error(0)
+}
+
+func (m *MockClientCRMService) SegmentClients(creatorID uint, rules salesmodel.SegmentRules) ([]salesmodel.Client, error) {
+	args := m.Called(creatorID, rules)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]salesmodel.Client), args.Error(1)
+}
+
+func (m *MockClientCRMService) GrantEbookToSegment(creatorID uint, segment *salesmodel.ClientSegment, ebookID uint) (int, error) {
+	args := m.Called(creatorID, segment, ebookID)
+	return args.Int(0), args.Error(1)
+}
@@ -68,6 +68,14 @@ func (m *MockClientRepository) FindByEmail(email string) (*salesmodel.Client, er
 	return args.Get(0).(*salesmodel.Client), args.Error(1)
 }
 
+func (m *MockClientRepository) FindByEmailAndCreator(email string, creatorID uint) (*salesmodel.Client, error) {
+	args := m.Called(email, creatorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*salesmodel.Client), args.Error(1)
+}
+
 func (m *MockClientRepository) FindByCPF(cpf string) (*salesmodel.Client, error) {
 	args := m.Called(cpf)
 	if args.Get(0) == nil {
@@ -75,3 +83,19 @@ func (m *MockClientRepository) FindByCPF(cpf string) (*salesmodel.Client, error)
 	}
 	return args.Get(0).(*salesmodel.Client), args.Error(1)
 }
+
+func (m *MockClientRepository) FindForeignByEmail(email string) (*salesmodel.Client, error) {
+	args := m.Called(email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*salesmodel.Client), args.Error(1)
+}
+
+func (m *MockClientRepository) FindClientsBySegment(creatorID uint, rules salesmodel.SegmentRules) ([]salesmodel.Client, error) {
+	args := m.Called(creatorID, rules)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]salesmodel.Client), args.Error(1)
+}
@@ -0,0 +1,39 @@
+package mocks
+
+import (
+	"time"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCPFVerificationService struct {
+	mock.Mock
+}
+
+func (m *MockCPFVerificationService) Verify(creatorID uint, name, cpf, birthdate string) (*salesmodel.CPFVerification, error) {
+	args := m.Called(creatorID, name, cpf, birthdate)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*salesmodel.CPFVerification), args.Error(1)
+}
+
+func (m *MockCPFVerificationService) IsVerified(cpf, birthdate string) bool {
+	args := m.Called(cpf, birthdate)
+	return args.Bool(0)
+}
+
+func (m *MockCPFVerificationService) ProcessPending() (int, error) {
+	args := m.Called()
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockCPFVerificationService) StartScheduler(interval time.Duration) {
+	m.Called(interval)
+}
+
+func (m *MockCPFVerificationService) GetConsumptionByCreator(creatorID uint, since time.Time) (int64, error) {
+	args := m.Called(creatorID, since)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -38,6 +38,14 @@ func (m *MockCreatorRepository) FindByCPF(cpf string) (*accountmodel.Creator, er
 	return args.Get(0).(*accountmodel.Creator), args.Error(1)
 }
 
+func (m *MockCreatorRepository) FindByCNPJ(cnpj string) (*accountmodel.Creator, error) {
+	args := m.Called(cnpj)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*accountmodel.Creator), args.Error(1)
+}
+
 func (m *MockCreatorRepository) FindByID(id uint) (*accountmodel.Creator, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
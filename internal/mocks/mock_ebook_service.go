@@ -66,3 +66,8 @@ func (m *MockEbookService) AppendFiles(ebookID uint, files []*librarymodel.File)
 	args := m.Called(ebookID, files)
 	return args.Error(0)
 }
+
+func (m *MockEbookService) SetPrices(ebookID uint, prices []librarymodel.EbookPrice) error {
+	args := m.Called(ebookID, prices)
+	return args.Error(0)
+}
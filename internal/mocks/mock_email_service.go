@@ -1,6 +1,8 @@
 package mocks
 
 import (
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
 	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
 	salesdto "github.com/anglesson/simple-web-server/internal/sales/service/dto"
 	"github.com/stretchr/testify/mock"
@@ -32,3 +34,28 @@ func (m *MockSalesEmailService) ResendDownloadLink(downloadDTO *salesdto.ResendD
 	args := m.Called(downloadDTO)
 	return args.Error(0)
 }
+
+func (m *MockSalesEmailService) SendCheckoutRecovery(recovery *salesmodel.CheckoutRecovery, discountPercent int64) {
+	m.Called(recovery, discountPercent)
+}
+
+func (m *MockSalesEmailService) SendWaitlistNotification(entry *salesmodel.WaitlistEntry, ebook *librarymodel.Ebook) {
+	m.Called(entry, ebook)
+}
+
+func (m *MockSalesEmailService) SendMonthlyStatement(creator *accountmodel.Creator, statement *salesmodel.MonthlyStatement, pdf []byte) {
+	m.Called(creator, statement, pdf)
+}
+
+func (m *MockSalesEmailService) SendReconciliationReport(run *salesmodel.ReconciliationRun) {
+	m.Called(run)
+}
+
+func (m *MockSalesEmailService) SendBroadcast(broadcast *salesmodel.Broadcast, delivery *salesmodel.BroadcastDelivery) error {
+	args := m.Called(broadcast, delivery)
+	return args.Error(0)
+}
+
+func (m *MockSalesEmailService) SendPrivacyRequestAlert(request *salesmodel.PrivacyRequest) {
+	m.Called(request)
+}
@@ -0,0 +1,48 @@
+package mocks
+
+import (
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockFeePlanService struct {
+	mock.Mock
+}
+
+func (m *MockFeePlanService) EffectivePlan(creator *accountmodel.Creator, total money.Money) *salesmodel.FeePlan {
+	args := m.Called(creator, total)
+	return args.Get(0).(*salesmodel.FeePlan)
+}
+
+func (m *MockFeePlanService) AssignToCreator(creatorID, feePlanID uint, startsAt time.Time, endsAt *time.Time, note string) error {
+	args := m.Called(creatorID, feePlanID, startsAt, endsAt, note)
+	return args.Error(0)
+}
+
+func (m *MockFeePlanService) CreatePlan(input salesmodel.FeePlanInput) (*salesmodel.FeePlan, error) {
+	args := m.Called(input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*salesmodel.FeePlan), args.Error(1)
+}
+
+func (m *MockFeePlanService) ListPlans() ([]*salesmodel.FeePlan, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*salesmodel.FeePlan), args.Error(1)
+}
+
+func (m *MockFeePlanService) FindPlanByPublicID(publicID string) (*salesmodel.FeePlan, error) {
+	args := m.Called(publicID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*salesmodel.FeePlan), args.Error(1)
+}
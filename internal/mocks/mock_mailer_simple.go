@@ -23,6 +23,18 @@ func (m *MockMailerSimple) Body(body string) {
 	m.Called(body)
 }
 
-func (m *MockMailerSimple) Send() {
-	m.Called()
+func (m *MockMailerSimple) Attach(filename string, content []byte) {
+	m.Called(filename, content)
+}
+
+func (m *MockMailerSimple) Header(name string, value string) {
+	m.Called(name, value)
+}
+
+func (m *MockMailerSimple) Send() error {
+	args := m.Called()
+	if len(args) == 0 {
+		return nil
+	}
+	return args.Error(0)
 }
@@ -0,0 +1,15 @@
+package mocks
+
+import (
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPaymentConfirmationService struct {
+	mock.Mock
+}
+
+func (m *MockPaymentConfirmationService) ConfirmEbookPayment(purchase *salesmodel.Purchase, paymentIntentID string) error {
+	args := m.Called(purchase, paymentIntentID)
+	return args.Error(0)
+}
@@ -1,6 +1,8 @@
 package mocks
 
 import (
+	"time"
+
 	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
 	"github.com/stretchr/testify/mock"
 )
@@ -58,8 +60,40 @@ func (m *MockPurchaseService) FindExistingPurchase(ebookID uint, clientID uint)
 	return args.Get(0).(*salesmodel.Purchase), args.Error(1)
 }
 
+func (m *MockPurchaseService) SetCheckoutSession(purchaseID uint, sessionID string) error {
+	args := m.Called(purchaseID, sessionID)
+	return args.Error(0)
+}
+
 func (m *MockPurchaseService) ConfirmPayment(purchaseID uint) error {
 	args := m.Called(purchaseID)
 	return args.Error(0)
 }
 
+func (m *MockPurchaseService) ConfirmOfflinePayment(purchaseID uint, creatorID uint, reason string) error {
+	args := m.Called(purchaseID, creatorID, reason)
+	return args.Error(0)
+}
+
+func (m *MockPurchaseService) ReleasePreOrders(now time.Time) (int, error) {
+	args := m.Called(now)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockPurchaseService) ChangeStatus(purchaseID uint, to salesmodel.PurchaseStatus, change salesmodel.StatusChange) error {
+	args := m.Called(purchaseID, to, change)
+	return args.Error(0)
+}
+
+func (m *MockPurchaseService) RevokeAccess(purchaseID uint, creatorID uint, reason string) error {
+	args := m.Called(purchaseID, creatorID, reason)
+	return args.Error(0)
+}
+
+func (m *MockPurchaseService) GetStatusHistory(purchaseID uint) ([]*salesmodel.PurchaseStatusHistory, error) {
+	args := m.Called(purchaseID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*salesmodel.PurchaseStatusHistory), args.Error(1)
+}
@@ -13,3 +13,11 @@ func (m *MockRFService) ConsultaCPF(nome, cpf, dataNascimento string) (*gov.Rece
 	args := m.Called(nome, cpf, dataNascimento)
 	return args.Get(0).(*gov.ReceitaFederalResponse), args.Error(1)
 }
+
+func (m *MockRFService) ConsultaCNPJ(cnpj string) (*gov.ReceitaFederalCNPJResponse, error) {
+	args := m.Called(cnpj)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*gov.ReceitaFederalCNPJResponse), args.Error(1)
+}
@@ -17,3 +17,8 @@ func (m *MockResendDownloadLinkService) ResendDownloadLinkByPurchaseID(purchaseI
 	args := m.Called(purchaseID, overrideEmail)
 	return args.Error(0)
 }
+
+func (m *MockResendDownloadLinkService) ResendAccessToBuyer(cpf, email string) error {
+	args := m.Called(cpf, email)
+	return args.Error(0)
+}
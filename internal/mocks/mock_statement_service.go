@@ -0,0 +1,34 @@
+package mocks
+
+import (
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockStatementService struct {
+	mock.Mock
+}
+
+func (m *MockStatementService) Generate(creatorID uint, month time.Time) (*salesmodel.MonthlyStatement, error) {
+	args := m.Called(creatorID, month)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*salesmodel.MonthlyStatement), args.Error(1)
+}
+
+func (m *MockStatementService) RenderPDF(creator *accountmodel.Creator, statement *salesmodel.MonthlyStatement) ([]byte, error) {
+	args := m.Called(creator, statement)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *MockStatementService) SendMonthlyStatements(now time.Time) (int, error) {
+	args := m.Called(now)
+	return args.Int(0), args.Error(1)
+}
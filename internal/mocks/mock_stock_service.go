@@ -0,0 +1,21 @@
+package mocks
+
+import (
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockStockService struct {
+	mock.Mock
+}
+
+func (m *MockStockService) ReserveForPurchase(ebook *librarymodel.Ebook, purchase *salesmodel.Purchase) error {
+	args := m.Called(ebook, purchase)
+	return args.Error(0)
+}
+
+func (m *MockStockService) ReleaseForPurchase(purchaseID uint) error {
+	args := m.Called(purchaseID)
+	return args.Error(0)
+}
@@ -1,6 +1,8 @@
 package mocks
 
 import (
+	"time"
+
 	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
 	"github.com/stretchr/testify/mock"
 )
@@ -58,3 +60,24 @@ func (m *MockTransactionRepository) UpdateTransactionStatus(id uint, status sale
 	args := m.Called(id, status)
 	return args.Error(0)
 }
+
+func (m *MockTransactionRepository) UpdateSplit(split *salesmodel.TransactionSplit) error {
+	args := m.Called(split)
+	return args.Error(0)
+}
+
+func (m *MockTransactionRepository) FindSplitsByCreatorID(creatorID uint, kind salesmodel.TransactionSplitKind, page, limit int) ([]*salesmodel.TransactionSplit, int64, error) {
+	args := m.Called(creatorID, kind, page, limit)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]*salesmodel.TransactionSplit), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockTransactionRepository) FindDueSplits(now time.Time) ([]*salesmodel.TransactionSplit, error) {
+	args := m.Called(now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*salesmodel.TransactionSplit), args.Error(1)
+}
@@ -1,7 +1,10 @@
 package mocks
 
 import (
+	"time"
+
 	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -9,7 +12,7 @@ type MockTransactionService struct {
 	mock.Mock
 }
 
-func (m *MockTransactionService) CreateTransaction(purchase *salesmodel.Purchase, totalAmount int64) (*salesmodel.Transaction, error) {
+func (m *MockTransactionService) CreateTransaction(purchase *salesmodel.Purchase, totalAmount money.Money) (*salesmodel.Transaction, error) {
 	args := m.Called(purchase, totalAmount)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -65,3 +68,39 @@ func (m *MockTransactionService) UpdateTransactionToCompleted(purchaseID uint, s
 	args := m.Called(purchaseID, stripePaymentIntentID)
 	return args.Error(0)
 }
+
+func (m *MockTransactionService) RecordPaymentMethod(purchaseID uint, paymentMethod string) error {
+	args := m.Called(purchaseID, paymentMethod)
+	return args.Error(0)
+}
+
+func (m *MockTransactionService) UpdatePendingTransactionAmount(purchaseID uint, totalAmount money.Money) error {
+	args := m.Called(purchaseID, totalAmount)
+	return args.Error(0)
+}
+
+func (m *MockTransactionService) RepricePendingTransaction(purchaseID uint, totalAmount money.Money, plan *salesmodel.FeePlan) (*salesmodel.Transaction, error) {
+	args := m.Called(purchaseID, totalAmount, plan)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*salesmodel.Transaction), args.Error(1)
+}
+
+func (m *MockTransactionService) TransferSplits(purchaseID uint) error {
+	args := m.Called(purchaseID)
+	return args.Error(0)
+}
+
+func (m *MockTransactionService) TransferDueSplits(now time.Time) (int, error) {
+	args := m.Called(now)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTransactionService) GetSplitsByCreatorID(creatorID uint, kind salesmodel.TransactionSplitKind, page, limit int) ([]*salesmodel.TransactionSplit, int64, error) {
+	args := m.Called(creatorID, kind, page, limit)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]*salesmodel.TransactionSplit), args.Get(1).(int64), args.Error(2)
+}
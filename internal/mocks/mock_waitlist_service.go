@@ -0,0 +1,55 @@
+package mocks
+
+import (
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockWaitlistService struct {
+	mock.Mock
+}
+
+func (m *MockWaitlistService) Join(ebook *librarymodel.Ebook, name, email string) (bool, error) {
+	args := m.Called(ebook, name, email)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockWaitlistService) NotifyAll(ebook *librarymodel.Ebook) (int, error) {
+	args := m.Called(ebook)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockWaitlistService) RegisterClick(token string) (*salesmodel.WaitlistEntry, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*salesmodel.WaitlistEntry), args.Error(1)
+}
+
+func (m *MockWaitlistService) AttributePurchase(token string, ebookID, purchaseID uint) error {
+	args := m.Called(token, ebookID, purchaseID)
+	return args.Error(0)
+}
+
+func (m *MockWaitlistService) MarkConverted(purchaseID uint) error {
+	args := m.Called(purchaseID)
+	return args.Error(0)
+}
+
+func (m *MockWaitlistService) ListByEbookID(ebookID uint) ([]*salesmodel.WaitlistEntry, error) {
+	args := m.Called(ebookID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*salesmodel.WaitlistEntry), args.Error(1)
+}
+
+func (m *MockWaitlistService) GetStatsByEbookID(ebookID uint) (*salesmodel.WaitlistStats, error) {
+	args := m.Called(ebookID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*salesmodel.WaitlistStats), args.Error(1)
+}
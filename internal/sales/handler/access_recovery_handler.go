@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/gov"
+	"github.com/anglesson/simple-web-server/pkg/template"
+)
+
+const accessRecoveryURL = "/reenviar-acesso"
+
+// AccessRecoveryHandler atende a página pública em que o comprador pede o reenvio do acesso
+// informando CPF e email, sem precisar contatar o criador.
+type AccessRecoveryHandler struct {
+	templateRenderer          template.TemplateRenderer
+	resendDownloadLinkService salesvc.ResendDownloadLinkServiceInterface
+}
+
+func NewAccessRecoveryHandler(
+	templateRenderer template.TemplateRenderer,
+	resendDownloadLinkService salesvc.ResendDownloadLinkServiceInterface,
+) *AccessRecoveryHandler {
+	return &AccessRecoveryHandler{
+		templateRenderer:          templateRenderer,
+		resendDownloadLinkService: resendDownloadLinkService,
+	}
+}
+
+func (h *AccessRecoveryHandler) AccessRecoveryView(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	h.templateRenderer.View(w, r, "purchase/access-recovery", map[string]interface{}{
+		"Sent":        query.Get("status") == "sent",
+		"Invalid":     query.Get("status") == "invalid",
+		"RateLimited": query.Get("error") == "rate_limit_exceeded",
+	}, "guest")
+}
+
+// AccessRecoverySubmit sempre responde com a mesma mensagem quando os dados têm formato válido,
+// para não revelar se o CPF ou o email possuem compras.
+func (h *AccessRecoveryHandler) AccessRecoverySubmit(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Unable to parse form", http.StatusBadRequest)
+		return
+	}
+
+	cpf := strings.NewReplacer(".", "", "-", "", " ", "").Replace(r.FormValue("cpf"))
+	email := strings.TrimSpace(r.FormValue("email"))
+	if _, err := mail.ParseAddress(email); err != nil || !gov.IsValidCPF(cpf) {
+		http.Redirect(w, r, accessRecoveryURL+"?status=invalid", http.StatusSeeOther)
+		return
+	}
+
+	if err := h.resendDownloadLinkService.ResendAccessToBuyer(cpf, email); err != nil {
+		slog.Error("Erro ao reenviar acesso ao comprador", "error", err)
+	}
+
+	http.Redirect(w, r, accessRecoveryURL+"?status=sent", http.StatusSeeOther)
+}
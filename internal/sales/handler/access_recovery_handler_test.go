@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/anglesson/simple-web-server/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func postAccessRecovery(h *AccessRecoveryHandler, cpf, email string) *httptest.ResponseRecorder {
+	form := url.Values{"cpf": {cpf}, "email": {email}}
+	req := httptest.NewRequest(http.MethodPost, "/reenviar-acesso", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	h.AccessRecoverySubmit(rr, req)
+	return rr
+}
+
+func TestAccessRecoverySubmit_ResendsAccess(t *testing.T) {
+	resendService := &mocks.MockResendDownloadLinkService{}
+	resendService.On("ResendAccessToBuyer", "52998224725", "ana@test.com").Return(nil).Once()
+	h := &AccessRecoveryHandler{resendDownloadLinkService: resendService}
+
+	rr := postAccessRecovery(h, "529.982.247-25", "ana@test.com")
+
+	assert.Equal(t, http.StatusSeeOther, rr.Code)
+	assert.Equal(t, "/reenviar-acesso?status=sent", rr.Header().Get("Location"))
+	resendService.AssertExpectations(t)
+}
+
+func TestAccessRecoverySubmit_ErrorDoesNotRevealBuyer(t *testing.T) {
+	resendService := &mocks.MockResendDownloadLinkService{}
+	resendService.On("ResendAccessToBuyer", "52998224725", "ana@test.com").Return(errors.New("db fora do ar")).Once()
+	h := &AccessRecoveryHandler{resendDownloadLinkService: resendService}
+
+	rr := postAccessRecovery(h, "52998224725", "ana@test.com")
+
+	assert.Equal(t, "/reenviar-acesso?status=sent", rr.Header().Get("Location"))
+}
+
+func TestAccessRecoverySubmit_InvalidInput(t *testing.T) {
+	resendService := &mocks.MockResendDownloadLinkService{}
+	h := &AccessRecoveryHandler{resendDownloadLinkService: resendService}
+
+	rr := postAccessRecovery(h, "11111111111", "ana@test.com")
+	assert.Equal(t, "/reenviar-acesso?status=invalid", rr.Header().Get("Location"))
+
+	rr = postAccessRecovery(h, "52998224725", "invalido")
+	assert.Equal(t, "/reenviar-acesso?status=invalid", rr.Header().Get("Location"))
+
+	resendService.AssertNotCalled(t, "ResendAccessToBuyer", mock.Anything, mock.Anything)
+}
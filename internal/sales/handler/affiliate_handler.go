@@ -0,0 +1,301 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	accountsvc "github.com/anglesson/simple-web-server/internal/account/service"
+	authsvc "github.com/anglesson/simple-web-server/internal/auth/service"
+	"github.com/anglesson/simple-web-server/internal/config"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	librarysvc "github.com/anglesson/simple-web-server/internal/library/service"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	cookies "github.com/anglesson/simple-web-server/pkg/cookie"
+	"github.com/anglesson/simple-web-server/pkg/template"
+	"github.com/go-chi/chi/v5"
+)
+
+// affiliateRefCookie guarda o código do último afiliado cujo link trouxe o visitante
+const affiliateRefCookie = "affiliate_ref"
+
+type AffiliateHandler struct {
+	templateRenderer   template.TemplateRenderer
+	affiliateService   salesvc.AffiliateService
+	ebookService       librarysvc.EbookService
+	transactionService salesvc.TransactionService
+	sessionService     authsvc.SessionService
+	creatorService     accountsvc.CreatorService
+}
+
+func NewAffiliateHandler(
+	templateRenderer template.TemplateRenderer,
+	affiliateService salesvc.AffiliateService,
+	ebookService librarysvc.EbookService,
+	transactionService salesvc.TransactionService,
+	sessionService authsvc.SessionService,
+	creatorService accountsvc.CreatorService,
+) *AffiliateHandler {
+	return &AffiliateHandler{
+		templateRenderer:   templateRenderer,
+		affiliateService:   affiliateService,
+		ebookService:       ebookService,
+		transactionService: transactionService,
+		sessionService:     sessionService,
+		creatorService:     creatorService,
+	}
+}
+
+// TrackReferral registra o clique no link do afiliado (?aff=CODE) e guarda o código em cookie
+// pela janela configurada. O último link clicado é o que recebe a comissão.
+func (h *AffiliateHandler) TrackReferral(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if code := r.URL.Query().Get("aff"); code != "" {
+			affiliate, err := h.affiliateService.RegisterClick(code, chi.URLParam(r, "id"))
+			if err != nil {
+				slog.Warn("Link de afiliado inválido", "code", code, "error", err)
+			} else if window := config.AppConfig.AffiliateCookieWindow; window > 0 {
+				http.SetCookie(w, &http.Cookie{
+					Name:     affiliateRefCookie,
+					Value:    affiliate.Code,
+					Path:     "/",
+					MaxAge:   int(window.Seconds()),
+					HttpOnly: true,
+					SameSite: http.SameSiteLaxMode,
+				})
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// JoinView apresenta o programa de afiliados do ebook para o criador logado se cadastrar
+func (h *AffiliateHandler) JoinView(w http.ResponseWriter, r *http.Request) {
+	creator, ok := h.loggedCreator(w, r)
+	if !ok {
+		return
+	}
+
+	ebook, err := h.ebookService.FindByPublicID(chi.URLParam(r, "id"))
+	if err != nil || ebook == nil || !ebook.Status || !ebook.HasAffiliateProgram() {
+		http.Error(w, "Programa de afiliados não encontrado", http.StatusNotFound)
+		return
+	}
+
+	h.templateRenderer.View(w, r, "affiliate/join", map[string]interface{}{
+		"Ebook":      ebook,
+		"IsOwner":    ebook.CreatorID == creator.ID,
+		"CanReceive": creator.StripeConnectAccountID != "" && creator.OnboardingCompleted,
+		"CookieDays": int(config.AppConfig.AffiliateCookieWindow.Hours() / 24),
+		"HoldDays":   int(config.AppConfig.AffiliateHoldPeriod.Hours() / 24),
+	}, "admin-daisy")
+}
+
+// Join cadastra o criador logado como afiliado do ebook
+func (h *AffiliateHandler) Join(w http.ResponseWriter, r *http.Request) {
+	creator, ok := h.loggedCreator(w, r)
+	if !ok {
+		return
+	}
+
+	ebookPublicID := chi.URLParam(r, "id")
+	ebook, err := h.ebookService.FindByPublicID(ebookPublicID)
+	if err != nil || ebook == nil || !ebook.Status {
+		http.Error(w, "Ebook não encontrado", http.StatusNotFound)
+		return
+	}
+
+	if _, err := h.affiliateService.Join(ebook, creator); err != nil {
+		cookies.NotifyError(w, affiliateErrorMessage(err))
+		http.Redirect(w, r, "/affiliate/join/"+ebookPublicID, http.StatusSeeOther)
+		return
+	}
+
+	cookies.NotifySuccess(w, "Você agora é afiliado de "+ebook.Title+"! Copie seu link abaixo para divulgar.")
+	http.Redirect(w, r, "/affiliate", http.StatusSeeOther)
+}
+
+// AffiliateDashboard exibe ao afiliado seus links, cliques, vendas e comissões recebidas
+func (h *AffiliateHandler) AffiliateDashboard(w http.ResponseWriter, r *http.Request) {
+	creator, ok := h.loggedCreator(w, r)
+	if !ok {
+		return
+	}
+
+	affiliations, err := h.affiliateService.ListForCreator(creator.ID)
+	if err != nil {
+		slog.Error("Erro ao buscar afiliações", "creatorID", creator.ID, "error", err)
+		http.Error(w, "Erro ao buscar afiliações", http.StatusInternalServerError)
+		return
+	}
+
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit := 10
+
+	splits, total, err := h.transactionService.GetSplitsByCreatorID(creator.ID, salesmodel.TransactionSplitKindAffiliate, page, limit)
+	if err != nil {
+		slog.Error("Erro ao buscar comissões do afiliado", "creatorID", creator.ID, "error", err)
+		http.Error(w, "Erro ao buscar comissões", http.StatusInternalServerError)
+		return
+	}
+
+	pagination := salesmodel.NewPagination(page, limit)
+	pagination.SetTotal(total)
+
+	h.templateRenderer.View(w, r, "affiliate/dashboard", map[string]interface{}{
+		"Affiliations": affiliations,
+		"Splits":       splits,
+		"Pagination":   pagination,
+		"RecordType":   "comissões",
+		"Host":         fmt.Sprintf("%s:%s", config.AppConfig.Host, config.AppConfig.Port),
+		"CanReceive":   creator.StripeConnectAccountID != "" && creator.OnboardingCompleted,
+	}, "admin-daisy")
+}
+
+// EbookAffiliatesView exibe ao criador a comissão do ebook e os afiliados com seus resultados
+func (h *AffiliateHandler) EbookAffiliatesView(w http.ResponseWriter, r *http.Request) {
+	creator, ok := h.loggedCreator(w, r)
+	if !ok {
+		return
+	}
+	ebook, ok := h.findCreatorEbook(w, r, creator)
+	if !ok {
+		return
+	}
+
+	affiliates, err := h.affiliateService.ListByEbook(ebook.ID)
+	if err != nil {
+		slog.Error("Erro ao buscar afiliados", "ebookID", ebook.ID, "error", err)
+		http.Error(w, "Erro ao buscar afiliados", http.StatusInternalServerError)
+		return
+	}
+
+	h.templateRenderer.View(w, r, "ebook/affiliates", map[string]interface{}{
+		"Ebook":      ebook,
+		"Affiliates": affiliates,
+		"Commission": strings.TrimSuffix(ebook.GetAffiliateCommissionPercent(), "%"),
+		"CookieDays": int(config.AppConfig.AffiliateCookieWindow.Hours() / 24),
+		"HoldDays":   int(config.AppConfig.AffiliateHoldPeriod.Hours() / 24),
+	}, "admin-daisy")
+}
+
+// UpdateCommission define a comissão paga aos afiliados do ebook (0 desativa o programa)
+func (h *AffiliateHandler) UpdateCommission(w http.ResponseWriter, r *http.Request) {
+	creator, ok := h.loggedCreator(w, r)
+	if !ok {
+		return
+	}
+	ebook, ok := h.findCreatorEbook(w, r, creator)
+	if !ok {
+		return
+	}
+
+	affiliatesURL := "/ebook/" + ebook.PublicID + "/affiliates"
+
+	percent, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(r.FormValue("commission")), ",", ".", 1), 64)
+	if err != nil {
+		cookies.NotifyError(w, "Comissão inválida. Informe um percentual (ex: 30 ou 12,5)")
+		http.Redirect(w, r, affiliatesURL, http.StatusSeeOther)
+		return
+	}
+
+	if err := h.affiliateService.SetCommission(ebook, percent/100); err != nil {
+		cookies.NotifyError(w, affiliateErrorMessage(err))
+		http.Redirect(w, r, affiliatesURL, http.StatusSeeOther)
+		return
+	}
+
+	if ebook.HasAffiliateProgram() {
+		cookies.NotifySuccess(w, "Comissão de afiliados atualizada para "+ebook.GetAffiliateCommissionPercent())
+	} else {
+		cookies.NotifySuccess(w, "Programa de afiliados desativado")
+	}
+	http.Redirect(w, r, affiliatesURL, http.StatusSeeOther)
+}
+
+func (h *AffiliateHandler) BlockAffiliate(w http.ResponseWriter, r *http.Request) {
+	h.setBlocked(w, r, true)
+}
+
+func (h *AffiliateHandler) UnblockAffiliate(w http.ResponseWriter, r *http.Request) {
+	h.setBlocked(w, r, false)
+}
+
+// setBlocked bloqueia ou reativa um afiliado; bloqueado, o link dele deixa de gerar comissão
+func (h *AffiliateHandler) setBlocked(w http.ResponseWriter, r *http.Request, blocked bool) {
+	creator, ok := h.loggedCreator(w, r)
+	if !ok {
+		return
+	}
+	ebook, ok := h.findCreatorEbook(w, r, creator)
+	if !ok {
+		return
+	}
+
+	affiliatesURL := "/ebook/" + ebook.PublicID + "/affiliates"
+
+	if err := h.affiliateService.SetBlocked(ebook, chi.URLParam(r, "affiliateID"), blocked); err != nil {
+		cookies.NotifyError(w, affiliateErrorMessage(err))
+		http.Redirect(w, r, affiliatesURL, http.StatusSeeOther)
+		return
+	}
+
+	if blocked {
+		cookies.NotifySuccess(w, "Afiliado bloqueado. As próximas vendas pelo link dele não geram comissão.")
+	} else {
+		cookies.NotifySuccess(w, "Afiliado reativado")
+	}
+	http.Redirect(w, r, affiliatesURL, http.StatusSeeOther)
+}
+
+func (h *AffiliateHandler) loggedCreator(w http.ResponseWriter, r *http.Request) (*accountmodel.Creator, bool) {
+	userEmail, err := h.sessionService.GetUserEmailFromSession(r)
+	if err != nil {
+		slog.Error("Erro ao obter email da sessão", "error", err)
+		http.Error(w, "Sessão inválida", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	creator, err := h.creatorService.FindCreatorByEmail(userEmail)
+	if err != nil {
+		slog.Error("Erro ao buscar criador", "error", err)
+		http.Error(w, "Criador não encontrado", http.StatusNotFound)
+		return nil, false
+	}
+
+	return creator, true
+}
+
+func (h *AffiliateHandler) findCreatorEbook(w http.ResponseWriter, r *http.Request, creator *accountmodel.Creator) (*librarymodel.Ebook, bool) {
+	ebook, err := h.ebookService.FindByPublicID(chi.URLParam(r, "id"))
+	if err != nil || ebook == nil || ebook.CreatorID != creator.ID {
+		http.Error(w, "Ebook não encontrado", http.StatusNotFound)
+		return nil, false
+	}
+	return ebook, true
+}
+
+// affiliateErrorMessage traduz os erros de regra de negócio do programa de afiliados para a mensagem exibida
+func affiliateErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, salesvc.ErrAffiliateProgramDisabled),
+		errors.Is(err, salesvc.ErrAffiliateIsOwner),
+		errors.Is(err, salesvc.ErrAffiliateWithoutStripe),
+		errors.Is(err, salesvc.ErrAffiliateBlocked),
+		errors.Is(err, salesvc.ErrAffiliateNotFound),
+		errors.Is(err, salesvc.ErrAffiliateInvalidCommission):
+		msg := err.Error()
+		return strings.ToUpper(msg[:1]) + msg[1:]
+	default:
+		slog.Error("Erro no programa de afiliados", "error", err)
+		return "Erro ao processar a solicitação. Tente novamente."
+	}
+}
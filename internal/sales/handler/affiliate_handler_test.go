@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	"github.com/anglesson/simple-web-server/internal/config"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type mockAffiliateService struct {
+	mock.Mock
+}
+
+func (m *mockAffiliateService) Join(ebook *librarymodel.Ebook, creator *accountmodel.Creator) (*salesmodel.Affiliate, error) {
+	args := m.Called(ebook, creator)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*salesmodel.Affiliate), args.Error(1)
+}
+
+func (m *mockAffiliateService) RegisterClick(code, ebookPublicID string) (*salesmodel.Affiliate, error) {
+	args := m.Called(code, ebookPublicID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*salesmodel.Affiliate), args.Error(1)
+}
+
+func (m *mockAffiliateService) ResolveReferral(code string, ebook *librarymodel.Ebook, buyerEmail string) *salesmodel.Affiliate {
+	args := m.Called(code, ebook, buyerEmail)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*salesmodel.Affiliate)
+}
+
+func (m *mockAffiliateService) SetCommission(ebook *librarymodel.Ebook, commission float64) error {
+	return m.Called(ebook, commission).Error(0)
+}
+
+func (m *mockAffiliateService) SetBlocked(ebook *librarymodel.Ebook, publicID string, blocked bool) error {
+	return m.Called(ebook, publicID, blocked).Error(0)
+}
+
+func (m *mockAffiliateService) ListByEbook(ebookID uint) ([]*salesvc.AffiliateSummary, error) {
+	args := m.Called(ebookID)
+	return args.Get(0).([]*salesvc.AffiliateSummary), args.Error(1)
+}
+
+func (m *mockAffiliateService) ListForCreator(creatorID uint) ([]*salesvc.AffiliateSummary, error) {
+	args := m.Called(creatorID)
+	return args.Get(0).([]*salesvc.AffiliateSummary), args.Error(1)
+}
+
+func TestTrackReferral_SetsCookieForConfiguredWindow(t *testing.T) {
+	previous := config.AppConfig.AffiliateCookieWindow
+	config.AppConfig.AffiliateCookieWindow = 30 * 24 * time.Hour
+	defer func() { config.AppConfig.AffiliateCookieWindow = previous }()
+
+	affiliateService := &mockAffiliateService{}
+	affiliateService.On("RegisterClick", "ABC123", "ebk_1").Return(&salesmodel.Affiliate{Code: "ABC123"}, nil).Once()
+	affiliateService.On("RegisterClick", "INVALIDO", "ebk_1").Return(nil, salesvc.ErrAffiliateNotFound).Once()
+	h := &AffiliateHandler{affiliateService: affiliateService}
+
+	var served int
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { served++ })
+
+	rr := httptest.NewRecorder()
+	h.TrackReferral(next).ServeHTTP(rr, withURLParam(httptest.NewRequest(http.MethodGet, "/sales/ebk_1?aff=ABC123", nil), "id", "ebk_1"))
+
+	cookies := rr.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, affiliateRefCookie, cookies[0].Name)
+		assert.Equal(t, "ABC123", cookies[0].Value)
+		assert.Equal(t, 30*24*3600, cookies[0].MaxAge)
+	}
+
+	// Código inválido não grava cookie, mas a página de vendas abre normalmente
+	rr = httptest.NewRecorder()
+	h.TrackReferral(next).ServeHTTP(rr, withURLParam(httptest.NewRequest(http.MethodGet, "/sales/ebk_1?aff=INVALIDO", nil), "id", "ebk_1"))
+	assert.Empty(t, rr.Result().Cookies())
+
+	assert.Equal(t, 2, served)
+	affiliateService.AssertExpectations(t)
+}
+
+func TestAddAffiliateSplit_UsesReferralCookie(t *testing.T) {
+	ebook := &librarymodel.Ebook{Model: gorm.Model{ID: 1}, AffiliateCommission: 0.3}
+	affiliate := &salesmodel.Affiliate{Model: gorm.Model{ID: 5}, CreatorID: 9}
+	affiliateService := &mockAffiliateService{}
+	affiliateService.On("ResolveReferral", "ABC123", ebook, "ana@test.com").Return(affiliate).Once()
+	h := &CheckoutHandler{affiliateService: affiliateService}
+
+	transaction := salesmodel.NewTransaction(4, 2, salesmodel.SplitTypeFixedAmount)
+	req := httptest.NewRequest(http.MethodPost, "/purchase/ebook/checkout", nil)
+	h.addAffiliateSplit(req, transaction, ebook, "ana@test.com")
+	assert.Empty(t, transaction.Splits, "sem cookie não há comissão")
+
+	req.AddCookie(&http.Cookie{Name: affiliateRefCookie, Value: "ABC123"})
+	h.addAffiliateSplit(req, transaction, ebook, "ana@test.com")
+
+	if assert.Len(t, transaction.Splits, 1) {
+		assert.True(t, transaction.Splits[0].IsAffiliate())
+		assert.Equal(t, uint(9), transaction.Splits[0].CreatorID)
+		assert.Equal(t, uint(5), *transaction.Splits[0].AffiliateID)
+		assert.Equal(t, 0.3, transaction.Splits[0].Share)
+	}
+	affiliateService.AssertExpectations(t)
+}
@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	accountsvc "github.com/anglesson/simple-web-server/internal/account/service"
+	authsvc "github.com/anglesson/simple-web-server/internal/auth/service"
+	librarysvc "github.com/anglesson/simple-web-server/internal/library/service"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/template"
+	"github.com/go-chi/chi/v5"
+)
+
+// Públicos que o criador pode escolher no formulário do broadcast
+const (
+	broadcastAudienceAll     = "all"
+	broadcastAudienceEbook   = "ebook"
+	broadcastAudienceSegment = "segment"
+)
+
+// BroadcastHandler cuida dos emails em massa do criador e do descadastro público dos clientes
+type BroadcastHandler struct {
+	broadcastService salesvc.BroadcastService
+	crmService       salesvc.ClientCRMService
+	ebookService     librarysvc.EbookService
+	creatorService   accountsvc.CreatorService
+	sessionManager   authsvc.SessionService
+	templateRenderer template.TemplateRenderer
+}
+
+func NewBroadcastHandler(
+	broadcastService salesvc.BroadcastService,
+	crmService salesvc.ClientCRMService,
+	ebookService librarysvc.EbookService,
+	creatorService accountsvc.CreatorService,
+	sessionManager authsvc.SessionService,
+	templateRenderer template.TemplateRenderer,
+) *BroadcastHandler {
+	return &BroadcastHandler{
+		broadcastService: broadcastService,
+		crmService:       crmService,
+		ebookService:     ebookService,
+		creatorService:   creatorService,
+		sessionManager:   sessionManager,
+		templateRenderer: templateRenderer,
+	}
+}
+
+// BroadcastsView lista os emails enviados pelo criador com as estatísticas de entrega
+func (h *BroadcastHandler) BroadcastsView(w http.ResponseWriter, r *http.Request) {
+	creator, ok := loggedCreator(w, r, h.creatorService, h.sessionManager)
+	if !ok {
+		return
+	}
+
+	broadcasts, err := h.broadcastService.ListRecent(creator.ID)
+	if err != nil {
+		log.Printf("Erro ao buscar broadcasts: %v", err)
+	}
+
+	h.templateRenderer.View(w, r, "broadcast/list", map[string]any{
+		"Broadcasts": broadcasts,
+		"Success":    h.sessionManager.GetFlashes(w, r, "success"),
+		"Errors":     h.sessionManager.GetFlashes(w, r, "error"),
+	}, "admin-daisy")
+}
+
+// NewBroadcastView exibe o formulário do email. ?segment= e ?ebook= já deixam o público escolhido.
+func (h *BroadcastHandler) NewBroadcastView(w http.ResponseWriter, r *http.Request) {
+	creator, ok := loggedCreator(w, r, h.creatorService, h.sessionManager)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	form := map[string]string{"audience": broadcastAudienceAll}
+	if segmentID := query.Get("segment"); segmentID != "" {
+		form["audience"] = broadcastAudienceSegment
+		form["segment_id"] = segmentID
+	} else if ebookID := query.Get("ebook"); ebookID != "" {
+		form["audience"] = broadcastAudienceEbook
+		form["ebook_id"] = ebookID
+	}
+	h.renderComposer(w, r, creator, form, nil)
+}
+
+// CreateBroadcast coloca o email na fila de envio. Em caso de erro o formulário volta preenchido.
+func (h *BroadcastHandler) CreateBroadcast(w http.ResponseWriter, r *http.Request) {
+	creator, ok := loggedCreator(w, r, h.creatorService, h.sessionManager)
+	if !ok {
+		return
+	}
+
+	form := map[string]string{
+		"subject":    r.FormValue("subject"),
+		"body":       r.FormValue("body"),
+		"audience":   r.FormValue("audience"),
+		"ebook_id":   r.FormValue("ebook_id"),
+		"segment_id": r.FormValue("segment_id"),
+	}
+
+	audience, rules, err := h.parseAudience(creator, form)
+	if err != nil {
+		h.renderComposer(w, r, creator, form, err)
+		return
+	}
+
+	broadcast, err := h.broadcastService.Create(creator.ID, form["subject"], form["body"], audience, rules)
+	if err != nil {
+		h.renderComposer(w, r, creator, form, err)
+		return
+	}
+
+	h.sessionManager.AddFlash(w, r, "Email na fila de envio para "+pluralClients(broadcast.Recipients), "success")
+	http.Redirect(w, r, "/broadcasts/"+broadcast.PublicID, http.StatusSeeOther)
+}
+
+// BroadcastDetailView exibe o andamento do envio e a situação de cada destinatário
+func (h *BroadcastHandler) BroadcastDetailView(w http.ResponseWriter, r *http.Request) {
+	creator, ok := loggedCreator(w, r, h.creatorService, h.sessionManager)
+	if !ok {
+		return
+	}
+
+	broadcast, err := h.broadcastService.FindByPublicID(creator.ID, chi.URLParam(r, "id"))
+	if err != nil {
+		if !errors.Is(err, salesvc.ErrBroadcastNotFound) {
+			log.Printf("Erro ao buscar broadcast: %v", err)
+		}
+		h.sessionManager.AddFlash(w, r, "Email não encontrado", "error")
+		http.Redirect(w, r, "/broadcasts", http.StatusSeeOther)
+		return
+	}
+
+	deliveries, err := h.broadcastService.FindDeliveries(broadcast)
+	if err != nil {
+		log.Printf("Erro ao buscar destinatários do broadcast: %v", err)
+	}
+	unsubscribed, err := h.broadcastService.CountUnsubscribed(broadcast)
+	if err != nil {
+		log.Printf("Erro ao contar descadastros do broadcast: %v", err)
+	}
+
+	h.templateRenderer.View(w, r, "broadcast/detail", map[string]any{
+		"Broadcast":    broadcast,
+		"Deliveries":   deliveries,
+		"Unsubscribed": unsubscribed,
+		"Success":      h.sessionManager.GetFlashes(w, r, "success"),
+		"Errors":       h.sessionManager.GetFlashes(w, r, "error"),
+	}, "admin-daisy")
+}
+
+// UnsubscribeView confirma o descadastro aberto pelo link do rodapé. O descadastro só acontece no POST,
+// para que antivírus e pré-visualizações que abrem os links do email não descadastrem o cliente.
+func (h *BroadcastHandler) UnsubscribeView(w http.ResponseWriter, r *http.Request) {
+	broadcast, err := h.broadcastService.FindByUnsubscribeToken(chi.URLParam(r, "token"))
+	h.renderUnsubscribe(w, r, broadcast, err, false)
+}
+
+// Unsubscribe atende o botão da página e o descadastro em um clique dos clientes de email (RFC 8058)
+func (h *BroadcastHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	broadcast, err := h.broadcastService.Unsubscribe(chi.URLParam(r, "token"))
+	h.renderUnsubscribe(w, r, broadcast, err, err == nil)
+}
+
+func (h *BroadcastHandler) renderUnsubscribe(w http.ResponseWriter, r *http.Request, broadcast *salesmodel.Broadcast, err error, done bool) {
+	if err != nil {
+		if !errors.Is(err, salesvc.ErrUnsubscribeNotFound) {
+			log.Printf("Erro no descadastro de emails: %v", err)
+		}
+		w.WriteHeader(http.StatusNotFound)
+		h.templateRenderer.View(w, r, "broadcast/unsubscribe", map[string]any{"Invalid": true}, "guest")
+		return
+	}
+
+	h.templateRenderer.View(w, r, "broadcast/unsubscribe", map[string]any{
+		"Token":       chi.URLParam(r, "token"),
+		"CreatorName": broadcast.Creator.Name,
+		"Done":        done,
+	}, "guest")
+}
+
+// parseAudience traduz o público escolhido no formulário para as regras de segmento e a descrição exibida
+func (h *BroadcastHandler) parseAudience(creator *accountmodel.Creator, form map[string]string) (string, salesmodel.SegmentRules, error) {
+	switch form["audience"] {
+	case broadcastAudienceAll:
+		return "Todos os clientes", salesmodel.SegmentRules{}, nil
+	case broadcastAudienceEbook:
+		ebooks, err := h.ebookService.GetEbooksByCreatorID(creator.ID)
+		if err != nil {
+			return "", salesmodel.SegmentRules{}, err
+		}
+		ebook := findEbook(ebooks, form["ebook_id"])
+		if ebook == nil {
+			return "", salesmodel.SegmentRules{}, fmt.Errorf("%w: escolha o ebook cujos compradores vão receber o email", salesvc.ErrBroadcastInvalid)
+		}
+		return "Compradores de " + ebook.Title, salesmodel.SegmentRules{PurchasedEbookIDs: []uint{ebook.ID}}, nil
+	case broadcastAudienceSegment:
+		segment, err := h.crmService.FindSegment(creator.ID, form["segment_id"])
+		if err != nil {
+			return "", salesmodel.SegmentRules{}, fmt.Errorf("%w: escolha o segmento que vai receber o email", salesvc.ErrBroadcastInvalid)
+		}
+		return "Segmento " + segment.Name, segment.Rules, nil
+	default:
+		return "", salesmodel.SegmentRules{}, fmt.Errorf("%w: escolha quem vai receber o email", salesvc.ErrBroadcastInvalid)
+	}
+}
+
+func (h *BroadcastHandler) renderComposer(w http.ResponseWriter, r *http.Request, creator *accountmodel.Creator, form map[string]string, formErr error) {
+	ebooks, err := h.ebookService.GetEbooksByCreatorID(creator.ID)
+	if err != nil {
+		log.Printf("Erro ao buscar ebooks do criador: %v", err)
+	}
+	segments, err := h.crmService.ListSegments(creator.ID)
+	if err != nil {
+		log.Printf("Erro ao buscar segmentos: %v", err)
+	}
+
+	var errorsList []string
+	if formErr != nil {
+		message := formErr.Error()
+		if !errors.Is(formErr, salesvc.ErrBroadcastInvalid) && !errors.Is(formErr, salesvc.ErrBroadcastNoRecipient) {
+			log.Printf("Erro ao criar broadcast: %v", formErr)
+			message = "Não foi possível enviar o email. Tente novamente."
+		}
+		errorsList = append(errorsList, message)
+	}
+
+	h.templateRenderer.View(w, r, "broadcast/new", map[string]any{
+		"Form":     form,
+		"Ebooks":   ebooks,
+		"Segments": segments,
+		"Errors":   errorsList,
+	}, "admin-daisy")
+}
+
+func pluralClients(count int) string {
+	if count == 1 {
+		return "1 cliente"
+	}
+	return strconv.Itoa(count) + " clientes"
+}
@@ -2,12 +2,15 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
 	accountsvc "github.com/anglesson/simple-web-server/internal/account/service"
 	"github.com/anglesson/simple-web-server/internal/config"
 	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
@@ -16,22 +19,59 @@ import (
 	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
 	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
 	"github.com/anglesson/simple-web-server/pkg/gov"
+	"github.com/anglesson/simple-web-server/pkg/middleware"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/anglesson/simple-web-server/pkg/template"
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"github.com/stripe/stripe-go/v76"
 	"github.com/stripe/stripe-go/v76/checkout/session"
 )
 
+// checkoutDeviceCookie identifica o navegador do comprador nas verificações de velocidade do checkout
+const checkoutDeviceCookie = "checkout_device"
+
+// stockReservationTTL é o prazo da sessão de checkout de uma oferta limitada (mínimo de 30 minutos no Stripe)
+const stockReservationTTL = 31 * time.Minute
+
+// customerRequest são os dados do comprador enviados pelo formulário de checkout.
+// Compradores estrangeiros não têm CPF e informam documento (passaporte ou
+// documento fiscal) e o país de emissão.
+type customerRequest struct {
+	Name      string `json:"name"`
+	CPF       string `json:"cpf"`
+	Birthdate string `json:"birthdate"`
+	Email     string `json:"email"`
+	Phone     string `json:"phone"`
+	BuyerType string `json:"buyerType"`
+	Country   string `json:"country"`
+	Document  string `json:"document"`
+	EbookID   string `json:"ebookId"`
+	Currency  string `json:"currency"`
+	CSRFToken string `json:"csrfToken"`
+}
+
+func (c customerRequest) isForeign() bool {
+	return salesmodel.BuyerType(c.BuyerType) == salesmodel.BuyerTypeForeign
+}
+
 type CheckoutHandler struct {
-	templateRenderer   template.TemplateRenderer
-	ebookService       librarysvc.EbookService
-	clientService      salesvc.ClientService
-	clientRepo         salesrepo.ClientRepository
-	creatorService     accountsvc.CreatorService
-	rfService          gov.ReceitaFederalService
-	emailService       salesvc.IEmailService
-	transactionService salesvc.TransactionService
-	purchaseService    salesvc.PurchaseService
+	templateRenderer       template.TemplateRenderer
+	ebookService           librarysvc.EbookService
+	clientService          salesvc.ClientService
+	clientRepo             salesrepo.ClientRepository
+	creatorService         accountsvc.CreatorService
+	cpfVerificationService salesvc.CPFVerificationService
+	emailService           salesvc.IEmailService
+	transactionService     salesvc.TransactionService
+	purchaseService        salesvc.PurchaseService
+	recoveryService        salesvc.CheckoutRecoveryService
+	stockService           salesvc.StockService
+	waitlistService        salesvc.WaitlistService
+	fraudService           salesvc.FraudService
+	feePlanService         salesvc.FeePlanService
+	coAuthorService        librarysvc.CoAuthorService
+	affiliateService       salesvc.AffiliateService
 }
 
 func NewCheckoutHandler(
@@ -40,21 +80,35 @@ func NewCheckoutHandler(
 	clientService salesvc.ClientService,
 	clientRepo salesrepo.ClientRepository,
 	creatorService accountsvc.CreatorService,
-	rfService gov.ReceitaFederalService,
+	cpfVerificationService salesvc.CPFVerificationService,
 	emailService salesvc.IEmailService,
 	transactionService salesvc.TransactionService,
 	purchaseService salesvc.PurchaseService,
+	recoveryService salesvc.CheckoutRecoveryService,
+	stockService salesvc.StockService,
+	waitlistService salesvc.WaitlistService,
+	fraudService salesvc.FraudService,
+	feePlanService salesvc.FeePlanService,
+	coAuthorService librarysvc.CoAuthorService,
+	affiliateService salesvc.AffiliateService,
 ) *CheckoutHandler {
 	return &CheckoutHandler{
-		templateRenderer:   templateRenderer,
-		ebookService:       ebookService,
-		clientService:      clientService,
-		clientRepo:         clientRepo,
-		creatorService:     creatorService,
-		rfService:          rfService,
-		emailService:       emailService,
-		transactionService: transactionService,
-		purchaseService:    purchaseService,
+		templateRenderer:       templateRenderer,
+		ebookService:           ebookService,
+		clientService:          clientService,
+		clientRepo:             clientRepo,
+		creatorService:         creatorService,
+		cpfVerificationService: cpfVerificationService,
+		emailService:           emailService,
+		transactionService:     transactionService,
+		purchaseService:        purchaseService,
+		recoveryService:        recoveryService,
+		stockService:           stockService,
+		waitlistService:        waitlistService,
+		fraudService:           fraudService,
+		feePlanService:         feePlanService,
+		coAuthorService:        coAuthorService,
+		affiliateService:       affiliateService,
 	}
 }
 
@@ -79,6 +133,10 @@ func (h *CheckoutHandler) CheckoutView(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if !ebook.Status {
+		if ebook.WaitlistEnabled {
+			http.Redirect(w, r, "/sales/"+ebook.PublicID, http.StatusSeeOther)
+			return
+		}
 		http.Error(w, "Ebook não disponível", http.StatusNotFound)
 		return
 	}
@@ -90,9 +148,24 @@ func (h *CheckoutHandler) CheckoutView(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if _, err := r.Cookie(checkoutDeviceCookie); err != nil {
+		http.SetCookie(w, &http.Cookie{
+			Name:     checkoutDeviceCookie,
+			Value:    strings.ReplaceAll(uuid.NewString(), "-", ""),
+			Path:     "/",
+			MaxAge:   365 * 86400,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+
+	price, _ := ebook.PriceIn(ebook.ResolveCurrency(r.URL.Query().Get("currency"), money.CurrencyForLocale(r.Header.Get("Accept-Language"))))
+
 	data := map[string]any{
-		"Ebook":   ebook,
-		"Creator": creator,
+		"Ebook":       ebook,
+		"Creator":     creator,
+		"Unavailable": !ebook.IsAvailableForSale(),
+		"Price":       price,
 	}
 
 	h.templateRenderer.View(w, r, "purchase/checkout", data, "guest")
@@ -102,15 +175,7 @@ func (h *CheckoutHandler) CheckoutView(w http.ResponseWriter, r *http.Request) {
 func (h *CheckoutHandler) ValidateCustomer(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	var request struct {
-		Name      string `json:"name"`
-		CPF       string `json:"cpf"`
-		Birthdate string `json:"birthdate"`
-		Email     string `json:"email"`
-		Phone     string `json:"phone"`
-		EbookID   string `json:"ebookId"`
-		CSRFToken string `json:"csrfToken"`
-	}
+	var request customerRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		log.Printf("Erro ao decodificar requisição: %v", err)
@@ -122,38 +187,11 @@ func (h *CheckoutHandler) ValidateCustomer(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if request.Name == "" || request.CPF == "" || request.Birthdate == "" || request.Email == "" || request.Phone == "" {
+	if message := validateCustomerFields(request); message != "" {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]any{
 			"success": false,
-			"error":   "Todos os campos são obrigatórios",
-		})
-		return
-	}
-
-	if len(request.CPF) != 11 {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]any{
-			"success": false,
-			"error":   "CPF inválido",
-		})
-		return
-	}
-
-	if !isValidEmail(request.Email) {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]any{
-			"success": false,
-			"error":   "E-mail inválido",
-		})
-		return
-	}
-
-	if len(request.Phone) != 11 {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]any{
-			"success": false,
-			"error":   "Telefone inválido",
+			"error":   message,
 		})
 		return
 	}
@@ -177,7 +215,12 @@ func (h *CheckoutHandler) ValidateCustomer(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	existingClient, err := h.clientRepo.FindByCPF(request.CPF)
+	if err := salesvc.AvailabilityError(ebook.Availability(time.Now())); err != nil {
+		writeUnavailableResponse(w, err)
+		return
+	}
+
+	existingClient, err := h.findExistingClient(request)
 	if err == nil && existingClient != nil {
 		existingPurchase, err := h.purchaseService.FindExistingPurchase(ebook.ID, existingClient.ID)
 		if err == nil && existingPurchase != nil {
@@ -193,7 +236,7 @@ func (h *CheckoutHandler) ValidateCustomer(w http.ResponseWriter, r *http.Reques
 				json.NewEncoder(w).Encode(map[string]any{
 					"success":           false,
 					"already_purchased": true,
-					"error":             "Você já adquiriu este ebook com o CPF informado.",
+					"error":             fmt.Sprintf("Você já adquiriu este ebook com o %s informado.", identityLabel(request)),
 					"creator_email":     creatorEmail,
 					"creator_name":      creatorName,
 				})
@@ -210,8 +253,14 @@ func (h *CheckoutHandler) ValidateCustomer(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
-	if h.rfService != nil && config.AppConfig.IsProduction() {
-		response, err := h.rfService.ConsultaCPF(request.Name, request.CPF, request.Birthdate)
+	if attempt := h.assessRisk(r, request, ebook.CreatorID, ebook.ID, salesmodel.CheckoutStageValidate); attempt != nil && attempt.IsBlocked() {
+		writeBlockedResponse(w)
+		return
+	}
+
+	// Estrangeiros não possuem cadastro na Receita Federal
+	if h.cpfVerificationService != nil && config.AppConfig.IsProduction() && !request.isForeign() {
+		verification, err := h.cpfVerificationService.Verify(ebook.CreatorID, request.Name, request.CPF, request.Birthdate)
 		if err != nil {
 			log.Printf("Erro na consulta da Receita Federal: %v", err)
 			w.WriteHeader(http.StatusInternalServerError)
@@ -222,22 +271,25 @@ func (h *CheckoutHandler) ValidateCustomer(w http.ResponseWriter, r *http.Reques
 			return
 		}
 
-		if !response.Status {
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(map[string]any{
-				"success": false,
-				"error":   "Dados não conferem com a Receita Federal",
-			})
-			return
-		}
+		// Com o provedor fora do ar a consulta fica na fila e a venda segue com a validação local
+		if !verification.IsPending() {
+			if !verification.IsVerified() {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]any{
+					"success": false,
+					"error":   "Dados não conferem com a Receita Federal",
+				})
+				return
+			}
 
-		if !isNameSimilar(request.Name, response.Result.NomeDaPF) {
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(map[string]any{
-				"success": false,
-				"error":   "Nome não confere com os dados da Receita Federal",
-			})
-			return
+			if !isNameSimilar(request.Name, verification.RegisteredName) {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]any{
+					"success": false,
+					"error":   "Nome não confere com os dados da Receita Federal",
+				})
+				return
+			}
 		}
 	}
 
@@ -253,15 +305,7 @@ func (h *CheckoutHandler) CreateEbookCheckout(w http.ResponseWriter, r *http.Req
 
 	stripe.Key = config.AppConfig.StripeSecretKey
 
-	var request struct {
-		Name      string `json:"name"`
-		CPF       string `json:"cpf"`
-		Birthdate string `json:"birthdate"`
-		Email     string `json:"email"`
-		Phone     string `json:"phone"`
-		EbookID   string `json:"ebookId"`
-		CSRFToken string `json:"csrfToken"`
-	}
+	var request customerRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		log.Printf("Erro ao decodificar requisição: %v", err)
@@ -292,6 +336,11 @@ func (h *CheckoutHandler) CreateEbookCheckout(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	if err := salesvc.AvailabilityError(ebook.Availability(time.Now())); err != nil {
+		writeUnavailableResponse(w, err)
+		return
+	}
+
 	creator, err := h.creatorService.FindByID(ebook.CreatorID)
 	if err != nil {
 		log.Printf("Erro ao buscar criador: %v", err)
@@ -303,6 +352,18 @@ func (h *CheckoutHandler) CreateEbookCheckout(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// A moeda vem do seletor da página de vendas; sem preço nela o checkout segue em reais
+	price, _ := ebook.PriceIn(ebook.ResolveCurrency(request.Currency))
+	// Taxas do plano vigente do criador no momento da venda
+	feePlan := h.feePlanService.EffectivePlan(creator, price)
+	var splitsAmount int64
+
+	attempt := h.assessRisk(r, request, creator.ID, ebook.ID, salesmodel.CheckoutStageCheckout)
+	if attempt != nil && attempt.IsBlocked() {
+		writeBlockedResponse(w)
+		return
+	}
+
 	client, err := h.createOrFindClient(request)
 	if err != nil {
 		log.Printf("Erro ao criar/buscar cliente: %v", err)
@@ -326,90 +387,49 @@ func (h *CheckoutHandler) CreateEbookCheckout(w http.ResponseWriter, r *http.Req
 	}
 
 	if purchase != nil {
-		log.Printf("Purchase processada com sucesso: ID=%d para EbookID=%d, ClientID=%d", purchase.ID, ebook.ID, client.ID)
-
-		existingTransaction, _ := h.transactionService.FindTransactionByPurchaseID(purchase.ID)
-		if existingTransaction == nil {
-			transaction := salesmodel.NewTransaction(purchase.ID, creator.ID, salesmodel.SplitTypeFixedAmount)
-			transaction.PlatformPercentage = config.Business.PlatformFeePercentage
-			transaction.CalculateSplit(int64(ebook.GetFinalValue() * 100))
-			transaction.Status = salesmodel.TransactionStatusPending
-
-			err = h.transactionService.CreateDirectTransaction(transaction)
-			if err != nil {
-				log.Printf("Erro ao criar transação pendente: %v", err)
-			} else {
-				log.Printf("Transação pendente criada com sucesso: ID=%d, PurchaseID=%d", transaction.ID, purchase.ID)
+		if err := h.stockService.ReserveForPurchase(ebook, purchase); err != nil {
+			if isAvailabilityError(err) {
+				writeUnavailableResponse(w, err)
+				return
 			}
-		} else {
-			log.Printf("Transação já existe para PurchaseID=%d: ID=%d", purchase.ID, existingTransaction.ID)
+			log.Printf("Erro ao reservar estoque para purchase_id=%d: %v", purchase.ID, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]any{
+				"success": false,
+				"error":   "Erro ao processar compra",
+			})
+			return
 		}
-	}
-
-	host := fmt.Sprintf("%s:%s", config.AppConfig.Host, config.AppConfig.Port)
-
-	params := &stripe.CheckoutSessionParams{
-		Mode: stripe.String(string(stripe.CheckoutSessionModePayment)),
-		LineItems: []*stripe.CheckoutSessionLineItemParams{
-			{
-				PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
-					Currency: stripe.String(string(stripe.CurrencyBRL)),
-					ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
-						Name:        stripe.String(ebook.Title),
-						Description: stripe.String(ebook.Description),
-					},
-					UnitAmount: stripe.Int64(int64(ebook.GetFinalValue() * 100)),
-				},
-				Quantity: stripe.Int64(1),
-			},
-		},
-		SuccessURL:    stripe.String(host + "/purchase/success?session_id={CHECKOUT_SESSION_ID}&creator_id=" + strconv.FormatUint(uint64(creator.ID), 10)),
-		CancelURL:     stripe.String(host + "/checkout/" + ebook.PublicID),
-		CustomerEmail: stripe.String(request.Email),
-		Metadata: map[string]string{
-			"ebook_id":        strconv.FormatUint(uint64(ebook.ID), 10),
-			"client_id":       strconv.FormatUint(uint64(client.ID), 10),
-			"creator_id":      strconv.FormatUint(uint64(creator.ID), 10),
-			"client_name":     request.Name,
-			"client_cpf":      request.CPF,
-			"ebook_title":     ebook.Title,
-			"ebook_price":     strconv.FormatFloat(ebook.GetFinalValue(), 'f', 2, 64),
-			"payment_version": "2.0",
-		},
-	}
-
-	if purchase != nil && purchase.ID > 0 {
-		params.Metadata["purchase_id"] = strconv.FormatUint(uint64(purchase.ID), 10)
-	}
-
-	if creator.StripeConnectAccountID != "" && creator.OnboardingCompleted && creator.ChargesEnabled {
-		log.Printf("Criador tem conta Stripe Connect habilitada: ID=%d, Nome=%s, Conta=%s",
-			creator.ID, creator.Name, creator.StripeConnectAccountID)
 
-		platformFeeAmount := config.Business.GetPlatformFeeAmount(int64(ebook.GetFinalValue() * 100))
-		creatorAmount := int64(ebook.GetFinalValue()*100) - platformFeeAmount
+		log.Printf("Purchase processada com sucesso: ID=%d para EbookID=%d, ClientID=%d", purchase.ID, ebook.ID, client.ID)
 
-		log.Printf("Divisão do pagamento: Total=%d centavos | Plataforma=%d centavos | Criador=%d centavos",
-			int64(ebook.GetFinalValue()*100), platformFeeAmount, creatorAmount)
+		// Checkout de risco intermediário: o pagamento segue, mas a entrega aguarda a revisão do criador
+		if attempt != nil && attempt.NeedsReview() {
+			if err := h.fraudService.HoldForReview(attempt, purchase.ID); err != nil {
+				log.Printf("Erro ao reter purchase_id=%d para revisão: %v", purchase.ID, err)
+			}
+		}
 
-		params.PaymentIntentData = &stripe.CheckoutSessionPaymentIntentDataParams{
-			ApplicationFeeAmount: stripe.Int64(platformFeeAmount),
-			Metadata: map[string]string{
-				"fee_percent":     config.Business.PlatformFeePercentageDisplay,
-				"payment_type":    "direct_to_creator",
-				"creator_account": creator.StripeConnectAccountID,
-				"platform_fee":    strconv.FormatInt(platformFeeAmount, 10),
-				"creator_amount":  strconv.FormatInt(creatorAmount, 10),
-			},
+		// Compra iniciada pelo link rastreado do aviso da lista de espera
+		if ref, err := r.Cookie(waitlistRefCookie); err == nil && ref.Value != "" {
+			if err := h.waitlistService.AttributePurchase(ref.Value, ebook.ID, purchase.ID); err != nil {
+				log.Printf("Erro ao atribuir compra %d à lista de espera: %v", purchase.ID, err)
+			}
 		}
-	} else {
-		log.Printf("Criador não tem conta Stripe Connect habilitada: ID=%d, Nome=%s, Conta=%s, OnboardingCompleted=%t, ChargesEnabled=%t",
-			creator.ID, creator.Name, creator.StripeConnectAccountID, creator.OnboardingCompleted, creator.ChargesEnabled)
 
-		params.Metadata["payment_type"] = "platform_only"
+		splitsAmount, err = h.preparePendingTransaction(r, purchase, creator, ebook, request.Email, price, feePlan)
+		if err != nil {
+			log.Printf("Erro ao recalcular transação pendente da purchase_id=%d: %v", purchase.ID, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]any{
+				"success": false,
+				"error":   "Erro ao processar compra",
+			})
+			return
+		}
 	}
 
-	params.SetStripeAccount(creator.StripeConnectAccountID)
+	params := h.buildEbookCheckoutSessionParams(ebook, client, creator, purchase, price, feePlan, splitsAmount)
 	s, err := session.New(params)
 	if err != nil {
 		log.Printf("Erro ao criar sessão do Stripe: %v", err)
@@ -420,6 +440,7 @@ func (h *CheckoutHandler) CreateEbookCheckout(w http.ResponseWriter, r *http.Req
 		})
 		return
 	}
+	h.trackCheckoutSession(purchase.ID, s.ID)
 
 	json.NewEncoder(w).Encode(map[string]any{
 		"success": true,
@@ -427,6 +448,40 @@ func (h *CheckoutHandler) CreateEbookCheckout(w http.ResponseWriter, r *http.Req
 	})
 }
 
+// preparePendingTransaction cria a transação pendente da compra e retorna o total dos repasses.
+// Quando o comprador volta ao checkout em outra moeda ou o preço mudou, a transação existente
+// é recalculada para o total atual, senão os repasses da sessão seguiriam os valores antigos.
+func (h *CheckoutHandler) preparePendingTransaction(r *http.Request, purchase *salesmodel.Purchase, creator *accountmodel.Creator, ebook *librarymodel.Ebook, buyerEmail string, price money.Money, feePlan *salesmodel.FeePlan) (int64, error) {
+	existingTransaction, _ := h.transactionService.FindTransactionByPurchaseID(purchase.ID)
+	if existingTransaction == nil {
+		transaction := salesmodel.NewTransaction(purchase.ID, creator.ID, salesmodel.SplitTypeFixedAmount)
+		transaction.ApplyFeePlan(feePlan)
+		h.addAffiliateSplit(r, transaction, ebook, buyerEmail)
+		h.addCoAuthorSplits(transaction, ebook)
+		transaction.CalculateSplit(price)
+		transaction.Status = salesmodel.TransactionStatusPending
+
+		if err := h.transactionService.CreateDirectTransaction(transaction); err != nil {
+			log.Printf("Erro ao criar transação pendente: %v", err)
+		} else {
+			log.Printf("Transação pendente criada com sucesso: ID=%d, PurchaseID=%d", transaction.ID, purchase.ID)
+		}
+		return transaction.SplitsAmount(), nil
+	}
+
+	log.Printf("Transação já existe para PurchaseID=%d: ID=%d", purchase.ID, existingTransaction.ID)
+	if existingTransaction.Status == salesmodel.TransactionStatusPending && existingTransaction.ChargesDifferently(price) {
+		log.Printf("Recalculando transação ID=%d: %s %d -> %s %d", existingTransaction.ID,
+			existingTransaction.Currency, existingTransaction.TotalAmount, price.CurrencyOrDefault(), price.Amount)
+		repriced, err := h.transactionService.RepricePendingTransaction(purchase.ID, price, feePlan)
+		if err != nil {
+			return 0, err
+		}
+		existingTransaction = repriced
+	}
+	return existingTransaction.SplitsAmount(), nil
+}
+
 // PurchaseSuccessView exibe a página de sucesso da compra
 func (h *CheckoutHandler) PurchaseSuccessView(w http.ResponseWriter, r *http.Request) {
 	sessionID := r.URL.Query().Get("session_id")
@@ -510,6 +565,7 @@ func (h *CheckoutHandler) PurchaseSuccessView(w http.ResponseWriter, r *http.Req
 
 	data := map[string]any{
 		"Ebook":         ebook,
+		"AmountPaid":    money.New(s.AmountTotal, string(s.Currency)),
 		"CustomerEmail": client.Email,
 		"CreatorEmail":  creator.Email,
 		"Purchase":      purchase,
@@ -519,19 +575,300 @@ func (h *CheckoutHandler) PurchaseSuccessView(w http.ResponseWriter, r *http.Req
 	h.templateRenderer.View(w, r, "purchase/purchase-success", data, "guest")
 }
 
+// buildEbookCheckoutSessionParams monta os parâmetros da sessão de checkout do Stripe para a compra de um ebook
+// Os repasses a coautores e afiliados entram na taxa de aplicação para ficar na conta principal e ser transferidos depois do pagamento.
+func (h *CheckoutHandler) buildEbookCheckoutSessionParams(ebook *librarymodel.Ebook, client *salesmodel.Client, creator *accountmodel.Creator, purchase *salesmodel.Purchase, totalAmount money.Money, feePlan *salesmodel.FeePlan, splitsAmount int64) *stripe.CheckoutSessionParams {
+	host := fmt.Sprintf("%s:%s", config.AppConfig.Host, config.AppConfig.Port)
+
+	params := &stripe.CheckoutSessionParams{
+		Mode: stripe.String(string(stripe.CheckoutSessionModePayment)),
+		LineItems: []*stripe.CheckoutSessionLineItemParams{
+			{
+				PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+					Currency: stripe.String(strings.ToLower(totalAmount.CurrencyOrDefault())),
+					ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
+						Name:        stripe.String(ebook.Title),
+						Description: stripe.String(ebook.Description),
+					},
+					UnitAmount: stripe.Int64(totalAmount.Amount),
+				},
+				Quantity: stripe.Int64(1),
+			},
+		},
+		SuccessURL:    stripe.String(host + "/purchase/success?session_id={CHECKOUT_SESSION_ID}&creator_id=" + strconv.FormatUint(uint64(creator.ID), 10)),
+		CancelURL:     stripe.String(host + "/checkout/" + ebook.PublicID),
+		CustomerEmail: stripe.String(client.Email),
+		Metadata: map[string]string{
+			"ebook_id":        strconv.FormatUint(uint64(ebook.ID), 10),
+			"client_id":       strconv.FormatUint(uint64(client.ID), 10),
+			"creator_id":      strconv.FormatUint(uint64(creator.ID), 10),
+			"client_name":     client.Name,
+			"client_cpf":      client.CPF,
+			"ebook_title":     ebook.Title,
+			"ebook_price":     strconv.FormatFloat(totalAmount.Float(), 'f', 2, 64),
+			"currency":        totalAmount.CurrencyOrDefault(),
+			"payment_version": "2.0",
+		},
+	}
+
+	if client.IsForeign() {
+		params.Metadata["client_document"] = client.IdentityDocument()
+	}
+
+	if purchase != nil && purchase.ID > 0 {
+		params.Metadata["purchase_id"] = strconv.FormatUint(uint64(purchase.ID), 10)
+	}
+
+	// O payment intent leva a compra para registrar pagamentos recusados (payment_intent.payment_failed)
+	paymentIntentMetadata := map[string]string{
+		"ebook_id":  params.Metadata["ebook_id"],
+		"client_id": params.Metadata["client_id"],
+	}
+	if purchaseID, ok := params.Metadata["purchase_id"]; ok {
+		paymentIntentMetadata["purchase_id"] = purchaseID
+	}
+
+	// Em ofertas limitadas a unidade fica reservada até a sessão expirar,
+	// então usamos o menor prazo aceito pelo Stripe para devolvê-la logo ao estoque
+	if ebook.HasStockLimit() {
+		params.ExpiresAt = stripe.Int64(time.Now().Add(stockReservationTTL).Unix())
+	}
+
+	if creator.StripeConnectAccountID != "" && creator.OnboardingCompleted && creator.ChargesEnabled {
+		log.Printf("Criador tem conta Stripe Connect habilitada: ID=%d, Nome=%s, Conta=%s",
+			creator.ID, creator.Name, creator.StripeConnectAccountID)
+
+		platformFeeAmount := feePlan.FeeFor(totalAmount)
+		creatorAmount := totalAmount.Amount - platformFeeAmount - splitsAmount
+
+		log.Printf("Divisão do pagamento (%s): Total=%d centavos | Plataforma=%d centavos | Criador=%d centavos",
+			totalAmount.CurrencyOrDefault(), totalAmount.Amount, platformFeeAmount, creatorAmount)
+
+		paymentIntentMetadata["fee_percent"] = feePlan.Display()
+		paymentIntentMetadata["fee_plan"] = feePlan.Name
+		paymentIntentMetadata["payment_type"] = "direct_to_creator"
+		paymentIntentMetadata["creator_account"] = creator.StripeConnectAccountID
+		paymentIntentMetadata["platform_fee"] = strconv.FormatInt(platformFeeAmount, 10)
+		paymentIntentMetadata["creator_amount"] = strconv.FormatInt(creatorAmount, 10)
+
+		if splitsAmount > 0 {
+			paymentIntentMetadata["splits_amount"] = strconv.FormatInt(splitsAmount, 10)
+		}
+
+		params.PaymentIntentData = &stripe.CheckoutSessionPaymentIntentDataParams{
+			ApplicationFeeAmount: stripe.Int64(platformFeeAmount + splitsAmount),
+			Metadata:             paymentIntentMetadata,
+		}
+	} else {
+		log.Printf("Criador não tem conta Stripe Connect habilitada: ID=%d, Nome=%s, Conta=%s, OnboardingCompleted=%t, ChargesEnabled=%t",
+			creator.ID, creator.Name, creator.StripeConnectAccountID, creator.OnboardingCompleted, creator.ChargesEnabled)
+
+		params.Metadata["payment_type"] = "platform_only"
+		params.PaymentIntentData = &stripe.CheckoutSessionPaymentIntentDataParams{
+			Metadata: paymentIntentMetadata,
+		}
+	}
+
+	params.SetStripeAccount(creator.StripeConnectAccountID)
+	return params
+}
+
+// RecoverCheckout gera uma nova sessão de checkout a partir do link enviado no email de recuperação
+func (h *CheckoutHandler) RecoverCheckout(w http.ResponseWriter, r *http.Request) {
+	stripe.Key = config.AppConfig.StripeSecretKey
+
+	recovery, err := h.recoveryService.FindByToken(chi.URLParam(r, "token"))
+	if errors.Is(err, salesvc.ErrCheckoutRecoveryLinkExpired) {
+		http.Error(w, "Este link de recuperação expirou ou já foi usado", http.StatusGone)
+		return
+	}
+	if err != nil || recovery == nil {
+		log.Printf("Link de recuperação inválido: %v", err)
+		http.Error(w, "Link de recuperação inválido ou expirado", http.StatusNotFound)
+		return
+	}
+
+	purchase := &recovery.Purchase
+	ebook := &purchase.Ebook
+
+	if purchase.IsPaymentConfirmed() {
+		http.Redirect(w, r, "/purchase/download/"+purchase.HashID, http.StatusSeeOther)
+		return
+	}
+
+	if !ebook.Status {
+		http.Error(w, "Ebook não disponível", http.StatusNotFound)
+		return
+	}
+
+	if err := h.stockService.ReserveForPurchase(ebook, purchase); err != nil {
+		if isAvailabilityError(err) {
+			http.Redirect(w, r, "/sales/"+ebook.PublicID, http.StatusSeeOther)
+			return
+		}
+		log.Printf("Erro ao reservar estoque para purchase_id=%d: %v", purchase.ID, err)
+		http.Error(w, "Erro ao processar pagamento", http.StatusInternalServerError)
+		return
+	}
+
+	creator, err := h.creatorService.FindByID(ebook.CreatorID)
+	if err != nil || creator == nil {
+		log.Printf("Erro ao buscar criador do ebook: %v", err)
+		http.Error(w, "Erro interno do servidor", http.StatusInternalServerError)
+		return
+	}
+
+	// O checkout recuperado mantém a moeda escolhida na compra original
+	// e as taxas do plano registrado na transação pendente
+	currency := money.BRL
+	var feePlan *salesmodel.FeePlan
+	if pending, err := h.transactionService.FindTransactionByPurchaseID(purchase.ID); err == nil && pending != nil {
+		currency = ebook.ResolveCurrency(pending.Currency)
+		feePlan = pending.FeePlan()
+	}
+	totalAmount, _ := ebook.PriceIn(currency)
+	if feePlan == nil {
+		feePlan = h.feePlanService.EffectivePlan(creator, totalAmount)
+	}
+	var splitsAmount int64
+	if discount := h.recoveryService.DiscountPercent(); discount > 0 {
+		totalAmount = totalAmount.Mul(float64(100-discount) / 100)
+		if err := h.transactionService.UpdatePendingTransactionAmount(purchase.ID, totalAmount); err != nil {
+			log.Printf("Erro ao aplicar cupom de recuperação na transação da purchase_id=%d: %v", purchase.ID, err)
+			http.Error(w, "Erro ao processar pagamento", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Com o desconto os repasses são recalculados na transação pendente
+	if pending, err := h.transactionService.FindTransactionByPurchaseID(purchase.ID); err == nil && pending != nil {
+		splitsAmount = pending.SplitsAmount()
+	}
+
+	params := h.buildEbookCheckoutSessionParams(ebook, &purchase.Client, creator, purchase, totalAmount, feePlan, splitsAmount)
+	params.Metadata["recovery_id"] = strconv.FormatUint(uint64(recovery.ID), 10)
+
+	s, err := session.New(params)
+	if err != nil {
+		log.Printf("Erro ao criar sessão do Stripe para recuperação: %v", err)
+		http.Error(w, "Erro ao processar pagamento", http.StatusInternalServerError)
+		return
+	}
+	h.trackCheckoutSession(purchase.ID, s.ID)
+
+	http.Redirect(w, r, s.URL, http.StatusSeeOther)
+}
+
+// trackCheckoutSession marca a sessão recém-aberta como a ativa da compra, para que a expiração
+// de uma sessão anterior não devolva o estoque nem expire a compra
+func (h *CheckoutHandler) trackCheckoutSession(purchaseID uint, sessionID string) {
+	if err := h.purchaseService.SetCheckoutSession(purchaseID, sessionID); err != nil {
+		log.Printf("Erro ao registrar sessão de checkout da purchase_id=%d: %v", purchaseID, err)
+	}
+}
+
+// addAffiliateSplit inclui a comissão do afiliado cujo link trouxe o comprador dentro da janela do cookie
+func (h *CheckoutHandler) addAffiliateSplit(r *http.Request, transaction *salesmodel.Transaction, ebook *librarymodel.Ebook, buyerEmail string) {
+	if h.affiliateService == nil {
+		return
+	}
+
+	ref, err := r.Cookie(affiliateRefCookie)
+	if err != nil || ref.Value == "" {
+		return
+	}
+
+	if affiliate := h.affiliateService.ResolveReferral(ref.Value, ebook, buyerEmail); affiliate != nil {
+		transaction.AddAffiliateSplit(affiliate.CreatorID, affiliate.ID, ebook.AffiliateCommission)
+	}
+}
+
+// addCoAuthorSplits inclui na transação o repasse de cada coautor que aceitou o convite do ebook
+func (h *CheckoutHandler) addCoAuthorSplits(transaction *salesmodel.Transaction, ebook *librarymodel.Ebook) {
+	if h.coAuthorService == nil {
+		return
+	}
+
+	coAuthors, err := h.coAuthorService.ListAccepted(ebook.ID)
+	if err != nil {
+		log.Printf("Erro ao buscar coautores do ebook %d: %v", ebook.ID, err)
+		return
+	}
+
+	for _, coAuthor := range coAuthors {
+		transaction.AddSplit(coAuthor.CreatorID, coAuthor.Share)
+	}
+}
+
+// assessRisk pontua o risco da tentativa de checkout. Erros na avaliação não bloqueiam a venda.
+func (h *CheckoutHandler) assessRisk(r *http.Request, request customerRequest, creatorID, ebookID uint, stage salesmodel.CheckoutAttemptStage) *salesmodel.CheckoutAttempt {
+	if h.fraudService == nil {
+		return nil
+	}
+
+	input := salesvc.CheckoutRiskInput{
+		CreatorID: creatorID,
+		EbookID:   ebookID,
+		Stage:     stage,
+		Name:      request.Name,
+		Birthdate: request.Birthdate,
+		Email:     request.Email,
+		IP:        middleware.ClientIP(r),
+	}
+	if !request.isForeign() {
+		input.CPF = request.CPF
+	}
+	if device, err := r.Cookie(checkoutDeviceCookie); err == nil {
+		input.DeviceID = device.Value
+	}
+
+	attempt, err := h.fraudService.Assess(input)
+	if err != nil {
+		log.Printf("Erro na análise de risco do checkout: %v", err)
+		return nil
+	}
+	return attempt
+}
+
+// writeBlockedResponse recusa o checkout de alto risco sem revelar quais verificações dispararam
+func writeBlockedResponse(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(map[string]any{
+		"success": false,
+		"blocked": true,
+		"error":   "Não foi possível concluir a compra. Entre em contato com o criador do e-book.",
+	})
+}
+
+func isAvailabilityError(err error) bool {
+	return errors.Is(err, salesvc.ErrEbookSoldOut) || errors.Is(err, salesvc.ErrSalesNotStarted) || errors.Is(err, salesvc.ErrSalesEnded)
+}
+
+// writeUnavailableResponse responde ao checkout quando a oferta esgotou ou está fora da janela de vendas
+func writeUnavailableResponse(w http.ResponseWriter, err error) {
+	message := "Este e-book não está disponível para venda no momento."
+	switch {
+	case errors.Is(err, salesvc.ErrEbookSoldOut):
+		message = "As unidades desta oferta esgotaram."
+	case errors.Is(err, salesvc.ErrSalesNotStarted):
+		message = "As vendas deste e-book ainda não começaram."
+	case errors.Is(err, salesvc.ErrSalesEnded):
+		message = "As vendas deste e-book foram encerradas."
+	}
+
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(map[string]any{
+		"success":     false,
+		"unavailable": true,
+		"error":       message,
+	})
+}
+
 // createOrFindClient cria ou busca um cliente existente
-func (h *CheckoutHandler) createOrFindClient(request struct {
-	Name      string `json:"name"`
-	CPF       string `json:"cpf"`
-	Birthdate string `json:"birthdate"`
-	Email     string `json:"email"`
-	Phone     string `json:"phone"`
-	EbookID   string `json:"ebookId"`
-	CSRFToken string `json:"csrfToken"`
-}) (*salesmodel.Client, error) {
-	existingClient, err := h.clientRepo.FindByCPF(request.CPF)
+func (h *CheckoutHandler) createOrFindClient(request customerRequest) (*salesmodel.Client, error) {
+	existingClient, err := h.findExistingClient(request)
 	if err == nil && existingClient != nil {
-		log.Printf("Cliente existente encontrado: ID=%d, CPF='%s'", existingClient.ID, existingClient.CPF)
+		log.Printf("Cliente existente encontrado: ID=%d, Documento='%s'", existingClient.ID, existingClient.IdentityDocument())
 		existingClient.Email = request.Email
 		return existingClient, nil
 	}
@@ -541,7 +878,13 @@ func (h *CheckoutHandler) createOrFindClient(request struct {
 		return nil, err
 	}
 
-	client := salesmodel.NewClient(request.Name, request.CPF, birthDate.Format("2006-01-02"), request.Email, request.Phone)
+	var client *salesmodel.Client
+	if request.isForeign() {
+		client = salesmodel.NewForeignClient(request.Name, request.Document, request.Country, birthDate.Format("2006-01-02"), request.Email, request.Phone)
+	} else {
+		client = salesmodel.NewClient(request.Name, request.CPF, birthDate.Format("2006-01-02"), request.Email, request.Phone)
+		client.Validated = h.cpfVerificationService != nil && h.cpfVerificationService.IsVerified(request.CPF, request.Birthdate)
+	}
 
 	log.Printf("Criando novo cliente: Name='%s', Email='%s', Phone='%s'",
 		client.Name, client.Email, client.Phone)
@@ -567,8 +910,9 @@ func (h *CheckoutHandler) recordStripePayment(purchaseID uint, creatorID uint, e
 		log.Printf("Alerta: payment intent %s para purchase_id=%d já tem transação completada (ID=%d, intent=%s). Criando nova transação.",
 			paymentIntentID, purchaseID, existingTx.ID, existingTx.StripePaymentIntentID)
 		newTx := salesmodel.NewTransaction(purchaseID, creatorID, salesmodel.SplitTypeFixedAmount)
-		newTx.PlatformPercentage = config.Business.PlatformFeePercentage
-		newTx.CalculateSplit(int64(ebook.GetFinalValue() * 100))
+		newTx.ApplyFeePlan(existingTx.FeePlan())
+		price, _ := ebook.PriceIn(ebook.ResolveCurrency(existingTx.Currency))
+		newTx.CalculateSplit(price)
 		newTx.Status = salesmodel.TransactionStatusCompleted
 		newTx.StripePaymentIntentID = paymentIntentID
 		now := time.Now()
@@ -588,6 +932,59 @@ func (h *CheckoutHandler) recordStripePayment(purchaseID uint, creatorID uint, e
 	}
 }
 
+// findExistingClient busca o cadastro do comprador: brasileiros pelo CPF, estrangeiros pelo email
+func (h *CheckoutHandler) findExistingClient(request customerRequest) (*salesmodel.Client, error) {
+	if request.isForeign() {
+		return h.clientRepo.FindForeignByEmail(request.Email)
+	}
+	return h.clientRepo.FindByCPF(request.CPF)
+}
+
+// identityLabel retorna o dado que identifica o comprador nas mensagens do checkout
+func identityLabel(request customerRequest) string {
+	if request.isForeign() {
+		return "e-mail"
+	}
+	return "CPF"
+}
+
+// validateCustomerFields valida os dados do formulário de checkout e retorna a mensagem de erro,
+// ou vazio quando os dados são válidos
+func validateCustomerFields(request customerRequest) string {
+	if request.isForeign() {
+		if request.Name == "" || request.Document == "" || request.Country == "" || request.Birthdate == "" || request.Email == "" || request.Phone == "" {
+			return "Todos os campos são obrigatórios"
+		}
+		if !salesmodel.IsValidForeignCountry(request.Country) {
+			return "País inválido"
+		}
+		if !salesmodel.IsValidDocumentID(request.Document) {
+			return "Documento inválido"
+		}
+		if !isValidEmail(request.Email) {
+			return "E-mail inválido"
+		}
+		if !salesmodel.IsValidInternationalPhone(request.Phone) {
+			return "Telefone inválido. Use o formato internacional, ex: +351912345678"
+		}
+		return ""
+	}
+
+	if request.Name == "" || request.CPF == "" || request.Birthdate == "" || request.Email == "" || request.Phone == "" {
+		return "Todos os campos são obrigatórios"
+	}
+	if !gov.IsValidCPF(request.CPF) {
+		return "CPF inválido"
+	}
+	if !isValidEmail(request.Email) {
+		return "E-mail inválido"
+	}
+	if len(request.Phone) != 11 {
+		return "Telefone inválido"
+	}
+	return ""
+}
+
 func isValidEmail(email string) bool {
 	return len(email) > 3 && len(email) < 254
 }
@@ -11,6 +11,7 @@ import (
 	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
 	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
 	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"gorm.io/gorm"
@@ -52,7 +53,7 @@ func TestTransactionServiceMock(t *testing.T) {
 func TestEbookServiceMock(t *testing.T) {
 	mockEbookService := new(mocks.MockEbookService)
 
-	ebook := &librarymodel.Ebook{Model: gorm.Model{ID: 1}, Title: "Test Ebook", Value: 10.0}
+	ebook := &librarymodel.Ebook{Model: gorm.Model{ID: 1}, Title: "Test Ebook", Value: money.FromCents(1000)}
 
 	// Configurar expectativa
 	mockEbookService.On("FindByID", uint(1)).Return(ebook, nil)
@@ -84,28 +85,18 @@ func TestCreatorServiceMock(t *testing.T) {
 	mockCreatorService.AssertExpectations(t)
 }
 
-type checkoutRequest struct {
-	Name      string `json:"name"`
-	CPF       string `json:"cpf"`
-	Birthdate string `json:"birthdate"`
-	Email     string `json:"email"`
-	Phone     string `json:"phone"`
-	EbookID   string `json:"ebookId"`
-	CSRFToken string `json:"csrfToken"`
-}
-
 // TestCreateOrFindClient_ExistingClientByCPF verifica que cliente existente é identificado pelo CPF
 // e que o banco de dados não é alterado.
 func TestCreateOrFindClient_ExistingClientByCPF(t *testing.T) {
 	mockClientRepo := new(mocks.MockClientRepository)
 	mockCreatorService := new(mocks.MockCreatorService)
 
-	existing := &salesmodel.Client{Model: gorm.Model{ID: 10}, CPF: "12345678901", Email: "old@email.com"}
-	mockClientRepo.On("FindByCPF", "12345678901").Return(existing, nil).Once()
+	existing := &salesmodel.Client{Model: gorm.Model{ID: 10}, CPF: "12345678909", Email: "old@email.com"}
+	mockClientRepo.On("FindByCPF", "12345678909").Return(existing, nil).Once()
 
 	h := &CheckoutHandler{clientRepo: mockClientRepo, creatorService: mockCreatorService}
-	req := checkoutRequest{
-		Name: "João", CPF: "12345678901", Email: "old@email.com",
+	req := customerRequest{
+		Name: "João", CPF: "12345678909", Email: "old@email.com",
 		Phone: "11999990000", Birthdate: "01/01/1990",
 	}
 
@@ -124,12 +115,12 @@ func TestCreateOrFindClient_ExistingClientUsesCheckoutEmail(t *testing.T) {
 	mockClientRepo := new(mocks.MockClientRepository)
 	mockCreatorService := new(mocks.MockCreatorService)
 
-	existing := &salesmodel.Client{Model: gorm.Model{ID: 10}, CPF: "12345678901", Email: "stored@email.com"}
-	mockClientRepo.On("FindByCPF", "12345678901").Return(existing, nil).Once()
+	existing := &salesmodel.Client{Model: gorm.Model{ID: 10}, CPF: "12345678909", Email: "stored@email.com"}
+	mockClientRepo.On("FindByCPF", "12345678909").Return(existing, nil).Once()
 
 	h := &CheckoutHandler{clientRepo: mockClientRepo, creatorService: mockCreatorService}
-	req := checkoutRequest{
-		Name: "João", CPF: "12345678901", Email: "checkout@email.com",
+	req := customerRequest{
+		Name: "João", CPF: "12345678909", Email: "checkout@email.com",
 		Phone: "11999990000", Birthdate: "01/01/1990",
 	}
 
@@ -147,12 +138,12 @@ func TestCreateOrFindClient_NewClientCreatedByCPF(t *testing.T) {
 	mockClientRepo := new(mocks.MockClientRepository)
 	mockCreatorService := new(mocks.MockCreatorService)
 
-	mockClientRepo.On("FindByCPF", "12345678901").Return(nil, nil).Once()
+	mockClientRepo.On("FindByCPF", "12345678909").Return(nil, nil).Once()
 	mockClientRepo.On("Save", mock.AnythingOfType("*model.Client")).Return(nil).Once()
 
 	h := &CheckoutHandler{clientRepo: mockClientRepo, creatorService: mockCreatorService}
-	req := checkoutRequest{
-		Name: "Maria", CPF: "12345678901", Email: "maria@email.com",
+	req := customerRequest{
+		Name: "Maria", CPF: "12345678909", Email: "maria@email.com",
 		Phone: "11988880000", Birthdate: "15/06/1985",
 	}
 
@@ -160,7 +151,7 @@ func TestCreateOrFindClient_NewClientCreatedByCPF(t *testing.T) {
 
 	assert.NoError(t, err)
 	assert.NotNil(t, client)
-	assert.Equal(t, "12345678901", client.CPF)
+	assert.Equal(t, "12345678909", client.CPF)
 	mockClientRepo.AssertNotCalled(t, "FindByEmail", mock.Anything)
 	mockClientRepo.AssertExpectations(t)
 	mockCreatorService.AssertExpectations(t)
@@ -184,7 +175,7 @@ func TestValidateCustomer_ConfirmedPurchase_Returns409WithAlreadyPurchased(t *te
 	mockCreatorService := new(mocks.MockCreatorService)
 
 	ebook := &librarymodel.Ebook{Model: gorm.Model{ID: 1}, Status: true, CreatorID: 2}
-	client := &salesmodel.Client{Model: gorm.Model{ID: 10}, CPF: "12345678901"}
+	client := &salesmodel.Client{Model: gorm.Model{ID: 10}, CPF: "12345678909"}
 	creator := &accountmodel.Creator{Model: gorm.Model{ID: 2}, Name: "Creator", Email: "creator@test.com"}
 	purchase := &salesmodel.Purchase{
 		Model:         gorm.Model{ID: 5},
@@ -192,7 +183,7 @@ func TestValidateCustomer_ConfirmedPurchase_Returns409WithAlreadyPurchased(t *te
 	}
 
 	mockEbookService.On("FindByPublicID", "ebk_abc").Return(ebook, nil)
-	mockClientRepo.On("FindByCPF", "12345678901").Return(client, nil)
+	mockClientRepo.On("FindByCPF", "12345678909").Return(client, nil)
 	mockPurchaseService.On("FindExistingPurchase", uint(1), uint(10)).Return(purchase, nil)
 	mockCreatorService.On("FindByID", uint(2)).Return(creator, nil)
 
@@ -204,7 +195,7 @@ func TestValidateCustomer_ConfirmedPurchase_Returns409WithAlreadyPurchased(t *te
 	}
 
 	body := map[string]any{
-		"name": "João", "cpf": "12345678901", "birthdate": "01/01/1990",
+		"name": "João", "cpf": "12345678909", "birthdate": "01/01/1990",
 		"email": "joao@test.com", "phone": "11999990000", "ebookId": "ebk_abc",
 	}
 	req := newValidateCustomerRequest(t, body)
@@ -234,7 +225,7 @@ func TestValidateCustomer_PendingPurchase_Returns409WithProcessingMessage(t *tes
 	mockCreatorService := new(mocks.MockCreatorService)
 
 	ebook := &librarymodel.Ebook{Model: gorm.Model{ID: 1}, Status: true, CreatorID: 2}
-	client := &salesmodel.Client{Model: gorm.Model{ID: 10}, CPF: "12345678901"}
+	client := &salesmodel.Client{Model: gorm.Model{ID: 10}, CPF: "12345678909"}
 	creator := &accountmodel.Creator{Model: gorm.Model{ID: 2}, Name: "Creator", Email: "creator@test.com"}
 	purchase := &salesmodel.Purchase{
 		Model:         gorm.Model{ID: 5},
@@ -242,7 +233,7 @@ func TestValidateCustomer_PendingPurchase_Returns409WithProcessingMessage(t *tes
 	}
 
 	mockEbookService.On("FindByPublicID", "ebk_abc").Return(ebook, nil)
-	mockClientRepo.On("FindByCPF", "12345678901").Return(client, nil)
+	mockClientRepo.On("FindByCPF", "12345678909").Return(client, nil)
 	mockPurchaseService.On("FindExistingPurchase", uint(1), uint(10)).Return(purchase, nil)
 	mockCreatorService.On("FindByID", uint(2)).Return(creator, nil)
 
@@ -254,7 +245,7 @@ func TestValidateCustomer_PendingPurchase_Returns409WithProcessingMessage(t *tes
 	}
 
 	body := map[string]any{
-		"name": "João", "cpf": "12345678901", "birthdate": "01/01/1990",
+		"name": "João", "cpf": "12345678909", "birthdate": "01/01/1990",
 		"email": "joao@test.com", "phone": "11999990000", "ebookId": "ebk_abc",
 	}
 	req := newValidateCustomerRequest(t, body)
@@ -283,10 +274,10 @@ func TestValidateCustomer_NoPurchase_Returns200(t *testing.T) {
 	mockPurchaseService := new(mocks.MockPurchaseService)
 
 	ebook := &librarymodel.Ebook{Model: gorm.Model{ID: 1}, Status: true, CreatorID: 2}
-	client := &salesmodel.Client{Model: gorm.Model{ID: 10}, CPF: "12345678901"}
+	client := &salesmodel.Client{Model: gorm.Model{ID: 10}, CPF: "12345678909"}
 
 	mockEbookService.On("FindByPublicID", "ebk_abc").Return(ebook, nil)
-	mockClientRepo.On("FindByCPF", "12345678901").Return(client, nil)
+	mockClientRepo.On("FindByCPF", "12345678909").Return(client, nil)
 	mockPurchaseService.On("FindExistingPurchase", uint(1), uint(10)).Return(nil, assert.AnError)
 
 	h := &CheckoutHandler{
@@ -296,7 +287,7 @@ func TestValidateCustomer_NoPurchase_Returns200(t *testing.T) {
 	}
 
 	body := map[string]any{
-		"name": "João", "cpf": "12345678901", "birthdate": "01/01/1990",
+		"name": "João", "cpf": "12345678909", "birthdate": "01/01/1990",
 		"email": "joao@test.com", "phone": "11999990000", "ebookId": "ebk_abc",
 	}
 	req := newValidateCustomerRequest(t, body)
@@ -324,7 +315,7 @@ func TestValidateCustomer_CPFNotFound_NeverCallsFindExistingPurchase(t *testing.
 	ebook := &librarymodel.Ebook{Model: gorm.Model{ID: 1}, Status: true, CreatorID: 2}
 
 	mockEbookService.On("FindByPublicID", "ebk_abc").Return(ebook, nil)
-	mockClientRepo.On("FindByCPF", "99999999999").Return(nil, assert.AnError)
+	mockClientRepo.On("FindByCPF", "52998224725").Return(nil, assert.AnError)
 
 	h := &CheckoutHandler{
 		ebookService:    mockEbookService,
@@ -333,7 +324,7 @@ func TestValidateCustomer_CPFNotFound_NeverCallsFindExistingPurchase(t *testing.
 	}
 
 	body := map[string]any{
-		"name": "Maria", "cpf": "99999999999", "birthdate": "01/01/1990",
+		"name": "Maria", "cpf": "52998224725", "birthdate": "01/01/1990",
 		"email": "maria@test.com", "phone": "11999990000", "ebookId": "ebk_abc",
 	}
 	req := newValidateCustomerRequest(t, body)
@@ -356,14 +347,14 @@ func TestValidateCustomer_CreatorNotFound_Returns409WithEmptyCreatorFields(t *te
 	mockCreatorService := new(mocks.MockCreatorService)
 
 	ebook := &librarymodel.Ebook{Model: gorm.Model{ID: 1}, Status: true, CreatorID: 2}
-	client := &salesmodel.Client{Model: gorm.Model{ID: 10}, CPF: "12345678901"}
+	client := &salesmodel.Client{Model: gorm.Model{ID: 10}, CPF: "12345678909"}
 	purchase := &salesmodel.Purchase{
 		Model:         gorm.Model{ID: 5},
 		PaymentStatus: salesmodel.PaymentStatusConfirmed,
 	}
 
 	mockEbookService.On("FindByPublicID", "ebk_abc").Return(ebook, nil)
-	mockClientRepo.On("FindByCPF", "12345678901").Return(client, nil)
+	mockClientRepo.On("FindByCPF", "12345678909").Return(client, nil)
 	mockPurchaseService.On("FindExistingPurchase", uint(1), uint(10)).Return(purchase, nil)
 	mockCreatorService.On("FindByID", uint(2)).Return(nil, assert.AnError)
 
@@ -375,7 +366,7 @@ func TestValidateCustomer_CreatorNotFound_Returns409WithEmptyCreatorFields(t *te
 	}
 
 	body := map[string]any{
-		"name": "João", "cpf": "12345678901", "birthdate": "01/01/1990",
+		"name": "João", "cpf": "12345678909", "birthdate": "01/01/1990",
 		"email": "joao@test.com", "phone": "11999990000", "ebookId": "ebk_abc",
 	}
 	req := newValidateCustomerRequest(t, body)
@@ -442,7 +433,7 @@ func TestCheckoutHandlerMocksIntegration(t *testing.T) {
 	mockCreatorService := new(mocks.MockCreatorService)
 
 	// Dados de teste
-	ebook := &librarymodel.Ebook{Model: gorm.Model{ID: 1}, Title: "Test Ebook", Value: 29.99, Status: true, CreatorID: 2}
+	ebook := &librarymodel.Ebook{Model: gorm.Model{ID: 1}, Title: "Test Ebook", Value: money.FromCents(2999), Status: true, CreatorID: 2}
 	creator := &accountmodel.Creator{Model: gorm.Model{ID: 2}, Name: "Test Creator", StripeConnectAccountID: "acct_test", OnboardingCompleted: true, ChargesEnabled: true}
 
 	// Configurar expectativas
@@ -482,3 +473,128 @@ func TestCheckoutHandlerMocksIntegration(t *testing.T) {
 	mockPurchaseService.AssertExpectations(t)
 	mockTransactionService.AssertExpectations(t)
 }
+
+// TestCreateOrFindClient_ForeignBuyerCreatedByEmail verifica que o comprador estrangeiro
+// é criado sem CPF, com documento e país.
+func TestCreateOrFindClient_ForeignBuyerCreatedByEmail(t *testing.T) {
+	mockClientRepo := new(mocks.MockClientRepository)
+
+	mockClientRepo.On("FindForeignByEmail", "ana@email.pt").Return(nil, nil).Once()
+	mockClientRepo.On("Save", mock.AnythingOfType("*model.Client")).Return(nil).Once()
+
+	h := &CheckoutHandler{clientRepo: mockClientRepo}
+	req := customerRequest{
+		Name: "Ana Costa", BuyerType: "foreign", Country: "PT", Document: "cb 123456",
+		Email: "ana@email.pt", Phone: "+351 912 345 678", Birthdate: "10/05/1990",
+	}
+
+	client, err := h.createOrFindClient(req)
+
+	assert.NoError(t, err)
+	assert.True(t, client.IsForeign())
+	assert.Empty(t, client.CPF)
+	assert.Equal(t, "CB123456", client.DocumentID)
+	assert.Equal(t, "PT", client.Country)
+	mockClientRepo.AssertNotCalled(t, "FindByCPF", mock.Anything)
+	mockClientRepo.AssertExpectations(t)
+}
+
+func TestBuildEbookCheckoutSessionParams_ChargesExactCents(t *testing.T) {
+	h := &CheckoutHandler{}
+	ebook := &librarymodel.Ebook{Model: gorm.Model{ID: 1}, Title: "Ebook", Value: money.FromCents(2990), PromotionalValue: money.FromFloat(19.99)}
+	client := &salesmodel.Client{Model: gorm.Model{ID: 3}, Email: "ana@test.com"}
+	creator := &accountmodel.Creator{Model: gorm.Model{ID: 2}}
+	purchase := &salesmodel.Purchase{Model: gorm.Model{ID: 4}}
+
+	params := h.buildEbookCheckoutSessionParams(ebook, client, creator, purchase, ebook.GetFinalValue(), salesmodel.DefaultFeePlan(), 0)
+
+	assert.Equal(t, int64(1999), *params.LineItems[0].PriceData.UnitAmount)
+	assert.Equal(t, "brl", *params.LineItems[0].PriceData.Currency)
+	assert.Equal(t, "19.99", params.Metadata["ebook_price"])
+}
+
+func TestBuildEbookCheckoutSessionParams_UsesCreatorFeePlan(t *testing.T) {
+	h := &CheckoutHandler{}
+	ebook := &librarymodel.Ebook{Model: gorm.Model{ID: 1}, Title: "Ebook", Value: money.FromCents(10000)}
+	client := &salesmodel.Client{Model: gorm.Model{ID: 3}, Email: "ana@test.com"}
+	creator := &accountmodel.Creator{
+		Model:                  gorm.Model{ID: 2},
+		StripeConnectAccountID: "acct_123",
+		OnboardingCompleted:    true,
+		ChargesEnabled:         true,
+	}
+	purchase := &salesmodel.Purchase{Model: gorm.Model{ID: 4}}
+	plan := &salesmodel.FeePlan{Name: "Assinante", Percentage: 0.015, FixedFeeCents: 50}
+
+	params := h.buildEbookCheckoutSessionParams(ebook, client, creator, purchase, ebook.GetFinalValue(), plan, 0)
+
+	assert.Equal(t, int64(150+50), *params.PaymentIntentData.ApplicationFeeAmount)
+	assert.Equal(t, "1,5% + R$ 0,50", params.PaymentIntentData.Metadata["fee_percent"])
+	assert.Equal(t, "Assinante", params.PaymentIntentData.Metadata["fee_plan"])
+}
+
+func TestBuildEbookCheckoutSessionParams_SplitsAmountGoesThroughPlatform(t *testing.T) {
+	h := &CheckoutHandler{}
+	ebook := &librarymodel.Ebook{Model: gorm.Model{ID: 1}, Title: "Ebook", Value: money.FromCents(10000)}
+	client := &salesmodel.Client{Model: gorm.Model{ID: 3}, Email: "ana@test.com"}
+	creator := &accountmodel.Creator{
+		Model:                  gorm.Model{ID: 2},
+		StripeConnectAccountID: "acct_123",
+		OnboardingCompleted:    true,
+		ChargesEnabled:         true,
+	}
+	purchase := &salesmodel.Purchase{Model: gorm.Model{ID: 4}}
+	plan := &salesmodel.FeePlan{Name: "Assinante", Percentage: 0.015, FixedFeeCents: 50}
+
+	params := h.buildEbookCheckoutSessionParams(ebook, client, creator, purchase, ebook.GetFinalValue(), plan, 3000)
+
+	// A taxa da plataforma inclui os repasses, transferidos a coautores e afiliados após a confirmação
+	assert.Equal(t, int64(150+50+3000), *params.PaymentIntentData.ApplicationFeeAmount)
+	assert.Equal(t, "3000", params.PaymentIntentData.Metadata["splits_amount"])
+	assert.Equal(t, "6800", params.PaymentIntentData.Metadata["creator_amount"])
+}
+
+func TestPreparePendingTransaction_RepricesWhenCurrencyChanges(t *testing.T) {
+	transactionService := new(mocks.MockTransactionService)
+	h := &CheckoutHandler{transactionService: transactionService}
+	purchase := &salesmodel.Purchase{Model: gorm.Model{ID: 7}}
+	plan := &salesmodel.FeePlan{Name: "Padrão", Percentage: 0.05, FixedFeeCents: 100}
+
+	pending := salesmodel.NewTransaction(7, 1, salesmodel.SplitTypeFixedAmount)
+	pending.AddSplit(2, 0.3)
+	pending.CalculateSplit(money.FromCents(5000))
+	transactionService.On("FindTransactionByPurchaseID", uint(7)).Return(pending, nil)
+
+	usd := money.New(1000, money.USD)
+	repriced := salesmodel.NewTransaction(7, 1, salesmodel.SplitTypeFixedAmount)
+	repriced.ApplyFeePlan(plan)
+	repriced.AddSplit(2, 0.3)
+	repriced.CalculateSplit(usd)
+	transactionService.On("RepricePendingTransaction", uint(7), usd, plan).Return(repriced, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/checkout", nil)
+	splitsAmount, err := h.preparePendingTransaction(req, purchase, &accountmodel.Creator{}, &librarymodel.Ebook{}, "ana@test.com", usd, plan)
+
+	assert.NoError(t, err)
+	assert.Equal(t, repriced.SplitsAmount(), splitsAmount)
+	assert.NotEqual(t, pending.SplitsAmount(), splitsAmount)
+	transactionService.AssertExpectations(t)
+}
+
+func TestPreparePendingTransaction_KeepsUnchangedTransaction(t *testing.T) {
+	transactionService := new(mocks.MockTransactionService)
+	h := &CheckoutHandler{transactionService: transactionService}
+	purchase := &salesmodel.Purchase{Model: gorm.Model{ID: 7}}
+
+	pending := salesmodel.NewTransaction(7, 1, salesmodel.SplitTypeFixedAmount)
+	pending.AddSplit(2, 0.3)
+	pending.CalculateSplit(money.FromCents(5000))
+	transactionService.On("FindTransactionByPurchaseID", uint(7)).Return(pending, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/checkout", nil)
+	splitsAmount, err := h.preparePendingTransaction(req, purchase, &accountmodel.Creator{}, &librarymodel.Ebook{}, "ana@test.com", money.FromCents(5000), salesmodel.DefaultFeePlan())
+
+	assert.NoError(t, err)
+	assert.Equal(t, pending.SplitsAmount(), splitsAmount)
+	transactionService.AssertNotCalled(t, "RepricePendingTransaction", mock.Anything, mock.Anything, mock.Anything)
+}
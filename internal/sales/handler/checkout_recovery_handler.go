@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	accountsvc "github.com/anglesson/simple-web-server/internal/account/service"
+	authsvc "github.com/anglesson/simple-web-server/internal/auth/service"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/template"
+)
+
+type CheckoutRecoveryHandler struct {
+	templateRenderer template.TemplateRenderer
+	recoveryService  salesvc.CheckoutRecoveryService
+	sessionService   authsvc.SessionService
+	creatorService   accountsvc.CreatorService
+}
+
+func NewCheckoutRecoveryHandler(
+	templateRenderer template.TemplateRenderer,
+	recoveryService salesvc.CheckoutRecoveryService,
+	sessionService authsvc.SessionService,
+	creatorService accountsvc.CreatorService,
+) *CheckoutRecoveryHandler {
+	return &CheckoutRecoveryHandler{
+		templateRenderer: templateRenderer,
+		recoveryService:  recoveryService,
+		sessionService:   sessionService,
+		creatorService:   creatorService,
+	}
+}
+
+// RecoveryReportView exibe as taxas de recuperação de checkouts abandonados por ebook
+func (h *CheckoutRecoveryHandler) RecoveryReportView(w http.ResponseWriter, r *http.Request) {
+	userEmail, err := h.sessionService.GetUserEmailFromSession(r)
+	if err != nil {
+		slog.Error("Erro ao obter email da sessão", "error", err)
+		http.Error(w, "Sessão inválida", http.StatusUnauthorized)
+		return
+	}
+
+	creator, err := h.creatorService.FindCreatorByEmail(userEmail)
+	if err != nil {
+		slog.Error("Erro ao buscar criador", "error", err)
+		http.Error(w, "Criador não encontrado", http.StatusNotFound)
+		return
+	}
+
+	stats, err := h.recoveryService.GetRecoveryStatsByCreatorID(creator.ID)
+	if err != nil {
+		slog.Error("Erro ao buscar estatísticas de recuperação", "error", err)
+		http.Error(w, "Erro ao buscar estatísticas de recuperação", http.StatusInternalServerError)
+		return
+	}
+
+	total := &salesmodel.EbookRecoveryStats{EbookTitle: "Total"}
+	for _, s := range stats {
+		total.Abandoned += s.Abandoned
+		total.Recovered += s.Recovered
+		total.EmailsSent += s.EmailsSent
+	}
+
+	h.templateRenderer.View(w, r, "purchase/recovery", map[string]interface{}{
+		"Creator":  creator,
+		"Stats":    stats,
+		"Total":    total,
+		"HasStats": len(stats) > 0,
+	}, "admin-daisy")
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	"github.com/anglesson/simple-web-server/internal/mocks"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stripe/stripe-go/v76"
+)
+
+func TestHandleEbookCheckoutExpired_RegistersRecovery(t *testing.T) {
+	recoveryService := &mocks.MockCheckoutRecoveryService{}
+	stockService := &mocks.MockStockService{}
+	purchaseService := &mocks.MockPurchaseService{}
+	h := &StripeHandler{recoveryService: recoveryService, stockService: stockService, purchaseService: purchaseService}
+
+	expiresAt := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	purchaseService.On("GetPurchaseByID", uint(42)).Return(&salesmodel.Purchase{CheckoutSessionID: "cs_expired"}, nil).Once()
+	stockService.On("ReleaseForPurchase", uint(42)).Return(nil).Once()
+	purchaseService.On("ChangeStatus", uint(42), salesmodel.PurchaseStatusExpired, mock.MatchedBy(func(change salesmodel.StatusChange) bool {
+		return change.Actor == salesmodel.StatusActorStripe
+	})).Return(nil).Once()
+	recoveryService.On("RegisterExpiredSession", uint(42), "cs_expired", mock.MatchedBy(func(at time.Time) bool {
+		return at.Equal(expiresAt)
+	})).Return(nil).Once()
+
+	err := h.handleEbookCheckoutExpired(stripe.CheckoutSession{
+		ID:        "cs_expired",
+		ExpiresAt: expiresAt.Unix(),
+		Metadata:  map[string]string{"purchase_id": "42"},
+	})
+
+	assert.NoError(t, err)
+	recoveryService.AssertExpectations(t)
+	stockService.AssertExpectations(t)
+	purchaseService.AssertExpectations(t)
+}
+
+func TestHandleEbookCheckoutExpired_IgnoresReplacedSession(t *testing.T) {
+	recoveryService := &mocks.MockCheckoutRecoveryService{}
+	stockService := &mocks.MockStockService{}
+	purchaseService := &mocks.MockPurchaseService{}
+	h := &StripeHandler{recoveryService: recoveryService, stockService: stockService, purchaseService: purchaseService}
+
+	// O comprador abriu um novo checkout pelo link de recuperação antes da sessão antiga expirar
+	purchaseService.On("GetPurchaseByID", uint(42)).Return(&salesmodel.Purchase{CheckoutSessionID: "cs_recovered"}, nil).Once()
+
+	err := h.handleEbookCheckoutExpired(stripe.CheckoutSession{
+		ID:       "cs_expired",
+		Metadata: map[string]string{"purchase_id": "42"},
+	})
+
+	assert.NoError(t, err)
+	stockService.AssertNotCalled(t, "ReleaseForPurchase", mock.Anything)
+	purchaseService.AssertNotCalled(t, "ChangeStatus", mock.Anything, mock.Anything, mock.Anything)
+	recoveryService.AssertNotCalled(t, "RegisterExpiredSession", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleEbookCheckoutExpired_WithoutPurchaseID(t *testing.T) {
+	recoveryService := &mocks.MockCheckoutRecoveryService{}
+	h := &StripeHandler{recoveryService: recoveryService}
+
+	err := h.handleEbookCheckoutExpired(stripe.CheckoutSession{ID: "cs_other", Metadata: map[string]string{}})
+
+	assert.NoError(t, err)
+	recoveryService.AssertNotCalled(t, "RegisterExpiredSession", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func recoverCheckoutRequest(token string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/checkout/recover/"+token, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("token", token)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestRecoverCheckout_InvalidToken(t *testing.T) {
+	recoveryService := &mocks.MockCheckoutRecoveryService{}
+	recoveryService.On("FindByToken", "invalid").Return(nil, errors.New("not found"))
+	h := &CheckoutHandler{recoveryService: recoveryService}
+
+	rr := httptest.NewRecorder()
+	h.RecoverCheckout(rr, recoverCheckoutRequest("invalid"))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestRecoverCheckout_ExpiredLink(t *testing.T) {
+	recoveryService := &mocks.MockCheckoutRecoveryService{}
+	recoveryService.On("FindByToken", "old").Return(nil, salesvc.ErrCheckoutRecoveryLinkExpired)
+	h := &CheckoutHandler{recoveryService: recoveryService}
+
+	rr := httptest.NewRecorder()
+	h.RecoverCheckout(rr, recoverCheckoutRequest("old"))
+
+	assert.Equal(t, http.StatusGone, rr.Code)
+}
+
+func TestRecoverCheckout_ConfirmedPurchaseRedirectsToDownload(t *testing.T) {
+	recovery := &salesmodel.CheckoutRecovery{
+		Purchase: salesmodel.Purchase{HashID: "hash-paid", PaymentStatus: salesmodel.PaymentStatusConfirmed},
+	}
+	recoveryService := &mocks.MockCheckoutRecoveryService{}
+	recoveryService.On("FindByToken", "tok").Return(recovery, nil)
+	h := &CheckoutHandler{recoveryService: recoveryService}
+
+	rr := httptest.NewRecorder()
+	h.RecoverCheckout(rr, recoverCheckoutRequest("tok"))
+
+	assert.Equal(t, http.StatusSeeOther, rr.Code)
+	assert.Equal(t, "/purchase/download/hash-paid", rr.Header().Get("Location"))
+}
+
+func TestRecoverCheckout_SoldOutRedirectsToSalesPage(t *testing.T) {
+	recovery := &salesmodel.CheckoutRecovery{
+		Purchase: salesmodel.Purchase{
+			HashID: "hash-pending",
+			Ebook:  librarymodel.Ebook{PublicID: "ebk_limited", Status: true, StockLimit: 10, StockUsed: 10},
+		},
+	}
+	recoveryService := &mocks.MockCheckoutRecoveryService{}
+	recoveryService.On("FindByToken", "tok").Return(recovery, nil)
+	stockService := &mocks.MockStockService{}
+	stockService.On("ReserveForPurchase", &recovery.Purchase.Ebook, &recovery.Purchase).Return(salesvc.ErrEbookSoldOut)
+	h := &CheckoutHandler{recoveryService: recoveryService, stockService: stockService}
+
+	rr := httptest.NewRecorder()
+	h.RecoverCheckout(rr, recoverCheckoutRequest("tok"))
+
+	assert.Equal(t, http.StatusSeeOther, rr.Code)
+	assert.Equal(t, "/sales/ebk_limited", rr.Header().Get("Location"))
+	stockService.AssertExpectations(t)
+}
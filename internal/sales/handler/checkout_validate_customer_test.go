@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	"github.com/anglesson/simple-web-server/internal/config"
 	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
 	"github.com/anglesson/simple-web-server/internal/mocks"
 	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
@@ -32,11 +33,11 @@ func buildCheckoutHandlerForValidation(
 	creatorSvc *mocks.MockCreatorService,
 ) *CheckoutHandler {
 	return &CheckoutHandler{
-		ebookService:    ebookSvc,
-		clientRepo:      clientRepo,
-		purchaseService: purchaseSvc,
-		creatorService:  creatorSvc,
-		rfService:       nil, // non-production: RF skipped
+		ebookService:           ebookSvc,
+		clientRepo:             clientRepo,
+		purchaseService:        purchaseSvc,
+		creatorService:         creatorSvc,
+		cpfVerificationService: nil, // non-production: RF skipped
 	}
 }
 
@@ -44,7 +45,7 @@ func buildCheckoutHandlerForValidation(
 // retorna 409 com already_purchased=true quando o cliente já comprou o ebook.
 func TestValidateCustomer_AlreadyPurchased_ReturnConflict(t *testing.T) {
 	ebook := &librarymodel.Ebook{Model: gorm.Model{ID: 1}, PublicID: "ebook-pub-1", Status: true, CreatorID: 10}
-	client := &salesmodel.Client{Model: gorm.Model{ID: 5}, CPF: "12345678901"}
+	client := &salesmodel.Client{Model: gorm.Model{ID: 5}, CPF: "12345678909"}
 	purchase := &salesmodel.Purchase{Model: gorm.Model{ID: 99}, PaymentStatus: salesmodel.PaymentStatusConfirmed}
 	creator := &accountmodel.Creator{Model: gorm.Model{ID: 10}, Name: "João Producer", Email: "joao@producer.com"}
 
@@ -54,7 +55,7 @@ func TestValidateCustomer_AlreadyPurchased_ReturnConflict(t *testing.T) {
 	mockCreator := new(mocks.MockCreatorService)
 
 	mockEbook.On("FindByPublicID", "ebook-pub-1").Return(ebook, nil)
-	mockClient.On("FindByCPF", "12345678901").Return(client, nil)
+	mockClient.On("FindByCPF", "12345678909").Return(client, nil)
 	mockPurchase.On("FindExistingPurchase", uint(1), uint(5)).Return(purchase, nil)
 	mockCreator.On("FindByID", uint(10)).Return(creator, nil)
 
@@ -62,7 +63,7 @@ func TestValidateCustomer_AlreadyPurchased_ReturnConflict(t *testing.T) {
 
 	body := map[string]any{
 		"name":      "João Silva",
-		"cpf":       "12345678901",
+		"cpf":       "12345678909",
 		"birthdate": "01/01/1990",
 		"email":     "joao@email.com",
 		"phone":     "11999999999",
@@ -134,7 +135,7 @@ func TestValidateCustomer_AlreadyPurchased_CreatorNotFound(t *testing.T) {
 // normalmente quando o cliente existe mas ainda não comprou o ebook.
 func TestValidateCustomer_CPFFound_NoPurchase_Proceeds(t *testing.T) {
 	ebook := &librarymodel.Ebook{Model: gorm.Model{ID: 3}, PublicID: "ebook-pub-3", Status: true, CreatorID: 30}
-	client := &salesmodel.Client{Model: gorm.Model{ID: 9}, CPF: "11122233344"}
+	client := &salesmodel.Client{Model: gorm.Model{ID: 9}, CPF: "11122233396"}
 
 	mockEbook := new(mocks.MockEbookService)
 	mockClient := new(mocks.MockClientRepository)
@@ -142,14 +143,14 @@ func TestValidateCustomer_CPFFound_NoPurchase_Proceeds(t *testing.T) {
 	mockCreator := new(mocks.MockCreatorService)
 
 	mockEbook.On("FindByPublicID", "ebook-pub-3").Return(ebook, nil)
-	mockClient.On("FindByCPF", "11122233344").Return(client, nil)
+	mockClient.On("FindByCPF", "11122233396").Return(client, nil)
 	mockPurchase.On("FindExistingPurchase", uint(3), uint(9)).Return((*salesmodel.Purchase)(nil), errors.New("not found"))
 
 	handler := buildCheckoutHandlerForValidation(mockEbook, mockClient, mockPurchase, mockCreator)
 
 	body := map[string]any{
 		"name":      "Carlos Lima",
-		"cpf":       "11122233344",
+		"cpf":       "11122233396",
 		"birthdate": "01/01/1992",
 		"email":     "carlos@email.com",
 		"phone":     "11977776666",
@@ -180,13 +181,13 @@ func TestValidateCustomer_CPFNotFound_Proceeds(t *testing.T) {
 	mockCreator := new(mocks.MockCreatorService)
 
 	mockEbook.On("FindByPublicID", "ebook-pub-4").Return(ebook, nil)
-	mockClient.On("FindByCPF", "55566677788").Return((*salesmodel.Client)(nil), nil)
+	mockClient.On("FindByCPF", "55566677720").Return((*salesmodel.Client)(nil), nil)
 
 	handler := buildCheckoutHandlerForValidation(mockEbook, mockClient, mockPurchase, mockCreator)
 
 	body := map[string]any{
 		"name":      "Ana Costa",
-		"cpf":       "55566677788",
+		"cpf":       "55566677720",
 		"birthdate": "01/01/2000",
 		"email":     "ana@email.com",
 		"phone":     "11966665555",
@@ -215,13 +216,13 @@ func TestValidateCustomer_CPFLookupError_Proceeds(t *testing.T) {
 	mockCreator := new(mocks.MockCreatorService)
 
 	mockEbook.On("FindByPublicID", "ebook-pub-5").Return(ebook, nil)
-	mockClient.On("FindByCPF", "99988877766").Return((*salesmodel.Client)(nil), errors.New("db error"))
+	mockClient.On("FindByCPF", "99988877714").Return((*salesmodel.Client)(nil), errors.New("db error"))
 
 	handler := buildCheckoutHandlerForValidation(mockEbook, mockClient, mockPurchase, mockCreator)
 
 	body := map[string]any{
 		"name":      "Pedro Alves",
-		"cpf":       "99988877766",
+		"cpf":       "99988877714",
 		"birthdate": "01/01/1988",
 		"email":     "pedro@email.com",
 		"phone":     "11955554444",
@@ -300,7 +301,7 @@ func TestValidateCustomer_InvalidEmail(t *testing.T) {
 
 	body := map[string]any{
 		"name":      "João",
-		"cpf":       "12345678901",
+		"cpf":       "12345678909",
 		"birthdate": "01/01/1990",
 		"email":     "ab", // inválido
 		"phone":     "11999999999",
@@ -325,7 +326,7 @@ func TestValidateCustomer_InvalidPhone(t *testing.T) {
 
 	body := map[string]any{
 		"name":      "João",
-		"cpf":       "12345678901",
+		"cpf":       "12345678909",
 		"birthdate": "01/01/1990",
 		"email":     "joao@email.com",
 		"phone":     "123", // inválido
@@ -350,7 +351,7 @@ func TestValidateCustomer_MissingEbookID(t *testing.T) {
 
 	body := map[string]any{
 		"name":      "João",
-		"cpf":       "12345678901",
+		"cpf":       "12345678909",
 		"birthdate": "01/01/1990",
 		"email":     "joao@email.com",
 		"phone":     "11999999999",
@@ -377,7 +378,7 @@ func TestValidateCustomer_EbookNotFound(t *testing.T) {
 
 	body := map[string]any{
 		"name":      "João",
-		"cpf":       "12345678901",
+		"cpf":       "12345678909",
 		"birthdate": "01/01/1990",
 		"email":     "joao@email.com",
 		"phone":     "11999999999",
@@ -423,7 +424,7 @@ func TestValidateCustomer_EbookInactive(t *testing.T) {
 
 	body := map[string]any{
 		"name":      "João",
-		"cpf":       "12345678901",
+		"cpf":       "12345678909",
 		"birthdate": "01/01/1990",
 		"email":     "joao@email.com",
 		"phone":     "11999999999",
@@ -440,7 +441,7 @@ func TestValidateCustomer_EbookInactive(t *testing.T) {
 // TestValidateCustomer_AlreadyPurchased_ErrorMessagePresent verifica a mensagem de erro.
 func TestValidateCustomer_AlreadyPurchased_ErrorMessagePresent(t *testing.T) {
 	ebook := &librarymodel.Ebook{Model: gorm.Model{ID: 7}, PublicID: "ebook-pub-7", Status: true, CreatorID: 70}
-	client := &salesmodel.Client{Model: gorm.Model{ID: 15}, CPF: "44455566677"}
+	client := &salesmodel.Client{Model: gorm.Model{ID: 15}, CPF: "44455566619"}
 	purchase := &salesmodel.Purchase{Model: gorm.Model{ID: 200}}
 	creator := &accountmodel.Creator{Model: gorm.Model{ID: 70}, Name: "Produtor Teste", Email: "produtor@test.com"}
 
@@ -450,7 +451,7 @@ func TestValidateCustomer_AlreadyPurchased_ErrorMessagePresent(t *testing.T) {
 	mockCreator := new(mocks.MockCreatorService)
 
 	mockEbook.On("FindByPublicID", "ebook-pub-7").Return(ebook, nil)
-	mockClient.On("FindByCPF", "44455566677").Return(client, nil)
+	mockClient.On("FindByCPF", "44455566619").Return(client, nil)
 	mockPurchase.On("FindExistingPurchase", uint(7), uint(15)).Return(purchase, nil)
 	mockCreator.On("FindByID", uint(70)).Return(creator, nil)
 
@@ -458,7 +459,7 @@ func TestValidateCustomer_AlreadyPurchased_ErrorMessagePresent(t *testing.T) {
 
 	body := map[string]any{
 		"name":      "Test User",
-		"cpf":       "44455566677",
+		"cpf":       "44455566619",
 		"birthdate": "01/01/1995",
 		"email":     "test@email.com",
 		"phone":     "11944443333",
@@ -510,11 +511,11 @@ func TestFindExistingPurchase_MockDelegate_NotFound(t *testing.T) {
 // TestFindByCPF_MockDelegate verifica que MockClientRepository.FindByCPF funciona.
 func TestFindByCPF_MockDelegate(t *testing.T) {
 	mockRepo := new(mocks.MockClientRepository)
-	client := &salesmodel.Client{Model: gorm.Model{ID: 5}, CPF: "12345678901"}
+	client := &salesmodel.Client{Model: gorm.Model{ID: 5}, CPF: "12345678909"}
 
-	mockRepo.On("FindByCPF", "12345678901").Return(client, nil)
+	mockRepo.On("FindByCPF", "12345678909").Return(client, nil)
 
-	result, err := mockRepo.FindByCPF("12345678901")
+	result, err := mockRepo.FindByCPF("12345678909")
 
 	assert.NoError(t, err)
 	assert.Equal(t, client, result)
@@ -537,7 +538,7 @@ func TestFindByCPF_MockDelegate_NotFound(t *testing.T) {
 // TestValidateCustomer_AlreadyPurchased_ContentTypeIsJSON verifica o Content-Type da resposta.
 func TestValidateCustomer_AlreadyPurchased_ContentTypeIsJSON(t *testing.T) {
 	ebook := &librarymodel.Ebook{Model: gorm.Model{ID: 8}, PublicID: "ebook-pub-8", Status: true, CreatorID: 80}
-	client := &salesmodel.Client{Model: gorm.Model{ID: 20}, CPF: "77788899900"}
+	client := &salesmodel.Client{Model: gorm.Model{ID: 20}, CPF: "77788899941"}
 	purchase := &salesmodel.Purchase{Model: gorm.Model{ID: 300}}
 	creator := &accountmodel.Creator{Model: gorm.Model{ID: 80}, Name: "Creator", Email: "creator@x.com"}
 
@@ -547,7 +548,7 @@ func TestValidateCustomer_AlreadyPurchased_ContentTypeIsJSON(t *testing.T) {
 	mockCreator := new(mocks.MockCreatorService)
 
 	mockEbook.On("FindByPublicID", "ebook-pub-8").Return(ebook, nil)
-	mockClient.On("FindByCPF", "77788899900").Return(client, nil)
+	mockClient.On("FindByCPF", "77788899941").Return(client, nil)
 	mockPurchase.On("FindExistingPurchase", uint(8), uint(20)).Return(purchase, nil)
 	mockCreator.On("FindByID", uint(80)).Return(creator, nil)
 
@@ -555,7 +556,7 @@ func TestValidateCustomer_AlreadyPurchased_ContentTypeIsJSON(t *testing.T) {
 
 	body := map[string]any{
 		"name":      "Test",
-		"cpf":       "77788899900",
+		"cpf":       "77788899941",
 		"birthdate": "01/01/1990",
 		"email":     "test@x.com",
 		"phone":     "11933332222",
@@ -571,3 +572,200 @@ func TestValidateCustomer_AlreadyPurchased_ContentTypeIsJSON(t *testing.T) {
 	// Verify mock with any argument for FindByID (to handle mock setup flexibility)
 	_ = mock.MatchedBy(func(id uint) bool { return true })
 }
+
+// TestValidateCustomer_ForeignBuyer_UsesEmailIdentity verifica que o comprador estrangeiro
+// é validado sem CPF e identificado pelo email.
+func TestValidateCustomer_ForeignBuyer_UsesEmailIdentity(t *testing.T) {
+	ebook := &librarymodel.Ebook{Model: gorm.Model{ID: 3}, PublicID: "ebook-pub-3", Status: true, CreatorID: 30}
+
+	mockEbook := new(mocks.MockEbookService)
+	mockClient := new(mocks.MockClientRepository)
+	mockPurchase := new(mocks.MockPurchaseService)
+	mockCreator := new(mocks.MockCreatorService)
+
+	mockEbook.On("FindByPublicID", "ebook-pub-3").Return(ebook, nil)
+	mockClient.On("FindForeignByEmail", "ana@email.pt").Return((*salesmodel.Client)(nil), nil)
+
+	handler := buildCheckoutHandlerForValidation(mockEbook, mockClient, mockPurchase, mockCreator)
+
+	body := map[string]any{
+		"name":      "Ana Costa",
+		"buyerType": "foreign",
+		"country":   "PT",
+		"document":  "CB123456",
+		"birthdate": "10/05/1990",
+		"email":     "ana@email.pt",
+		"phone":     "+351912345678",
+		"ebookId":   "ebook-pub-3",
+	}
+	req := buildValidateCustomerRequest(t, body)
+	rr := httptest.NewRecorder()
+
+	handler.ValidateCustomer(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockClient.AssertNotCalled(t, "FindByCPF", mock.Anything)
+	mockClient.AssertExpectations(t)
+}
+
+// TestValidateCustomer_ForeignBuyer_AlreadyPurchased verifica o bloqueio de compra
+// duplicada do estrangeiro pelo email.
+func TestValidateCustomer_ForeignBuyer_AlreadyPurchased(t *testing.T) {
+	ebook := &librarymodel.Ebook{Model: gorm.Model{ID: 4}, PublicID: "ebook-pub-4", Status: true, CreatorID: 40}
+	client := &salesmodel.Client{Model: gorm.Model{ID: 8}, Email: "john@email.com", BuyerType: salesmodel.BuyerTypeForeign}
+	purchase := &salesmodel.Purchase{Model: gorm.Model{ID: 77}, PaymentStatus: salesmodel.PaymentStatusConfirmed}
+
+	mockEbook := new(mocks.MockEbookService)
+	mockClient := new(mocks.MockClientRepository)
+	mockPurchase := new(mocks.MockPurchaseService)
+	mockCreator := new(mocks.MockCreatorService)
+
+	mockEbook.On("FindByPublicID", "ebook-pub-4").Return(ebook, nil)
+	mockClient.On("FindForeignByEmail", "john@email.com").Return(client, nil)
+	mockPurchase.On("FindExistingPurchase", uint(4), uint(8)).Return(purchase, nil)
+	mockCreator.On("FindByID", uint(40)).Return((*accountmodel.Creator)(nil), errors.New("not found"))
+
+	handler := buildCheckoutHandlerForValidation(mockEbook, mockClient, mockPurchase, mockCreator)
+
+	body := map[string]any{
+		"name":      "John Smith",
+		"buyerType": "foreign",
+		"country":   "US",
+		"document":  "123-45-6789",
+		"birthdate": "20/02/1985",
+		"email":     "john@email.com",
+		"phone":     "+14155552671",
+		"ebookId":   "ebook-pub-4",
+	}
+	req := buildValidateCustomerRequest(t, body)
+	rr := httptest.NewRecorder()
+
+	handler.ValidateCustomer(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, true, resp["already_purchased"])
+	assert.Contains(t, resp["error"], "e-mail informado")
+}
+
+// TestValidateCustomer_ForeignBuyer_InvalidFields verifica a validação de país,
+// documento e telefone internacional.
+func TestValidateCustomer_ForeignBuyer_InvalidFields(t *testing.T) {
+	base := map[string]any{
+		"name":      "Ana Costa",
+		"buyerType": "foreign",
+		"country":   "PT",
+		"document":  "CB123456",
+		"birthdate": "10/05/1990",
+		"email":     "ana@email.pt",
+		"phone":     "+351912345678",
+		"ebookId":   "ebook-pub-3",
+	}
+
+	tests := []struct {
+		field    string
+		value    string
+		expected string
+	}{
+		{"country", "BR", "País inválido"},
+		{"document", "12", "Documento inválido"},
+		{"phone", "912345678", "Telefone inválido"},
+		{"document", "", "Todos os campos são obrigatórios"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field+"="+tt.value, func(t *testing.T) {
+			body := map[string]any{}
+			for k, v := range base {
+				body[k] = v
+			}
+			body[tt.field] = tt.value
+
+			handler := buildCheckoutHandlerForValidation(new(mocks.MockEbookService), new(mocks.MockClientRepository), new(mocks.MockPurchaseService), new(mocks.MockCreatorService))
+			rr := httptest.NewRecorder()
+
+			handler.ValidateCustomer(rr, buildValidateCustomerRequest(t, body))
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			var resp map[string]any
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Contains(t, resp["error"], tt.expected)
+		})
+	}
+}
+
+// TestValidateCustomer_Production_UsesCPFVerification verifica que, em produção, a consulta
+// passa pelo serviço de verificação e que uma consulta pendente (provedor fora do ar) não
+// bloqueia a venda, enquanto uma consulta rejeitada bloqueia.
+func TestValidateCustomer_Production_UsesCPFVerification(t *testing.T) {
+	originalMode := config.AppConfig.AppMode
+	config.AppConfig.AppMode = "production"
+	defer func() { config.AppConfig.AppMode = originalMode }()
+
+	tests := []struct {
+		name         string
+		verification *salesmodel.CPFVerification
+		expectedCode int
+	}{
+		{"pendente", &salesmodel.CPFVerification{Status: salesmodel.CPFVerificationPending}, http.StatusOK},
+		{"verificado", &salesmodel.CPFVerification{Status: salesmodel.CPFVerificationVerified, RegisteredName: "JOAO SILVA"}, http.StatusOK},
+		{"rejeitado", &salesmodel.CPFVerification{Status: salesmodel.CPFVerificationRejected}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ebook := &librarymodel.Ebook{Model: gorm.Model{ID: 6}, PublicID: "ebook-pub-6", Status: true, CreatorID: 60}
+
+			mockEbook := new(mocks.MockEbookService)
+			mockClient := new(mocks.MockClientRepository)
+			mockVerification := new(mocks.MockCPFVerificationService)
+
+			mockEbook.On("FindByPublicID", "ebook-pub-6").Return(ebook, nil)
+			mockClient.On("FindByCPF", "52998224725").Return((*salesmodel.Client)(nil), nil)
+			mockVerification.On("Verify", uint(60), "João Silva", "52998224725", "01/01/1990").Return(tt.verification, nil).Once()
+
+			handler := buildCheckoutHandlerForValidation(mockEbook, mockClient, new(mocks.MockPurchaseService), new(mocks.MockCreatorService))
+			handler.cpfVerificationService = mockVerification
+
+			body := map[string]any{
+				"name":      "João Silva",
+				"cpf":       "52998224725",
+				"birthdate": "01/01/1990",
+				"email":     "joao@email.com",
+				"phone":     "11999999999",
+				"ebookId":   "ebook-pub-6",
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ValidateCustomer(rr, buildValidateCustomerRequest(t, body))
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			mockVerification.AssertExpectations(t)
+		})
+	}
+}
+
+// TestValidateCustomer_InvalidCPFCheckDigits verifica que o CPF com dígitos verificadores
+// incorretos é recusado antes de qualquer consulta.
+func TestValidateCustomer_InvalidCPFCheckDigits(t *testing.T) {
+	handler := buildCheckoutHandlerForValidation(new(mocks.MockEbookService), new(mocks.MockClientRepository), new(mocks.MockPurchaseService), new(mocks.MockCreatorService))
+
+	body := map[string]any{
+		"name":      "João Silva",
+		"cpf":       "12345678901",
+		"birthdate": "01/01/1990",
+		"email":     "joao@email.com",
+		"phone":     "11999999999",
+		"ebookId":   "ebook-pub-6",
+	}
+	rr := httptest.NewRecorder()
+
+	handler.ValidateCustomer(rr, buildValidateCustomerRequest(t, body))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "CPF inválido", resp["error"])
+}
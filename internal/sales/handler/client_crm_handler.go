@@ -0,0 +1,397 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	accountsvc "github.com/anglesson/simple-web-server/internal/account/service"
+	authsvc "github.com/anglesson/simple-web-server/internal/auth/service"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	librarysvc "github.com/anglesson/simple-web-server/internal/library/service"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/anglesson/simple-web-server/pkg/template"
+	"github.com/go-chi/chi/v5"
+)
+
+// ClientCRMHandler cuida da ficha do cliente (etiquetas e anotações) e dos segmentos salvos
+type ClientCRMHandler struct {
+	crmService       salesvc.ClientCRMService
+	ebookService     librarysvc.EbookService
+	creatorService   accountsvc.CreatorService
+	sessionManager   authsvc.SessionService
+	templateRenderer template.TemplateRenderer
+}
+
+func NewClientCRMHandler(
+	crmService salesvc.ClientCRMService,
+	ebookService librarysvc.EbookService,
+	creatorService accountsvc.CreatorService,
+	sessionManager authsvc.SessionService,
+	templateRenderer template.TemplateRenderer,
+) *ClientCRMHandler {
+	return &ClientCRMHandler{
+		crmService:       crmService,
+		ebookService:     ebookService,
+		creatorService:   creatorService,
+		sessionManager:   sessionManager,
+		templateRenderer: templateRenderer,
+	}
+}
+
+// segmentView é uma linha da listagem de segmentos
+type segmentView struct {
+	Segment     *salesmodel.ClientSegment
+	Description []string
+	Count       int
+}
+
+// ClientDetailView exibe a ficha do cliente com as compras, etiquetas e anotações do criador
+func (h *ClientCRMHandler) ClientDetailView(w http.ResponseWriter, r *http.Request) {
+	creator, client, ok := h.ownedClient(w, r)
+	if !ok {
+		return
+	}
+
+	purchases, err := h.crmService.FindPurchases(creator.ID, client.ID)
+	if err != nil {
+		log.Printf("Erro ao buscar compras do cliente: %v", err)
+	}
+	spent, err := h.crmService.TotalSpent(creator.ID, client.ID)
+	if err != nil {
+		log.Printf("Erro ao somar compras do cliente: %v", err)
+	}
+	clientTags, err := h.crmService.TagsByClient(creator.ID, []uint{client.ID})
+	if err != nil {
+		log.Printf("Erro ao buscar etiquetas do cliente: %v", err)
+	}
+	tags, err := h.crmService.ListTags(creator.ID)
+	if err != nil {
+		log.Printf("Erro ao buscar etiquetas: %v", err)
+	}
+	notes, err := h.crmService.ListNotes(creator.ID, client.ID)
+	if err != nil {
+		log.Printf("Erro ao buscar anotações do cliente: %v", err)
+	}
+
+	h.templateRenderer.View(w, r, "client/detail", map[string]any{
+		"Client":     client,
+		"Purchases":  purchases,
+		"TotalSpent": money.FromCents(spent),
+		"ClientTags": clientTags[client.ID],
+		"Tags":       tags,
+		"Notes":      notes,
+		"Success":    h.sessionManager.GetFlashes(w, r, "success"),
+		"Errors":     h.sessionManager.GetFlashes(w, r, "error"),
+	}, "admin-daisy")
+}
+
+func (h *ClientCRMHandler) AddTag(w http.ResponseWriter, r *http.Request) {
+	creator, client, ok := h.ownedClient(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.crmService.TagClient(creator.ID, client.ID, r.FormValue("tag_name")); err != nil {
+		h.redirectWithError(w, r, clientURL(client), err, "Não foi possível adicionar a etiqueta")
+		return
+	}
+	http.Redirect(w, r, clientURL(client), http.StatusSeeOther)
+}
+
+func (h *ClientCRMHandler) RemoveTag(w http.ResponseWriter, r *http.Request) {
+	creator, client, ok := h.ownedClient(w, r)
+	if !ok {
+		return
+	}
+
+	tagID, _ := strconv.ParseUint(chi.URLParam(r, "tagID"), 10, 64)
+	if err := h.crmService.UntagClient(creator.ID, client.ID, uint(tagID)); err != nil {
+		h.redirectWithError(w, r, clientURL(client), err, "Não foi possível remover a etiqueta")
+		return
+	}
+	http.Redirect(w, r, clientURL(client), http.StatusSeeOther)
+}
+
+func (h *ClientCRMHandler) AddNote(w http.ResponseWriter, r *http.Request) {
+	creator, client, ok := h.ownedClient(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.crmService.AddNote(creator.ID, client.ID, r.FormValue("body")); err != nil {
+		h.redirectWithError(w, r, clientURL(client), err, "Não foi possível salvar a anotação")
+		return
+	}
+	h.sessionManager.AddFlash(w, r, "Anotação salva!", "success")
+	http.Redirect(w, r, clientURL(client), http.StatusSeeOther)
+}
+
+func (h *ClientCRMHandler) DeleteNote(w http.ResponseWriter, r *http.Request) {
+	creator, client, ok := h.ownedClient(w, r)
+	if !ok {
+		return
+	}
+
+	noteID, _ := strconv.ParseUint(chi.URLParam(r, "noteID"), 10, 64)
+	if err := h.crmService.DeleteNote(creator.ID, client.ID, uint(noteID)); err != nil {
+		h.redirectWithError(w, r, clientURL(client), err, "Não foi possível excluir a anotação")
+		return
+	}
+	http.Redirect(w, r, clientURL(client), http.StatusSeeOther)
+}
+
+// SegmentsView lista os segmentos salvos, com a quantidade atual de clientes, e o formulário de criação
+func (h *ClientCRMHandler) SegmentsView(w http.ResponseWriter, r *http.Request) {
+	creator, ok := loggedCreator(w, r, h.creatorService, h.sessionManager)
+	if !ok {
+		return
+	}
+
+	tags, err := h.crmService.ListTags(creator.ID)
+	if err != nil {
+		log.Printf("Erro ao buscar etiquetas: %v", err)
+	}
+	ebooks, err := h.ebookService.GetEbooksByCreatorID(creator.ID)
+	if err != nil {
+		log.Printf("Erro ao buscar ebooks do criador: %v", err)
+	}
+	segments, err := h.crmService.ListSegments(creator.ID)
+	if err != nil {
+		log.Printf("Erro ao buscar segmentos: %v", err)
+	}
+
+	tagNames := make(map[uint]string, len(tags))
+	for _, tag := range tags {
+		tagNames[tag.ID] = tag.Name
+	}
+	ebookTitles := make(map[uint]string, len(ebooks))
+	for _, ebook := range ebooks {
+		ebookTitles[ebook.ID] = ebook.Title
+	}
+
+	views := make([]segmentView, 0, len(segments))
+	for _, segment := range segments {
+		clients, err := h.crmService.SegmentClients(creator.ID, segment.Rules)
+		if err != nil {
+			log.Printf("Erro ao contar clientes do segmento %s: %v", segment.PublicID, err)
+		}
+		views = append(views, segmentView{
+			Segment:     segment,
+			Description: segment.Rules.Describe(tagNames, ebookTitles),
+			Count:       len(clients),
+		})
+	}
+
+	h.templateRenderer.View(w, r, "client/segments", map[string]any{
+		"Segments": views,
+		"Tags":     tags,
+		"Ebooks":   ebooks,
+		"Success":  h.sessionManager.GetFlashes(w, r, "success"),
+		"Errors":   h.sessionManager.GetFlashes(w, r, "error"),
+	}, "admin-daisy")
+}
+
+func (h *ClientCRMHandler) CreateSegment(w http.ResponseWriter, r *http.Request) {
+	creator, ok := loggedCreator(w, r, h.creatorService, h.sessionManager)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		h.redirectWithError(w, r, "/client/segments", err, "Erro ao processar formulário")
+		return
+	}
+
+	rules, err := h.parseSegmentRules(r, creator)
+	if err != nil {
+		h.redirectWithError(w, r, "/client/segments", err, "")
+		return
+	}
+
+	segment, err := h.crmService.CreateSegment(creator.ID, r.FormValue("name"), rules)
+	if err != nil {
+		h.redirectWithError(w, r, "/client/segments", err, "Não foi possível salvar o segmento")
+		return
+	}
+	h.sessionManager.AddFlash(w, r, "Segmento salvo!", "success")
+	http.Redirect(w, r, "/client?segment="+segment.PublicID, http.StatusSeeOther)
+}
+
+func (h *ClientCRMHandler) DeleteSegment(w http.ResponseWriter, r *http.Request) {
+	creator, ok := loggedCreator(w, r, h.creatorService, h.sessionManager)
+	if !ok {
+		return
+	}
+
+	if err := h.crmService.DeleteSegment(creator.ID, chi.URLParam(r, "id")); err != nil {
+		h.redirectWithError(w, r, "/client/segments", err, "Não foi possível excluir o segmento")
+		return
+	}
+	h.sessionManager.AddFlash(w, r, "Segmento excluído", "success")
+	http.Redirect(w, r, "/client/segments", http.StatusSeeOther)
+}
+
+// SegmentExportCSV exporta os clientes do segmento no mesmo formato da exportação geral
+func (h *ClientCRMHandler) SegmentExportCSV(w http.ResponseWriter, r *http.Request) {
+	creator, segment, ok := h.ownedSegment(w, r)
+	if !ok {
+		return
+	}
+
+	clients, err := h.crmService.SegmentClients(creator.ID, segment.Rules)
+	if err != nil {
+		h.redirectWithError(w, r, "/client/segments", err, "Erro na exportação de clientes")
+		return
+	}
+	writeClientsCSV(w, "clientes-"+segment.PublicID+".csv", clients)
+}
+
+// SegmentGrantEbook libera um ebook do criador para todos os clientes do segmento
+func (h *ClientCRMHandler) SegmentGrantEbook(w http.ResponseWriter, r *http.Request) {
+	creator, segment, ok := h.ownedSegment(w, r)
+	if !ok {
+		return
+	}
+
+	ebook, err := h.ebookService.FindByPublicID(r.FormValue("ebook_id"))
+	if err != nil || ebook == nil || ebook.CreatorID != creator.ID {
+		h.sessionManager.AddFlash(w, r, "Selecione um dos seus ebooks", "error")
+		http.Redirect(w, r, "/client/segments", http.StatusSeeOther)
+		return
+	}
+
+	granted, err := h.crmService.GrantEbookToSegment(creator.ID, segment, ebook.ID)
+	if err != nil {
+		h.redirectWithError(w, r, "/client/segments", err, "Não foi possível liberar o ebook")
+		return
+	}
+	h.sessionManager.AddFlash(w, r, "Ebook liberado para "+strconv.Itoa(granted)+" cliente(s) do segmento "+segment.Name, "success")
+	http.Redirect(w, r, "/client/segments", http.StatusSeeOther)
+}
+
+func (h *ClientCRMHandler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	creator, ok := loggedCreator(w, r, h.creatorService, h.sessionManager)
+	if !ok {
+		return
+	}
+
+	tagID, _ := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err := h.crmService.DeleteTag(creator.ID, uint(tagID)); err != nil {
+		h.redirectWithError(w, r, "/client/segments", err, "Não foi possível excluir a etiqueta")
+		return
+	}
+	h.sessionManager.AddFlash(w, r, "Etiqueta excluída", "success")
+	http.Redirect(w, r, "/client/segments", http.StatusSeeOther)
+}
+
+// parseSegmentRules converte o formulário nos critérios do segmento. Os ebooks chegam pelo id público
+// e só são aceitos os do próprio criador.
+func (h *ClientCRMHandler) parseSegmentRules(r *http.Request, creator *accountmodel.Creator) (salesmodel.SegmentRules, error) {
+	var rules salesmodel.SegmentRules
+
+	for _, value := range r.Form["tag_ids"] {
+		tagID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return rules, errors.New("Etiqueta inválida")
+		}
+		rules.TagIDs = append(rules.TagIDs, uint(tagID))
+	}
+
+	ebooks, err := h.ebookService.GetEbooksByCreatorID(creator.ID)
+	if err != nil {
+		return rules, err
+	}
+	ebookIDs := func(publicIDs []string) ([]uint, error) {
+		var ids []uint
+		for _, publicID := range publicIDs {
+			ebook := findEbook(ebooks, publicID)
+			if ebook == nil {
+				return nil, errors.New("Selecione um dos seus ebooks")
+			}
+			ids = append(ids, ebook.ID)
+		}
+		return ids, nil
+	}
+	if rules.PurchasedEbookIDs, err = ebookIDs(r.Form["purchased"]); err != nil {
+		return rules, err
+	}
+	if rules.NotPurchasedEbookIDs, err = ebookIDs(r.Form["not_purchased"]); err != nil {
+		return rules, err
+	}
+
+	if minSpent := strings.TrimSpace(r.FormValue("min_spent")); minSpent != "" {
+		amount, err := money.ParseBRL(minSpent)
+		if err != nil {
+			return rules, errors.New("Valor gasto inválido")
+		}
+		rules.MinSpent = amount.Amount
+	}
+	if inactiveDays := strings.TrimSpace(r.FormValue("inactive_days")); inactiveDays != "" {
+		days, err := strconv.Atoi(inactiveDays)
+		if err != nil {
+			return rules, errors.New("Quantidade de dias inválida")
+		}
+		rules.InactiveDays = days
+	}
+	return rules, nil
+}
+
+func findEbook(ebooks []*librarymodel.Ebook, publicID string) *librarymodel.Ebook {
+	for _, ebook := range ebooks {
+		if ebook.PublicID == publicID {
+			return ebook
+		}
+	}
+	return nil
+}
+
+// ownedClient carrega o cliente da URL garantindo que ele é cliente do criador logado
+func (h *ClientCRMHandler) ownedClient(w http.ResponseWriter, r *http.Request) (*accountmodel.Creator, *salesmodel.Client, bool) {
+	creator, ok := loggedCreator(w, r, h.creatorService, h.sessionManager)
+	if !ok {
+		return nil, nil, false
+	}
+
+	client, err := h.crmService.FindClient(creator.ID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.redirectWithError(w, r, "/client", err, "Cliente não encontrado")
+		return nil, nil, false
+	}
+	return creator, client, true
+}
+
+func (h *ClientCRMHandler) ownedSegment(w http.ResponseWriter, r *http.Request) (*accountmodel.Creator, *salesmodel.ClientSegment, bool) {
+	creator, ok := loggedCreator(w, r, h.creatorService, h.sessionManager)
+	if !ok {
+		return nil, nil, false
+	}
+
+	segment, err := h.crmService.FindSegment(creator.ID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.redirectWithError(w, r, "/client/segments", err, "Segmento não encontrado")
+		return nil, nil, false
+	}
+	return creator, segment, true
+}
+
+// redirectWithError mostra a mensagem dos erros de validação e uma mensagem genérica para os demais
+func (h *ClientCRMHandler) redirectWithError(w http.ResponseWriter, r *http.Request, redirectTo string, err error, fallback string) {
+	message := err.Error()
+	known := errors.Is(err, salesvc.ErrClientCRMInvalid) || errors.Is(err, salesvc.ErrCRMClientNotFound) || errors.Is(err, salesvc.ErrSegmentNotFound)
+	if !known && fallback != "" {
+		log.Printf("Erro no CRM de clientes: %v", err)
+		message = fallback
+	}
+	h.sessionManager.AddFlash(w, r, message, "error")
+	http.Redirect(w, r, redirectTo, http.StatusSeeOther)
+}
+
+func clientURL(client *salesmodel.Client) string {
+	return "/client/" + client.PublicID
+}
@@ -18,6 +18,7 @@ import (
 
 type ClientHandler struct {
 	clientService    salesvc.ClientService
+	crmService       salesvc.ClientCRMService
 	creatorService   accountsvc.CreatorService
 	sessionManager   authsvc.SessionService
 	templateRenderer template.TemplateRenderer
@@ -25,12 +26,14 @@ type ClientHandler struct {
 
 func NewClientHandler(
 	clientService salesvc.ClientService,
+	crmService salesvc.ClientCRMService,
 	creatorService accountsvc.CreatorService,
 	sessionManager authsvc.SessionService,
 	templateRenderer template.TemplateRenderer,
 ) *ClientHandler {
 	return &ClientHandler{
 		clientService:    clientService,
+		crmService:       crmService,
 		creatorService:   creatorService,
 		sessionManager:   sessionManager,
 		templateRenderer: templateRenderer,
@@ -76,10 +79,28 @@ func (ch *ClientHandler) ClientIndexView(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	clients, err := salesrepogorm.NewClientGormRepository().FindClientsByCreator(creator, salesmodel.ClientFilter{
+	filter := salesmodel.ClientFilter{
 		Term:       term,
 		Pagination: pagination,
-	})
+	}
+
+	// Filtro por segmento salvo ou por uma única etiqueta
+	segmentID := r.URL.Query().Get("segment")
+	tagID, _ := strconv.ParseUint(r.URL.Query().Get("tag"), 10, 64)
+	var activeSegment *salesmodel.ClientSegment
+	if segmentID != "" {
+		activeSegment, err = ch.crmService.FindSegment(creator.ID, segmentID)
+		if err != nil {
+			ch.sessionManager.AddFlash(w, r, err.Error(), "error")
+			http.Redirect(w, r, "/client", http.StatusSeeOther)
+			return
+		}
+		filter.Segment = &activeSegment.Rules
+	} else if tagID != 0 {
+		filter.Segment = &salesmodel.SegmentRules{TagIDs: []uint{uint(tagID)}}
+	}
+
+	clients, err := salesrepogorm.NewClientGormRepository().FindClientsByCreator(creator, filter)
 	if err != nil {
 		log.Printf("Erro ao buscar clientes: %v", err)
 		ch.sessionManager.AddFlash(w, r, err.Error(), "error")
@@ -102,20 +123,44 @@ func (ch *ClientHandler) ClientIndexView(w http.ResponseWriter, r *http.Request)
 
 	hasClients := clients != nil && len(*clients) > 0
 
+	clientIDs := make([]uint, 0, len(*clients))
+	for _, client := range *clients {
+		clientIDs = append(clientIDs, client.ID)
+	}
+	clientTags, err := ch.crmService.TagsByClient(creator.ID, clientIDs)
+	if err != nil {
+		log.Printf("Erro ao buscar etiquetas dos clientes: %v", err)
+	}
+	tags, err := ch.crmService.ListTags(creator.ID)
+	if err != nil {
+		log.Printf("Erro ao buscar etiquetas: %v", err)
+	}
+	segments, err := ch.crmService.ListSegments(creator.ID)
+	if err != nil {
+		log.Printf("Erro ao buscar segmentos: %v", err)
+	}
+
 	log.Printf("Encontrados %d clientes para exibição", len(*clients))
 
 	successMessages := ch.sessionManager.GetFlashes(w, r, "success")
 	errorMessages := ch.sessionManager.GetFlashes(w, r, "error")
 
 	ch.templateRenderer.View(w, r, "client/list", map[string]any{
-		"Clients":    clients,
-		"Pagination": pagination,
-		"SearchTerm": term,
-		"HasClients": hasClients,
-		"Success":    successMessages,
-		"Errors":     errorMessages,
+		"Clients":       clients,
+		"ClientTags":    clientTags,
+		"Tags":          tags,
+		"Segments":      segments,
+		"ActiveTag":     uint(tagID),
+		"ActiveSegment": activeSegment,
+		"Pagination":    pagination,
+		"SearchTerm":    term,
+		"HasClients":    hasClients,
+		"Success":       successMessages,
+		"Errors":        errorMessages,
 		"Filters": map[string]interface{}{
-			"term": term,
+			"term":    term,
+			"tag":     r.URL.Query().Get("tag"),
+			"segment": segmentID,
 		},
 	}, "admin-daisy")
 }
@@ -168,15 +213,24 @@ func (ch *ClientHandler) ClientExportCSV(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	writeClientsCSV(w, "clientes.csv", *clients)
+}
+
+// writeClientsCSV envia a planilha de clientes usada na exportação geral e na de segmentos
+func writeClientsCSV(w http.ResponseWriter, fileName string, clients []salesmodel.Client) {
 	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
-	w.Header().Set("Content-Disposition", "attachment; filename=clientes.csv")
+	w.Header().Set("Content-Disposition", "attachment; filename="+fileName)
 
 	writer := csv.NewWriter(w)
 	defer writer.Flush()
 
-	writer.Write([]string{"Nome", "Email", "Telefone"})
-	for _, client := range *clients {
-		writer.Write([]string{client.Name, client.Email, client.Phone})
+	writer.Write([]string{"Nome", "Email", "Telefone", "Tipo", "País", "Documento"})
+	for _, client := range clients {
+		buyerType, country, document := "Brasileiro", "BR", client.CPF
+		if client.IsForeign() {
+			buyerType, country, document = "Estrangeiro", client.Country, client.DocumentID
+		}
+		writer.Write([]string{client.Name, client.Email, client.Phone, buyerType, country, document})
 	}
 }
 
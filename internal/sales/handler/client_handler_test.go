@@ -52,7 +52,7 @@ func (suite *ClientHandlerTestSuite) SetupTest() {
 	suite.mockCreatorService = new(mocks.MockCreatorService)
 	suite.mockTemplateRenderer = new(MockTemplateRenderer)
 
-	suite.sut = handler.NewClientHandler(suite.mockClientService, suite.mockCreatorService, suite.mockSessionManager, suite.mockTemplateRenderer)
+	suite.sut = handler.NewClientHandler(suite.mockClientService, new(mocks.MockClientCRMService), suite.mockCreatorService, suite.mockSessionManager, suite.mockTemplateRenderer)
 }
 
 func (suite *ClientHandlerTestSuite) TestShouldUpdateClientSuccessfully() {
@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	accountsvc "github.com/anglesson/simple-web-server/internal/account/service"
+	authmw "github.com/anglesson/simple-web-server/internal/auth/handler/middleware"
+	authsvc "github.com/anglesson/simple-web-server/internal/auth/service"
+	librarysvc "github.com/anglesson/simple-web-server/internal/library/service"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/template"
+	"github.com/go-chi/chi/v5"
+)
+
+// Quantidade de erros exibidos na página; o relatório completo é baixado em CSV
+const clientImportErrorsPreview = 100
+
+type ClientImportHandler struct {
+	clientImportService salesvc.ClientImportService
+	ebookService        librarysvc.EbookService
+	creatorService      accountsvc.CreatorService
+	sessionManager      authsvc.SessionService
+	templateRenderer    template.TemplateRenderer
+}
+
+func NewClientImportHandler(
+	clientImportService salesvc.ClientImportService,
+	ebookService librarysvc.EbookService,
+	creatorService accountsvc.CreatorService,
+	sessionManager authsvc.SessionService,
+	templateRenderer template.TemplateRenderer,
+) *ClientImportHandler {
+	return &ClientImportHandler{
+		clientImportService: clientImportService,
+		ebookService:        ebookService,
+		creatorService:      creatorService,
+		sessionManager:      sessionManager,
+		templateRenderer:    templateRenderer,
+	}
+}
+
+// ImportView exibe o envio da planilha e as últimas importações do criador
+func (h *ClientImportHandler) ImportView(w http.ResponseWriter, r *http.Request) {
+	creator, ok := h.loggedCreator(w, r)
+	if !ok {
+		return
+	}
+
+	ebooks, err := h.ebookService.GetEbooksByCreatorID(creator.ID)
+	if err != nil {
+		log.Printf("Erro ao buscar ebooks do criador: %v", err)
+	}
+	imports, err := h.clientImportService.ListRecent(creator.ID)
+	if err != nil {
+		log.Printf("Erro ao buscar importações de clientes: %v", err)
+	}
+
+	h.templateRenderer.View(w, r, "client/import", map[string]any{
+		"Ebooks":  ebooks,
+		"Imports": imports,
+		"Success": h.sessionManager.GetFlashes(w, r, "success"),
+		"Errors":  h.sessionManager.GetFlashes(w, r, "error"),
+	}, "admin-daisy")
+}
+
+// ImportUpload recebe a planilha e leva o criador ao mapeamento das colunas
+func (h *ClientImportHandler) ImportUpload(w http.ResponseWriter, r *http.Request) {
+	creator, ok := h.loggedCreator(w, r)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, salesvc.MaxClientImportSize+(1<<20))
+	if err := r.ParseMultipartForm(salesvc.MaxClientImportSize); err != nil {
+		h.failImport(w, r, "/client/import", "O arquivo deve ter no máximo 5MB")
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		h.failImport(w, r, "/client/import", "Selecione a planilha de clientes")
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, salesvc.MaxClientImportSize+1))
+	if err != nil {
+		h.failImport(w, r, "/client/import", "Não foi possível ler o arquivo")
+		return
+	}
+
+	var ebookID *uint
+	if ebookPublicID := r.FormValue("ebook_id"); ebookPublicID != "" {
+		ebook, err := h.ebookService.FindByPublicID(ebookPublicID)
+		if err != nil || ebook == nil || ebook.CreatorID != creator.ID {
+			h.failImport(w, r, "/client/import", "Selecione um dos seus ebooks")
+			return
+		}
+		ebookID = &ebook.ID
+	}
+
+	clientImport, err := h.clientImportService.Upload(creator.ID, header.Filename, content, ebookID)
+	if err != nil {
+		message := err.Error()
+		if !errors.Is(err, salesvc.ErrClientImportInvalid) {
+			log.Printf("Erro ao registrar importação de clientes: %v", err)
+			message = "Não foi possível registrar a importação"
+		}
+		h.failImport(w, r, "/client/import", message)
+		return
+	}
+
+	http.Redirect(w, r, "/client/import/"+clientImport.PublicID, http.StatusSeeOther)
+}
+
+// ImportDetailView mostra o mapeamento das colunas ou, depois de iniciada, o relatório da importação
+func (h *ClientImportHandler) ImportDetailView(w http.ResponseWriter, r *http.Request) {
+	clientImport, ok := h.ownedImport(w, r)
+	if !ok {
+		return
+	}
+
+	data := map[string]any{
+		"Import":  clientImport,
+		"Fields":  salesmodel.ClientImportFields,
+		"Success": h.sessionManager.GetFlashes(w, r, "success"),
+		"Errors":  h.sessionManager.GetFlashes(w, r, "error"),
+	}
+
+	if clientImport.Status == salesmodel.ClientImportStatusCompleted || clientImport.Status == salesmodel.ClientImportStatusFailed {
+		importErrors, err := h.clientImportService.FindErrors(clientImport)
+		if err != nil {
+			log.Printf("Erro ao buscar erros da importação: %v", err)
+		}
+		data["TotalErrors"] = len(importErrors)
+		if len(importErrors) > clientImportErrorsPreview {
+			importErrors = importErrors[:clientImportErrorsPreview]
+		}
+		data["RowErrors"] = importErrors
+	}
+
+	h.templateRenderer.View(w, r, "client/import-detail", data, "admin-daisy")
+}
+
+// ImportStart confirma o mapeamento das colunas e coloca a importação na fila
+func (h *ClientImportHandler) ImportStart(w http.ResponseWriter, r *http.Request) {
+	clientImport, ok := h.ownedImport(w, r)
+	if !ok {
+		return
+	}
+
+	detailURL := "/client/import/" + clientImport.PublicID
+	mapping := make(map[string]int)
+	for _, field := range salesmodel.ClientImportFields {
+		column, err := strconv.Atoi(r.FormValue("column_" + field.Key))
+		if err == nil && column >= 0 {
+			mapping[field.Key] = column
+		}
+	}
+
+	if err := h.clientImportService.Start(clientImport, mapping); err != nil {
+		message := err.Error()
+		if !errors.Is(err, salesvc.ErrClientImportInvalid) && !errors.Is(err, salesvc.ErrClientImportNotEditable) {
+			log.Printf("Erro ao iniciar importação de clientes: %v", err)
+			message = "Não foi possível iniciar a importação"
+		}
+		h.failImport(w, r, detailURL, message)
+		return
+	}
+
+	h.sessionManager.AddFlash(w, r, "Importação iniciada! Os clientes serão processados em instantes.", "success")
+	http.Redirect(w, r, detailURL, http.StatusSeeOther)
+}
+
+// ImportErrorsCSV baixa o relatório com todas as linhas que não foram importadas
+func (h *ClientImportHandler) ImportErrorsCSV(w http.ResponseWriter, r *http.Request) {
+	clientImport, ok := h.ownedImport(w, r)
+	if !ok {
+		return
+	}
+
+	importErrors, err := h.clientImportService.FindErrors(clientImport)
+	if err != nil {
+		http.Error(w, "Erro ao gerar o relatório", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=erros-importacao-"+clientImport.PublicID+".csv")
+
+	writer := csv.NewWriter(w)
+	defer writer.Flush()
+
+	writer.Write([]string{"Linha", "Campo", "Valor", "Erro"})
+	for _, importError := range importErrors {
+		writer.Write([]string{strconv.Itoa(importError.Line), importError.GetFieldLabel(), importError.Value, importError.Message})
+	}
+}
+
+func (h *ClientImportHandler) loggedCreator(w http.ResponseWriter, r *http.Request) (*accountmodel.Creator, bool) {
+	return loggedCreator(w, r, h.creatorService, h.sessionManager)
+}
+
+// loggedCreator busca o criador do usuário logado; sem ele, redireciona ao dashboard com a mensagem de erro
+func loggedCreator(w http.ResponseWriter, r *http.Request, creatorService accountsvc.CreatorService, sessionManager authsvc.SessionService) (*accountmodel.Creator, bool) {
+	loggedUser := authmw.Auth(r)
+	if loggedUser.ID == 0 {
+		http.Error(w, "Não foi possível prosseguir com a sua solicitação", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	creator, err := creatorService.FindCreatorByUserID(loggedUser.ID)
+	if err != nil {
+		sessionManager.AddFlash(w, r, err.Error(), "error")
+		http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+		return nil, false
+	}
+	return creator, true
+}
+
+// ownedImport carrega a importação da URL garantindo que ela pertence ao criador logado
+func (h *ClientImportHandler) ownedImport(w http.ResponseWriter, r *http.Request) (*salesmodel.ClientImport, bool) {
+	creator, ok := h.loggedCreator(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	clientImport, err := h.clientImportService.FindByPublicID(chi.URLParam(r, "id"))
+	if err != nil || clientImport == nil || clientImport.CreatorID != creator.ID {
+		h.failImport(w, r, "/client/import", "Importação não encontrada")
+		return nil, false
+	}
+	return clientImport, true
+}
+
+func (h *ClientImportHandler) failImport(w http.ResponseWriter, r *http.Request, redirectTo, message string) {
+	h.sessionManager.AddFlash(w, r, message, "error")
+	http.Redirect(w, r, redirectTo, http.StatusSeeOther)
+}
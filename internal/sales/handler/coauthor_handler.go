@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	accountsvc "github.com/anglesson/simple-web-server/internal/account/service"
+	authsvc "github.com/anglesson/simple-web-server/internal/auth/service"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	librarysvc "github.com/anglesson/simple-web-server/internal/library/service"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	cookies "github.com/anglesson/simple-web-server/pkg/cookie"
+	"github.com/anglesson/simple-web-server/pkg/template"
+	"github.com/go-chi/chi/v5"
+)
+
+type CoAuthorHandler struct {
+	templateRenderer   template.TemplateRenderer
+	coAuthorService    librarysvc.CoAuthorService
+	ebookService       librarysvc.EbookService
+	transactionService salesvc.TransactionService
+	sessionService     authsvc.SessionService
+	creatorService     accountsvc.CreatorService
+}
+
+func NewCoAuthorHandler(
+	templateRenderer template.TemplateRenderer,
+	coAuthorService librarysvc.CoAuthorService,
+	ebookService librarysvc.EbookService,
+	transactionService salesvc.TransactionService,
+	sessionService authsvc.SessionService,
+	creatorService accountsvc.CreatorService,
+) *CoAuthorHandler {
+	return &CoAuthorHandler{
+		templateRenderer:   templateRenderer,
+		coAuthorService:    coAuthorService,
+		ebookService:       ebookService,
+		transactionService: transactionService,
+		sessionService:     sessionService,
+		creatorService:     creatorService,
+	}
+}
+
+// CoAuthorsView exibe os coautores de um ebook do criador e o formulário de convite
+func (h *CoAuthorHandler) CoAuthorsView(w http.ResponseWriter, r *http.Request) {
+	creator, ok := h.loggedCreator(w, r)
+	if !ok {
+		return
+	}
+	ebook, ok := h.findCreatorEbook(w, r, creator)
+	if !ok {
+		return
+	}
+
+	coAuthors, err := h.coAuthorService.ListByEbook(ebook.ID)
+	if err != nil {
+		slog.Error("Erro ao buscar coautores", "ebookID", ebook.ID, "error", err)
+		http.Error(w, "Erro ao buscar coautores", http.StatusInternalServerError)
+		return
+	}
+
+	var committedShare float64
+	for _, coAuthor := range coAuthors {
+		if coAuthor.Status != librarymodel.CoAuthorStatusDeclined {
+			committedShare += coAuthor.Share
+		}
+	}
+
+	h.templateRenderer.View(w, r, "ebook/coauthors", map[string]interface{}{
+		"Ebook":      ebook,
+		"CoAuthors":  coAuthors,
+		"OwnerShare": strings.Replace(strconv.FormatFloat(math.Round((1-committedShare)*10000)/100, 'f', -1, 64), ".", ",", 1) + "%",
+	}, "admin-daisy")
+}
+
+// InviteCoAuthor convida outro criador para dividir a receita do ebook
+func (h *CoAuthorHandler) InviteCoAuthor(w http.ResponseWriter, r *http.Request) {
+	creator, ok := h.loggedCreator(w, r)
+	if !ok {
+		return
+	}
+	ebook, ok := h.findCreatorEbook(w, r, creator)
+	if !ok {
+		return
+	}
+
+	coAuthorsURL := "/ebook/" + ebook.PublicID + "/coauthors"
+
+	percent, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(r.FormValue("share")), ",", ".", 1), 64)
+	if err != nil {
+		cookies.NotifyError(w, "Participação inválida. Informe um percentual (ex: 30 ou 12,5)")
+		http.Redirect(w, r, coAuthorsURL, http.StatusSeeOther)
+		return
+	}
+
+	if _, err := h.coAuthorService.Invite(ebook, r.FormValue("email"), percent/100); err != nil {
+		cookies.NotifyError(w, coAuthorErrorMessage(err))
+		http.Redirect(w, r, coAuthorsURL, http.StatusSeeOther)
+		return
+	}
+
+	cookies.NotifySuccess(w, "Convite enviado! O coautor precisa aceitar em Coautorias para começar a receber.")
+	http.Redirect(w, r, coAuthorsURL, http.StatusSeeOther)
+}
+
+// RemoveCoAuthor encerra a coautoria; vendas futuras deixam de repassar a participação
+func (h *CoAuthorHandler) RemoveCoAuthor(w http.ResponseWriter, r *http.Request) {
+	creator, ok := h.loggedCreator(w, r)
+	if !ok {
+		return
+	}
+	ebook, ok := h.findCreatorEbook(w, r, creator)
+	if !ok {
+		return
+	}
+
+	coAuthorsURL := "/ebook/" + ebook.PublicID + "/coauthors"
+
+	if err := h.coAuthorService.Remove(ebook, chi.URLParam(r, "coauthorID")); err != nil {
+		cookies.NotifyError(w, coAuthorErrorMessage(err))
+		http.Redirect(w, r, coAuthorsURL, http.StatusSeeOther)
+		return
+	}
+
+	cookies.NotifySuccess(w, "Coautor removido. As próximas vendas não terão repasse para ele.")
+	http.Redirect(w, r, coAuthorsURL, http.StatusSeeOther)
+}
+
+// CoAuthorshipView exibe ao criador os convites recebidos, as coautorias ativas e os repasses
+func (h *CoAuthorHandler) CoAuthorshipView(w http.ResponseWriter, r *http.Request) {
+	creator, ok := h.loggedCreator(w, r)
+	if !ok {
+		return
+	}
+
+	coAuthorships, err := h.coAuthorService.ListForCreator(creator.ID)
+	if err != nil {
+		slog.Error("Erro ao buscar coautorias", "creatorID", creator.ID, "error", err)
+		http.Error(w, "Erro ao buscar coautorias", http.StatusInternalServerError)
+		return
+	}
+
+	var invites, accepted []*librarymodel.EbookCoAuthor
+	for _, coAuthorship := range coAuthorships {
+		if coAuthorship.IsInvited() {
+			invites = append(invites, coAuthorship)
+		} else if coAuthorship.IsAccepted() {
+			accepted = append(accepted, coAuthorship)
+		}
+	}
+
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit := 10
+
+	splits, total, err := h.transactionService.GetSplitsByCreatorID(creator.ID, salesmodel.TransactionSplitKindCoAuthor, page, limit)
+	if err != nil {
+		slog.Error("Erro ao buscar repasses de coautoria", "creatorID", creator.ID, "error", err)
+		http.Error(w, "Erro ao buscar repasses", http.StatusInternalServerError)
+		return
+	}
+
+	pagination := salesmodel.NewPagination(page, limit)
+	pagination.SetTotal(total)
+
+	h.templateRenderer.View(w, r, "ebook/coauthorship", map[string]interface{}{
+		"Invites":    invites,
+		"Accepted":   accepted,
+		"Splits":     splits,
+		"Pagination": pagination,
+		"RecordType": "repasses",
+		"CanReceive": creator.StripeConnectAccountID != "" && creator.OnboardingCompleted,
+	}, "admin-daisy")
+}
+
+func (h *CoAuthorHandler) AcceptCoAuthorship(w http.ResponseWriter, r *http.Request) {
+	h.answerInvite(w, r, true)
+}
+
+func (h *CoAuthorHandler) DeclineCoAuthorship(w http.ResponseWriter, r *http.Request) {
+	h.answerInvite(w, r, false)
+}
+
+func (h *CoAuthorHandler) answerInvite(w http.ResponseWriter, r *http.Request, accept bool) {
+	creator, ok := h.loggedCreator(w, r)
+	if !ok {
+		return
+	}
+
+	publicID := chi.URLParam(r, "id")
+	var err error
+	if accept {
+		err = h.coAuthorService.Accept(publicID, creator.ID)
+	} else {
+		err = h.coAuthorService.Decline(publicID, creator.ID)
+	}
+
+	if err != nil {
+		cookies.NotifyError(w, coAuthorErrorMessage(err))
+	} else if accept {
+		cookies.NotifySuccess(w, "Coautoria aceita! Você passa a receber sua participação nas próximas vendas.")
+	} else {
+		cookies.NotifySuccess(w, "Convite recusado")
+	}
+	http.Redirect(w, r, "/coauthorship", http.StatusSeeOther)
+}
+
+func (h *CoAuthorHandler) loggedCreator(w http.ResponseWriter, r *http.Request) (*accountmodel.Creator, bool) {
+	userEmail, err := h.sessionService.GetUserEmailFromSession(r)
+	if err != nil {
+		slog.Error("Erro ao obter email da sessão", "error", err)
+		http.Error(w, "Sessão inválida", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	creator, err := h.creatorService.FindCreatorByEmail(userEmail)
+	if err != nil {
+		slog.Error("Erro ao buscar criador", "error", err)
+		http.Error(w, "Criador não encontrado", http.StatusNotFound)
+		return nil, false
+	}
+
+	return creator, true
+}
+
+func (h *CoAuthorHandler) findCreatorEbook(w http.ResponseWriter, r *http.Request, creator *accountmodel.Creator) (*librarymodel.Ebook, bool) {
+	ebook, err := h.ebookService.FindByPublicID(chi.URLParam(r, "id"))
+	if err != nil || ebook == nil || ebook.CreatorID != creator.ID {
+		http.Error(w, "Ebook não encontrado", http.StatusNotFound)
+		return nil, false
+	}
+	return ebook, true
+}
+
+// coAuthorErrorMessage traduz os erros de regra de negócio da coautoria para a mensagem exibida ao criador
+func coAuthorErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, librarysvc.ErrCoAuthorNotFound),
+		errors.Is(err, librarysvc.ErrCoAuthorIsOwner),
+		errors.Is(err, librarysvc.ErrCoAuthorWithoutStripe),
+		errors.Is(err, librarysvc.ErrCoAuthorAlreadyInvited),
+		errors.Is(err, librarysvc.ErrCoAuthorInvalidShare),
+		errors.Is(err, librarysvc.ErrCoAuthorSharesExceeded),
+		errors.Is(err, librarysvc.ErrCoAuthorInviteNotFound):
+		msg := err.Error()
+		return strings.ToUpper(msg[:1]) + msg[1:]
+	default:
+		slog.Error("Erro na coautoria", "error", err)
+		return "Erro ao processar a coautoria. Tente novamente."
+	}
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	accountsvc "github.com/anglesson/simple-web-server/internal/account/service"
+	authsvc "github.com/anglesson/simple-web-server/internal/auth/service"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	cookies "github.com/anglesson/simple-web-server/pkg/cookie"
+	"github.com/anglesson/simple-web-server/pkg/template"
+	"github.com/go-chi/chi/v5"
+)
+
+const suspiciousCheckoutsURL = "/purchase/suspicious"
+
+type FraudHandler struct {
+	templateRenderer template.TemplateRenderer
+	fraudService     salesvc.FraudService
+	sessionService   authsvc.SessionService
+	creatorService   accountsvc.CreatorService
+}
+
+func NewFraudHandler(
+	templateRenderer template.TemplateRenderer,
+	fraudService salesvc.FraudService,
+	sessionService authsvc.SessionService,
+	creatorService accountsvc.CreatorService,
+) *FraudHandler {
+	return &FraudHandler{
+		templateRenderer: templateRenderer,
+		fraudService:     fraudService,
+		sessionService:   sessionService,
+		creatorService:   creatorService,
+	}
+}
+
+// SuspiciousCheckoutsView lista os checkouts bloqueados ou retidos para revisão nas vendas do criador
+func (h *FraudHandler) SuspiciousCheckoutsView(w http.ResponseWriter, r *http.Request) {
+	creator, ok := h.currentCreator(w, r)
+	if !ok {
+		return
+	}
+
+	onlyPending := r.URL.Query().Get("status") == "pending"
+	attempts, err := h.fraudService.ListSuspicious(creator.ID, onlyPending)
+	if err != nil {
+		slog.Error("Erro ao buscar checkouts suspeitos", "creatorID", creator.ID, "error", err)
+		http.Error(w, "Erro ao buscar checkouts suspeitos", http.StatusInternalServerError)
+		return
+	}
+
+	awaitingReview, err := h.fraudService.CountAwaitingReview(creator.ID)
+	if err != nil {
+		slog.Error("Erro ao contar checkouts em revisão", "creatorID", creator.ID, "error", err)
+	}
+
+	h.templateRenderer.View(w, r, "purchase/suspicious", map[string]interface{}{
+		"Attempts":       attempts,
+		"AwaitingReview": awaitingReview,
+		"OnlyPending":    onlyPending,
+	}, "admin-daisy")
+}
+
+// ApproveCheckout libera a entrega da compra retida para revisão
+func (h *FraudHandler) ApproveCheckout(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, true)
+}
+
+// RejectCheckout bloqueia a compra e estorna o pagamento no Stripe
+func (h *FraudHandler) RejectCheckout(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, false)
+}
+
+func (h *FraudHandler) review(w http.ResponseWriter, r *http.Request, approve bool) {
+	creator, ok := h.currentCreator(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := h.fraudService.Review(creator.ID, chi.URLParam(r, "id"), approve)
+	switch {
+	case errors.Is(err, salesvc.ErrCheckoutAttemptNotFound):
+		http.Error(w, "Checkout não encontrado", http.StatusNotFound)
+		return
+	case errors.Is(err, salesvc.ErrCheckoutAttemptNotReviewable):
+		cookies.NotifyError(w, "Este checkout já foi revisado")
+	case err != nil:
+		slog.Error("Erro ao revisar checkout suspeito", "creatorID", creator.ID, "error", err)
+		cookies.NotifyError(w, "Erro ao revisar o checkout")
+	case approve:
+		cookies.NotifySuccess(w, "Compra liberada! O cliente receberá o link de download se o pagamento estiver confirmado.")
+	default:
+		cookies.NotifySuccess(w, "Compra recusada. O acesso foi bloqueado e o pagamento será estornado ao cliente.")
+	}
+
+	http.Redirect(w, r, suspiciousCheckoutsURL, http.StatusSeeOther)
+}
+
+func (h *FraudHandler) currentCreator(w http.ResponseWriter, r *http.Request) (*accountmodel.Creator, bool) {
+	userEmail, err := h.sessionService.GetUserEmailFromSession(r)
+	if err != nil {
+		slog.Error("Erro ao obter email da sessão", "error", err)
+		http.Error(w, "Sessão inválida", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	creator, err := h.creatorService.FindCreatorByEmail(userEmail)
+	if err != nil {
+		slog.Error("Erro ao buscar criador", "error", err)
+		http.Error(w, "Criador não encontrado", http.StatusNotFound)
+		return nil, false
+	}
+	return creator, true
+}
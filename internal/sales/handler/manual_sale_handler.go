@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	accountsvc "github.com/anglesson/simple-web-server/internal/account/service"
+	authsvc "github.com/anglesson/simple-web-server/internal/auth/service"
+	librarysvc "github.com/anglesson/simple-web-server/internal/library/service"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/anglesson/simple-web-server/pkg/template"
+)
+
+type ManualSaleHandler struct {
+	manualSaleService salesvc.ManualSaleService
+	ebookService      librarysvc.EbookService
+	sessionService    authsvc.SessionService
+	creatorService    accountsvc.CreatorService
+	templateRenderer  template.TemplateRenderer
+}
+
+func NewManualSaleHandler(
+	manualSaleService salesvc.ManualSaleService,
+	ebookService librarysvc.EbookService,
+	sessionService authsvc.SessionService,
+	creatorService accountsvc.CreatorService,
+	templateRenderer template.TemplateRenderer,
+) *ManualSaleHandler {
+	return &ManualSaleHandler{
+		manualSaleService: manualSaleService,
+		ebookService:      ebookService,
+		sessionService:    sessionService,
+		creatorService:    creatorService,
+		templateRenderer:  templateRenderer,
+	}
+}
+
+// ManualSaleView exibe o formulário para registrar uma venda recebida fora da plataforma
+func (h *ManualSaleHandler) ManualSaleView(w http.ResponseWriter, r *http.Request) {
+	creator, ok := h.sessionCreator(w, r)
+	if !ok {
+		return
+	}
+
+	h.renderForm(w, r, creator, map[string]string{
+		"ebook_id": r.URL.Query().Get("ebook_id"),
+		"paid_at":  time.Now().Format("2006-01-02"),
+	}, "")
+}
+
+// RegisterManualSale cria a compra paga e envia o ebook ao comprador
+func (h *ManualSaleHandler) RegisterManualSale(w http.ResponseWriter, r *http.Request) {
+	creator, ok := h.sessionCreator(w, r)
+	if !ok {
+		return
+	}
+
+	form := map[string]string{}
+	for _, field := range []string{"ebook_id", "buyer_name", "buyer_email", "buyer_cpf", "buyer_phone", "amount", "payment_method", "paid_at"} {
+		form[field] = strings.TrimSpace(r.FormValue(field))
+	}
+
+	ebook, err := h.ebookService.FindByPublicID(form["ebook_id"])
+	if err != nil || ebook == nil || ebook.CreatorID != creator.ID {
+		h.renderForm(w, r, creator, form, "Selecione um dos seus ebooks")
+		return
+	}
+
+	amount, err := money.ParseBRL(form["amount"])
+	if err != nil {
+		h.renderForm(w, r, creator, form, "Valor inválido")
+		return
+	}
+
+	paidAt, err := time.ParseInLocation("2006-01-02", form["paid_at"], time.Local)
+	if err != nil {
+		h.renderForm(w, r, creator, form, "Data do pagamento inválida")
+		return
+	}
+
+	purchase, err := h.manualSaleService.RegisterManualSale(salesvc.ManualSaleInput{
+		CreatorID:     creator.ID,
+		EbookID:       ebook.ID,
+		BuyerName:     form["buyer_name"],
+		BuyerEmail:    form["buyer_email"],
+		BuyerCPF:      form["buyer_cpf"],
+		BuyerPhone:    form["buyer_phone"],
+		Amount:        amount,
+		PaymentMethod: form["payment_method"],
+		PaidAt:        paidAt,
+	})
+	if err != nil {
+		if errors.Is(err, salesvc.ErrManualSaleInvalid) || errors.Is(err, salesvc.ErrManualSaleDuplicate) ||
+			errors.Is(err, salesvc.ErrInvalidStatusTransition) {
+			h.renderForm(w, r, creator, form, err.Error())
+			return
+		}
+		slog.Error("Erro ao registrar venda manual", "error", err, "creatorID", creator.ID, "ebookID", ebook.ID)
+		h.renderForm(w, r, creator, form, "Não foi possível registrar a venda. Tente novamente.")
+		return
+	}
+
+	slog.Info("Venda manual registrada pelo criador", "creatorID", creator.ID, "purchasePublicID", purchase.PublicID)
+	http.Redirect(w, r, "/purchase/sales?success=manual_sale_registered", http.StatusSeeOther)
+}
+
+func (h *ManualSaleHandler) renderForm(w http.ResponseWriter, r *http.Request, creator *accountmodel.Creator, form map[string]string, errorMessage string) {
+	ebooks, err := h.ebookService.GetEbooksByCreatorID(creator.ID)
+	if err != nil {
+		slog.Error("Erro ao buscar ebooks para venda manual", "error", err, "creatorID", creator.ID)
+	}
+
+	paymentMethods := make([]map[string]string, 0, len(salesmodel.ManualPaymentMethods))
+	for _, method := range salesmodel.ManualPaymentMethods {
+		transaction := salesmodel.Transaction{PaymentMethod: method}
+		paymentMethods = append(paymentMethods, map[string]string{"Value": method, "Label": transaction.GetPaymentMethodLabel()})
+	}
+
+	if errorMessage != "" {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	h.templateRenderer.View(w, r, "purchase/manual", map[string]interface{}{
+		"Creator":        creator,
+		"Ebooks":         ebooks,
+		"PaymentMethods": paymentMethods,
+		"Form":           form,
+		"Error":          errorMessage,
+		"Today":          time.Now().Format("2006-01-02"),
+	}, "admin-daisy")
+}
+
+func (h *ManualSaleHandler) sessionCreator(w http.ResponseWriter, r *http.Request) (*accountmodel.Creator, bool) {
+	userEmail, err := h.sessionService.GetUserEmailFromSession(r)
+	if err != nil {
+		slog.Error("Erro ao obter email da sessão", "error", err)
+		http.Error(w, "Sessão inválida", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	creator, err := h.creatorService.FindCreatorByEmail(userEmail)
+	if err != nil {
+		slog.Error("Erro ao buscar criador", "error", err)
+		http.Error(w, "Criador não encontrado", http.StatusNotFound)
+		return nil, false
+	}
+	return creator, true
+}
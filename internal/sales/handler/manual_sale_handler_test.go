@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	"github.com/anglesson/simple-web-server/internal/mocks"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type mockManualSaleService struct {
+	mock.Mock
+}
+
+func (m *mockManualSaleService) RegisterManualSale(input salesvc.ManualSaleInput) (*salesmodel.Purchase, error) {
+	args := m.Called(input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*salesmodel.Purchase), args.Error(1)
+}
+
+func newManualSaleHandlerForTest() (*ManualSaleHandler, *mockManualSaleService, *mocks.MockEbookService, *mocks.MockTemplateRenderer) {
+	sessionService := new(mocks.MockSessionService)
+	sessionService.On("GetUserEmailFromSession", mock.Anything).Return("maria@test.com", nil)
+	creatorService := new(mocks.MockCreatorService)
+	creatorService.On("FindCreatorByEmail", "maria@test.com").Return(&accountmodel.Creator{Model: gorm.Model{ID: 7}}, nil)
+	ebookService := new(mocks.MockEbookService)
+	ebookService.On("GetEbooksByCreatorID", uint(7)).Return([]*librarymodel.Ebook{}, nil)
+	service := new(mockManualSaleService)
+	renderer := new(mocks.MockTemplateRenderer)
+	return NewManualSaleHandler(service, ebookService, sessionService, creatorService, renderer), service, ebookService, renderer
+}
+
+func manualSaleRequest(ebookID string) *http.Request {
+	form := url.Values{
+		"ebook_id":       {ebookID},
+		"buyer_name":     {"João Leitor"},
+		"buyer_email":    {"joao@test.com"},
+		"amount":         {"39,90"},
+		"payment_method": {"pix"},
+		"paid_at":        {"2025-03-10"},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/purchase/manual", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestManualSaleHandler_RegisterManualSale(t *testing.T) {
+	handler, service, ebookService, _ := newManualSaleHandlerForTest()
+	ebookService.On("FindByPublicID", "ebk_1").Return(&librarymodel.Ebook{Model: gorm.Model{ID: 3}, CreatorID: 7}, nil)
+	service.On("RegisterManualSale", mock.MatchedBy(func(input salesvc.ManualSaleInput) bool {
+		return input.CreatorID == 7 && input.EbookID == 3 && input.Amount.Amount == 3990 &&
+			input.PaymentMethod == "pix" && input.PaidAt.Format("2006-01-02") == "2025-03-10"
+	})).Return(&salesmodel.Purchase{PublicID: "pur_1"}, nil).Once()
+
+	w := httptest.NewRecorder()
+	handler.RegisterManualSale(w, manualSaleRequest("ebk_1"))
+
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/purchase/sales?success=manual_sale_registered", w.Header().Get("Location"))
+	service.AssertExpectations(t)
+}
+
+func TestManualSaleHandler_RejectsEbookFromAnotherCreator(t *testing.T) {
+	handler, service, ebookService, renderer := newManualSaleHandlerForTest()
+	ebookService.On("FindByPublicID", "ebk_2").Return(&librarymodel.Ebook{Model: gorm.Model{ID: 4}, CreatorID: 99}, nil)
+	renderer.On("View", mock.Anything, mock.Anything, "purchase/manual", mock.MatchedBy(func(data map[string]interface{}) bool {
+		return data["Error"] == "Selecione um dos seus ebooks"
+	}), "admin-daisy").Once()
+
+	w := httptest.NewRecorder()
+	handler.RegisterManualSale(w, manualSaleRequest("ebk_2"))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	service.AssertNotCalled(t, "RegisterManualSale", mock.Anything)
+	renderer.AssertExpectations(t)
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	accountsvc "github.com/anglesson/simple-web-server/internal/account/service"
+	authsvc "github.com/anglesson/simple-web-server/internal/auth/service"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/template"
+)
+
+type PayoutHandler struct {
+	payoutService    salesvc.PayoutService
+	sessionService   authsvc.SessionService
+	creatorService   accountsvc.CreatorService
+	templateRenderer template.TemplateRenderer
+}
+
+func NewPayoutHandler(
+	payoutService salesvc.PayoutService,
+	sessionService authsvc.SessionService,
+	creatorService accountsvc.CreatorService,
+	templateRenderer template.TemplateRenderer,
+) *PayoutHandler {
+	return &PayoutHandler{
+		payoutService:    payoutService,
+		sessionService:   sessionService,
+		creatorService:   creatorService,
+		templateRenderer: templateRenderer,
+	}
+}
+
+// PayoutsView exibe saldo, próximos repasses e a situação de recebimento de cada venda
+func (h *PayoutHandler) PayoutsView(w http.ResponseWriter, r *http.Request) {
+	userEmail, err := h.sessionService.GetUserEmailFromSession(r)
+	if err != nil {
+		slog.Error("Erro ao obter email da sessão", "error", err)
+		http.Error(w, "Sessão inválida", http.StatusUnauthorized)
+		return
+	}
+
+	creator, err := h.creatorService.FindCreatorByEmail(userEmail)
+	if err != nil {
+		slog.Error("Erro ao buscar criador", "error", err)
+		http.Error(w, "Criador não encontrado", http.StatusNotFound)
+		return
+	}
+
+	dashboard, err := h.payoutService.GetDashboard(creator)
+	if err != nil {
+		slog.Error("Erro ao carregar recebimentos", "error", err, "creatorID", creator.ID)
+		http.Error(w, "Erro ao carregar recebimentos", http.StatusInternalServerError)
+		return
+	}
+
+	h.templateRenderer.View(w, r, "payouts/index", map[string]interface{}{
+		"Creator":   creator,
+		"Dashboard": dashboard,
+		"Connected": creator.StripeConnectAccountID != "" && creator.OnboardingCompleted,
+	}, "admin-daisy")
+}
+
+// SyncPayouts busca no Stripe os repasses do criador sem esperar a sincronização periódica
+func (h *PayoutHandler) SyncPayouts(w http.ResponseWriter, r *http.Request) {
+	userEmail, err := h.sessionService.GetUserEmailFromSession(r)
+	if err != nil {
+		slog.Error("Erro ao obter email da sessão", "error", err)
+		http.Error(w, "Sessão inválida", http.StatusUnauthorized)
+		return
+	}
+
+	creator, err := h.creatorService.FindCreatorByEmail(userEmail)
+	if err != nil {
+		slog.Error("Erro ao buscar criador", "error", err)
+		http.Error(w, "Criador não encontrado", http.StatusNotFound)
+		return
+	}
+
+	if err := h.payoutService.SyncCreator(creator); err != nil {
+		slog.Error("Erro ao sincronizar repasses", "error", err, "creatorID", creator.ID)
+		http.Redirect(w, r, "/payouts?error=sync_failed", http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, "/payouts?success=synced", http.StatusSeeOther)
+}
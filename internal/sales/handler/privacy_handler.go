@@ -0,0 +1,263 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	accountsvc "github.com/anglesson/simple-web-server/internal/account/service"
+	authmw "github.com/anglesson/simple-web-server/internal/auth/handler/middleware"
+	authsvc "github.com/anglesson/simple-web-server/internal/auth/service"
+	"github.com/anglesson/simple-web-server/internal/config"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/template"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	creatorPrivacyURL  = "/client/privacy"
+	operatorPrivacyURL = "/ops/privacy"
+)
+
+// PrivacyHandler atende as solicitações dos titulares (LGPD): os criadores registram e exportam os dados
+// das próprias vendas; os operadores da plataforma acompanham todos os prazos e executam a anonimização.
+type PrivacyHandler struct {
+	privacyService   salesvc.PrivacyService
+	crmService       salesvc.ClientCRMService
+	creatorService   accountsvc.CreatorService
+	sessionManager   authsvc.SessionService
+	templateRenderer template.TemplateRenderer
+}
+
+func NewPrivacyHandler(
+	privacyService salesvc.PrivacyService,
+	crmService salesvc.ClientCRMService,
+	creatorService accountsvc.CreatorService,
+	sessionManager authsvc.SessionService,
+	templateRenderer template.TemplateRenderer,
+) *PrivacyHandler {
+	return &PrivacyHandler{
+		privacyService:   privacyService,
+		crmService:       crmService,
+		creatorService:   creatorService,
+		sessionManager:   sessionManager,
+		templateRenderer: templateRenderer,
+	}
+}
+
+// RequireOperator libera as rotas apenas para os emails configurados em PRIVACY_OPERATOR_EMAILS.
+// Para os demais usuários as rotas não existem.
+func (h *PrivacyHandler) RequireOperator(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := authmw.Auth(r)
+		if user == nil || !config.AppConfig.IsPrivacyOperator(user.Email) {
+			http.NotFound(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequestsView lista as solicitações registradas pelo criador
+func (h *PrivacyHandler) RequestsView(w http.ResponseWriter, r *http.Request) {
+	creator, ok := loggedCreator(w, r, h.creatorService, h.sessionManager)
+	if !ok {
+		return
+	}
+
+	requests, err := h.privacyService.ListByCreator(creator.ID)
+	if err != nil {
+		log.Printf("Erro ao buscar solicitações LGPD: %v", err)
+	}
+
+	h.templateRenderer.View(w, r, "privacy/list", map[string]any{
+		"Requests": requests,
+		"Now":      time.Now(),
+		"Success":  h.sessionManager.GetFlashes(w, r, "success"),
+		"Errors":   h.sessionManager.GetFlashes(w, r, "error"),
+	}, "admin-daisy")
+}
+
+// RegisterRequest registra a solicitação feita por um cliente do criador, a partir da ficha do cliente
+func (h *PrivacyHandler) RegisterRequest(w http.ResponseWriter, r *http.Request) {
+	creator, ok := loggedCreator(w, r, h.creatorService, h.sessionManager)
+	if !ok {
+		return
+	}
+
+	client, err := h.crmService.FindClient(creator.ID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.fail(w, r, "/client", err)
+		return
+	}
+
+	_, err = h.privacyService.Register(client, &creator.ID, r.FormValue("type"), r.FormValue("requester_email"), r.FormValue("details"))
+	if err != nil {
+		h.fail(w, r, "/client/"+client.PublicID, err)
+		return
+	}
+
+	h.sessionManager.AddFlash(w, r, "Solicitação registrada. Acompanhe o prazo de resposta nesta página.", "success")
+	http.Redirect(w, r, creatorPrivacyURL, http.StatusSeeOther)
+}
+
+// ExportRequest baixa o ZIP com os dados do cliente gerados nas vendas do criador
+func (h *PrivacyHandler) ExportRequest(w http.ResponseWriter, r *http.Request) {
+	creator, request, ok := h.creatorRequest(w, r)
+	if !ok {
+		return
+	}
+	h.writeExport(w, r, request, creator.ID, creatorPrivacyURL)
+}
+
+// CompleteRequest encerra a solicitação de acesso do criador depois do envio da exportação ao titular
+func (h *PrivacyHandler) CompleteRequest(w http.ResponseWriter, r *http.Request) {
+	creator, request, ok := h.creatorRequest(w, r)
+	if !ok {
+		return
+	}
+	h.finish(w, r, creatorPrivacyURL, h.privacyService.CompleteAccess(request, creator.Email), "Solicitação concluída")
+}
+
+// OperatorRequestsView lista as solicitações de todos os criadores, com as em aberto pelo prazo mais próximo
+func (h *PrivacyHandler) OperatorRequestsView(w http.ResponseWriter, r *http.Request) {
+	requests, err := h.privacyService.ListAll()
+	if err != nil {
+		log.Printf("Erro ao buscar solicitações LGPD: %v", err)
+	}
+
+	now := time.Now()
+	open, overdue := 0, 0
+	for _, request := range requests {
+		if request.IsOpen() {
+			open++
+		}
+		if request.IsOverdue(now) {
+			overdue++
+		}
+	}
+
+	h.templateRenderer.View(w, r, "privacy/operator", map[string]any{
+		"Requests": requests,
+		"Open":     open,
+		"Overdue":  overdue,
+		"Now":      now,
+		"Success":  h.sessionManager.GetFlashes(w, r, "success"),
+		"Errors":   h.sessionManager.GetFlashes(w, r, "error"),
+	}, "admin-daisy")
+}
+
+// OperatorRegisterRequest registra uma solicitação recebida pelos canais da plataforma
+func (h *PrivacyHandler) OperatorRegisterRequest(w http.ResponseWriter, r *http.Request) {
+	client, err := h.privacyService.FindClient(r.FormValue("client"))
+	if err != nil {
+		h.fail(w, r, operatorPrivacyURL, err)
+		return
+	}
+
+	_, err = h.privacyService.Register(client, nil, r.FormValue("type"), r.FormValue("requester_email"), r.FormValue("details"))
+	if err != nil {
+		h.fail(w, r, operatorPrivacyURL, err)
+		return
+	}
+
+	h.sessionManager.AddFlash(w, r, "Solicitação registrada", "success")
+	http.Redirect(w, r, operatorPrivacyURL, http.StatusSeeOther)
+}
+
+// OperatorExportRequest baixa o ZIP com todos os dados do cliente na plataforma
+func (h *PrivacyHandler) OperatorExportRequest(w http.ResponseWriter, r *http.Request) {
+	request, ok := h.operatorRequest(w, r)
+	if !ok {
+		return
+	}
+	h.writeExport(w, r, request, 0, operatorPrivacyURL)
+}
+
+func (h *PrivacyHandler) OperatorCompleteRequest(w http.ResponseWriter, r *http.Request) {
+	request, ok := h.operatorRequest(w, r)
+	if !ok {
+		return
+	}
+	h.finish(w, r, operatorPrivacyURL, h.privacyService.CompleteAccess(request, authmw.Auth(r).Email), "Solicitação concluída")
+}
+
+// OperatorAnonymize atende a solicitação de eliminação anonimizando o cliente
+func (h *PrivacyHandler) OperatorAnonymize(w http.ResponseWriter, r *http.Request) {
+	request, ok := h.operatorRequest(w, r)
+	if !ok {
+		return
+	}
+	h.finish(w, r, operatorPrivacyURL, h.privacyService.Anonymize(request, authmw.Auth(r).Email), "Cliente anonimizado e solicitação concluída")
+}
+
+func (h *PrivacyHandler) OperatorReject(w http.ResponseWriter, r *http.Request) {
+	request, ok := h.operatorRequest(w, r)
+	if !ok {
+		return
+	}
+	h.finish(w, r, operatorPrivacyURL, h.privacyService.Reject(request, authmw.Auth(r).Email, r.FormValue("reason")), "Solicitação recusada")
+}
+
+func (h *PrivacyHandler) creatorRequest(w http.ResponseWriter, r *http.Request) (*accountmodel.Creator, *salesmodel.PrivacyRequest, bool) {
+	creator, ok := loggedCreator(w, r, h.creatorService, h.sessionManager)
+	if !ok {
+		return nil, nil, false
+	}
+	request, err := h.privacyService.FindForCreator(creator.ID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.fail(w, r, creatorPrivacyURL, err)
+		return nil, nil, false
+	}
+	return creator, request, true
+}
+
+func (h *PrivacyHandler) operatorRequest(w http.ResponseWriter, r *http.Request) (*salesmodel.PrivacyRequest, bool) {
+	request, err := h.privacyService.Find(chi.URLParam(r, "id"))
+	if err != nil {
+		h.fail(w, r, operatorPrivacyURL, err)
+		return nil, false
+	}
+	return request, true
+}
+
+func (h *PrivacyHandler) writeExport(w http.ResponseWriter, r *http.Request, request *salesmodel.PrivacyRequest, creatorID uint, backURL string) {
+	export, err := h.privacyService.Export(request, creatorID)
+	if err != nil {
+		h.fail(w, r, backURL, err)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", "attachment; filename="+strconv.Quote(export.FileName))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Write(export.Content)
+}
+
+func (h *PrivacyHandler) finish(w http.ResponseWriter, r *http.Request, backURL string, err error, success string) {
+	if err != nil {
+		h.fail(w, r, backURL, err)
+		return
+	}
+	h.sessionManager.AddFlash(w, r, success, "success")
+	http.Redirect(w, r, backURL, http.StatusSeeOther)
+}
+
+// fail exibe os erros de validação para o usuário e registra no log os inesperados
+func (h *PrivacyHandler) fail(w http.ResponseWriter, r *http.Request, backURL string, err error) {
+	message := err.Error()
+	switch {
+	case errors.Is(err, salesvc.ErrPrivacyInvalid), errors.Is(err, salesvc.ErrPrivacyRequestNotFound),
+		errors.Is(err, salesvc.ErrPrivacyClientNotFound), errors.Is(err, salesvc.ErrPrivacyRequestClosed),
+		errors.Is(err, salesvc.ErrPrivacyDuplicate), errors.Is(err, salesvc.ErrPrivacyNotExported),
+		errors.Is(err, salesvc.ErrCRMClientNotFound):
+	default:
+		log.Printf("Erro na solicitação LGPD: %v", err)
+		message = "Não foi possível concluir a operação. Tente novamente."
+	}
+	h.sessionManager.AddFlash(w, r, message, "error")
+	http.Redirect(w, r, backURL, http.StatusSeeOther)
+}
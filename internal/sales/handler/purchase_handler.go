@@ -12,6 +12,7 @@ import (
 	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
 	"github.com/anglesson/simple-web-server/internal/config"
 	cookies "github.com/anglesson/simple-web-server/pkg/cookie"
+	"github.com/anglesson/simple-web-server/pkg/database"
 	"github.com/anglesson/simple-web-server/pkg/mail"
 	"github.com/anglesson/simple-web-server/pkg/template"
 	"github.com/go-chi/chi/v5"
@@ -35,7 +36,8 @@ func purchaseServiceFactory() salesvc.PurchaseService {
 		config.AppConfig.MailHost,
 		mailPort,
 		config.AppConfig.MailUsername,
-		config.AppConfig.MailPassword))
+		config.AppConfig.MailPassword),
+		salesvc.NewReceiptService(salesrepo.NewTransactionRepository(database.DB)))
 	pr := salesrepo.NewPurchaseRepository()
 	return salesvc.NewPurchaseService(pr, ms)
 }
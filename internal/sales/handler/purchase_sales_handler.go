@@ -1,11 +1,14 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
 	accountsvc "github.com/anglesson/simple-web-server/internal/account/service"
 	authsvc "github.com/anglesson/simple-web-server/internal/auth/service"
 	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
@@ -13,6 +16,7 @@ import (
 	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
 	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
 	"github.com/anglesson/simple-web-server/pkg/template"
+	"github.com/go-chi/chi/v5"
 )
 
 type PurchaseSalesHandler struct {
@@ -174,6 +178,10 @@ func (h *PurchaseSalesHandler) BlockDownload(w http.ResponseWriter, r *http.Requ
 
 	err = h.purchaseService.BlockDownload(purchase.ID, creator.ID, true)
 	if err != nil {
+		if errors.Is(err, salesvc.ErrInvalidStatusTransition) {
+			http.Redirect(w, r, "/purchase/sales?error=invalid_status_transition", http.StatusSeeOther)
+			return
+		}
 		slog.Error("Erro ao bloquear download", "error", err, "purchaseID", purchase.ID)
 		http.Error(w, "Erro interno do servidor", http.StatusInternalServerError)
 		return
@@ -233,6 +241,10 @@ func (h *PurchaseSalesHandler) UnblockDownload(w http.ResponseWriter, r *http.Re
 
 	err = h.purchaseService.BlockDownload(purchase.ID, creator.ID, false)
 	if err != nil {
+		if errors.Is(err, salesvc.ErrInvalidStatusTransition) {
+			http.Redirect(w, r, "/purchase/sales?error=invalid_status_transition", http.StatusSeeOther)
+			return
+		}
 		slog.Error("Erro ao desbloquear download", "error", err, "purchaseID", purchase.ID)
 		http.Error(w, "Erro interno do servidor", http.StatusInternalServerError)
 		return
@@ -307,3 +319,91 @@ func (h *PurchaseSalesHandler) ResendDownloadLink(w http.ResponseWriter, r *http
 
 	http.Redirect(w, r, fmt.Sprintf("/purchase/sales?success=download_link_resent&purchase_id=%s", purchasePublicID), http.StatusSeeOther)
 }
+
+// RevokeAccess revoga definitivamente o acesso do cliente a uma venda
+func (h *PurchaseSalesHandler) RevokeAccess(w http.ResponseWriter, r *http.Request) {
+	purchasePublicID := r.FormValue("purchase_id")
+	if purchasePublicID == "" {
+		slog.Error("ID de purchase não fornecido")
+		http.Error(w, "ID de purchase inválido", http.StatusBadRequest)
+		return
+	}
+
+	creator, purchase, ok := h.findCreatorPurchase(w, r, purchasePublicID)
+	if !ok {
+		return
+	}
+
+	err := h.purchaseService.RevokeAccess(purchase.ID, creator.ID, strings.TrimSpace(r.FormValue("reason")))
+	if err != nil {
+		if errors.Is(err, salesvc.ErrInvalidStatusTransition) {
+			http.Redirect(w, r, "/purchase/sales?error=invalid_status_transition", http.StatusSeeOther)
+			return
+		}
+		slog.Error("Erro ao revogar acesso", "error", err, "purchaseID", purchase.ID)
+		http.Error(w, "Erro interno do servidor", http.StatusInternalServerError)
+		return
+	}
+
+	slog.Info("Acesso revogado com sucesso",
+		"purchasePublicID", purchasePublicID,
+		"creatorID", creator.ID,
+		"clientID", purchase.ClientID)
+
+	http.Redirect(w, r, "/purchase/sales?success=access_revoked", http.StatusSeeOther)
+}
+
+// PurchaseStatusHistoryView exibe o histórico de status de uma venda
+func (h *PurchaseSalesHandler) PurchaseStatusHistoryView(w http.ResponseWriter, r *http.Request) {
+	_, purchase, ok := h.findCreatorPurchase(w, r, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	history, err := h.purchaseService.GetStatusHistory(purchase.ID)
+	if err != nil {
+		slog.Error("Erro ao buscar histórico de status", "error", err, "purchaseID", purchase.ID)
+		http.Error(w, "Erro ao buscar histórico de status", http.StatusInternalServerError)
+		return
+	}
+
+	h.templateRenderer.View(w, r, "purchase/status-history", map[string]interface{}{
+		"Purchase": purchase,
+		"History":  history,
+	}, "admin-daisy")
+}
+
+// findCreatorPurchase busca a venda e garante que ela pertence ao criador da sessão
+func (h *PurchaseSalesHandler) findCreatorPurchase(w http.ResponseWriter, r *http.Request, purchasePublicID string) (*accountmodel.Creator, *salesmodel.Purchase, bool) {
+	userEmail, err := h.sessionService.GetUserEmailFromSession(r)
+	if err != nil {
+		slog.Error("Erro ao obter email da sessão", "error", err)
+		http.Error(w, "Sessão inválida", http.StatusUnauthorized)
+		return nil, nil, false
+	}
+
+	creator, err := h.creatorService.FindCreatorByEmail(userEmail)
+	if err != nil {
+		slog.Error("Erro ao buscar criador", "error", err)
+		http.Error(w, "Criador não encontrado", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	purchase, err := h.purchaseService.GetPurchaseByPublicID(purchasePublicID)
+	if err != nil || purchase == nil {
+		slog.Error("Erro ao buscar purchase", "error", err)
+		http.Error(w, "Venda não encontrada", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	if purchase.Ebook.CreatorID != creator.ID {
+		slog.Warn("Acesso não autorizado à venda",
+			"purchasePublicID", purchasePublicID,
+			"creatorID", creator.ID,
+			"ownerID", purchase.Ebook.CreatorID)
+		http.Error(w, "Acesso negado", http.StatusForbidden)
+		return nil, nil, false
+	}
+
+	return creator, purchase, true
+}
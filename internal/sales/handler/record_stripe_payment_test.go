@@ -8,6 +8,7 @@ import (
 	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
 	"github.com/anglesson/simple-web-server/internal/mocks"
 	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"gorm.io/gorm"
@@ -44,7 +45,7 @@ func TestRecordStripePayment_PendingTransaction_UpdatesToCompleted(t *testing.T)
 	mockTx.On("FindTransactionByPurchaseID", uint(1)).Return(tx, nil)
 	mockTx.On("UpdateTransactionToCompleted", uint(1), "pi_new_intent").Return(nil)
 
-	ebook := &librarymodel.Ebook{Value: 50}
+	ebook := &librarymodel.Ebook{Value: money.FromCents(5000)}
 	handler := buildHandlerForRecordStripePayment(mockTx)
 
 	handler.recordStripePayment(1, 10, ebook, "pi_new_intent")
@@ -61,7 +62,7 @@ func TestRecordStripePayment_NoTransaction_CallsUpdate(t *testing.T) {
 	mockTx.On("FindTransactionByPurchaseID", uint(2)).Return((*salesmodel.Transaction)(nil), errors.New("not found"))
 	mockTx.On("UpdateTransactionToCompleted", uint(2), "pi_fresh").Return(nil)
 
-	ebook := &librarymodel.Ebook{Value: 100}
+	ebook := &librarymodel.Ebook{Value: money.FromCents(10000)}
 	handler := buildHandlerForRecordStripePayment(mockTx)
 
 	handler.recordStripePayment(2, 20, ebook, "pi_fresh")
@@ -85,7 +86,7 @@ func TestRecordStripePayment_CompletedWithDifferentIntent_CreatesNewTransaction(
 			tx.ProcessedAt != nil
 	})).Return(nil)
 
-	ebook := &librarymodel.Ebook{Value: 50}
+	ebook := &librarymodel.Ebook{Value: money.FromCents(5000)}
 	handler := buildHandlerForRecordStripePayment(mockTx)
 
 	handler.recordStripePayment(1, 10, ebook, "pi_new_different_intent")
@@ -103,7 +104,7 @@ func TestRecordStripePayment_CompletedWithSameIntent_CallsUpdate(t *testing.T) {
 	mockTx.On("FindTransactionByPurchaseID", uint(5)).Return(existingTx, nil)
 	mockTx.On("UpdateTransactionToCompleted", uint(5), "pi_same_intent").Return(nil)
 
-	ebook := &librarymodel.Ebook{Value: 30}
+	ebook := &librarymodel.Ebook{Value: money.FromCents(3000)}
 	handler := buildHandlerForRecordStripePayment(mockTx)
 
 	handler.recordStripePayment(5, 20, ebook, "pi_same_intent")
@@ -121,7 +122,7 @@ func TestRecordStripePayment_CompletedWithDifferentIntent_CreateFails(t *testing
 	mockTx.On("FindTransactionByPurchaseID", uint(7)).Return(existingTx, nil)
 	mockTx.On("CreateDirectTransaction", mock.Anything).Return(errors.New("db error"))
 
-	ebook := &librarymodel.Ebook{Value: 75}
+	ebook := &librarymodel.Ebook{Value: money.FromCents(7500)}
 	handler := buildHandlerForRecordStripePayment(mockTx)
 
 	// Não deve entrar em panic
@@ -140,7 +141,7 @@ func TestRecordStripePayment_UpdateFails_LogsError(t *testing.T) {
 	mockTx.On("FindTransactionByPurchaseID", uint(9)).Return(tx, nil)
 	mockTx.On("UpdateTransactionToCompleted", uint(9), "pi_x").Return(errors.New("update error"))
 
-	ebook := &librarymodel.Ebook{Value: 20}
+	ebook := &librarymodel.Ebook{Value: money.FromCents(2000)}
 	handler := buildHandlerForRecordStripePayment(mockTx)
 
 	assert.NotPanics(t, func() {
@@ -162,7 +163,7 @@ func TestRecordStripePayment_NewTransactionHasCorrectFields(t *testing.T) {
 		capturedTx = args.Get(0).(*salesmodel.Transaction)
 	}).Return(nil)
 
-	ebook := &librarymodel.Ebook{Value: 100, PromotionalValue: 80}
+	ebook := &librarymodel.Ebook{Value: money.FromCents(10000), PromotionalValue: money.FromCents(8000)}
 	handler := buildHandlerForRecordStripePayment(mockTx)
 	beforeCall := time.Now()
 
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"encoding/csv"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	accountsvc "github.com/anglesson/simple-web-server/internal/account/service"
+	authsvc "github.com/anglesson/simple-web-server/internal/auth/service"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/template"
+)
+
+type StatementHandler struct {
+	statementService salesvc.StatementService
+	sessionService   authsvc.SessionService
+	creatorService   accountsvc.CreatorService
+	templateRenderer template.TemplateRenderer
+}
+
+func NewStatementHandler(
+	statementService salesvc.StatementService,
+	sessionService authsvc.SessionService,
+	creatorService accountsvc.CreatorService,
+	templateRenderer template.TemplateRenderer,
+) *StatementHandler {
+	return &StatementHandler{
+		statementService: statementService,
+		sessionService:   sessionService,
+		creatorService:   creatorService,
+		templateRenderer: templateRenderer,
+	}
+}
+
+// StatementView exibe o extrato mensal do criador por ebook
+func (h *StatementHandler) StatementView(w http.ResponseWriter, r *http.Request) {
+	creator, statement, ok := h.loadStatement(w, r)
+	if !ok {
+		return
+	}
+
+	h.templateRenderer.View(w, r, "transactions/statements", map[string]interface{}{
+		"Creator":   creator,
+		"Statement": statement,
+		"PrevMonth": statement.Month.AddDate(0, -1, 0).Format("2006-01"),
+		"NextMonth": statement.Month.AddDate(0, 1, 0).Format("2006-01"),
+		"IsCurrent": !statement.Month.Before(salesmodel.StatementMonth(time.Now())),
+	}, "admin-daisy")
+}
+
+// StatementCSV exporta o extrato do mês em CSV, com os valores em centavos convertidos para decimal
+func (h *StatementHandler) StatementCSV(w http.ResponseWriter, r *http.Request) {
+	_, statement, ok := h.loadStatement(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=extrato-"+statement.GetMonthParam()+".csv")
+
+	writer := csv.NewWriter(w)
+	defer writer.Flush()
+
+	writer.Write([]string{"Mês", "E-book", "Moeda", "Vendas", "Reembolsos", "Bruto", "Taxa Stripe", "Taxa plataforma", "Repasses", "Valor reembolsado", "Líquido"})
+	for _, line := range append(append([]*salesmodel.StatementLine{}, statement.Lines...), statement.Totals...) {
+		writer.Write([]string{
+			statement.GetMonthParam(),
+			line.EbookTitle,
+			line.Currency,
+			strconv.FormatInt(line.Sales, 10),
+			strconv.FormatInt(line.Refunds, 10),
+			csvAmount(line.Gross),
+			csvAmount(line.ProcessingFee),
+			csvAmount(line.PlatformFee),
+			csvAmount(line.Splits),
+			csvAmount(line.RefundAmount),
+			csvAmount(line.Net),
+		})
+	}
+}
+
+// StatementPDF exporta o extrato do mês em PDF
+func (h *StatementHandler) StatementPDF(w http.ResponseWriter, r *http.Request) {
+	creator, statement, ok := h.loadStatement(w, r)
+	if !ok {
+		return
+	}
+
+	pdf, err := h.statementService.RenderPDF(creator, statement)
+	if err != nil {
+		slog.Error("Erro ao gerar PDF do extrato", "error", err, "creatorID", creator.ID)
+		http.Error(w, "Não foi possível gerar o extrato", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", "attachment; filename=extrato-"+statement.GetMonthParam()+".pdf")
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Write(pdf)
+}
+
+// loadStatement resolve o criador da sessão e gera o extrato do mês em ?month=2025-03 (padrão: mês atual)
+func (h *StatementHandler) loadStatement(w http.ResponseWriter, r *http.Request) (*accountmodel.Creator, *salesmodel.MonthlyStatement, bool) {
+	month := time.Now()
+	if value := r.URL.Query().Get("month"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01", value, time.Local)
+		if err != nil {
+			http.Error(w, "Mês inválido", http.StatusBadRequest)
+			return nil, nil, false
+		}
+		month = parsed
+	}
+
+	userEmail, err := h.sessionService.GetUserEmailFromSession(r)
+	if err != nil {
+		slog.Error("Erro ao obter email da sessão", "error", err)
+		http.Error(w, "Sessão inválida", http.StatusUnauthorized)
+		return nil, nil, false
+	}
+
+	creator, err := h.creatorService.FindCreatorByEmail(userEmail)
+	if err != nil {
+		slog.Error("Erro ao buscar criador", "error", err)
+		http.Error(w, "Criador não encontrado", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	statement, err := h.statementService.Generate(creator.ID, month)
+	if err != nil {
+		slog.Error("Erro ao gerar extrato", "error", err, "creatorID", creator.ID)
+		http.Error(w, "Erro ao gerar extrato", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	return creator, statement, true
+}
+
+// csvAmount converte centavos para decimal com ponto, formato aceito pelas planilhas e pelo contador
+func csvAmount(cents int64) string {
+	return strconv.FormatFloat(float64(cents)/100, 'f', 2, 64)
+}
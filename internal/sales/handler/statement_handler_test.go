@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	"github.com/anglesson/simple-web-server/internal/mocks"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestStatementHandler_StatementCSV(t *testing.T) {
+	sessionService := new(mocks.MockSessionService)
+	sessionService.On("GetUserEmailFromSession", mock.Anything).Return("maria@test.com", nil)
+	creatorService := new(mocks.MockCreatorService)
+	creatorService.On("FindCreatorByEmail", "maria@test.com").Return(&accountmodel.Creator{Model: gorm.Model{ID: 7}}, nil)
+
+	month := time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local)
+	statement := salesmodel.NewMonthlyStatement(7, month, []*salesmodel.StatementLine{
+		{EbookID: 1, EbookTitle: "Guia Prático", Currency: money.BRL, Sales: 2, Gross: 20000, ProcessingFee: 800, PlatformFee: 1000, Net: 18200},
+	}, nil)
+	statementService := new(mocks.MockStatementService)
+	statementService.On("Generate", uint(7), month).Return(statement, nil).Once()
+
+	handler := NewStatementHandler(statementService, sessionService, creatorService, nil)
+	req := httptest.NewRequest(http.MethodGet, "/transactions/statements/csv?month=2025-03", nil)
+	w := httptest.NewRecorder()
+
+	handler.StatementCSV(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "extrato-2025-03.csv")
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, "2025-03,Guia Prático,BRL,2,0,200.00,8.00,10.00,0.00,0.00,182.00", lines[1])
+	assert.True(t, strings.HasPrefix(lines[2], "2025-03,Total,BRL,2,"))
+	statementService.AssertExpectations(t)
+}
+
+func TestStatementHandler_InvalidMonth(t *testing.T) {
+	handler := NewStatementHandler(new(mocks.MockStatementService), nil, nil, nil)
+	req := httptest.NewRequest(http.MethodGet, "/transactions/statements?month=marco", nil)
+	w := httptest.NewRecorder()
+
+	handler.StatementView(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -29,6 +29,11 @@ type StripeHandler struct {
 	emailService        salesvc.IEmailService
 	transactionService  salesvc.TransactionService
 	creatorService      accountsvc.CreatorService
+	recoveryService     salesvc.CheckoutRecoveryService
+	stockService        salesvc.StockService
+	fraudService        salesvc.FraudService
+	payoutService       salesvc.PayoutService
+	paymentConfirmation salesvc.PaymentConfirmationService
 }
 
 func NewStripeHandler(
@@ -39,6 +44,11 @@ func NewStripeHandler(
 	emailService salesvc.IEmailService,
 	transactionService salesvc.TransactionService,
 	creatorService accountsvc.CreatorService,
+	recoveryService salesvc.CheckoutRecoveryService,
+	stockService salesvc.StockService,
+	fraudService salesvc.FraudService,
+	payoutService salesvc.PayoutService,
+	paymentConfirmation salesvc.PaymentConfirmationService,
 ) *StripeHandler {
 	return &StripeHandler{
 		userRepository:      userRepository,
@@ -48,6 +58,11 @@ func NewStripeHandler(
 		emailService:        emailService,
 		transactionService:  transactionService,
 		creatorService:      creatorService,
+		recoveryService:     recoveryService,
+		stockService:        stockService,
+		fraudService:        fraudService,
+		payoutService:       payoutService,
+		paymentConfirmation: paymentConfirmation,
 	}
 }
 
@@ -240,6 +255,114 @@ func (h *StripeHandler) HandleStripeWebhook(w http.ResponseWriter, r *http.Reque
 			}
 		}
 
+	case "checkout.session.expired":
+		var stripeSession stripe.CheckoutSession
+		err := json.Unmarshal(event.Data.Raw, &stripeSession)
+		if err != nil {
+			log.Printf("Error parsing checkout session: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if stripeSession.Mode == stripe.CheckoutSessionModePayment {
+			err = h.handleEbookCheckoutExpired(stripeSession)
+			if err != nil {
+				log.Printf("Error handling expired checkout session: %v", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+
+	case "checkout.session.async_payment_failed":
+		var stripeSession stripe.CheckoutSession
+		err := json.Unmarshal(event.Data.Raw, &stripeSession)
+		if err != nil {
+			log.Printf("Error parsing checkout session: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if stripeSession.Mode == stripe.CheckoutSessionModePayment {
+			h.handleEbookPaymentFailed(stripeSession.Metadata)
+		}
+
+	case "payment_intent.payment_failed":
+		var paymentIntent stripe.PaymentIntent
+		err := json.Unmarshal(event.Data.Raw, &paymentIntent)
+		if err != nil {
+			log.Printf("Error parsing payment intent: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		h.handleEbookPaymentFailed(paymentIntent.Metadata)
+
+	case "charge.succeeded":
+		var charge stripe.Charge
+		err := json.Unmarshal(event.Data.Raw, &charge)
+		if err != nil {
+			log.Printf("Error parsing charge: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		h.handleEbookChargeSucceeded(charge)
+
+	case "charge.refunded":
+		var charge stripe.Charge
+		err := json.Unmarshal(event.Data.Raw, &charge)
+		if err != nil {
+			log.Printf("Error parsing charge: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		// Reembolsos parciais não encerram o acesso do comprador
+		if charge.Refunded {
+			h.changeEbookPurchaseStatus(charge.Metadata, charge.PaymentIntent, salesmodel.PurchaseStatusRefunded, "Pagamento reembolsado no Stripe")
+		}
+
+	case "charge.dispute.created":
+		var dispute stripe.Dispute
+		err := json.Unmarshal(event.Data.Raw, &dispute)
+		if err != nil {
+			log.Printf("Error parsing dispute: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		reason := fmt.Sprintf("Contestação aberta pelo comprador (%s)", dispute.Reason)
+		h.changeEbookPurchaseStatus(dispute.Metadata, dispute.PaymentIntent, salesmodel.PurchaseStatusChargeback, reason)
+
+	case "charge.dispute.closed":
+		var dispute stripe.Dispute
+		err := json.Unmarshal(event.Data.Raw, &dispute)
+		if err != nil {
+			log.Printf("Error parsing dispute: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if dispute.Status == stripe.DisputeStatusWon {
+			h.changeEbookPurchaseStatus(dispute.Metadata, dispute.PaymentIntent, salesmodel.PurchaseStatusPaid, "Contestação encerrada a favor do vendedor")
+		}
+
+	case "payout.created", "payout.updated", "payout.paid", "payout.failed", "payout.canceled":
+		var payout stripe.Payout
+		err := json.Unmarshal(event.Data.Raw, &payout)
+		if err != nil {
+			log.Printf("Error parsing payout: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		// Eventos de contas conectadas trazem a conta do criador em event.Account
+		if err := h.payoutService.HandlePayoutEvent(event.Account, &payout); err != nil {
+			log.Printf("Error syncing payout %s: %v", payout.ID, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
 	case "customer.subscription.updated":
 		var stripeSubscription stripe.Subscription
 		err := json.Unmarshal(event.Data.Raw, &stripeSubscription)
@@ -350,41 +473,137 @@ func (h *StripeHandler) handleEbookPayment(stripeSession stripe.CheckoutSession)
 		return fmt.Errorf("purchase não encontrado após criação")
 	}
 
-	paymentIntentID := stripeSession.PaymentIntent.ID
+	return h.paymentConfirmation.ConfirmEbookPayment(purchaseWithRelations, stripeSession.PaymentIntent.ID)
+}
 
-	// A transação pendente foi criada durante o checkout (CreateEbookCheckout).
-	// O webhook apenas a confirma — nunca cria uma segunda transação para a mesma purchase.
-	if err := h.transactionService.UpdateTransactionToCompleted(purchase.ID, paymentIntentID); err != nil {
-		log.Printf("Aviso: não foi possível atualizar transação para purchase_id=%d: %v", purchase.ID, err)
-	} else {
-		log.Printf("Transação atualizada para completed: purchase_id=%d", purchase.ID)
+// handleEbookCheckoutExpired libera a reserva de estoque e registra o checkout abandonado para o envio dos emails de recuperação
+func (h *StripeHandler) handleEbookCheckoutExpired(stripeSession stripe.CheckoutSession) error {
+	purchaseIDStr := stripeSession.Metadata["purchase_id"]
+	if purchaseIDStr == "" {
+		log.Printf("Sessão expirada sem purchase_id, ignorando: %s", stripeSession.ID)
+		return nil
 	}
 
-	if purchaseWithRelations.Client.ID == 0 {
-		log.Printf("Cliente não foi carregado! Client.ID=0")
-	} else {
-		log.Printf("Cliente carregado: ID=%d, Name='%s', Email='%s'",
-			purchaseWithRelations.Client.ID,
-			purchaseWithRelations.Client.Name,
-			purchaseWithRelations.Client.Email)
+	purchaseID, err := strconv.ParseUint(purchaseIDStr, 10, 32)
+	if err != nil {
+		return fmt.Errorf("purchase ID inválido: %v", err)
 	}
 
-	if err := h.purchaseService.ConfirmPayment(purchase.ID); err != nil {
-		log.Printf("Erro ao confirmar pagamento para purchase_id=%d: %v", purchase.ID, err)
-	} else {
-		log.Printf("Pagamento confirmado para purchase_id=%d", purchase.ID)
+	purchase, err := h.purchaseService.GetPurchaseByID(uint(purchaseID))
+	if err != nil {
+		return fmt.Errorf("erro ao buscar compra: %v", err)
+	}
+	// Outra sessão foi aberta depois desta (nova tentativa ou recuperação) e ainda pode ser paga
+	if !purchase.IsActiveCheckoutSession(stripeSession.ID) {
+		log.Printf("Sessão %s da purchase_id=%d substituída por %s, ignorando expiração",
+			stripeSession.ID, purchaseID, purchase.CheckoutSessionID)
+		return nil
 	}
 
-	if purchaseWithRelations.Client.Email == "" {
-		log.Printf("Cliente sem email: ClientID=%d", purchaseWithRelations.ClientID)
-		return fmt.Errorf("cliente sem email válido")
+	expiredAt := time.Now()
+	if stripeSession.ExpiresAt > 0 {
+		expiredAt = time.Unix(stripeSession.ExpiresAt, 0)
 	}
 
-	log.Printf("Enviando email para: %s", purchaseWithRelations.Client.Email)
+	// Devolve ao estoque a unidade reservada pela sessão expirada
+	if err := h.stockService.ReleaseForPurchase(uint(purchaseID)); err != nil {
+		log.Printf("Erro ao liberar estoque da purchase_id=%d: %v", purchaseID, err)
+	}
 
-	go h.emailService.SendLinkToDownload([]*salesmodel.Purchase{purchaseWithRelations})
+	// Compra recusada na revisão de fraude: o checkout foi encerrado e não entra na recuperação
+	if purchase.IsAccessRevoked() {
+		return nil
+	}
 
-	return nil
+	change := salesmodel.StatusChange{Actor: salesmodel.StatusActorStripe, Reason: "Sessão de checkout expirada sem pagamento"}
+	if err := h.purchaseService.ChangeStatus(uint(purchaseID), salesmodel.PurchaseStatusExpired, change); err != nil {
+		log.Printf("Status da purchase_id=%d não alterado para expirada: %v", purchaseID, err)
+	}
+
+	return h.recoveryService.RegisterExpiredSession(uint(purchaseID), stripeSession.ID, expiredAt)
+}
+
+// handleEbookPaymentFailed registra o pagamento recusado na análise de risco do comprador.
+// Eventos sem purchase_id (ex.: assinaturas) são ignorados.
+func (h *StripeHandler) handleEbookPaymentFailed(metadata map[string]string) {
+	purchaseIDStr := metadata["purchase_id"]
+	if purchaseIDStr == "" || h.fraudService == nil {
+		return
+	}
+
+	purchaseID, err := strconv.ParseUint(purchaseIDStr, 10, 32)
+	if err != nil {
+		log.Printf("purchase ID inválido no pagamento recusado: %v", err)
+		return
+	}
+
+	purchase, err := h.purchaseRepository.FindByID(uint(purchaseID))
+	if err != nil {
+		log.Printf("Erro ao buscar compra do pagamento recusado purchase_id=%d: %v", purchaseID, err)
+		return
+	}
+
+	input := salesvc.CheckoutRiskInput{
+		CreatorID: purchase.Ebook.CreatorID,
+		EbookID:   purchase.EbookID,
+		Name:      purchase.Client.Name,
+		CPF:       purchase.Client.CPF,
+		Email:     purchase.Client.Email,
+	}
+	if err := h.fraudService.RecordPaymentFailure(input, purchase.ID); err != nil {
+		log.Printf("Erro ao registrar pagamento recusado purchase_id=%d: %v", purchase.ID, err)
+	}
+}
+
+// changeEbookPurchaseStatus aplica a mudança de status vinda de reembolsos e contestações.
+func (h *StripeHandler) changeEbookPurchaseStatus(metadata map[string]string, paymentIntent *stripe.PaymentIntent, to salesmodel.PurchaseStatus, reason string) {
+	purchaseID, ok := h.findEbookPurchaseID(metadata, paymentIntent)
+	if !ok {
+		return
+	}
+
+	change := salesmodel.StatusChange{Actor: salesmodel.StatusActorStripe, Reason: reason}
+	if err := h.purchaseService.ChangeStatus(purchaseID, to, change); err != nil {
+		log.Printf("Erro ao alterar status da purchase_id=%d para %s: %v", purchaseID, to, err)
+	}
+}
+
+// handleEbookChargeSucceeded registra a forma de pagamento da cobrança para o recibo do comprador
+func (h *StripeHandler) handleEbookChargeSucceeded(charge stripe.Charge) {
+	if charge.PaymentMethodDetails == nil || charge.PaymentMethodDetails.Type == "" {
+		return
+	}
+
+	purchaseID, ok := h.findEbookPurchaseID(charge.Metadata, charge.PaymentIntent)
+	if !ok {
+		return
+	}
+
+	if err := h.transactionService.RecordPaymentMethod(purchaseID, string(charge.PaymentMethodDetails.Type)); err != nil {
+		log.Printf("Erro ao registrar forma de pagamento da purchase_id=%d: %v", purchaseID, err)
+	}
+}
+
+// findEbookPurchaseID localiza a compra pelo purchase_id dos metadados ou, na falta dele, pelo PaymentIntent
+func (h *StripeHandler) findEbookPurchaseID(metadata map[string]string, paymentIntent *stripe.PaymentIntent) (uint, bool) {
+	if purchaseIDStr := metadata["purchase_id"]; purchaseIDStr != "" {
+		id, err := strconv.ParseUint(purchaseIDStr, 10, 32)
+		if err != nil {
+			log.Printf("purchase ID inválido no evento do Stripe: %v", err)
+			return 0, false
+		}
+		return uint(id), true
+	}
+
+	if paymentIntent != nil && paymentIntent.ID != "" {
+		id, err := h.purchaseRepository.FindIDByPaymentIntent(paymentIntent.ID)
+		if err != nil {
+			log.Printf("Compra não encontrada para o PaymentIntent %s: %v", paymentIntent.ID, err)
+			return 0, false
+		}
+		return id, true
+	}
+	return 0, false
 }
 
 // handleSubscriptionPayment processa pagamento de assinatura
@@ -13,6 +13,8 @@ import (
 	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
 	"github.com/anglesson/simple-web-server/internal/mocks"
 	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stripe/stripe-go/v76"
@@ -28,11 +30,19 @@ func newTestStripeHandler(
 	creatorService *mocks.MockCreatorService,
 	transactionService *mocks.MockTransactionService,
 ) *StripeHandler {
+	recoveryService := &mocks.MockCheckoutRecoveryService{}
+	recoveryService.On("MarkRecovered", mock.Anything).Return(nil).Maybe()
+	waitlistService := &mocks.MockWaitlistService{}
+	waitlistService.On("MarkConverted", mock.Anything).Return(nil).Maybe()
+
 	return &StripeHandler{
 		purchaseService:    purchaseService,
 		emailService:       emailService,
 		creatorService:     creatorService,
 		transactionService: transactionService,
+		recoveryService:    recoveryService,
+		paymentConfirmation: salesvc.NewPaymentConfirmationService(
+			purchaseService, transactionService, recoveryService, waitlistService, emailService),
 	}
 }
 
@@ -43,7 +53,7 @@ func fullyLoadedPurchase(id uint, creatorID uint, clientEmail string) *salesmode
 		Model:    gorm.Model{ID: id},
 		EbookID:  1,
 		ClientID: 1,
-		Ebook:    librarymodel.Ebook{Model: gorm.Model{ID: 1}, Value: 100.0, CreatorID: creatorID},
+		Ebook:    librarymodel.Ebook{Model: gorm.Model{ID: 1}, Value: money.FromCents(10000), CreatorID: creatorID},
 		Client:   salesmodel.Client{Model: gorm.Model{ID: 1}, Email: clientEmail},
 	}
 }
@@ -74,6 +84,7 @@ func TestHandleEbookPayment_SendsEmailExactlyOnce(t *testing.T) {
 	mockPurchaseService.On("ConfirmPayment", uint(1)).Return(nil).Once()
 	mockCreatorService.On("FindByID", uint(1)).Return(creator, nil).Once()
 	mockTransactionService.On("UpdateTransactionToCompleted", uint(1), "").Return(nil).Once()
+	mockTransactionService.On("TransferSplits", uint(1)).Return(nil).Once()
 	mockEmailService.On("SendLinkToDownload", mock.Anything).Return().Once()
 
 	h := newTestStripeHandler(mockPurchaseService, mockEmailService, mockCreatorService, mockTransactionService)
@@ -132,6 +143,7 @@ func TestHandleEbookPayment_DoesNotSendEmailWhenClientEmailIsEmpty(t *testing.T)
 	mockPurchaseService.On("ConfirmPayment", uint(2)).Return(nil).Once()
 	mockCreatorService.On("FindByID", uint(1)).Return(creator, nil).Once()
 	mockTransactionService.On("UpdateTransactionToCompleted", uint(2), "").Return(nil).Once()
+	mockTransactionService.On("TransferSplits", uint(2)).Return(nil).Once()
 
 	h := newTestStripeHandler(mockPurchaseService, mockEmailService, mockCreatorService, mockTransactionService)
 
@@ -140,3 +152,19 @@ func TestHandleEbookPayment_DoesNotSendEmailWhenClientEmailIsEmpty(t *testing.T)
 	assert.Error(t, err)
 	mockEmailService.AssertNotCalled(t, "SendLinkToDownload", mock.Anything)
 }
+
+// TestHandleEbookChargeSucceeded_RecordsPaymentMethod garante que a forma de pagamento da cobrança chega ao recibo.
+func TestHandleEbookChargeSucceeded_RecordsPaymentMethod(t *testing.T) {
+	mockTransactionService := new(mocks.MockTransactionService)
+	mockTransactionService.On("RecordPaymentMethod", uint(7), "pix").Return(nil).Once()
+	h := newTestStripeHandler(new(mocks.MockPurchaseService), new(mocks.MockSalesEmailService), new(mocks.MockCreatorService), mockTransactionService)
+
+	h.handleEbookChargeSucceeded(stripe.Charge{
+		Metadata:             map[string]string{"purchase_id": "7"},
+		PaymentMethodDetails: &stripe.ChargePaymentMethodDetails{Type: "pix"},
+	})
+	// Cobrança sem detalhes do método não altera a transação
+	h.handleEbookChargeSucceeded(stripe.Charge{Metadata: map[string]string{"purchase_id": "7"}})
+
+	mockTransactionService.AssertExpectations(t)
+}
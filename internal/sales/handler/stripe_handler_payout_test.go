@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	"github.com/anglesson/simple-web-server/internal/config"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stripe/stripe-go/v76"
+)
+
+// mockPayoutService fica aqui porque PayoutDashboard é do pacote salesvc, que internal/mocks não importa
+type mockPayoutService struct {
+	mock.Mock
+}
+
+func (m *mockPayoutService) SyncCreator(creator *accountmodel.Creator) error {
+	return m.Called(creator).Error(0)
+}
+
+func (m *mockPayoutService) SyncAll() (int, error) {
+	args := m.Called()
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockPayoutService) HandlePayoutEvent(accountID string, payout *stripe.Payout) error {
+	return m.Called(accountID, payout).Error(0)
+}
+
+func (m *mockPayoutService) GetDashboard(creator *accountmodel.Creator) (*salesvc.PayoutDashboard, error) {
+	args := m.Called(creator)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*salesvc.PayoutDashboard), args.Error(1)
+}
+
+func TestHandleStripeWebhook_SyncsConnectedAccountPayout(t *testing.T) {
+	prev := config.AppConfig.StripeWebhookSecret
+	config.AppConfig.StripeWebhookSecret = ""
+	defer func() { config.AppConfig.StripeWebhookSecret = prev }()
+
+	payoutService := new(mockPayoutService)
+	payoutService.On("HandlePayoutEvent", "acct_maria", mock.MatchedBy(func(p *stripe.Payout) bool {
+		return p.ID == "po_1" && p.Status == stripe.PayoutStatusPaid
+	})).Return(nil).Once()
+	h := &StripeHandler{payoutService: payoutService}
+
+	body, _ := json.Marshal(stripe.Event{
+		Type:    "payout.paid",
+		Account: "acct_maria",
+		Data:    &stripe.EventData{Raw: json.RawMessage(`{"id":"po_1","status":"paid","amount":4800,"currency":"brl"}`)},
+	})
+	w := httptest.NewRecorder()
+	h.HandleStripeWebhook(w, httptest.NewRequest(http.MethodPost, "/api/webhook", bytes.NewReader(body)))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	payoutService.AssertExpectations(t)
+}
@@ -21,6 +21,7 @@ import (
 	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
 
 	"github.com/anglesson/simple-web-server/pkg/database"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -141,7 +142,7 @@ func TestTransactionDeduplication_Integration(t *testing.T) {
 			transaction := salesmodel.NewTransaction(purchase.ID, creator.ID, salesmodel.SplitTypePercentage)
 			transaction.Status = salesmodel.TransactionStatusCompleted
 			transaction.StripePaymentIntentID = fmt.Sprintf("pi_test_multiple_%d", i)
-			transaction.CalculateSplit(29000)
+			transaction.CalculateSplit(money.FromCents(29000))
 			err = checkoutHandler.transactionService.CreateDirectTransaction(transaction)
 			require.NoError(t, err)
 		}
@@ -193,7 +194,7 @@ func TestRealWorldScenario_DuplicateTransactionBug(t *testing.T) {
 
 		transaction1 := salesmodel.NewTransaction(purchase1.ID, creator.ID, salesmodel.SplitTypePercentage)
 		transaction1.Status = salesmodel.TransactionStatusPending
-		transaction1.CalculateSplit(29000)
+		transaction1.CalculateSplit(money.FromCents(29000))
 		err = checkoutHandler.transactionService.CreateDirectTransaction(transaction1)
 		require.NoError(t, err)
 
@@ -334,7 +335,7 @@ func TestDuplicatePurchaseBug(t *testing.T) {
 		// Criar transação para a primeira purchase
 		transaction1 := salesmodel.NewTransaction(purchases1[0].ID, creator.ID, salesmodel.SplitTypePercentage)
 		transaction1.Status = salesmodel.TransactionStatusPending
-		transaction1.CalculateSplit(29000)
+		transaction1.CalculateSplit(money.FromCents(29000))
 		err = checkoutHandler.transactionService.CreateDirectTransaction(transaction1)
 		require.NoError(t, err)
 
@@ -437,7 +438,7 @@ func setupTestEbook(t *testing.T, creatorID uint) *librarymodel.Ebook {
 	ebook := &librarymodel.Ebook{
 		Title:       "Test Ebook",
 		Description: "Test Description",
-		Value:       290.00,
+		Value:       money.FromCents(29000),
 		Status:      true,
 		CreatorID:   creatorID,
 	}
@@ -459,7 +460,7 @@ func setupTestCheckoutHandler(t *testing.T) *CheckoutHandler {
 	})).Return()
 
 	purchaseService := salesvc.NewPurchaseService(purchaseRepo, emailService)
-	transactionService := salesvc.NewTransactionService(transactionRepo, purchaseService, nil, nil)
+	transactionService := salesvc.NewTransactionService(transactionRepo, purchaseService, nil, nil, nil, nil)
 
 	// Template renderer mock
 	templateRenderer := &mocks.MockTemplateRenderer{}
@@ -512,7 +513,7 @@ func simulateCheckoutCreation(t *testing.T, handler *CheckoutHandler, ebookID ui
 	// Simular criação da transação pendente (como no checkout_handler.go)
 	transaction := salesmodel.NewTransaction(latestPurchase.ID, 1, salesmodel.SplitTypePercentage) // creator_id = 1
 	transaction.Status = salesmodel.TransactionStatusPending
-	transaction.CalculateSplit(29000) // 290.00 * 100
+	transaction.CalculateSplit(money.FromCents(29000)) // 290.00 * 100
 
 	err = handler.transactionService.CreateDirectTransaction(transaction)
 	require.NoError(t, err)
@@ -18,6 +18,7 @@ type TransactionHandler struct {
 	sessionService            authsvc.SessionService
 	creatorService            accountsvc.CreatorService
 	resendDownloadLinkService salesvc.ResendDownloadLinkServiceInterface
+	receiptService            salesvc.ReceiptService
 	templateRenderer          template.TemplateRenderer
 }
 
@@ -26,6 +27,7 @@ func NewTransactionHandler(
 	sessionService authsvc.SessionService,
 	creatorService accountsvc.CreatorService,
 	resendDownloadLinkService salesvc.ResendDownloadLinkServiceInterface,
+	receiptService salesvc.ReceiptService,
 	templateRenderer template.TemplateRenderer,
 ) *TransactionHandler {
 	return &TransactionHandler{
@@ -33,6 +35,7 @@ func NewTransactionHandler(
 		sessionService:            sessionService,
 		creatorService:            creatorService,
 		resendDownloadLinkService: resendDownloadLinkService,
+		receiptService:            receiptService,
 		templateRenderer:          templateRenderer,
 	}
 }
@@ -139,6 +142,56 @@ func (h *TransactionHandler) TransactionDetail(w http.ResponseWriter, r *http.Re
 	}, "admin-daisy")
 }
 
+// DownloadReceipt gera novamente o recibo em PDF da venda para o criador
+func (h *TransactionHandler) DownloadReceipt(w http.ResponseWriter, r *http.Request) {
+	transactionPublicID := r.URL.Query().Get("id")
+	if transactionPublicID == "" {
+		http.Error(w, "ID de transação inválido", http.StatusBadRequest)
+		return
+	}
+
+	userEmail, err := h.sessionService.GetUserEmailFromSession(r)
+	if err != nil {
+		slog.Error("Erro ao obter email da sessão", "error", err)
+		http.Error(w, "Sessão inválida", http.StatusUnauthorized)
+		return
+	}
+
+	creator, err := h.creatorService.FindCreatorByEmail(userEmail)
+	if err != nil {
+		slog.Error("Erro ao buscar criador", "error", err)
+		http.Error(w, "Criador não encontrado", http.StatusNotFound)
+		return
+	}
+
+	transaction, err := h.transactionService.GetTransactionByPublicID(transactionPublicID)
+	if err != nil {
+		slog.Error("Erro ao buscar transação", "error", err)
+		http.Error(w, "Transação não encontrada", http.StatusNotFound)
+		return
+	}
+
+	if transaction.CreatorID != creator.ID {
+		slog.Warn("Tentativa de acesso não autorizado ao recibo",
+			"transactionPublicID", transactionPublicID,
+			"creatorID", creator.ID,
+			"ownerID", transaction.CreatorID)
+		http.Error(w, "Acesso negado", http.StatusForbidden)
+		return
+	}
+
+	receipt, err := h.receiptService.GenerateForTransaction(transaction)
+	if err != nil {
+		slog.Error("Erro ao gerar recibo", "error", err, "transactionPublicID", transactionPublicID)
+		http.Error(w, "Não foi possível gerar o recibo desta transação", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", "attachment; filename="+strconv.Quote(receipt.FileName))
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Write(receipt.Content)
+}
+
 // ResendDownloadLink reenvia o link de download para o cliente
 func (h *TransactionHandler) ResendDownloadLink(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
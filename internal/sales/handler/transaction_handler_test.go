@@ -5,8 +5,12 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	"github.com/anglesson/simple-web-server/internal/mocks"
 	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"gorm.io/gorm"
 )
 
@@ -57,3 +61,30 @@ func TestTransactionStatus_ValidateCompletedTransactions(t *testing.T) {
 	assert.Equal(t, salesmodel.TransactionStatusCompleted, completedTransaction.Status)
 	assert.NotEqual(t, salesmodel.TransactionStatusCompleted, pendingTransaction.Status)
 }
+
+func TestTransactionHandler_DownloadReceipt_OnlyForOwner(t *testing.T) {
+	sessionService := new(mocks.MockSessionService)
+	sessionService.On("GetUserEmailFromSession", mock.Anything).Return("dono@test.com", nil)
+	creatorService := new(mocks.MockCreatorService)
+	creatorService.On("FindCreatorByEmail", "dono@test.com").Return(&accountmodel.Creator{Model: gorm.Model{ID: 1}}, nil)
+	transactionService := new(mocks.MockTransactionService)
+	transactionService.On("GetTransactionByPublicID", "txn_dono").Return(&salesmodel.Transaction{
+		PublicID: "txn_dono", CreatorID: 1, Status: salesmodel.TransactionStatusCompleted, TotalAmount: 1000,
+	}, nil)
+	transactionService.On("GetTransactionByPublicID", "txn_outro").Return(&salesmodel.Transaction{
+		PublicID: "txn_outro", CreatorID: 2, Status: salesmodel.TransactionStatusCompleted,
+	}, nil)
+
+	handler := NewTransactionHandler(transactionService, sessionService, creatorService, nil,
+		salesvc.NewReceiptService(new(mocks.MockTransactionRepository)), nil)
+
+	w := httptest.NewRecorder()
+	handler.DownloadReceipt(w, httptest.NewRequest(http.MethodGet, "/transactions/receipt?id=txn_dono", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "recibo-txn_dono.pdf")
+
+	w = httptest.NewRecorder()
+	handler.DownloadReceipt(w, httptest.NewRequest(http.MethodGet, "/transactions/receipt?id=txn_outro", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
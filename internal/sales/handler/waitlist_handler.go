@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	accountsvc "github.com/anglesson/simple-web-server/internal/account/service"
+	authsvc "github.com/anglesson/simple-web-server/internal/auth/service"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	librarysvc "github.com/anglesson/simple-web-server/internal/library/service"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	cookies "github.com/anglesson/simple-web-server/pkg/cookie"
+	"github.com/anglesson/simple-web-server/pkg/template"
+	"github.com/go-chi/chi/v5"
+)
+
+// waitlistRefCookie guarda o token do link rastreado para atribuir a compra à lista de espera
+const waitlistRefCookie = "waitlist_ref"
+
+type WaitlistHandler struct {
+	templateRenderer template.TemplateRenderer
+	waitlistService  salesvc.WaitlistService
+	ebookService     librarysvc.EbookService
+	sessionService   authsvc.SessionService
+	creatorService   accountsvc.CreatorService
+}
+
+func NewWaitlistHandler(
+	templateRenderer template.TemplateRenderer,
+	waitlistService salesvc.WaitlistService,
+	ebookService librarysvc.EbookService,
+	sessionService authsvc.SessionService,
+	creatorService accountsvc.CreatorService,
+) *WaitlistHandler {
+	return &WaitlistHandler{
+		templateRenderer: templateRenderer,
+		waitlistService:  waitlistService,
+		ebookService:     ebookService,
+		sessionService:   sessionService,
+		creatorService:   creatorService,
+	}
+}
+
+// JoinWaitlist inscreve o visitante da página de vendas na lista de espera do ebook
+func (h *WaitlistHandler) JoinWaitlist(w http.ResponseWriter, r *http.Request) {
+	ebookPublicID := chi.URLParam(r, "id")
+	salesPageURL := "/sales/" + ebookPublicID
+
+	ebook, err := h.ebookService.FindByPublicID(ebookPublicID)
+	if err != nil || ebook == nil {
+		http.Error(w, "Ebook não encontrado", http.StatusNotFound)
+		return
+	}
+
+	email := strings.TrimSpace(r.FormValue("email"))
+	if _, err := mail.ParseAddress(email); err != nil || !isValidEmail(email) {
+		http.Redirect(w, r, salesPageURL+"?waitlist=invalid", http.StatusSeeOther)
+		return
+	}
+
+	created, err := h.waitlistService.Join(ebook, r.FormValue("name"), email)
+	if err != nil {
+		if errors.Is(err, salesvc.ErrWaitlistDisabled) {
+			http.Error(w, "Lista de espera indisponível", http.StatusNotFound)
+			return
+		}
+		slog.Error("Erro ao inscrever na lista de espera", "ebookID", ebook.ID, "error", err)
+		http.Error(w, "Erro interno do servidor", http.StatusInternalServerError)
+		return
+	}
+
+	status := "joined"
+	if !created {
+		status = "already"
+	}
+	http.Redirect(w, r, salesPageURL+"?waitlist="+status, http.StatusSeeOther)
+}
+
+// TrackClick registra o clique no link do email de aviso e leva o inscrito à página de vendas
+func (h *WaitlistHandler) TrackClick(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+
+	entry, err := h.waitlistService.RegisterClick(token)
+	if err != nil || entry == nil {
+		slog.Warn("Link da lista de espera inválido", "error", err)
+		http.Error(w, "Link inválido ou expirado", http.StatusNotFound)
+		return
+	}
+
+	ebook, err := h.ebookService.FindByID(entry.EbookID)
+	if err != nil || ebook == nil {
+		http.Error(w, "Ebook não encontrado", http.StatusNotFound)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     waitlistRefCookie,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   7 * 86400,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, "/sales/"+ebook.PublicID, http.StatusSeeOther)
+}
+
+// WaitlistView exibe os inscritos da lista de espera de um ebook do criador
+func (h *WaitlistHandler) WaitlistView(w http.ResponseWriter, r *http.Request) {
+	ebook, ok := h.findCreatorEbook(w, r)
+	if !ok {
+		return
+	}
+
+	entries, err := h.waitlistService.ListByEbookID(ebook.ID)
+	if err != nil {
+		slog.Error("Erro ao buscar lista de espera", "ebookID", ebook.ID, "error", err)
+		http.Error(w, "Erro ao buscar lista de espera", http.StatusInternalServerError)
+		return
+	}
+
+	stats, err := h.waitlistService.GetStatsByEbookID(ebook.ID)
+	if err != nil {
+		slog.Error("Erro ao buscar estatísticas da lista de espera", "ebookID", ebook.ID, "error", err)
+		http.Error(w, "Erro ao buscar lista de espera", http.StatusInternalServerError)
+		return
+	}
+
+	h.templateRenderer.View(w, r, "ebook/waitlist", map[string]interface{}{
+		"Ebook":     ebook,
+		"Entries":   entries,
+		"Stats":     stats,
+		"CanNotify": ebook.Status && ebook.IsAvailableForSale(),
+	}, "admin-daisy")
+}
+
+// NotifyWaitlist envia o aviso de disponibilidade para toda a lista de espera do ebook
+func (h *WaitlistHandler) NotifyWaitlist(w http.ResponseWriter, r *http.Request) {
+	ebook, ok := h.findCreatorEbook(w, r)
+	if !ok {
+		return
+	}
+
+	waitlistURL := "/ebook/" + ebook.PublicID + "/waitlist"
+
+	notified, err := h.waitlistService.NotifyAll(ebook)
+	if err != nil {
+		if errors.Is(err, salesvc.ErrWaitlistEbookUnavailable) {
+			cookies.NotifyError(w, "Publique o e-book ou reabra as vendas antes de avisar a lista de espera")
+		} else {
+			slog.Error("Erro ao avisar lista de espera", "ebookID", ebook.ID, "error", err)
+			cookies.NotifyError(w, "Erro ao avisar a lista de espera")
+		}
+		http.Redirect(w, r, waitlistURL, http.StatusSeeOther)
+		return
+	}
+
+	if notified == 0 {
+		cookies.NotifySuccess(w, "Não há inscritos pendentes para avisar")
+	} else {
+		cookies.NotifySuccess(w, fmt.Sprintf("Aviso enviado para %d inscrito(s) da lista de espera!", notified))
+	}
+	http.Redirect(w, r, waitlistURL, http.StatusSeeOther)
+}
+
+func (h *WaitlistHandler) findCreatorEbook(w http.ResponseWriter, r *http.Request) (*librarymodel.Ebook, bool) {
+	userEmail, err := h.sessionService.GetUserEmailFromSession(r)
+	if err != nil {
+		slog.Error("Erro ao obter email da sessão", "error", err)
+		http.Error(w, "Sessão inválida", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	creator, err := h.creatorService.FindCreatorByEmail(userEmail)
+	if err != nil {
+		slog.Error("Erro ao buscar criador", "error", err)
+		http.Error(w, "Criador não encontrado", http.StatusNotFound)
+		return nil, false
+	}
+
+	ebook, err := h.ebookService.FindByPublicID(chi.URLParam(r, "id"))
+	if err != nil || ebook == nil || ebook.CreatorID != creator.ID {
+		http.Error(w, "Ebook não encontrado", http.StatusNotFound)
+		return nil, false
+	}
+
+	return ebook, true
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	"github.com/anglesson/simple-web-server/internal/mocks"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func withURLParam(req *http.Request, key, value string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add(key, value)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestJoinWaitlist_RedirectsWithStatus(t *testing.T) {
+	ebook := &librarymodel.Ebook{Model: gorm.Model{ID: 1}, PublicID: "ebk_soon", WaitlistEnabled: true}
+	ebookService := &mocks.MockEbookService{}
+	ebookService.On("FindByPublicID", "ebk_soon").Return(ebook, nil)
+	waitlistService := &mocks.MockWaitlistService{}
+	waitlistService.On("Join", ebook, "Ana", "ana@test.com").Return(true, nil).Once()
+	h := &WaitlistHandler{ebookService: ebookService, waitlistService: waitlistService}
+
+	form := url.Values{"name": {"Ana"}, "email": {"ana@test.com"}}
+	req := httptest.NewRequest(http.MethodPost, "/sales/ebk_soon/waitlist", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	h.JoinWaitlist(rr, withURLParam(req, "id", "ebk_soon"))
+
+	assert.Equal(t, http.StatusSeeOther, rr.Code)
+	assert.Equal(t, "/sales/ebk_soon?waitlist=joined", rr.Header().Get("Location"))
+	waitlistService.AssertExpectations(t)
+}
+
+func TestJoinWaitlist_InvalidEmail(t *testing.T) {
+	ebookService := &mocks.MockEbookService{}
+	ebookService.On("FindByPublicID", "ebk_soon").Return(&librarymodel.Ebook{PublicID: "ebk_soon", WaitlistEnabled: true}, nil)
+	waitlistService := &mocks.MockWaitlistService{}
+	h := &WaitlistHandler{ebookService: ebookService, waitlistService: waitlistService}
+
+	form := url.Values{"email": {"invalido"}}
+	req := httptest.NewRequest(http.MethodPost, "/sales/ebk_soon/waitlist", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	h.JoinWaitlist(rr, withURLParam(req, "id", "ebk_soon"))
+
+	assert.Equal(t, "/sales/ebk_soon?waitlist=invalid", rr.Header().Get("Location"))
+	waitlistService.AssertNotCalled(t, "Join", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTrackClick_SetsReferenceCookie(t *testing.T) {
+	entry := &salesmodel.WaitlistEntry{EbookID: 9, Token: "tok123"}
+	waitlistService := &mocks.MockWaitlistService{}
+	waitlistService.On("RegisterClick", "tok123").Return(entry, nil)
+	ebookService := &mocks.MockEbookService{}
+	ebookService.On("FindByID", uint(9)).Return(&librarymodel.Ebook{PublicID: "ebk_back"}, nil)
+	h := &WaitlistHandler{ebookService: ebookService, waitlistService: waitlistService}
+
+	req := httptest.NewRequest(http.MethodGet, "/waitlist/tok123", nil)
+	rr := httptest.NewRecorder()
+	h.TrackClick(rr, withURLParam(req, "token", "tok123"))
+
+	assert.Equal(t, http.StatusSeeOther, rr.Code)
+	assert.Equal(t, "/sales/ebk_back", rr.Header().Get("Location"))
+
+	cookies := rr.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, waitlistRefCookie, cookies[0].Name)
+		assert.Equal(t, "tok123", cookies[0].Value)
+	}
+}
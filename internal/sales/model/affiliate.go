@@ -0,0 +1,94 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/anglesson/simple-web-server/pkg/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AffiliateStatus string
+
+const (
+	AffiliateStatusActive  AffiliateStatus = "active"
+	AffiliateStatusBlocked AffiliateStatus = "blocked"
+)
+
+// Affiliate liga um criador que divulga o ebook de outro ao programa de afiliados desse ebook.
+// O Code vai no link da página de vendas (/sales/{id}?aff=CODE) e atribui a venda ao afiliado.
+type Affiliate struct {
+	gorm.Model
+	PublicID  string               `json:"public_id" gorm:"type:varchar(40);uniqueIndex"`
+	Code      string               `json:"code" gorm:"type:varchar(20);uniqueIndex"`
+	EbookID   uint                 `json:"ebook_id" gorm:"uniqueIndex:idx_affiliate_ebook_creator"`
+	Ebook     librarymodel.Ebook   `json:"-" gorm:"foreignKey:EbookID"`
+	CreatorID uint                 `json:"creator_id" gorm:"uniqueIndex:idx_affiliate_ebook_creator"`
+	Creator   accountmodel.Creator `json:"-" gorm:"foreignKey:CreatorID"`
+	Status    AffiliateStatus      `json:"status" gorm:"type:varchar(20);default:'active'"`
+	Clicks    int64                `json:"clicks" gorm:"default:0"`
+}
+
+func (a *Affiliate) BeforeCreate(tx *gorm.DB) error {
+	if a.PublicID == "" {
+		a.PublicID = utils.GeneratePublicID("afl_")
+	}
+	if a.Code == "" {
+		a.Code = strings.ToUpper(strings.ReplaceAll(uuid.NewString(), "-", "")[:10])
+	}
+	return nil
+}
+
+func NewAffiliate(ebookID, creatorID uint) *Affiliate {
+	return &Affiliate{
+		EbookID:   ebookID,
+		CreatorID: creatorID,
+		Status:    AffiliateStatusActive,
+	}
+}
+
+func (a *Affiliate) IsActive() bool {
+	return a.Status == AffiliateStatusActive
+}
+
+// GetLinkPath retorna o caminho do link de divulgação do afiliado
+func (a *Affiliate) GetLinkPath() string {
+	return fmt.Sprintf("/sales/%s?aff=%s", a.Ebook.PublicID, a.Code)
+}
+
+// AffiliateStats resume as vendas indicadas por um afiliado. As comissões ficam em centavos por moeda
+type AffiliateStats struct {
+	Sales   int64
+	Paid    map[string]int64
+	Pending map[string]int64
+}
+
+func NewAffiliateStats() *AffiliateStats {
+	return &AffiliateStats{Paid: map[string]int64{}, Pending: map[string]int64{}}
+}
+
+func (s *AffiliateStats) GetFormattedPaid() string {
+	return formatCurrencyTotals(s.Paid)
+}
+
+func (s *AffiliateStats) GetFormattedPending() string {
+	return formatCurrencyTotals(s.Pending)
+}
+
+// formatCurrencyTotals exibe os totais de cada moeda (ex.: "R$ 10,00 + € 2,00")
+func formatCurrencyTotals(totals map[string]int64) string {
+	var parts []string
+	for _, currency := range money.SupportedCurrencies {
+		if amount := totals[currency]; amount != 0 {
+			parts = append(parts, money.New(amount, currency).String())
+		}
+	}
+	if len(parts) == 0 {
+		return money.New(0, money.BRL).String()
+	}
+	return strings.Join(parts, " + ")
+}
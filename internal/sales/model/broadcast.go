@@ -0,0 +1,151 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	"github.com/anglesson/simple-web-server/pkg/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BroadcastStatus acompanha o email em massa desde o envio do formulário até a última entrega
+type BroadcastStatus string
+
+const (
+	BroadcastStatusQueued  BroadcastStatus = "queued"
+	BroadcastStatusSending BroadcastStatus = "sending"
+	BroadcastStatusSent    BroadcastStatus = "sent"
+	BroadcastStatusFailed  BroadcastStatus = "failed"
+)
+
+// Broadcast é um email do criador para um público de clientes (ex.: compradores de um ebook).
+// Os destinatários são definidos na criação e ficam em BroadcastDelivery.
+type Broadcast struct {
+	gorm.Model
+	PublicID  string               `json:"public_id" gorm:"type:varchar(40);uniqueIndex"`
+	CreatorID uint                 `json:"creator_id" gorm:"index"`
+	Creator   accountmodel.Creator `gorm:"foreignKey:CreatorID"`
+	Subject   string               `json:"subject"`
+	// Body é o HTML escrito pelo criador, inserido no template de email da plataforma
+	Body string `json:"body" gorm:"type:text"`
+	// Audience descreve o público escolhido (ex.: "Compradores de Volume 1")
+	Audience string          `json:"audience"`
+	Rules    SegmentRules    `json:"rules" gorm:"serializer:json"`
+	Status   BroadcastStatus `json:"status" gorm:"type:varchar(20);index"`
+
+	Recipients   int        `json:"recipients"`
+	Sent         int        `json:"sent"`
+	Failed       int        `json:"failed"`
+	Skipped      int        `json:"skipped"`
+	ErrorMessage string     `json:"error_message"`
+	StartedAt    *time.Time `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at"`
+}
+
+func (b *Broadcast) BeforeCreate(tx *gorm.DB) error {
+	if b.PublicID == "" {
+		b.PublicID = utils.GeneratePublicID("brd_")
+	}
+	return nil
+}
+
+func (b *Broadcast) IsRunning() bool {
+	return b.Status == BroadcastStatusQueued || b.Status == BroadcastStatusSending
+}
+
+// Pending é a quantidade de destinatários que ainda aguardam o envio
+func (b *Broadcast) Pending() int {
+	pending := b.Recipients - b.Sent - b.Failed - b.Skipped
+	if pending < 0 {
+		return 0
+	}
+	return pending
+}
+
+func (b *Broadcast) GetStatusLabel() string {
+	switch b.Status {
+	case BroadcastStatusQueued:
+		return "Na fila"
+	case BroadcastStatusSending:
+		return "Enviando"
+	case BroadcastStatusSent:
+		return "Enviado"
+	case BroadcastStatusFailed:
+		return "Falhou"
+	default:
+		return string(b.Status)
+	}
+}
+
+func (b *Broadcast) GetStatusBadgeClass() string {
+	switch b.Status {
+	case BroadcastStatusSent:
+		return "badge-success"
+	case BroadcastStatusFailed:
+		return "badge-error"
+	default:
+		return "badge-info"
+	}
+}
+
+// BroadcastDeliveryStatus é a situação do email de um destinatário
+type BroadcastDeliveryStatus string
+
+const (
+	BroadcastDeliveryPending BroadcastDeliveryStatus = "pending"
+	BroadcastDeliverySent    BroadcastDeliveryStatus = "sent"
+	BroadcastDeliveryFailed  BroadcastDeliveryStatus = "failed"
+	// O cliente tinha se descadastrado dos emails do criador
+	BroadcastDeliverySkipped BroadcastDeliveryStatus = "skipped"
+)
+
+// BroadcastDelivery é o envio do broadcast para um cliente.
+// O Token identifica o link de descadastro do email.
+type BroadcastDelivery struct {
+	ID          uint                    `gorm:"primarykey"`
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+	BroadcastID uint                    `json:"broadcast_id" gorm:"uniqueIndex:idx_broadcast_delivery"`
+	ClientID    uint                    `json:"client_id" gorm:"uniqueIndex:idx_broadcast_delivery"`
+	Name        string                  `json:"name"`
+	Email       string                  `json:"email"`
+	Token       string                  `json:"-" gorm:"type:varchar(64);uniqueIndex"`
+	Status      BroadcastDeliveryStatus `json:"status" gorm:"type:varchar(20);index"`
+	Error       string                  `json:"error"`
+	SentAt      *time.Time              `json:"sent_at"`
+}
+
+func (d *BroadcastDelivery) BeforeCreate(tx *gorm.DB) error {
+	if d.Token == "" {
+		d.Token = strings.ReplaceAll(uuid.NewString(), "-", "")
+	}
+	return nil
+}
+
+func (d *BroadcastDelivery) GetStatusLabel() string {
+	switch d.Status {
+	case BroadcastDeliveryPending:
+		return "Aguardando"
+	case BroadcastDeliverySent:
+		return "Enviado"
+	case BroadcastDeliveryFailed:
+		return "Falhou"
+	case BroadcastDeliverySkipped:
+		return "Descadastrado"
+	default:
+		return string(d.Status)
+	}
+}
+
+// EmailUnsubscribe registra o cliente que não quer mais receber os emails em massa de um criador.
+// Emails transacionais (link de download, recibos) continuam sendo enviados.
+type EmailUnsubscribe struct {
+	ID        uint      `gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	CreatorID uint      `json:"creator_id" gorm:"uniqueIndex:idx_email_unsubscribe"`
+	ClientID  uint      `json:"client_id" gorm:"uniqueIndex:idx_email_unsubscribe"`
+	// Broadcast de onde veio o descadastro
+	BroadcastID *uint `json:"broadcast_id" gorm:"index"`
+}
@@ -0,0 +1,163 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	"github.com/anglesson/simple-web-server/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// CheckoutAttemptStage indica em que ponto do checkout a tentativa foi registrada
+type CheckoutAttemptStage string
+
+const (
+	CheckoutStageValidate      CheckoutAttemptStage = "validate"
+	CheckoutStageCheckout      CheckoutAttemptStage = "checkout"
+	CheckoutStagePaymentFailed CheckoutAttemptStage = "payment_failed"
+)
+
+// RiskDecision é o resultado da avaliação de risco da tentativa
+type RiskDecision string
+
+const (
+	RiskDecisionAllow  RiskDecision = "allow"
+	RiskDecisionReview RiskDecision = "review"
+	RiskDecisionBlock  RiskDecision = "block"
+)
+
+// ReviewStatus acompanha a revisão manual feita pelo criador
+type ReviewStatus string
+
+const (
+	ReviewStatusNone     ReviewStatus = ""
+	ReviewStatusPending  ReviewStatus = "pending"
+	ReviewStatusApproved ReviewStatus = "approved"
+	ReviewStatusRejected ReviewStatus = "rejected"
+)
+
+// CheckoutAttempt registra cada tentativa de checkout com os sinais usados na pontuação de risco.
+// As tentativas anteriores alimentam as verificações de velocidade por CPF, email, IP e dispositivo;
+// as suspeitas aparecem na lista de revisão do criador.
+type CheckoutAttempt struct {
+	gorm.Model
+	PublicID     string               `json:"public_id" gorm:"type:varchar(40);uniqueIndex"`
+	CreatorID    uint                 `json:"creator_id" gorm:"index"`
+	EbookID      uint                 `json:"ebook_id"`
+	PurchaseID   *uint                `json:"purchase_id" gorm:"index"`
+	Stage        CheckoutAttemptStage `json:"stage" gorm:"type:varchar(20)"`
+	Name         string               `json:"name"`
+	CPF          string               `json:"cpf" gorm:"serializer:pii"`
+	CPFIndex     string               `json:"-" gorm:"type:varchar(64);index" pii:"index"` // HMAC do CPF cifrado, usado na velocidade por CPF
+	Email        string               `json:"email" gorm:"index"`
+	IP           string               `json:"ip" gorm:"type:varchar(45);index"`
+	DeviceID     string               `json:"device_id" gorm:"type:varchar(64);index"`
+	Score        int                  `json:"score"`
+	Decision     RiskDecision         `json:"decision" gorm:"type:varchar(10);index"`
+	Reasons      string               `json:"reasons"` // Motivos separados por ";"
+	ReviewStatus ReviewStatus         `json:"review_status" gorm:"type:varchar(10);index"`
+	ReviewedAt   *time.Time           `json:"reviewed_at"`
+
+	Ebook    librarymodel.Ebook `json:"ebook" gorm:"foreignKey:EbookID"`
+	Purchase *Purchase          `json:"purchase,omitempty" gorm:"foreignKey:PurchaseID"`
+}
+
+func (a *CheckoutAttempt) BeforeCreate(tx *gorm.DB) error {
+	if a.PublicID == "" {
+		a.PublicID = utils.GeneratePublicID("chk_")
+	}
+	return nil
+}
+
+// BeforeSave recalcula o índice cego do CPF a cada gravação, inclusive na rotação de chaves
+func (a *CheckoutAttempt) BeforeSave(tx *gorm.DB) error {
+	a.CPFIndex = CPFBlindIndex(a.CPF)
+	return nil
+}
+
+// RiskSignal é uma verificação que disparou e a pontuação que ela soma ao risco
+type RiskSignal struct {
+	Reason string
+	Score  int
+}
+
+// ApplySignals soma as pontuações e define a decisão conforme os limites de revisão e bloqueio
+func (a *CheckoutAttempt) ApplySignals(signals []RiskSignal, reviewScore, blockScore int) {
+	reasons := make([]string, 0, len(signals))
+	a.Score = 0
+	for _, signal := range signals {
+		a.Score += signal.Score
+		reasons = append(reasons, signal.Reason)
+	}
+	a.Reasons = strings.Join(reasons, ";")
+
+	switch {
+	case a.Score >= blockScore:
+		a.Decision = RiskDecisionBlock
+	case a.Score >= reviewScore:
+		a.Decision = RiskDecisionReview
+	default:
+		a.Decision = RiskDecisionAllow
+	}
+}
+
+func (a *CheckoutAttempt) GetReasons() []string {
+	if a.Reasons == "" {
+		return nil
+	}
+	return strings.Split(a.Reasons, ";")
+}
+
+func (a *CheckoutAttempt) IsAllowed() bool {
+	return a.Decision == RiskDecisionAllow
+}
+
+func (a *CheckoutAttempt) IsBlocked() bool {
+	return a.Decision == RiskDecisionBlock
+}
+
+func (a *CheckoutAttempt) NeedsReview() bool {
+	return a.Decision == RiskDecisionReview
+}
+
+// IsAwaitingReview indica que a entrega da compra está retida até a revisão do criador
+func (a *CheckoutAttempt) IsAwaitingReview() bool {
+	return a.ReviewStatus == ReviewStatusPending
+}
+
+func (a *CheckoutAttempt) MarkReviewed(approved bool, reviewedAt time.Time) {
+	a.ReviewStatus = ReviewStatusRejected
+	if approved {
+		a.ReviewStatus = ReviewStatusApproved
+	}
+	a.ReviewedAt = &reviewedAt
+}
+
+func (a *CheckoutAttempt) GetDecisionLabel() string {
+	switch a.Decision {
+	case RiskDecisionBlock:
+		return "Bloqueado"
+	case RiskDecisionReview:
+		return "Em revisão"
+	default:
+		return "Aprovado"
+	}
+}
+
+func (a *CheckoutAttempt) GetReviewStatusLabel() string {
+	switch a.ReviewStatus {
+	case ReviewStatusPending:
+		return "Aguardando revisão"
+	case ReviewStatusApproved:
+		return "Liberado"
+	case ReviewStatusRejected:
+		return "Recusado"
+	default:
+		return "-"
+	}
+}
+
+func (a *CheckoutAttempt) GetCreatedAtBR() string {
+	return a.CreatedAt.Format("02/01/2006 15:04")
+}
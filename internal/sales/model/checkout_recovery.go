@@ -0,0 +1,133 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/anglesson/simple-web-server/pkg/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CheckoutRecoveryStatus string
+
+const (
+	CheckoutRecoveryStatusPending   CheckoutRecoveryStatus = "pending"
+	CheckoutRecoveryStatusRecovered CheckoutRecoveryStatus = "recovered"
+)
+
+// CheckoutRecovery registra um checkout abandonado (sessão do Stripe expirada)
+// e o andamento da sequência de emails de recuperação enviados ao cliente.
+type CheckoutRecovery struct {
+	gorm.Model
+	PublicID        string   `json:"public_id" gorm:"type:varchar(40);uniqueIndex"`
+	PurchaseID      uint     `json:"purchase_id" gorm:"uniqueIndex"`
+	Purchase        Purchase `gorm:"foreignKey:PurchaseID"`
+	EbookID         uint     `json:"ebook_id" gorm:"index"`
+	ClientID        uint     `json:"client_id"`
+	CreatorID       uint     `json:"creator_id" gorm:"index"`
+	StripeSessionID string   `json:"stripe_session_id"`
+	// Token do link de recuperação; fica nulo depois que o checkout é recuperado
+	Token          *string                `json:"-" gorm:"type:varchar(64);uniqueIndex"`
+	TokenExpiresAt *time.Time             `json:"token_expires_at"`
+	ExpiredAt      time.Time              `json:"expired_at"`
+	EmailsSent     int                    `json:"emails_sent" gorm:"default:0"`
+	LastEmailAt    *time.Time             `json:"last_email_at"`
+	RecoveredAt    *time.Time             `json:"recovered_at"`
+	Status         CheckoutRecoveryStatus `json:"status" gorm:"type:varchar(20);default:'pending';index"`
+}
+
+func (c *CheckoutRecovery) BeforeCreate(tx *gorm.DB) error {
+	if c.PublicID == "" {
+		c.PublicID = utils.GeneratePublicID("rec_")
+	}
+	if c.Token == nil {
+		token := strings.ReplaceAll(uuid.NewString(), "-", "")
+		c.Token = &token
+	}
+	return nil
+}
+
+func NewCheckoutRecovery(purchaseID, ebookID, clientID, creatorID uint, stripeSessionID string, expiredAt time.Time) *CheckoutRecovery {
+	return &CheckoutRecovery{
+		PurchaseID:      purchaseID,
+		EbookID:         ebookID,
+		ClientID:        clientID,
+		CreatorID:       creatorID,
+		StripeSessionID: stripeSessionID,
+		ExpiredAt:       expiredAt,
+		Status:          CheckoutRecoveryStatusPending,
+	}
+}
+
+// TokenValue retorna o token do link de recuperação ou vazio se ele já foi invalidado
+func (c *CheckoutRecovery) TokenValue() string {
+	if c.Token == nil {
+		return ""
+	}
+	return *c.Token
+}
+
+// SetLinkExpiry faz o link valer até o último email da sequência mais a validade dada a esse email
+func (c *CheckoutRecovery) SetLinkExpiry(schedule []time.Duration, validity time.Duration) {
+	expiresAt := c.ExpiredAt.Add(validity)
+	if len(schedule) > 0 {
+		expiresAt = expiresAt.Add(schedule[len(schedule)-1])
+	}
+	c.TokenExpiresAt = &expiresAt
+}
+
+// IsLinkValid indica se o link de recuperação ainda pode abrir um checkout
+func (c *CheckoutRecovery) IsLinkValid(now time.Time) bool {
+	if c.IsRecovered() || c.Token == nil {
+		return false
+	}
+	return c.TokenExpiresAt == nil || now.Before(*c.TokenExpiresAt)
+}
+
+func (c *CheckoutRecovery) IsRecovered() bool {
+	return c.Status == CheckoutRecoveryStatusRecovered
+}
+
+// NextEmailDueAt retorna quando o próximo email da sequência deve ser enviado.
+// O segundo retorno é false quando a sequência já foi concluída ou o checkout foi recuperado.
+func (c *CheckoutRecovery) NextEmailDueAt(schedule []time.Duration) (time.Time, bool) {
+	if c.IsRecovered() || c.EmailsSent >= len(schedule) {
+		return time.Time{}, false
+	}
+	return c.ExpiredAt.Add(schedule[c.EmailsSent]), true
+}
+
+func (c *CheckoutRecovery) RegisterEmailSent(sentAt time.Time) {
+	c.EmailsSent++
+	c.LastEmailAt = &sentAt
+}
+
+// MarkRecovered encerra a recuperação e invalida o link enviado nos emails
+func (c *CheckoutRecovery) MarkRecovered(recoveredAt time.Time) {
+	c.Status = CheckoutRecoveryStatusRecovered
+	c.RecoveredAt = &recoveredAt
+	c.Token = nil
+}
+
+// EbookRecoveryStats agrega os checkouts abandonados e recuperados de um ebook
+type EbookRecoveryStats struct {
+	EbookID    uint
+	EbookTitle string
+	Abandoned  int64
+	Recovered  int64
+	EmailsSent int64
+}
+
+// RecoveryRate retorna a taxa de recuperação entre 0 e 1
+func (s *EbookRecoveryStats) RecoveryRate() float64 {
+	if s.Abandoned == 0 {
+		return 0
+	}
+	return float64(s.Recovered) / float64(s.Abandoned)
+}
+
+func (s *EbookRecoveryStats) GetFormattedRecoveryRate() string {
+	return strings.Replace(fmt.Sprintf("%.1f%%", s.RecoveryRate()*100), ".", ",", 1)
+}
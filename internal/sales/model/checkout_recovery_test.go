@@ -0,0 +1,66 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckoutRecovery_NextEmailDueAt(t *testing.T) {
+	expiredAt := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	schedule := []time.Duration{time.Hour, 24 * time.Hour}
+	recovery := salesmodel.NewCheckoutRecovery(1, 2, 3, 4, "cs_test", expiredAt)
+
+	dueAt, ok := recovery.NextEmailDueAt(schedule)
+	assert.True(t, ok)
+	assert.Equal(t, expiredAt.Add(time.Hour), dueAt)
+
+	recovery.RegisterEmailSent(dueAt)
+	dueAt, ok = recovery.NextEmailDueAt(schedule)
+	assert.True(t, ok)
+	assert.Equal(t, expiredAt.Add(24*time.Hour), dueAt)
+	assert.Equal(t, 1, recovery.EmailsSent)
+	assert.NotNil(t, recovery.LastEmailAt)
+
+	recovery.RegisterEmailSent(dueAt)
+	_, ok = recovery.NextEmailDueAt(schedule)
+	assert.False(t, ok, "sequência concluída não deve ter próximo envio")
+}
+
+func TestCheckoutRecovery_NextEmailDueAt_Recovered(t *testing.T) {
+	recovery := salesmodel.NewCheckoutRecovery(1, 2, 3, 4, "cs_test", time.Now())
+	recovery.MarkRecovered(time.Now())
+
+	_, ok := recovery.NextEmailDueAt([]time.Duration{time.Hour})
+	assert.False(t, ok)
+	assert.True(t, recovery.IsRecovered())
+	assert.NotNil(t, recovery.RecoveredAt)
+}
+
+func TestEbookRecoveryStats_RecoveryRate(t *testing.T) {
+	stats := &salesmodel.EbookRecoveryStats{Abandoned: 8, Recovered: 2}
+	assert.InDelta(t, 0.25, stats.RecoveryRate(), 0.0001)
+	assert.Equal(t, "25,0%", stats.GetFormattedRecoveryRate())
+
+	empty := &salesmodel.EbookRecoveryStats{}
+	assert.Equal(t, float64(0), empty.RecoveryRate())
+}
+
+func TestCheckoutRecovery_LinkExpiry(t *testing.T) {
+	expiredAt := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	token := "tok"
+	recovery := salesmodel.NewCheckoutRecovery(1, 2, 3, 4, "cs_test", expiredAt)
+	recovery.Token = &token
+	recovery.SetLinkExpiry([]time.Duration{time.Hour, 24 * time.Hour}, 72*time.Hour)
+
+	assert.Equal(t, expiredAt.Add(96*time.Hour), *recovery.TokenExpiresAt)
+	assert.True(t, recovery.IsLinkValid(expiredAt.Add(95*time.Hour)))
+	assert.False(t, recovery.IsLinkValid(expiredAt.Add(96*time.Hour)))
+
+	recovery.MarkRecovered(expiredAt.Add(time.Hour))
+	assert.Nil(t, recovery.Token, "o link é invalidado ao recuperar o checkout")
+	assert.Empty(t, recovery.TokenValue())
+	assert.False(t, recovery.IsLinkValid(expiredAt.Add(2*time.Hour)))
+}
@@ -2,22 +2,49 @@ package model
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
+	"time"
 
+	"github.com/anglesson/simple-web-server/pkg/pii"
 	"github.com/anglesson/simple-web-server/pkg/utils"
 	"gorm.io/gorm"
 )
 
+// BuyerType distingue compradores brasileiros (identificados pelo CPF)
+// de estrangeiros (identificados pelo email)
+type BuyerType string
+
+const (
+	BuyerTypeBrazilian BuyerType = "br"
+	BuyerTypeForeign   BuyerType = "foreign"
+)
+
+var (
+	documentIDPattern         = regexp.MustCompile(`^[A-Z0-9]{5,20}$`)
+	countryCodePattern        = regexp.MustCompile(`^[A-Z]{2}$`)
+	internationalPhonePattern = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+)
+
 type Client struct {
 	gorm.Model
 	PublicID  string `json:"public_id" gorm:"type:varchar(40);uniqueIndex"`
 	Name      string `json:"name"`
-	CPF       string `gorm:"unique" json:"cpf"`
-	Birthdate string `json:"birthdate"`
+	CPF       string `gorm:"serializer:pii" json:"cpf"`
+	Birthdate string `gorm:"serializer:pii" json:"birthdate"`
 	Email     string `json:"email"`
-	Phone     string `json:"phone"`
+	Phone     string `gorm:"serializer:pii" json:"phone"`
 	Validated bool   `json:"validated"`
-	Purchases []*Purchase
+	// CPF e telefone ficam cifrados; as buscas exatas usam o HMAC dos dígitos (ver BeforeSave)
+	CPFIndex   string `json:"-" gorm:"type:varchar(64);uniqueIndex:idx_clients_cpf_index,where:cpf_index <> ''" pii:"index"`
+	PhoneIndex string `json:"-" gorm:"type:varchar(64);index" pii:"index"`
+	// Compradores estrangeiros não têm CPF: usam passaporte ou documento fiscal do país
+	BuyerType  BuyerType `json:"buyer_type" gorm:"type:varchar(20);default:'br'"`
+	Country    string    `json:"country" gorm:"type:varchar(2)"`
+	DocumentID string    `json:"document_id" gorm:"type:varchar(20)"`
+	// AnonymizedAt marca o cliente cujos dados pessoais foram apagados a pedido do titular (LGPD)
+	AnonymizedAt *time.Time `json:"anonymized_at"`
+	Purchases    []*Purchase
 }
 
 func (c *Client) BeforeCreate(tx *gorm.DB) error {
@@ -27,6 +54,23 @@ func (c *Client) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// BeforeSave recalcula os índices cegos a cada gravação, inclusive na rotação de chaves
+func (c *Client) BeforeSave(tx *gorm.DB) error {
+	c.CPFIndex = CPFBlindIndex(c.CPF)
+	c.PhoneIndex = PhoneBlindIndex(c.Phone)
+	return nil
+}
+
+// CPFBlindIndex é o valor de busca exata por CPF, com ou sem pontuação
+func CPFBlindIndex(cpf string) string {
+	return pii.BlindIndex(pii.Digits(cpf))
+}
+
+// PhoneBlindIndex é o valor de busca exata por telefone; o "+" dos números internacionais é ignorado
+func PhoneBlindIndex(phone string) string {
+	return pii.BlindIndex(pii.Digits(phone))
+}
+
 func NewClient(name, cpf, birthDate, email, phone string) *Client {
 	return &Client{
 		Name:      name,
@@ -38,6 +82,44 @@ func NewClient(name, cpf, birthDate, email, phone string) *Client {
 	}
 }
 
+// NewForeignClient cria um comprador estrangeiro, identificado pelo email em vez do CPF
+func NewForeignClient(name, documentID, country, birthDate, email, phone string) *Client {
+	return &Client{
+		Name:       name,
+		Birthdate:  birthDate,
+		Email:      strings.ToLower(strings.TrimSpace(email)),
+		Phone:      NormalizeInternationalPhone(phone),
+		Validated:  false,
+		BuyerType:  BuyerTypeForeign,
+		Country:    strings.ToUpper(strings.TrimSpace(country)),
+		DocumentID: NormalizeDocumentID(documentID),
+	}
+}
+
+func (c *Client) IsAnonymized() bool {
+	return c.AnonymizedAt != nil
+}
+
+func (c *Client) IsForeign() bool {
+	return c.BuyerType == BuyerTypeForeign
+}
+
+// DocumentLabel retorna o nome do documento de identificação do comprador
+func (c *Client) DocumentLabel() string {
+	if c.IsForeign() {
+		return "Documento"
+	}
+	return "CPF"
+}
+
+// IdentityDocument retorna o documento que identifica o comprador na marca d'água e nas exportações
+func (c *Client) IdentityDocument() string {
+	if c.IsForeign() {
+		return fmt.Sprintf("%s %s", c.Country, c.DocumentID)
+	}
+	return c.CPF
+}
+
 func (c *Client) Update(name, cpf, email, phone string) {
 	c.Name = name
 	c.CPF = cpf
@@ -67,6 +149,10 @@ func (c *Client) TotalDownloadsByEbook(ebookID uint) int {
 
 func (c *Client) GetBirthdateBR() string {
 	partsDate := strings.Split(c.Birthdate, "-")
+	if len(partsDate) != 3 {
+		// Clientes importados podem não ter a data de nascimento
+		return c.Birthdate
+	}
 	return fmt.Sprintf("%s/%s/%s", partsDate[2], partsDate[1], partsDate[0])
 }
 
@@ -86,3 +172,35 @@ func (c *Client) GetInitials() string {
 
 	return strings.ToUpper(initials)
 }
+
+// NormalizeDocumentID remove espaços, pontos e traços do passaporte ou documento fiscal estrangeiro
+func NormalizeDocumentID(documentID string) string {
+	replacer := strings.NewReplacer(" ", "", ".", "", "-", "", "/", "")
+	return strings.ToUpper(replacer.Replace(strings.TrimSpace(documentID)))
+}
+
+// NormalizeInternationalPhone mantém apenas o "+" inicial e os dígitos do telefone
+func NormalizeInternationalPhone(phone string) string {
+	var b strings.Builder
+	for i, char := range strings.TrimSpace(phone) {
+		if (char == '+' && i == 0) || (char >= '0' && char <= '9') {
+			b.WriteRune(char)
+		}
+	}
+	return b.String()
+}
+
+func IsValidDocumentID(documentID string) bool {
+	return documentIDPattern.MatchString(NormalizeDocumentID(documentID))
+}
+
+// IsValidForeignCountry aceita códigos ISO 3166-1 alfa-2, exceto o Brasil (que deve usar CPF)
+func IsValidForeignCountry(country string) bool {
+	country = strings.ToUpper(strings.TrimSpace(country))
+	return countryCodePattern.MatchString(country) && country != "BR"
+}
+
+// IsValidInternationalPhone valida telefones no formato E.164 (ex: +351912345678)
+func IsValidInternationalPhone(phone string) bool {
+	return internationalPhonePattern.MatchString(NormalizeInternationalPhone(phone))
+}
@@ -0,0 +1,111 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/anglesson/simple-web-server/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// ClientTagColors são as cores de etiqueta disponíveis (classes de badge do daisyUI)
+var ClientTagColors = []string{"primary", "secondary", "accent", "info", "success", "warning", "error"}
+
+// ClientTag é uma etiqueta criada pelo criador para organizar os próprios clientes
+type ClientTag struct {
+	gorm.Model
+	CreatorID uint   `json:"creator_id" gorm:"uniqueIndex:idx_client_tag_name"`
+	Name      string `json:"name" gorm:"type:varchar(40);uniqueIndex:idx_client_tag_name"`
+	Color     string `json:"color" gorm:"type:varchar(20)"`
+}
+
+// ClientTagAssignment liga uma etiqueta a um cliente. Ao remover a etiqueta do cliente o registro é apagado.
+type ClientTagAssignment struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	CreatorID uint `json:"creator_id" gorm:"index"`
+	TagID     uint `json:"tag_id" gorm:"uniqueIndex:idx_client_tag_assignment"`
+	ClientID  uint `json:"client_id" gorm:"uniqueIndex:idx_client_tag_assignment;index"`
+
+	Tag ClientTag `json:"tag" gorm:"foreignKey:TagID"`
+}
+
+// ClientNote é uma anotação privada do criador sobre um cliente
+type ClientNote struct {
+	gorm.Model
+	CreatorID uint   `json:"creator_id" gorm:"index:idx_client_note"`
+	ClientID  uint   `json:"client_id" gorm:"index:idx_client_note"`
+	Body      string `json:"body" gorm:"type:text"`
+}
+
+// SegmentRules são os critérios de um segmento de clientes. Critérios vazios não filtram nada
+// e o cliente precisa atender a todos os preenchidos.
+type SegmentRules struct {
+	// Cliente com pelo menos uma das etiquetas
+	TagIDs []uint `json:"tag_ids,omitempty"`
+	// Comprou todos estes ebooks
+	PurchasedEbookIDs []uint `json:"purchased_ebook_ids,omitempty"`
+	// Não comprou nenhum destes ebooks
+	NotPurchasedEbookIDs []uint `json:"not_purchased_ebook_ids,omitempty"`
+	// Gastou mais que este valor, em centavos, nas vendas concluídas do criador
+	MinSpent int64 `json:"min_spent,omitempty"`
+	// Não baixou nenhum ebook do criador nos últimos dias
+	InactiveDays int `json:"inactive_days,omitempty"`
+}
+
+func (r SegmentRules) IsEmpty() bool {
+	return len(r.TagIDs) == 0 && len(r.PurchasedEbookIDs) == 0 && len(r.NotPurchasedEbookIDs) == 0 &&
+		r.MinSpent <= 0 && r.InactiveDays <= 0
+}
+
+// Describe descreve os critérios em texto para a listagem de segmentos
+func (r SegmentRules) Describe(tagNames, ebookTitles map[uint]string) []string {
+	names := func(ids []uint, lookup map[uint]string) string {
+		var labels []string
+		for _, id := range ids {
+			if label, ok := lookup[id]; ok {
+				labels = append(labels, label)
+			} else {
+				labels = append(labels, fmt.Sprintf("#%d", id))
+			}
+		}
+		return strings.Join(labels, ", ")
+	}
+
+	var description []string
+	if len(r.TagIDs) > 0 {
+		description = append(description, "Com a etiqueta "+names(r.TagIDs, tagNames))
+	}
+	if len(r.PurchasedEbookIDs) > 0 {
+		description = append(description, "Comprou "+names(r.PurchasedEbookIDs, ebookTitles))
+	}
+	if len(r.NotPurchasedEbookIDs) > 0 {
+		description = append(description, "Não comprou "+names(r.NotPurchasedEbookIDs, ebookTitles))
+	}
+	if r.MinSpent > 0 {
+		description = append(description, "Gastou mais de "+money.FromCents(r.MinSpent).String())
+	}
+	if r.InactiveDays > 0 {
+		description = append(description, fmt.Sprintf("Sem download há %d dias", r.InactiveDays))
+	}
+	return description
+}
+
+// ClientSegment é um filtro de clientes salvo pelo criador, usado na listagem, exportação,
+// liberação de ebooks em massa e envio de emails
+type ClientSegment struct {
+	gorm.Model
+	PublicID  string       `json:"public_id" gorm:"type:varchar(40);uniqueIndex"`
+	CreatorID uint         `json:"creator_id" gorm:"index"`
+	Name      string       `json:"name" gorm:"type:varchar(80)"`
+	Rules     SegmentRules `json:"rules" gorm:"serializer:json"`
+}
+
+func (s *ClientSegment) BeforeCreate(tx *gorm.DB) error {
+	if s.PublicID == "" {
+		s.PublicID = utils.GeneratePublicID("seg_")
+	}
+	return nil
+}
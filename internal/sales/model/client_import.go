@@ -0,0 +1,160 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/anglesson/simple-web-server/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// ClientImportStatus acompanha a importação de clientes desde o envio da planilha até o relatório final
+type ClientImportStatus string
+
+const (
+	// Planilha lida, aguardando o criador confirmar o mapeamento das colunas
+	ClientImportStatusMapping    ClientImportStatus = "mapping"
+	ClientImportStatusQueued     ClientImportStatus = "queued"
+	ClientImportStatusProcessing ClientImportStatus = "processing"
+	ClientImportStatusCompleted  ClientImportStatus = "completed"
+	ClientImportStatusFailed     ClientImportStatus = "failed"
+)
+
+// Campos do cliente que podem receber uma coluna da planilha
+const (
+	ClientImportFieldName      = "name"
+	ClientImportFieldEmail     = "email"
+	ClientImportFieldCPF       = "cpf"
+	ClientImportFieldPhone     = "phone"
+	ClientImportFieldBirthdate = "birthdate"
+)
+
+// ClientImportField descreve um campo do cliente no formulário de mapeamento
+type ClientImportField struct {
+	Key      string
+	Label    string
+	Required bool
+	aliases  []string
+}
+
+// ClientImportFields lista os campos na ordem do formulário, com os nomes de coluna reconhecidos automaticamente
+var ClientImportFields = []ClientImportField{
+	{Key: ClientImportFieldName, Label: "Nome", Required: true, aliases: []string{"nome", "nome completo", "name", "cliente"}},
+	{Key: ClientImportFieldEmail, Label: "Email", Required: true, aliases: []string{"email", "e-mail", "e mail", "mail"}},
+	{Key: ClientImportFieldCPF, Label: "CPF", Required: true, aliases: []string{"cpf", "documento", "cpf do cliente"}},
+	{Key: ClientImportFieldPhone, Label: "Telefone", aliases: []string{"telefone", "celular", "whatsapp", "phone", "fone"}},
+	{Key: ClientImportFieldBirthdate, Label: "Data de nascimento", aliases: []string{"data de nascimento", "nascimento", "data nascimento", "birthdate", "aniversario"}},
+}
+
+// ClientImport é uma planilha de clientes enviada pelo criador. O conteúdo fica guardado até o job processá-la.
+type ClientImport struct {
+	gorm.Model
+	PublicID  string             `json:"public_id" gorm:"type:varchar(40);uniqueIndex"`
+	CreatorID uint               `json:"creator_id" gorm:"index"`
+	FileName  string             `json:"file_name"`
+	Status    ClientImportStatus `json:"status" gorm:"type:varchar(20);index"`
+	Headers   []string           `json:"headers" gorm:"serializer:json"`
+	// Mapping liga cada campo do cliente ao índice da coluna na planilha
+	Mapping map[string]int `json:"mapping" gorm:"serializer:json"`
+	Content []byte         `json:"-"`
+	// Ebook liberado para os clientes importados, quando escolhido
+	EbookID *uint `json:"ebook_id"`
+
+	TotalRows     int        `json:"total_rows"`
+	Created       int        `json:"created"`
+	Existing      int        `json:"existing"`
+	Failed        int        `json:"failed"`
+	AccessGranted int        `json:"access_granted"`
+	ErrorMessage  string     `json:"error_message"`
+	StartedAt     *time.Time `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at"`
+
+	Errors []ClientImportError `json:"errors" gorm:"foreignKey:ImportID"`
+}
+
+func (i *ClientImport) BeforeCreate(tx *gorm.DB) error {
+	if i.PublicID == "" {
+		i.PublicID = utils.GeneratePublicID("imp_")
+	}
+	return nil
+}
+
+// IsRunning indica que a importação ainda não terminou e a página deve ser atualizada
+func (i *ClientImport) IsRunning() bool {
+	return i.Status == ClientImportStatusQueued || i.Status == ClientImportStatusProcessing
+}
+
+// ColumnFor retorna a coluna mapeada para o campo ou -1 quando o campo não será importado
+func (i *ClientImport) ColumnFor(field string) int {
+	if column, ok := i.Mapping[field]; ok {
+		return column
+	}
+	return -1
+}
+
+func (i *ClientImport) GetStatusLabel() string {
+	switch i.Status {
+	case ClientImportStatusMapping:
+		return "Aguardando mapeamento"
+	case ClientImportStatusQueued:
+		return "Na fila"
+	case ClientImportStatusProcessing:
+		return "Processando"
+	case ClientImportStatusCompleted:
+		return "Concluída"
+	case ClientImportStatusFailed:
+		return "Falhou"
+	default:
+		return string(i.Status)
+	}
+}
+
+// ClientImportError é uma linha da planilha que não pôde ser importada
+type ClientImportError struct {
+	gorm.Model
+	ImportID uint   `json:"import_id" gorm:"index"`
+	Line     int    `json:"line"`
+	Field    string `json:"field"`
+	Value    string `json:"value"`
+	Message  string `json:"message"`
+}
+
+// GetFieldLabel retorna o nome do campo como aparece no formulário de mapeamento
+func (e *ClientImportError) GetFieldLabel() string {
+	for _, field := range ClientImportFields {
+		if field.Key == e.Field {
+			return field.Label
+		}
+	}
+	return e.Field
+}
+
+// CreatorClient liga ao criador os clientes que ele cadastrou sem passar por uma compra, como na importação
+type CreatorClient struct {
+	gorm.Model
+	CreatorID uint   `json:"creator_id" gorm:"uniqueIndex:idx_creator_client"`
+	ClientID  uint   `json:"client_id" gorm:"uniqueIndex:idx_creator_client"`
+	Source    string `json:"source" gorm:"type:varchar(20)"`
+}
+
+const CreatorClientSourceImport = "import"
+
+// SuggestMapping reconhece as colunas da planilha pelo nome do cabeçalho, ignorando acentos e maiúsculas
+func SuggestMapping(headers []string) map[string]int {
+	mapping := make(map[string]int)
+	for column, header := range headers {
+		normalized := strings.TrimSpace(utils.NormalizeText(header))
+		for _, field := range ClientImportFields {
+			if _, taken := mapping[field.Key]; taken {
+				continue
+			}
+			for _, alias := range field.aliases {
+				if normalized == alias {
+					mapping[field.Key] = column
+					break
+				}
+			}
+		}
+	}
+	return mapping
+}
@@ -0,0 +1,23 @@
+package model_test
+
+import (
+	"testing"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggestMapping(t *testing.T) {
+	headers := []string{"Código", " NOME COMPLETO ", "E-mail", "WhatsApp", "Data de Nascimento", "CPF", "Email"}
+
+	mapping := salesmodel.SuggestMapping(headers)
+
+	assert.Equal(t, map[string]int{"name": 1, "email": 2, "phone": 3, "birthdate": 4, "cpf": 5}, mapping)
+}
+
+func TestClientImport_ColumnFor(t *testing.T) {
+	clientImport := &salesmodel.ClientImport{Mapping: map[string]int{salesmodel.ClientImportFieldName: 0}}
+
+	assert.Equal(t, 0, clientImport.ColumnFor(salesmodel.ClientImportFieldName))
+	assert.Equal(t, -1, clientImport.ColumnFor(salesmodel.ClientImportFieldPhone))
+}
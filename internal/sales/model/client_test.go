@@ -48,3 +48,69 @@ func TestClient_GetInitials(t *testing.T) {
 		})
 	}
 }
+
+func TestNewForeignClient_NormalizesIdentity(t *testing.T) {
+	client := salesmodel.NewForeignClient("Ana Costa", "ab 123-456", "pt", "1990-05-10", " Ana@Email.PT ", "+351 912 345 678")
+
+	if !client.IsForeign() {
+		t.Fatalf("IsForeign() = false, want true")
+	}
+	if client.CPF != "" {
+		t.Errorf("CPF = %q, want empty", client.CPF)
+	}
+	if client.DocumentID != "AB123456" {
+		t.Errorf("DocumentID = %q, want %q", client.DocumentID, "AB123456")
+	}
+	if client.Email != "ana@email.pt" {
+		t.Errorf("Email = %q, want %q", client.Email, "ana@email.pt")
+	}
+	if client.Phone != "+351912345678" {
+		t.Errorf("Phone = %q, want %q", client.Phone, "+351912345678")
+	}
+	if got := client.IdentityDocument(); got != "PT AB123456" {
+		t.Errorf("IdentityDocument() = %q, want %q", got, "PT AB123456")
+	}
+}
+
+func TestClient_IdentityDocument_Brazilian(t *testing.T) {
+	client := salesmodel.NewClient("João Silva", "12345678901", "1990-01-01", "joao@email.com", "11999999999")
+
+	if client.IsForeign() {
+		t.Fatalf("IsForeign() = true, want false")
+	}
+	if got := client.IdentityDocument(); got != "12345678901" {
+		t.Errorf("IdentityDocument() = %q, want %q", got, "12345678901")
+	}
+	if got := client.DocumentLabel(); got != "CPF" {
+		t.Errorf("DocumentLabel() = %q, want %q", got, "CPF")
+	}
+}
+
+func TestForeignBuyerValidators(t *testing.T) {
+	tests := []struct {
+		name     string
+		valid    bool
+		expected bool
+	}{
+		{"country PT", salesmodel.IsValidForeignCountry("pt"), true},
+		{"country US", salesmodel.IsValidForeignCountry("US"), true},
+		{"country BR rejeitado", salesmodel.IsValidForeignCountry("BR"), false},
+		{"country inválido", salesmodel.IsValidForeignCountry("POR"), false},
+		{"passaporte", salesmodel.IsValidDocumentID("CB123456"), true},
+		{"tax id com traços", salesmodel.IsValidDocumentID("123-45-6789"), true},
+		{"documento curto", salesmodel.IsValidDocumentID("12"), false},
+		{"documento com símbolos", salesmodel.IsValidDocumentID("AB#1234"), false},
+		{"telefone português", salesmodel.IsValidInternationalPhone("+351 912 345 678"), true},
+		{"telefone americano", salesmodel.IsValidInternationalPhone("+1 (415) 555-2671"), true},
+		{"telefone sem +", salesmodel.IsValidInternationalPhone("351912345678"), false},
+		{"telefone curto", salesmodel.IsValidInternationalPhone("+12345"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.valid != tt.expected {
+				t.Errorf("got %v, want %v", tt.valid, tt.expected)
+			}
+		})
+	}
+}
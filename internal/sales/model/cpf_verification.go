@@ -0,0 +1,89 @@
+package model
+
+import (
+	"time"
+
+	"github.com/anglesson/simple-web-server/pkg/pii"
+	"gorm.io/gorm"
+)
+
+type CPFVerificationStatus string
+
+const (
+	CPFVerificationPending  CPFVerificationStatus = "pending"
+	CPFVerificationVerified CPFVerificationStatus = "verified"
+	CPFVerificationRejected CPFVerificationStatus = "rejected"
+)
+
+// CPFVerification guarda o resultado da consulta de um CPF na Receita Federal.
+// Resultados verificados ou rejeitados servem de cache até ExpiresAt; consultas
+// pendentes formam a fila do modo degradado, reprocessada quando o provedor volta.
+type CPFVerification struct {
+	gorm.Model
+	CPF            string                `gorm:"serializer:pii"`
+	Birthdate      string                `gorm:"serializer:pii"`
+	CPFIndex       string                `gorm:"type:varchar(64);index" pii:"index"`
+	LookupIndex    string                `gorm:"type:varchar(64);index" pii:"index"` // HMAC do CPF com a data de nascimento, usado no cache
+	Name           string                // Nome informado no checkout
+	RegisteredName string                // Nome retornado pela Receita Federal
+	Status         CPFVerificationStatus `gorm:"type:varchar(20);index"`
+	CreatorID      uint                  `gorm:"index"` // Criador cuja venda originou a consulta
+	Attempts       int                   `gorm:"default:0"`
+	VerifiedAt     *time.Time
+	ExpiresAt      *time.Time
+}
+
+func NewPendingCPFVerification(creatorID uint, name, cpf, birthdate string) *CPFVerification {
+	return &CPFVerification{
+		CPF:       cpf,
+		Birthdate: birthdate,
+		Name:      name,
+		Status:    CPFVerificationPending,
+		CreatorID: creatorID,
+	}
+}
+
+// BeforeSave recalcula os índices cegos a cada gravação, inclusive na rotação de chaves
+func (v *CPFVerification) BeforeSave(tx *gorm.DB) error {
+	v.CPFIndex = CPFBlindIndex(v.CPF)
+	v.LookupIndex = CPFVerificationLookupIndex(v.CPF, v.Birthdate)
+	return nil
+}
+
+// CPFVerificationLookupIndex é o valor de busca do resultado em cache para o CPF e a data de nascimento
+func CPFVerificationLookupIndex(cpf, birthdate string) string {
+	return pii.BlindIndex(pii.Digits(cpf) + "|" + birthdate)
+}
+
+func (v *CPFVerification) IsPending() bool {
+	return v.Status == CPFVerificationPending
+}
+
+func (v *CPFVerification) IsVerified() bool {
+	return v.Status == CPFVerificationVerified
+}
+
+// IsFresh indica se o resultado ainda pode ser reaproveitado sem nova consulta paga
+func (v *CPFVerification) IsFresh(now time.Time) bool {
+	return !v.IsPending() && v.ExpiresAt != nil && now.Before(*v.ExpiresAt)
+}
+
+// Resolve registra o resultado da consulta e define a validade do cache
+func (v *CPFVerification) Resolve(verified bool, registeredName string, now time.Time, ttl time.Duration) {
+	v.Status = CPFVerificationRejected
+	if verified {
+		v.Status = CPFVerificationVerified
+	}
+	v.RegisteredName = registeredName
+	v.VerifiedAt = &now
+	expiresAt := now.Add(ttl)
+	v.ExpiresAt = &expiresAt
+}
+
+// CPFLookupUsage registra cada consulta paga feita ao provedor, atribuída ao criador da venda
+type CPFLookupUsage struct {
+	gorm.Model
+	CreatorID uint `gorm:"index"`
+	Consumed  int
+	Success   bool
+}
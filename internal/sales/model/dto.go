@@ -106,6 +106,8 @@ type ClientFilter struct {
 	Term       string
 	EbookID    uint
 	Pagination *Pagination
+	// Segment restringe a listagem aos clientes que atendem aos critérios
+	Segment *SegmentRules
 }
 
 type ClientRequest struct {
@@ -0,0 +1,116 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/config"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/anglesson/simple-web-server/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// FeePlanKind define como o plano de taxas chega ao criador
+type FeePlanKind string
+
+const (
+	FeePlanKindDefault    FeePlanKind = "default"    // Plano padrão da plataforma
+	FeePlanKindSubscriber FeePlanKind = "subscriber" // Vale para criadores com assinatura paga ativa
+	FeePlanKindVolume     FeePlanKind = "volume"     // Faixa liberada pelo volume vendido nos últimos 30 dias
+	FeePlanKindCustom     FeePlanKind = "custom"     // Só vale quando atribuído ao criador (promoções e negociações)
+)
+
+// IsValid indica se o tipo é um dos conhecidos
+func (k FeePlanKind) IsValid() bool {
+	switch k {
+	case FeePlanKindDefault, FeePlanKindSubscriber, FeePlanKindVolume, FeePlanKindCustom:
+		return true
+	}
+	return false
+}
+
+// FeePlanInput são os dados de um plano novo. Percentage é uma fração (0.0291 = 2,91%)
+type FeePlanInput struct {
+	Name             string
+	Kind             FeePlanKind
+	Percentage       float64
+	FixedFeeCents    int64
+	MinMonthlyVolume int64
+}
+
+// FeePlan é um plano de taxas da Docffy: percentual sobre a venda + parcela fixa em centavos de real.
+// Nas moedas estrangeiras a parcela fixa segue config.Business.ForeignCurrencyFees.
+type FeePlan struct {
+	gorm.Model
+	PublicID      string      `json:"public_id" gorm:"type:varchar(40);uniqueIndex"`
+	Name          string      `json:"name"`
+	Kind          FeePlanKind `json:"kind" gorm:"type:varchar(20);index"`
+	Percentage    float64     `json:"percentage"`
+	FixedFeeCents int64       `json:"fixed_fee_cents"`
+	// Volume mínimo (centavos de real, últimos 30 dias) para as faixas do tipo volume
+	MinMonthlyVolume int64 `json:"min_monthly_volume"`
+	Active           bool  `json:"active" gorm:"default:true"`
+}
+
+func (p *FeePlan) BeforeCreate(tx *gorm.DB) error {
+	if p.PublicID == "" {
+		p.PublicID = utils.GeneratePublicID("fee_")
+	}
+	return nil
+}
+
+// DefaultFeePlan monta o plano padrão a partir de config.Business, usado quando não há plano cadastrado
+func DefaultFeePlan() *FeePlan {
+	return &FeePlan{
+		Name:          "Padrão",
+		Kind:          FeePlanKindDefault,
+		Percentage:    config.Business.PlatformFeePercentage,
+		FixedFeeCents: config.Business.PlatformFixedFeeCents,
+		Active:        true,
+	}
+}
+
+// FixedFeeFor retorna a parcela fixa do plano na moeda informada
+func (p *FeePlan) FixedFeeFor(currency string) int64 {
+	if currency == "" || currency == money.BRL {
+		return p.FixedFeeCents
+	}
+	return config.Business.PlatformFixedFeeFor(currency)
+}
+
+// FeeFor calcula a taxa da Docffy sobre o total, com arredondamento para o centavo mais próximo
+func (p *FeePlan) FeeFor(total money.Money) int64 {
+	return total.Mul(p.Percentage).Amount + p.FixedFeeFor(total.CurrencyOrDefault())
+}
+
+// Display retorna a taxa no formato exibido ao criador (ex.: "2,91% + R$ 1,00")
+func (p *FeePlan) Display() string {
+	percent := strconv.FormatFloat(math.Round(p.Percentage*10000)/100, 'f', -1, 64)
+	percent = strings.Replace(percent, ".", ",", 1)
+	if p.FixedFeeCents == 0 {
+		return percent + "%"
+	}
+	return fmt.Sprintf("%s%% + %s", percent, money.FromCents(p.FixedFeeCents))
+}
+
+// CreatorFeePlan atribui um plano a um criador, opcionalmente por tempo limitado (taxas promocionais)
+type CreatorFeePlan struct {
+	gorm.Model
+	CreatorID uint       `json:"creator_id" gorm:"index"`
+	FeePlanID uint       `json:"fee_plan_id"`
+	FeePlan   FeePlan    `json:"fee_plan" gorm:"foreignKey:FeePlanID"`
+	StartsAt  time.Time  `json:"starts_at"`
+	EndsAt    *time.Time `json:"ends_at"`
+	Note      string     `json:"note"`
+}
+
+// IsActiveAt indica se a atribuição vale no instante informado
+func (a *CreatorFeePlan) IsActiveAt(at time.Time) bool {
+	if at.Before(a.StartsAt) {
+		return false
+	}
+	return a.EndsAt == nil || at.Before(*a.EndsAt)
+}
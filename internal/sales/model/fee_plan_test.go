@@ -0,0 +1,34 @@
+package model_test
+
+import (
+	"testing"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeePlan_FeeForAndDisplay(t *testing.T) {
+	plan := &salesmodel.FeePlan{Percentage: 0.0291, FixedFeeCents: 100}
+
+	assert.Equal(t, int64(291+100), plan.FeeFor(money.FromCents(10000)))
+	assert.Equal(t, int64(291+20), plan.FeeFor(money.New(10000, money.EUR)), "em euro vale a parcela fixa da moeda")
+	assert.Equal(t, "2,91% + R$ 1,00", plan.Display())
+	assert.Equal(t, "1,5%", (&salesmodel.FeePlan{Percentage: 0.015}).Display())
+}
+
+func TestTransaction_ApplyFeePlanSnapshotsPlan(t *testing.T) {
+	plan := &salesmodel.FeePlan{Name: "Assinante", Percentage: 0.015, FixedFeeCents: 50}
+	plan.ID = 4
+	transaction := salesmodel.NewTransaction(1, 2, salesmodel.SplitTypeFixedAmount)
+
+	transaction.ApplyFeePlan(plan)
+	transaction.CalculateSplit(money.FromCents(10000))
+
+	assert.Equal(t, int64(150+50), transaction.PlatformAmount)
+	assert.Equal(t, "Assinante", transaction.FeePlanName)
+	assert.Equal(t, uint(4), *transaction.FeePlanID)
+
+	restored := transaction.FeePlan()
+	assert.Equal(t, plan.FeeFor(money.FromCents(10000)), restored.FeeFor(money.FromCents(10000)))
+}
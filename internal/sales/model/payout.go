@@ -0,0 +1,80 @@
+package model
+
+import (
+	"time"
+
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"gorm.io/gorm"
+)
+
+// PayoutStatus acompanha o status do repasse no Stripe
+type PayoutStatus string
+
+const (
+	PayoutStatusPending   PayoutStatus = "pending"
+	PayoutStatusInTransit PayoutStatus = "in_transit"
+	PayoutStatusPaid      PayoutStatus = "paid"
+	PayoutStatusFailed    PayoutStatus = "failed"
+	PayoutStatusCanceled  PayoutStatus = "canceled"
+)
+
+// Payout é um repasse do saldo da conta Stripe Connect do criador para a conta bancária dele
+type Payout struct {
+	gorm.Model
+	CreatorID      uint         `json:"creator_id" gorm:"index"`
+	StripePayoutID string       `json:"stripe_payout_id" gorm:"type:varchar(60);uniqueIndex"`
+	Amount         int64        `json:"amount"`
+	Currency       string       `json:"currency" gorm:"type:varchar(3)"`
+	Status         PayoutStatus `json:"status" gorm:"type:varchar(20)"`
+	Automatic      bool         `json:"automatic"`
+	ArrivalDate    time.Time    `json:"arrival_date"`
+	FailureMessage string       `json:"failure_message"`
+	// ItemsSyncedAt marca a importação das movimentações do repasse; só repasses automáticos têm essa lista no Stripe
+	ItemsSyncedAt *time.Time `json:"items_synced_at"`
+
+	Items []PayoutItem `json:"items" gorm:"foreignKey:PayoutID"`
+}
+
+// IsUpcoming indica que o dinheiro ainda não chegou à conta bancária
+func (p *Payout) IsUpcoming() bool {
+	return p.Status == PayoutStatusPending || p.Status == PayoutStatusInTransit
+}
+
+func (p *Payout) GetFormattedAmount() string {
+	return money.New(p.Amount, p.Currency).String()
+}
+
+func (p *Payout) GetStatusLabel() string {
+	switch p.Status {
+	case PayoutStatusPending:
+		return "Agendado"
+	case PayoutStatusInTransit:
+		return "A caminho"
+	case PayoutStatusPaid:
+		return "Pago"
+	case PayoutStatusFailed:
+		return "Falhou"
+	case PayoutStatusCanceled:
+		return "Cancelado"
+	default:
+		return string(p.Status)
+	}
+}
+
+// PayoutItem é uma movimentação do saldo (venda, reembolso, ajuste) incluída no repasse
+type PayoutItem struct {
+	gorm.Model
+	PayoutID                   uint   `json:"payout_id" gorm:"index"`
+	StripeBalanceTransactionID string `json:"stripe_balance_transaction_id" gorm:"type:varchar(60);uniqueIndex"`
+	Type                       string `json:"type" gorm:"type:varchar(30)"`
+	StripePaymentIntentID      string `json:"stripe_payment_intent_id"`
+
+	// Valores em centavos na moeda Currency; Net = Amount - Fee
+	Amount   int64  `json:"amount"`
+	Fee      int64  `json:"fee"`
+	Net      int64  `json:"net"`
+	Currency string `json:"currency" gorm:"type:varchar(3)"`
+
+	// TransactionID aponta a venda da plataforma coberta pela movimentação, quando identificada
+	TransactionID *uint `json:"transaction_id" gorm:"index"`
+}
@@ -0,0 +1,163 @@
+package model
+
+import (
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	"github.com/anglesson/simple-web-server/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// PrivacyRequestType é o direito exercido pelo titular dos dados (art. 18 da LGPD)
+type PrivacyRequestType string
+
+const (
+	// Acesso aos dados: o titular recebe uma cópia de tudo o que a plataforma guarda sobre ele
+	PrivacyRequestAccess PrivacyRequestType = "access"
+	// Eliminação dos dados: os dados pessoais são anonimizados, mantendo os registros financeiros exigidos por lei
+	PrivacyRequestDeletion PrivacyRequestType = "deletion"
+)
+
+// AnonymizedClientName substitui o nome do cliente anonimizado nas telas e relatórios
+const AnonymizedClientName = "Titular anonimizado"
+
+type PrivacyRequestStatus string
+
+const (
+	PrivacyRequestOpen      PrivacyRequestStatus = "open"
+	PrivacyRequestCompleted PrivacyRequestStatus = "completed"
+	PrivacyRequestRejected  PrivacyRequestStatus = "rejected"
+)
+
+// PrivacyRequest registra a solicitação de um titular, o prazo de resposta e quem a atendeu.
+// O registro é mantido mesmo depois da anonimização, como prova do atendimento.
+type PrivacyRequest struct {
+	gorm.Model
+	PublicID string `json:"public_id" gorm:"type:varchar(40);uniqueIndex"`
+	ClientID uint   `json:"client_id" gorm:"index"`
+	Client   Client `gorm:"foreignKey:ClientID"`
+	// CreatorID é o criador que registrou a solicitação; vazio quando registrada pelos operadores da plataforma
+	CreatorID *uint                 `json:"creator_id" gorm:"index"`
+	Creator   *accountmodel.Creator `gorm:"foreignKey:CreatorID"`
+	Type      PrivacyRequestType    `json:"type" gorm:"type:varchar(20)"`
+	Status    PrivacyRequestStatus  `json:"status" gorm:"type:varchar(20);index"`
+	// RequesterEmail é o canal informado pelo titular para receber a resposta
+	RequesterEmail string    `json:"requester_email"`
+	Details        string    `json:"details" gorm:"type:text"`
+	DueAt          time.Time `json:"due_at" gorm:"index"`
+
+	ExportedAt  *time.Time `json:"exported_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	// HandledBy é o email do usuário que concluiu ou recusou a solicitação
+	HandledBy  string `json:"handled_by"`
+	Resolution string `json:"resolution" gorm:"type:text"`
+}
+
+func (p *PrivacyRequest) BeforeCreate(tx *gorm.DB) error {
+	if p.PublicID == "" {
+		p.PublicID = utils.GeneratePublicID("dsr_")
+	}
+	return nil
+}
+
+func NewPrivacyRequest(clientID uint, creatorID *uint, requestType PrivacyRequestType, requesterEmail, details string, dueAt time.Time) *PrivacyRequest {
+	return &PrivacyRequest{
+		ClientID:       clientID,
+		CreatorID:      creatorID,
+		Type:           requestType,
+		Status:         PrivacyRequestOpen,
+		RequesterEmail: requesterEmail,
+		Details:        details,
+		DueAt:          dueAt,
+	}
+}
+
+func IsPrivacyRequestType(value string) bool {
+	return value == string(PrivacyRequestAccess) || value == string(PrivacyRequestDeletion)
+}
+
+func (p *PrivacyRequest) IsOpen() bool {
+	return p.Status == PrivacyRequestOpen
+}
+
+func (p *PrivacyRequest) IsDeletion() bool {
+	return p.Type == PrivacyRequestDeletion
+}
+
+// IsOverdue indica a solicitação em aberto cujo prazo de resposta já passou
+func (p *PrivacyRequest) IsOverdue(now time.Time) bool {
+	return p.IsOpen() && now.After(p.DueAt)
+}
+
+// Complete encerra a solicitação como atendida
+func (p *PrivacyRequest) Complete(handledBy, resolution string, now time.Time) {
+	p.Status = PrivacyRequestCompleted
+	p.HandledBy = handledBy
+	p.Resolution = resolution
+	p.CompletedAt = &now
+}
+
+// Reject encerra a solicitação sem atendê-la; o motivo é obrigatório e fica registrado
+func (p *PrivacyRequest) Reject(handledBy, reason string, now time.Time) {
+	p.Status = PrivacyRequestRejected
+	p.HandledBy = handledBy
+	p.Resolution = reason
+	p.CompletedAt = &now
+}
+
+func (p *PrivacyRequest) GetTypeLabel() string {
+	switch p.Type {
+	case PrivacyRequestAccess:
+		return "Acesso aos dados"
+	case PrivacyRequestDeletion:
+		return "Eliminação dos dados"
+	default:
+		return string(p.Type)
+	}
+}
+
+func (p *PrivacyRequest) GetStatusLabel() string {
+	switch p.Status {
+	case PrivacyRequestOpen:
+		if p.IsOverdue(time.Now()) {
+			return "Atrasada"
+		}
+		return "Em aberto"
+	case PrivacyRequestCompleted:
+		return "Atendida"
+	case PrivacyRequestRejected:
+		return "Recusada"
+	default:
+		return string(p.Status)
+	}
+}
+
+func (p *PrivacyRequest) GetStatusBadgeClass() string {
+	switch p.Status {
+	case PrivacyRequestOpen:
+		if p.IsOverdue(time.Now()) {
+			return "badge-error"
+		}
+		return "badge-warning"
+	case PrivacyRequestCompleted:
+		return "badge-success"
+	default:
+		return "badge-ghost"
+	}
+}
+
+// ClientPersonalData reúne os registros ligados ao cliente que entram na exportação de acesso
+type ClientPersonalData struct {
+	Client           *Client
+	Purchases        []*Purchase
+	Transactions     []*Transaction
+	DownloadLogs     []*DownloadLog
+	Tags             []*ClientTagAssignment
+	Notes            []*ClientNote
+	Broadcasts       []*Broadcast
+	Deliveries       []*BroadcastDelivery
+	Unsubscribes     []*EmailUnsubscribe
+	CheckoutAttempts []*CheckoutAttempt
+	WaitlistEntries  []*WaitlistEntry
+	Requests         []*PrivacyRequest
+}
@@ -28,6 +28,17 @@ type Purchase struct {
 	DownloadLimit int                `json:"download_limit"`
 	HashID        string             `json:"purchase_id" gorm:"uniqueIndex:purchase_id_unique"`
 	PaymentStatus PaymentStatus      `json:"payment_status" gorm:"type:varchar(20);default:'pending'"`
+	// AwaitingRelease indica uma pré-venda paga cujo acesso será liberado no lançamento do ebook
+	AwaitingRelease bool `json:"awaiting_release" gorm:"default:false;index"`
+	// StockReserved indica que a compra ocupa uma unidade do estoque de uma oferta limitada
+	StockReserved bool `json:"stock_reserved" gorm:"default:false"`
+	// HeldForReview retém a entrega de uma compra suspeita até a revisão do criador
+	HeldForReview bool `json:"held_for_review" gorm:"default:false"`
+	// CheckoutSessionID é a sessão de checkout do Stripe aberta por último para a compra;
+	// novas tentativas e recuperações substituem a anterior
+	CheckoutSessionID string `json:"-" gorm:"type:varchar(255)"`
+	// Status é o ciclo de vida da compra; as mudanças passam pelo PurchaseService e ficam no histórico
+	Status PurchaseStatus `json:"status" gorm:"type:varchar(20);default:'pending';index"`
 }
 
 func (p *Purchase) BeforeCreate(tx *gorm.DB) error {
@@ -44,6 +55,7 @@ func NewPurchase(ebookID, clientID uint, hashID string) *Purchase {
 		DownloadLimit: -1,
 		HashID:        hashID,
 		PaymentStatus: PaymentStatusPending,
+		Status:        PurchaseStatusPending,
 	}
 }
 
@@ -51,6 +63,37 @@ func (p *Purchase) IsPaymentConfirmed() bool {
 	return p.PaymentStatus == PaymentStatusConfirmed
 }
 
+func (p *Purchase) IsAwaitingRelease() bool {
+	return p.AwaitingRelease
+}
+
+func (p *Purchase) IsHeldForReview() bool {
+	return p.HeldForReview
+}
+
+// IsActiveCheckoutSession indica se a sessão é a última aberta para a compra.
+// Compras anteriores ao registro da sessão aceitam qualquer uma.
+func (p *Purchase) IsActiveCheckoutSession(sessionID string) bool {
+	return p.CheckoutSessionID == "" || p.CheckoutSessionID == sessionID
+}
+
+// IsAccessRevoked indica que o acesso foi bloqueado, revogado, reembolsado ou contestado
+func (p *Purchase) IsAccessRevoked() bool {
+	return p.Status.RevokesAccess()
+}
+
+func (p *Purchase) IsBlocked() bool {
+	return p.Status == PurchaseStatusBlocked
+}
+
+func (p *Purchase) CanBlock() bool {
+	return p.Status.CanTransitionTo(PurchaseStatusBlocked)
+}
+
+func (p *Purchase) CanRevoke() bool {
+	return p.Status.CanTransitionTo(PurchaseStatusRevoked)
+}
+
 func (p *Purchase) AvailableDownloads() bool {
 	if p.DownloadLimit == -1 {
 		return true
@@ -64,6 +107,9 @@ func (p *Purchase) AvailableDownloads() bool {
 }
 
 func (p *Purchase) IsExpired() bool {
+	if p.Status == PurchaseStatusExpired {
+		return true
+	}
 	if p.ExpiresAt.IsZero() {
 		return false
 	}
@@ -0,0 +1,146 @@
+package model
+
+import (
+	"gorm.io/gorm"
+)
+
+// PurchaseStatus é o ciclo de vida da compra, do checkout ao fim do acesso
+type PurchaseStatus string
+
+const (
+	PurchaseStatusPending    PurchaseStatus = "pending"
+	PurchaseStatusPaid       PurchaseStatus = "paid"
+	PurchaseStatusRefunded   PurchaseStatus = "refunded"
+	PurchaseStatusChargeback PurchaseStatus = "chargeback"
+	PurchaseStatusRevoked    PurchaseStatus = "revoked"
+	PurchaseStatusBlocked    PurchaseStatus = "blocked"
+	PurchaseStatusExpired    PurchaseStatus = "expired"
+)
+
+// purchaseStatusTransitions lista os destinos permitidos a partir de cada status.
+// Uma sessão expirada ainda pode ser paga pelo link de recuperação do checkout, e uma
+// contestação encerrada a favor do vendedor devolve a compra para paga.
+// Reembolso e revogação são definitivos.
+var purchaseStatusTransitions = map[PurchaseStatus][]PurchaseStatus{
+	PurchaseStatusPending:    {PurchaseStatusPaid, PurchaseStatusExpired, PurchaseStatusRevoked},
+	PurchaseStatusPaid:       {PurchaseStatusRefunded, PurchaseStatusChargeback, PurchaseStatusRevoked, PurchaseStatusBlocked, PurchaseStatusExpired},
+	PurchaseStatusBlocked:    {PurchaseStatusPaid, PurchaseStatusRefunded, PurchaseStatusChargeback, PurchaseStatusRevoked},
+	PurchaseStatusChargeback: {PurchaseStatusPaid},
+	PurchaseStatusExpired:    {PurchaseStatusPaid},
+}
+
+// CanTransitionTo indica se a mudança de status é permitida pela máquina de estados
+func (s PurchaseStatus) CanTransitionTo(to PurchaseStatus) bool {
+	for _, allowed := range purchaseStatusTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// RevokesAccess indica os status em que o comprador perde o acesso ao conteúdo já pago
+func (s PurchaseStatus) RevokesAccess() bool {
+	switch s {
+	case PurchaseStatusRefunded, PurchaseStatusChargeback, PurchaseStatusRevoked, PurchaseStatusBlocked:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s PurchaseStatus) Label() string {
+	switch s {
+	case PurchaseStatusPending:
+		return "Pendente"
+	case PurchaseStatusPaid:
+		return "Paga"
+	case PurchaseStatusRefunded:
+		return "Reembolsada"
+	case PurchaseStatusChargeback:
+		return "Contestada"
+	case PurchaseStatusRevoked:
+		return "Acesso revogado"
+	case PurchaseStatusBlocked:
+		return "Bloqueada"
+	case PurchaseStatusExpired:
+		return "Expirada"
+	default:
+		return "-"
+	}
+}
+
+// BadgeClass é a classe do badge (DaisyUI) usada para exibir o status
+func (s PurchaseStatus) BadgeClass() string {
+	switch s {
+	case PurchaseStatusPaid:
+		return "badge-success text-white"
+	case PurchaseStatusPending:
+		return "badge-warning"
+	case PurchaseStatusRefunded, PurchaseStatusExpired:
+		return "badge-neutral"
+	default:
+		return "badge-error text-white"
+	}
+}
+
+// StatusActor identifica quem originou a mudança de status
+type StatusActor string
+
+const (
+	StatusActorSystem  StatusActor = "system"
+	StatusActorStripe  StatusActor = "stripe"
+	StatusActorCreator StatusActor = "creator"
+)
+
+// StatusChange descreve o autor e o motivo de uma mudança de status
+type StatusChange struct {
+	Actor   StatusActor
+	ActorID uint
+	Reason  string
+}
+
+// PurchaseStatusHistory registra cada mudança de status da compra com o autor e o motivo
+type PurchaseStatusHistory struct {
+	gorm.Model
+	PurchaseID uint           `json:"purchase_id" gorm:"index"`
+	FromStatus PurchaseStatus `json:"from_status" gorm:"type:varchar(20)"`
+	ToStatus   PurchaseStatus `json:"to_status" gorm:"type:varchar(20)"`
+	Actor      StatusActor    `json:"actor" gorm:"type:varchar(20)"`
+	ActorID    *uint          `json:"actor_id"`
+	Reason     string         `json:"reason"`
+}
+
+func (PurchaseStatusHistory) TableName() string {
+	return "purchase_status_history"
+}
+
+func NewPurchaseStatusHistory(purchaseID uint, from, to PurchaseStatus, change StatusChange) *PurchaseStatusHistory {
+	history := &PurchaseStatusHistory{
+		PurchaseID: purchaseID,
+		FromStatus: from,
+		ToStatus:   to,
+		Actor:      change.Actor,
+		Reason:     change.Reason,
+	}
+	if change.ActorID != 0 {
+		actorID := change.ActorID
+		history.ActorID = &actorID
+	}
+	return history
+}
+
+func (h *PurchaseStatusHistory) GetActorLabel() string {
+	switch h.Actor {
+	case StatusActorCreator:
+		return "Criador"
+	case StatusActorStripe:
+		return "Stripe"
+	default:
+		return "Sistema"
+	}
+}
+
+func (h *PurchaseStatusHistory) GetCreatedAtBR() string {
+	return h.CreatedAt.Format("02/01/2006 15:04")
+}
@@ -0,0 +1,54 @@
+package model_test
+
+import (
+	"testing"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurchaseStatus_CanTransitionTo(t *testing.T) {
+	cases := []struct {
+		from, to salesmodel.PurchaseStatus
+		allowed  bool
+	}{
+		{salesmodel.PurchaseStatusPending, salesmodel.PurchaseStatusPaid, true},
+		{salesmodel.PurchaseStatusPending, salesmodel.PurchaseStatusExpired, true},
+		{salesmodel.PurchaseStatusPending, salesmodel.PurchaseStatusBlocked, false},
+		{salesmodel.PurchaseStatusPaid, salesmodel.PurchaseStatusBlocked, true},
+		{salesmodel.PurchaseStatusPaid, salesmodel.PurchaseStatusChargeback, true},
+		{salesmodel.PurchaseStatusBlocked, salesmodel.PurchaseStatusPaid, true},
+		{salesmodel.PurchaseStatusExpired, salesmodel.PurchaseStatusPaid, true},
+		{salesmodel.PurchaseStatusChargeback, salesmodel.PurchaseStatusPaid, true},
+		{salesmodel.PurchaseStatusChargeback, salesmodel.PurchaseStatusBlocked, false},
+		{salesmodel.PurchaseStatusRefunded, salesmodel.PurchaseStatusPaid, false},
+		{salesmodel.PurchaseStatusRevoked, salesmodel.PurchaseStatusPaid, false},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.allowed, c.from.CanTransitionTo(c.to), "%s → %s", c.from, c.to)
+	}
+}
+
+func TestPurchase_IsAccessRevoked(t *testing.T) {
+	assert.False(t, (&salesmodel.Purchase{Status: salesmodel.PurchaseStatusPaid}).IsAccessRevoked())
+	assert.False(t, (&salesmodel.Purchase{}).IsAccessRevoked())
+	assert.True(t, (&salesmodel.Purchase{Status: salesmodel.PurchaseStatusBlocked}).IsAccessRevoked())
+	assert.True(t, (&salesmodel.Purchase{Status: salesmodel.PurchaseStatusChargeback}).IsAccessRevoked())
+	assert.True(t, (&salesmodel.Purchase{Status: salesmodel.PurchaseStatusExpired}).IsExpired())
+}
+
+func TestNewPurchaseStatusHistory(t *testing.T) {
+	history := salesmodel.NewPurchaseStatusHistory(7, salesmodel.PurchaseStatusPaid, salesmodel.PurchaseStatusBlocked,
+		salesmodel.StatusChange{Actor: salesmodel.StatusActorCreator, ActorID: 3, Reason: "Compartilhou o link"})
+
+	assert.Equal(t, uint(7), history.PurchaseID)
+	assert.Equal(t, "Criador", history.GetActorLabel())
+	if assert.NotNil(t, history.ActorID) {
+		assert.Equal(t, uint(3), *history.ActorID)
+	}
+
+	system := salesmodel.NewPurchaseStatusHistory(7, salesmodel.PurchaseStatusPending, salesmodel.PurchaseStatusExpired,
+		salesmodel.StatusChange{Actor: salesmodel.StatusActorSystem})
+	assert.Nil(t, system.ActorID)
+}
@@ -136,3 +136,12 @@ func TestPurchaseValidationLogic(t *testing.T) {
 	assert.Equal(t, 5, purchaseValid.DownloadLimit)
 	assert.True(t, purchaseValid.ExpiresAt.After(time.Now()))
 }
+
+func TestPurchase_IsActiveCheckoutSession(t *testing.T) {
+	legacy := &salesmodel.Purchase{}
+	assert.True(t, legacy.IsActiveCheckoutSession("cs_qualquer"))
+
+	purchase := &salesmodel.Purchase{CheckoutSessionID: "cs_nova"}
+	assert.True(t, purchase.IsActiveCheckoutSession("cs_nova"))
+	assert.False(t, purchase.IsActiveCheckoutSession("cs_antiga"))
+}
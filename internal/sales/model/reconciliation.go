@@ -0,0 +1,79 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReconciliationIssueKind classifica a divergência encontrada entre o banco e o Stripe
+type ReconciliationIssueKind string
+
+const (
+	// Pagamento confirmado no Stripe sem Transaction com o payment intent
+	ReconciliationMissingLocal ReconciliationIssueKind = "missing_local"
+	// Valor ou moeda recebidos no Stripe diferentes do registrado na Transaction
+	ReconciliationAmountMismatch ReconciliationIssueKind = "amount_mismatch"
+	// Status da Transaction ou da compra diferente do estado do pagamento no Stripe
+	ReconciliationStatusMismatch ReconciliationIssueKind = "status_mismatch"
+	// Transaction pendente há mais tempo do que dura uma sessão de checkout
+	ReconciliationOrphanPending ReconciliationIssueKind = "orphan_pending"
+)
+
+// ReconciliationRun registra uma execução da conciliação com o Stripe
+type ReconciliationRun struct {
+	gorm.Model
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Checked   int       `json:"checked"`
+	AutoFixed int       `json:"auto_fixed"`
+
+	Issues []ReconciliationIssue `json:"issues" gorm:"foreignKey:RunID"`
+}
+
+// PendingIssues retorna as divergências que precisam de um operador
+func (r *ReconciliationRun) PendingIssues() []ReconciliationIssue {
+	var pending []ReconciliationIssue
+	for _, issue := range r.Issues {
+		if !issue.AutoFixed {
+			pending = append(pending, issue)
+		}
+	}
+	return pending
+}
+
+// ReconciliationIssue é uma divergência encontrada na conciliação, corrigida automaticamente ou não
+type ReconciliationIssue struct {
+	gorm.Model
+	RunID                 uint                    `json:"run_id" gorm:"index"`
+	Kind                  ReconciliationIssueKind `json:"kind" gorm:"type:varchar(30)"`
+	CreatorID             uint                    `json:"creator_id" gorm:"index"`
+	TransactionID         *uint                   `json:"transaction_id"`
+	StripeAccountID       string                  `json:"stripe_account_id"`
+	StripePaymentIntentID string                  `json:"stripe_payment_intent_id" gorm:"index"`
+
+	// Valores em centavos na moeda Currency
+	LocalAmount  int64  `json:"local_amount"`
+	StripeAmount int64  `json:"stripe_amount"`
+	Currency     string `json:"currency" gorm:"type:varchar(3)"`
+	LocalStatus  string `json:"local_status"`
+	StripeStatus string `json:"stripe_status"`
+
+	Detail    string `json:"detail"`
+	AutoFixed bool   `json:"auto_fixed"`
+}
+
+func (i *ReconciliationIssue) GetKindLabel() string {
+	switch i.Kind {
+	case ReconciliationMissingLocal:
+		return "Pagamento sem transação"
+	case ReconciliationAmountMismatch:
+		return "Valor divergente"
+	case ReconciliationStatusMismatch:
+		return "Status divergente"
+	case ReconciliationOrphanPending:
+		return "Transação pendente órfã"
+	default:
+		return string(i.Kind)
+	}
+}
@@ -0,0 +1,119 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"gorm.io/gorm"
+)
+
+var monthNames = [...]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho",
+	"julho", "agosto", "setembro", "outubro", "novembro", "dezembro"}
+
+// StatementLine reúne os valores de um ebook no mês, em centavos na moeda Currency.
+// Net é o que ficou com o criador: o líquido das vendas menos os reembolsos do mês.
+// Nas linhas com SplitKind o criador é coautor ou afiliado do ebook e Gross e Net são os repasses recebidos.
+type StatementLine struct {
+	EbookID       uint
+	EbookTitle    string
+	Currency      string
+	SplitKind     TransactionSplitKind
+	Sales         int64
+	Refunds       int64
+	Gross         int64
+	ProcessingFee int64
+	PlatformFee   int64
+	Splits        int64
+	RefundAmount  int64
+	Net           int64
+}
+
+func (l *StatementLine) add(other *StatementLine) {
+	l.Sales += other.Sales
+	l.Refunds += other.Refunds
+	l.Gross += other.Gross
+	l.ProcessingFee += other.ProcessingFee
+	l.PlatformFee += other.PlatformFee
+	l.Splits += other.Splits
+	l.RefundAmount += other.RefundAmount
+	l.Net += other.Net
+}
+
+func (l *StatementLine) format(cents int64) string {
+	return money.New(cents, l.Currency).String()
+}
+
+func (l *StatementLine) GetFormattedGross() string         { return l.format(l.Gross) }
+func (l *StatementLine) GetFormattedProcessingFee() string { return l.format(l.ProcessingFee) }
+func (l *StatementLine) GetFormattedPlatformFee() string   { return l.format(l.PlatformFee) }
+func (l *StatementLine) GetFormattedSplits() string        { return l.format(l.Splits) }
+func (l *StatementLine) GetFormattedRefunds() string       { return l.format(l.RefundAmount) }
+func (l *StatementLine) GetFormattedNet() string           { return l.format(l.Net) }
+
+// MonthlyStatement é o extrato mensal do criador, por ebook e com os totais de cada moeda
+type MonthlyStatement struct {
+	CreatorID uint
+	Month     time.Time
+	Lines     []*StatementLine
+	Totals    []*StatementLine
+}
+
+// NewMonthlyStatement junta as vendas e os reembolsos do mês na mesma linha de cada ebook e moeda.
+// Os repasses recebidos como coautor ou afiliado entram em sales, com linhas próprias.
+func NewMonthlyStatement(creatorID uint, month time.Time, sales, refunds []*StatementLine) *MonthlyStatement {
+	statement := &MonthlyStatement{CreatorID: creatorID, Month: StatementMonth(month)}
+
+	lines := make(map[string]*StatementLine)
+	totals := make(map[string]*StatementLine)
+	for _, row := range append(append([]*StatementLine{}, sales...), refunds...) {
+		key := fmt.Sprintf("%d-%s-%s", row.EbookID, row.Currency, row.SplitKind)
+		line, ok := lines[key]
+		if !ok {
+			line = &StatementLine{EbookID: row.EbookID, EbookTitle: row.EbookTitle, Currency: row.Currency, SplitKind: row.SplitKind}
+			lines[key] = line
+			statement.Lines = append(statement.Lines, line)
+		}
+		line.add(row)
+
+		total, ok := totals[row.Currency]
+		if !ok {
+			total = &StatementLine{EbookTitle: "Total", Currency: row.Currency}
+			totals[row.Currency] = total
+			statement.Totals = append(statement.Totals, total)
+		}
+		total.add(row)
+	}
+	return statement
+}
+
+// StatementMonth normaliza a data para o primeiro instante do mês
+func StatementMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// Period retorna o intervalo [início, fim) do mês do extrato
+func (s *MonthlyStatement) Period() (time.Time, time.Time) {
+	return s.Month, s.Month.AddDate(0, 1, 0)
+}
+
+func (s *MonthlyStatement) IsEmpty() bool {
+	return len(s.Lines) == 0
+}
+
+// GetMonthLabel retorna o mês por extenso, ex.: "março de 2025"
+func (s *MonthlyStatement) GetMonthLabel() string {
+	return fmt.Sprintf("%s de %d", monthNames[s.Month.Month()-1], s.Month.Year())
+}
+
+// GetMonthParam retorna o mês no formato do parâmetro ?month=2025-03
+func (s *MonthlyStatement) GetMonthParam() string {
+	return s.Month.Format("2006-01")
+}
+
+// MonthlyStatementDelivery registra o envio do extrato por email para não repetir o mesmo mês
+type MonthlyStatementDelivery struct {
+	gorm.Model
+	CreatorID uint      `json:"creator_id" gorm:"uniqueIndex:idx_statement_delivery"`
+	Month     time.Time `json:"month" gorm:"uniqueIndex:idx_statement_delivery"`
+}
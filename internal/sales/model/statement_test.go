@@ -0,0 +1,37 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMonthlyStatement_MergesRowsByEbookAndCurrency(t *testing.T) {
+	sales := []*salesmodel.StatementLine{
+		{EbookID: 1, EbookTitle: "Guia", Currency: money.BRL, Sales: 2, Gross: 20000, Net: 18000},
+		{EbookID: 1, EbookTitle: "Guia", Currency: money.USD, Sales: 1, Gross: 1500, Net: 1300},
+		{EbookID: 2, EbookTitle: "Manual", Currency: money.BRL, Sales: 1, Gross: 5000, Net: 4500},
+	}
+	refunds := []*salesmodel.StatementLine{
+		{EbookID: 1, EbookTitle: "Guia", Currency: money.BRL, Refunds: 1, RefundAmount: 10000, Net: -10000},
+	}
+
+	statement := salesmodel.NewMonthlyStatement(3, time.Date(2025, 3, 18, 10, 0, 0, 0, time.UTC), sales, refunds)
+
+	assert.Equal(t, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), statement.Month)
+	require.Len(t, statement.Lines, 3)
+	assert.Equal(t, int64(1), statement.Lines[0].Refunds)
+	assert.Equal(t, int64(8000), statement.Lines[0].Net)
+
+	require.Len(t, statement.Totals, 2)
+	assert.Equal(t, money.BRL, statement.Totals[0].Currency)
+	assert.Equal(t, int64(3), statement.Totals[0].Sales)
+	assert.Equal(t, int64(12500), statement.Totals[0].Net)
+	assert.Equal(t, int64(1300), statement.Totals[1].Net)
+	assert.Equal(t, "2025-03", statement.GetMonthParam())
+	assert.Equal(t, "março de 2025", statement.GetMonthLabel())
+}
@@ -1,12 +1,11 @@
 package model
 
 import (
-	"fmt"
-	"math"
 	"time"
 
 	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
 	"github.com/anglesson/simple-web-server/internal/config"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/anglesson/simple-web-server/pkg/utils"
 	"gorm.io/gorm"
 )
@@ -28,6 +27,26 @@ const (
 	TransactionStatusFailed    TransactionStatus = "failed"
 )
 
+// Formas de pagamento aceitas na venda manual, recebidas diretamente pelo criador
+const (
+	ManualPaymentPix          = "pix"
+	ManualPaymentCash         = "cash"
+	ManualPaymentBankTransfer = "bank_transfer"
+	ManualPaymentOther        = "other"
+)
+
+var ManualPaymentMethods = []string{ManualPaymentPix, ManualPaymentCash, ManualPaymentBankTransfer, ManualPaymentOther}
+
+// IsManualPaymentMethod indica se a forma de pagamento pode ser usada numa venda manual
+func IsManualPaymentMethod(method string) bool {
+	for _, m := range ManualPaymentMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
 // Transaction representa uma transação financeira com split de pagamento
 type Transaction struct {
 	gorm.Model
@@ -35,21 +54,36 @@ type Transaction struct {
 	PublicID              string `json:"public_id" gorm:"type:varchar(40);uniqueIndex"`
 	StripePaymentIntentID string `json:"stripe_payment_intent_id"`
 	StripeTransferID      string `json:"stripe_transfer_id"`
+	// PaymentMethod é o tipo informado pelo Stripe na cobrança (card, boleto, pix...)
+	// ou a forma de pagamento declarada pelo criador numa venda manual
+	PaymentMethod string `json:"payment_method" gorm:"type:varchar(30)"`
+	// OffPlatform marca a venda registrada manualmente pelo criador e paga fora do Stripe.
+	// Ela entra nos relatórios, mas não paga taxas nem conta no volume dos planos de taxa
+	OffPlatform bool `json:"off_platform" gorm:"default:false;index"`
 
-	TotalAmount         int64 `json:"total_amount"`
-	PlatformAmount      int64 `json:"platform_amount"`
-	CreatorAmount       int64 `json:"creator_amount"`
-	StripeProcessingFee int64 `json:"stripe_processing_fee"`
+	// Valores em centavos na moeda Currency
+	TotalAmount         int64  `json:"total_amount"`
+	PlatformAmount      int64  `json:"platform_amount"`
+	CreatorAmount       int64  `json:"creator_amount"`
+	StripeProcessingFee int64  `json:"stripe_processing_fee"`
+	Currency            string `json:"currency" gorm:"type:varchar(3);default:'BRL'"`
 
 	SplitType          SplitType `json:"split_type"`
 	PlatformPercentage float64   `json:"platform_percentage"`
 	PlatformFixedFee   int64     `json:"platform_fixed_fee"`
 
-	PurchaseID uint                `json:"purchase_id"`
-	Purchase   Purchase            `gorm:"foreignKey:PurchaseID"`
-	CreatorID  uint                `json:"creator_id"`
+	// Plano de taxas vigente no momento da venda (percentual e parcela fixa ficam copiados acima)
+	FeePlanID   *uint  `json:"fee_plan_id"`
+	FeePlanName string `json:"fee_plan_name"`
+
+	PurchaseID uint                 `json:"purchase_id"`
+	Purchase   Purchase             `gorm:"foreignKey:PurchaseID"`
+	CreatorID  uint                 `json:"creator_id"`
 	Creator    accountmodel.Creator `gorm:"foreignKey:CreatorID"`
 
+	// Repasses aos coautores do ebook, descontados de CreatorAmount
+	Splits []TransactionSplit `json:"splits" gorm:"foreignKey:TransactionID"`
+
 	Status       TransactionStatus `json:"status"`
 	ProcessedAt  *time.Time        `json:"processed_at"`
 	ErrorMessage string            `json:"error_message"`
@@ -63,17 +97,78 @@ func (t *Transaction) BeforeCreate(tx *gorm.DB) error {
 }
 
 // CalculateSplit calcula os valores de split com base na configuração
-func (t *Transaction) CalculateSplit(totalAmount int64) {
-	t.TotalAmount = totalAmount
+func (t *Transaction) CalculateSplit(total money.Money) {
+	t.TotalAmount = total.Amount
+	t.Currency = total.CurrencyOrDefault()
+
+	// A parcela fixa configurada em reais não vale para as outras moedas
+	if t.Currency != money.BRL {
+		t.PlatformFixedFee = config.Business.PlatformFixedFeeFor(t.Currency)
+	}
 
-	t.StripeProcessingFee = config.Business.GetStripeProcessingFee(totalAmount)
+	t.StripeProcessingFee = config.Business.GetStripeProcessingFee(total)
 
-	percentPart := int64(math.Round(float64(totalAmount) * t.PlatformPercentage))
+	percentPart := total.Mul(t.PlatformPercentage).Amount
 	fixedPart := t.PlatformFixedFee
 	t.PlatformAmount = percentPart + fixedPart
 
-	remainingAmount := totalAmount - t.StripeProcessingFee
+	remainingAmount := total.Amount - t.StripeProcessingFee
 	t.CreatorAmount = remainingAmount - t.PlatformAmount
+
+	// A comissão do afiliado sai do líquido do criador; os coautores dividem o que sobra.
+	// O arredondamento fica com o dono
+	net := money.New(t.CreatorAmount, t.Currency)
+	for i := range t.Splits {
+		if t.Splits[i].IsAffiliate() {
+			t.Splits[i].Amount = net.Mul(t.Splits[i].Share).Amount
+			t.Splits[i].Currency = t.Currency
+			t.CreatorAmount -= t.Splits[i].Amount
+		}
+	}
+
+	net = money.New(t.CreatorAmount, t.Currency)
+	for i := range t.Splits {
+		if !t.Splits[i].IsAffiliate() {
+			t.Splits[i].Amount = net.Mul(t.Splits[i].Share).Amount
+			t.Splits[i].Currency = t.Currency
+			t.CreatorAmount -= t.Splits[i].Amount
+		}
+	}
+}
+
+// AddSplit inclui o repasse de um coautor; os valores são calculados em CalculateSplit
+func (t *Transaction) AddSplit(creatorID uint, share float64) {
+	t.Splits = append(t.Splits, TransactionSplit{
+		Kind:      TransactionSplitKindCoAuthor,
+		CreatorID: creatorID,
+		Share:     share,
+		Status:    TransactionSplitStatusPending,
+	})
+}
+
+// AddAffiliateSplit inclui a comissão do afiliado que indicou a venda
+func (t *Transaction) AddAffiliateSplit(creatorID, affiliateID uint, commission float64) {
+	t.Splits = append(t.Splits, TransactionSplit{
+		Kind:        TransactionSplitKindAffiliate,
+		AffiliateID: &affiliateID,
+		CreatorID:   creatorID,
+		Share:       commission,
+		Status:      TransactionSplitStatusPending,
+	})
+}
+
+// ChargesDifferently indica se o total (valor ou moeda) difere do que foi calculado na transação
+func (t *Transaction) ChargesDifferently(total money.Money) bool {
+	return t.TotalAmount != total.Amount || t.Currency != total.CurrencyOrDefault()
+}
+
+// SplitsAmount soma os repasses aos coautores em centavos
+func (t *Transaction) SplitsAmount() int64 {
+	var total int64
+	for _, split := range t.Splits {
+		total += split.Amount
+	}
+	return total
 }
 
 // NewTransaction cria uma nova transação com split
@@ -85,29 +180,97 @@ func NewTransaction(purchaseID, creatorID uint, splitType SplitType) *Transactio
 		Status:     TransactionStatusPending,
 	}
 
-	t.PlatformPercentage = config.Business.PlatformFeePercentage
-	t.PlatformFixedFee = config.Business.PlatformFixedFeeCents
+	t.ApplyFeePlan(DefaultFeePlan())
 
 	return t
 }
 
+// NewManualTransaction registra uma venda paga fora da plataforma. O valor é todo do criador:
+// não há taxa de processamento, taxa da plataforma nem repasse a coautores e afiliados
+func NewManualTransaction(purchaseID, creatorID uint, total money.Money, paymentMethod string, paidAt time.Time) *Transaction {
+	return &Transaction{
+		PurchaseID:    purchaseID,
+		CreatorID:     creatorID,
+		SplitType:     SplitTypeFixedAmount,
+		PaymentMethod: paymentMethod,
+		OffPlatform:   true,
+		TotalAmount:   total.Amount,
+		CreatorAmount: total.Amount,
+		Currency:      total.CurrencyOrDefault(),
+		Status:        TransactionStatusCompleted,
+		ProcessedAt:   &paidAt,
+	}
+}
+
+// FeePlan reconstrói o plano de taxas registrado na transação
+func (t *Transaction) FeePlan() *FeePlan {
+	plan := &FeePlan{
+		Name:          t.FeePlanName,
+		Percentage:    t.PlatformPercentage,
+		FixedFeeCents: t.PlatformFixedFee,
+		Active:        true,
+	}
+	if t.FeePlanID != nil {
+		plan.ID = *t.FeePlanID
+	}
+	return plan
+}
+
+// ApplyFeePlan copia para a transação as taxas do plano do criador, preservando o plano usado na venda
+func (t *Transaction) ApplyFeePlan(plan *FeePlan) {
+	t.PlatformPercentage = plan.Percentage
+	t.PlatformFixedFee = plan.FixedFeeCents
+	t.FeePlanName = plan.Name
+	t.FeePlanID = nil
+	if plan.ID != 0 {
+		id := plan.ID
+		t.FeePlanID = &id
+	}
+}
+
+// GetTotal retorna o valor total da transação como Money
+func (t *Transaction) GetTotal() money.Money {
+	return t.amount(t.TotalAmount)
+}
+
+func (t *Transaction) amount(cents int64) money.Money {
+	return money.New(cents, t.Currency)
+}
+
 func (t *Transaction) GetFormattedTotalAmount() string {
-	return formatCentsToBRL(t.TotalAmount)
+	return t.GetTotal().String()
 }
 
 func (t *Transaction) GetFormattedPlatformAmount() string {
-	return formatCentsToBRL(t.PlatformAmount + t.StripeProcessingFee)
+	return t.amount(t.PlatformAmount + t.StripeProcessingFee).String()
 }
 
 func (t *Transaction) GetFormattedCreatorAmount() string {
-	return formatCentsToBRL(t.CreatorAmount)
+	return t.amount(t.CreatorAmount).String()
 }
 
-func (t *Transaction) GetFormattedProcessingFee() string {
-	return formatCentsToBRL(t.StripeProcessingFee)
+// GetPaymentMethodLabel retorna a forma de pagamento como aparece no recibo
+func (t *Transaction) GetPaymentMethodLabel() string {
+	switch t.PaymentMethod {
+	case "card":
+		return "Cartão de crédito"
+	case "boleto":
+		return "Boleto bancário"
+	case "pix":
+		return "Pix"
+	case ManualPaymentCash:
+		return "Dinheiro"
+	case ManualPaymentBankTransfer:
+		return "Transferência bancária"
+	case ManualPaymentOther:
+		return "Outra"
+	case "":
+		return "Não informada"
+	default:
+		return t.PaymentMethod
+	}
 }
 
-func formatCentsToBRL(cents int64) string {
-	reais := float64(cents) / 100.0
-	return fmt.Sprintf("R$ %.2f", reais)
+func (t *Transaction) GetFormattedProcessingFee() string {
+	return t.amount(t.StripeProcessingFee).String()
 }
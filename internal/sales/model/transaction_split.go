@@ -0,0 +1,149 @@
+package model
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/anglesson/simple-web-server/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// TransactionSplitStatus representa a situação do repasse ao coautor
+type TransactionSplitStatus string
+
+const (
+	TransactionSplitStatusPending     TransactionSplitStatus = "pending"
+	TransactionSplitStatusTransferred TransactionSplitStatus = "transferred"
+	TransactionSplitStatusFailed      TransactionSplitStatus = "failed"
+	TransactionSplitStatusCanceled    TransactionSplitStatus = "canceled"
+)
+
+// TransactionSplitKind diferencia o repasse a um coautor da comissão de um afiliado
+type TransactionSplitKind string
+
+const (
+	TransactionSplitKindCoAuthor  TransactionSplitKind = "coauthor"
+	TransactionSplitKindAffiliate TransactionSplitKind = "affiliate"
+)
+
+// Label retorna o papel de quem recebe o repasse, como aparece no extrato
+func (k TransactionSplitKind) Label() string {
+	if k == TransactionSplitKindAffiliate {
+		return "comissão de afiliado"
+	}
+	return "coautoria"
+}
+
+// TransactionSplit é a parte de uma venda repassada a um coautor ou afiliado por transferência no Stripe.
+// Share é a fração do valor líquido do dono do ebook; Amount fica em centavos na moeda da transação.
+// AvailableAt, quando preenchido, segura a transferência até o fim da carência contra reembolsos.
+type TransactionSplit struct {
+	gorm.Model
+	PublicID         string                 `json:"public_id" gorm:"type:varchar(40);uniqueIndex"`
+	TransactionID    uint                   `json:"transaction_id" gorm:"index"`
+	Transaction      *Transaction           `json:"-" gorm:"foreignKey:TransactionID"`
+	Kind             TransactionSplitKind   `json:"kind" gorm:"type:varchar(20);default:'coauthor';index"`
+	AffiliateID      *uint                  `json:"affiliate_id" gorm:"index"`
+	CreatorID        uint                   `json:"creator_id" gorm:"index"`
+	Creator          accountmodel.Creator   `json:"-" gorm:"foreignKey:CreatorID"`
+	Share            float64                `json:"share"`
+	Amount           int64                  `json:"amount"`
+	Currency         string                 `json:"currency" gorm:"type:varchar(3);default:'BRL'"`
+	Status           TransactionSplitStatus `json:"status" gorm:"type:varchar(20);default:'pending'"`
+	StripeTransferID string                 `json:"stripe_transfer_id"`
+	AvailableAt      *time.Time             `json:"available_at" gorm:"index"`
+	TransferredAt    *time.Time             `json:"transferred_at"`
+	ErrorMessage     string                 `json:"error_message"`
+	// Attempts conta as transferências recusadas; NextAttemptAt agenda a próxima tentativa do job
+	Attempts      int        `json:"attempts" gorm:"default:0"`
+	NextAttemptAt *time.Time `json:"next_attempt_at" gorm:"index"`
+}
+
+// Espera entre as tentativas de um repasse recusado: dobra a cada falha, de 1 hora até 1 dia.
+// A recusa mais comum é o saldo da cobrança ainda não disponível na conta da plataforma.
+const (
+	splitRetryBaseDelay = time.Hour
+	splitRetryMaxDelay  = 24 * time.Hour
+)
+
+func (s *TransactionSplit) BeforeCreate(tx *gorm.DB) error {
+	if s.PublicID == "" {
+		s.PublicID = utils.GeneratePublicID("tsp_")
+	}
+	return nil
+}
+
+func (s *TransactionSplit) GetAmount() money.Money {
+	return money.New(s.Amount, s.Currency)
+}
+
+func (s *TransactionSplit) GetFormattedAmount() string {
+	return s.GetAmount().String()
+}
+
+// GetSharePercent retorna a participação formatada (ex.: "30%")
+func (s *TransactionSplit) GetSharePercent() string {
+	percent := strconv.FormatFloat(math.Round(s.Share*10000)/100, 'f', -1, 64)
+	return strings.Replace(percent, ".", ",", 1) + "%"
+}
+
+func (s *TransactionSplit) IsTransferred() bool {
+	return s.Status == TransactionSplitStatusTransferred
+}
+
+func (s *TransactionSplit) IsAffiliate() bool {
+	return s.Kind == TransactionSplitKindAffiliate
+}
+
+// IsOnHold indica que a transferência aguarda o fim da carência
+func (s *TransactionSplit) IsOnHold(now time.Time) bool {
+	return s.AvailableAt != nil && now.Before(*s.AvailableAt)
+}
+
+func (s *TransactionSplit) MarkTransferred(transferID string, at time.Time) {
+	s.Status = TransactionSplitStatusTransferred
+	s.StripeTransferID = transferID
+	s.TransferredAt = &at
+	s.ErrorMessage = ""
+	s.NextAttemptAt = nil
+}
+
+// MarkFailed registra a recusa e agenda a próxima tentativa. Depois de maxAttempts recusas o
+// repasse fica falho sem novo agendamento, para ser tratado pelo operador.
+func (s *TransactionSplit) MarkFailed(message string, now time.Time, maxAttempts int) {
+	s.Status = TransactionSplitStatusFailed
+	s.ErrorMessage = message
+	s.Attempts++
+	s.NextAttemptAt = nil
+	if s.Attempts < maxAttempts {
+		next := now.Add(SplitRetryDelay(s.Attempts))
+		s.NextAttemptAt = &next
+	}
+}
+
+// SplitRetryDelay é a espera até a próxima tentativa depois da recusa de número attempts
+func SplitRetryDelay(attempts int) time.Duration {
+	delay := splitRetryBaseDelay
+	for i := 1; i < attempts && delay < splitRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > splitRetryMaxDelay {
+		delay = splitRetryMaxDelay
+	}
+	return delay
+}
+
+// MarkCanceled encerra um repasse que não será mais transferido (ex.: venda reembolsada na carência)
+func (s *TransactionSplit) MarkCanceled(message string) {
+	s.Status = TransactionSplitStatusCanceled
+	s.ErrorMessage = message
+	s.NextAttemptAt = nil
+}
+
+func (s *TransactionSplit) GetCreatedAtBR() string {
+	return s.CreatedAt.Format("02/01/2006 15:04")
+}
@@ -1,9 +1,13 @@
 package model_test
 
 import (
+	"math"
 	"testing"
+	"time"
 
+	"github.com/anglesson/simple-web-server/internal/config"
 	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -54,7 +58,7 @@ func TestTransactionCalculateSplit(t *testing.T) {
 				PlatformFixedFee:   tt.platformFixedFee,
 			}
 
-			transaction.CalculateSplit(tt.totalAmount)
+			transaction.CalculateSplit(money.FromCents(tt.totalAmount))
 
 			// Verificar valor da plataforma
 			assert.Equal(t, tt.expectedPlatform, transaction.PlatformAmount)
@@ -68,3 +72,104 @@ func TestTransactionCalculateSplit(t *testing.T) {
 		})
 	}
 }
+
+func TestTransactionCalculateSplit_KeepsCurrency(t *testing.T) {
+	transaction := &salesmodel.Transaction{PlatformPercentage: 0.05}
+
+	transaction.CalculateSplit(money.FromFloat(19.99))
+
+	assert.Equal(t, int64(1999), transaction.TotalAmount)
+	assert.Equal(t, money.BRL, transaction.Currency)
+	assert.Equal(t, "R$ 19,99", transaction.GetFormattedTotalAmount())
+}
+
+func TestTransactionCalculateSplit_ForeignCurrencyUsesCurrencyFees(t *testing.T) {
+	transaction := &salesmodel.Transaction{PlatformPercentage: 0.05, PlatformFixedFee: 99}
+
+	transaction.CalculateSplit(money.New(1000, money.EUR))
+
+	assert.Equal(t, money.EUR, transaction.Currency)
+	assert.Equal(t, int64(20), transaction.PlatformFixedFee)
+	assert.Equal(t, int64(50+20), transaction.PlatformAmount)
+	assert.Equal(t, config.Business.StripeFixedFeeFor(money.EUR)+int64(math.Round(1000*config.Business.StripeProcessingPercentage)), transaction.StripeProcessingFee)
+	assert.Equal(t, int64(1000), transaction.PlatformAmount+transaction.CreatorAmount+transaction.StripeProcessingFee)
+	assert.Equal(t, "€ 10,00", transaction.GetFormattedTotalAmount())
+}
+
+func TestTransactionCalculateSplit_CoAuthorsShareCreatorNet(t *testing.T) {
+	transaction := &salesmodel.Transaction{PlatformPercentage: 0.05}
+	transaction.AddSplit(7, 0.3)
+	transaction.AddSplit(8, 0.125)
+
+	transaction.CalculateSplit(money.FromCents(10000))
+
+	net := 10000 - transaction.StripeProcessingFee - transaction.PlatformAmount
+	assert.Equal(t, int64(math.Round(float64(net)*0.3)), transaction.Splits[0].Amount)
+	assert.Equal(t, int64(math.Round(float64(net)*0.125)), transaction.Splits[1].Amount)
+	assert.Equal(t, money.BRL, transaction.Splits[0].Currency)
+	assert.Equal(t, net, transaction.CreatorAmount+transaction.SplitsAmount())
+	assert.Equal(t, "30%", transaction.Splits[0].GetSharePercent())
+	assert.Equal(t, "12,5%", transaction.Splits[1].GetSharePercent())
+}
+
+func TestTransactionCalculateSplit_AffiliateBeforeCoAuthors(t *testing.T) {
+	transaction := &salesmodel.Transaction{PlatformPercentage: 0.05}
+	transaction.AddSplit(7, 0.5)
+	transaction.AddAffiliateSplit(8, 3, 0.2)
+
+	transaction.CalculateSplit(money.FromCents(10000))
+
+	net := 10000 - transaction.StripeProcessingFee - transaction.PlatformAmount
+	commission := int64(math.Round(float64(net) * 0.2))
+	coAuthor := int64(math.Round(float64(net-commission) * 0.5))
+
+	assert.True(t, transaction.Splits[1].IsAffiliate())
+	assert.Equal(t, uint(3), *transaction.Splits[1].AffiliateID)
+	assert.Equal(t, commission, transaction.Splits[1].Amount)
+	assert.Equal(t, coAuthor, transaction.Splits[0].Amount)
+	assert.Equal(t, net, transaction.CreatorAmount+transaction.SplitsAmount())
+}
+
+func TestAffiliateStats_FormatsTotalsPerCurrency(t *testing.T) {
+	stats := salesmodel.NewAffiliateStats()
+	assert.Equal(t, "R$ 0,00", stats.GetFormattedPaid())
+
+	stats.Paid[money.BRL] = 1500
+	stats.Paid[money.EUR] = 200
+	assert.Equal(t, "R$ 15,00 + € 2,00", stats.GetFormattedPaid())
+}
+
+func TestTransaction_GetPaymentMethodLabel(t *testing.T) {
+	assert.Equal(t, "Cartão de crédito", (&salesmodel.Transaction{PaymentMethod: "card"}).GetPaymentMethodLabel())
+	assert.Equal(t, "Pix", (&salesmodel.Transaction{PaymentMethod: "pix"}).GetPaymentMethodLabel())
+	assert.Equal(t, "Não informada", (&salesmodel.Transaction{}).GetPaymentMethodLabel())
+	assert.Equal(t, "link", (&salesmodel.Transaction{PaymentMethod: "link"}).GetPaymentMethodLabel())
+}
+
+func TestTransactionSplit_MarkFailedSchedulesRetryWithBackoff(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	split := &salesmodel.TransactionSplit{Status: salesmodel.TransactionSplitStatusPending}
+
+	expected := []time.Duration{time.Hour, 2 * time.Hour, 4 * time.Hour, 8 * time.Hour, 16 * time.Hour, 24 * time.Hour, 24 * time.Hour}
+	for i, delay := range expected {
+		split.MarkFailed("saldo insuficiente", now, 10)
+		assert.Equal(t, i+1, split.Attempts)
+		if assert.NotNil(t, split.NextAttemptAt) {
+			assert.Equal(t, now.Add(delay), *split.NextAttemptAt, "tentativa %d", i+1)
+		}
+	}
+
+	split.MarkTransferred("tr_1", now)
+	assert.Nil(t, split.NextAttemptAt)
+}
+
+func TestTransactionSplit_MarkFailedStopsAtMaxAttempts(t *testing.T) {
+	now := time.Now()
+	split := &salesmodel.TransactionSplit{Attempts: 2}
+
+	split.MarkFailed("saldo insuficiente", now, 3)
+
+	assert.Equal(t, salesmodel.TransactionSplitStatusFailed, split.Status)
+	assert.Equal(t, 3, split.Attempts)
+	assert.Nil(t, split.NextAttemptAt, "sem novas tentativas depois do limite")
+}
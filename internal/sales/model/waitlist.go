@@ -0,0 +1,76 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/anglesson/simple-web-server/pkg/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WaitlistEntry registra um visitante interessado em um ebook indisponível ou que ainda não abriu as vendas.
+// O Token identifica o link rastreado do email de aviso para atribuir a venda à lista de espera.
+type WaitlistEntry struct {
+	gorm.Model
+	PublicID    string     `json:"public_id" gorm:"type:varchar(40);uniqueIndex"`
+	EbookID     uint       `json:"ebook_id" gorm:"uniqueIndex:idx_waitlist_ebook_email"`
+	CreatorID   uint       `json:"creator_id" gorm:"index"`
+	Name        string     `json:"name"`
+	Email       string     `json:"email" gorm:"uniqueIndex:idx_waitlist_ebook_email"`
+	Token       string     `json:"-" gorm:"type:varchar(64);uniqueIndex"`
+	NotifiedAt  *time.Time `json:"notified_at"`
+	ClickedAt   *time.Time `json:"clicked_at"`
+	PurchaseID  *uint      `json:"purchase_id" gorm:"index"`
+	ConvertedAt *time.Time `json:"converted_at"`
+}
+
+func (w *WaitlistEntry) BeforeCreate(tx *gorm.DB) error {
+	if w.PublicID == "" {
+		w.PublicID = utils.GeneratePublicID("wtl_")
+	}
+	if w.Token == "" {
+		w.Token = strings.ReplaceAll(uuid.NewString(), "-", "")
+	}
+	return nil
+}
+
+func NewWaitlistEntry(ebookID, creatorID uint, name, email string) *WaitlistEntry {
+	return &WaitlistEntry{
+		EbookID:   ebookID,
+		CreatorID: creatorID,
+		Name:      strings.TrimSpace(name),
+		Email:     strings.ToLower(strings.TrimSpace(email)),
+	}
+}
+
+func (w *WaitlistEntry) IsNotified() bool {
+	return w.NotifiedAt != nil
+}
+
+func (w *WaitlistEntry) IsConverted() bool {
+	return w.ConvertedAt != nil
+}
+
+func (w *WaitlistEntry) MarkNotified(notifiedAt time.Time) {
+	w.NotifiedAt = &notifiedAt
+}
+
+// MarkClicked registra o primeiro clique no link rastreado
+func (w *WaitlistEntry) MarkClicked(clickedAt time.Time) {
+	if w.ClickedAt == nil {
+		w.ClickedAt = &clickedAt
+	}
+}
+
+func (w *WaitlistEntry) GetCreatedAtBR() string {
+	return w.CreatedAt.Format("02/01/2006 15:04")
+}
+
+// WaitlistStats resume a lista de espera de um ebook e as conversões dos avisos enviados
+type WaitlistStats struct {
+	Total     int64
+	Notified  int64
+	Clicked   int64
+	Converted int64
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"gorm.io/gorm"
+)
+
+type AffiliateRepository interface {
+	Create(affiliate *salesmodel.Affiliate) error
+	Update(affiliate *salesmodel.Affiliate) error
+	FindByPublicID(publicID string) (*salesmodel.Affiliate, error)
+	FindByCode(code string) (*salesmodel.Affiliate, error)
+	FindByEbookAndCreator(ebookID, creatorID uint) (*salesmodel.Affiliate, error)
+	FindByEbookID(ebookID uint) ([]*salesmodel.Affiliate, error)
+	FindByCreatorID(creatorID uint) ([]*salesmodel.Affiliate, error)
+	IncrementClicks(id uint) error
+	GetStats(affiliateIDs []uint) (map[uint]*salesmodel.AffiliateStats, error)
+}
+
+type affiliateRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewAffiliateRepository(db *gorm.DB) AffiliateRepository {
+	return &affiliateRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *affiliateRepositoryImpl) Create(affiliate *salesmodel.Affiliate) error {
+	return r.db.Omit("Ebook", "Creator").Create(affiliate).Error
+}
+
+func (r *affiliateRepositoryImpl) Update(affiliate *salesmodel.Affiliate) error {
+	return r.db.Omit("Ebook", "Creator").Save(affiliate).Error
+}
+
+func (r *affiliateRepositoryImpl) FindByPublicID(publicID string) (*salesmodel.Affiliate, error) {
+	var affiliate salesmodel.Affiliate
+	err := r.db.Preload("Ebook").Preload("Creator").Where("public_id = ?", publicID).First(&affiliate).Error
+	if err != nil {
+		return nil, err
+	}
+	return &affiliate, nil
+}
+
+func (r *affiliateRepositoryImpl) FindByCode(code string) (*salesmodel.Affiliate, error) {
+	var affiliate salesmodel.Affiliate
+	err := r.db.Preload("Ebook").Preload("Creator").Where("code = ?", code).First(&affiliate).Error
+	if err != nil {
+		return nil, err
+	}
+	return &affiliate, nil
+}
+
+// FindByEbookAndCreator retorna nil, nil quando o criador ainda não é afiliado do ebook
+func (r *affiliateRepositoryImpl) FindByEbookAndCreator(ebookID, creatorID uint) (*salesmodel.Affiliate, error) {
+	var affiliate salesmodel.Affiliate
+	err := r.db.Where("ebook_id = ? AND creator_id = ?", ebookID, creatorID).First(&affiliate).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &affiliate, nil
+}
+
+func (r *affiliateRepositoryImpl) FindByEbookID(ebookID uint) ([]*salesmodel.Affiliate, error) {
+	var affiliates []*salesmodel.Affiliate
+	err := r.db.Preload("Creator").Where("ebook_id = ?", ebookID).Order("created_at DESC").Find(&affiliates).Error
+	return affiliates, err
+}
+
+func (r *affiliateRepositoryImpl) FindByCreatorID(creatorID uint) ([]*salesmodel.Affiliate, error) {
+	var affiliates []*salesmodel.Affiliate
+	err := r.db.Preload("Ebook").Where("creator_id = ?", creatorID).Order("created_at DESC").Find(&affiliates).Error
+	return affiliates, err
+}
+
+// IncrementClicks soma o clique de forma atômica no banco
+func (r *affiliateRepositoryImpl) IncrementClicks(id uint) error {
+	return r.db.Model(&salesmodel.Affiliate{}).Where("id = ?", id).
+		UpdateColumn("clicks", gorm.Expr("clicks + 1")).Error
+}
+
+// GetStats soma as vendas concluídas e as comissões de cada afiliado, por moeda
+func (r *affiliateRepositoryImpl) GetStats(affiliateIDs []uint) (map[uint]*salesmodel.AffiliateStats, error) {
+	stats := make(map[uint]*salesmodel.AffiliateStats, len(affiliateIDs))
+	for _, id := range affiliateIDs {
+		stats[id] = salesmodel.NewAffiliateStats()
+	}
+	if len(affiliateIDs) == 0 {
+		return stats, nil
+	}
+
+	var rows []struct {
+		AffiliateID uint
+		Currency    string
+		Status      salesmodel.TransactionSplitStatus
+		Sales       int64
+		Amount      int64
+	}
+	err := r.db.Model(&salesmodel.TransactionSplit{}).
+		Select("transaction_splits.affiliate_id, transaction_splits.currency, transaction_splits.status, COUNT(*) AS sales, COALESCE(SUM(transaction_splits.amount), 0) AS amount").
+		Joins("JOIN transactions ON transactions.id = transaction_splits.transaction_id").
+		Where("transaction_splits.affiliate_id IN ? AND transactions.status = ?", affiliateIDs, salesmodel.TransactionStatusCompleted).
+		Group("transaction_splits.affiliate_id, transaction_splits.currency, transaction_splits.status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		stat, ok := stats[row.AffiliateID]
+		if !ok || row.Status == salesmodel.TransactionSplitStatusCanceled {
+			continue
+		}
+		stat.Sales += row.Sales
+		if row.Status == salesmodel.TransactionSplitStatusTransferred {
+			stat.Paid[row.Currency] += row.Amount
+		} else {
+			stat.Pending[row.Currency] += row.Amount
+		}
+	}
+	return stats, nil
+}
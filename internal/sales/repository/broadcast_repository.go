@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BroadcastRepository interface {
+	Create(broadcast *salesmodel.Broadcast, deliveries []*salesmodel.BroadcastDelivery) error
+	Save(broadcast *salesmodel.Broadcast) error
+	FindByID(id uint) (*salesmodel.Broadcast, error)
+	FindByPublicID(publicID string) (*salesmodel.Broadcast, error)
+	FindRecentByCreatorID(creatorID uint, limit int) ([]*salesmodel.Broadcast, error)
+	FindQueued() ([]*salesmodel.Broadcast, error)
+	Claim(broadcast *salesmodel.Broadcast) (bool, error)
+	RequeueStale(before time.Time) (int64, error)
+	FindPendingDeliveries(broadcastID uint, limit int) ([]*salesmodel.BroadcastDelivery, error)
+	FindDeliveries(broadcastID uint, limit int) ([]*salesmodel.BroadcastDelivery, error)
+	FindDeliveryByToken(token string) (*salesmodel.BroadcastDelivery, error)
+	SaveDelivery(delivery *salesmodel.BroadcastDelivery) error
+	IsUnsubscribed(creatorID, clientID uint) (bool, error)
+	Unsubscribe(unsubscribe *salesmodel.EmailUnsubscribe) error
+	CountUnsubscribed(broadcastID uint) (int64, error)
+}
+
+type broadcastRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewBroadcastRepository(db *gorm.DB) BroadcastRepository {
+	return &broadcastRepositoryImpl{
+		db: db,
+	}
+}
+
+// Create grava o broadcast e os destinatários juntos, para que o job nunca encontre um envio sem destinatários
+func (r *broadcastRepositoryImpl) Create(broadcast *salesmodel.Broadcast, deliveries []*salesmodel.BroadcastDelivery) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Creator").Create(broadcast).Error; err != nil {
+			return err
+		}
+		for _, delivery := range deliveries {
+			delivery.BroadcastID = broadcast.ID
+		}
+		return tx.CreateInBatches(deliveries, 500).Error
+	})
+}
+
+func (r *broadcastRepositoryImpl) Save(broadcast *salesmodel.Broadcast) error {
+	return r.db.Omit("Creator").Save(broadcast).Error
+}
+
+func (r *broadcastRepositoryImpl) FindByID(id uint) (*salesmodel.Broadcast, error) {
+	var broadcast salesmodel.Broadcast
+	err := r.db.Preload("Creator").First(&broadcast, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &broadcast, nil
+}
+
+func (r *broadcastRepositoryImpl) FindByPublicID(publicID string) (*salesmodel.Broadcast, error) {
+	var broadcast salesmodel.Broadcast
+	err := r.db.Where("public_id = ?", publicID).First(&broadcast).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &broadcast, nil
+}
+
+// FindRecentByCreatorID lista os últimos broadcasts sem carregar o corpo dos emails
+func (r *broadcastRepositoryImpl) FindRecentByCreatorID(creatorID uint, limit int) ([]*salesmodel.Broadcast, error) {
+	var broadcasts []*salesmodel.Broadcast
+	err := r.db.Omit("body").
+		Where("creator_id = ?", creatorID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&broadcasts).Error
+	return broadcasts, err
+}
+
+func (r *broadcastRepositoryImpl) FindQueued() ([]*salesmodel.Broadcast, error) {
+	var broadcasts []*salesmodel.Broadcast
+	err := r.db.Preload("Creator").
+		Where("status = ?", salesmodel.BroadcastStatusQueued).
+		Order("created_at").
+		Find(&broadcasts).Error
+	return broadcasts, err
+}
+
+// Claim marca o broadcast como em envio somente se ele ainda estiver na fila,
+// para que duas instâncias do job não enviem o mesmo email
+func (r *broadcastRepositoryImpl) Claim(broadcast *salesmodel.Broadcast) (bool, error) {
+	result := r.db.Model(&salesmodel.Broadcast{}).
+		Where("id = ? AND status = ?", broadcast.ID, salesmodel.BroadcastStatusQueued).
+		Updates(map[string]interface{}{
+			"status":     salesmodel.BroadcastStatusSending,
+			"started_at": broadcast.StartedAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	broadcast.Status = salesmodel.BroadcastStatusSending
+	return true, nil
+}
+
+// RequeueStale devolve à fila os broadcasts em envio sem progresso desde before
+// (ex.: o processo reiniciou no meio do envio). Os destinatários já atendidos não recebem de novo.
+func (r *broadcastRepositoryImpl) RequeueStale(before time.Time) (int64, error) {
+	result := r.db.Model(&salesmodel.Broadcast{}).
+		Where("status = ? AND updated_at < ?", salesmodel.BroadcastStatusSending, before).
+		Update("status", salesmodel.BroadcastStatusQueued)
+	return result.RowsAffected, result.Error
+}
+
+func (r *broadcastRepositoryImpl) FindPendingDeliveries(broadcastID uint, limit int) ([]*salesmodel.BroadcastDelivery, error) {
+	var deliveries []*salesmodel.BroadcastDelivery
+	err := r.db.Where("broadcast_id = ? AND status = ?", broadcastID, salesmodel.BroadcastDeliveryPending).
+		Order("id").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// FindDeliveries lista os destinatários com as falhas primeiro
+func (r *broadcastRepositoryImpl) FindDeliveries(broadcastID uint, limit int) ([]*salesmodel.BroadcastDelivery, error) {
+	var deliveries []*salesmodel.BroadcastDelivery
+	err := r.db.Where("broadcast_id = ?", broadcastID).
+		Order("CASE WHEN status = 'failed' THEN 0 ELSE 1 END, name").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *broadcastRepositoryImpl) FindDeliveryByToken(token string) (*salesmodel.BroadcastDelivery, error) {
+	var delivery salesmodel.BroadcastDelivery
+	err := r.db.Where("token = ?", token).First(&delivery).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *broadcastRepositoryImpl) SaveDelivery(delivery *salesmodel.BroadcastDelivery) error {
+	return r.db.Save(delivery).Error
+}
+
+func (r *broadcastRepositoryImpl) IsUnsubscribed(creatorID, clientID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&salesmodel.EmailUnsubscribe{}).
+		Where("creator_id = ? AND client_id = ?", creatorID, clientID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Unsubscribe registra o descadastro; repetir o pedido mantém o registro original
+func (r *broadcastRepositoryImpl) Unsubscribe(unsubscribe *salesmodel.EmailUnsubscribe) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(unsubscribe).Error
+}
+
+func (r *broadcastRepositoryImpl) CountUnsubscribed(broadcastID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&salesmodel.EmailUnsubscribe{}).
+		Where("broadcast_id = ?", broadcastID).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"gorm.io/gorm"
+)
+
+type CheckoutAttemptRepository interface {
+	Create(attempt *salesmodel.CheckoutAttempt) error
+	Update(attempt *salesmodel.CheckoutAttempt) error
+	FindByPublicID(publicID string) (*salesmodel.CheckoutAttempt, error)
+	FindAwaitingReviewByPurchaseID(purchaseID uint) (*salesmodel.CheckoutAttempt, error)
+	CountSince(field, value string, stages []salesmodel.CheckoutAttemptStage, since time.Time) (int64, error)
+	CountOtherBuyersSince(field, value, email string, since time.Time) (int64, error)
+	ListSuspiciousByCreator(creatorID uint, onlyPending bool, limit int) ([]*salesmodel.CheckoutAttempt, error)
+	CountAwaitingReviewByCreator(creatorID uint) (int64, error)
+}
+
+type checkoutAttemptRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewCheckoutAttemptRepository(db *gorm.DB) CheckoutAttemptRepository {
+	return &checkoutAttemptRepositoryImpl{
+		db: db,
+	}
+}
+
+// attemptIdentityFields são as colunas aceitas nas consultas de velocidade
+var attemptIdentityFields = map[string]bool{
+	"cpf":       true,
+	"email":     true,
+	"ip":        true,
+	"device_id": true,
+}
+
+// attemptIdentityCondition compara o CPF, que fica cifrado, pelo índice cego; a comparação em
+// texto puro só encontra tentativas gravadas antes da criptografia
+func attemptIdentityCondition(field, value string) (string, []interface{}) {
+	if field == "cpf" {
+		return "cpf_index = ? OR cpf = ?", []interface{}{salesmodel.CPFBlindIndex(value), value}
+	}
+	return field + " = ?", []interface{}{value}
+}
+
+func (r *checkoutAttemptRepositoryImpl) Create(attempt *salesmodel.CheckoutAttempt) error {
+	return r.db.Create(attempt).Error
+}
+
+func (r *checkoutAttemptRepositoryImpl) Update(attempt *salesmodel.CheckoutAttempt) error {
+	return r.db.Save(attempt).Error
+}
+
+func (r *checkoutAttemptRepositoryImpl) FindByPublicID(publicID string) (*salesmodel.CheckoutAttempt, error) {
+	var attempt salesmodel.CheckoutAttempt
+	err := r.db.Preload("Ebook").Preload("Purchase").Where("public_id = ?", publicID).First(&attempt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// FindAwaitingReviewByPurchaseID retorna a tentativa que retém a entrega da compra, ou nil
+func (r *checkoutAttemptRepositoryImpl) FindAwaitingReviewByPurchaseID(purchaseID uint) (*salesmodel.CheckoutAttempt, error) {
+	var attempt salesmodel.CheckoutAttempt
+	err := r.db.Where("purchase_id = ? AND review_status = ?", purchaseID, salesmodel.ReviewStatusPending).
+		Order("created_at DESC").
+		First(&attempt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// CountSince conta as tentativas com o mesmo CPF, email, IP ou dispositivo desde a data informada
+func (r *checkoutAttemptRepositoryImpl) CountSince(field, value string, stages []salesmodel.CheckoutAttemptStage, since time.Time) (int64, error) {
+	if !attemptIdentityFields[field] {
+		return 0, errors.New("campo de tentativa inválido")
+	}
+
+	var total int64
+	condition, args := attemptIdentityCondition(field, value)
+	err := r.db.Model(&salesmodel.CheckoutAttempt{}).
+		Where(condition, args...).
+		Where("stage IN ? AND created_at >= ?", stages, since).
+		Count(&total).Error
+	return total, err
+}
+
+// CountOtherBuyersSince conta os emails distintos, além do informado, que usaram o mesmo IP ou dispositivo
+func (r *checkoutAttemptRepositoryImpl) CountOtherBuyersSince(field, value, email string, since time.Time) (int64, error) {
+	if !attemptIdentityFields[field] {
+		return 0, errors.New("campo de tentativa inválido")
+	}
+
+	var total int64
+	condition, args := attemptIdentityCondition(field, value)
+	err := r.db.Model(&salesmodel.CheckoutAttempt{}).
+		Where(condition, args...).
+		Where("email <> '' AND email <> ? AND created_at >= ?", email, since).
+		Distinct("email").
+		Count(&total).Error
+	return total, err
+}
+
+// ListSuspiciousByCreator lista as tentativas bloqueadas ou enviadas para revisão nas vendas do criador
+func (r *checkoutAttemptRepositoryImpl) ListSuspiciousByCreator(creatorID uint, onlyPending bool, limit int) ([]*salesmodel.CheckoutAttempt, error) {
+	query := r.db.Preload("Ebook").Preload("Purchase").
+		Where("creator_id = ? AND decision IN ?", creatorID, []salesmodel.RiskDecision{salesmodel.RiskDecisionReview, salesmodel.RiskDecisionBlock})
+	if onlyPending {
+		query = query.Where("review_status = ?", salesmodel.ReviewStatusPending)
+	}
+
+	var attempts []*salesmodel.CheckoutAttempt
+	err := query.Order("created_at DESC").Limit(limit).Find(&attempts).Error
+	return attempts, err
+}
+
+func (r *checkoutAttemptRepositoryImpl) CountAwaitingReviewByCreator(creatorID uint) (int64, error) {
+	var total int64
+	err := r.db.Model(&salesmodel.CheckoutAttempt{}).
+		Where("creator_id = ? AND review_status = ?", creatorID, salesmodel.ReviewStatusPending).
+		Count(&total).Error
+	return total, err
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"gorm.io/gorm"
+)
+
+type CheckoutRecoveryRepository interface {
+	Create(recovery *salesmodel.CheckoutRecovery) error
+	Update(recovery *salesmodel.CheckoutRecovery) error
+	FindByPurchaseID(purchaseID uint) (*salesmodel.CheckoutRecovery, error)
+	FindByToken(token string) (*salesmodel.CheckoutRecovery, error)
+	FindPendingWithEmailsSentBelow(maxEmails int) ([]*salesmodel.CheckoutRecovery, error)
+	GetStatsByCreatorID(creatorID uint) ([]*salesmodel.EbookRecoveryStats, error)
+}
+
+type checkoutRecoveryRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewCheckoutRecoveryRepository(db *gorm.DB) CheckoutRecoveryRepository {
+	return &checkoutRecoveryRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *checkoutRecoveryRepositoryImpl) Create(recovery *salesmodel.CheckoutRecovery) error {
+	return r.db.Create(recovery).Error
+}
+
+func (r *checkoutRecoveryRepositoryImpl) Update(recovery *salesmodel.CheckoutRecovery) error {
+	return r.db.Omit("Purchase").Save(recovery).Error
+}
+
+func (r *checkoutRecoveryRepositoryImpl) FindByPurchaseID(purchaseID uint) (*salesmodel.CheckoutRecovery, error) {
+	var recovery salesmodel.CheckoutRecovery
+	err := r.db.Where("purchase_id = ?", purchaseID).First(&recovery).Error
+	if err != nil {
+		return nil, err
+	}
+	return &recovery, nil
+}
+
+func (r *checkoutRecoveryRepositoryImpl) FindByToken(token string) (*salesmodel.CheckoutRecovery, error) {
+	var recovery salesmodel.CheckoutRecovery
+	err := r.db.Preload("Purchase").Preload("Purchase.Ebook").Preload("Purchase.Client").
+		Where("token = ?", token).First(&recovery).Error
+	if err != nil {
+		return nil, err
+	}
+	return &recovery, nil
+}
+
+func (r *checkoutRecoveryRepositoryImpl) FindPendingWithEmailsSentBelow(maxEmails int) ([]*salesmodel.CheckoutRecovery, error) {
+	var recoveries []*salesmodel.CheckoutRecovery
+	err := r.db.Preload("Purchase").Preload("Purchase.Ebook").Preload("Purchase.Client").
+		Where("status = ? AND emails_sent < ?", salesmodel.CheckoutRecoveryStatusPending, maxEmails).
+		Order("expired_at ASC").
+		Find(&recoveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return recoveries, nil
+}
+
+func (r *checkoutRecoveryRepositoryImpl) GetStatsByCreatorID(creatorID uint) ([]*salesmodel.EbookRecoveryStats, error) {
+	var stats []*salesmodel.EbookRecoveryStats
+	err := r.db.Model(&salesmodel.CheckoutRecovery{}).
+		Select(`checkout_recoveries.ebook_id AS ebook_id,
+			ebooks.title AS ebook_title,
+			COUNT(checkout_recoveries.id) AS abandoned,
+			SUM(CASE WHEN checkout_recoveries.status = ? THEN 1 ELSE 0 END) AS recovered,
+			SUM(checkout_recoveries.emails_sent) AS emails_sent`, salesmodel.CheckoutRecoveryStatusRecovered).
+		Joins("JOIN ebooks ON ebooks.id = checkout_recoveries.ebook_id").
+		Where("checkout_recoveries.creator_id = ?", creatorID).
+		Group("checkout_recoveries.ebook_id, ebooks.title").
+		Order("abandoned DESC").
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
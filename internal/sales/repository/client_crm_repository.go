@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"errors"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ClientCRMRepository interface {
+	IsCreatorClient(creatorID, clientID uint) (bool, error)
+	FindCreatorPurchases(creatorID, clientID uint) ([]*salesmodel.Purchase, error)
+	SumSpent(creatorID, clientID uint) (int64, error)
+
+	CreateTag(tag *salesmodel.ClientTag) error
+	FindTagsByCreatorID(creatorID uint) ([]*salesmodel.ClientTag, error)
+	FindTag(creatorID, tagID uint) (*salesmodel.ClientTag, error)
+	FindTagByName(creatorID uint, name string) (*salesmodel.ClientTag, error)
+	DeleteTag(tag *salesmodel.ClientTag) error
+	AssignTag(assignment *salesmodel.ClientTagAssignment) error
+	UnassignTag(creatorID, clientID, tagID uint) error
+	FindAssignments(creatorID uint, clientIDs []uint) ([]*salesmodel.ClientTagAssignment, error)
+
+	CreateNote(note *salesmodel.ClientNote) error
+	FindNotes(creatorID, clientID uint) ([]*salesmodel.ClientNote, error)
+	DeleteNote(creatorID, clientID, noteID uint) error
+
+	CreateSegment(segment *salesmodel.ClientSegment) error
+	FindSegmentsByCreatorID(creatorID uint) ([]*salesmodel.ClientSegment, error)
+	FindSegmentByPublicID(publicID string) (*salesmodel.ClientSegment, error)
+	DeleteSegment(segment *salesmodel.ClientSegment) error
+}
+
+type clientCRMRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewClientCRMRepository(db *gorm.DB) ClientCRMRepository {
+	return &clientCRMRepositoryImpl{
+		db: db,
+	}
+}
+
+// IsCreatorClient verifica se o cliente comprou um ebook do criador ou foi cadastrado por ele
+func (r *clientCRMRepositoryImpl) IsCreatorClient(creatorID, clientID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&salesmodel.Purchase{}).
+		Joins("JOIN ebooks ON ebooks.id = purchases.ebook_id").
+		Where("ebooks.creator_id = ? AND purchases.client_id = ?", creatorID, clientID).
+		Count(&count).Error
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+
+	err = r.db.Model(&salesmodel.CreatorClient{}).
+		Where("creator_id = ? AND client_id = ?", creatorID, clientID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// FindCreatorPurchases lista as compras do cliente apenas dos ebooks do criador
+func (r *clientCRMRepositoryImpl) FindCreatorPurchases(creatorID, clientID uint) ([]*salesmodel.Purchase, error) {
+	var purchases []*salesmodel.Purchase
+	err := r.db.Preload("Ebook").
+		Joins("JOIN ebooks ON ebooks.id = purchases.ebook_id").
+		Where("ebooks.creator_id = ? AND purchases.client_id = ?", creatorID, clientID).
+		Order("purchases.created_at DESC").
+		Find(&purchases).Error
+	return purchases, err
+}
+
+// SumSpent soma, em centavos, as transações concluídas do cliente com o criador
+func (r *clientCRMRepositoryImpl) SumSpent(creatorID, clientID uint) (int64, error) {
+	var total int64
+	err := r.db.Model(&salesmodel.Transaction{}).
+		Select("COALESCE(SUM(transactions.total_amount), 0)").
+		Joins("JOIN purchases ON purchases.id = transactions.purchase_id").
+		Where("transactions.creator_id = ? AND transactions.status = ? AND purchases.client_id = ?",
+			creatorID, salesmodel.TransactionStatusCompleted, clientID).
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *clientCRMRepositoryImpl) CreateTag(tag *salesmodel.ClientTag) error {
+	return r.db.Create(tag).Error
+}
+
+func (r *clientCRMRepositoryImpl) FindTagsByCreatorID(creatorID uint) ([]*salesmodel.ClientTag, error) {
+	var tags []*salesmodel.ClientTag
+	err := r.db.Where("creator_id = ?", creatorID).Order("name").Find(&tags).Error
+	return tags, err
+}
+
+func (r *clientCRMRepositoryImpl) FindTag(creatorID, tagID uint) (*salesmodel.ClientTag, error) {
+	var tag salesmodel.ClientTag
+	err := r.db.Where("creator_id = ? AND id = ?", creatorID, tagID).First(&tag).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (r *clientCRMRepositoryImpl) FindTagByName(creatorID uint, name string) (*salesmodel.ClientTag, error) {
+	var tag salesmodel.ClientTag
+	err := r.db.Where("creator_id = ? AND LOWER(name) = LOWER(?)", creatorID, name).First(&tag).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// DeleteTag apaga a etiqueta definitivamente, junto com as ligações aos clientes,
+// para que o nome possa ser reutilizado
+func (r *clientCRMRepositoryImpl) DeleteTag(tag *salesmodel.ClientTag) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", tag.ID).Delete(&salesmodel.ClientTagAssignment{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(tag).Error
+	})
+}
+
+// AssignTag liga a etiqueta ao cliente; ligações já existentes são mantidas
+func (r *clientCRMRepositoryImpl) AssignTag(assignment *salesmodel.ClientTagAssignment) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Omit("Tag").Create(assignment).Error
+}
+
+func (r *clientCRMRepositoryImpl) UnassignTag(creatorID, clientID, tagID uint) error {
+	return r.db.Where("creator_id = ? AND client_id = ? AND tag_id = ?", creatorID, clientID, tagID).
+		Delete(&salesmodel.ClientTagAssignment{}).Error
+}
+
+func (r *clientCRMRepositoryImpl) FindAssignments(creatorID uint, clientIDs []uint) ([]*salesmodel.ClientTagAssignment, error) {
+	var assignments []*salesmodel.ClientTagAssignment
+	if len(clientIDs) == 0 {
+		return assignments, nil
+	}
+	err := r.db.Preload("Tag").
+		Where("creator_id = ? AND client_id IN ?", creatorID, clientIDs).
+		Find(&assignments).Error
+	return assignments, err
+}
+
+func (r *clientCRMRepositoryImpl) CreateNote(note *salesmodel.ClientNote) error {
+	return r.db.Create(note).Error
+}
+
+func (r *clientCRMRepositoryImpl) FindNotes(creatorID, clientID uint) ([]*salesmodel.ClientNote, error) {
+	var notes []*salesmodel.ClientNote
+	err := r.db.Where("creator_id = ? AND client_id = ?", creatorID, clientID).
+		Order("created_at DESC").
+		Find(&notes).Error
+	return notes, err
+}
+
+func (r *clientCRMRepositoryImpl) DeleteNote(creatorID, clientID, noteID uint) error {
+	return r.db.Where("creator_id = ? AND client_id = ? AND id = ?", creatorID, clientID, noteID).
+		Delete(&salesmodel.ClientNote{}).Error
+}
+
+func (r *clientCRMRepositoryImpl) CreateSegment(segment *salesmodel.ClientSegment) error {
+	return r.db.Create(segment).Error
+}
+
+func (r *clientCRMRepositoryImpl) FindSegmentsByCreatorID(creatorID uint) ([]*salesmodel.ClientSegment, error) {
+	var segments []*salesmodel.ClientSegment
+	err := r.db.Where("creator_id = ?", creatorID).Order("name").Find(&segments).Error
+	return segments, err
+}
+
+func (r *clientCRMRepositoryImpl) FindSegmentByPublicID(publicID string) (*salesmodel.ClientSegment, error) {
+	var segment salesmodel.ClientSegment
+	err := r.db.Where("public_id = ?", publicID).First(&segment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &segment, nil
+}
+
+func (r *clientCRMRepositoryImpl) DeleteSegment(segment *salesmodel.ClientSegment) error {
+	return r.db.Delete(segment).Error
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"errors"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ClientImportRepository interface {
+	Create(clientImport *salesmodel.ClientImport) error
+	Save(clientImport *salesmodel.ClientImport) error
+	FindByPublicID(publicID string) (*salesmodel.ClientImport, error)
+	FindRecentByCreatorID(creatorID uint, limit int) ([]*salesmodel.ClientImport, error)
+	FindQueued() ([]*salesmodel.ClientImport, error)
+	Claim(clientImport *salesmodel.ClientImport) (bool, error)
+	CreateErrors(importErrors []*salesmodel.ClientImportError) error
+	FindErrors(importID uint) ([]*salesmodel.ClientImportError, error)
+	LinkClient(creatorID, clientID uint, source string) error
+}
+
+type clientImportRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewClientImportRepository(db *gorm.DB) ClientImportRepository {
+	return &clientImportRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *clientImportRepositoryImpl) Create(clientImport *salesmodel.ClientImport) error {
+	return r.db.Create(clientImport).Error
+}
+
+func (r *clientImportRepositoryImpl) Save(clientImport *salesmodel.ClientImport) error {
+	return r.db.Omit("Errors").Save(clientImport).Error
+}
+
+func (r *clientImportRepositoryImpl) FindByPublicID(publicID string) (*salesmodel.ClientImport, error) {
+	var clientImport salesmodel.ClientImport
+	err := r.db.Where("public_id = ?", publicID).First(&clientImport).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &clientImport, nil
+}
+
+// FindRecentByCreatorID lista as últimas importações sem carregar o conteúdo das planilhas
+func (r *clientImportRepositoryImpl) FindRecentByCreatorID(creatorID uint, limit int) ([]*salesmodel.ClientImport, error) {
+	var imports []*salesmodel.ClientImport
+	err := r.db.Omit("content").
+		Where("creator_id = ?", creatorID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&imports).Error
+	return imports, err
+}
+
+func (r *clientImportRepositoryImpl) FindQueued() ([]*salesmodel.ClientImport, error) {
+	var imports []*salesmodel.ClientImport
+	err := r.db.Where("status = ?", salesmodel.ClientImportStatusQueued).
+		Order("created_at").
+		Find(&imports).Error
+	return imports, err
+}
+
+// Claim marca a importação como em processamento somente se ela ainda estiver na fila,
+// para que duas instâncias do job não processem a mesma planilha
+func (r *clientImportRepositoryImpl) Claim(clientImport *salesmodel.ClientImport) (bool, error) {
+	result := r.db.Model(&salesmodel.ClientImport{}).
+		Where("id = ? AND status = ?", clientImport.ID, salesmodel.ClientImportStatusQueued).
+		Updates(map[string]interface{}{
+			"status":     salesmodel.ClientImportStatusProcessing,
+			"started_at": clientImport.StartedAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	clientImport.Status = salesmodel.ClientImportStatusProcessing
+	return true, nil
+}
+
+func (r *clientImportRepositoryImpl) CreateErrors(importErrors []*salesmodel.ClientImportError) error {
+	if len(importErrors) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(importErrors, 500).Error
+}
+
+func (r *clientImportRepositoryImpl) FindErrors(importID uint) ([]*salesmodel.ClientImportError, error) {
+	var importErrors []*salesmodel.ClientImportError
+	err := r.db.Where("import_id = ?", importID).Order("line, id").Find(&importErrors).Error
+	return importErrors, err
+}
+
+// LinkClient liga o cliente ao criador; ligações já existentes são mantidas
+func (r *clientImportRepositoryImpl) LinkClient(creatorID, clientID uint, source string) error {
+	link := &salesmodel.CreatorClient{CreatorID: creatorID, ClientID: clientID, Source: source}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(link).Error
+}
@@ -14,5 +14,8 @@ type ClientRepository interface {
 	FindByClientsWhereEbookWasSend(creator *accountmodel.Creator, query salesmodel.ClientFilter) (*[]salesmodel.Client, error)
 	FindClientsByPurchasesFromCreator(creator *accountmodel.Creator) (*[]salesmodel.Client, error)
 	FindByEmail(email string) (*salesmodel.Client, error)
+	FindByEmailAndCreator(email string, creatorID uint) (*salesmodel.Client, error)
 	FindByCPF(cpf string) (*salesmodel.Client, error)
+	FindForeignByEmail(email string) (*salesmodel.Client, error)
+	FindClientsBySegment(creatorID uint, rules salesmodel.SegmentRules) ([]salesmodel.Client, error)
 }
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"gorm.io/gorm"
+)
+
+type CPFVerificationRepository interface {
+	Save(verification *salesmodel.CPFVerification) error
+	FindLatest(cpf, birthdate string) (*salesmodel.CPFVerification, error)
+	FindPending(limit int) ([]*salesmodel.CPFVerification, error)
+	MarkClientsValidated(cpf string) error
+	RecordUsage(usage *salesmodel.CPFLookupUsage) error
+	SumConsumedByCreator(creatorID uint, since time.Time) (int64, error)
+}
+
+type cpfVerificationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewCPFVerificationRepository(db *gorm.DB) CPFVerificationRepository {
+	return &cpfVerificationRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *cpfVerificationRepositoryImpl) Save(verification *salesmodel.CPFVerification) error {
+	return r.db.Save(verification).Error
+}
+
+// FindLatest retorna a consulta mais recente do CPF com a data de nascimento informada, ou nil
+func (r *cpfVerificationRepositoryImpl) FindLatest(cpf, birthdate string) (*salesmodel.CPFVerification, error) {
+	var verification salesmodel.CPFVerification
+	// A comparação em texto puro só encontra consultas gravadas antes da criptografia
+	err := r.db.Where("lookup_index = ? OR (cpf = ? AND birthdate = ?)",
+		salesmodel.CPFVerificationLookupIndex(cpf, birthdate), cpf, birthdate).
+		Order("updated_at DESC").
+		First(&verification).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &verification, nil
+}
+
+func (r *cpfVerificationRepositoryImpl) FindPending(limit int) ([]*salesmodel.CPFVerification, error) {
+	var verifications []*salesmodel.CPFVerification
+	err := r.db.Where("status = ?", salesmodel.CPFVerificationPending).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&verifications).Error
+	return verifications, err
+}
+
+// MarkClientsValidated marca como validados os clientes com o CPF confirmado na Receita Federal.
+// O CPF dos clientes fica cifrado, então a busca é pelo índice cego (e pelo texto puro nas linhas antigas).
+func (r *cpfVerificationRepositoryImpl) MarkClientsValidated(cpf string) error {
+	if cpf == "" {
+		return nil
+	}
+	return r.db.Model(&salesmodel.Client{}).
+		Where("cpf_index = ? OR cpf = ?", salesmodel.CPFBlindIndex(cpf), cpf).
+		Update("validated", true).Error
+}
+
+func (r *cpfVerificationRepositoryImpl) RecordUsage(usage *salesmodel.CPFLookupUsage) error {
+	return r.db.Create(usage).Error
+}
+
+// SumConsumedByCreator soma os créditos de consulta consumidos pelas vendas do criador desde a data informada
+func (r *cpfVerificationRepositoryImpl) SumConsumedByCreator(creatorID uint, since time.Time) (int64, error) {
+	var total int64
+	err := r.db.Model(&salesmodel.CPFLookupUsage{}).
+		Where("creator_id = ? AND created_at >= ?", creatorID, since).
+		Select("COALESCE(SUM(consumed), 0)").
+		Scan(&total).Error
+	return total, err
+}
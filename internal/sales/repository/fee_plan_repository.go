@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"gorm.io/gorm"
+)
+
+type FeePlanRepository interface {
+	Create(plan *salesmodel.FeePlan) error
+	FindByID(id uint) (*salesmodel.FeePlan, error)
+	FindByPublicID(publicID string) (*salesmodel.FeePlan, error)
+	FindAll() ([]*salesmodel.FeePlan, error)
+	FindActiveByKind(kind salesmodel.FeePlanKind) ([]*salesmodel.FeePlan, error)
+	FindActiveAssignment(creatorID uint, at time.Time) (*salesmodel.CreatorFeePlan, error)
+	CreateAssignment(assignment *salesmodel.CreatorFeePlan) error
+	SumCompletedVolume(creatorID uint, since time.Time) (int64, error)
+}
+
+type feePlanRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewFeePlanRepository(db *gorm.DB) FeePlanRepository {
+	return &feePlanRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *feePlanRepositoryImpl) Create(plan *salesmodel.FeePlan) error {
+	return r.db.Create(plan).Error
+}
+
+func (r *feePlanRepositoryImpl) FindByID(id uint) (*salesmodel.FeePlan, error) {
+	var plan salesmodel.FeePlan
+	err := r.db.First(&plan, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// FindByPublicID retorna o plano pelo identificador público (nil se não existir)
+func (r *feePlanRepositoryImpl) FindByPublicID(publicID string) (*salesmodel.FeePlan, error) {
+	var plan salesmodel.FeePlan
+	err := r.db.Where("public_id = ?", publicID).First(&plan).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func (r *feePlanRepositoryImpl) FindAll() ([]*salesmodel.FeePlan, error) {
+	var plans []*salesmodel.FeePlan
+	err := r.db.Order("kind ASC, id ASC").Find(&plans).Error
+	return plans, err
+}
+
+func (r *feePlanRepositoryImpl) FindActiveByKind(kind salesmodel.FeePlanKind) ([]*salesmodel.FeePlan, error) {
+	var plans []*salesmodel.FeePlan
+	err := r.db.Where("kind = ? AND active = ?", kind, true).Order("id ASC").Find(&plans).Error
+	return plans, err
+}
+
+// FindActiveAssignment retorna a atribuição mais recente vigente no instante informado (nil se não houver)
+func (r *feePlanRepositoryImpl) FindActiveAssignment(creatorID uint, at time.Time) (*salesmodel.CreatorFeePlan, error) {
+	var assignment salesmodel.CreatorFeePlan
+	err := r.db.Preload("FeePlan").
+		Joins("JOIN fee_plans ON fee_plans.id = creator_fee_plans.fee_plan_id AND fee_plans.deleted_at IS NULL").
+		Where("creator_fee_plans.creator_id = ? AND creator_fee_plans.starts_at <= ?", creatorID, at).
+		Where("creator_fee_plans.ends_at IS NULL OR creator_fee_plans.ends_at > ?", at).
+		Where("fee_plans.active = ?", true).
+		Order("creator_fee_plans.starts_at DESC, creator_fee_plans.id DESC").
+		First(&assignment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+func (r *feePlanRepositoryImpl) CreateAssignment(assignment *salesmodel.CreatorFeePlan) error {
+	return r.db.Omit("FeePlan").Create(assignment).Error
+}
+
+// SumCompletedVolume soma, em centavos de real, as vendas concluídas do criador desde a data informada.
+// Vendas manuais, pagas fora da plataforma, não contam para o volume
+func (r *feePlanRepositoryImpl) SumCompletedVolume(creatorID uint, since time.Time) (int64, error) {
+	var total int64
+	err := r.db.Model(&salesmodel.Transaction{}).
+		Select("COALESCE(SUM(total_amount), 0)").
+		Where("creator_id = ? AND status = ? AND created_at >= ?", creatorID, salesmodel.TransactionStatusCompleted, since).
+		Where("off_platform = ?", false).
+		Where("currency = ? OR currency IS NULL OR currency = ''", money.BRL).
+		Scan(&total).Error
+	return total, err
+}
@@ -3,10 +3,13 @@ package gorm
 import (
 	"errors"
 	"log"
+	"strings"
+	"time"
 
 	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
 	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
 	"github.com/anglesson/simple-web-server/pkg/database"
+	"github.com/anglesson/simple-web-server/pkg/pii"
 	"gorm.io/gorm"
 )
 
@@ -19,7 +22,10 @@ func NewClientGormRepository() *ClientGormRepository {
 
 func (cr *ClientGormRepository) Save(client *salesmodel.Client) error {
 	var existingClient salesmodel.Client
-	err := database.DB.Where("cpf = ?", client.CPF).First(&existingClient).Error
+	err := gorm.ErrRecordNotFound
+	if query := identityQuery(client); query != nil {
+		err = query.First(&existingClient).Error
+	}
 
 	if err != nil {
 		err = database.DB.Create(client).Error
@@ -55,6 +61,31 @@ func (cr *ClientGormRepository) Save(client *salesmodel.Client) error {
 	return nil
 }
 
+// cpfQuery busca pelo índice cego do CPF. A comparação com a coluna cpf só encontra linhas
+// gravadas antes da criptografia e deixa de ser necessária depois de rodar cmd/pii-rotate.
+// CPF vazio não identifica ninguém: sem o filtro 1 = 0 casaria com qualquer cliente sem CPF.
+func cpfQuery(db *gorm.DB, cpf string) *gorm.DB {
+	if cpf == "" {
+		return db.Where("1 = 0")
+	}
+	return db.Where("clients.cpf_index = ? OR clients.cpf = ?", salesmodel.CPFBlindIndex(cpf), cpf)
+}
+
+// identityQuery localiza o cadastro do cliente: brasileiros pelo CPF, estrangeiros pelo email.
+// Sem CPF nem ID (ex.: venda manual sem CPF) não há como identificar o cadastro e devolve nil.
+func identityQuery(client *salesmodel.Client) *gorm.DB {
+	if !client.IsForeign() && client.CPF != "" {
+		return cpfQuery(database.DB, client.CPF)
+	}
+	if client.ID != 0 {
+		return database.DB.Where("id = ?", client.ID)
+	}
+	if client.IsForeign() {
+		return database.DB.Where("buyer_type = ? AND email = ?", salesmodel.BuyerTypeForeign, client.Email)
+	}
+	return nil
+}
+
 func (cr *ClientGormRepository) FindClientsByCreator(creator *accountmodel.Creator, query salesmodel.ClientFilter) (*[]salesmodel.Client, error) {
 	var clients []salesmodel.Client
 
@@ -69,9 +100,9 @@ func (cr *ClientGormRepository) FindClientsByCreator(creator *accountmodel.Creat
 		Offset(getOffset(query.Pagination)).
 		Limit(getLimit(query.Pagination)).
 		Model(&salesmodel.Client{}).
-		Where("clients.id IN (?)", subquery).
+		Where("clients.id IN (?) OR clients.id IN (?)", subquery, linkedClientIDs(creator.ID)).
 		Preload("Purchases").
-		Scopes(ContainsNameCpfEmailOrPhoneWith(query.Term)).
+		Scopes(ContainsNameCpfEmailOrPhoneWith(query.Term), InSegment(creator.ID, query.Segment)).
 		Find(&clients).
 		Error
 
@@ -84,14 +115,103 @@ func (cr *ClientGormRepository) FindClientsByCreator(creator *accountmodel.Creat
 	return &clients, nil
 }
 
+// linkedClientIDs seleciona os clientes ligados ao criador sem compra, como os importados por planilha
+func linkedClientIDs(creatorID uint) *gorm.DB {
+	return database.DB.Model(&salesmodel.CreatorClient{}).
+		Select("client_id").
+		Where("creator_id = ?", creatorID)
+}
+
+// FindClientsBySegment retorna todos os clientes do criador que atendem aos critérios, sem paginação
+func (cr *ClientGormRepository) FindClientsBySegment(creatorID uint, rules salesmodel.SegmentRules) ([]salesmodel.Client, error) {
+	var clients []salesmodel.Client
+
+	purchased := database.DB.Model(&salesmodel.Purchase{}).
+		Select("purchases.client_id").
+		Joins("JOIN ebooks ON ebooks.id = purchases.ebook_id").
+		Where("ebooks.creator_id = ?", creatorID)
+
+	err := database.DB.
+		Model(&salesmodel.Client{}).
+		Where("clients.id IN (?) OR clients.id IN (?)", purchased, linkedClientIDs(creatorID)).
+		Scopes(InSegment(creatorID, &rules)).
+		Order("clients.name").
+		Find(&clients).Error
+	if err != nil {
+		log.Printf("Erro na busca de clientes do segmento: %s", err)
+		return nil, errors.New("erro na busca de clientes")
+	}
+	return clients, nil
+}
+
+// InSegment filtra os clientes pelos critérios do segmento, considerando apenas compras,
+// transações e downloads dos ebooks do criador
+func InSegment(creatorID uint, rules *salesmodel.SegmentRules) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if rules == nil || rules.IsEmpty() {
+			return db
+		}
+
+		// Compras válidas: pagas e que não foram estornadas ou revogadas
+		activePurchases := func(ebookIDs []uint) *gorm.DB {
+			return database.DB.Model(&salesmodel.Purchase{}).
+				Select("purchases.client_id").
+				Where("purchases.ebook_id IN ? AND purchases.payment_status = ?", ebookIDs, salesmodel.PaymentStatusConfirmed).
+				Where("purchases.status NOT IN ?", []salesmodel.PurchaseStatus{
+					salesmodel.PurchaseStatusRefunded, salesmodel.PurchaseStatusChargeback, salesmodel.PurchaseStatusRevoked,
+				})
+		}
+
+		if len(rules.TagIDs) > 0 {
+			tagged := database.DB.Model(&salesmodel.ClientTagAssignment{}).
+				Select("client_id").
+				Where("creator_id = ? AND tag_id IN ?", creatorID, rules.TagIDs)
+			db = db.Where("clients.id IN (?)", tagged)
+		}
+		for _, ebookID := range rules.PurchasedEbookIDs {
+			db = db.Where("clients.id IN (?)", activePurchases([]uint{ebookID}))
+		}
+		if len(rules.NotPurchasedEbookIDs) > 0 {
+			db = db.Where("clients.id NOT IN (?)", activePurchases(rules.NotPurchasedEbookIDs))
+		}
+		if rules.MinSpent > 0 {
+			spent := database.DB.Model(&salesmodel.Transaction{}).
+				Select("purchases.client_id").
+				Joins("JOIN purchases ON purchases.id = transactions.purchase_id").
+				Where("transactions.creator_id = ? AND transactions.status = ?", creatorID, salesmodel.TransactionStatusCompleted).
+				Group("purchases.client_id").
+				Having("SUM(transactions.total_amount) > ?", rules.MinSpent)
+			db = db.Where("clients.id IN (?)", spent)
+		}
+		if rules.InactiveDays > 0 {
+			since := time.Now().AddDate(0, 0, -rules.InactiveDays)
+			downloaded := database.DB.Model(&salesmodel.DownloadLog{}).
+				Select("purchases.client_id").
+				Joins("JOIN purchases ON purchases.id = download_logs.purchase_id").
+				Joins("JOIN ebooks ON ebooks.id = purchases.ebook_id").
+				Where("ebooks.creator_id = ? AND download_logs.created_at >= ?", creatorID, since)
+			db = db.Where("clients.id NOT IN (?)", downloaded)
+		}
+		return db
+	}
+}
+
+// ContainsNameCpfEmailOrPhoneWith busca por trecho do nome, documento estrangeiro ou email.
+// CPF e telefone ficam cifrados, então só são encontrados pelo valor completo (com ou sem pontuação).
 func ContainsNameCpfEmailOrPhoneWith(term string) func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
 		if term == "" {
 			return db
 		}
 		searchTerm := "%" + term + "%"
-		return db.Where("clients.name LIKE ? OR clients.cpf LIKE ? OR clients.email LIKE ? OR clients.phone LIKE ?",
-			searchTerm, searchTerm, searchTerm, searchTerm)
+		condition := db.Session(&gorm.Session{NewDB: true}).Where("clients.name LIKE ? OR clients.document_id LIKE ? OR clients.email LIKE ?",
+			searchTerm, searchTerm, searchTerm)
+		if digits := pii.Digits(term); digits != "" {
+			condition = condition.
+				Or("clients.cpf_index = ?", salesmodel.CPFBlindIndex(digits)).
+				Or("clients.phone_index = ?", salesmodel.PhoneBlindIndex(digits))
+		}
+		return db.Where(condition)
 	}
 }
 
@@ -115,9 +235,14 @@ func (cr *ClientGormRepository) FindByIDAndCreators(client *salesmodel.Client, c
 		Joins("JOIN creators as ec ON ec.id = ebooks.creator_id").
 		Where("ec.email = ? AND clients.id = ?", creator, clientID)
 
+	linked := database.DB.Model(&salesmodel.CreatorClient{}).
+		Select("creator_clients.client_id").
+		Joins("JOIN creators as lc ON lc.id = creator_clients.creator_id").
+		Where("lc.email = ? AND creator_clients.client_id = ?", creator, clientID)
+
 	err := database.DB.
 		Preload("Purchases").
-		Where("id IN (?)", subquery).
+		Where("id IN (?) OR id IN (?)", subquery, linked).
 		First(client).
 		Error
 	if err != nil {
@@ -185,11 +310,14 @@ func (cr *ClientGormRepository) FindByClientsWhereEbookWasSend(creator *accountm
 
 func (cr *ClientGormRepository) FindClientsByPurchasesFromCreator(creator *accountmodel.Creator) (*[]salesmodel.Client, error) {
 	var clients []salesmodel.Client
+	purchased := database.DB.Model(&salesmodel.Purchase{}).
+		Select("purchases.client_id").
+		Joins("JOIN ebooks ON ebooks.id = purchases.ebook_id").
+		Where("ebooks.creator_id = ?", creator.ID)
+
 	err := database.DB.
 		Model(&salesmodel.Client{}).
-		Distinct("clients.*").
-		Joins("JOIN purchases ON purchases.client_id = clients.id").
-		Joins("JOIN ebooks ON ebooks.id = purchases.ebook_id AND ebooks.creator_id = ?", creator.ID).
+		Where("clients.id IN (?) OR clients.id IN (?)", purchased, linkedClientIDs(creator.ID)).
 		Find(&clients).Error
 
 	if err != nil {
@@ -212,9 +340,32 @@ func (cr *ClientGormRepository) FindByEmail(email string) (*salesmodel.Client, e
 	return &client, nil
 }
 
+// FindByEmailAndCreator busca o cliente pelo email entre os compradores e os clientes vinculados ao criador
+func (cr *ClientGormRepository) FindByEmailAndCreator(email string, creatorID uint) (*salesmodel.Client, error) {
+	purchased := database.DB.Model(&salesmodel.Purchase{}).
+		Select("purchases.client_id").
+		Joins("JOIN ebooks ON ebooks.id = purchases.ebook_id").
+		Where("ebooks.creator_id = ?", creatorID)
+
+	var client salesmodel.Client
+	err := database.DB.
+		Where("LOWER(clients.email) = ?", strings.ToLower(email)).
+		Where("clients.id IN (?) OR clients.id IN (?)", purchased, linkedClientIDs(creatorID)).
+		Order("clients.id").
+		First(&client).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Erro ao buscar cliente do criador por email: %s", err)
+		return nil, errors.New("erro ao buscar cliente")
+	}
+	return &client, nil
+}
+
 func (cr *ClientGormRepository) FindByCPF(cpf string) (*salesmodel.Client, error) {
 	var client salesmodel.Client
-	err := database.DB.Where("cpf = ?", cpf).First(&client).Error
+	err := cpfQuery(database.DB, cpf).First(&client).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
@@ -225,6 +376,20 @@ func (cr *ClientGormRepository) FindByCPF(cpf string) (*salesmodel.Client, error
 	return &client, nil
 }
 
+// FindForeignByEmail busca o comprador estrangeiro pelo email, que é a sua identidade na plataforma
+func (cr *ClientGormRepository) FindForeignByEmail(email string) (*salesmodel.Client, error) {
+	var client salesmodel.Client
+	err := database.DB.Where("buyer_type = ? AND email = ?", salesmodel.BuyerTypeForeign, strings.ToLower(strings.TrimSpace(email))).First(&client).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Erro ao buscar cliente estrangeiro por email: %s", err)
+		return nil, errors.New("erro ao buscar cliente")
+	}
+	return &client, nil
+}
+
 func getOffset(pagination *salesmodel.Pagination) int {
 	if pagination == nil {
 		return 0
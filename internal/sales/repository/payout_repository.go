@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"errors"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PayoutRepository interface {
+	Save(payout *salesmodel.Payout) error
+	FindByStripePayoutID(stripePayoutID string) (*salesmodel.Payout, error)
+	CreateItems(items []*salesmodel.PayoutItem) error
+	FindUpcomingByCreatorID(creatorID uint) ([]*salesmodel.Payout, error)
+	FindRecentByCreatorID(creatorID uint, limit int) ([]*salesmodel.Payout, error)
+	FindRecentSalesByCreatorID(creatorID uint, limit int) ([]*salesmodel.Transaction, error)
+	FindPayoutsByTransactionIDs(transactionIDs []uint) (map[uint]*salesmodel.Payout, error)
+	FindTransactionIDsByPaymentIntents(creatorID uint, paymentIntentIDs []string) (map[string]uint, error)
+	FindConnectedCreators() ([]*accountmodel.Creator, error)
+	FindCreatorByStripeAccountID(accountID string) (*accountmodel.Creator, error)
+}
+
+type payoutRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewPayoutRepository(db *gorm.DB) PayoutRepository {
+	return &payoutRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *payoutRepositoryImpl) Save(payout *salesmodel.Payout) error {
+	return r.db.Omit(clause.Associations).Save(payout).Error
+}
+
+// FindByStripePayoutID retorna nil, sem erro, quando o repasse ainda não foi importado
+func (r *payoutRepositoryImpl) FindByStripePayoutID(stripePayoutID string) (*salesmodel.Payout, error) {
+	var payout salesmodel.Payout
+	err := r.db.Where("stripe_payout_id = ?", stripePayoutID).First(&payout).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &payout, nil
+}
+
+// CreateItems grava as movimentações do repasse; as que já foram importadas são ignoradas
+func (r *payoutRepositoryImpl) CreateItems(items []*salesmodel.PayoutItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(items).Error
+}
+
+func (r *payoutRepositoryImpl) FindUpcomingByCreatorID(creatorID uint) ([]*salesmodel.Payout, error) {
+	var payouts []*salesmodel.Payout
+	err := r.db.Where("creator_id = ? AND status IN ?", creatorID,
+		[]salesmodel.PayoutStatus{salesmodel.PayoutStatusPending, salesmodel.PayoutStatusInTransit}).
+		Order("arrival_date ASC").
+		Find(&payouts).Error
+	return payouts, err
+}
+
+func (r *payoutRepositoryImpl) FindRecentByCreatorID(creatorID uint, limit int) ([]*salesmodel.Payout, error) {
+	var payouts []*salesmodel.Payout
+	err := r.db.Where("creator_id = ? AND status NOT IN ?", creatorID,
+		[]salesmodel.PayoutStatus{salesmodel.PayoutStatusPending, salesmodel.PayoutStatusInTransit}).
+		Order("arrival_date DESC").
+		Limit(limit).
+		Find(&payouts).Error
+	return payouts, err
+}
+
+func (r *payoutRepositoryImpl) FindRecentSalesByCreatorID(creatorID uint, limit int) ([]*salesmodel.Transaction, error) {
+	var transactions []*salesmodel.Transaction
+	err := r.db.Preload("Purchase.Ebook").
+		Where("creator_id = ? AND status = ?", creatorID, salesmodel.TransactionStatusCompleted).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&transactions).Error
+	return transactions, err
+}
+
+// FindPayoutsByTransactionIDs retorna o repasse que levou cada venda à conta bancária do criador
+func (r *payoutRepositoryImpl) FindPayoutsByTransactionIDs(transactionIDs []uint) (map[uint]*salesmodel.Payout, error) {
+	result := make(map[uint]*salesmodel.Payout)
+	if len(transactionIDs) == 0 {
+		return result, nil
+	}
+
+	var items []*salesmodel.PayoutItem
+	// Reembolsos e contestações também apontam para a venda, mas o recebimento é o da cobrança
+	err := r.db.Where("transaction_id IN ? AND type IN ?", transactionIDs, []string{"charge", "payment"}).Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	payoutIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		payoutIDs = append(payoutIDs, item.PayoutID)
+	}
+
+	var payouts []*salesmodel.Payout
+	if len(payoutIDs) > 0 {
+		if err := r.db.Where("id IN ?", payoutIDs).Find(&payouts).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	byID := make(map[uint]*salesmodel.Payout, len(payouts))
+	for _, payout := range payouts {
+		byID[payout.ID] = payout
+	}
+	for _, item := range items {
+		if payout, ok := byID[item.PayoutID]; ok && item.TransactionID != nil {
+			result[*item.TransactionID] = payout
+		}
+	}
+	return result, nil
+}
+
+func (r *payoutRepositoryImpl) FindTransactionIDsByPaymentIntents(creatorID uint, paymentIntentIDs []string) (map[string]uint, error) {
+	result := make(map[string]uint)
+	if len(paymentIntentIDs) == 0 {
+		return result, nil
+	}
+
+	var transactions []*salesmodel.Transaction
+	err := r.db.Select("id", "stripe_payment_intent_id").
+		Where("creator_id = ? AND stripe_payment_intent_id IN ?", creatorID, paymentIntentIDs).
+		Find(&transactions).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, transaction := range transactions {
+		result[transaction.StripePaymentIntentID] = transaction.ID
+	}
+	return result, nil
+}
+
+// FindConnectedCreators lista os criadores com a conta Stripe Connect ativa
+func (r *payoutRepositoryImpl) FindConnectedCreators() ([]*accountmodel.Creator, error) {
+	var creators []*accountmodel.Creator
+	err := r.db.Where("stripe_connect_account_id <> '' AND onboarding_completed = ?", true).Find(&creators).Error
+	return creators, err
+}
+
+func (r *payoutRepositoryImpl) FindCreatorByStripeAccountID(accountID string) (*accountmodel.Creator, error) {
+	var creator accountmodel.Creator
+	err := r.db.Where("stripe_connect_account_id = ?", accountID).First(&creator).Error
+	if err != nil {
+		return nil, err
+	}
+	return &creator, nil
+}
@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"gorm.io/gorm"
+)
+
+type PrivacyRepository interface {
+	Create(request *salesmodel.PrivacyRequest) error
+	Save(request *salesmodel.PrivacyRequest) error
+	FindByPublicID(publicID string) (*salesmodel.PrivacyRequest, error)
+	FindByCreatorID(creatorID uint, limit int) ([]*salesmodel.PrivacyRequest, error)
+	FindAll(limit int) ([]*salesmodel.PrivacyRequest, error)
+	HasOpenRequest(clientID uint, requestType salesmodel.PrivacyRequestType) (bool, error)
+	FindPersonalData(client *salesmodel.Client, creatorID uint) (*salesmodel.ClientPersonalData, error)
+	Anonymize(client *salesmodel.Client, now time.Time) error
+}
+
+const openRequestsFirst = "CASE WHEN status = 'open' THEN 0 ELSE 1 END"
+
+type privacyRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewPrivacyRepository(db *gorm.DB) PrivacyRepository {
+	return &privacyRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *privacyRepositoryImpl) Create(request *salesmodel.PrivacyRequest) error {
+	return r.db.Omit("Client", "Creator").Create(request).Error
+}
+
+func (r *privacyRepositoryImpl) Save(request *salesmodel.PrivacyRequest) error {
+	return r.db.Omit("Client", "Creator").Save(request).Error
+}
+
+func (r *privacyRepositoryImpl) FindByPublicID(publicID string) (*salesmodel.PrivacyRequest, error) {
+	var request salesmodel.PrivacyRequest
+	err := r.db.Preload("Client").Preload("Creator").Where("public_id = ?", publicID).First(&request).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (r *privacyRepositoryImpl) FindByCreatorID(creatorID uint, limit int) ([]*salesmodel.PrivacyRequest, error) {
+	var requests []*salesmodel.PrivacyRequest
+	err := r.db.Preload("Client").
+		Where("creator_id = ?", creatorID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&requests).Error
+	return requests, err
+}
+
+// FindAll lista as solicitações em aberto pelo prazo mais próximo e depois as encerradas mais recentes
+func (r *privacyRepositoryImpl) FindAll(limit int) ([]*salesmodel.PrivacyRequest, error) {
+	var requests []*salesmodel.PrivacyRequest
+	err := r.db.Preload("Client").Preload("Creator").
+		Order(openRequestsFirst).
+		Order("due_at").
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&requests).Error
+	return requests, err
+}
+
+func (r *privacyRepositoryImpl) HasOpenRequest(clientID uint, requestType salesmodel.PrivacyRequestType) (bool, error) {
+	var count int64
+	err := r.db.Model(&salesmodel.PrivacyRequest{}).
+		Where("client_id = ? AND type = ? AND status = ?", clientID, requestType, salesmodel.PrivacyRequestOpen).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// FindPersonalData carrega os registros do cliente. Com creatorID diferente de zero traz apenas
+// o que foi gerado nas vendas e no relacionamento com esse criador.
+func (r *privacyRepositoryImpl) FindPersonalData(client *salesmodel.Client, creatorID uint) (*salesmodel.ClientPersonalData, error) {
+	data := &salesmodel.ClientPersonalData{Client: client}
+
+	purchases := r.db.Preload("Ebook").
+		Joins("JOIN ebooks ON ebooks.id = purchases.ebook_id").
+		Where("purchases.client_id = ?", client.ID)
+	if creatorID != 0 {
+		purchases = purchases.Where("ebooks.creator_id = ?", creatorID)
+	}
+	if err := purchases.Order("purchases.created_at").Find(&data.Purchases).Error; err != nil {
+		return nil, err
+	}
+
+	purchaseIDs := make([]uint, 0, len(data.Purchases))
+	for _, purchase := range data.Purchases {
+		purchaseIDs = append(purchaseIDs, purchase.ID)
+	}
+	if len(purchaseIDs) > 0 {
+		if err := r.db.Where("purchase_id IN ?", purchaseIDs).Order("created_at").Find(&data.Transactions).Error; err != nil {
+			return nil, err
+		}
+		if err := r.db.Where("purchase_id IN ?", purchaseIDs).Order("created_at").Find(&data.DownloadLogs).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.scoped(creatorID, "creator_id").Preload("Tag").Where("client_id = ?", client.ID).
+		Find(&data.Tags).Error; err != nil {
+		return nil, err
+	}
+	if err := r.scoped(creatorID, "creator_id").Where("client_id = ?", client.ID).Order("created_at").
+		Find(&data.Notes).Error; err != nil {
+		return nil, err
+	}
+
+	broadcasts := r.scoped(creatorID, "creator_id").Omit("body").
+		Where("id IN (?)", r.db.Model(&salesmodel.BroadcastDelivery{}).Select("broadcast_id").Where("client_id = ?", client.ID))
+	if err := broadcasts.Order("created_at").Find(&data.Broadcasts).Error; err != nil {
+		return nil, err
+	}
+	broadcastIDs := make([]uint, 0, len(data.Broadcasts))
+	for _, broadcast := range data.Broadcasts {
+		broadcastIDs = append(broadcastIDs, broadcast.ID)
+	}
+	if len(broadcastIDs) > 0 {
+		if err := r.db.Where("client_id = ? AND broadcast_id IN ?", client.ID, broadcastIDs).Order("id").
+			Find(&data.Deliveries).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := r.scoped(creatorID, "creator_id").Where("client_id = ?", client.ID).
+		Find(&data.Unsubscribes).Error; err != nil {
+		return nil, err
+	}
+
+	if identity := r.identityQuery(client); identity != nil {
+		if err := r.scoped(creatorID, "creator_id").Where(identity).Order("created_at").
+			Find(&data.CheckoutAttempts).Error; err != nil {
+			return nil, err
+		}
+	}
+	if client.Email != "" {
+		if err := r.scoped(creatorID, "creator_id").Where("email = ?", client.Email).Order("created_at").
+			Find(&data.WaitlistEntries).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.scoped(creatorID, "creator_id").Where("client_id = ?", client.ID).Order("created_at").
+		Find(&data.Requests).Error; err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Anonymize apaga os dados pessoais do cliente e as cópias guardadas em outros registros.
+// Compras e transações continuam existindo, ligadas ao cliente anonimizado, porque a lei fiscal exige guardá-las.
+func (r *privacyRepositoryImpl) Anonymize(client *salesmodel.Client, now time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if identity := r.identityQuery(client); identity != nil {
+			err := tx.Model(&salesmodel.CheckoutAttempt{}).Where(identity).Updates(map[string]interface{}{
+				"name": "", "cpf": "", "cpf_index": "", "email": "", "ip": "", "device_id": "",
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		if client.CPF != "" {
+			err := tx.Unscoped().Where("cpf_index = ? OR cpf = ?", salesmodel.CPFBlindIndex(client.CPF), client.CPF).
+				Delete(&salesmodel.CPFVerification{}).Error
+			if err != nil {
+				return err
+			}
+		}
+		if client.Email != "" {
+			if err := tx.Unscoped().Where("email = ?", client.Email).Delete(&salesmodel.WaitlistEntry{}).Error; err != nil {
+				return err
+			}
+		}
+
+		err := tx.Model(&salesmodel.BroadcastDelivery{}).Where("client_id = ?", client.ID).
+			Updates(map[string]interface{}{"name": "", "email": ""}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("client_id = ?", client.ID).Delete(&salesmodel.ClientNote{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("client_id = ?", client.ID).Delete(&salesmodel.ClientTagAssignment{}).Error; err != nil {
+			return err
+		}
+
+		client.Name = salesmodel.AnonymizedClientName
+		client.CPF = ""
+		client.Birthdate = ""
+		client.Email = ""
+		client.Phone = ""
+		client.Country = ""
+		client.DocumentID = ""
+		client.Validated = false
+		client.AnonymizedAt = &now
+		return tx.Model(client).Select("name", "cpf", "cpf_index", "birthdate", "email", "phone", "phone_index", "country", "document_id",
+			"validated", "anonymized_at").Updates(client).Error
+	})
+}
+
+// scoped filtra pela coluna do criador quando creatorID é diferente de zero
+func (r *privacyRepositoryImpl) scoped(creatorID uint, column string) *gorm.DB {
+	if creatorID == 0 {
+		return r.db
+	}
+	return r.db.Where(column+" = ?", creatorID)
+}
+
+// identityQuery encontra os registros guardados só com o CPF ou o email do cliente, como as tentativas de checkout.
+// O CPF fica cifrado e é comparado pelo índice cego; o texto puro cobre linhas anteriores à criptografia.
+func (r *privacyRepositoryImpl) identityQuery(client *salesmodel.Client) *gorm.DB {
+	cpfIndex := salesmodel.CPFBlindIndex(client.CPF)
+	switch {
+	case client.CPF != "" && client.Email != "":
+		return r.db.Where("cpf_index = ? OR cpf = ?", cpfIndex, client.CPF).Or("email = ?", client.Email)
+	case client.CPF != "":
+		return r.db.Where("cpf_index = ? OR cpf = ?", cpfIndex, client.CPF)
+	case client.Email != "":
+		return r.db.Where("email = ?", client.Email)
+	default:
+		return nil
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"log"
 	"log/slog"
+	"strings"
+	"time"
 
 	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
 	"github.com/anglesson/simple-web-server/pkg/database"
@@ -201,3 +203,139 @@ func (pr *PurchaseRepository) FindEbookByPurchaseHash(hashID string) (*salesmode
 
 	return &purchase, nil
 }
+
+// FindAwaitingReleaseReady busca as pré-vendas pagas cujo ebook já foi lançado
+// (data de lançamento atingida ou pré-venda desativada pelo criador).
+// Compras retidas para revisão do criador ficam de fora até serem liberadas, e as reembolsadas,
+// contestadas ou revogadas antes do lançamento nunca são liberadas.
+func (pr *PurchaseRepository) FindAwaitingReleaseReady(now time.Time) ([]*salesmodel.Purchase, error) {
+	var purchases []*salesmodel.Purchase
+	err := database.DB.
+		Preload("Client").
+		Preload("Ebook").
+		Preload("Ebook.Files").
+		Joins("JOIN ebooks ON ebooks.id = purchases.ebook_id").
+		Where("purchases.awaiting_release = ? AND purchases.status = ?", true, salesmodel.PurchaseStatusPaid).
+		Where("purchases.held_for_review = ?", false).
+		Where("ebooks.pre_order = ? OR ebooks.release_at IS NULL OR ebooks.release_at <= ?", false, now).
+		Find(&purchases).Error
+	if err != nil {
+		slog.Error("Erro ao buscar pré-vendas para liberação", "error", err)
+		return nil, errors.New("erro ao buscar pré-vendas para liberação")
+	}
+	return purchases, nil
+}
+
+// SetAwaitingRelease altera apenas a espera pelo lançamento, sem sobrescrever o restante da compra
+func (pr *PurchaseRepository) SetAwaitingRelease(purchaseID uint, awaiting bool) error {
+	err := database.DB.Model(&salesmodel.Purchase{}).
+		Where("id = ?", purchaseID).
+		Update("awaiting_release", awaiting).Error
+	if err != nil {
+		slog.Error("Erro ao atualizar liberação da pré-venda", "purchaseID", purchaseID, "error", err)
+		return errors.New("erro ao atualizar liberação da pré-venda")
+	}
+	return nil
+}
+
+// SetHeldForReview retém ou libera a entrega da compra enquanto o criador revisa o checkout suspeito
+func (pr *PurchaseRepository) SetHeldForReview(purchaseID uint, held bool) error {
+	err := database.DB.Model(&salesmodel.Purchase{}).
+		Where("id = ?", purchaseID).
+		Update("held_for_review", held).Error
+	if err != nil {
+		slog.Error("Erro ao atualizar retenção da compra", "purchaseID", purchaseID, "error", err)
+		return errors.New("erro ao atualizar retenção da compra")
+	}
+	return nil
+}
+
+// SetCheckoutSession registra a sessão de checkout do Stripe aberta por último para a compra
+func (pr *PurchaseRepository) SetCheckoutSession(purchaseID uint, sessionID string) error {
+	err := database.DB.Model(&salesmodel.Purchase{}).
+		Where("id = ?", purchaseID).
+		Update("checkout_session_id", sessionID).Error
+	if err != nil {
+		slog.Error("Erro ao registrar sessão de checkout da compra", "purchaseID", purchaseID, "error", err)
+		return errors.New("erro ao registrar sessão de checkout da compra")
+	}
+	return nil
+}
+
+// FindDeliverableByBuyer busca as compras pagas e liberadas do comprador com o CPF e email informados.
+// Compras reembolsadas, contestadas, bloqueadas ou revogadas mantêm o pagamento confirmado, mas saem pelo status.
+// O email é comparado sem diferenciar maiúsculas para aceitar o endereço como o comprador o digita.
+func (pr *PurchaseRepository) FindDeliverableByBuyer(cpf, email string) ([]*salesmodel.Purchase, error) {
+	var purchases []*salesmodel.Purchase
+	err := database.DB.
+		Preload("Client").
+		Preload("Ebook").
+		Preload("Ebook.Files").
+		Joins("JOIN clients ON clients.id = purchases.client_id").
+		Where("(clients.cpf_index = ? OR clients.cpf = ?) AND LOWER(clients.email) = ? AND clients.deleted_at IS NULL",
+			salesmodel.CPFBlindIndex(cpf), cpf, strings.ToLower(email)).
+		Where("purchases.payment_status = ? AND purchases.status = ?", salesmodel.PaymentStatusConfirmed, salesmodel.PurchaseStatusPaid).
+		Where("purchases.awaiting_release = ? AND purchases.held_for_review = ?", false, false).
+		Order("purchases.created_at DESC").
+		Find(&purchases).Error
+	if err != nil {
+		slog.Error("Erro ao buscar compras do comprador", "error", err)
+		return nil, errors.New("erro ao buscar compras do comprador")
+	}
+	return purchases, nil
+}
+
+// ErrPurchaseStatusChanged indica que o status da compra mudou entre a leitura e a atualização
+var ErrPurchaseStatusChanged = errors.New("o status da compra foi alterado por outra operação")
+
+// TransitionStatus aplica a mudança de status registrada no histórico junto com os campos extras,
+// na mesma transação. A atualização só ocorre se a compra ainda estiver no status de origem,
+// evitando que webhooks repetidos ou concorrentes registrem a mesma mudança duas vezes.
+func (pr *PurchaseRepository) TransitionStatus(history *salesmodel.PurchaseStatusHistory, fields map[string]interface{}) error {
+	updates := map[string]interface{}{"status": history.ToStatus}
+	for column, value := range fields {
+		updates[column] = value
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&salesmodel.Purchase{}).
+			Where("id = ? AND status = ?", history.PurchaseID, history.FromStatus).
+			Updates(updates)
+		if result.Error != nil {
+			slog.Error("Erro ao atualizar status da compra", "purchaseID", history.PurchaseID, "error", result.Error)
+			return errors.New("erro ao atualizar status da compra")
+		}
+		if result.RowsAffected == 0 {
+			return ErrPurchaseStatusChanged
+		}
+		return tx.Create(history).Error
+	})
+}
+
+// FindStatusHistory lista as mudanças de status da compra da mais recente para a mais antiga
+func (pr *PurchaseRepository) FindStatusHistory(purchaseID uint) ([]*salesmodel.PurchaseStatusHistory, error) {
+	var history []*salesmodel.PurchaseStatusHistory
+	err := database.DB.
+		Where("purchase_id = ?", purchaseID).
+		Order("created_at DESC, id DESC").
+		Find(&history).Error
+	if err != nil {
+		slog.Error("Erro ao buscar histórico de status", "purchaseID", purchaseID, "error", err)
+		return nil, errors.New("erro ao buscar histórico de status")
+	}
+	return history, nil
+}
+
+// FindIDByPaymentIntent busca a compra pela transação do PaymentIntent, usada pelos eventos
+// de reembolso e contestação que não trazem o purchase_id nos metadados
+func (pr *PurchaseRepository) FindIDByPaymentIntent(paymentIntentID string) (uint, error) {
+	var transaction salesmodel.Transaction
+	err := database.DB.
+		Select("purchase_id").
+		Where("stripe_payment_intent_id = ?", paymentIntentID).
+		First(&transaction).Error
+	if err != nil {
+		return 0, err
+	}
+	return transaction.PurchaseID, nil
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"gorm.io/gorm"
+)
+
+type ReconciliationRepository interface {
+	CreateRun(run *salesmodel.ReconciliationRun) error
+	FindTransactionsByPaymentIntents(paymentIntentIDs []string) (map[string]*salesmodel.Transaction, error)
+	FindPendingTransactions(createdFrom, createdBefore time.Time) ([]*salesmodel.Transaction, error)
+	FindConnectedAccounts() (map[string]uint, error)
+}
+
+type reconciliationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewReconciliationRepository(db *gorm.DB) ReconciliationRepository {
+	return &reconciliationRepositoryImpl{
+		db: db,
+	}
+}
+
+// CreateRun grava a execução junto com as divergências encontradas
+func (r *reconciliationRepositoryImpl) CreateRun(run *salesmodel.ReconciliationRun) error {
+	return r.db.Create(run).Error
+}
+
+func (r *reconciliationRepositoryImpl) FindTransactionsByPaymentIntents(paymentIntentIDs []string) (map[string]*salesmodel.Transaction, error) {
+	result := make(map[string]*salesmodel.Transaction)
+	if len(paymentIntentIDs) == 0 {
+		return result, nil
+	}
+
+	var transactions []*salesmodel.Transaction
+	err := r.db.Preload("Purchase").Preload("Purchase.Ebook").Preload("Purchase.Client").
+		Where("stripe_payment_intent_id IN ?", paymentIntentIDs).
+		Find(&transactions).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, transaction := range transactions {
+		result[transaction.StripePaymentIntentID] = transaction
+	}
+	return result, nil
+}
+
+// FindPendingTransactions lista as transações ainda pendentes criadas no intervalo
+func (r *reconciliationRepositoryImpl) FindPendingTransactions(createdFrom, createdBefore time.Time) ([]*salesmodel.Transaction, error) {
+	var transactions []*salesmodel.Transaction
+	err := r.db.Where("status = ? AND created_at >= ? AND created_at < ?", salesmodel.TransactionStatusPending, createdFrom, createdBefore).
+		Find(&transactions).Error
+	return transactions, err
+}
+
+// FindConnectedAccounts mapeia cada conta Stripe Connect ao criador dono dela
+func (r *reconciliationRepositoryImpl) FindConnectedAccounts() (map[string]uint, error) {
+	var creators []*accountmodel.Creator
+	err := r.db.Select("id", "stripe_connect_account_id").
+		Where("stripe_connect_account_id <> ''").
+		Find(&creators).Error
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make(map[string]uint, len(creators))
+	for _, creator := range creators {
+		accounts[creator.StripeConnectAccountID] = creator.ID
+	}
+	return accounts, nil
+}
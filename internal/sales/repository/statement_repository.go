@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"time"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"gorm.io/gorm"
+)
+
+// statementPaidAt é a data usada para posicionar a venda no mês; transações antigas não têm processed_at
+const statementPaidAt = "COALESCE(transactions.processed_at, transactions.created_at)"
+
+type StatementRepository interface {
+	SalesByEbook(creatorID uint, from, to time.Time) ([]*salesmodel.StatementLine, error)
+	SplitsReceivedByEbook(creatorID uint, from, to time.Time) ([]*salesmodel.StatementLine, error)
+	RefundsByEbook(creatorID uint, from, to time.Time) ([]*salesmodel.StatementLine, error)
+	FindCreatorIDsWithActivity(from, to time.Time) ([]uint, error)
+	IsDelivered(creatorID uint, month time.Time) (bool, error)
+	CreateDelivery(delivery *salesmodel.MonthlyStatementDelivery) error
+}
+
+type statementRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewStatementRepository(db *gorm.DB) StatementRepository {
+	return &statementRepositoryImpl{
+		db: db,
+	}
+}
+
+// SalesByEbook soma as transações concluídas no período, por ebook e moeda
+func (r *statementRepositoryImpl) SalesByEbook(creatorID uint, from, to time.Time) ([]*salesmodel.StatementLine, error) {
+	var lines []*salesmodel.StatementLine
+	err := r.db.Model(&salesmodel.Transaction{}).
+		Select(`purchases.ebook_id, ebooks.title AS ebook_title, transactions.currency, COUNT(*) AS sales,
+			COALESCE(SUM(transactions.total_amount), 0) AS gross,
+			COALESCE(SUM(transactions.stripe_processing_fee), 0) AS processing_fee,
+			COALESCE(SUM(transactions.platform_amount), 0) AS platform_fee,
+			COALESCE(SUM(transactions.total_amount - transactions.stripe_processing_fee - transactions.platform_amount - transactions.creator_amount), 0) AS splits,
+			COALESCE(SUM(transactions.creator_amount), 0) AS net`).
+		Joins("JOIN purchases ON purchases.id = transactions.purchase_id").
+		Joins("JOIN ebooks ON ebooks.id = purchases.ebook_id").
+		Where("transactions.creator_id = ? AND transactions.status = ?", creatorID, salesmodel.TransactionStatusCompleted).
+		Where(statementPaidAt+" >= ? AND "+statementPaidAt+" < ?", from, to).
+		Group("purchases.ebook_id, ebooks.title, transactions.currency").
+		Order("ebooks.title").
+		Scan(&lines).Error
+	return lines, err
+}
+
+// SplitsReceivedByEbook soma, por ebook, moeda e tipo, os repasses recebidos pelo criador como coautor
+// ou afiliado nas vendas concluídas no período. Repasses cancelados (vendas manuais) não contam.
+func (r *statementRepositoryImpl) SplitsReceivedByEbook(creatorID uint, from, to time.Time) ([]*salesmodel.StatementLine, error) {
+	var lines []*salesmodel.StatementLine
+	err := r.db.Model(&salesmodel.TransactionSplit{}).
+		Select(`purchases.ebook_id, ebooks.title AS ebook_title, transaction_splits.currency,
+			transaction_splits.kind AS split_kind, COUNT(*) AS sales,
+			COALESCE(SUM(transaction_splits.amount), 0) AS gross,
+			COALESCE(SUM(transaction_splits.amount), 0) AS net`).
+		Joins("JOIN transactions ON transactions.id = transaction_splits.transaction_id").
+		Joins("JOIN purchases ON purchases.id = transactions.purchase_id").
+		Joins("JOIN ebooks ON ebooks.id = purchases.ebook_id").
+		Where("transaction_splits.creator_id = ? AND transaction_splits.status <> ?", creatorID, salesmodel.TransactionSplitStatusCanceled).
+		Where("transactions.status = ?", salesmodel.TransactionStatusCompleted).
+		Where(statementPaidAt+" >= ? AND "+statementPaidAt+" < ?", from, to).
+		Group("purchases.ebook_id, ebooks.title, transaction_splits.currency, transaction_splits.kind").
+		Order("ebooks.title").
+		Scan(&lines).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		line.EbookTitle += " (" + line.SplitKind.Label() + ")"
+	}
+	return lines, nil
+}
+
+// RefundsByEbook soma as compras reembolsadas ou contestadas no período, pela data da mudança de status
+func (r *statementRepositoryImpl) RefundsByEbook(creatorID uint, from, to time.Time) ([]*salesmodel.StatementLine, error) {
+	var lines []*salesmodel.StatementLine
+	err := r.db.Model(&salesmodel.PurchaseStatusHistory{}).
+		Select(`purchases.ebook_id, ebooks.title AS ebook_title, transactions.currency, COUNT(*) AS refunds,
+			COALESCE(SUM(transactions.total_amount), 0) AS refund_amount`).
+		Joins("JOIN purchases ON purchases.id = purchase_status_history.purchase_id").
+		Joins("JOIN ebooks ON ebooks.id = purchases.ebook_id").
+		Joins("JOIN transactions ON transactions.purchase_id = purchases.id AND transactions.status = ?", salesmodel.TransactionStatusCompleted).
+		Where("transactions.creator_id = ?", creatorID).
+		Where("purchase_status_history.to_status IN ?", []salesmodel.PurchaseStatus{salesmodel.PurchaseStatusRefunded, salesmodel.PurchaseStatusChargeback}).
+		Where("purchase_status_history.created_at >= ? AND purchase_status_history.created_at < ?", from, to).
+		Group("purchases.ebook_id, ebooks.title, transactions.currency").
+		Scan(&lines).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// O valor devolvido ao comprador sai do saldo do criador
+	for _, line := range lines {
+		line.Net = -line.RefundAmount
+	}
+	return lines, nil
+}
+
+// FindCreatorIDsWithActivity lista os criadores com vendas, repasses recebidos ou reembolsos no período
+func (r *statementRepositoryImpl) FindCreatorIDsWithActivity(from, to time.Time) ([]uint, error) {
+	var salesCreators []uint
+	err := r.db.Model(&salesmodel.Transaction{}).
+		Where("transactions.status = ?", salesmodel.TransactionStatusCompleted).
+		Where(statementPaidAt+" >= ? AND "+statementPaidAt+" < ?", from, to).
+		Distinct().Pluck("transactions.creator_id", &salesCreators).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var splitCreators []uint
+	err = r.db.Model(&salesmodel.TransactionSplit{}).
+		Joins("JOIN transactions ON transactions.id = transaction_splits.transaction_id").
+		Where("transaction_splits.status <> ? AND transactions.status = ?", salesmodel.TransactionSplitStatusCanceled, salesmodel.TransactionStatusCompleted).
+		Where(statementPaidAt+" >= ? AND "+statementPaidAt+" < ?", from, to).
+		Distinct().Pluck("transaction_splits.creator_id", &splitCreators).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var refundCreators []uint
+	err = r.db.Model(&salesmodel.PurchaseStatusHistory{}).
+		Joins("JOIN transactions ON transactions.purchase_id = purchase_status_history.purchase_id AND transactions.status = ?", salesmodel.TransactionStatusCompleted).
+		Where("purchase_status_history.to_status IN ?", []salesmodel.PurchaseStatus{salesmodel.PurchaseStatusRefunded, salesmodel.PurchaseStatusChargeback}).
+		Where("purchase_status_history.created_at >= ? AND purchase_status_history.created_at < ?", from, to).
+		Distinct().Pluck("transactions.creator_id", &refundCreators).Error
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[uint]bool)
+	var ids []uint
+	for _, id := range append(append(salesCreators, splitCreators...), refundCreators...) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (r *statementRepositoryImpl) IsDelivered(creatorID uint, month time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&salesmodel.MonthlyStatementDelivery{}).
+		Where("creator_id = ? AND month = ?", creatorID, month).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *statementRepositoryImpl) CreateDelivery(delivery *salesmodel.MonthlyStatementDelivery) error {
+	return r.db.Create(delivery).Error
+}
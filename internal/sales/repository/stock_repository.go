@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"errors"
+
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"gorm.io/gorm"
+)
+
+var errStockUnavailable = errors.New("estoque esgotado")
+
+// StockRepository controla as unidades reservadas das ofertas com estoque limitado.
+// As alterações são feitas com UPDATE condicional para que checkouts simultâneos
+// nunca ultrapassem o limite do ebook.
+type StockRepository interface {
+	Reserve(purchaseID, ebookID uint) (bool, error)
+	Release(purchaseID, ebookID uint) (bool, error)
+}
+
+type stockRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewStockRepository(db *gorm.DB) StockRepository {
+	return &stockRepositoryImpl{
+		db: db,
+	}
+}
+
+// Reserve ocupa uma unidade do estoque para a compra. Retorna false quando o estoque acabou.
+// A reserva é idempotente: uma compra que já possui reserva não consome outra unidade.
+func (r *stockRepositoryImpl) Reserve(purchaseID, ebookID uint) (bool, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&salesmodel.Purchase{}).
+			Where("id = ? AND stock_reserved = ?", purchaseID, false).
+			Update("stock_reserved", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		result = tx.Model(&librarymodel.Ebook{}).
+			Where("id = ? AND (stock_limit = 0 OR stock_used < stock_limit)", ebookID).
+			Update("stock_used", gorm.Expr("stock_used + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errStockUnavailable
+		}
+		return nil
+	})
+
+	if errors.Is(err, errStockUnavailable) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Release devolve ao estoque a unidade reservada por uma compra que não foi paga.
+// Retorna false quando não havia reserva a liberar.
+func (r *stockRepositoryImpl) Release(purchaseID, ebookID uint) (bool, error) {
+	released := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&salesmodel.Purchase{}).
+			Where("id = ? AND stock_reserved = ? AND payment_status <> ?", purchaseID, true, salesmodel.PaymentStatusConfirmed).
+			Update("stock_reserved", false)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		released = true
+		return tx.Model(&librarymodel.Ebook{}).
+			Where("id = ? AND stock_used > 0", ebookID).
+			Update("stock_used", gorm.Expr("stock_used - 1")).Error
+	})
+	if err != nil {
+		return false, err
+	}
+	return released, nil
+}
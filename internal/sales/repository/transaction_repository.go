@@ -3,6 +3,7 @@ package repository
 import (
 	"strconv"
 	"strings"
+	"time"
 
 	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
 	"gorm.io/gorm"
@@ -18,6 +19,9 @@ type TransactionRepository interface {
 	FindByCreatorIDWithFilters(creatorID uint, page, limit int, search, status string) ([]*salesmodel.Transaction, int64, error)
 	FindByPurchaseID(purchaseID uint) (*salesmodel.Transaction, error)
 	UpdateTransactionStatus(id uint, status salesmodel.TransactionStatus) error
+	UpdateSplit(split *salesmodel.TransactionSplit) error
+	FindSplitsByCreatorID(creatorID uint, kind salesmodel.TransactionSplitKind, page, limit int) ([]*salesmodel.TransactionSplit, int64, error)
+	FindDueSplits(now time.Time) ([]*salesmodel.TransactionSplit, error)
 }
 
 type transactionRepositoryImpl struct {
@@ -34,13 +38,68 @@ func (r *transactionRepositoryImpl) CreateTransaction(transaction *salesmodel.Tr
 	return r.db.Create(transaction).Error
 }
 
+// UpdateTransaction grava a transação e os valores recalculados dos repasses aos coautores
 func (r *transactionRepositoryImpl) UpdateTransaction(transaction *salesmodel.Transaction) error {
-	return r.db.Save(transaction).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Splits").Save(transaction).Error; err != nil {
+			return err
+		}
+		for i := range transaction.Splits {
+			transaction.Splits[i].TransactionID = transaction.ID
+			if err := tx.Omit(clause.Associations).Save(&transaction.Splits[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *transactionRepositoryImpl) UpdateSplit(split *salesmodel.TransactionSplit) error {
+	return r.db.Omit(clause.Associations).Save(split).Error
+}
+
+// FindSplitsByCreatorID lista os repasses recebidos pelo coautor ou afiliado, com a venda e o ebook
+func (r *transactionRepositoryImpl) FindSplitsByCreatorID(creatorID uint, kind salesmodel.TransactionSplitKind, page, limit int) ([]*salesmodel.TransactionSplit, int64, error) {
+	var splits []*salesmodel.TransactionSplit
+	var count int64
+
+	query := r.db.Model(&salesmodel.TransactionSplit{}).
+		Joins("JOIN transactions ON transactions.id = transaction_splits.transaction_id").
+		Where("transaction_splits.creator_id = ? AND transaction_splits.kind = ? AND transactions.status = ?", creatorID, kind, salesmodel.TransactionStatusCompleted)
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Transaction").Preload("Transaction.Purchase").Preload("Transaction.Purchase.Ebook").
+		Order("transaction_splits.created_at desc").
+		Offset((page - 1) * limit).Limit(limit).
+		Find(&splits).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return splits, count, nil
+}
+
+// FindDueSplits lista os repasses de vendas concluídas prontos para transferir: pendentes cuja carência
+// já terminou e recusados cuja próxima tentativa chegou. Vendas manuais não passam pelo Stripe e nunca
+// geram transferência
+func (r *transactionRepositoryImpl) FindDueSplits(now time.Time) ([]*salesmodel.TransactionSplit, error) {
+	var splits []*salesmodel.TransactionSplit
+	err := r.db.Joins("JOIN transactions ON transactions.id = transaction_splits.transaction_id").
+		Where("(transaction_splits.status = ? AND transaction_splits.available_at <= ?) OR (transaction_splits.status = ? AND transaction_splits.next_attempt_at <= ?)",
+			salesmodel.TransactionSplitStatusPending, now, salesmodel.TransactionSplitStatusFailed, now).
+		Where("transactions.status = ? AND transactions.off_platform = ?", salesmodel.TransactionStatusCompleted, false).
+		Preload("Creator").Preload("Transaction").Preload("Transaction.Purchase").
+		Order("transaction_splits.id asc").
+		Find(&splits).Error
+	return splits, err
 }
 
 func (r *transactionRepositoryImpl) FindByID(id uint) (*salesmodel.Transaction, error) {
 	var transaction salesmodel.Transaction
-	err := r.db.Preload("Creator").Preload("Purchase").Preload("Purchase.Ebook").First(&transaction, id).Error
+	err := r.db.Preload("Creator").Preload("Purchase").Preload("Purchase.Ebook").Preload("Purchase.Client").Preload("Splits").Preload("Splits.Creator").First(&transaction, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +108,7 @@ func (r *transactionRepositoryImpl) FindByID(id uint) (*salesmodel.Transaction,
 
 func (r *transactionRepositoryImpl) FindByPublicID(publicID string) (*salesmodel.Transaction, error) {
 	var transaction salesmodel.Transaction
-	err := r.db.Preload("Creator").Preload("Purchase").Preload("Purchase.Ebook").
+	err := r.db.Preload("Creator").Preload("Purchase").Preload("Purchase.Ebook").Preload("Purchase.Client").Preload("Splits").Preload("Splits.Creator").
 		Where("public_id = ?", publicID).First(&transaction).Error
 	if err != nil {
 		return nil, err
@@ -163,7 +222,7 @@ func (r *transactionRepositoryImpl) FindByCreatorIDWithFilters(creatorID uint, p
 
 func (r *transactionRepositoryImpl) FindByPurchaseID(purchaseID uint) (*salesmodel.Transaction, error) {
 	var transaction salesmodel.Transaction
-	err := r.db.Preload("Creator").Preload("Purchase").Preload("Purchase.Ebook").
+	err := r.db.Preload("Creator").Preload("Purchase").Preload("Purchase.Ebook").Preload("Purchase.Client").Preload("Splits").Preload("Splits.Creator").
 		Where("purchase_id = ?", purchaseID).
 		Order("created_at ASC").
 		First(&transaction).Error
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"time"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WaitlistRepository interface {
+	Create(entry *salesmodel.WaitlistEntry) (bool, error)
+	Update(entry *salesmodel.WaitlistEntry) error
+	FindByToken(token string) (*salesmodel.WaitlistEntry, error)
+	FindByEbookID(ebookID uint) ([]*salesmodel.WaitlistEntry, error)
+	FindNotConvertedByEbookID(ebookID uint) ([]*salesmodel.WaitlistEntry, error)
+	MarkConvertedByPurchaseID(purchaseID uint, convertedAt time.Time) error
+	GetStatsByEbookID(ebookID uint) (*salesmodel.WaitlistStats, error)
+}
+
+type waitlistRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewWaitlistRepository(db *gorm.DB) WaitlistRepository {
+	return &waitlistRepositoryImpl{
+		db: db,
+	}
+}
+
+// Create adiciona o email à lista de espera do ebook. Retorna false se o email já estava inscrito.
+func (r *waitlistRepositoryImpl) Create(entry *salesmodel.WaitlistEntry) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(entry)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *waitlistRepositoryImpl) Update(entry *salesmodel.WaitlistEntry) error {
+	return r.db.Save(entry).Error
+}
+
+func (r *waitlistRepositoryImpl) FindByToken(token string) (*salesmodel.WaitlistEntry, error) {
+	var entry salesmodel.WaitlistEntry
+	err := r.db.Where("token = ?", token).First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *waitlistRepositoryImpl) FindByEbookID(ebookID uint) ([]*salesmodel.WaitlistEntry, error) {
+	var entries []*salesmodel.WaitlistEntry
+	err := r.db.Where("ebook_id = ?", ebookID).Order("created_at DESC").Find(&entries).Error
+	return entries, err
+}
+
+func (r *waitlistRepositoryImpl) FindNotConvertedByEbookID(ebookID uint) ([]*salesmodel.WaitlistEntry, error) {
+	var entries []*salesmodel.WaitlistEntry
+	err := r.db.Where("ebook_id = ? AND converted_at IS NULL", ebookID).Order("created_at ASC").Find(&entries).Error
+	return entries, err
+}
+
+func (r *waitlistRepositoryImpl) MarkConvertedByPurchaseID(purchaseID uint, convertedAt time.Time) error {
+	return r.db.Model(&salesmodel.WaitlistEntry{}).
+		Where("purchase_id = ? AND converted_at IS NULL", purchaseID).
+		Update("converted_at", convertedAt).Error
+}
+
+func (r *waitlistRepositoryImpl) GetStatsByEbookID(ebookID uint) (*salesmodel.WaitlistStats, error) {
+	var stats salesmodel.WaitlistStats
+	err := r.db.Model(&salesmodel.WaitlistEntry{}).
+		Select(`COUNT(*) AS total,
+			COUNT(notified_at) AS notified,
+			COUNT(clicked_at) AS clicked,
+			COUNT(converted_at) AS converted`).
+		Where("ebook_id = ?", ebookID).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
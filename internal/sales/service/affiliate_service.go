@@ -0,0 +1,168 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	librarysvc "github.com/anglesson/simple-web-server/internal/library/service"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+)
+
+var ErrAffiliateProgramDisabled = errors.New("este e-book não tem programa de afiliados")
+var ErrAffiliateIsOwner = errors.New("o dono do e-book não pode ser afiliado dele")
+var ErrAffiliateWithoutStripe = errors.New("conclua o cadastro no Stripe Connect para receber as comissões")
+var ErrAffiliateBlocked = errors.New("sua afiliação a este e-book foi bloqueada pelo criador")
+var ErrAffiliateNotFound = errors.New("afiliado não encontrado")
+var ErrAffiliateInvalidCommission = errors.New("a comissão deve estar entre 1% e 90% (ou 0 para desativar)")
+
+// AffiliateSummary junta o afiliado aos números das vendas que ele indicou
+type AffiliateSummary struct {
+	*salesmodel.Affiliate
+	Stats *salesmodel.AffiliateStats
+}
+
+type AffiliateService interface {
+	Join(ebook *librarymodel.Ebook, creator *accountmodel.Creator) (*salesmodel.Affiliate, error)
+	RegisterClick(code, ebookPublicID string) (*salesmodel.Affiliate, error)
+	ResolveReferral(code string, ebook *librarymodel.Ebook, buyerEmail string) *salesmodel.Affiliate
+	SetCommission(ebook *librarymodel.Ebook, commission float64) error
+	SetBlocked(ebook *librarymodel.Ebook, publicID string, blocked bool) error
+	ListByEbook(ebookID uint) ([]*AffiliateSummary, error)
+	ListForCreator(creatorID uint) ([]*AffiliateSummary, error)
+}
+
+type affiliateServiceImpl struct {
+	affiliateRepo salesrepo.AffiliateRepository
+	ebookService  librarysvc.EbookService
+}
+
+func NewAffiliateService(affiliateRepo salesrepo.AffiliateRepository, ebookService librarysvc.EbookService) AffiliateService {
+	return &affiliateServiceImpl{
+		affiliateRepo: affiliateRepo,
+		ebookService:  ebookService,
+	}
+}
+
+// Join cadastra o criador como afiliado do ebook; quem já é afiliado recebe o cadastro existente
+func (s *affiliateServiceImpl) Join(ebook *librarymodel.Ebook, creator *accountmodel.Creator) (*salesmodel.Affiliate, error) {
+	if !ebook.HasAffiliateProgram() {
+		return nil, ErrAffiliateProgramDisabled
+	}
+	if ebook.CreatorID == creator.ID {
+		return nil, ErrAffiliateIsOwner
+	}
+	if creator.StripeConnectAccountID == "" || !creator.OnboardingCompleted {
+		return nil, ErrAffiliateWithoutStripe
+	}
+
+	existing, err := s.affiliateRepo.FindByEbookAndCreator(ebook.ID, creator.ID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar afiliação: %v", err)
+	}
+	if existing != nil {
+		if !existing.IsActive() {
+			return nil, ErrAffiliateBlocked
+		}
+		return existing, nil
+	}
+
+	affiliate := salesmodel.NewAffiliate(ebook.ID, creator.ID)
+	if err := s.affiliateRepo.Create(affiliate); err != nil {
+		return nil, fmt.Errorf("erro ao cadastrar afiliado: %v", err)
+	}
+	return affiliate, nil
+}
+
+// RegisterClick conta a visita à página de vendas pelo link do afiliado
+func (s *affiliateServiceImpl) RegisterClick(code, ebookPublicID string) (*salesmodel.Affiliate, error) {
+	affiliate, err := s.affiliateRepo.FindByCode(strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil || affiliate.Ebook.PublicID != ebookPublicID || !affiliate.IsActive() || !affiliate.Ebook.HasAffiliateProgram() {
+		return nil, ErrAffiliateNotFound
+	}
+
+	if err := s.affiliateRepo.IncrementClicks(affiliate.ID); err != nil {
+		return nil, fmt.Errorf("erro ao registrar clique do afiliado: %v", err)
+	}
+	return affiliate, nil
+}
+
+// ResolveReferral retorna o afiliado que deve receber a comissão da compra, ou nil quando a indicação
+// não vale: código de outro ebook, afiliado bloqueado, programa desativado ou o próprio afiliado comprando
+func (s *affiliateServiceImpl) ResolveReferral(code string, ebook *librarymodel.Ebook, buyerEmail string) *salesmodel.Affiliate {
+	if code == "" || !ebook.HasAffiliateProgram() {
+		return nil
+	}
+
+	affiliate, err := s.affiliateRepo.FindByCode(code)
+	if err != nil || affiliate.EbookID != ebook.ID || !affiliate.IsActive() {
+		return nil
+	}
+	if strings.EqualFold(affiliate.Creator.Email, strings.TrimSpace(buyerEmail)) {
+		return nil
+	}
+	if affiliate.Creator.StripeConnectAccountID == "" {
+		return nil
+	}
+	return affiliate
+}
+
+// SetCommission define a comissão do ebook; vale para as próximas vendas
+func (s *affiliateServiceImpl) SetCommission(ebook *librarymodel.Ebook, commission float64) error {
+	if commission != 0 && (commission < 0.01 || commission > 0.9) {
+		return ErrAffiliateInvalidCommission
+	}
+
+	ebook.AffiliateCommission = commission
+	return s.ebookService.Update(ebook)
+}
+
+func (s *affiliateServiceImpl) SetBlocked(ebook *librarymodel.Ebook, publicID string, blocked bool) error {
+	affiliate, err := s.affiliateRepo.FindByPublicID(publicID)
+	if err != nil || affiliate.EbookID != ebook.ID {
+		return ErrAffiliateNotFound
+	}
+
+	affiliate.Status = salesmodel.AffiliateStatusActive
+	if blocked {
+		affiliate.Status = salesmodel.AffiliateStatusBlocked
+	}
+	return s.affiliateRepo.Update(affiliate)
+}
+
+func (s *affiliateServiceImpl) ListByEbook(ebookID uint) ([]*AffiliateSummary, error) {
+	affiliates, err := s.affiliateRepo.FindByEbookID(ebookID)
+	if err != nil {
+		return nil, err
+	}
+	return s.summarize(affiliates)
+}
+
+func (s *affiliateServiceImpl) ListForCreator(creatorID uint) ([]*AffiliateSummary, error) {
+	affiliates, err := s.affiliateRepo.FindByCreatorID(creatorID)
+	if err != nil {
+		return nil, err
+	}
+	return s.summarize(affiliates)
+}
+
+func (s *affiliateServiceImpl) summarize(affiliates []*salesmodel.Affiliate) ([]*AffiliateSummary, error) {
+	ids := make([]uint, 0, len(affiliates))
+	for _, affiliate := range affiliates {
+		ids = append(ids, affiliate.ID)
+	}
+
+	stats, err := s.affiliateRepo.GetStats(ids)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao calcular vendas dos afiliados: %v", err)
+	}
+
+	summaries := make([]*AffiliateSummary, 0, len(affiliates))
+	for _, affiliate := range affiliates {
+		summaries = append(summaries, &AffiliateSummary{Affiliate: affiliate, Stats: stats[affiliate.ID]})
+	}
+	return summaries, nil
+}
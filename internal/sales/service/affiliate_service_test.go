@@ -0,0 +1,143 @@
+package service_test
+
+import (
+	"testing"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	"github.com/anglesson/simple-web-server/internal/mocks"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupAffiliateTest(t *testing.T) (salesvc.AffiliateService, *mocks.MockEbookService, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&accountmodel.Creator{}, &librarymodel.Ebook{}, &salesmodel.Purchase{},
+		&salesmodel.Transaction{}, &salesmodel.TransactionSplit{}, &salesmodel.Affiliate{}))
+
+	ebookService := new(mocks.MockEbookService)
+	return salesvc.NewAffiliateService(salesrepo.NewAffiliateRepository(db), ebookService), ebookService, db
+}
+
+func createAffiliateCreator(t *testing.T, db *gorm.DB, email string, connected bool) *accountmodel.Creator {
+	t.Helper()
+	creator := &accountmodel.Creator{Name: email, Email: email}
+	if connected {
+		creator.StripeConnectAccountID = "acct_" + email
+		creator.OnboardingCompleted = true
+	}
+	require.NoError(t, db.Create(creator).Error)
+	return creator
+}
+
+func TestAffiliateService_JoinRules(t *testing.T) {
+	service, _, db := setupAffiliateTest(t)
+	owner := createAffiliateCreator(t, db, "dono@test.com", true)
+	ana := createAffiliateCreator(t, db, "ana@test.com", true)
+	semStripe := createAffiliateCreator(t, db, "sem@test.com", false)
+	ebook := &librarymodel.Ebook{Title: "Ebook", CreatorID: owner.ID, Status: true}
+	require.NoError(t, db.Create(ebook).Error)
+
+	_, err := service.Join(ebook, ana)
+	assert.ErrorIs(t, err, salesvc.ErrAffiliateProgramDisabled)
+
+	ebook.AffiliateCommission = 0.3
+	_, err = service.Join(ebook, owner)
+	assert.ErrorIs(t, err, salesvc.ErrAffiliateIsOwner)
+	_, err = service.Join(ebook, semStripe)
+	assert.ErrorIs(t, err, salesvc.ErrAffiliateWithoutStripe)
+
+	affiliate, err := service.Join(ebook, ana)
+	require.NoError(t, err)
+	assert.Len(t, affiliate.Code, 10)
+
+	again, err := service.Join(ebook, ana)
+	require.NoError(t, err)
+	assert.Equal(t, affiliate.ID, again.ID)
+
+	require.NoError(t, service.SetBlocked(ebook, affiliate.PublicID, true))
+	_, err = service.Join(ebook, ana)
+	assert.ErrorIs(t, err, salesvc.ErrAffiliateBlocked)
+}
+
+func TestAffiliateService_ClicksAndReferral(t *testing.T) {
+	service, _, db := setupAffiliateTest(t)
+	owner := createAffiliateCreator(t, db, "dono@test.com", true)
+	ana := createAffiliateCreator(t, db, "ana@test.com", true)
+	ebook := &librarymodel.Ebook{Title: "Ebook", CreatorID: owner.ID, Status: true, AffiliateCommission: 0.3}
+	other := &librarymodel.Ebook{Title: "Outro", CreatorID: owner.ID, Status: true, AffiliateCommission: 0.3}
+	require.NoError(t, db.Create(ebook).Error)
+	require.NoError(t, db.Create(other).Error)
+
+	affiliate, err := service.Join(ebook, ana)
+	require.NoError(t, err)
+
+	_, err = service.RegisterClick(affiliate.Code, other.PublicID)
+	assert.ErrorIs(t, err, salesvc.ErrAffiliateNotFound)
+	_, err = service.RegisterClick(affiliate.Code, ebook.PublicID)
+	require.NoError(t, err)
+
+	summaries, err := service.ListByEbook(ebook.ID)
+	require.NoError(t, err)
+	require.Len(t, summaries, 1)
+	assert.Equal(t, int64(1), summaries[0].Clicks)
+
+	assert.NotNil(t, service.ResolveReferral(affiliate.Code, ebook, "cliente@test.com"))
+	assert.Nil(t, service.ResolveReferral(affiliate.Code, other, "cliente@test.com"), "código de outro ebook")
+	assert.Nil(t, service.ResolveReferral(affiliate.Code, ebook, "ANA@test.com"), "o afiliado comprando pelo próprio link")
+
+	ebook.AffiliateCommission = 0
+	assert.Nil(t, service.ResolveReferral(affiliate.Code, ebook, "cliente@test.com"), "programa desativado")
+}
+
+func TestAffiliateService_StatsCountCompletedSales(t *testing.T) {
+	service, _, db := setupAffiliateTest(t)
+	owner := createAffiliateCreator(t, db, "dono@test.com", true)
+	ana := createAffiliateCreator(t, db, "ana@test.com", true)
+	ebook := &librarymodel.Ebook{Title: "Ebook", CreatorID: owner.ID, Status: true, AffiliateCommission: 0.3}
+	require.NoError(t, db.Create(ebook).Error)
+	affiliate, err := service.Join(ebook, ana)
+	require.NoError(t, err)
+
+	createSale := func(status salesmodel.TransactionStatus, splitStatus salesmodel.TransactionSplitStatus, amount int64) {
+		transaction := &salesmodel.Transaction{CreatorID: owner.ID, Status: status, Currency: money.BRL}
+		transaction.AddAffiliateSplit(ana.ID, affiliate.ID, 0.3)
+		transaction.Splits[0].Amount = amount
+		transaction.Splits[0].Currency = money.BRL
+		transaction.Splits[0].Status = splitStatus
+		require.NoError(t, db.Create(transaction).Error)
+	}
+	createSale(salesmodel.TransactionStatusCompleted, salesmodel.TransactionSplitStatusTransferred, 1000)
+	createSale(salesmodel.TransactionStatusCompleted, salesmodel.TransactionSplitStatusPending, 500)
+	createSale(salesmodel.TransactionStatusCompleted, salesmodel.TransactionSplitStatusCanceled, 700)
+	createSale(salesmodel.TransactionStatusPending, salesmodel.TransactionSplitStatusPending, 900)
+
+	summaries, err := service.ListForCreator(ana.ID)
+	require.NoError(t, err)
+	require.Len(t, summaries, 1)
+	assert.Equal(t, int64(2), summaries[0].Stats.Sales)
+	assert.Equal(t, "R$ 10,00", summaries[0].Stats.GetFormattedPaid())
+	assert.Equal(t, "R$ 5,00", summaries[0].Stats.GetFormattedPending())
+}
+
+func TestAffiliateService_SetCommission(t *testing.T) {
+	service, ebookService, _ := setupAffiliateTest(t)
+	ebook := &librarymodel.Ebook{Title: "Ebook"}
+
+	assert.ErrorIs(t, service.SetCommission(ebook, 0.95), salesvc.ErrAffiliateInvalidCommission)
+	ebookService.AssertNotCalled(t, "Update", ebook)
+
+	ebookService.On("Update", ebook).Return(nil).Twice()
+	require.NoError(t, service.SetCommission(ebook, 0.25))
+	assert.Equal(t, "25%", ebook.GetAffiliateCommissionPercent())
+	require.NoError(t, service.SetCommission(ebook, 0))
+	assert.False(t, ebook.HasAffiliateProgram())
+}
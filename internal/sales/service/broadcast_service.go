@@ -0,0 +1,286 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+)
+
+const (
+	maxBroadcastSubjectLength = 150
+	maxBroadcastBodyLength    = 100000
+	recentBroadcastsLimit     = 30
+	broadcastDeliveriesLimit  = 200
+	broadcastBatchSize        = 100
+	// Um broadcast em envio sem progresso por esse tempo volta para a fila
+	broadcastStaleAfter = 10 * time.Minute
+)
+
+var (
+	ErrBroadcastInvalid     = errors.New("dados inválidos")
+	ErrBroadcastNotFound    = errors.New("email não encontrado")
+	ErrUnsubscribeNotFound  = errors.New("link de descadastro inválido")
+	ErrBroadcastNoRecipient = errors.New("nenhum cliente com email no público escolhido")
+)
+
+type BroadcastService interface {
+	Create(creatorID uint, subject, body, audience string, rules salesmodel.SegmentRules) (*salesmodel.Broadcast, error)
+	ListRecent(creatorID uint) ([]*salesmodel.Broadcast, error)
+	FindByPublicID(creatorID uint, publicID string) (*salesmodel.Broadcast, error)
+	FindDeliveries(broadcast *salesmodel.Broadcast) ([]*salesmodel.BroadcastDelivery, error)
+	CountUnsubscribed(broadcast *salesmodel.Broadcast) (int64, error)
+	FindByUnsubscribeToken(token string) (*salesmodel.Broadcast, error)
+	Unsubscribe(token string) (*salesmodel.Broadcast, error)
+	ProcessQueued() (int, error)
+}
+
+type broadcastServiceImpl struct {
+	broadcastRepo salesrepo.BroadcastRepository
+	crmService    ClientCRMService
+	emailService  IEmailService
+}
+
+// NewBroadcastService recebe o IEmailService dos envios em massa, cujo mailer limita a taxa de envio
+func NewBroadcastService(
+	broadcastRepo salesrepo.BroadcastRepository,
+	crmService ClientCRMService,
+	emailService IEmailService,
+) BroadcastService {
+	return &broadcastServiceImpl{
+		broadcastRepo: broadcastRepo,
+		crmService:    crmService,
+		emailService:  emailService,
+	}
+}
+
+// Create valida o email e coloca na fila um envio para cada cliente do público com email.
+// O público é resolvido agora; clientes que chegarem depois não recebem este broadcast.
+func (s *broadcastServiceImpl) Create(creatorID uint, subject, body, audience string, rules salesmodel.SegmentRules) (*salesmodel.Broadcast, error) {
+	subject = strings.Join(strings.Fields(subject), " ")
+	if subject == "" || utf8.RuneCountInString(subject) > maxBroadcastSubjectLength {
+		return nil, fmt.Errorf("%w: o assunto deve ter entre 1 e %d caracteres", ErrBroadcastInvalid, maxBroadcastSubjectLength)
+	}
+	body = strings.TrimSpace(body)
+	if body == "" || utf8.RuneCountInString(body) > maxBroadcastBodyLength {
+		return nil, fmt.Errorf("%w: escreva a mensagem do email", ErrBroadcastInvalid)
+	}
+
+	clients, err := s.crmService.SegmentClients(creatorID, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]*salesmodel.BroadcastDelivery, 0, len(clients))
+	for _, client := range clients {
+		if client.Email == "" {
+			continue
+		}
+		deliveries = append(deliveries, &salesmodel.BroadcastDelivery{
+			ClientID: client.ID,
+			Name:     client.Name,
+			Email:    client.Email,
+			Status:   salesmodel.BroadcastDeliveryPending,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil, ErrBroadcastNoRecipient
+	}
+
+	broadcast := &salesmodel.Broadcast{
+		CreatorID:  creatorID,
+		Subject:    subject,
+		Body:       body,
+		Audience:   audience,
+		Rules:      rules,
+		Status:     salesmodel.BroadcastStatusQueued,
+		Recipients: len(deliveries),
+	}
+	if err := s.broadcastRepo.Create(broadcast, deliveries); err != nil {
+		return nil, err
+	}
+	return broadcast, nil
+}
+
+func (s *broadcastServiceImpl) ListRecent(creatorID uint) ([]*salesmodel.Broadcast, error) {
+	return s.broadcastRepo.FindRecentByCreatorID(creatorID, recentBroadcastsLimit)
+}
+
+func (s *broadcastServiceImpl) FindByPublicID(creatorID uint, publicID string) (*salesmodel.Broadcast, error) {
+	broadcast, err := s.broadcastRepo.FindByPublicID(publicID)
+	if err != nil {
+		return nil, err
+	}
+	if broadcast == nil || broadcast.CreatorID != creatorID {
+		return nil, ErrBroadcastNotFound
+	}
+	return broadcast, nil
+}
+
+func (s *broadcastServiceImpl) FindDeliveries(broadcast *salesmodel.Broadcast) ([]*salesmodel.BroadcastDelivery, error) {
+	return s.broadcastRepo.FindDeliveries(broadcast.ID, broadcastDeliveriesLimit)
+}
+
+// CountUnsubscribed conta os clientes que se descadastraram pelo link deste broadcast
+func (s *broadcastServiceImpl) CountUnsubscribed(broadcast *salesmodel.Broadcast) (int64, error) {
+	return s.broadcastRepo.CountUnsubscribed(broadcast.ID)
+}
+
+// FindByUnsubscribeToken retorna o broadcast do link de descadastro, com o criador carregado
+func (s *broadcastServiceImpl) FindByUnsubscribeToken(token string) (*salesmodel.Broadcast, error) {
+	_, broadcast, err := s.findByToken(token)
+	return broadcast, err
+}
+
+// Unsubscribe descadastra o cliente dos emails em massa do criador que enviou o broadcast
+func (s *broadcastServiceImpl) Unsubscribe(token string) (*salesmodel.Broadcast, error) {
+	delivery, broadcast, err := s.findByToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.broadcastRepo.Unsubscribe(&salesmodel.EmailUnsubscribe{
+		CreatorID:   broadcast.CreatorID,
+		ClientID:    delivery.ClientID,
+		BroadcastID: &broadcast.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return broadcast, nil
+}
+
+func (s *broadcastServiceImpl) findByToken(token string) (*salesmodel.BroadcastDelivery, *salesmodel.Broadcast, error) {
+	if token == "" {
+		return nil, nil, ErrUnsubscribeNotFound
+	}
+	delivery, err := s.broadcastRepo.FindDeliveryByToken(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if delivery == nil {
+		return nil, nil, ErrUnsubscribeNotFound
+	}
+	broadcast, err := s.broadcastRepo.FindByID(delivery.BroadcastID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if broadcast == nil {
+		return nil, nil, ErrUnsubscribeNotFound
+	}
+	return delivery, broadcast, nil
+}
+
+// ProcessQueued envia os broadcasts da fila. Retorna quantos foram concluídos.
+func (s *broadcastServiceImpl) ProcessQueued() (int, error) {
+	requeued, err := s.broadcastRepo.RequeueStale(time.Now().Add(-broadcastStaleAfter))
+	if err != nil {
+		return 0, err
+	}
+	if requeued > 0 {
+		slog.Warn("Broadcasts interrompidos voltaram para a fila", "count", requeued)
+	}
+
+	queued, err := s.broadcastRepo.FindQueued()
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for _, broadcast := range queued {
+		if broadcast.StartedAt == nil {
+			now := time.Now()
+			broadcast.StartedAt = &now
+		}
+		claimed, err := s.broadcastRepo.Claim(broadcast)
+		if err != nil {
+			return processed, err
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := s.send(broadcast); err != nil {
+			slog.Error("Erro ao enviar broadcast", "broadcast", broadcast.PublicID, "error", err)
+			broadcast.Status = salesmodel.BroadcastStatusFailed
+			broadcast.ErrorMessage = err.Error()
+		} else if broadcast.Sent == 0 && broadcast.Failed > 0 {
+			broadcast.Status = salesmodel.BroadcastStatusFailed
+			broadcast.ErrorMessage = "nenhum email foi entregue ao servidor de envio"
+		} else {
+			broadcast.Status = salesmodel.BroadcastStatusSent
+		}
+
+		finishedAt := time.Now()
+		broadcast.FinishedAt = &finishedAt
+		if err := s.broadcastRepo.Save(broadcast); err != nil {
+			return processed, err
+		}
+		processed++
+	}
+	return processed, nil
+}
+
+// send entrega os emails pendentes em lotes. O descadastro é conferido a cada envio,
+// para valer também para quem se descadastrou durante um envio longo.
+func (s *broadcastServiceImpl) send(broadcast *salesmodel.Broadcast) error {
+	for {
+		deliveries, err := s.broadcastRepo.FindPendingDeliveries(broadcast.ID, broadcastBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		for _, delivery := range deliveries {
+			unsubscribed, err := s.broadcastRepo.IsUnsubscribed(broadcast.CreatorID, delivery.ClientID)
+			if err != nil {
+				return err
+			}
+
+			if unsubscribed {
+				delivery.Status = salesmodel.BroadcastDeliverySkipped
+				broadcast.Skipped++
+			} else if err := s.emailService.SendBroadcast(broadcast, delivery); err != nil {
+				delivery.Status = salesmodel.BroadcastDeliveryFailed
+				delivery.Error = err.Error()
+				broadcast.Failed++
+			} else {
+				sentAt := time.Now()
+				delivery.Status = salesmodel.BroadcastDeliverySent
+				delivery.SentAt = &sentAt
+				broadcast.Sent++
+			}
+
+			if err := s.broadcastRepo.SaveDelivery(delivery); err != nil {
+				return err
+			}
+			if err := s.broadcastRepo.Save(broadcast); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// StartBroadcastJob envia os broadcasts da fila em segundo plano
+func StartBroadcastJob(broadcastService BroadcastService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			processed, err := broadcastService.ProcessQueued()
+			if err != nil {
+				slog.Error("Erro ao processar broadcasts", "error", err)
+				continue
+			}
+			if processed > 0 {
+				slog.Info("Broadcasts enviados", "count", processed)
+			}
+		}
+	}()
+}
@@ -0,0 +1,126 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/anglesson/simple-web-server/internal/mocks"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupBroadcast(t *testing.T) (*clientCRMFixture, salesvc.BroadcastService, *mocks.MockSalesEmailService) {
+	t.Helper()
+	f := setupClientCRM(t)
+	require.NoError(t, f.db.AutoMigrate(&salesmodel.Broadcast{}, &salesmodel.BroadcastDelivery{}, &salesmodel.EmailUnsubscribe{}))
+	email := new(mocks.MockSalesEmailService)
+	service := salesvc.NewBroadcastService(salesrepo.NewBroadcastRepository(f.db), f.service, email)
+	return f, service, email
+}
+
+func deliveryOf(t *testing.T, f *clientCRMFixture, broadcast *salesmodel.Broadcast, client *salesmodel.Client) *salesmodel.BroadcastDelivery {
+	t.Helper()
+	var delivery salesmodel.BroadcastDelivery
+	require.NoError(t, f.db.Where("broadcast_id = ? AND client_id = ?", broadcast.ID, client.ID).First(&delivery).Error)
+	return &delivery
+}
+
+func TestBroadcastService_CreateValidation(t *testing.T) {
+	f, service, _ := setupBroadcast(t)
+	rules := salesmodel.SegmentRules{PurchasedEbookIDs: []uint{f.volume1.ID}}
+
+	_, err := service.Create(f.creator.ID, "   ", "<p>Olá</p>", "Todos", rules)
+	assert.ErrorIs(t, err, salesvc.ErrBroadcastInvalid)
+
+	_, err = service.Create(f.creator.ID, "Novidade", "  ", "Todos", rules)
+	assert.ErrorIs(t, err, salesvc.ErrBroadcastInvalid)
+
+	_, err = service.Create(f.creator.ID, "Novidade", "<p>Olá</p>", "Compradores de Volume 1", rules)
+	assert.ErrorIs(t, err, salesvc.ErrBroadcastNoRecipient)
+}
+
+// TestBroadcastService_ProcessQueued verifica a contagem de envios, falhas e descadastros
+func TestBroadcastService_ProcessQueued(t *testing.T) {
+	f, service, email := setupBroadcast(t)
+	f.paidPurchase(t, f.ana, f.volume1, 5000)
+	f.paidPurchase(t, f.bruno, f.volume1, 5000)
+	f.paidPurchase(t, f.carla, f.volume1, 5000)
+	rules := salesmodel.SegmentRules{PurchasedEbookIDs: []uint{f.volume1.ID}}
+
+	first, err := service.Create(f.creator.ID, "  Saiu a   segunda edição ", "<p>Olá</p>", "Compradores de Volume 1", rules)
+	require.NoError(t, err)
+	assert.Equal(t, "Saiu a segunda edição", first.Subject)
+	assert.Equal(t, 3, first.Recipients)
+	assert.Equal(t, salesmodel.BroadcastStatusQueued, first.Status)
+
+	email.On("SendBroadcast", mock.Anything, mock.MatchedBy(func(d *salesmodel.BroadcastDelivery) bool {
+		return d.ClientID == f.carla.ID
+	})).Return(errors.New("caixa cheia"))
+	email.On("SendBroadcast", mock.Anything, mock.Anything).Return(nil)
+
+	processed, err := service.ProcessQueued()
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+
+	first, err = service.FindByPublicID(f.creator.ID, first.PublicID)
+	require.NoError(t, err)
+	assert.Equal(t, salesmodel.BroadcastStatusSent, first.Status)
+	assert.Equal(t, 2, first.Sent)
+	assert.Equal(t, 1, first.Failed)
+	assert.Equal(t, 0, first.Pending())
+	assert.NotNil(t, first.FinishedAt)
+
+	failed := deliveryOf(t, f, first, f.carla)
+	assert.Equal(t, salesmodel.BroadcastDeliveryFailed, failed.Status)
+	assert.Equal(t, "caixa cheia", failed.Error)
+
+	// Ana se descadastra pelo link do primeiro email e fica de fora dos próximos
+	token := deliveryOf(t, f, first, f.ana).Token
+	found, err := service.Unsubscribe(token)
+	require.NoError(t, err)
+	assert.Equal(t, "Maria", found.Creator.Name)
+	_, err = service.Unsubscribe(token)
+	require.NoError(t, err)
+
+	unsubscribed, err := service.CountUnsubscribed(first)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), unsubscribed)
+
+	second, err := service.Create(f.creator.ID, "Última chamada", "<p>Olá</p>", "Compradores de Volume 1", rules)
+	require.NoError(t, err)
+	_, err = service.ProcessQueued()
+	require.NoError(t, err)
+
+	second, err = service.FindByPublicID(f.creator.ID, second.PublicID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, second.Sent)
+	assert.Equal(t, 1, second.Failed)
+	assert.Equal(t, 1, second.Skipped)
+	assert.Equal(t, salesmodel.BroadcastDeliverySkipped, deliveryOf(t, f, second, f.ana).Status)
+	email.AssertNumberOfCalls(t, "SendBroadcast", 5)
+}
+
+func TestBroadcastService_UnsubscribeInvalidToken(t *testing.T) {
+	_, service, _ := setupBroadcast(t)
+
+	_, err := service.FindByUnsubscribeToken("nao-existe")
+	assert.ErrorIs(t, err, salesvc.ErrUnsubscribeNotFound)
+	_, err = service.Unsubscribe("")
+	assert.ErrorIs(t, err, salesvc.ErrUnsubscribeNotFound)
+}
+
+func TestBroadcastService_FindByPublicIDOfAnotherCreator(t *testing.T) {
+	f, service, _ := setupBroadcast(t)
+	f.paidPurchase(t, f.ana, f.volume1, 5000)
+
+	broadcast, err := service.Create(f.creator.ID, "Novidade", "<p>Olá</p>", "Todos os clientes",
+		salesmodel.SegmentRules{PurchasedEbookIDs: []uint{f.volume1.ID}})
+	require.NoError(t, err)
+
+	_, err = service.FindByPublicID(f.creator.ID+1, broadcast.PublicID)
+	assert.ErrorIs(t, err, salesvc.ErrBroadcastNotFound)
+}
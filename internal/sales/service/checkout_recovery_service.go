@@ -0,0 +1,190 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	"gorm.io/gorm"
+)
+
+var ErrCheckoutRecoveryLinkExpired = errors.New("link de recuperação expirado")
+
+type CheckoutRecoveryService interface {
+	RegisterExpiredSession(purchaseID uint, stripeSessionID string, expiredAt time.Time) error
+	ProcessDueRecoveries(now time.Time) (int, error)
+	MarkRecovered(purchaseID uint) error
+	FindByToken(token string) (*salesmodel.CheckoutRecovery, error)
+	GetRecoveryStatsByCreatorID(creatorID uint) ([]*salesmodel.EbookRecoveryStats, error)
+	DiscountPercent() int64
+	StartScheduler(interval time.Duration)
+}
+
+type checkoutRecoveryServiceImpl struct {
+	recoveryRepo    salesrepo.CheckoutRecoveryRepository
+	purchaseService PurchaseService
+	emailService    IEmailService
+	schedule        []time.Duration
+	linkValidity    time.Duration
+	discountPercent int64
+}
+
+func NewCheckoutRecoveryService(
+	recoveryRepo salesrepo.CheckoutRecoveryRepository,
+	purchaseService PurchaseService,
+	emailService IEmailService,
+	schedule []time.Duration,
+	linkValidity time.Duration,
+	discountPercent int64,
+) CheckoutRecoveryService {
+	return &checkoutRecoveryServiceImpl{
+		recoveryRepo:    recoveryRepo,
+		purchaseService: purchaseService,
+		emailService:    emailService,
+		schedule:        schedule,
+		linkValidity:    linkValidity,
+		discountPercent: discountPercent,
+	}
+}
+
+// RegisterExpiredSession registra o abandono de checkout de uma compra pendente.
+// Se a compra já possui registro (ex.: o cliente abandonou novamente o link de recuperação),
+// apenas a sessão é atualizada para não reiniciar a sequência de emails.
+func (s *checkoutRecoveryServiceImpl) RegisterExpiredSession(purchaseID uint, stripeSessionID string, expiredAt time.Time) error {
+	purchase, err := s.purchaseService.GetPurchaseByID(purchaseID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar compra: %v", err)
+	}
+
+	if purchase.IsPaymentConfirmed() {
+		slog.Info("Sessão expirada para compra já confirmada, ignorando", "purchaseID", purchaseID)
+		return nil
+	}
+
+	existing, err := s.recoveryRepo.FindByPurchaseID(purchaseID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("erro ao buscar recuperação de checkout: %v", err)
+	}
+
+	if existing != nil {
+		existing.StripeSessionID = stripeSessionID
+		return s.recoveryRepo.Update(existing)
+	}
+
+	recovery := salesmodel.NewCheckoutRecovery(
+		purchase.ID,
+		purchase.EbookID,
+		purchase.ClientID,
+		purchase.Ebook.CreatorID,
+		stripeSessionID,
+		expiredAt,
+	)
+	recovery.SetLinkExpiry(s.schedule, s.linkValidity)
+
+	return s.recoveryRepo.Create(recovery)
+}
+
+// ProcessDueRecoveries envia os emails de recuperação cujo horário já chegou e retorna quantos foram enviados
+func (s *checkoutRecoveryServiceImpl) ProcessDueRecoveries(now time.Time) (int, error) {
+	if len(s.schedule) == 0 {
+		return 0, nil
+	}
+
+	recoveries, err := s.recoveryRepo.FindPendingWithEmailsSentBelow(len(s.schedule))
+	if err != nil {
+		return 0, fmt.Errorf("erro ao buscar checkouts abandonados: %v", err)
+	}
+
+	sent := 0
+	for _, recovery := range recoveries {
+		if recovery.Purchase.IsPaymentConfirmed() {
+			recovery.MarkRecovered(now)
+			if err := s.recoveryRepo.Update(recovery); err != nil {
+				slog.Error("Erro ao marcar checkout como recuperado", "recoveryID", recovery.ID, "error", err)
+			}
+			continue
+		}
+
+		dueAt, ok := recovery.NextEmailDueAt(s.schedule)
+		if !ok || now.Before(dueAt) {
+			continue
+		}
+
+		s.emailService.SendCheckoutRecovery(recovery, s.discountPercent)
+		recovery.RegisterEmailSent(now)
+		if err := s.recoveryRepo.Update(recovery); err != nil {
+			slog.Error("Erro ao atualizar recuperação de checkout", "recoveryID", recovery.ID, "error", err)
+			continue
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// MarkRecovered marca como recuperado o checkout abandonado da compra, se existir
+func (s *checkoutRecoveryServiceImpl) MarkRecovered(purchaseID uint) error {
+	recovery, err := s.recoveryRepo.FindByPurchaseID(purchaseID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if recovery.IsRecovered() {
+		return nil
+	}
+
+	recovery.MarkRecovered(time.Now())
+	return s.recoveryRepo.Update(recovery)
+}
+
+// FindByToken retorna a recuperação do link enviado por email. Links de checkouts já recuperados
+// ou cuja validade terminou resultam em ErrCheckoutRecoveryLinkExpired.
+func (s *checkoutRecoveryServiceImpl) FindByToken(token string) (*salesmodel.CheckoutRecovery, error) {
+	if token == "" {
+		return nil, fmt.Errorf("token de recuperação inválido")
+	}
+	recovery, err := s.recoveryRepo.FindByToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	// Registros anteriores à validade gravada seguem a sequência de emails atual
+	if recovery.TokenExpiresAt == nil {
+		recovery.SetLinkExpiry(s.schedule, s.linkValidity)
+	}
+	if !recovery.IsLinkValid(time.Now()) {
+		return nil, ErrCheckoutRecoveryLinkExpired
+	}
+	return recovery, nil
+}
+
+func (s *checkoutRecoveryServiceImpl) GetRecoveryStatsByCreatorID(creatorID uint) ([]*salesmodel.EbookRecoveryStats, error) {
+	return s.recoveryRepo.GetStatsByCreatorID(creatorID)
+}
+
+func (s *checkoutRecoveryServiceImpl) DiscountPercent() int64 {
+	return s.discountPercent
+}
+
+// StartScheduler processa periodicamente os emails de recuperação pendentes
+func (s *checkoutRecoveryServiceImpl) StartScheduler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for now := range ticker.C {
+			sent, err := s.ProcessDueRecoveries(now)
+			if err != nil {
+				slog.Error("Erro ao processar recuperação de checkouts", "error", err)
+				continue
+			}
+			if sent > 0 {
+				slog.Info("Emails de recuperação de checkout enviados", "total", sent)
+			}
+		}
+	}()
+}
@@ -0,0 +1,200 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	authmodel "github.com/anglesson/simple-web-server/internal/auth/model"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	"github.com/anglesson/simple-web-server/internal/mocks"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/database"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupCheckoutRecoveryTestDB(t *testing.T) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	err = db.AutoMigrate(
+		&authmodel.User{},
+		&accountmodel.Creator{},
+		&salesmodel.Client{},
+		&librarymodel.Ebook{},
+		&salesmodel.Purchase{},
+		&salesmodel.CheckoutRecovery{},
+	)
+	require.NoError(t, err)
+	database.DB = db
+}
+
+func createPendingPurchaseForRecovery(t *testing.T) *salesmodel.Purchase {
+	t.Helper()
+	client := &salesmodel.Client{Name: "Maria", CPF: "11122233344", Email: "maria@test.com", Phone: "11999999999"}
+	require.NoError(t, database.DB.Create(client).Error)
+
+	ebook := &librarymodel.Ebook{Title: "Ebook Recuperável", Value: money.FromCents(5000), Status: true, CreatorID: 7}
+	require.NoError(t, database.DB.Create(ebook).Error)
+
+	purchase := salesmodel.NewPurchase(ebook.ID, client.ID, "hash-recovery")
+	require.NoError(t, database.DB.Create(purchase).Error)
+	return purchase
+}
+
+func newCheckoutRecoveryServiceForTest(emailMock *mocks.MockSalesEmailService) salesvc.CheckoutRecoveryService {
+	purchaseService := salesvc.NewPurchaseService(salesrepo.NewPurchaseRepository(), emailMock)
+	return salesvc.NewCheckoutRecoveryService(
+		salesrepo.NewCheckoutRecoveryRepository(database.DB),
+		purchaseService,
+		emailMock,
+		[]time.Duration{time.Hour, 24 * time.Hour},
+		72*time.Hour,
+		10,
+	)
+}
+
+func TestCheckoutRecoveryService_SendsScheduledEmails(t *testing.T) {
+	setupCheckoutRecoveryTestDB(t)
+	purchase := createPendingPurchaseForRecovery(t)
+
+	emailMock := &mocks.MockSalesEmailService{}
+	emailMock.On("SendCheckoutRecovery", mock.Anything, int64(10)).Return()
+	svc := newCheckoutRecoveryServiceForTest(emailMock)
+
+	expiredAt := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, svc.RegisterExpiredSession(purchase.ID, "cs_expired", expiredAt))
+
+	sent, err := svc.ProcessDueRecoveries(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	// O segundo email só vence 24h após a expiração
+	sent, err = svc.ProcessDueRecoveries(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	sent, err = svc.ProcessDueRecoveries(expiredAt.Add(25 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	emailMock.AssertNumberOfCalls(t, "SendCheckoutRecovery", 2)
+}
+
+func TestCheckoutRecoveryService_RegisterExpiredSession_KeepsSequence(t *testing.T) {
+	setupCheckoutRecoveryTestDB(t)
+	purchase := createPendingPurchaseForRecovery(t)
+
+	emailMock := &mocks.MockSalesEmailService{}
+	emailMock.On("SendCheckoutRecovery", mock.Anything, mock.Anything).Return()
+	svc := newCheckoutRecoveryServiceForTest(emailMock)
+
+	require.NoError(t, svc.RegisterExpiredSession(purchase.ID, "cs_first", time.Now().Add(-2*time.Hour)))
+	_, err := svc.ProcessDueRecoveries(time.Now())
+	require.NoError(t, err)
+
+	require.NoError(t, svc.RegisterExpiredSession(purchase.ID, "cs_second", time.Now()))
+
+	var recoveries []salesmodel.CheckoutRecovery
+	require.NoError(t, database.DB.Find(&recoveries).Error)
+	require.Len(t, recoveries, 1)
+	assert.Equal(t, "cs_second", recoveries[0].StripeSessionID)
+	assert.Equal(t, 1, recoveries[0].EmailsSent)
+}
+
+func TestCheckoutRecoveryService_MarkRecovered_StopsEmailsAndCountsInStats(t *testing.T) {
+	setupCheckoutRecoveryTestDB(t)
+	purchase := createPendingPurchaseForRecovery(t)
+
+	emailMock := &mocks.MockSalesEmailService{}
+	svc := newCheckoutRecoveryServiceForTest(emailMock)
+
+	require.NoError(t, svc.RegisterExpiredSession(purchase.ID, "cs_expired", time.Now().Add(-2*time.Hour)))
+	require.NoError(t, svc.MarkRecovered(purchase.ID))
+
+	sent, err := svc.ProcessDueRecoveries(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+	emailMock.AssertNotCalled(t, "SendCheckoutRecovery", mock.Anything, mock.Anything)
+
+	stats, err := svc.GetRecoveryStatsByCreatorID(7)
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, "Ebook Recuperável", stats[0].EbookTitle)
+	assert.Equal(t, int64(1), stats[0].Abandoned)
+	assert.Equal(t, int64(1), stats[0].Recovered)
+}
+
+func TestCheckoutRecoveryService_IgnoresConfirmedPurchase(t *testing.T) {
+	setupCheckoutRecoveryTestDB(t)
+	purchase := createPendingPurchaseForRecovery(t)
+	require.NoError(t, database.DB.Model(purchase).Update("payment_status", salesmodel.PaymentStatusConfirmed).Error)
+
+	svc := newCheckoutRecoveryServiceForTest(&mocks.MockSalesEmailService{})
+	require.NoError(t, svc.RegisterExpiredSession(purchase.ID, "cs_paid", time.Now()))
+
+	var count int64
+	database.DB.Model(&salesmodel.CheckoutRecovery{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestCheckoutRecoveryService_MarkRecovered_WithoutRecovery(t *testing.T) {
+	setupCheckoutRecoveryTestDB(t)
+	svc := newCheckoutRecoveryServiceForTest(&mocks.MockSalesEmailService{})
+	assert.NoError(t, svc.MarkRecovered(999))
+}
+
+func TestCheckoutRecoveryService_FindByToken_RejectsExpiredAndRecoveredLinks(t *testing.T) {
+	setupCheckoutRecoveryTestDB(t)
+	purchase := createPendingPurchaseForRecovery(t)
+	svc := newCheckoutRecoveryServiceForTest(&mocks.MockSalesEmailService{})
+
+	require.NoError(t, svc.RegisterExpiredSession(purchase.ID, "cs_expired", time.Now().Add(-2*time.Hour)))
+	var recovery salesmodel.CheckoutRecovery
+	require.NoError(t, database.DB.First(&recovery).Error)
+	require.NotNil(t, recovery.Token)
+	require.NotNil(t, recovery.TokenExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(94*time.Hour), *recovery.TokenExpiresAt, time.Minute, "último email (24h) + 72h de validade")
+	token := *recovery.Token
+
+	found, err := svc.FindByToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, recovery.ID, found.ID)
+
+	// Validade encerrada
+	require.NoError(t, database.DB.Model(&recovery).Update("token_expires_at", time.Now().Add(-time.Minute)).Error)
+	_, err = svc.FindByToken(token)
+	assert.ErrorIs(t, err, salesvc.ErrCheckoutRecoveryLinkExpired)
+
+	// Checkout recuperado: o token deixa de existir
+	require.NoError(t, database.DB.Model(&recovery).Update("token_expires_at", time.Now().Add(time.Hour)).Error)
+	require.NoError(t, svc.MarkRecovered(purchase.ID))
+	var recovered salesmodel.CheckoutRecovery
+	require.NoError(t, database.DB.First(&recovered, recovery.ID).Error)
+	assert.Nil(t, recovered.Token)
+	_, err = svc.FindByToken(token)
+	assert.Error(t, err)
+}
+
+func TestCheckoutRecoveryService_MarkRecovered_AllowsSeveralInvalidatedTokens(t *testing.T) {
+	setupCheckoutRecoveryTestDB(t)
+	first := createPendingPurchaseForRecovery(t)
+	ebook := &librarymodel.Ebook{Title: "Outro Ebook", Value: money.FromCents(3000), Status: true, CreatorID: 7}
+	require.NoError(t, database.DB.Create(ebook).Error)
+	second := salesmodel.NewPurchase(ebook.ID, first.ClientID, "hash-recovery-2")
+	require.NoError(t, database.DB.Create(second).Error)
+
+	svc := newCheckoutRecoveryServiceForTest(&mocks.MockSalesEmailService{})
+	require.NoError(t, svc.RegisterExpiredSession(first.ID, "cs_1", time.Now()))
+	require.NoError(t, svc.RegisterExpiredSession(second.ID, "cs_2", time.Now()))
+
+	require.NoError(t, svc.MarkRecovered(first.ID))
+	require.NoError(t, svc.MarkRecovered(second.ID), "tokens nulos não conflitam no índice único")
+}
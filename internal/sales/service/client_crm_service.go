@@ -0,0 +1,254 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+)
+
+const (
+	maxClientTagLength  = 40
+	maxClientNoteLength = 2000
+	maxSegmentNameLen   = 80
+	maxInactiveDays     = 3650
+)
+
+var ErrClientCRMInvalid = errors.New("dados inválidos")
+var ErrCRMClientNotFound = errors.New("cliente não encontrado")
+var ErrSegmentNotFound = errors.New("segmento não encontrado")
+
+// ClientCRMService organiza os clientes do criador com etiquetas, anotações privadas e segmentos salvos.
+// Tudo é isolado por criador: um cliente pode comprar de vários criadores e cada um vê apenas os próprios dados.
+type ClientCRMService interface {
+	FindClient(creatorID uint, publicID string) (*salesmodel.Client, error)
+	FindPurchases(creatorID, clientID uint) ([]*salesmodel.Purchase, error)
+	TotalSpent(creatorID, clientID uint) (int64, error)
+
+	ListTags(creatorID uint) ([]*salesmodel.ClientTag, error)
+	TagsByClient(creatorID uint, clientIDs []uint) (map[uint][]*salesmodel.ClientTag, error)
+	TagClient(creatorID, clientID uint, tagName string) (*salesmodel.ClientTag, error)
+	UntagClient(creatorID, clientID, tagID uint) error
+	DeleteTag(creatorID, tagID uint) error
+
+	ListNotes(creatorID, clientID uint) ([]*salesmodel.ClientNote, error)
+	AddNote(creatorID, clientID uint, body string) (*salesmodel.ClientNote, error)
+	DeleteNote(creatorID, clientID, noteID uint) error
+
+	ListSegments(creatorID uint) ([]*salesmodel.ClientSegment, error)
+	FindSegment(creatorID uint, publicID string) (*salesmodel.ClientSegment, error)
+	CreateSegment(creatorID uint, name string, rules salesmodel.SegmentRules) (*salesmodel.ClientSegment, error)
+	DeleteSegment(creatorID uint, publicID string) error
+	SegmentClients(creatorID uint, rules salesmodel.SegmentRules) ([]salesmodel.Client, error)
+	GrantEbookToSegment(creatorID uint, segment *salesmodel.ClientSegment, ebookID uint) (int, error)
+}
+
+type clientCRMServiceImpl struct {
+	crmRepo         salesrepo.ClientCRMRepository
+	clientRepo      salesrepo.ClientRepository
+	purchaseService PurchaseService
+	emailService    IEmailService
+}
+
+func NewClientCRMService(
+	crmRepo salesrepo.ClientCRMRepository,
+	clientRepo salesrepo.ClientRepository,
+	purchaseService PurchaseService,
+	emailService IEmailService,
+) ClientCRMService {
+	return &clientCRMServiceImpl{
+		crmRepo:         crmRepo,
+		clientRepo:      clientRepo,
+		purchaseService: purchaseService,
+		emailService:    emailService,
+	}
+}
+
+// FindClient busca o cliente pelo id público, desde que ele seja cliente do criador
+func (s *clientCRMServiceImpl) FindClient(creatorID uint, publicID string) (*salesmodel.Client, error) {
+	client, err := s.clientRepo.FindByPublicID(publicID)
+	if err != nil || client == nil {
+		return nil, ErrCRMClientNotFound
+	}
+
+	owned, err := s.crmRepo.IsCreatorClient(creatorID, client.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !owned {
+		return nil, ErrCRMClientNotFound
+	}
+	// As compras carregadas pelo repositório incluem as de outros criadores
+	client.Purchases = nil
+	return client, nil
+}
+
+func (s *clientCRMServiceImpl) FindPurchases(creatorID, clientID uint) ([]*salesmodel.Purchase, error) {
+	return s.crmRepo.FindCreatorPurchases(creatorID, clientID)
+}
+
+func (s *clientCRMServiceImpl) TotalSpent(creatorID, clientID uint) (int64, error) {
+	return s.crmRepo.SumSpent(creatorID, clientID)
+}
+
+func (s *clientCRMServiceImpl) ListTags(creatorID uint) ([]*salesmodel.ClientTag, error) {
+	return s.crmRepo.FindTagsByCreatorID(creatorID)
+}
+
+// TagsByClient agrupa as etiquetas do criador por cliente, para exibir na listagem
+func (s *clientCRMServiceImpl) TagsByClient(creatorID uint, clientIDs []uint) (map[uint][]*salesmodel.ClientTag, error) {
+	assignments, err := s.crmRepo.FindAssignments(creatorID, clientIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[uint][]*salesmodel.ClientTag)
+	for _, assignment := range assignments {
+		tag := assignment.Tag
+		tags[assignment.ClientID] = append(tags[assignment.ClientID], &tag)
+	}
+	return tags, nil
+}
+
+// TagClient aplica a etiqueta ao cliente, criando-a quando o criador ainda não tem uma com esse nome
+func (s *clientCRMServiceImpl) TagClient(creatorID, clientID uint, tagName string) (*salesmodel.ClientTag, error) {
+	tagName = strings.Join(strings.Fields(tagName), " ")
+	if tagName == "" || utf8.RuneCountInString(tagName) > maxClientTagLength {
+		return nil, fmt.Errorf("%w: a etiqueta deve ter entre 1 e %d caracteres", ErrClientCRMInvalid, maxClientTagLength)
+	}
+
+	tag, err := s.crmRepo.FindTagByName(creatorID, tagName)
+	if err != nil {
+		return nil, err
+	}
+	if tag == nil {
+		existing, err := s.crmRepo.FindTagsByCreatorID(creatorID)
+		if err != nil {
+			return nil, err
+		}
+		tag = &salesmodel.ClientTag{
+			CreatorID: creatorID,
+			Name:      tagName,
+			Color:     salesmodel.ClientTagColors[len(existing)%len(salesmodel.ClientTagColors)],
+		}
+		if err := s.crmRepo.CreateTag(tag); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.crmRepo.AssignTag(&salesmodel.ClientTagAssignment{CreatorID: creatorID, ClientID: clientID, TagID: tag.ID})
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+func (s *clientCRMServiceImpl) UntagClient(creatorID, clientID, tagID uint) error {
+	return s.crmRepo.UnassignTag(creatorID, clientID, tagID)
+}
+
+func (s *clientCRMServiceImpl) DeleteTag(creatorID, tagID uint) error {
+	tag, err := s.crmRepo.FindTag(creatorID, tagID)
+	if err != nil {
+		return err
+	}
+	if tag == nil {
+		return fmt.Errorf("%w: etiqueta não encontrada", ErrClientCRMInvalid)
+	}
+	return s.crmRepo.DeleteTag(tag)
+}
+
+func (s *clientCRMServiceImpl) ListNotes(creatorID, clientID uint) ([]*salesmodel.ClientNote, error) {
+	return s.crmRepo.FindNotes(creatorID, clientID)
+}
+
+func (s *clientCRMServiceImpl) AddNote(creatorID, clientID uint, body string) (*salesmodel.ClientNote, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || utf8.RuneCountInString(body) > maxClientNoteLength {
+		return nil, fmt.Errorf("%w: a anotação deve ter entre 1 e %d caracteres", ErrClientCRMInvalid, maxClientNoteLength)
+	}
+
+	note := &salesmodel.ClientNote{CreatorID: creatorID, ClientID: clientID, Body: body}
+	if err := s.crmRepo.CreateNote(note); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+func (s *clientCRMServiceImpl) DeleteNote(creatorID, clientID, noteID uint) error {
+	return s.crmRepo.DeleteNote(creatorID, clientID, noteID)
+}
+
+func (s *clientCRMServiceImpl) ListSegments(creatorID uint) ([]*salesmodel.ClientSegment, error) {
+	return s.crmRepo.FindSegmentsByCreatorID(creatorID)
+}
+
+func (s *clientCRMServiceImpl) FindSegment(creatorID uint, publicID string) (*salesmodel.ClientSegment, error) {
+	segment, err := s.crmRepo.FindSegmentByPublicID(publicID)
+	if err != nil {
+		return nil, err
+	}
+	if segment == nil || segment.CreatorID != creatorID {
+		return nil, ErrSegmentNotFound
+	}
+	return segment, nil
+}
+
+// CreateSegment salva o filtro. As etiquetas precisam ser do criador; os ebooks são validados pelo handler.
+func (s *clientCRMServiceImpl) CreateSegment(creatorID uint, name string, rules salesmodel.SegmentRules) (*salesmodel.ClientSegment, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxSegmentNameLen {
+		return nil, fmt.Errorf("%w: o nome do segmento deve ter entre 1 e %d caracteres", ErrClientCRMInvalid, maxSegmentNameLen)
+	}
+	if rules.IsEmpty() {
+		return nil, fmt.Errorf("%w: escolha ao menos um critério", ErrClientCRMInvalid)
+	}
+	if rules.MinSpent < 0 || rules.InactiveDays < 0 || rules.InactiveDays > maxInactiveDays {
+		return nil, fmt.Errorf("%w: valor ou quantidade de dias inválidos", ErrClientCRMInvalid)
+	}
+	for _, tagID := range rules.TagIDs {
+		tag, err := s.crmRepo.FindTag(creatorID, tagID)
+		if err != nil {
+			return nil, err
+		}
+		if tag == nil {
+			return nil, fmt.Errorf("%w: etiqueta não encontrada", ErrClientCRMInvalid)
+		}
+	}
+
+	segment := &salesmodel.ClientSegment{CreatorID: creatorID, Name: name, Rules: rules}
+	if err := s.crmRepo.CreateSegment(segment); err != nil {
+		return nil, err
+	}
+	return segment, nil
+}
+
+func (s *clientCRMServiceImpl) DeleteSegment(creatorID uint, publicID string) error {
+	segment, err := s.FindSegment(creatorID, publicID)
+	if err != nil {
+		return err
+	}
+	return s.crmRepo.DeleteSegment(segment)
+}
+
+func (s *clientCRMServiceImpl) SegmentClients(creatorID uint, rules salesmodel.SegmentRules) ([]salesmodel.Client, error) {
+	return s.clientRepo.FindClientsBySegment(creatorID, rules)
+}
+
+// GrantEbookToSegment libera o ebook para todos os clientes do segmento que ainda não o têm
+func (s *clientCRMServiceImpl) GrantEbookToSegment(creatorID uint, segment *salesmodel.ClientSegment, ebookID uint) (int, error) {
+	clients, err := s.clientRepo.FindClientsBySegment(creatorID, segment.Rules)
+	if err != nil {
+		return 0, err
+	}
+
+	clientIDs := make([]uint, 0, len(clients))
+	for _, client := range clients {
+		clientIDs = append(clientIDs, client.ID)
+	}
+	reason := fmt.Sprintf("Acesso liberado para o segmento %s", segment.Name)
+	return grantEbookAccess(s.purchaseService, s.emailService, creatorID, ebookID, clientIDs, reason), nil
+}
@@ -0,0 +1,220 @@
+package service_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	"github.com/anglesson/simple-web-server/internal/mocks"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	salesrepogorm "github.com/anglesson/simple-web-server/internal/sales/repository/gorm"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/database"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type clientCRMFixture struct {
+	db      *gorm.DB
+	service salesvc.ClientCRMService
+	sent    chan []*salesmodel.Purchase
+	creator *accountmodel.Creator
+	volume1 *librarymodel.Ebook
+	volume2 *librarymodel.Ebook
+	ana     *salesmodel.Client
+	bruno   *salesmodel.Client
+	carla   *salesmodel.Client
+}
+
+func setupClientCRM(t *testing.T) *clientCRMFixture {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&accountmodel.Creator{}, &salesmodel.Client{}, &librarymodel.File{}, &librarymodel.Ebook{},
+		&salesmodel.Purchase{}, &salesmodel.PurchaseStatusHistory{}, &salesmodel.Transaction{}, &salesmodel.DownloadLog{},
+		&salesmodel.CreatorClient{}, &salesmodel.ClientTag{}, &salesmodel.ClientTagAssignment{}, &salesmodel.ClientNote{},
+		&salesmodel.ClientSegment{}))
+	database.DB = db
+
+	f := &clientCRMFixture{db: db, sent: make(chan []*salesmodel.Purchase, 1)}
+	f.creator = &accountmodel.Creator{Name: "Maria", Email: "maria@test.com"}
+	require.NoError(t, db.Create(f.creator).Error)
+	f.volume1 = &librarymodel.Ebook{Title: "Volume 1", Value: money.FromCents(5000), Status: true, CreatorID: f.creator.ID}
+	f.volume2 = &librarymodel.Ebook{Title: "Volume 2", Value: money.FromCents(5000), Status: true, CreatorID: f.creator.ID}
+	require.NoError(t, db.Create(f.volume1).Error)
+	require.NoError(t, db.Create(f.volume2).Error)
+
+	f.ana = salesmodel.NewClient("Ana", "52998224725", "", "ana@test.com", "")
+	f.bruno = salesmodel.NewClient("Bruno", "11144477735", "", "bruno@test.com", "")
+	f.carla = salesmodel.NewClient("Carla", "39053344705", "", "carla@test.com", "")
+	for _, client := range []*salesmodel.Client{f.ana, f.bruno, f.carla} {
+		require.NoError(t, db.Create(client).Error)
+	}
+
+	email := new(mocks.MockSalesEmailService)
+	email.On("SendLinkToDownload", mock.Anything).Run(func(args mock.Arguments) {
+		f.sent <- args.Get(0).([]*salesmodel.Purchase)
+	}).Return()
+	purchaseService := salesvc.NewPurchaseService(salesrepo.NewPurchaseRepository(), email)
+	f.service = salesvc.NewClientCRMService(salesrepo.NewClientCRMRepository(db), salesrepogorm.NewClientGormRepository(),
+		purchaseService, email)
+	return f
+}
+
+// paidPurchase registra uma compra paga com a transação concluída do valor informado
+func (f *clientCRMFixture) paidPurchase(t *testing.T, client *salesmodel.Client, ebook *librarymodel.Ebook, amount int64) *salesmodel.Purchase {
+	t.Helper()
+	purchase := salesmodel.NewPurchase(ebook.ID, client.ID, fmt.Sprintf("hash-%d-%d", ebook.ID, client.ID))
+	purchase.PaymentStatus = salesmodel.PaymentStatusConfirmed
+	purchase.Status = salesmodel.PurchaseStatusPaid
+	require.NoError(t, f.db.Create(purchase).Error)
+	require.NoError(t, f.db.Create(&salesmodel.Transaction{
+		PurchaseID: purchase.ID, CreatorID: f.creator.ID, TotalAmount: amount, Status: salesmodel.TransactionStatusCompleted,
+	}).Error)
+	return purchase
+}
+
+func segmentNames(clients []salesmodel.Client) []string {
+	names := make([]string, 0, len(clients))
+	for _, client := range clients {
+		names = append(names, client.Name)
+	}
+	return names
+}
+
+// TestClientCRMService_SegmentRules verifica cada critério do segmento e a combinação entre eles
+func TestClientCRMService_SegmentRules(t *testing.T) {
+	f := setupClientCRM(t)
+	anaPurchase := f.paidPurchase(t, f.ana, f.volume1, 30000)
+	f.paidPurchase(t, f.ana, f.volume2, 5000)
+	f.paidPurchase(t, f.bruno, f.volume1, 5000)
+	refunded := f.paidPurchase(t, f.carla, f.volume1, 5000)
+	require.NoError(t, f.db.Model(refunded).Update("status", salesmodel.PurchaseStatusRefunded).Error)
+	require.NoError(t, f.db.Create(&salesmodel.DownloadLog{PurchaseID: anaPurchase.ID}).Error)
+
+	vip, err := f.service.TagClient(f.creator.ID, f.bruno.ID, "  VIP  ")
+	require.NoError(t, err)
+	assert.Equal(t, "VIP", vip.Name)
+
+	cases := []struct {
+		name  string
+		rules salesmodel.SegmentRules
+		want  []string
+	}{
+		{"etiqueta", salesmodel.SegmentRules{TagIDs: []uint{vip.ID}}, []string{"Bruno"}},
+		{"comprou", salesmodel.SegmentRules{PurchasedEbookIDs: []uint{f.volume1.ID}}, []string{"Ana", "Bruno"}},
+		{"comprou mas não comprou", salesmodel.SegmentRules{PurchasedEbookIDs: []uint{f.volume1.ID}, NotPurchasedEbookIDs: []uint{f.volume2.ID}}, []string{"Bruno"}},
+		{"gastou mais de", salesmodel.SegmentRules{MinSpent: 20000}, []string{"Ana"}},
+		{"sem download", salesmodel.SegmentRules{InactiveDays: 30}, []string{"Bruno", "Carla"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			clients, err := f.service.SegmentClients(f.creator.ID, tc.rules)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, segmentNames(clients))
+		})
+	}
+
+	// O filtro da listagem usa as mesmas regras
+	clients, err := salesrepogorm.NewClientGormRepository().FindClientsByCreator(f.creator, salesmodel.ClientFilter{
+		Segment: &salesmodel.SegmentRules{PurchasedEbookIDs: []uint{f.volume1.ID}, NotPurchasedEbookIDs: []uint{f.volume2.ID}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bruno"}, segmentNames(*clients))
+}
+
+// TestClientCRMService_TagsAndNotes verifica reaproveitamento e exclusão de etiquetas e as anotações privadas
+func TestClientCRMService_TagsAndNotes(t *testing.T) {
+	f := setupClientCRM(t)
+
+	vip, err := f.service.TagClient(f.creator.ID, f.ana.ID, "VIP")
+	require.NoError(t, err)
+	again, err := f.service.TagClient(f.creator.ID, f.bruno.ID, "vip")
+	require.NoError(t, err)
+	assert.Equal(t, vip.ID, again.ID)
+	_, err = f.service.TagClient(f.creator.ID, f.ana.ID, "VIP")
+	require.NoError(t, err, "etiquetar duas vezes não deve falhar")
+	_, err = f.service.TagClient(f.creator.ID, f.ana.ID, " ")
+	assert.ErrorIs(t, err, salesvc.ErrClientCRMInvalid)
+
+	tagsByClient, err := f.service.TagsByClient(f.creator.ID, []uint{f.ana.ID, f.bruno.ID, f.carla.ID})
+	require.NoError(t, err)
+	assert.Len(t, tagsByClient[f.ana.ID], 1)
+	assert.Len(t, tagsByClient[f.bruno.ID], 1)
+	assert.Empty(t, tagsByClient[f.carla.ID])
+
+	// Outro criador não enxerga nem apaga a etiqueta
+	assert.ErrorIs(t, f.service.DeleteTag(f.creator.ID+1, vip.ID), salesvc.ErrClientCRMInvalid)
+	require.NoError(t, f.service.DeleteTag(f.creator.ID, vip.ID))
+	tags, err := f.service.ListTags(f.creator.ID)
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+	tagsByClient, err = f.service.TagsByClient(f.creator.ID, []uint{f.ana.ID})
+	require.NoError(t, err)
+	assert.Empty(t, tagsByClient[f.ana.ID])
+
+	note, err := f.service.AddNote(f.creator.ID, f.ana.ID, "  Pediu desconto  ")
+	require.NoError(t, err)
+	assert.Equal(t, "Pediu desconto", note.Body)
+	notes, err := f.service.ListNotes(f.creator.ID+1, f.ana.ID)
+	require.NoError(t, err)
+	assert.Empty(t, notes, "anotações são privadas do criador")
+	require.NoError(t, f.service.DeleteNote(f.creator.ID, f.ana.ID, note.ID))
+	notes, err = f.service.ListNotes(f.creator.ID, f.ana.ID)
+	require.NoError(t, err)
+	assert.Empty(t, notes)
+}
+
+// TestClientCRMService_SegmentGrant verifica a validação do segmento salvo e a liberação do ebook para os clientes dele
+func TestClientCRMService_SegmentGrant(t *testing.T) {
+	f := setupClientCRM(t)
+	f.paidPurchase(t, f.ana, f.volume1, 5000)
+	f.paidPurchase(t, f.ana, f.volume2, 5000)
+	f.paidPurchase(t, f.bruno, f.volume1, 5000)
+
+	_, err := f.service.CreateSegment(f.creator.ID, "Vazio", salesmodel.SegmentRules{})
+	assert.ErrorIs(t, err, salesvc.ErrClientCRMInvalid)
+	_, err = f.service.CreateSegment(f.creator.ID, "Etiqueta alheia", salesmodel.SegmentRules{TagIDs: []uint{99}})
+	assert.ErrorIs(t, err, salesvc.ErrClientCRMInvalid)
+
+	segment, err := f.service.CreateSegment(f.creator.ID, "Só o volume 1", salesmodel.SegmentRules{
+		PurchasedEbookIDs: []uint{f.volume1.ID}, NotPurchasedEbookIDs: []uint{f.volume2.ID},
+	})
+	require.NoError(t, err)
+
+	_, err = f.service.FindSegment(f.creator.ID+1, segment.PublicID)
+	assert.ErrorIs(t, err, salesvc.ErrSegmentNotFound)
+	found, err := f.service.FindSegment(f.creator.ID, segment.PublicID)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{f.volume2.ID}, found.Rules.NotPurchasedEbookIDs)
+
+	granted, err := f.service.GrantEbookToSegment(f.creator.ID, found, f.volume2.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, granted)
+
+	select {
+	case purchases := <-f.sent:
+		require.Len(t, purchases, 1)
+		assert.Equal(t, f.bruno.ID, purchases[0].ClientID)
+		assert.True(t, purchases[0].IsPaymentConfirmed())
+	case <-time.After(2 * time.Second):
+		t.Fatal("o link de download não foi enviado")
+	}
+
+	// Depois da liberação o Bruno sai do segmento
+	clients, err := f.service.SegmentClients(f.creator.ID, found.Rules)
+	require.NoError(t, err)
+	assert.Empty(t, clients)
+
+	require.NoError(t, f.service.DeleteSegment(f.creator.ID, segment.PublicID))
+	segments, err := f.service.ListSegments(f.creator.ID)
+	require.NoError(t, err)
+	assert.Empty(t, segments)
+}
@@ -0,0 +1,401 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"strings"
+	"time"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	"github.com/anglesson/simple-web-server/pkg/gov"
+	"github.com/anglesson/simple-web-server/pkg/spreadsheet"
+)
+
+const (
+	// MaxClientImportSize limita o tamanho da planilha enviada
+	MaxClientImportSize = 5 << 20
+	maxClientImportRows = 5000
+)
+
+var ErrClientImportInvalid = errors.New("planilha de clientes inválida")
+var ErrClientImportNotEditable = errors.New("a importação já foi iniciada")
+
+// ClientImportService importa clientes em massa a partir de planilhas CSV ou XLSX.
+// O envio só lê o cabeçalho; as linhas são validadas e gravadas pelo job em segundo plano.
+type ClientImportService interface {
+	Upload(creatorID uint, fileName string, content []byte, ebookID *uint) (*salesmodel.ClientImport, error)
+	Start(clientImport *salesmodel.ClientImport, mapping map[string]int) error
+	FindByPublicID(publicID string) (*salesmodel.ClientImport, error)
+	ListRecent(creatorID uint) ([]*salesmodel.ClientImport, error)
+	FindErrors(clientImport *salesmodel.ClientImport) ([]*salesmodel.ClientImportError, error)
+	ProcessQueued() (int, error)
+}
+
+type clientImportServiceImpl struct {
+	importRepo      salesrepo.ClientImportRepository
+	clientRepo      salesrepo.ClientRepository
+	purchaseService PurchaseService
+	emailService    IEmailService
+}
+
+func NewClientImportService(
+	importRepo salesrepo.ClientImportRepository,
+	clientRepo salesrepo.ClientRepository,
+	purchaseService PurchaseService,
+	emailService IEmailService,
+) ClientImportService {
+	return &clientImportServiceImpl{
+		importRepo:      importRepo,
+		clientRepo:      clientRepo,
+		purchaseService: purchaseService,
+		emailService:    emailService,
+	}
+}
+
+// Upload lê a planilha e sugere o mapeamento das colunas a partir do cabeçalho
+func (s *clientImportServiceImpl) Upload(creatorID uint, fileName string, content []byte, ebookID *uint) (*salesmodel.ClientImport, error) {
+	if len(content) > MaxClientImportSize {
+		return nil, fmt.Errorf("%w: o arquivo deve ter no máximo 5MB", ErrClientImportInvalid)
+	}
+
+	rows, err := spreadsheet.Read(fileName, content)
+	if err != nil {
+		if errors.Is(err, spreadsheet.ErrUnsupportedFormat) {
+			return nil, fmt.Errorf("%w: envie um arquivo .csv ou .xlsx", ErrClientImportInvalid)
+		}
+		return nil, fmt.Errorf("%w: não foi possível ler o arquivo", ErrClientImportInvalid)
+	}
+	if len(rows) < 2 {
+		return nil, fmt.Errorf("%w: a planilha precisa de um cabeçalho e ao menos um cliente", ErrClientImportInvalid)
+	}
+	if len(rows)-1 > maxClientImportRows {
+		return nil, fmt.Errorf("%w: importe no máximo %d clientes por planilha", ErrClientImportInvalid, maxClientImportRows)
+	}
+
+	headers := make([]string, len(rows[0]))
+	for i, header := range rows[0] {
+		headers[i] = strings.TrimSpace(header)
+	}
+
+	clientImport := &salesmodel.ClientImport{
+		CreatorID: creatorID,
+		FileName:  fileName,
+		Status:    salesmodel.ClientImportStatusMapping,
+		Headers:   headers,
+		Mapping:   salesmodel.SuggestMapping(headers),
+		Content:   content,
+		EbookID:   ebookID,
+		TotalRows: len(rows) - 1,
+	}
+	if err := s.importRepo.Create(clientImport); err != nil {
+		return nil, err
+	}
+	return clientImport, nil
+}
+
+// Start confirma o mapeamento e coloca a importação na fila do job
+func (s *clientImportServiceImpl) Start(clientImport *salesmodel.ClientImport, mapping map[string]int) error {
+	if clientImport.Status != salesmodel.ClientImportStatusMapping {
+		return ErrClientImportNotEditable
+	}
+
+	confirmed := make(map[string]int)
+	used := make(map[int]bool)
+	for _, field := range salesmodel.ClientImportFields {
+		column, ok := mapping[field.Key]
+		if !ok || column < 0 || column >= len(clientImport.Headers) {
+			if field.Required {
+				return fmt.Errorf("%w: escolha a coluna do campo %s", ErrClientImportInvalid, field.Label)
+			}
+			continue
+		}
+		if used[column] {
+			return fmt.Errorf("%w: a coluna %s foi escolhida para mais de um campo", ErrClientImportInvalid, clientImport.Headers[column])
+		}
+		used[column] = true
+		confirmed[field.Key] = column
+	}
+
+	clientImport.Mapping = confirmed
+	clientImport.Status = salesmodel.ClientImportStatusQueued
+	return s.importRepo.Save(clientImport)
+}
+
+func (s *clientImportServiceImpl) FindByPublicID(publicID string) (*salesmodel.ClientImport, error) {
+	return s.importRepo.FindByPublicID(publicID)
+}
+
+func (s *clientImportServiceImpl) ListRecent(creatorID uint) ([]*salesmodel.ClientImport, error) {
+	return s.importRepo.FindRecentByCreatorID(creatorID, 10)
+}
+
+func (s *clientImportServiceImpl) FindErrors(clientImport *salesmodel.ClientImport) ([]*salesmodel.ClientImportError, error) {
+	return s.importRepo.FindErrors(clientImport.ID)
+}
+
+// ProcessQueued processa as importações na fila e retorna quantas foram concluídas
+func (s *clientImportServiceImpl) ProcessQueued() (int, error) {
+	queued, err := s.importRepo.FindQueued()
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for _, clientImport := range queued {
+		now := time.Now()
+		clientImport.StartedAt = &now
+		claimed, err := s.importRepo.Claim(clientImport)
+		if err != nil {
+			return processed, err
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := s.process(clientImport); err != nil {
+			slog.Error("Erro ao importar clientes", "import", clientImport.PublicID, "error", err)
+			clientImport.Status = salesmodel.ClientImportStatusFailed
+			clientImport.ErrorMessage = err.Error()
+		} else {
+			clientImport.Status = salesmodel.ClientImportStatusCompleted
+		}
+
+		finishedAt := time.Now()
+		clientImport.FinishedAt = &finishedAt
+		// A planilha tem dados pessoais: não fica guardada depois do processamento
+		clientImport.Content = nil
+		if err := s.importRepo.Save(clientImport); err != nil {
+			return processed, err
+		}
+		processed++
+	}
+	return processed, nil
+}
+
+// importRow são os dados de uma linha já normalizados
+type importRow struct {
+	name      string
+	email     string
+	cpf       string
+	phone     string
+	birthdate string
+}
+
+func (s *clientImportServiceImpl) process(clientImport *salesmodel.ClientImport) error {
+	rows, err := spreadsheet.Read(clientImport.FileName, clientImport.Content)
+	if err != nil {
+		return fmt.Errorf("não foi possível ler a planilha: %w", err)
+	}
+
+	var importErrors []*salesmodel.ClientImportError
+	seenCPF := make(map[string]int)
+	var grantTo []uint
+
+	for i, cells := range rows[1:] {
+		// A linha 1 é o cabeçalho
+		line := i + 2
+		if isBlankRow(cells) {
+			continue
+		}
+
+		row, rowErrors := parseImportRow(clientImport, line, cells)
+		if len(rowErrors) == 0 {
+			if firstLine, ok := seenCPF[row.cpf]; ok {
+				rowErrors = append(rowErrors, newImportError(clientImport, line, salesmodel.ClientImportFieldCPF, row.cpf,
+					fmt.Sprintf("CPF repetido na planilha (linha %d)", firstLine)))
+			}
+		}
+		if len(rowErrors) > 0 {
+			importErrors = append(importErrors, rowErrors...)
+			clientImport.Failed++
+			continue
+		}
+		seenCPF[row.cpf] = line
+
+		clientID, err := s.saveClient(clientImport, row)
+		if err != nil {
+			importErrors = append(importErrors, newImportError(clientImport, line, "", "", err.Error()))
+			clientImport.Failed++
+			continue
+		}
+		grantTo = append(grantTo, clientID)
+	}
+
+	if clientImport.EbookID != nil {
+		clientImport.AccessGranted = grantEbookAccess(s.purchaseService, s.emailService, clientImport.CreatorID, *clientImport.EbookID,
+			grantTo, "Acesso liberado na importação de clientes")
+	}
+
+	return s.importRepo.CreateErrors(importErrors)
+}
+
+// saveClient deduplica pelo CPF: clientes já cadastrados na plataforma não têm os dados sobrescritos
+func (s *clientImportServiceImpl) saveClient(clientImport *salesmodel.ClientImport, row importRow) (uint, error) {
+	existing, err := s.clientRepo.FindByCPF(row.cpf)
+	if err != nil {
+		return 0, err
+	}
+
+	var clientID uint
+	if existing != nil {
+		clientID = existing.ID
+		clientImport.Existing++
+	} else {
+		client := salesmodel.NewClient(row.name, row.cpf, row.birthdate, row.email, row.phone)
+		if err := s.clientRepo.Save(client); err != nil {
+			return 0, err
+		}
+		clientID = client.ID
+		clientImport.Created++
+	}
+
+	if err := s.importRepo.LinkClient(clientImport.CreatorID, clientID, salesmodel.CreatorClientSourceImport); err != nil {
+		return 0, errors.New("erro ao vincular o cliente")
+	}
+	return clientID, nil
+}
+
+// grantEbookAccess libera o ebook para os clientes que ainda não o têm e envia o link de download.
+// Retorna quantos clientes receberam o acesso.
+func grantEbookAccess(purchaseService PurchaseService, emailService IEmailService, creatorID, ebookID uint, clientIDs []uint, reason string) int {
+	var granted []*salesmodel.Purchase
+	count := 0
+	for _, clientID := range clientIDs {
+		purchase, err := purchaseService.CreatePurchaseWithResult(ebookID, clientID)
+		if err != nil {
+			slog.Error("Erro ao liberar ebook", "ebook", ebookID, "client", clientID, "error", err)
+			continue
+		}
+		if purchase.Status != salesmodel.PurchaseStatusPending {
+			continue
+		}
+		if err := purchaseService.ConfirmOfflinePayment(purchase.ID, creatorID, reason); err != nil {
+			slog.Error("Erro ao liberar ebook", "purchase", purchase.ID, "error", err)
+			continue
+		}
+		count++
+
+		confirmed, err := purchaseService.GetPurchaseByID(purchase.ID)
+		if err == nil && !confirmed.IsAwaitingRelease() {
+			granted = append(granted, confirmed)
+		}
+	}
+
+	if len(granted) > 0 {
+		go emailService.SendLinkToDownload(granted)
+	}
+	return count
+}
+
+func parseImportRow(clientImport *salesmodel.ClientImport, line int, cells []string) (importRow, []*salesmodel.ClientImportError) {
+	cell := func(field string) string {
+		column := clientImport.ColumnFor(field)
+		if column < 0 || column >= len(cells) {
+			return ""
+		}
+		return strings.TrimSpace(cells[column])
+	}
+
+	var rowErrors []*salesmodel.ClientImportError
+	fail := func(field, value, message string) {
+		rowErrors = append(rowErrors, newImportError(clientImport, line, field, value, message))
+	}
+
+	row := importRow{name: cell(salesmodel.ClientImportFieldName)}
+	if row.name == "" {
+		fail(salesmodel.ClientImportFieldName, "", "Nome não informado")
+	}
+
+	rawEmail := cell(salesmodel.ClientImportFieldEmail)
+	row.email = strings.ToLower(rawEmail)
+	if row.email == "" {
+		fail(salesmodel.ClientImportFieldEmail, "", "Email não informado")
+	} else if address, err := mail.ParseAddress(row.email); err != nil || address.Address != row.email {
+		fail(salesmodel.ClientImportFieldEmail, rawEmail, "Email inválido")
+	}
+
+	rawCPF := cell(salesmodel.ClientImportFieldCPF)
+	row.cpf = onlyDigits(rawCPF)
+	// Planilhas costumam perder os zeros à esquerda quando a coluna é numérica
+	if len(row.cpf) > 0 && len(row.cpf) < 11 {
+		row.cpf = strings.Repeat("0", 11-len(row.cpf)) + row.cpf
+	}
+	if rawCPF == "" {
+		fail(salesmodel.ClientImportFieldCPF, "", "CPF não informado")
+	} else if !gov.IsValidCPF(row.cpf) {
+		fail(salesmodel.ClientImportFieldCPF, rawCPF, "CPF inválido")
+	}
+
+	if rawPhone := cell(salesmodel.ClientImportFieldPhone); rawPhone != "" {
+		row.phone = normalizeImportPhone(rawPhone)
+		if row.phone == "" {
+			fail(salesmodel.ClientImportFieldPhone, rawPhone, "Telefone inválido: informe DDD e número")
+		}
+	}
+
+	if rawBirthdate := cell(salesmodel.ClientImportFieldBirthdate); rawBirthdate != "" {
+		row.birthdate = parseImportBirthdate(rawBirthdate)
+		if row.birthdate == "" {
+			fail(salesmodel.ClientImportFieldBirthdate, rawBirthdate, "Data de nascimento inválida: use DD/MM/AAAA")
+		}
+	}
+
+	return row, rowErrors
+}
+
+// normalizeImportPhone aceita telefones brasileiros com DDD, com ou sem o código do país
+func normalizeImportPhone(phone string) string {
+	digits := onlyDigits(phone)
+	if (len(digits) == 12 || len(digits) == 13) && strings.HasPrefix(digits, "55") {
+		digits = digits[2:]
+	}
+	if len(digits) != 10 && len(digits) != 11 {
+		return ""
+	}
+	return digits
+}
+
+// parseImportBirthdate converte a data para o formato AAAA-MM-DD usado no cadastro do cliente.
+// Células de data do XLSX sem formatação própria chegam no formato padrão do Excel (mm-dd-yy).
+func parseImportBirthdate(value string) string {
+	for _, layout := range []string{"02/01/2006", "2006-01-02", "01-02-06"} {
+		date, err := time.Parse(layout, value)
+		if err == nil && date.Before(time.Now()) {
+			return date.Format("2006-01-02")
+		}
+	}
+	return ""
+}
+
+func newImportError(clientImport *salesmodel.ClientImport, line int, field, value, message string) *salesmodel.ClientImportError {
+	return &salesmodel.ClientImportError{ImportID: clientImport.ID, Line: line, Field: field, Value: value, Message: message}
+}
+
+func isBlankRow(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// StartClientImportJob processa periodicamente as importações de clientes na fila
+func StartClientImportJob(clientImportService ClientImportService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			processed, err := clientImportService.ProcessQueued()
+			if err != nil {
+				slog.Error("Erro ao processar importações de clientes", "error", err)
+				continue
+			}
+			if processed > 0 {
+				slog.Info("Importações de clientes processadas", "count", processed)
+			}
+		}
+	}()
+}
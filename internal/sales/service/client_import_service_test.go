@@ -0,0 +1,151 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	"github.com/anglesson/simple-web-server/internal/mocks"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	salesrepogorm "github.com/anglesson/simple-web-server/internal/sales/repository/gorm"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/database"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const clientImportCSV = "Nome;E-mail;CPF;Celular;Nascimento\n" +
+	"Ana Nova;Ana@Test.com;529.982.247-25;+55 (11) 99999-8888;10/05/1990\n" +
+	"Bruno Antigo;bruno@test.com;111.444.777-35;;\n" +
+	"Carla;carla@test.com;123.456.789-00;;\n" +
+	"Davi;davi@;390.533.447-05;123;\n" +
+	"Ana Repetida;ana2@test.com;52998224725;;\n" +
+	";;;;\n"
+
+type clientImportFixture struct {
+	service salesvc.ClientImportService
+	sent    chan []*salesmodel.Purchase
+	creator *accountmodel.Creator
+	ebook   *librarymodel.Ebook
+}
+
+func setupClientImport(t *testing.T) *clientImportFixture {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&accountmodel.Creator{}, &salesmodel.Client{}, &librarymodel.File{}, &librarymodel.Ebook{},
+		&salesmodel.Purchase{}, &salesmodel.PurchaseStatusHistory{},
+		&salesmodel.ClientImport{}, &salesmodel.ClientImportError{}, &salesmodel.CreatorClient{}))
+	database.DB = db
+
+	creator := &accountmodel.Creator{Name: "Maria", Email: "maria@test.com"}
+	require.NoError(t, db.Create(creator).Error)
+	ebook := &librarymodel.Ebook{Title: "Ebook Importado", Value: money.FromCents(5000), Status: true, CreatorID: creator.ID}
+	require.NoError(t, db.Create(ebook).Error)
+
+	fixture := &clientImportFixture{sent: make(chan []*salesmodel.Purchase, 1), creator: creator, ebook: ebook}
+	email := new(mocks.MockSalesEmailService)
+	email.On("SendLinkToDownload", mock.Anything).Run(func(args mock.Arguments) {
+		fixture.sent <- args.Get(0).([]*salesmodel.Purchase)
+	}).Return()
+
+	purchaseService := salesvc.NewPurchaseService(salesrepo.NewPurchaseRepository(), email)
+	fixture.service = salesvc.NewClientImportService(salesrepo.NewClientImportRepository(db),
+		salesrepogorm.NewClientGormRepository(), purchaseService, email)
+	return fixture
+}
+
+// TestClientImportService_ImportsSpreadsheet verifica validação, deduplicação, relatório de erros e liberação do ebook.
+func TestClientImportService_ImportsSpreadsheet(t *testing.T) {
+	f := setupClientImport(t)
+	existing := salesmodel.NewClient("Bruno", "11144477735", "1985-01-02", "bruno.antigo@test.com", "11988887777")
+	require.NoError(t, database.DB.Create(existing).Error)
+
+	clientImport, err := f.service.Upload(f.creator.ID, "clientes.csv", []byte(clientImportCSV), &f.ebook.ID)
+	require.NoError(t, err)
+	assert.Equal(t, salesmodel.ClientImportStatusMapping, clientImport.Status)
+	assert.Equal(t, 6, clientImport.TotalRows)
+	assert.Equal(t, map[string]int{"name": 0, "email": 1, "cpf": 2, "phone": 3, "birthdate": 4}, clientImport.Mapping)
+
+	require.NoError(t, f.service.Start(clientImport, clientImport.Mapping))
+	processed, err := f.service.ProcessQueued()
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+
+	clientImport, err = f.service.FindByPublicID(clientImport.PublicID)
+	require.NoError(t, err)
+	assert.Equal(t, salesmodel.ClientImportStatusCompleted, clientImport.Status)
+	assert.Equal(t, 1, clientImport.Created)
+	assert.Equal(t, 1, clientImport.Existing)
+	assert.Equal(t, 3, clientImport.Failed)
+	assert.Equal(t, 2, clientImport.AccessGranted)
+	assert.Empty(t, clientImport.Content)
+	assert.NotNil(t, clientImport.FinishedAt)
+
+	importErrors, err := f.service.FindErrors(clientImport)
+	require.NoError(t, err)
+	var messages []string
+	for _, importError := range importErrors {
+		messages = append(messages, importError.Message)
+	}
+	assert.Equal(t, []string{"CPF inválido", "Email inválido", "Telefone inválido: informe DDD e número", "CPF repetido na planilha (linha 2)"}, messages)
+	assert.Equal(t, 4, importErrors[0].Line)
+
+	created, err := salesrepogorm.NewClientGormRepository().FindByCPF("52998224725")
+	require.NoError(t, err)
+	assert.Equal(t, "ana@test.com", created.Email)
+	assert.Equal(t, "11999998888", created.Phone)
+	assert.Equal(t, "1990-05-10", created.Birthdate)
+
+	// O cliente que já existia não tem os dados sobrescritos
+	var bruno salesmodel.Client
+	require.NoError(t, database.DB.First(&bruno, existing.ID).Error)
+	assert.Equal(t, "bruno.antigo@test.com", bruno.Email)
+
+	// Os importados aparecem na lista do criador mesmo antes de qualquer compra
+	clients, err := salesrepogorm.NewClientGormRepository().FindClientsByCreator(f.creator, salesmodel.ClientFilter{})
+	require.NoError(t, err)
+	assert.Len(t, *clients, 2)
+
+	select {
+	case purchases := <-f.sent:
+		require.Len(t, purchases, 2)
+		for _, purchase := range purchases {
+			assert.True(t, purchase.IsPaymentConfirmed())
+			assert.Equal(t, f.ebook.ID, purchase.EbookID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("link de download não enviado")
+	}
+}
+
+// TestClientImportService_RequiresMandatoryColumns verifica que nome, email e CPF precisam de uma coluna.
+func TestClientImportService_RequiresMandatoryColumns(t *testing.T) {
+	f := setupClientImport(t)
+	clientImport, err := f.service.Upload(f.creator.ID, "clientes.csv", []byte("Cliente,Contato\nAna,ana@test.com\n"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"name": 0}, clientImport.Mapping)
+
+	err = f.service.Start(clientImport, map[string]int{"name": 0, "email": 1})
+	assert.ErrorIs(t, err, salesvc.ErrClientImportInvalid)
+
+	err = f.service.Start(clientImport, map[string]int{"name": 0, "email": 1, "cpf": 1})
+	assert.ErrorIs(t, err, salesvc.ErrClientImportInvalid)
+	assert.Equal(t, salesmodel.ClientImportStatusMapping, clientImport.Status)
+}
+
+func TestClientImportService_RejectsUnreadableFiles(t *testing.T) {
+	f := setupClientImport(t)
+
+	_, err := f.service.Upload(f.creator.ID, "clientes.pdf", []byte("%PDF"), nil)
+	assert.ErrorIs(t, err, salesvc.ErrClientImportInvalid)
+
+	_, err = f.service.Upload(f.creator.ID, "clientes.csv", []byte("Nome,Email,CPF\n"), nil)
+	assert.ErrorIs(t, err, salesvc.ErrClientImportInvalid)
+}
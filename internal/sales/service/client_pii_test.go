@@ -0,0 +1,63 @@
+package service_test
+
+import (
+	"testing"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepogorm "github.com/anglesson/simple-web-server/internal/sales/repository/gorm"
+	"github.com/anglesson/simple-web-server/pkg/pii"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClientPII_EncryptedAtRestAndSearchable garante que CPF e telefone ficam cifrados no banco
+// e continuam encontrados pela busca exata e pela listagem de clientes do criador
+func TestClientPII_EncryptedAtRestAndSearchable(t *testing.T) {
+	useEncryptedPII(t)
+	f := setupClientCRM(t)
+	f.paidPurchase(t, f.ana, f.volume1, 5000)
+	f.paidPurchase(t, f.bruno, f.volume1, 5000)
+	f.ana.Phone = "+5511988887777"
+	require.NoError(t, f.db.Save(f.ana).Error)
+
+	var stored struct{ CPF, Phone string }
+	require.NoError(t, f.db.Raw("SELECT cpf, phone FROM clients WHERE id = ?", f.ana.ID).Scan(&stored).Error)
+	assert.NotContains(t, stored.CPF, "52998224725")
+	assert.NotContains(t, stored.Phone, "988887777")
+
+	repo := salesrepogorm.NewClientGormRepository()
+	found, err := repo.FindByCPF("52998224725")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, f.ana.ID, found.ID)
+	assert.Equal(t, "52998224725", found.CPF)
+
+	for term, want := range map[string][]string{
+		"529.982.247-25":    {"Ana"},
+		"+55 11 98888-7777": {"Ana"},
+		"5511988887777":     {"Ana"},
+		"bruno@":            {"Bruno"},
+		"529982":            nil,
+	} {
+		clients, err := repo.FindClientsByCreator(f.creator, salesmodel.ClientFilter{Term: term})
+		require.NoError(t, err)
+		assert.Equal(t, want, nilIfEmpty(segmentNames(*clients)), "termo %q", term)
+	}
+}
+
+// useEncryptedPII liga a criptografia dos dados pessoais durante o teste
+func useEncryptedPII(t *testing.T) {
+	t.Helper()
+	keyring, err := pii.NewKeyring("v1:MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=", "segredo-do-indice")
+	require.NoError(t, err)
+	previous := pii.Default()
+	pii.Configure(keyring)
+	t.Cleanup(func() { pii.Configure(previous) })
+}
+
+func nilIfEmpty(names []string) []string {
+	if len(names) == 0 {
+		return nil
+	}
+	return names
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	"github.com/anglesson/simple-web-server/pkg/gov"
+)
+
+// pendingVerificationBatch limita quantas consultas pendentes são reprocessadas por execução
+const pendingVerificationBatch = 50
+
+// CPFVerificationService consulta o CPF do comprador na Receita Federal evitando consultas pagas
+// desnecessárias: valida os dígitos localmente, reaproveita resultados dentro do TTL e, quando o
+// provedor está fora do ar, enfileira a consulta (modo degradado) em vez de bloquear a venda.
+type CPFVerificationService interface {
+	Verify(creatorID uint, name, cpf, birthdate string) (*salesmodel.CPFVerification, error)
+	IsVerified(cpf, birthdate string) bool
+	ProcessPending() (int, error)
+	StartScheduler(interval time.Duration)
+	GetConsumptionByCreator(creatorID uint, since time.Time) (int64, error)
+}
+
+type cpfVerificationServiceImpl struct {
+	verificationRepo salesrepo.CPFVerificationRepository
+	rfService        gov.ReceitaFederalService
+	cacheTTL         time.Duration
+	now              func() time.Time
+}
+
+func NewCPFVerificationService(verificationRepo salesrepo.CPFVerificationRepository, rfService gov.ReceitaFederalService, cacheTTL time.Duration) CPFVerificationService {
+	return &cpfVerificationServiceImpl{
+		verificationRepo: verificationRepo,
+		rfService:        rfService,
+		cacheTTL:         cacheTTL,
+		now:              time.Now,
+	}
+}
+
+// Verify retorna o resultado da consulta do CPF. Com o provedor indisponível, a consulta fica
+// pendente e é devolvida sem erro para que o checkout siga com a validação local dos dígitos.
+func (s *cpfVerificationServiceImpl) Verify(creatorID uint, name, cpf, birthdate string) (*salesmodel.CPFVerification, error) {
+	if !gov.IsValidCPF(cpf) {
+		return nil, gov.ErrInvalidCPF
+	}
+
+	now := s.now()
+	verification, err := s.verificationRepo.FindLatest(cpf, birthdate)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar consulta de CPF: %v", err)
+	}
+	if verification != nil && (verification.IsFresh(now) || verification.IsPending()) {
+		return verification, nil
+	}
+
+	if verification == nil {
+		verification = salesmodel.NewPendingCPFVerification(creatorID, name, cpf, birthdate)
+	} else {
+		verification.CreatorID = creatorID
+		verification.Name = name
+	}
+
+	err = s.consult(verification, now)
+	if errors.Is(err, gov.ErrProviderUnavailable) {
+		verification.Status = salesmodel.CPFVerificationPending
+		verification.Attempts++
+		if saveErr := s.verificationRepo.Save(verification); saveErr != nil {
+			return nil, fmt.Errorf("erro ao enfileirar consulta de CPF: %v", saveErr)
+		}
+		slog.Warn("Receita Federal indisponível, consulta de CPF enfileirada", "verificationID", verification.ID)
+		return verification, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return verification, nil
+}
+
+func (s *cpfVerificationServiceImpl) IsVerified(cpf, birthdate string) bool {
+	verification, err := s.verificationRepo.FindLatest(cpf, birthdate)
+	return err == nil && verification != nil && verification.IsVerified()
+}
+
+// ProcessPending reprocessa a fila do modo degradado. Interrompe na primeira falha do provedor
+// para não gastar tentativas enquanto ele continua fora do ar.
+func (s *cpfVerificationServiceImpl) ProcessPending() (int, error) {
+	pending, err := s.verificationRepo.FindPending(pendingVerificationBatch)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao buscar consultas pendentes: %v", err)
+	}
+
+	resolved := 0
+	for _, verification := range pending {
+		err := s.consult(verification, s.now())
+		if errors.Is(err, gov.ErrProviderUnavailable) {
+			verification.Attempts++
+			if saveErr := s.verificationRepo.Save(verification); saveErr != nil {
+				slog.Error("Erro ao atualizar consulta de CPF pendente", "verificationID", verification.ID, "error", saveErr)
+			}
+			break
+		}
+		if err != nil {
+			slog.Error("Erro ao processar consulta de CPF pendente", "verificationID", verification.ID, "error", err)
+			continue
+		}
+
+		if !verification.IsVerified() {
+			slog.Warn("CPF de venda aceita em modo degradado não confere com a Receita Federal",
+				"verificationID", verification.ID, "creatorID", verification.CreatorID)
+		}
+		resolved++
+	}
+	return resolved, nil
+}
+
+func (s *cpfVerificationServiceImpl) StartScheduler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			resolved, err := s.ProcessPending()
+			if err != nil {
+				slog.Error("Erro ao processar consultas de CPF pendentes", "error", err)
+				continue
+			}
+			if resolved > 0 {
+				slog.Info("Consultas de CPF pendentes processadas", "total", resolved)
+			}
+		}
+	}()
+}
+
+func (s *cpfVerificationServiceImpl) GetConsumptionByCreator(creatorID uint, since time.Time) (int64, error) {
+	return s.verificationRepo.SumConsumedByCreator(creatorID, since)
+}
+
+// consult consulta o provedor e grava o resultado. Falhas do provedor retornam
+// gov.ErrProviderUnavailable; dados divergentes são registrados como consulta rejeitada.
+func (s *cpfVerificationServiceImpl) consult(verification *salesmodel.CPFVerification, now time.Time) error {
+	response, err := s.rfService.ConsultaCPF(verification.Name, verification.CPF, verification.Birthdate)
+	if err != nil && !errors.Is(err, gov.ErrCPFNotFound) {
+		if errors.Is(err, gov.ErrProviderUnavailable) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", gov.ErrProviderUnavailable, err)
+	}
+
+	// Toda consulta respondida pelo provedor é cobrada, inclusive as rejeitadas
+	consumed := 1
+	verified := false
+	registeredName := ""
+	if response != nil {
+		consumed = response.Consumed
+		verified = response.Status
+		registeredName = response.Result.NomeDaPF
+	}
+
+	if err := s.verificationRepo.RecordUsage(&salesmodel.CPFLookupUsage{
+		CreatorID: verification.CreatorID,
+		Consumed:  consumed,
+		Success:   verified,
+	}); err != nil {
+		slog.Error("Erro ao registrar consumo da consulta de CPF", "creatorID", verification.CreatorID, "error", err)
+	}
+
+	verification.Resolve(verified, registeredName, now, s.cacheTTL)
+	if err := s.verificationRepo.Save(verification); err != nil {
+		return fmt.Errorf("erro ao salvar consulta de CPF: %v", err)
+	}
+
+	if verified {
+		if err := s.verificationRepo.MarkClientsValidated(verification.CPF); err != nil {
+			slog.Error("Erro ao marcar clientes como validados", "verificationID", verification.ID, "error", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/mocks"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/database"
+	"github.com/anglesson/simple-web-server/pkg/gov"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const verifiedCPF = "52998224725"
+
+func setupCPFVerificationTest(t *testing.T) (salesvc.CPFVerificationService, *mocks.MockRFService) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&salesmodel.Client{}, &salesmodel.CPFVerification{}, &salesmodel.CPFLookupUsage{}))
+	database.DB = db
+
+	rf := new(mocks.MockRFService)
+	service := salesvc.NewCPFVerificationService(salesrepo.NewCPFVerificationRepository(db), rf, time.Hour)
+	return service, rf
+}
+
+func regularResponse(name string) *gov.ReceitaFederalResponse {
+	return &gov.ReceitaFederalResponse{Status: true, Consumed: 1, Result: gov.ConsultaData{NomeDaPF: name}}
+}
+
+func TestCPFVerificationService_InvalidCPFSkipsProvider(t *testing.T) {
+	service, rf := setupCPFVerificationTest(t)
+
+	_, err := service.Verify(1, "Maria", "12345678901", "01/01/1990")
+
+	assert.ErrorIs(t, err, gov.ErrInvalidCPF)
+	rf.AssertNotCalled(t, "ConsultaCPF", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCPFVerificationService_CachesVerifiedResult(t *testing.T) {
+	service, rf := setupCPFVerificationTest(t)
+	rf.On("ConsultaCPF", "Maria Souza", verifiedCPF, "01/01/1990").Return(regularResponse("MARIA SOUZA"), nil).Once()
+
+	first, err := service.Verify(1, "Maria Souza", verifiedCPF, "01/01/1990")
+	require.NoError(t, err)
+	assert.True(t, first.IsVerified())
+	assert.Equal(t, "MARIA SOUZA", first.RegisteredName)
+
+	second, err := service.Verify(2, "Maria Souza", verifiedCPF, "01/01/1990")
+	require.NoError(t, err)
+	assert.True(t, second.IsVerified())
+	assert.True(t, service.IsVerified(verifiedCPF, "01/01/1990"))
+
+	rf.AssertNumberOfCalls(t, "ConsultaCPF", 1)
+
+	consumed, err := service.GetConsumptionByCreator(1, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), consumed)
+
+	consumed, err = service.GetConsumptionByCreator(2, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), consumed, "consulta servida pelo cache não é cobrada")
+}
+
+func TestCPFVerificationService_RecordsRejection(t *testing.T) {
+	service, rf := setupCPFVerificationTest(t)
+	rf.On("ConsultaCPF", "Maria", verifiedCPF, "02/02/1990").Return((*gov.ReceitaFederalResponse)(nil), gov.ErrCPFNotFound).Once()
+
+	verification, err := service.Verify(1, "Maria", verifiedCPF, "02/02/1990")
+
+	require.NoError(t, err)
+	assert.Equal(t, salesmodel.CPFVerificationRejected, verification.Status)
+	assert.False(t, service.IsVerified(verifiedCPF, "02/02/1990"))
+}
+
+func TestCPFVerificationService_DegradedModeQueuesAndProcesses(t *testing.T) {
+	service, rf := setupCPFVerificationTest(t)
+	client := salesmodel.NewClient("Maria Souza", verifiedCPF, "1990-01-01", "maria@test.com", "11999999999")
+	require.NoError(t, database.DB.Create(client).Error)
+
+	rf.On("ConsultaCPF", "Maria Souza", verifiedCPF, "01/01/1990").Return((*gov.ReceitaFederalResponse)(nil), gov.ErrProviderUnavailable).Once()
+
+	verification, err := service.Verify(1, "Maria Souza", verifiedCPF, "01/01/1990")
+	require.NoError(t, err, "provedor fora do ar não deve bloquear a venda")
+	assert.True(t, verification.IsPending())
+	assert.Equal(t, 1, verification.Attempts)
+
+	// Enquanto pendente, novas chamadas não consultam o provedor novamente
+	_, err = service.Verify(1, "Maria Souza", verifiedCPF, "01/01/1990")
+	require.NoError(t, err)
+	rf.AssertNumberOfCalls(t, "ConsultaCPF", 1)
+
+	rf.On("ConsultaCPF", "Maria Souza", verifiedCPF, "01/01/1990").Return(regularResponse("MARIA SOUZA"), nil).Once()
+
+	resolved, err := service.ProcessPending()
+	require.NoError(t, err)
+	assert.Equal(t, 1, resolved)
+	assert.True(t, service.IsVerified(verifiedCPF, "01/01/1990"))
+
+	var stored salesmodel.Client
+	require.NoError(t, database.DB.First(&stored, client.ID).Error)
+	assert.True(t, stored.Validated)
+}
+
+func TestCPFVerificationService_ProcessPendingStopsWhileProviderDown(t *testing.T) {
+	service, rf := setupCPFVerificationTest(t)
+	outage := errors.New("timeout")
+	rf.On("ConsultaCPF", mock.Anything, mock.Anything, mock.Anything).Return((*gov.ReceitaFederalResponse)(nil), outage)
+
+	_, err := service.Verify(1, "Maria", verifiedCPF, "01/01/1990")
+	require.NoError(t, err)
+	_, err = service.Verify(1, "João", "12345678909", "01/01/1990")
+	require.NoError(t, err)
+
+	resolved, err := service.ProcessPending()
+	require.NoError(t, err)
+	assert.Equal(t, 0, resolved)
+	rf.AssertNumberOfCalls(t, "ConsultaCPF", 3)
+}
+
+// TestCPFVerificationService_EncryptedPII verifica o cache e a validação dos clientes com o CPF cifrado no banco
+func TestCPFVerificationService_EncryptedPII(t *testing.T) {
+	useEncryptedPII(t)
+	service, rf := setupCPFVerificationTest(t)
+	client := salesmodel.NewClient("Maria Souza", verifiedCPF, "1990-01-01", "maria@test.com", "11999999999")
+	require.NoError(t, database.DB.Create(client).Error)
+
+	rf.On("ConsultaCPF", "Maria Souza", verifiedCPF, "01/01/1990").Return((*gov.ReceitaFederalResponse)(nil), gov.ErrProviderUnavailable).Once()
+	_, err := service.Verify(1, "Maria Souza", verifiedCPF, "01/01/1990")
+	require.NoError(t, err)
+
+	var stored struct{ CPF, Birthdate string }
+	require.NoError(t, database.DB.Raw("SELECT cpf, birthdate FROM cpf_verifications").Scan(&stored).Error)
+	assert.NotContains(t, stored.CPF, verifiedCPF)
+	assert.NotContains(t, stored.Birthdate, "1990")
+
+	rf.On("ConsultaCPF", "Maria Souza", verifiedCPF, "01/01/1990").Return(regularResponse("MARIA SOUZA"), nil).Once()
+	resolved, err := service.ProcessPending()
+	require.NoError(t, err)
+	assert.Equal(t, 1, resolved)
+	assert.True(t, service.IsVerified(verifiedCPF, "01/01/1990"))
+	rf.AssertNumberOfCalls(t, "ConsultaCPF", 2)
+
+	var validated salesmodel.Client
+	require.NoError(t, database.DB.First(&validated, client.ID).Error)
+	assert.True(t, validated.Validated)
+}
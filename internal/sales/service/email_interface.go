@@ -1,12 +1,20 @@
 package service
 
 import (
-	salesdto "github.com/anglesson/simple-web-server/internal/sales/service/dto"
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
 	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesdto "github.com/anglesson/simple-web-server/internal/sales/service/dto"
 )
 
 // IEmailService defines the email operations needed by the sales module
 type IEmailService interface {
 	SendLinkToDownload(purchases []*salesmodel.Purchase)
 	ResendDownloadLink(dto *salesdto.ResendDownloadLinkDTO) error
+	SendCheckoutRecovery(recovery *salesmodel.CheckoutRecovery, discountPercent int64)
+	SendWaitlistNotification(entry *salesmodel.WaitlistEntry, ebook *librarymodel.Ebook)
+	SendMonthlyStatement(creator *accountmodel.Creator, statement *salesmodel.MonthlyStatement, pdf []byte)
+	SendReconciliationReport(run *salesmodel.ReconciliationRun)
+	SendBroadcast(broadcast *salesmodel.Broadcast, delivery *salesmodel.BroadcastDelivery) error
+	SendPrivacyRequestAlert(request *salesmodel.PrivacyRequest)
 }
@@ -2,20 +2,25 @@ package service
 
 import (
 	"fmt"
+	"html/template"
 	"log"
 
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
 	"github.com/anglesson/simple-web-server/internal/config"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
 	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
 	salesdto "github.com/anglesson/simple-web-server/internal/sales/service/dto"
 	"github.com/anglesson/simple-web-server/pkg/mail"
 )
 
 type EmailService struct {
-	mailer mail.Mailer
+	mailer         mail.Mailer
+	receiptService ReceiptService
 }
 
-func NewEmailService(mailer mail.Mailer) *EmailService {
-	return &EmailService{mailer: mailer}
+// NewEmailService recebe o ReceiptService para anexar o recibo ao email de download; sem ele o email segue sem anexo
+func NewEmailService(mailer mail.Mailer, receiptService ReceiptService) *EmailService {
+	return &EmailService{mailer: mailer, receiptService: receiptService}
 }
 
 func (s *EmailService) SendLinkToDownload(purchases []*salesmodel.Purchase) {
@@ -50,8 +55,11 @@ func (s *EmailService) SendLinkToDownload(purchases []*salesmodel.Purchase) {
 			"Files":             purchase.Ebook.Files,
 		}
 
+		receipt := s.findReceipt(purchase.ID)
+		data["HasReceipt"] = receipt != nil
+
 		log.Printf("Configurando email para: %s", purchase.Client.Email)
-		s.prepareAndSendEmail(purchase.Client.Email, "Seu e-book chegou!", "ebook_download", data)
+		s.prepareAndSendEmail(purchase.Client.Email, "Seu e-book chegou!", "ebook_download", data, receipt)
 	}
 }
 
@@ -86,6 +94,138 @@ func (s *EmailService) ResendDownloadLink(downloadDTO *salesdto.ResendDownloadLi
 	return nil
 }
 
+// SendCheckoutRecovery envia o email de recuperação de checkout abandonado com um link para um novo checkout
+func (s *EmailService) SendCheckoutRecovery(recovery *salesmodel.CheckoutRecovery, discountPercent int64) {
+	client := recovery.Purchase.Client
+	if client.Email == "" {
+		log.Printf("Checkout recovery %d sem email do cliente, envio ignorado", recovery.ID)
+		return
+	}
+
+	data := map[string]interface{}{
+		"Name":            client.Name,
+		"Title":           "Seu e-book está esperando por você",
+		"AppName":         config.AppConfig.AppName,
+		"Contact":         config.AppConfig.MailFromAddress,
+		"Ebook":           recovery.Purchase.Ebook,
+		"CheckoutLink":    s.buildAppURL("/checkout/recover/" + recovery.TokenValue()),
+		"DiscountPercent": discountPercent,
+	}
+
+	s.prepareAndSendEmail(client.Email, "Você esqueceu algo: "+recovery.Purchase.Ebook.Title, "checkout_recovery", data)
+}
+
+// SendWaitlistNotification avisa o inscrito da lista de espera que o ebook está disponível.
+// O link passa pela rota de rastreamento para atribuir a compra à lista de espera.
+func (s *EmailService) SendWaitlistNotification(entry *salesmodel.WaitlistEntry, ebook *librarymodel.Ebook) {
+	if entry.Email == "" {
+		log.Printf("Inscrito %d da lista de espera sem email, envio ignorado", entry.ID)
+		return
+	}
+
+	data := map[string]interface{}{
+		"Name":        entry.Name,
+		"Title":       "O e-book que você esperava está disponível!",
+		"AppName":     config.AppConfig.AppName,
+		"Contact":     config.AppConfig.MailFromAddress,
+		"Ebook":       ebook,
+		"TrackedLink": s.buildAppURL("/waitlist/" + entry.Token),
+	}
+
+	s.prepareAndSendEmail(entry.Email, "Disponível agora: "+ebook.Title, "waitlist_notification", data)
+}
+
+// SendMonthlyStatement envia ao criador o extrato do mês com o PDF em anexo
+func (s *EmailService) SendMonthlyStatement(creator *accountmodel.Creator, statement *salesmodel.MonthlyStatement, pdf []byte) {
+	data := map[string]interface{}{
+		"Name":          creator.Name,
+		"Title":         "Seu extrato de " + statement.GetMonthLabel(),
+		"AppName":       config.AppConfig.AppName,
+		"Contact":       config.AppConfig.MailFromAddress,
+		"Statement":     statement,
+		"StatementLink": s.buildAppURL("/transactions/statements?month=" + statement.GetMonthParam()),
+	}
+
+	attachment := &Receipt{FileName: "extrato-" + statement.GetMonthParam() + ".pdf", Content: pdf}
+	s.prepareAndSendEmail(creator.Email, "Extrato mensal de "+statement.GetMonthLabel(), "monthly_statement", data, attachment)
+}
+
+// SendReconciliationReport avisa os operadores das divergências com o Stripe que não foram corrigidas
+func (s *EmailService) SendReconciliationReport(run *salesmodel.ReconciliationRun) {
+	to := config.AppConfig.ReconciliationAlertEmail
+	if to == "" {
+		log.Printf("RECONCILIATION_ALERT_EMAIL não configurado, relatório da conciliação %d não enviado", run.ID)
+		return
+	}
+
+	issues := run.PendingIssues()
+	data := map[string]interface{}{
+		"Title":   "Divergências na conciliação com o Stripe",
+		"AppName": config.AppConfig.AppName,
+		"Run":     run,
+		"Issues":  issues,
+	}
+
+	subject := fmt.Sprintf("Conciliação Stripe: %d divergência(s) para revisar", len(issues))
+	s.prepareAndSendEmail(to, subject, "reconciliation_report", data)
+}
+
+// SendPrivacyRequestAlert avisa os operadores de uma nova solicitação de titular e do prazo de resposta
+func (s *EmailService) SendPrivacyRequestAlert(request *salesmodel.PrivacyRequest) {
+	if len(config.AppConfig.PrivacyOperatorEmails) == 0 {
+		log.Printf("PRIVACY_OPERATOR_EMAILS não configurado, aviso da solicitação %s não enviado", request.PublicID)
+		return
+	}
+
+	data := map[string]interface{}{
+		"Title":   "Nova solicitação de titular (LGPD)",
+		"AppName": config.AppConfig.AppName,
+		"Request": request,
+		"Link":    s.buildAppURL("/ops/privacy"),
+	}
+
+	subject := fmt.Sprintf("LGPD: %s até %s", request.GetTypeLabel(), request.DueAt.Format("02/01/2006"))
+	for _, to := range config.AppConfig.PrivacyOperatorEmails {
+		s.prepareAndSendEmail(to, subject, "privacy_request_alert", data)
+	}
+}
+
+// SendBroadcast envia o email em massa do criador para um destinatário. O link de descadastro vai no
+// rodapé e nos cabeçalhos List-Unsubscribe, que permitem o descadastro em um clique (RFC 8058).
+func (s *EmailService) SendBroadcast(broadcast *salesmodel.Broadcast, delivery *salesmodel.BroadcastDelivery) error {
+	unsubscribeLink := s.buildAppURL("/unsubscribe/" + delivery.Token)
+	data := map[string]interface{}{
+		"Name":            delivery.Name,
+		"Title":           broadcast.Subject,
+		"AppName":         config.AppConfig.AppName,
+		"CreatorName":     broadcast.Creator.Name,
+		"Body":            template.HTML(broadcast.Body),
+		"UnsubscribeLink": unsubscribeLink,
+	}
+
+	fromName := config.AppConfig.AppName
+	if broadcast.Creator.Name != "" {
+		fromName = fmt.Sprintf("%s via %s", broadcast.Creator.Name, config.AppConfig.AppName)
+	}
+	s.mailer.From(fromName, config.AppConfig.MailFromAddress)
+	s.mailer.To(delivery.Email)
+	s.mailer.Subject(broadcast.Subject)
+	if broadcast.Creator.Email != "" {
+		s.mailer.Header("Reply-To", broadcast.Creator.Email)
+	}
+	s.mailer.Header("List-Unsubscribe", "<"+unsubscribeLink+">")
+	s.mailer.Header("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+	s.mailer.Body(mail.NewEmail("broadcast", data))
+	return s.mailer.Send()
+}
+
+func (s *EmailService) buildAppURL(path string) string {
+	if config.AppConfig.IsProduction() {
+		return config.AppConfig.Host + path
+	}
+	return fmt.Sprintf("%s:%s%s", config.AppConfig.Host, config.AppConfig.Port, path)
+}
+
 func (s *EmailService) buildDownloadURL(hashID string) string {
 	if config.AppConfig.IsProduction() {
 		return fmt.Sprintf("%s/purchase/download/%s", config.AppConfig.Host, hashID)
@@ -93,10 +233,29 @@ func (s *EmailService) buildDownloadURL(hashID string) string {
 	return fmt.Sprintf("%s:%s/purchase/download/%s", config.AppConfig.Host, config.AppConfig.Port, hashID)
 }
 
-func (s *EmailService) prepareAndSendEmail(to, subject, template string, data any) {
+// findReceipt gera o recibo da compra; uma falha não impede o envio do link de download
+func (s *EmailService) findReceipt(purchaseID uint) *Receipt {
+	if s.receiptService == nil {
+		return nil
+	}
+
+	receipt, err := s.receiptService.GenerateForPurchase(purchaseID)
+	if err != nil {
+		log.Printf("Recibo não anexado para purchase_id=%d: %v", purchaseID, err)
+		return nil
+	}
+	return receipt
+}
+
+func (s *EmailService) prepareAndSendEmail(to, subject, template string, data any, attachments ...*Receipt) {
 	s.mailer.From(config.AppConfig.AppName, config.AppConfig.MailFromAddress)
 	s.mailer.To(to)
 	s.mailer.Subject(subject)
 	s.mailer.Body(mail.NewEmail(template, data))
+	for _, attachment := range attachments {
+		if attachment != nil {
+			s.mailer.Attach(attachment.FileName, attachment.Content)
+		}
+	}
 	s.mailer.Send()
 }
@@ -0,0 +1,126 @@
+package service_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	"github.com/anglesson/simple-web-server/internal/config"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	"github.com/anglesson/simple-web-server/internal/mocks"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// chdirProjectRoot muda para a raiz do projeto, onde mail.NewEmail encontra os templates em web/mails/
+func chdirProjectRoot(t *testing.T) {
+	t.Helper()
+	previous, err := os.Getwd()
+	require.NoError(t, err)
+	_, filename, _, _ := runtime.Caller(0)
+	require.NoError(t, os.Chdir(filepath.Join(filepath.Dir(filename), "..", "..", "..")))
+	t.Cleanup(func() { _ = os.Chdir(previous) })
+}
+
+func downloadEmailPurchase() *salesmodel.Purchase {
+	return &salesmodel.Purchase{
+		Model:  gorm.Model{ID: 4},
+		HashID: "hash-recibo",
+		Ebook:  librarymodel.Ebook{Title: "Ebook"},
+		Client: salesmodel.Client{Model: gorm.Model{ID: 2}, Name: "Ana", CPF: "52998224725", Email: "ana@test.com"},
+	}
+}
+
+func TestSendLinkToDownload_AttachesReceipt(t *testing.T) {
+	chdirProjectRoot(t)
+
+	purchase := downloadEmailPurchase()
+	transactionRepo := new(mocks.MockTransactionRepository)
+	transactionRepo.On("FindByPurchaseID", uint(4)).Return(&salesmodel.Transaction{
+		PublicID:    "txn_email",
+		Status:      salesmodel.TransactionStatusCompleted,
+		TotalAmount: 4000,
+		Currency:    money.BRL,
+		Purchase:    *purchase,
+	}, nil).Once()
+
+	var body string
+	mailer := new(mocks.MockMailerSimple)
+	mailer.On("From", mock.Anything).Return()
+	mailer.On("To", "ana@test.com").Return()
+	mailer.On("Subject", mock.Anything).Return()
+	mailer.On("Body", mock.MatchedBy(func(b string) bool { body = b; return true })).Return()
+	mailer.On("Attach", "recibo-txn_email.pdf", mock.MatchedBy(func(content []byte) bool {
+		return strings.HasPrefix(string(content), "%PDF")
+	})).Return().Once()
+	mailer.On("Send").Return().Once()
+
+	salesvc.NewEmailService(mailer, salesvc.NewReceiptService(transactionRepo)).SendLinkToDownload([]*salesmodel.Purchase{purchase})
+
+	assert.Contains(t, body, "segue em anexo")
+	mailer.AssertExpectations(t)
+}
+
+func TestSendLinkToDownload_SendsWithoutReceiptWhenUnavailable(t *testing.T) {
+	chdirProjectRoot(t)
+
+	transactionRepo := new(mocks.MockTransactionRepository)
+	transactionRepo.On("FindByPurchaseID", uint(4)).Return(nil, errors.New("record not found")).Once()
+
+	var body string
+	mailer := new(mocks.MockMailerSimple)
+	mailer.On("From", mock.Anything).Return()
+	mailer.On("To", "ana@test.com").Return()
+	mailer.On("Subject", mock.Anything).Return()
+	mailer.On("Body", mock.MatchedBy(func(b string) bool { body = b; return true })).Return()
+	mailer.On("Send").Return().Once()
+
+	salesvc.NewEmailService(mailer, salesvc.NewReceiptService(transactionRepo)).SendLinkToDownload([]*salesmodel.Purchase{downloadEmailPurchase()})
+
+	assert.NotContains(t, body, "segue em anexo")
+	mailer.AssertNotCalled(t, "Attach", mock.Anything, mock.Anything)
+	mailer.AssertExpectations(t)
+}
+
+func TestSendBroadcast_AddsUnsubscribeHeaders(t *testing.T) {
+	chdirProjectRoot(t)
+	previousHost, previousMode := config.AppConfig.Host, config.AppConfig.AppMode
+	config.AppConfig.Host, config.AppConfig.AppMode = "https://app.test", "production"
+	t.Cleanup(func() { config.AppConfig.Host, config.AppConfig.AppMode = previousHost, previousMode })
+
+	broadcast := &salesmodel.Broadcast{
+		Subject: "Nova edição",
+		Body:    "<p>Saiu a <b>segunda edição</b>!</p>",
+		Creator: accountmodel.Creator{Name: "Maria", Email: "maria@test.com"},
+	}
+	delivery := &salesmodel.BroadcastDelivery{Name: "Ana", Email: "ana@test.com", Token: "tok123"}
+
+	var body string
+	mailer := new(mocks.MockMailerSimple)
+	mailer.On("From", mock.Anything).Return()
+	mailer.On("To", "ana@test.com").Return()
+	mailer.On("Subject", "Nova edição").Return()
+	mailer.On("Header", "Reply-To", "maria@test.com").Return().Once()
+	mailer.On("Header", "List-Unsubscribe", mock.MatchedBy(func(v string) bool {
+		return v == "<https://app.test/unsubscribe/tok123>"
+	})).Return().Once()
+	mailer.On("Header", "List-Unsubscribe-Post", "List-Unsubscribe=One-Click").Return().Once()
+	mailer.On("Body", mock.MatchedBy(func(b string) bool { body = b; return true })).Return()
+	mailer.On("Send").Return(errors.New("smtp indisponível")).Once()
+
+	err := salesvc.NewEmailService(mailer, nil).SendBroadcast(broadcast, delivery)
+
+	assert.EqualError(t, err, "smtp indisponível")
+	assert.Contains(t, body, "<b>segunda edição</b>", "o HTML do criador não é escapado")
+	assert.Contains(t, body, `href="https://app.test/unsubscribe/tok123"`)
+	mailer.AssertExpectations(t)
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	subscriptionrepo "github.com/anglesson/simple-web-server/internal/subscription/repository"
+	"github.com/anglesson/simple-web-server/pkg/money"
+)
+
+// feePlanVolumeWindow é a janela usada para enquadrar o criador nas faixas de volume
+const feePlanVolumeWindow = 30 * 24 * time.Hour
+
+var ErrFeePlanInvalid = errors.New("dados do plano de taxas inválidos")
+
+type FeePlanService interface {
+	EffectivePlan(creator *accountmodel.Creator, total money.Money) *salesmodel.FeePlan
+	CreatePlan(input salesmodel.FeePlanInput) (*salesmodel.FeePlan, error)
+	ListPlans() ([]*salesmodel.FeePlan, error)
+	FindPlanByPublicID(publicID string) (*salesmodel.FeePlan, error)
+	AssignToCreator(creatorID, feePlanID uint, startsAt time.Time, endsAt *time.Time, note string) error
+}
+
+type feePlanServiceImpl struct {
+	feePlanRepo      salesrepo.FeePlanRepository
+	subscriptionRepo subscriptionrepo.SubscriptionRepository
+}
+
+func NewFeePlanService(feePlanRepo salesrepo.FeePlanRepository, subscriptionRepo subscriptionrepo.SubscriptionRepository) FeePlanService {
+	return &feePlanServiceImpl{
+		feePlanRepo:      feePlanRepo,
+		subscriptionRepo: subscriptionRepo,
+	}
+}
+
+// EffectivePlan resolve o plano de taxas do criador no momento da venda.
+// Uma atribuição vigente (promoção ou negociação) prevalece; sem ela, vale o plano mais barato
+// para o total entre o padrão, o de assinantes (com assinatura paga ativa) e as faixas de volume alcançadas.
+// Falhas na consulta caem no plano padrão para não bloquear a venda.
+func (s *feePlanServiceImpl) EffectivePlan(creator *accountmodel.Creator, total money.Money) *salesmodel.FeePlan {
+	now := time.Now()
+
+	assignment, err := s.feePlanRepo.FindActiveAssignment(creator.ID, now)
+	if err != nil {
+		slog.Error("Erro ao buscar plano de taxas atribuído", "creatorID", creator.ID, "error", err)
+	}
+	if assignment != nil {
+		return &assignment.FeePlan
+	}
+
+	best := s.defaultPlan()
+	consider := func(plans []*salesmodel.FeePlan) {
+		for _, plan := range plans {
+			if plan.FeeFor(total) < best.FeeFor(total) {
+				best = plan
+			}
+		}
+	}
+
+	if s.isSubscriber(creator) {
+		plans, err := s.feePlanRepo.FindActiveByKind(salesmodel.FeePlanKindSubscriber)
+		if err != nil {
+			slog.Error("Erro ao buscar planos de assinantes", "error", err)
+		}
+		consider(plans)
+	}
+
+	tiers, err := s.feePlanRepo.FindActiveByKind(salesmodel.FeePlanKindVolume)
+	if err != nil {
+		slog.Error("Erro ao buscar faixas de volume", "error", err)
+	}
+	if len(tiers) > 0 {
+		volume, err := s.feePlanRepo.SumCompletedVolume(creator.ID, now.Add(-feePlanVolumeWindow))
+		if err != nil {
+			slog.Error("Erro ao calcular volume de vendas do criador", "creatorID", creator.ID, "error", err)
+		}
+		var reached []*salesmodel.FeePlan
+		for _, tier := range tiers {
+			if volume >= tier.MinMonthlyVolume {
+				reached = append(reached, tier)
+			}
+		}
+		consider(reached)
+	}
+
+	return best
+}
+
+// CreatePlan cadastra um plano ativo. Faixas de volume exigem o volume mínimo; nos outros tipos ele é ignorado
+func (s *feePlanServiceImpl) CreatePlan(input salesmodel.FeePlanInput) (*salesmodel.FeePlan, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Kind != salesmodel.FeePlanKindVolume {
+		input.MinMonthlyVolume = 0
+	}
+
+	switch {
+	case input.Name == "":
+		return nil, fmt.Errorf("%w: informe o nome", ErrFeePlanInvalid)
+	case !input.Kind.IsValid():
+		return nil, fmt.Errorf("%w: tipo %q desconhecido", ErrFeePlanInvalid, input.Kind)
+	case input.Percentage < 0 || input.Percentage >= 1:
+		return nil, fmt.Errorf("%w: o percentual deve ficar entre 0%% e 100%%", ErrFeePlanInvalid)
+	case input.FixedFeeCents < 0:
+		return nil, fmt.Errorf("%w: a parcela fixa não pode ser negativa", ErrFeePlanInvalid)
+	case input.Kind == salesmodel.FeePlanKindVolume && input.MinMonthlyVolume <= 0:
+		return nil, fmt.Errorf("%w: informe o volume mínimo da faixa", ErrFeePlanInvalid)
+	}
+
+	plan := &salesmodel.FeePlan{
+		Name:             input.Name,
+		Kind:             input.Kind,
+		Percentage:       input.Percentage,
+		FixedFeeCents:    input.FixedFeeCents,
+		MinMonthlyVolume: input.MinMonthlyVolume,
+		Active:           true,
+	}
+	if err := s.feePlanRepo.Create(plan); err != nil {
+		return nil, err
+	}
+	slog.Info("Plano de taxas cadastrado", "feePlanID", plan.ID, "kind", plan.Kind, "fee", plan.Display())
+	return plan, nil
+}
+
+func (s *feePlanServiceImpl) ListPlans() ([]*salesmodel.FeePlan, error) {
+	return s.feePlanRepo.FindAll()
+}
+
+func (s *feePlanServiceImpl) FindPlanByPublicID(publicID string) (*salesmodel.FeePlan, error) {
+	return s.feePlanRepo.FindByPublicID(publicID)
+}
+
+// AssignToCreator atribui um plano ao criador; endsAt nil mantém o plano sem prazo
+func (s *feePlanServiceImpl) AssignToCreator(creatorID, feePlanID uint, startsAt time.Time, endsAt *time.Time, note string) error {
+	if endsAt != nil && !endsAt.After(startsAt) {
+		return fmt.Errorf("o fim do plano deve ser posterior ao início")
+	}
+
+	plan, err := s.feePlanRepo.FindByID(feePlanID)
+	if err != nil {
+		return fmt.Errorf("plano de taxas não encontrado: %v", err)
+	}
+	if !plan.Active {
+		return fmt.Errorf("plano de taxas inativo")
+	}
+
+	err = s.feePlanRepo.CreateAssignment(&salesmodel.CreatorFeePlan{
+		CreatorID: creatorID,
+		FeePlanID: plan.ID,
+		StartsAt:  startsAt,
+		EndsAt:    endsAt,
+		Note:      note,
+	})
+	if err != nil {
+		return err
+	}
+	slog.Info("Plano de taxas atribuído ao criador", "creatorID", creatorID, "feePlanID", plan.ID, "startsAt", startsAt, "endsAt", endsAt)
+	return nil
+}
+
+// defaultPlan usa o plano padrão cadastrado ou, na falta dele, o de config.Business
+func (s *feePlanServiceImpl) defaultPlan() *salesmodel.FeePlan {
+	plans, err := s.feePlanRepo.FindActiveByKind(salesmodel.FeePlanKindDefault)
+	if err != nil {
+		slog.Error("Erro ao buscar plano de taxas padrão", "error", err)
+	}
+	if len(plans) > 0 {
+		return plans[0]
+	}
+	return salesmodel.DefaultFeePlan()
+}
+
+func (s *feePlanServiceImpl) isSubscriber(creator *accountmodel.Creator) bool {
+	if s.subscriptionRepo == nil || creator.UserID == 0 {
+		return false
+	}
+	subscription, err := s.subscriptionRepo.FindByUserID(creator.UserID)
+	if err != nil || subscription == nil {
+		return false
+	}
+	return subscription.IsSubscribed()
+}
@@ -0,0 +1,156 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	"github.com/anglesson/simple-web-server/internal/mocks"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	subscriptionmodel "github.com/anglesson/simple-web-server/internal/subscription/model"
+	"github.com/anglesson/simple-web-server/pkg/database"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupFeePlanTestDB(t *testing.T) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&salesmodel.FeePlan{}, &salesmodel.CreatorFeePlan{}, &salesmodel.Transaction{}))
+	database.DB = db
+}
+
+func createFeePlan(t *testing.T, plan *salesmodel.FeePlan) *salesmodel.FeePlan {
+	t.Helper()
+	plan.Active = true
+	require.NoError(t, database.DB.Create(plan).Error)
+	return plan
+}
+
+func newFeePlanService(subscribed bool) salesvc.FeePlanService {
+	subscriptionRepo := new(mocks.MockSubscriptionRepository)
+	status := "inactive"
+	if subscribed {
+		status = "active"
+	}
+	subscriptionRepo.On("FindByUserID", uint(9)).Return(&subscriptionmodel.Subscription{SubscriptionStatus: status}, nil)
+	return salesvc.NewFeePlanService(salesrepo.NewFeePlanRepository(database.DB), subscriptionRepo)
+}
+
+func feePlanCreator() *accountmodel.Creator {
+	creator := &accountmodel.Creator{UserID: 9}
+	creator.ID = 5
+	return creator
+}
+
+func TestFeePlanService_EffectivePlan_FallsBackToConfig(t *testing.T) {
+	setupFeePlanTestDB(t)
+
+	plan := newFeePlanService(false).EffectivePlan(feePlanCreator(), money.FromCents(10000))
+
+	assert.Equal(t, salesmodel.DefaultFeePlan().Percentage, plan.Percentage)
+	assert.Equal(t, salesmodel.DefaultFeePlan().FixedFeeCents, plan.FixedFeeCents)
+}
+
+func TestFeePlanService_EffectivePlan_SubscriberGetsLowerFee(t *testing.T) {
+	setupFeePlanTestDB(t)
+	createFeePlan(t, &salesmodel.FeePlan{Name: "Padrão", Kind: salesmodel.FeePlanKindDefault, Percentage: 0.0291, FixedFeeCents: 100})
+	createFeePlan(t, &salesmodel.FeePlan{Name: "Assinante", Kind: salesmodel.FeePlanKindSubscriber, Percentage: 0.015, FixedFeeCents: 50})
+
+	assert.Equal(t, "Padrão", newFeePlanService(false).EffectivePlan(feePlanCreator(), money.FromCents(10000)).Name)
+	assert.Equal(t, "Assinante", newFeePlanService(true).EffectivePlan(feePlanCreator(), money.FromCents(10000)).Name)
+}
+
+func TestFeePlanService_EffectivePlan_VolumeTiers(t *testing.T) {
+	setupFeePlanTestDB(t)
+	createFeePlan(t, &salesmodel.FeePlan{Name: "Padrão", Kind: salesmodel.FeePlanKindDefault, Percentage: 0.0291, FixedFeeCents: 100})
+	createFeePlan(t, &salesmodel.FeePlan{Name: "Faixa 1k", Kind: salesmodel.FeePlanKindVolume, Percentage: 0.025, FixedFeeCents: 100, MinMonthlyVolume: 100000})
+	createFeePlan(t, &salesmodel.FeePlan{Name: "Faixa 10k", Kind: salesmodel.FeePlanKindVolume, Percentage: 0.02, FixedFeeCents: 100, MinMonthlyVolume: 1000000})
+
+	service := newFeePlanService(false)
+	assert.Equal(t, "Padrão", service.EffectivePlan(feePlanCreator(), money.FromCents(10000)).Name)
+
+	// Vendas concluídas nos últimos 30 dias; a antiga e a pendente não contam
+	require.NoError(t, database.DB.Create(&salesmodel.Transaction{CreatorID: 5, TotalAmount: 150000, Currency: money.BRL, Status: salesmodel.TransactionStatusCompleted}).Error)
+	require.NoError(t, database.DB.Create(&salesmodel.Transaction{CreatorID: 5, TotalAmount: 900000, Currency: money.BRL, Status: salesmodel.TransactionStatusPending}).Error)
+	old := &salesmodel.Transaction{CreatorID: 5, TotalAmount: 900000, Currency: money.BRL, Status: salesmodel.TransactionStatusCompleted}
+	old.CreatedAt = time.Now().AddDate(0, -2, 0)
+	require.NoError(t, database.DB.Create(old).Error)
+
+	assert.Equal(t, "Faixa 1k", service.EffectivePlan(feePlanCreator(), money.FromCents(10000)).Name)
+}
+
+func TestFeePlanService_AssignmentOverridesAndExpires(t *testing.T) {
+	setupFeePlanTestDB(t)
+	createFeePlan(t, &salesmodel.FeePlan{Name: "Padrão", Kind: salesmodel.FeePlanKindDefault, Percentage: 0.0291, FixedFeeCents: 100})
+	promo := createFeePlan(t, &salesmodel.FeePlan{Name: "Black Friday", Kind: salesmodel.FeePlanKindCustom, Percentage: 0.01})
+	service := newFeePlanService(false)
+
+	now := time.Now()
+	ended := now.Add(-time.Hour)
+	require.NoError(t, service.AssignToCreator(5, promo.ID, now.Add(-48*time.Hour), &ended, "promoção encerrada"))
+	assert.Equal(t, "Padrão", service.EffectivePlan(feePlanCreator(), money.FromCents(10000)).Name)
+
+	ends := now.Add(24 * time.Hour)
+	require.NoError(t, service.AssignToCreator(5, promo.ID, now.Add(-time.Minute), &ends, "promoção vigente"))
+	plan := service.EffectivePlan(feePlanCreator(), money.FromCents(10000))
+	assert.Equal(t, "Black Friday", plan.Name)
+	assert.Equal(t, promo.ID, plan.ID)
+
+	err := service.AssignToCreator(5, promo.ID, now, &ended, "")
+	assert.Error(t, err, "o fim não pode ser anterior ao início")
+}
+
+func TestFeePlanService_CreatePlan(t *testing.T) {
+	setupFeePlanTestDB(t)
+	service := newFeePlanService(false)
+
+	plan, err := service.CreatePlan(salesmodel.FeePlanInput{Name: " Faixa 10k ", Kind: salesmodel.FeePlanKindVolume, Percentage: 0.02, FixedFeeCents: 100, MinMonthlyVolume: 1000000})
+	require.NoError(t, err)
+	assert.Equal(t, "Faixa 10k", plan.Name)
+	assert.True(t, plan.Active)
+	assert.NotEmpty(t, plan.PublicID)
+
+	found, err := service.FindPlanByPublicID(plan.PublicID)
+	require.NoError(t, err)
+	assert.Equal(t, plan.ID, found.ID)
+
+	custom, err := service.CreatePlan(salesmodel.FeePlanInput{Name: "Promo", Kind: salesmodel.FeePlanKindCustom, Percentage: 0.0199, MinMonthlyVolume: 500})
+	require.NoError(t, err)
+	assert.Zero(t, custom.MinMonthlyVolume, "volume mínimo só vale para faixas de volume")
+
+	plans, err := service.ListPlans()
+	require.NoError(t, err)
+	assert.Len(t, plans, 2)
+
+	missing, err := service.FindPlanByPublicID("fee_inexistente")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestFeePlanService_CreatePlan_Invalid(t *testing.T) {
+	setupFeePlanTestDB(t)
+	service := newFeePlanService(false)
+
+	invalid := []salesmodel.FeePlanInput{
+		{Kind: salesmodel.FeePlanKindCustom, Percentage: 0.02},
+		{Name: "Sem tipo", Kind: "promo", Percentage: 0.02},
+		{Name: "Percentual em pontos", Kind: salesmodel.FeePlanKindCustom, Percentage: 2.91},
+		{Name: "Fixa negativa", Kind: salesmodel.FeePlanKindCustom, Percentage: 0.02, FixedFeeCents: -1},
+		{Name: "Faixa sem volume", Kind: salesmodel.FeePlanKindVolume, Percentage: 0.02},
+	}
+	for _, input := range invalid {
+		_, err := service.CreatePlan(input)
+		assert.ErrorIs(t, err, salesvc.ErrFeePlanInvalid, input.Name)
+	}
+
+	plans, err := service.ListPlans()
+	require.NoError(t, err)
+	assert.Empty(t, plans)
+}
@@ -0,0 +1,389 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	"github.com/anglesson/simple-web-server/pkg/utils"
+)
+
+var ErrCheckoutAttemptNotFound = errors.New("tentativa de checkout não encontrada")
+var ErrCheckoutAttemptNotReviewable = errors.New("esta tentativa de checkout não aguarda revisão")
+
+// suspiciousCheckoutsLimit limita quantas tentativas suspeitas são exibidas ao criador
+const suspiciousCheckoutsLimit = 100
+
+// Limites das verificações de velocidade. As tentativas anteriores são contadas
+// antes de registrar a atual, então o limite é o número de tentativas já feitas.
+const (
+	velocityWindow           = time.Hour
+	identityWindow           = 24 * time.Hour
+	cpfVelocityLimit         = 6
+	emailVelocityLimit       = 6
+	ipVelocityLimit          = 15
+	deviceVelocityLimit      = 10
+	ipDistinctBuyersLimit    = 5
+	deviceDistinctBuyerLimit = 3
+	failedPaymentsLimit      = 2
+	failedPaymentsHighLimit  = 4
+)
+
+// disposableEmailDomains são provedores de email temporário usados para esconder a identidade do comprador
+var disposableEmailDomains = map[string]bool{
+	"10minutemail.com":  true,
+	"discard.email":     true,
+	"dispostable.com":   true,
+	"emailondeck.com":   true,
+	"fakeinbox.com":     true,
+	"getnada.com":       true,
+	"guerrillamail.com": true,
+	"maildrop.cc":       true,
+	"mailinator.com":    true,
+	"mailnesia.com":     true,
+	"mintemail.com":     true,
+	"mohmal.com":        true,
+	"sharklasers.com":   true,
+	"temp-mail.org":     true,
+	"tempmail.com":      true,
+	"throwawaymail.com": true,
+	"trashmail.com":     true,
+	"yopmail.com":       true,
+}
+
+// CheckoutRiskInput são os dados do checkout usados na avaliação de risco
+type CheckoutRiskInput struct {
+	CreatorID uint
+	EbookID   uint
+	Stage     salesmodel.CheckoutAttemptStage
+	Name      string
+	CPF       string
+	Birthdate string
+	Email     string
+	IP        string
+	DeviceID  string
+}
+
+// FraudService pontua o risco de cada tentativa de checkout. Tentativas de alto risco são
+// bloqueadas; as intermediárias seguem para o pagamento com a entrega retida até o criador
+// revisar a venda na lista de checkouts suspeitos.
+type FraudService interface {
+	Assess(input CheckoutRiskInput) (*salesmodel.CheckoutAttempt, error)
+	HoldForReview(attempt *salesmodel.CheckoutAttempt, purchaseID uint) error
+	RecordPaymentFailure(input CheckoutRiskInput, purchaseID uint) error
+	ListSuspicious(creatorID uint, onlyPending bool) ([]*salesmodel.CheckoutAttempt, error)
+	CountAwaitingReview(creatorID uint) (int64, error)
+	Review(creatorID uint, attemptPublicID string, approve bool) (*salesmodel.CheckoutAttempt, error)
+}
+
+type fraudServiceImpl struct {
+	attemptRepo      salesrepo.CheckoutAttemptRepository
+	verificationRepo salesrepo.CPFVerificationRepository
+	purchaseRepo     *salesrepo.PurchaseRepository
+	purchaseService  PurchaseService
+	transactionRepo  salesrepo.TransactionRepository
+	refundGateway    RefundGateway
+	emailService     IEmailService
+	reviewScore      int
+	blockScore       int
+	now              func() time.Time
+}
+
+func NewFraudService(
+	attemptRepo salesrepo.CheckoutAttemptRepository,
+	verificationRepo salesrepo.CPFVerificationRepository,
+	purchaseRepo *salesrepo.PurchaseRepository,
+	purchaseService PurchaseService,
+	transactionRepo salesrepo.TransactionRepository,
+	refundGateway RefundGateway,
+	emailService IEmailService,
+	reviewScore, blockScore int,
+) FraudService {
+	return &fraudServiceImpl{
+		attemptRepo:      attemptRepo,
+		verificationRepo: verificationRepo,
+		purchaseRepo:     purchaseRepo,
+		purchaseService:  purchaseService,
+		transactionRepo:  transactionRepo,
+		refundGateway:    refundGateway,
+		emailService:     emailService,
+		reviewScore:      reviewScore,
+		blockScore:       blockScore,
+		now:              time.Now,
+	}
+}
+
+// Assess avalia e registra a tentativa. O registro alimenta as verificações de velocidade
+// das próximas tentativas, inclusive quando ela é bloqueada.
+func (s *fraudServiceImpl) Assess(input CheckoutRiskInput) (*salesmodel.CheckoutAttempt, error) {
+	attempt := newCheckoutAttempt(input)
+	attempt.ApplySignals(s.collectSignals(input), s.reviewScore, s.blockScore)
+
+	if err := s.attemptRepo.Create(attempt); err != nil {
+		return nil, fmt.Errorf("erro ao registrar tentativa de checkout: %v", err)
+	}
+
+	if !attempt.IsAllowed() {
+		slog.Warn("Checkout suspeito", "attemptID", attempt.ID, "creatorID", attempt.CreatorID,
+			"score", attempt.Score, "decision", attempt.Decision, "reasons", attempt.Reasons)
+	}
+	return attempt, nil
+}
+
+// HoldForReview vincula a tentativa à compra e retém a entrega até a revisão do criador
+func (s *fraudServiceImpl) HoldForReview(attempt *salesmodel.CheckoutAttempt, purchaseID uint) error {
+	attempt.PurchaseID = &purchaseID
+	attempt.ReviewStatus = salesmodel.ReviewStatusPending
+	if err := s.attemptRepo.Update(attempt); err != nil {
+		return fmt.Errorf("erro ao enviar checkout para revisão: %v", err)
+	}
+	return s.purchaseRepo.SetHeldForReview(purchaseID, true)
+}
+
+// RecordPaymentFailure registra um pagamento recusado para a verificação de falhas repetidas
+func (s *fraudServiceImpl) RecordPaymentFailure(input CheckoutRiskInput, purchaseID uint) error {
+	input.Stage = salesmodel.CheckoutStagePaymentFailed
+	attempt := newCheckoutAttempt(input)
+	attempt.PurchaseID = &purchaseID
+	attempt.Decision = salesmodel.RiskDecisionAllow
+	if err := s.attemptRepo.Create(attempt); err != nil {
+		return fmt.Errorf("erro ao registrar falha de pagamento: %v", err)
+	}
+	return nil
+}
+
+func (s *fraudServiceImpl) ListSuspicious(creatorID uint, onlyPending bool) ([]*salesmodel.CheckoutAttempt, error) {
+	return s.attemptRepo.ListSuspiciousByCreator(creatorID, onlyPending, suspiciousCheckoutsLimit)
+}
+
+func (s *fraudServiceImpl) CountAwaitingReview(creatorID uint) (int64, error) {
+	return s.attemptRepo.CountAwaitingReviewByCreator(creatorID)
+}
+
+// Review registra a decisão do criador. Ao aprovar, a entrega é liberada e o link de download
+// é enviado se o pagamento já foi confirmado; ao recusar, a compra é bloqueada e o pagamento estornado.
+func (s *fraudServiceImpl) Review(creatorID uint, attemptPublicID string, approve bool) (*salesmodel.CheckoutAttempt, error) {
+	attempt, err := s.attemptRepo.FindByPublicID(attemptPublicID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar tentativa de checkout: %v", err)
+	}
+	if attempt == nil || attempt.CreatorID != creatorID {
+		return nil, ErrCheckoutAttemptNotFound
+	}
+	if !attempt.IsAwaitingReview() || attempt.PurchaseID == nil {
+		return nil, ErrCheckoutAttemptNotReviewable
+	}
+
+	// A recusa é aplicada antes de registrar a revisão, para que uma falha no estorno possa ser repetida
+	if !approve {
+		if err := s.reject(*attempt.PurchaseID); err != nil {
+			return nil, err
+		}
+	}
+
+	attempt.MarkReviewed(approve, s.now())
+	if err := s.attemptRepo.Update(attempt); err != nil {
+		return nil, fmt.Errorf("erro ao salvar revisão: %v", err)
+	}
+
+	if !approve {
+		return attempt, nil
+	}
+
+	if err := s.purchaseRepo.SetHeldForReview(*attempt.PurchaseID, false); err != nil {
+		return nil, err
+	}
+
+	purchase, err := s.purchaseRepo.FindByID(*attempt.PurchaseID)
+	if err != nil {
+		slog.Error("Erro ao buscar compra liberada na revisão", "purchaseID", *attempt.PurchaseID, "error", err)
+		return attempt, nil
+	}
+	if purchase.IsPaymentConfirmed() && !purchase.IsAwaitingRelease() {
+		s.emailService.SendLinkToDownload([]*salesmodel.Purchase{purchase})
+	}
+	return attempt, nil
+}
+
+// reject tira o acesso da compra recusada e desfaz a cobrança. A compra paga é bloqueada e estornada
+// (o webhook charge.refunded a passa para reembolsada); a ainda não paga é revogada e o checkout
+// aberto é encerrado para que não receba mais o pagamento.
+func (s *fraudServiceImpl) reject(purchaseID uint) error {
+	purchase, err := s.purchaseRepo.FindByID(purchaseID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar compra recusada: %v", err)
+	}
+
+	change := salesmodel.StatusChange{Actor: salesmodel.StatusActorCreator, Reason: "Checkout recusado na revisão de fraude"}
+	switch purchase.Status {
+	case salesmodel.PurchaseStatusPaid:
+		err = s.purchaseService.ChangeStatus(purchaseID, salesmodel.PurchaseStatusBlocked, change)
+	case salesmodel.PurchaseStatusPending:
+		err = s.purchaseService.ChangeStatus(purchaseID, salesmodel.PurchaseStatusRevoked, change)
+	}
+	if err != nil {
+		return err
+	}
+
+	transaction, err := s.transactionRepo.FindByPurchaseID(purchaseID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar transação da compra recusada: %v", err)
+	}
+	accountID := transaction.Creator.StripeConnectAccountID
+
+	if transaction.Status == salesmodel.TransactionStatusCompleted && transaction.StripePaymentIntentID != "" {
+		refundID, err := s.refundGateway.RefundPaymentIntent(accountID, transaction.StripePaymentIntentID,
+			map[string]string{"purchase_id": strconv.FormatUint(uint64(purchaseID), 10)})
+		if err != nil {
+			return fmt.Errorf("erro ao estornar pagamento da compra recusada: %v", err)
+		}
+		slog.Info("Estorno da compra recusada iniciado", "purchaseID", purchaseID, "refundID", refundID)
+		return nil
+	}
+
+	if purchase.CheckoutSessionID != "" {
+		// A sessão pode já ter expirado; nesse caso não há mais pagamento a impedir
+		if err := s.refundGateway.ExpireCheckoutSession(accountID, purchase.CheckoutSessionID); err != nil {
+			slog.Warn("Não foi possível encerrar o checkout da compra recusada", "purchaseID", purchaseID, "error", err)
+		}
+	}
+	return nil
+}
+
+// collectSignals executa as verificações de risco. Falhas de consulta são registradas
+// e ignoradas para não bloquear vendas legítimas por erro interno.
+func (s *fraudServiceImpl) collectSignals(input CheckoutRiskInput) []salesmodel.RiskSignal {
+	now := s.now()
+	checkoutStages := []salesmodel.CheckoutAttemptStage{salesmodel.CheckoutStageValidate, salesmodel.CheckoutStageCheckout}
+	failureStages := []salesmodel.CheckoutAttemptStage{salesmodel.CheckoutStagePaymentFailed}
+
+	var signals []salesmodel.RiskSignal
+	add := func(reason string, score int) {
+		signals = append(signals, salesmodel.RiskSignal{Reason: reason, Score: score})
+	}
+
+	if input.CPF != "" && s.count("cpf", input.CPF, checkoutStages, now.Add(-velocityWindow)) >= cpfVelocityLimit {
+		add("Muitas tentativas com o mesmo CPF na última hora", 25)
+	}
+	if input.Email != "" && s.count("email", input.Email, checkoutStages, now.Add(-velocityWindow)) >= emailVelocityLimit {
+		add("Muitas tentativas com o mesmo e-mail na última hora", 20)
+	}
+	if input.IP != "" {
+		if s.count("ip", input.IP, checkoutStages, now.Add(-velocityWindow)) >= ipVelocityLimit {
+			add("Muitas tentativas do mesmo IP na última hora", 20)
+		}
+		if s.countDistinctBuyers("ip", input.IP, input.Email, now.Add(-identityWindow)) >= ipDistinctBuyersLimit {
+			add("Vários compradores diferentes usando o mesmo IP", 25)
+		}
+	}
+	if input.DeviceID != "" {
+		if s.count("device_id", input.DeviceID, checkoutStages, now.Add(-velocityWindow)) >= deviceVelocityLimit {
+			add("Muitas tentativas do mesmo dispositivo na última hora", 20)
+		}
+		if s.countDistinctBuyers("device_id", input.DeviceID, input.Email, now.Add(-identityWindow)) >= deviceDistinctBuyerLimit {
+			add("Vários compradores diferentes usando o mesmo dispositivo", 35)
+		}
+	}
+
+	if isDisposableEmail(input.Email) {
+		add("E-mail descartável", 30)
+	}
+
+	if input.CPF != "" && input.Birthdate != "" {
+		verification, err := s.verificationRepo.FindLatest(input.CPF, input.Birthdate)
+		if err != nil {
+			slog.Error("Erro ao buscar consulta de CPF para análise de risco", "error", err)
+		} else if verification != nil {
+			switch {
+			case verification.Status == salesmodel.CPFVerificationRejected:
+				add("CPF e data de nascimento não conferem com a Receita Federal", 50)
+			case verification.IsVerified() && !namesMatch(input.Name, verification.RegisteredName):
+				add("Nome diferente do registrado na Receita Federal", 40)
+			}
+		}
+	}
+
+	failures := s.count("email", input.Email, failureStages, now.Add(-identityWindow))
+	if input.CPF != "" {
+		if byCPF := s.count("cpf", input.CPF, failureStages, now.Add(-identityWindow)); byCPF > failures {
+			failures = byCPF
+		}
+	}
+	switch {
+	case failures >= failedPaymentsHighLimit:
+		add(fmt.Sprintf("%d pagamentos recusados nas últimas 24 horas", failures), 60)
+	case failures >= failedPaymentsLimit:
+		add(fmt.Sprintf("%d pagamentos recusados nas últimas 24 horas", failures), 30)
+	}
+
+	return signals
+}
+
+func (s *fraudServiceImpl) count(field, value string, stages []salesmodel.CheckoutAttemptStage, since time.Time) int64 {
+	if value == "" {
+		return 0
+	}
+	total, err := s.attemptRepo.CountSince(field, value, stages, since)
+	if err != nil {
+		slog.Error("Erro ao contar tentativas de checkout", "field", field, "error", err)
+		return 0
+	}
+	return total
+}
+
+// countDistinctBuyers conta os compradores (emails) que passaram pelo mesmo IP ou dispositivo, incluindo o atual
+func (s *fraudServiceImpl) countDistinctBuyers(field, value, email string, since time.Time) int64 {
+	others, err := s.attemptRepo.CountOtherBuyersSince(field, value, strings.ToLower(strings.TrimSpace(email)), since)
+	if err != nil {
+		slog.Error("Erro ao contar compradores distintos", "field", field, "error", err)
+		return 0
+	}
+	return others + 1
+}
+
+func newCheckoutAttempt(input CheckoutRiskInput) *salesmodel.CheckoutAttempt {
+	return &salesmodel.CheckoutAttempt{
+		CreatorID: input.CreatorID,
+		EbookID:   input.EbookID,
+		Stage:     input.Stage,
+		Name:      strings.TrimSpace(input.Name),
+		CPF:       input.CPF,
+		Email:     strings.ToLower(strings.TrimSpace(input.Email)),
+		IP:        input.IP,
+		DeviceID:  input.DeviceID,
+	}
+}
+
+func isDisposableEmail(email string) bool {
+	at := strings.LastIndex(email, "@")
+	if at == -1 {
+		return false
+	}
+	return disposableEmailDomains[strings.ToLower(strings.TrimSpace(email[at+1:]))]
+}
+
+// namesMatch compara o nome informado com o registrado na Receita Federal ignorando acentos e
+// maiúsculas. Aceita nomes abreviados desde que todas as partes informadas existam no registro.
+func namesMatch(supplied, registered string) bool {
+	suppliedParts := strings.Fields(utils.NormalizeText(supplied))
+	registeredParts := strings.Fields(utils.NormalizeText(registered))
+	if len(suppliedParts) == 0 || len(registeredParts) == 0 {
+		return false
+	}
+
+	known := make(map[string]bool, len(registeredParts))
+	for _, part := range registeredParts {
+		known[part] = true
+	}
+	for _, part := range suppliedParts {
+		if !known[part] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,311 @@
+package service_test
+
+import (
+	"fmt"
+	"testing"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	"github.com/anglesson/simple-web-server/internal/mocks"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/database"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// fakeRefundGateway registra os estornos e os checkouts encerrados sem chamar o Stripe
+type fakeRefundGateway struct {
+	refunded []string
+	expired  []string
+}
+
+func (g *fakeRefundGateway) RefundPaymentIntent(accountID, paymentIntentID string, metadata map[string]string) (string, error) {
+	g.refunded = append(g.refunded, paymentIntentID)
+	return "re_" + paymentIntentID, nil
+}
+
+func (g *fakeRefundGateway) ExpireCheckoutSession(accountID, sessionID string) error {
+	g.expired = append(g.expired, sessionID)
+	return nil
+}
+
+func setupFraudService(t *testing.T) (salesvc.FraudService, *mocks.MockSalesEmailService) {
+	service, emailService, _ := setupFraudServiceWithRefunds(t)
+	return service, emailService
+}
+
+func setupFraudServiceWithRefunds(t *testing.T) (salesvc.FraudService, *mocks.MockSalesEmailService, *fakeRefundGateway) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&accountmodel.Creator{},
+		&salesmodel.Client{},
+		&librarymodel.File{},
+		&librarymodel.Ebook{},
+		&salesmodel.Purchase{},
+		&salesmodel.PurchaseStatusHistory{},
+		&salesmodel.Transaction{},
+		&salesmodel.TransactionSplit{},
+		&salesmodel.CheckoutAttempt{},
+		&salesmodel.CPFVerification{},
+	))
+	database.DB = db
+
+	emailService := new(mocks.MockSalesEmailService)
+	refundGateway := &fakeRefundGateway{}
+	purchaseRepository := salesrepo.NewPurchaseRepository()
+	service := salesvc.NewFraudService(
+		salesrepo.NewCheckoutAttemptRepository(db),
+		salesrepo.NewCPFVerificationRepository(db),
+		purchaseRepository,
+		salesvc.NewPurchaseService(purchaseRepository, emailService),
+		salesrepo.NewTransactionRepository(db),
+		refundGateway,
+		emailService,
+		40,
+		80,
+	)
+	return service, emailService, refundGateway
+}
+
+func fraudInput(email string) salesvc.CheckoutRiskInput {
+	return salesvc.CheckoutRiskInput{
+		CreatorID: 1,
+		EbookID:   1,
+		Stage:     salesmodel.CheckoutStageCheckout,
+		Name:      "Maria da Silva",
+		CPF:       "52998224725",
+		Birthdate: "1990-05-10",
+		Email:     email,
+		IP:        "200.1.1.1",
+		DeviceID:  "device-1",
+	}
+}
+
+func createVerification(t *testing.T, status salesmodel.CPFVerificationStatus, registeredName string) {
+	t.Helper()
+	verification := salesmodel.NewPendingCPFVerification(1, "Maria da Silva", "52998224725", "1990-05-10")
+	verification.Status = status
+	verification.RegisteredName = registeredName
+	require.NoError(t, database.DB.Create(verification).Error)
+}
+
+func TestFraudService_Assess_AllowsCleanCheckout(t *testing.T) {
+	service, _ := setupFraudService(t)
+	createVerification(t, salesmodel.CPFVerificationVerified, "MARIA DA SILVA SANTOS")
+
+	attempt, err := service.Assess(fraudInput("maria@gmail.com"))
+
+	require.NoError(t, err)
+	assert.True(t, attempt.IsAllowed())
+	assert.Equal(t, 0, attempt.Score)
+	assert.NotEmpty(t, attempt.PublicID)
+}
+
+func TestFraudService_Assess_AccentInsensitiveNameMatch(t *testing.T) {
+	service, _ := setupFraudService(t)
+	createVerification(t, salesmodel.CPFVerificationVerified, "MARIA DA SILVA")
+
+	input := fraudInput("maria@gmail.com")
+	input.Name = "María Silva"
+	attempt, err := service.Assess(input)
+
+	require.NoError(t, err)
+	assert.True(t, attempt.IsAllowed())
+}
+
+func TestFraudService_Assess_NameMismatchGoesToReview(t *testing.T) {
+	service, _ := setupFraudService(t)
+	createVerification(t, salesmodel.CPFVerificationVerified, "JOAO PEREIRA")
+
+	attempt, err := service.Assess(fraudInput("maria@gmail.com"))
+
+	require.NoError(t, err)
+	assert.True(t, attempt.NeedsReview())
+	assert.Equal(t, 40, attempt.Score)
+	assert.Contains(t, attempt.GetReasons(), "Nome diferente do registrado na Receita Federal")
+}
+
+func TestFraudService_Assess_BlocksDisposableEmailWithRejectedCPF(t *testing.T) {
+	service, _ := setupFraudService(t)
+	createVerification(t, salesmodel.CPFVerificationRejected, "")
+
+	attempt, err := service.Assess(fraudInput("buyer@mailinator.com"))
+
+	require.NoError(t, err)
+	assert.True(t, attempt.IsBlocked())
+	assert.Equal(t, 80, attempt.Score)
+	assert.Len(t, attempt.GetReasons(), 2)
+}
+
+func TestFraudService_Assess_ManyBuyersOnSameDeviceAndIP(t *testing.T) {
+	service, _ := setupFraudService(t)
+
+	for i := 0; i < 4; i++ {
+		_, err := service.Assess(fraudInput(fmt.Sprintf("buyer%d@gmail.com", i)))
+		require.NoError(t, err)
+	}
+
+	attempt, err := service.Assess(fraudInput("buyer4@gmail.com"))
+
+	require.NoError(t, err)
+	assert.True(t, attempt.NeedsReview())
+	assert.Equal(t, 60, attempt.Score)
+	assert.Contains(t, attempt.GetReasons(), "Vários compradores diferentes usando o mesmo IP")
+	assert.Contains(t, attempt.GetReasons(), "Vários compradores diferentes usando o mesmo dispositivo")
+}
+
+func TestFraudService_Assess_RepeatedPaymentFailures(t *testing.T) {
+	service, _ := setupFraudService(t)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, service.RecordPaymentFailure(fraudInput("maria@gmail.com"), uint(i+1)))
+	}
+
+	attempt, err := service.Assess(fraudInput("maria@gmail.com"))
+
+	require.NoError(t, err)
+	assert.Equal(t, 30, attempt.Score)
+	assert.True(t, attempt.IsAllowed())
+}
+
+// TestFraudService_Assess_FailuresByEncryptedCPF verifica que os pagamentos recusados com o mesmo CPF,
+// cifrado no banco, continuam somando mesmo quando o comprador troca de email
+func TestFraudService_Assess_FailuresByEncryptedCPF(t *testing.T) {
+	useEncryptedPII(t)
+	service, _ := setupFraudService(t)
+
+	for i, email := range []string{"maria@gmail.com", "maria.silva@gmail.com"} {
+		require.NoError(t, service.RecordPaymentFailure(fraudInput(email), uint(i+1)))
+	}
+
+	var stored string
+	require.NoError(t, database.DB.Raw("SELECT cpf FROM checkout_attempts LIMIT 1").Scan(&stored).Error)
+	assert.NotContains(t, stored, "52998224725")
+
+	attempt, err := service.Assess(fraudInput("maria.s@gmail.com"))
+
+	require.NoError(t, err)
+	assert.Contains(t, attempt.GetReasons(), "2 pagamentos recusados nas últimas 24 horas")
+}
+
+func createReviewPurchase(t *testing.T, confirmed bool) *salesmodel.Purchase {
+	t.Helper()
+	client := &salesmodel.Client{Name: "Maria", CPF: "52998224725", Email: "maria@gmail.com", Phone: "11999999999"}
+	require.NoError(t, database.DB.Create(client).Error)
+	ebook := &librarymodel.Ebook{Title: "Ebook", Value: money.FromCents(4000), Status: true}
+	require.NoError(t, database.DB.Create(ebook).Error)
+
+	purchase := salesmodel.NewPurchase(ebook.ID, client.ID, "hash-review")
+	if confirmed {
+		purchase.PaymentStatus = salesmodel.PaymentStatusConfirmed
+	}
+	require.NoError(t, database.DB.Create(purchase).Error)
+	return purchase
+}
+
+func TestFraudService_Review_ApproveReleasesPurchase(t *testing.T) {
+	service, emailService := setupFraudService(t)
+	createVerification(t, salesmodel.CPFVerificationVerified, "JOAO PEREIRA")
+	purchase := createReviewPurchase(t, true)
+
+	attempt, err := service.Assess(fraudInput("maria@gmail.com"))
+	require.NoError(t, err)
+	require.NoError(t, service.HoldForReview(attempt, purchase.ID))
+
+	var held salesmodel.Purchase
+	require.NoError(t, database.DB.First(&held, purchase.ID).Error)
+	assert.True(t, held.IsHeldForReview())
+
+	pending, err := service.CountAwaitingReview(1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), pending)
+
+	emailService.On("SendLinkToDownload", mock.Anything).Return().Once()
+
+	reviewed, err := service.Review(1, attempt.PublicID, true)
+
+	require.NoError(t, err)
+	assert.Equal(t, salesmodel.ReviewStatusApproved, reviewed.ReviewStatus)
+	require.NoError(t, database.DB.First(&held, purchase.ID).Error)
+	assert.False(t, held.IsHeldForReview())
+	emailService.AssertExpectations(t)
+
+	_, err = service.Review(1, attempt.PublicID, true)
+	assert.ErrorIs(t, err, salesvc.ErrCheckoutAttemptNotReviewable)
+}
+
+func createReviewTransaction(t *testing.T, purchase *salesmodel.Purchase, status salesmodel.TransactionStatus, paymentIntentID string) {
+	t.Helper()
+	creator := &accountmodel.Creator{Name: "Criador", Email: "criador@test.com", StripeConnectAccountID: "acct_criador"}
+	require.NoError(t, database.DB.Create(creator).Error)
+	transaction := &salesmodel.Transaction{PurchaseID: purchase.ID, CreatorID: creator.ID, Status: status,
+		StripePaymentIntentID: paymentIntentID, Currency: money.BRL, TotalAmount: 4000}
+	require.NoError(t, database.DB.Create(transaction).Error)
+}
+
+func TestFraudService_Review_RejectBlocksAndRefundsPaidPurchase(t *testing.T) {
+	service, emailService, refundGateway := setupFraudServiceWithRefunds(t)
+	createVerification(t, salesmodel.CPFVerificationVerified, "JOAO PEREIRA")
+	purchase := createReviewPurchase(t, true)
+	require.NoError(t, database.DB.Model(purchase).Update("status", salesmodel.PurchaseStatusPaid).Error)
+	createReviewTransaction(t, purchase, salesmodel.TransactionStatusCompleted, "pi_suspeito")
+
+	attempt, err := service.Assess(fraudInput("maria@gmail.com"))
+	require.NoError(t, err)
+	require.NoError(t, service.HoldForReview(attempt, purchase.ID))
+
+	reviewed, err := service.Review(1, attempt.PublicID, false)
+
+	require.NoError(t, err)
+	assert.Equal(t, salesmodel.ReviewStatusRejected, reviewed.ReviewStatus)
+	var rejected salesmodel.Purchase
+	require.NoError(t, database.DB.First(&rejected, purchase.ID).Error)
+	assert.Equal(t, salesmodel.PurchaseStatusBlocked, rejected.Status)
+	assert.True(t, rejected.IsHeldForReview())
+	assert.Equal(t, []string{"pi_suspeito"}, refundGateway.refunded)
+	assert.Empty(t, refundGateway.expired)
+	emailService.AssertNotCalled(t, "SendLinkToDownload", mock.Anything)
+}
+
+func TestFraudService_Review_RejectRevokesUnpaidPurchaseAndClosesCheckout(t *testing.T) {
+	service, _, refundGateway := setupFraudServiceWithRefunds(t)
+	createVerification(t, salesmodel.CPFVerificationVerified, "JOAO PEREIRA")
+	purchase := createReviewPurchase(t, false)
+	require.NoError(t, database.DB.Model(purchase).Update("checkout_session_id", "cs_suspeito").Error)
+	createReviewTransaction(t, purchase, salesmodel.TransactionStatusPending, "")
+
+	attempt, err := service.Assess(fraudInput("maria@gmail.com"))
+	require.NoError(t, err)
+	require.NoError(t, service.HoldForReview(attempt, purchase.ID))
+
+	_, err = service.Review(1, attempt.PublicID, false)
+
+	require.NoError(t, err)
+	var rejected salesmodel.Purchase
+	require.NoError(t, database.DB.First(&rejected, purchase.ID).Error)
+	assert.Equal(t, salesmodel.PurchaseStatusRevoked, rejected.Status)
+	assert.Empty(t, refundGateway.refunded)
+	assert.Equal(t, []string{"cs_suspeito"}, refundGateway.expired)
+}
+
+func TestFraudService_Review_OtherCreator(t *testing.T) {
+	service, _ := setupFraudService(t)
+	createVerification(t, salesmodel.CPFVerificationVerified, "JOAO PEREIRA")
+	purchase := createReviewPurchase(t, false)
+
+	attempt, err := service.Assess(fraudInput("maria@gmail.com"))
+	require.NoError(t, err)
+	require.NoError(t, service.HoldForReview(attempt, purchase.ID))
+
+	_, err = service.Review(2, attempt.PublicID, true)
+
+	assert.ErrorIs(t, err, salesvc.ErrCheckoutAttemptNotFound)
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	"github.com/anglesson/simple-web-server/pkg/gov"
+	"github.com/anglesson/simple-web-server/pkg/money"
+)
+
+var ErrManualSaleInvalid = errors.New("dados da venda manual inválidos")
+var ErrManualSaleDuplicate = errors.New("o comprador já tem acesso a este ebook")
+
+// ManualSaleInput são os dados de uma venda que o criador recebeu fora da plataforma (Pix direto, em mãos...)
+type ManualSaleInput struct {
+	CreatorID     uint
+	EbookID       uint
+	BuyerName     string
+	BuyerEmail    string
+	BuyerCPF      string
+	BuyerPhone    string
+	Amount        money.Money
+	PaymentMethod string
+	PaidAt        time.Time
+}
+
+// ManualSaleService registra vendas feitas fora do checkout para que o comprador
+// receba o ebook com marca d'água pela plataforma
+type ManualSaleService interface {
+	RegisterManualSale(input ManualSaleInput) (*salesmodel.Purchase, error)
+}
+
+type manualSaleServiceImpl struct {
+	purchaseService PurchaseService
+	transactionRepo salesrepo.TransactionRepository
+	clientRepo      salesrepo.ClientRepository
+	emailService    IEmailService
+}
+
+func NewManualSaleService(
+	purchaseService PurchaseService,
+	transactionRepo salesrepo.TransactionRepository,
+	clientRepo salesrepo.ClientRepository,
+	emailService IEmailService,
+) ManualSaleService {
+	return &manualSaleServiceImpl{
+		purchaseService: purchaseService,
+		transactionRepo: transactionRepo,
+		clientRepo:      clientRepo,
+		emailService:    emailService,
+	}
+}
+
+// RegisterManualSale cria a compra já paga, a transação fora da plataforma e envia o link de download.
+// Pré-vendas ficam aguardando o lançamento e recebem o link pelo job de liberação.
+func (s *manualSaleServiceImpl) RegisterManualSale(input ManualSaleInput) (*salesmodel.Purchase, error) {
+	input.BuyerName = strings.TrimSpace(input.BuyerName)
+	input.BuyerEmail = strings.ToLower(strings.TrimSpace(input.BuyerEmail))
+	input.BuyerCPF = onlyDigits(input.BuyerCPF)
+	if err := validateManualSale(input); err != nil {
+		return nil, err
+	}
+
+	client, err := s.findOrCreateClient(input)
+	if err != nil {
+		return nil, err
+	}
+
+	purchase, err := s.purchaseService.CreatePurchaseWithResult(input.EbookID, client.ID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar compra: %v", err)
+	}
+	if err := checkManualSaleStatus(purchase); err != nil {
+		return nil, err
+	}
+
+	transaction, err := s.saveTransaction(purchase.ID, input)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao registrar transação: %v", err)
+	}
+
+	reason := "Venda manual registrada pelo criador (" + transaction.GetPaymentMethodLabel() + ")"
+	if err := s.purchaseService.ConfirmOfflinePayment(purchase.ID, input.CreatorID, reason); err != nil {
+		return nil, err
+	}
+
+	purchase, err = s.purchaseService.GetPurchaseByID(purchase.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("Venda manual registrada",
+		"purchaseID", purchase.ID,
+		"transactionID", transaction.ID,
+		"creatorID", input.CreatorID,
+		"amount", transaction.TotalAmount,
+		"paymentMethod", transaction.PaymentMethod)
+
+	if !purchase.IsAwaitingRelease() {
+		go s.emailService.SendLinkToDownload([]*salesmodel.Purchase{purchase})
+	}
+	return purchase, nil
+}
+
+// checkManualSaleStatus recusa compras que já dão ou retêm o acesso. Compras pendentes e expiradas
+// (checkout abandonado, pago depois por Pix direto) podem virar a venda manual.
+func checkManualSaleStatus(purchase *salesmodel.Purchase) error {
+	switch {
+	case purchase.IsHeldForReview(),
+		purchase.Status == salesmodel.PurchaseStatusPaid,
+		purchase.Status == salesmodel.PurchaseStatusBlocked:
+		return ErrManualSaleDuplicate
+	case purchase.Status != salesmodel.PurchaseStatusPending && purchase.Status != salesmodel.PurchaseStatusExpired:
+		return fmt.Errorf("%w: compra %s", ErrInvalidStatusTransition, strings.ToLower(purchase.Status.Label()))
+	}
+	return nil
+}
+
+// saveTransaction registra a transação fora da plataforma. Se o comprador tinha abandonado um checkout
+// desse ebook, a transação pendente dele passa a ser a da venda manual, mantendo uma por compra
+func (s *manualSaleServiceImpl) saveTransaction(purchaseID uint, input ManualSaleInput) (*salesmodel.Transaction, error) {
+	transaction := salesmodel.NewManualTransaction(purchaseID, input.CreatorID, input.Amount, input.PaymentMethod, input.PaidAt)
+
+	pending, err := s.transactionRepo.FindByPurchaseID(purchaseID)
+	if err != nil || pending == nil || pending.Status != salesmodel.TransactionStatusPending {
+		return transaction, s.transactionRepo.CreateTransaction(transaction)
+	}
+
+	transaction.Model = pending.Model
+	transaction.PublicID = pending.PublicID
+	transaction.StripePaymentIntentID = pending.StripePaymentIntentID
+	// O dinheiro não passou pelo Stripe, então não há repasse a coautores e afiliados
+	transaction.Splits = pending.Splits
+	for i := range transaction.Splits {
+		transaction.Splits[i].Status = salesmodel.TransactionSplitStatusCanceled
+		transaction.Splits[i].Amount = 0
+	}
+	return transaction, s.transactionRepo.UpdateTransaction(transaction)
+}
+
+// findOrCreateClient reaproveita o cadastro do comprador pelo CPF ou, sem CPF, pelo email entre os
+// clientes do próprio criador. Sem correspondência o comprador é cadastrado em uma linha nova.
+func (s *manualSaleServiceImpl) findOrCreateClient(input ManualSaleInput) (*salesmodel.Client, error) {
+	var client *salesmodel.Client
+	var err error
+	if input.BuyerCPF != "" {
+		client, err = s.clientRepo.FindByCPF(input.BuyerCPF)
+	} else {
+		client, err = s.clientRepo.FindByEmailAndCreator(input.BuyerEmail, input.CreatorID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if client != nil {
+		return client, nil
+	}
+
+	client = salesmodel.NewClient(input.BuyerName, input.BuyerCPF, "", input.BuyerEmail, input.BuyerPhone)
+	if err := s.clientRepo.Save(client); err != nil {
+		return nil, fmt.Errorf("erro ao cadastrar comprador: %v", err)
+	}
+	return client, nil
+}
+
+func validateManualSale(input ManualSaleInput) error {
+	switch {
+	case input.CreatorID == 0 || input.EbookID == 0:
+		return fmt.Errorf("%w: ebook não informado", ErrManualSaleInvalid)
+	case input.BuyerName == "" || input.BuyerEmail == "":
+		return fmt.Errorf("%w: informe o nome e o email do comprador", ErrManualSaleInvalid)
+	case input.BuyerCPF != "" && !gov.IsValidCPF(input.BuyerCPF):
+		return fmt.Errorf("%w: CPF inválido", ErrManualSaleInvalid)
+	case !input.Amount.IsPositive():
+		return fmt.Errorf("%w: informe o valor recebido", ErrManualSaleInvalid)
+	case !salesmodel.IsManualPaymentMethod(input.PaymentMethod):
+		return fmt.Errorf("%w: forma de pagamento inválida", ErrManualSaleInvalid)
+	case input.PaidAt.IsZero() || input.PaidAt.After(time.Now()):
+		return fmt.Errorf("%w: a data do pagamento não pode ser futura", ErrManualSaleInvalid)
+	}
+	return nil
+}
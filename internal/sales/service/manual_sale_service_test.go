@@ -0,0 +1,230 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	"github.com/anglesson/simple-web-server/internal/mocks"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	salesrepogorm "github.com/anglesson/simple-web-server/internal/sales/repository/gorm"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/database"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type manualSaleFixture struct {
+	service salesvc.ManualSaleService
+	email   *mocks.MockSalesEmailService
+	sent    chan *salesmodel.Purchase
+	creator *accountmodel.Creator
+	ebook   *librarymodel.Ebook
+}
+
+func setupManualSale(t *testing.T) *manualSaleFixture {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&accountmodel.Creator{}, &salesmodel.Client{}, &librarymodel.File{}, &librarymodel.Ebook{},
+		&salesmodel.Purchase{}, &salesmodel.PurchaseStatusHistory{}, &salesmodel.Transaction{}, &salesmodel.TransactionSplit{},
+		&salesmodel.CreatorClient{}))
+	database.DB = db
+
+	creator := &accountmodel.Creator{Name: "Maria", Email: "maria@test.com"}
+	require.NoError(t, db.Create(creator).Error)
+	ebook := &librarymodel.Ebook{Title: "Ebook Manual", Value: money.FromCents(5000), Status: true, CreatorID: creator.ID}
+	require.NoError(t, db.Create(ebook).Error)
+
+	fixture := &manualSaleFixture{email: new(mocks.MockSalesEmailService), sent: make(chan *salesmodel.Purchase, 1), creator: creator, ebook: ebook}
+	fixture.email.On("SendLinkToDownload", mock.Anything).Run(func(args mock.Arguments) {
+		fixture.sent <- args.Get(0).([]*salesmodel.Purchase)[0]
+	}).Return()
+
+	purchaseService := salesvc.NewPurchaseService(salesrepo.NewPurchaseRepository(), fixture.email)
+	fixture.service = salesvc.NewManualSaleService(purchaseService, salesrepo.NewTransactionRepository(db),
+		salesrepogorm.NewClientGormRepository(), fixture.email)
+	return fixture
+}
+
+func (f *manualSaleFixture) input() salesvc.ManualSaleInput {
+	return salesvc.ManualSaleInput{
+		CreatorID:     f.creator.ID,
+		EbookID:       f.ebook.ID,
+		BuyerName:     "João Leitor",
+		BuyerEmail:    " Joao@Test.com ",
+		BuyerCPF:      "529.982.247-25",
+		Amount:        money.FromCents(4000),
+		PaymentMethod: salesmodel.ManualPaymentPix,
+		PaidAt:        time.Now().Add(-48 * time.Hour),
+	}
+}
+
+// TestManualSaleService_RegistersPaidOffPlatformSale verifica a compra paga, a transação sem taxas e o envio do ebook.
+func TestManualSaleService_RegistersPaidOffPlatformSale(t *testing.T) {
+	f := setupManualSale(t)
+	input := f.input()
+
+	purchase, err := f.service.RegisterManualSale(input)
+	require.NoError(t, err)
+
+	assert.Equal(t, salesmodel.PurchaseStatusPaid, purchase.Status)
+	assert.True(t, purchase.IsPaymentConfirmed())
+	assert.Equal(t, "52998224725", purchase.Client.CPF)
+	assert.Equal(t, "joao@test.com", purchase.Client.Email)
+
+	var transaction salesmodel.Transaction
+	require.NoError(t, database.DB.Where("purchase_id = ?", purchase.ID).First(&transaction).Error)
+	assert.True(t, transaction.OffPlatform)
+	assert.Equal(t, salesmodel.TransactionStatusCompleted, transaction.Status)
+	assert.Equal(t, int64(4000), transaction.TotalAmount)
+	assert.Equal(t, int64(4000), transaction.CreatorAmount)
+	assert.Zero(t, transaction.PlatformAmount)
+	assert.Zero(t, transaction.StripeProcessingFee)
+	assert.Equal(t, "pix", transaction.PaymentMethod)
+	assert.WithinDuration(t, input.PaidAt, *transaction.ProcessedAt, time.Second)
+
+	var history salesmodel.PurchaseStatusHistory
+	require.NoError(t, database.DB.Where("purchase_id = ?", purchase.ID).First(&history).Error)
+	assert.Equal(t, salesmodel.StatusActorCreator, history.Actor)
+
+	select {
+	case delivered := <-f.sent:
+		assert.Equal(t, purchase.ID, delivered.ID)
+	case <-time.After(time.Second):
+		t.Fatal("link de download não enviado")
+	}
+
+	// A venda manual não pesa no volume usado pelos planos de taxa
+	volume, err := salesrepo.NewFeePlanRepository(database.DB).SumCompletedVolume(f.creator.ID, time.Now().AddDate(0, -1, 0))
+	require.NoError(t, err)
+	assert.Zero(t, volume)
+}
+
+// TestManualSaleService_RejectsBuyerWithAccess verifica que a mesma venda não é registrada duas vezes.
+func TestManualSaleService_RejectsBuyerWithAccess(t *testing.T) {
+	f := setupManualSale(t)
+	_, err := f.service.RegisterManualSale(f.input())
+	require.NoError(t, err)
+
+	_, err = f.service.RegisterManualSale(f.input())
+	assert.ErrorIs(t, err, salesvc.ErrManualSaleDuplicate)
+
+	var count int64
+	database.DB.Model(&salesmodel.Transaction{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+// TestManualSaleService_ReusesAbandonedCheckout verifica que a transação pendente do checkout vira a venda manual.
+func TestManualSaleService_ReusesAbandonedCheckout(t *testing.T) {
+	f := setupManualSale(t)
+	client := &salesmodel.Client{Name: "João Leitor", CPF: "52998224725", Email: "joao@test.com"}
+	require.NoError(t, database.DB.Create(client).Error)
+	purchase := salesmodel.NewPurchase(f.ebook.ID, client.ID, "hash-abandonado")
+	require.NoError(t, database.DB.Create(purchase).Error)
+	pending := salesmodel.NewTransaction(purchase.ID, f.creator.ID, salesmodel.SplitTypePercentage)
+	pending.AddSplit(99, 0.3)
+	pending.CalculateSplit(money.FromCents(5000))
+	require.NoError(t, database.DB.Create(pending).Error)
+
+	registered, err := f.service.RegisterManualSale(f.input())
+	require.NoError(t, err)
+	assert.Equal(t, purchase.ID, registered.ID)
+
+	var transactions []salesmodel.Transaction
+	require.NoError(t, database.DB.Preload("Splits").Find(&transactions).Error)
+	require.Len(t, transactions, 1)
+	assert.Equal(t, pending.PublicID, transactions[0].PublicID)
+	assert.True(t, transactions[0].OffPlatform)
+	assert.Equal(t, int64(4000), transactions[0].CreatorAmount)
+	require.Len(t, transactions[0].Splits, 1)
+	assert.Equal(t, salesmodel.TransactionSplitStatusCanceled, transactions[0].Splits[0].Status)
+}
+
+// TestManualSaleService_UpgradesExpiredCheckout verifica que o checkout abandonado e pago depois por Pix vira a venda manual.
+func TestManualSaleService_UpgradesExpiredCheckout(t *testing.T) {
+	f := setupManualSale(t)
+	client := &salesmodel.Client{Name: "João Leitor", CPF: "52998224725", Email: "joao@test.com"}
+	require.NoError(t, database.DB.Create(client).Error)
+	purchase := salesmodel.NewPurchase(f.ebook.ID, client.ID, "hash-expirado")
+	purchase.Status = salesmodel.PurchaseStatusExpired
+	require.NoError(t, database.DB.Create(purchase).Error)
+
+	registered, err := f.service.RegisterManualSale(f.input())
+	require.NoError(t, err)
+	assert.Equal(t, purchase.ID, registered.ID)
+	assert.Equal(t, salesmodel.PurchaseStatusPaid, registered.Status)
+}
+
+// TestManualSaleService_RejectsPurchaseHeldForReview verifica que a compra retida na análise de risco não é paga de novo.
+func TestManualSaleService_RejectsPurchaseHeldForReview(t *testing.T) {
+	f := setupManualSale(t)
+	client := &salesmodel.Client{Name: "João Leitor", CPF: "52998224725", Email: "joao@test.com"}
+	require.NoError(t, database.DB.Create(client).Error)
+	purchase := salesmodel.NewPurchase(f.ebook.ID, client.ID, "hash-retida")
+	purchase.HeldForReview = true
+	require.NoError(t, database.DB.Create(purchase).Error)
+
+	_, err := f.service.RegisterManualSale(f.input())
+	assert.ErrorIs(t, err, salesvc.ErrManualSaleDuplicate)
+}
+
+// TestManualSaleService_BuyerWithoutCPF verifica que o comprador sem CPF não sobrescreve outro cliente sem CPF
+// e é reaproveitado pelo email apenas entre os clientes do criador.
+func TestManualSaleService_BuyerWithoutCPF(t *testing.T) {
+	f := setupManualSale(t)
+	foreign := &salesmodel.Client{Name: "John Reader", Email: "john@test.com", BuyerType: salesmodel.BuyerTypeForeign, Country: "US"}
+	require.NoError(t, database.DB.Create(foreign).Error)
+	otherCreatorClient := &salesmodel.Client{Name: "Joana Outra", Email: "joao@test.com"}
+	require.NoError(t, database.DB.Create(otherCreatorClient).Error)
+
+	input := f.input()
+	input.BuyerCPF = ""
+	purchase, err := f.service.RegisterManualSale(input)
+	require.NoError(t, err)
+	assert.NotEqual(t, foreign.ID, purchase.ClientID)
+	assert.NotEqual(t, otherCreatorClient.ID, purchase.ClientID)
+
+	var untouched salesmodel.Client
+	require.NoError(t, database.DB.First(&untouched, foreign.ID).Error)
+	assert.Equal(t, "John Reader", untouched.Name)
+	assert.Equal(t, "john@test.com", untouched.Email)
+	var otherUntouched salesmodel.Client
+	require.NoError(t, database.DB.First(&otherUntouched, otherCreatorClient.ID).Error)
+	assert.Equal(t, "Joana Outra", otherUntouched.Name)
+
+	// A segunda venda do mesmo comprador, de outro ebook, reaproveita o cadastro pelo email
+	second := &librarymodel.Ebook{Title: "Outro Ebook", Value: money.FromCents(5000), Status: true, CreatorID: f.creator.ID}
+	require.NoError(t, database.DB.Create(second).Error)
+	input.EbookID = second.ID
+	again, err := f.service.RegisterManualSale(input)
+	require.NoError(t, err)
+	assert.Equal(t, purchase.ClientID, again.ClientID)
+}
+
+// TestManualSaleService_Validation verifica os dados obrigatórios da venda manual.
+func TestManualSaleService_Validation(t *testing.T) {
+	f := setupManualSale(t)
+
+	cases := map[string]func(*salesvc.ManualSaleInput){
+		"data futura":        func(in *salesvc.ManualSaleInput) { in.PaidAt = time.Now().Add(48 * time.Hour) },
+		"valor zerado":       func(in *salesvc.ManualSaleInput) { in.Amount = money.FromCents(0) },
+		"forma desconhecida": func(in *salesvc.ManualSaleInput) { in.PaymentMethod = "card" },
+		"CPF inválido":       func(in *salesvc.ManualSaleInput) { in.BuyerCPF = "111.111.111-11" },
+		"sem email":          func(in *salesvc.ManualSaleInput) { in.BuyerEmail = " " },
+	}
+	for name, change := range cases {
+		t.Run(name, func(t *testing.T) {
+			input := f.input()
+			change(&input)
+			_, err := f.service.RegisterManualSale(input)
+			assert.ErrorIs(t, err, salesvc.ErrManualSaleInvalid)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"errors"
+	"log/slog"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+)
+
+var ErrPurchaseClientWithoutEmail = errors.New("cliente sem email válido")
+
+// PaymentConfirmationService entrega uma compra de ebook paga. É o mesmo caminho para o webhook
+// checkout.session.completed e para a conciliação que recupera um webhook perdido.
+type PaymentConfirmationService interface {
+	ConfirmEbookPayment(purchase *salesmodel.Purchase, paymentIntentID string) error
+}
+
+type paymentConfirmationServiceImpl struct {
+	purchaseService    PurchaseService
+	transactionService TransactionService
+	recoveryService    CheckoutRecoveryService
+	waitlistService    WaitlistService
+	emailService       IEmailService
+}
+
+func NewPaymentConfirmationService(
+	purchaseService PurchaseService,
+	transactionService TransactionService,
+	recoveryService CheckoutRecoveryService,
+	waitlistService WaitlistService,
+	emailService IEmailService,
+) PaymentConfirmationService {
+	return &paymentConfirmationServiceImpl{
+		purchaseService:    purchaseService,
+		transactionService: transactionService,
+		recoveryService:    recoveryService,
+		waitlistService:    waitlistService,
+		emailService:       emailService,
+	}
+}
+
+// ConfirmEbookPayment conclui a transação, repassa os splits, confirma a compra e envia o link de download.
+// A compra precisa vir com Ebook e Client carregados. Todas as etapas são idempotentes.
+func (s *paymentConfirmationServiceImpl) ConfirmEbookPayment(purchase *salesmodel.Purchase, paymentIntentID string) error {
+	// A transação pendente foi criada durante o checkout (CreateEbookCheckout).
+	// Aqui ela apenas é confirmada — nunca se cria uma segunda transação para a mesma purchase.
+	if err := s.transactionService.UpdateTransactionToCompleted(purchase.ID, paymentIntentID); err != nil {
+		slog.Warn("Não foi possível atualizar a transação", "purchaseID", purchase.ID, "error", err)
+	} else {
+		slog.Info("Transação atualizada para completed", "purchaseID", purchase.ID)
+
+		// Repasse da participação dos coautores a partir da conta principal
+		if err := s.transactionService.TransferSplits(purchase.ID); err != nil {
+			slog.Error("Erro nos repasses aos coautores", "purchaseID", purchase.ID, "error", err)
+		}
+	}
+
+	if err := s.purchaseService.ConfirmPayment(purchase.ID); err != nil {
+		slog.Error("Erro ao confirmar pagamento", "purchaseID", purchase.ID, "error", err)
+	} else {
+		slog.Info("Pagamento confirmado", "purchaseID", purchase.ID)
+	}
+
+	if err := s.recoveryService.MarkRecovered(purchase.ID); err != nil {
+		slog.Error("Erro ao marcar checkout recuperado", "purchaseID", purchase.ID, "error", err)
+	}
+
+	if err := s.waitlistService.MarkConverted(purchase.ID); err != nil {
+		slog.Error("Erro ao marcar conversão da lista de espera", "purchaseID", purchase.ID, "error", err)
+	}
+
+	if purchase.Client.Email == "" {
+		slog.Warn("Cliente sem email", "clientID", purchase.ClientID)
+		return ErrPurchaseClientWithoutEmail
+	}
+
+	if purchase.Ebook.IsAwaitingRelease() {
+		slog.Info("Pré-venda confirmada, email será enviado no lançamento",
+			"purchaseID", purchase.ID, "releaseDate", purchase.Ebook.GetReleaseDateBR())
+		return nil
+	}
+
+	if purchase.IsHeldForReview() {
+		slog.Info("Compra retida para revisão do criador, email de download não enviado", "purchaseID", purchase.ID)
+		return nil
+	}
+
+	go s.emailService.SendLinkToDownload([]*salesmodel.Purchase{purchase})
+
+	return nil
+}
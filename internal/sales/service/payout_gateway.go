@@ -0,0 +1,85 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/config"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/balance"
+	"github.com/stripe/stripe-go/v76/balancetransaction"
+	"github.com/stripe/stripe-go/v76/payout"
+)
+
+// ConnectBalance é o saldo da conta Stripe Connect do criador, separado por moeda
+type ConnectBalance struct {
+	Available []money.Money
+	Pending   []money.Money
+}
+
+// PayoutGateway consulta saldo, repasses e movimentações nas contas Stripe Connect dos criadores
+type PayoutGateway interface {
+	GetBalance(accountID string) (*ConnectBalance, error)
+	ListPayouts(accountID string, createdSince time.Time) ([]*stripe.Payout, error)
+	ListPayoutBalanceTransactions(accountID, payoutID string) ([]*stripe.BalanceTransaction, error)
+}
+
+type StripePayoutGateway struct{}
+
+func NewStripePayoutGateway() PayoutGateway {
+	if stripe.Key == "" {
+		stripe.Key = config.AppConfig.StripeSecretKey
+	}
+	return &StripePayoutGateway{}
+}
+
+func (g *StripePayoutGateway) GetBalance(accountID string) (*ConnectBalance, error) {
+	params := &stripe.BalanceParams{}
+	params.SetStripeAccount(accountID)
+
+	b, err := balance.Get(params)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ConnectBalance{}
+	for _, amount := range b.Available {
+		result.Available = append(result.Available, money.New(amount.Amount, strings.ToUpper(string(amount.Currency))))
+	}
+	for _, amount := range b.Pending {
+		result.Pending = append(result.Pending, money.New(amount.Amount, strings.ToUpper(string(amount.Currency))))
+	}
+	return result, nil
+}
+
+func (g *StripePayoutGateway) ListPayouts(accountID string, createdSince time.Time) ([]*stripe.Payout, error) {
+	params := &stripe.PayoutListParams{
+		CreatedRange: &stripe.RangeQueryParams{GreaterThanOrEqual: createdSince.Unix()},
+	}
+	params.SetStripeAccount(accountID)
+
+	var payouts []*stripe.Payout
+	iter := payout.List(params)
+	for iter.Next() {
+		payouts = append(payouts, iter.Payout())
+	}
+	return payouts, iter.Err()
+}
+
+// ListPayoutBalanceTransactions traz as movimentações do repasse com a origem expandida,
+// de onde sai o PaymentIntent que liga a movimentação à Transaction
+func (g *StripePayoutGateway) ListPayoutBalanceTransactions(accountID, payoutID string) ([]*stripe.BalanceTransaction, error) {
+	params := &stripe.BalanceTransactionListParams{
+		Payout: stripe.String(payoutID),
+	}
+	params.AddExpand("data.source")
+	params.SetStripeAccount(accountID)
+
+	var transactions []*stripe.BalanceTransaction
+	iter := balancetransaction.List(params)
+	for iter.Next() {
+		transactions = append(transactions, iter.BalanceTransaction())
+	}
+	return transactions, iter.Err()
+}
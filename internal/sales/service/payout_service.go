@@ -0,0 +1,261 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	"github.com/stripe/stripe-go/v76"
+)
+
+const (
+	// payoutSyncWindow cobre o prazo em que um repasse ainda pode mudar de status no Stripe
+	payoutSyncWindow  = 30 * 24 * time.Hour
+	payoutRecentLimit = 10
+	payoutSalesLimit  = 20
+)
+
+// PayoutSale é uma venda com o repasse que a levou à conta bancária do criador (nil enquanto não repassada)
+type PayoutSale struct {
+	Transaction *salesmodel.Transaction
+	Payout      *salesmodel.Payout
+}
+
+// GetReconciliationLabel resume onde está o dinheiro da venda
+func (s *PayoutSale) GetReconciliationLabel() string {
+	switch {
+	case s.Payout == nil:
+		return "Aguardando repasse"
+	case s.Payout.IsUpcoming():
+		return "A caminho"
+	case s.Payout.Status == salesmodel.PayoutStatusPaid:
+		return "Recebido"
+	default:
+		return "Repasse com falha"
+	}
+}
+
+// PayoutDashboard reúne o que a página de recebimentos mostra ao criador.
+// Balance fica nil quando o Stripe não responde; o restante vem do banco.
+type PayoutDashboard struct {
+	Balance  *ConnectBalance
+	Upcoming []*salesmodel.Payout
+	Recent   []*salesmodel.Payout
+	Sales    []*PayoutSale
+}
+
+// PayoutService sincroniza os repasses das contas Stripe Connect e os liga às vendas
+type PayoutService interface {
+	SyncCreator(creator *accountmodel.Creator) error
+	SyncAll() (int, error)
+	HandlePayoutEvent(accountID string, payout *stripe.Payout) error
+	GetDashboard(creator *accountmodel.Creator) (*PayoutDashboard, error)
+}
+
+type payoutServiceImpl struct {
+	payoutRepo salesrepo.PayoutRepository
+	gateway    PayoutGateway
+}
+
+func NewPayoutService(payoutRepo salesrepo.PayoutRepository, gateway PayoutGateway) PayoutService {
+	return &payoutServiceImpl{
+		payoutRepo: payoutRepo,
+		gateway:    gateway,
+	}
+}
+
+// SyncCreator importa os repasses recentes da conta Connect do criador
+func (s *payoutServiceImpl) SyncCreator(creator *accountmodel.Creator) error {
+	if creator.StripeConnectAccountID == "" {
+		return nil
+	}
+
+	payouts, err := s.gateway.ListPayouts(creator.StripeConnectAccountID, time.Now().Add(-payoutSyncWindow))
+	if err != nil {
+		return fmt.Errorf("erro ao listar repasses no Stripe: %v", err)
+	}
+
+	for _, stripePayout := range payouts {
+		if err := s.syncPayout(creator, stripePayout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SyncAll sincroniza todos os criadores conectados; a falha de um não interrompe os demais
+func (s *payoutServiceImpl) SyncAll() (int, error) {
+	creators, err := s.payoutRepo.FindConnectedCreators()
+	if err != nil {
+		return 0, fmt.Errorf("erro ao buscar criadores conectados: %v", err)
+	}
+
+	synced := 0
+	for _, creator := range creators {
+		if err := s.SyncCreator(creator); err != nil {
+			slog.Error("Erro ao sincronizar repasses", "creatorID", creator.ID, "error", err)
+			continue
+		}
+		synced++
+	}
+	return synced, nil
+}
+
+// HandlePayoutEvent aplica os webhooks payout.* recebidos das contas conectadas
+func (s *payoutServiceImpl) HandlePayoutEvent(accountID string, payout *stripe.Payout) error {
+	if accountID == "" {
+		// Repasses da própria conta da plataforma não pertencem a nenhum criador
+		return nil
+	}
+
+	creator, err := s.payoutRepo.FindCreatorByStripeAccountID(accountID)
+	if err != nil {
+		return fmt.Errorf("criador da conta %s não encontrado: %v", accountID, err)
+	}
+	return s.syncPayout(creator, payout)
+}
+
+func (s *payoutServiceImpl) GetDashboard(creator *accountmodel.Creator) (*PayoutDashboard, error) {
+	dashboard := &PayoutDashboard{}
+
+	if creator.StripeConnectAccountID != "" {
+		balance, err := s.gateway.GetBalance(creator.StripeConnectAccountID)
+		if err != nil {
+			slog.Error("Erro ao consultar saldo no Stripe", "creatorID", creator.ID, "error", err)
+		} else {
+			dashboard.Balance = balance
+		}
+	}
+
+	var err error
+	if dashboard.Upcoming, err = s.payoutRepo.FindUpcomingByCreatorID(creator.ID); err != nil {
+		return nil, err
+	}
+	if dashboard.Recent, err = s.payoutRepo.FindRecentByCreatorID(creator.ID, payoutRecentLimit); err != nil {
+		return nil, err
+	}
+
+	transactions, err := s.payoutRepo.FindRecentSalesByCreatorID(creator.ID, payoutSalesLimit)
+	if err != nil {
+		return nil, err
+	}
+	transactionIDs := make([]uint, 0, len(transactions))
+	for _, transaction := range transactions {
+		transactionIDs = append(transactionIDs, transaction.ID)
+	}
+	payouts, err := s.payoutRepo.FindPayoutsByTransactionIDs(transactionIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, transaction := range transactions {
+		dashboard.Sales = append(dashboard.Sales, &PayoutSale{Transaction: transaction, Payout: payouts[transaction.ID]})
+	}
+	return dashboard, nil
+}
+
+// syncPayout grava o repasse e, na primeira vez, as movimentações que ele cobre
+func (s *payoutServiceImpl) syncPayout(creator *accountmodel.Creator, stripePayout *stripe.Payout) error {
+	payout, err := s.payoutRepo.FindByStripePayoutID(stripePayout.ID)
+	if err != nil {
+		return err
+	}
+	if payout == nil {
+		payout = &salesmodel.Payout{CreatorID: creator.ID, StripePayoutID: stripePayout.ID}
+	}
+
+	payout.Amount = stripePayout.Amount
+	payout.Currency = strings.ToUpper(string(stripePayout.Currency))
+	payout.Status = salesmodel.PayoutStatus(stripePayout.Status)
+	payout.Automatic = stripePayout.Automatic
+	payout.ArrivalDate = time.Unix(stripePayout.ArrivalDate, 0)
+	payout.FailureMessage = stripePayout.FailureMessage
+	if err := s.payoutRepo.Save(payout); err != nil {
+		return fmt.Errorf("erro ao salvar repasse %s: %v", stripePayout.ID, err)
+	}
+
+	// O Stripe só lista as movimentações de repasses automáticos; os manuais ficam sem vínculo
+	if payout.ItemsSyncedAt != nil || !payout.Automatic ||
+		payout.Status == salesmodel.PayoutStatusFailed || payout.Status == salesmodel.PayoutStatusCanceled {
+		return nil
+	}
+
+	balanceTransactions, err := s.gateway.ListPayoutBalanceTransactions(creator.StripeConnectAccountID, stripePayout.ID)
+	if err != nil {
+		return fmt.Errorf("erro ao listar movimentações do repasse %s: %v", stripePayout.ID, err)
+	}
+
+	items := make([]*salesmodel.PayoutItem, 0, len(balanceTransactions))
+	paymentIntentIDs := make([]string, 0, len(balanceTransactions))
+	for _, bt := range balanceTransactions {
+		if bt.Type == stripe.BalanceTransactionTypePayout {
+			continue
+		}
+		item := &salesmodel.PayoutItem{
+			PayoutID:                   payout.ID,
+			StripeBalanceTransactionID: bt.ID,
+			Type:                       string(bt.Type),
+			StripePaymentIntentID:      balanceTransactionPaymentIntent(bt),
+			Amount:                     bt.Amount,
+			Fee:                        bt.Fee,
+			Net:                        bt.Net,
+			Currency:                   strings.ToUpper(string(bt.Currency)),
+		}
+		if item.StripePaymentIntentID != "" {
+			paymentIntentIDs = append(paymentIntentIDs, item.StripePaymentIntentID)
+		}
+		items = append(items, item)
+	}
+
+	transactionIDs, err := s.payoutRepo.FindTransactionIDsByPaymentIntents(creator.ID, paymentIntentIDs)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if id, ok := transactionIDs[item.StripePaymentIntentID]; ok {
+			item.TransactionID = &id
+		}
+	}
+
+	if err := s.payoutRepo.CreateItems(items); err != nil {
+		return fmt.Errorf("erro ao salvar movimentações do repasse %s: %v", stripePayout.ID, err)
+	}
+
+	now := time.Now()
+	payout.ItemsSyncedAt = &now
+	return s.payoutRepo.Save(payout)
+}
+
+// balanceTransactionPaymentIntent extrai o PaymentIntent da origem expandida da movimentação
+func balanceTransactionPaymentIntent(bt *stripe.BalanceTransaction) string {
+	if bt.Source == nil {
+		return ""
+	}
+	switch {
+	case bt.Source.Charge != nil && bt.Source.Charge.PaymentIntent != nil:
+		return bt.Source.Charge.PaymentIntent.ID
+	case bt.Source.Refund != nil && bt.Source.Refund.PaymentIntent != nil:
+		return bt.Source.Refund.PaymentIntent.ID
+	case bt.Source.Dispute != nil && bt.Source.Dispute.PaymentIntent != nil:
+		return bt.Source.Dispute.PaymentIntent.ID
+	}
+	return ""
+}
+
+// StartPayoutSyncJob complementa os webhooks payout.* com uma sincronização periódica
+func StartPayoutSyncJob(payoutService PayoutService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			synced, err := payoutService.SyncAll()
+			if err != nil {
+				slog.Error("Erro ao sincronizar repasses", "error", err)
+				continue
+			}
+			slog.Info("Repasses sincronizados", "creators", synced)
+		}
+	}()
+}
@@ -0,0 +1,154 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v76"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// fakePayoutGateway devolve repasses e movimentações fixos e conta as consultas de movimentações
+type fakePayoutGateway struct {
+	payouts      []*stripe.Payout
+	transactions map[string][]*stripe.BalanceTransaction
+	itemCalls    int
+}
+
+func (g *fakePayoutGateway) GetBalance(accountID string) (*salesvc.ConnectBalance, error) {
+	return &salesvc.ConnectBalance{Available: []money.Money{money.New(5000, money.BRL)}}, nil
+}
+
+func (g *fakePayoutGateway) ListPayouts(accountID string, createdSince time.Time) ([]*stripe.Payout, error) {
+	return g.payouts, nil
+}
+
+func (g *fakePayoutGateway) ListPayoutBalanceTransactions(accountID, payoutID string) ([]*stripe.BalanceTransaction, error) {
+	g.itemCalls++
+	return g.transactions[payoutID], nil
+}
+
+func chargeBalanceTransaction(id, paymentIntentID string, amount, fee int64) *stripe.BalanceTransaction {
+	return &stripe.BalanceTransaction{
+		ID:       id,
+		Type:     stripe.BalanceTransactionTypeCharge,
+		Amount:   amount,
+		Fee:      fee,
+		Net:      amount - fee,
+		Currency: "brl",
+		Source: &stripe.BalanceTransactionSource{
+			ID:     "ch_" + id,
+			Charge: &stripe.Charge{PaymentIntent: &stripe.PaymentIntent{ID: paymentIntentID}},
+		},
+	}
+}
+
+func setupPayoutTest(t *testing.T) (salesvc.PayoutService, *fakePayoutGateway, *gorm.DB, *accountmodel.Creator) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&accountmodel.Creator{}, &librarymodel.Ebook{}, &salesmodel.Purchase{},
+		&salesmodel.Transaction{}, &salesmodel.Payout{}, &salesmodel.PayoutItem{}))
+
+	creator := &accountmodel.Creator{Name: "Maria", Email: "maria@test.com", StripeConnectAccountID: "acct_maria", OnboardingCompleted: true}
+	require.NoError(t, db.Create(creator).Error)
+
+	gateway := &fakePayoutGateway{transactions: map[string][]*stripe.BalanceTransaction{}}
+	return salesvc.NewPayoutService(salesrepo.NewPayoutRepository(db), gateway), gateway, db, creator
+}
+
+func createPayoutSale(t *testing.T, db *gorm.DB, creatorID uint, paymentIntentID string) *salesmodel.Transaction {
+	t.Helper()
+	purchase := &salesmodel.Purchase{HashID: paymentIntentID}
+	require.NoError(t, db.Create(purchase).Error)
+	transaction := &salesmodel.Transaction{
+		PurchaseID:            purchase.ID,
+		CreatorID:             creatorID,
+		StripePaymentIntentID: paymentIntentID,
+		Status:                salesmodel.TransactionStatusCompleted,
+		Currency:              money.BRL,
+		TotalAmount:           5000,
+		CreatorAmount:         4200,
+	}
+	require.NoError(t, db.Create(transaction).Error)
+	return transaction
+}
+
+func TestPayoutService_SyncLinksPayoutToSales(t *testing.T) {
+	service, gateway, db, creator := setupPayoutTest(t)
+	paid := createPayoutSale(t, db, creator.ID, "pi_pago")
+	waiting := createPayoutSale(t, db, creator.ID, "pi_aguardando")
+
+	gateway.payouts = []*stripe.Payout{{
+		ID:          "po_1",
+		Amount:      4800,
+		Currency:    "brl",
+		Status:      stripe.PayoutStatusInTransit,
+		Automatic:   true,
+		ArrivalDate: time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC).Unix(),
+	}}
+	gateway.transactions["po_1"] = []*stripe.BalanceTransaction{
+		chargeBalanceTransaction("txn_1", "pi_pago", 5000, 200),
+		{ID: "txn_po", Type: stripe.BalanceTransactionTypePayout, Amount: -4800, Currency: "brl"},
+	}
+
+	require.NoError(t, service.SyncCreator(creator))
+	require.NoError(t, service.SyncCreator(creator))
+	assert.Equal(t, 1, gateway.itemCalls, "as movimentações do repasse são importadas uma única vez")
+
+	var items []salesmodel.PayoutItem
+	require.NoError(t, db.Find(&items).Error)
+	require.Len(t, items, 1)
+	assert.Equal(t, "BRL", items[0].Currency)
+	assert.Equal(t, int64(4800), items[0].Net)
+	require.NotNil(t, items[0].TransactionID)
+	assert.Equal(t, paid.ID, *items[0].TransactionID)
+
+	dashboard, err := service.GetDashboard(creator)
+	require.NoError(t, err)
+	require.Len(t, dashboard.Upcoming, 1)
+	assert.Equal(t, "A caminho", dashboard.Upcoming[0].GetStatusLabel())
+	require.NotNil(t, dashboard.Balance)
+
+	labels := map[uint]string{}
+	for _, sale := range dashboard.Sales {
+		labels[sale.Transaction.ID] = sale.GetReconciliationLabel()
+	}
+	assert.Equal(t, "A caminho", labels[paid.ID])
+	assert.Equal(t, "Aguardando repasse", labels[waiting.ID])
+}
+
+func TestPayoutService_HandlePayoutEventUpdatesStatus(t *testing.T) {
+	service, gateway, db, creator := setupPayoutTest(t)
+	sale := createPayoutSale(t, db, creator.ID, "pi_pago")
+	gateway.transactions["po_1"] = []*stripe.BalanceTransaction{chargeBalanceTransaction("txn_1", "pi_pago", 5000, 200)}
+
+	payout := &stripe.Payout{ID: "po_1", Amount: 4800, Currency: "brl", Status: stripe.PayoutStatusInTransit, Automatic: true}
+	require.NoError(t, service.HandlePayoutEvent("acct_maria", payout))
+
+	payout.Status = stripe.PayoutStatusPaid
+	require.NoError(t, service.HandlePayoutEvent("acct_maria", payout))
+
+	var stored salesmodel.Payout
+	require.NoError(t, db.Where("stripe_payout_id = ?", "po_1").First(&stored).Error)
+	assert.Equal(t, salesmodel.PayoutStatusPaid, stored.Status)
+	assert.NotNil(t, stored.ItemsSyncedAt)
+
+	dashboard, err := service.GetDashboard(creator)
+	require.NoError(t, err)
+	require.Len(t, dashboard.Sales, 1)
+	assert.Equal(t, sale.ID, dashboard.Sales[0].Transaction.ID)
+	assert.Equal(t, "Recebido", dashboard.Sales[0].GetReconciliationLabel())
+
+	assert.Error(t, service.HandlePayoutEvent("acct_desconhecida", payout))
+	assert.NoError(t, service.HandlePayoutEvent("", payout), "repasses da conta da plataforma são ignorados")
+}
@@ -0,0 +1,490 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+)
+
+const (
+	maxPrivacyDetailsLength = 2000
+	privacyRequestsLimit    = 200
+	anonymizedResolution    = "Dados pessoais anonimizados. Compras e transações mantidas para cumprimento de obrigação legal."
+)
+
+var (
+	ErrPrivacyInvalid         = errors.New("dados inválidos")
+	ErrPrivacyRequestNotFound = errors.New("solicitação não encontrada")
+	ErrPrivacyClientNotFound  = errors.New("cliente não encontrado")
+	ErrPrivacyRequestClosed   = errors.New("a solicitação já foi encerrada")
+	ErrPrivacyDuplicate       = errors.New("já existe uma solicitação desse tipo em aberto para o cliente")
+	ErrPrivacyNotExported     = errors.New("gere a exportação dos dados antes de concluir a solicitação")
+)
+
+// DataExport é o arquivo ZIP entregue ao titular na solicitação de acesso
+type DataExport struct {
+	FileName string
+	Content  []byte
+}
+
+// PrivacyService atende as solicitações dos titulares dos dados (LGPD). Criadores registram e exportam
+// os dados gerados nas próprias vendas; a anonimização altera um cadastro compartilhado entre criadores
+// e por isso é executada apenas pelos operadores da plataforma.
+type PrivacyService interface {
+	Register(client *salesmodel.Client, creatorID *uint, requestType, requesterEmail, details string) (*salesmodel.PrivacyRequest, error)
+	FindClient(identifier string) (*salesmodel.Client, error)
+	ListByCreator(creatorID uint) ([]*salesmodel.PrivacyRequest, error)
+	ListAll() ([]*salesmodel.PrivacyRequest, error)
+	Find(publicID string) (*salesmodel.PrivacyRequest, error)
+	FindForCreator(creatorID uint, publicID string) (*salesmodel.PrivacyRequest, error)
+	Export(request *salesmodel.PrivacyRequest, creatorID uint) (*DataExport, error)
+	CompleteAccess(request *salesmodel.PrivacyRequest, handledBy string) error
+	Anonymize(request *salesmodel.PrivacyRequest, handledBy string) error
+	Reject(request *salesmodel.PrivacyRequest, handledBy, reason string) error
+}
+
+type privacyServiceImpl struct {
+	privacyRepo  salesrepo.PrivacyRepository
+	clientRepo   salesrepo.ClientRepository
+	emailService IEmailService
+	responseDays int
+}
+
+// NewPrivacyService recebe o prazo de resposta em dias, contado a partir do registro da solicitação
+func NewPrivacyService(
+	privacyRepo salesrepo.PrivacyRepository,
+	clientRepo salesrepo.ClientRepository,
+	emailService IEmailService,
+	responseDays int,
+) PrivacyService {
+	return &privacyServiceImpl{
+		privacyRepo:  privacyRepo,
+		clientRepo:   clientRepo,
+		emailService: emailService,
+		responseDays: responseDays,
+	}
+}
+
+// Register registra a solicitação com o prazo de resposta e avisa os operadores da plataforma
+func (s *privacyServiceImpl) Register(client *salesmodel.Client, creatorID *uint, requestType, requesterEmail, details string) (*salesmodel.PrivacyRequest, error) {
+	if !salesmodel.IsPrivacyRequestType(requestType) {
+		return nil, fmt.Errorf("%w: escolha o tipo da solicitação", ErrPrivacyInvalid)
+	}
+	requesterEmail = strings.TrimSpace(requesterEmail)
+	if address, err := mail.ParseAddress(requesterEmail); err != nil || address.Address != requesterEmail {
+		return nil, fmt.Errorf("%w: informe o email do titular para enviar a resposta", ErrPrivacyInvalid)
+	}
+	details = strings.TrimSpace(details)
+	if utf8.RuneCountInString(details) > maxPrivacyDetailsLength {
+		return nil, fmt.Errorf("%w: os detalhes devem ter até %d caracteres", ErrPrivacyInvalid, maxPrivacyDetailsLength)
+	}
+	if client.IsAnonymized() {
+		return nil, fmt.Errorf("%w: os dados deste cliente já foram anonimizados", ErrPrivacyInvalid)
+	}
+
+	duplicate, err := s.privacyRepo.HasOpenRequest(client.ID, salesmodel.PrivacyRequestType(requestType))
+	if err != nil {
+		return nil, err
+	}
+	if duplicate {
+		return nil, ErrPrivacyDuplicate
+	}
+
+	dueAt := time.Now().AddDate(0, 0, s.responseDays)
+	request := salesmodel.NewPrivacyRequest(client.ID, creatorID, salesmodel.PrivacyRequestType(requestType), requesterEmail, details, dueAt)
+	if err := s.privacyRepo.Create(request); err != nil {
+		return nil, err
+	}
+
+	// Recarrega com o cliente e o criador para o aviso aos operadores
+	if loaded, err := s.privacyRepo.FindByPublicID(request.PublicID); err == nil && loaded != nil {
+		request = loaded
+	}
+	go s.emailService.SendPrivacyRequestAlert(request)
+	return request, nil
+}
+
+// FindClient localiza o cliente pelo id público, CPF ou email, para as solicitações recebidas pela plataforma
+func (s *privacyServiceImpl) FindClient(identifier string) (*salesmodel.Client, error) {
+	identifier = strings.TrimSpace(identifier)
+
+	var client *salesmodel.Client
+	var err error
+	switch {
+	case identifier == "":
+		return nil, ErrPrivacyClientNotFound
+	case strings.HasPrefix(identifier, "cli_"):
+		client, err = s.clientRepo.FindByPublicID(identifier)
+		if err != nil {
+			return nil, ErrPrivacyClientNotFound
+		}
+	case strings.Contains(identifier, "@"):
+		client, err = s.clientRepo.FindByEmail(identifier)
+	default:
+		client, err = s.clientRepo.FindByCPF(strings.NewReplacer(".", "", "-", "", " ", "").Replace(identifier))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, ErrPrivacyClientNotFound
+	}
+	client.Purchases = nil
+	return client, nil
+}
+
+func (s *privacyServiceImpl) ListByCreator(creatorID uint) ([]*salesmodel.PrivacyRequest, error) {
+	return s.privacyRepo.FindByCreatorID(creatorID, privacyRequestsLimit)
+}
+
+func (s *privacyServiceImpl) ListAll() ([]*salesmodel.PrivacyRequest, error) {
+	return s.privacyRepo.FindAll(privacyRequestsLimit)
+}
+
+func (s *privacyServiceImpl) Find(publicID string) (*salesmodel.PrivacyRequest, error) {
+	request, err := s.privacyRepo.FindByPublicID(publicID)
+	if err != nil {
+		return nil, err
+	}
+	if request == nil {
+		return nil, ErrPrivacyRequestNotFound
+	}
+	return request, nil
+}
+
+// FindForCreator busca a solicitação garantindo que foi registrada pelo criador
+func (s *privacyServiceImpl) FindForCreator(creatorID uint, publicID string) (*salesmodel.PrivacyRequest, error) {
+	request, err := s.Find(publicID)
+	if err != nil {
+		return nil, err
+	}
+	if request.CreatorID == nil || *request.CreatorID != creatorID {
+		return nil, ErrPrivacyRequestNotFound
+	}
+	return request, nil
+}
+
+// Export gera o ZIP com os dados do cliente. Com creatorID diferente de zero a exportação
+// contém apenas os dados das vendas e do relacionamento com esse criador.
+func (s *privacyServiceImpl) Export(request *salesmodel.PrivacyRequest, creatorID uint) (*DataExport, error) {
+	data, err := s.privacyRepo.FindPersonalData(&request.Client, creatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	content, err := buildDataExport(request, data, now)
+	if err != nil {
+		return nil, err
+	}
+
+	request.ExportedAt = &now
+	if err := s.privacyRepo.Save(request); err != nil {
+		return nil, err
+	}
+	return &DataExport{
+		FileName: "dados-" + request.Client.PublicID + ".zip",
+		Content:  content,
+	}, nil
+}
+
+// CompleteAccess encerra a solicitação de acesso depois que a exportação foi gerada e enviada ao titular
+func (s *privacyServiceImpl) CompleteAccess(request *salesmodel.PrivacyRequest, handledBy string) error {
+	if !request.IsOpen() {
+		return ErrPrivacyRequestClosed
+	}
+	if request.IsDeletion() {
+		return fmt.Errorf("%w: a eliminação é concluída pela anonimização", ErrPrivacyInvalid)
+	}
+	if request.ExportedAt == nil {
+		return ErrPrivacyNotExported
+	}
+	request.Complete(handledBy, "Exportação dos dados enviada ao titular.", time.Now())
+	return s.privacyRepo.Save(request)
+}
+
+// Anonymize atende a solicitação de eliminação apagando os dados pessoais do cliente
+func (s *privacyServiceImpl) Anonymize(request *salesmodel.PrivacyRequest, handledBy string) error {
+	if !request.IsOpen() {
+		return ErrPrivacyRequestClosed
+	}
+	if !request.IsDeletion() {
+		return fmt.Errorf("%w: apenas solicitações de eliminação anonimizam o cliente", ErrPrivacyInvalid)
+	}
+
+	now := time.Now()
+	if !request.Client.IsAnonymized() {
+		if err := s.privacyRepo.Anonymize(&request.Client, now); err != nil {
+			return err
+		}
+	}
+	request.Complete(handledBy, anonymizedResolution, now)
+	return s.privacyRepo.Save(request)
+}
+
+// Reject encerra a solicitação sem atendê-la, por exemplo quando a identidade do titular não foi confirmada
+func (s *privacyServiceImpl) Reject(request *salesmodel.PrivacyRequest, handledBy, reason string) error {
+	if !request.IsOpen() {
+		return ErrPrivacyRequestClosed
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" || utf8.RuneCountInString(reason) > maxPrivacyDetailsLength {
+		return fmt.Errorf("%w: informe o motivo da recusa", ErrPrivacyInvalid)
+	}
+	request.Reject(handledBy, reason, time.Now())
+	return s.privacyRepo.Save(request)
+}
+
+// buildDataExport monta o ZIP com o JSON dos dados e um texto explicando o conteúdo
+func buildDataExport(request *salesmodel.PrivacyRequest, data *salesmodel.ClientPersonalData, now time.Time) ([]byte, error) {
+	payload, err := json.MarshalIndent(newPersonalDataExport(request, data, now), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	files := []struct {
+		name    string
+		content []byte
+	}{
+		{"dados.json", payload},
+		{"LEIA-ME.txt", []byte(dataExportReadme)},
+	}
+	for _, file := range files {
+		writer, err := archive.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := writer.Write(file.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+const dataExportReadme = `Este arquivo reúne os dados pessoais tratados sobre você, atendendo à solicitação de acesso
+prevista no art. 18 da Lei Geral de Proteção de Dados (Lei nº 13.709/2018).
+
+dados.json contém:
+- titular: os dados do seu cadastro;
+- compras, transacoes e downloads: as compras realizadas, os pagamentos e os downloads dos arquivos;
+- etiquetas e anotacoes: as etiquetas e anotações registradas pelos vendedores sobre você;
+- emails e descadastros: os emails de divulgação recebidos e os pedidos de descadastro;
+- tentativas_checkout e listas_espera: os dados informados no checkout e nas listas de espera;
+- solicitacoes: as solicitações de titular registradas em seu nome.
+
+Valores monetários estão em centavos e as datas no padrão ISO 8601.
+`
+
+type personalDataExport struct {
+	GeradoEm            time.Time               `json:"gerado_em"`
+	Solicitacao         string                  `json:"solicitacao"`
+	Titular             exportedClient          `json:"titular"`
+	Compras             []exportedPurchase      `json:"compras"`
+	Transacoes          []exportedTransaction   `json:"transacoes"`
+	Downloads           []exportedDownload      `json:"downloads"`
+	Etiquetas           []string                `json:"etiquetas"`
+	Anotacoes           []exportedNote          `json:"anotacoes"`
+	Emails              []exportedBroadcast     `json:"emails"`
+	Descadastros        []time.Time             `json:"descadastros"`
+	TentativasCheckout  []exportedCheckout      `json:"tentativas_checkout"`
+	ListasEspera        []exportedWaitlistEntry `json:"listas_espera"`
+	SolicitacoesTitular []exportedRequest       `json:"solicitacoes"`
+}
+
+type exportedClient struct {
+	ID             string     `json:"id"`
+	Nome           string     `json:"nome"`
+	CPF            string     `json:"cpf,omitempty"`
+	DataNascimento string     `json:"data_nascimento,omitempty"`
+	Email          string     `json:"email"`
+	Telefone       string     `json:"telefone,omitempty"`
+	Pais           string     `json:"pais,omitempty"`
+	Documento      string     `json:"documento,omitempty"`
+	CadastradoEm   time.Time  `json:"cadastrado_em"`
+	AnonimizadoEm  *time.Time `json:"anonimizado_em,omitempty"`
+}
+
+type exportedPurchase struct {
+	ID        string    `json:"id"`
+	Ebook     string    `json:"ebook"`
+	Data      time.Time `json:"data"`
+	Status    string    `json:"status"`
+	Pagamento string    `json:"pagamento"`
+	ExpiraEm  time.Time `json:"expira_em"`
+	Downloads int       `json:"downloads"`
+}
+
+type exportedTransaction struct {
+	ID             string    `json:"id"`
+	Compra         string    `json:"compra"`
+	Data           time.Time `json:"data"`
+	Valor          int64     `json:"valor"`
+	Moeda          string    `json:"moeda"`
+	FormaPagamento string    `json:"forma_pagamento,omitempty"`
+	Status         string    `json:"status"`
+}
+
+type exportedDownload struct {
+	Compra string    `json:"compra"`
+	Data   time.Time `json:"data"`
+}
+
+type exportedNote struct {
+	Texto    string    `json:"texto"`
+	CriadaEm time.Time `json:"criada_em"`
+}
+
+type exportedBroadcast struct {
+	Assunto   string     `json:"assunto"`
+	Status    string     `json:"status"`
+	EnviadoEm *time.Time `json:"enviado_em,omitempty"`
+}
+
+type exportedCheckout struct {
+	Data    time.Time `json:"data"`
+	Nome    string    `json:"nome"`
+	CPF     string    `json:"cpf,omitempty"`
+	Email   string    `json:"email"`
+	IP      string    `json:"ip,omitempty"`
+	Decisao string    `json:"decisao"`
+}
+
+type exportedWaitlistEntry struct {
+	Nome       string     `json:"nome"`
+	Email      string     `json:"email"`
+	InscritoEm time.Time  `json:"inscrito_em"`
+	AvisadoEm  *time.Time `json:"avisado_em,omitempty"`
+}
+
+type exportedRequest struct {
+	ID           string     `json:"id"`
+	Tipo         string     `json:"tipo"`
+	Status       string     `json:"status"`
+	RegistradaEm time.Time  `json:"registrada_em"`
+	Prazo        time.Time  `json:"prazo"`
+	ConcluidaEm  *time.Time `json:"concluida_em,omitempty"`
+}
+
+// newPersonalDataExport traduz os registros para o formato entregue ao titular, sem ids internos nem tokens
+func newPersonalDataExport(request *salesmodel.PrivacyRequest, data *salesmodel.ClientPersonalData, now time.Time) personalDataExport {
+	client := data.Client
+	export := personalDataExport{
+		GeradoEm:    now,
+		Solicitacao: request.PublicID,
+		Titular: exportedClient{
+			ID:             client.PublicID,
+			Nome:           client.Name,
+			CPF:            client.CPF,
+			DataNascimento: client.Birthdate,
+			Email:          client.Email,
+			Telefone:       client.Phone,
+			Pais:           client.Country,
+			Documento:      client.DocumentID,
+			CadastradoEm:   client.CreatedAt,
+			AnonimizadoEm:  client.AnonymizedAt,
+		},
+		Compras:             []exportedPurchase{},
+		Transacoes:          []exportedTransaction{},
+		Downloads:           []exportedDownload{},
+		Etiquetas:           []string{},
+		Anotacoes:           []exportedNote{},
+		Emails:              []exportedBroadcast{},
+		Descadastros:        []time.Time{},
+		TentativasCheckout:  []exportedCheckout{},
+		ListasEspera:        []exportedWaitlistEntry{},
+		SolicitacoesTitular: []exportedRequest{},
+	}
+
+	purchaseIDs := make(map[uint]string, len(data.Purchases))
+	for _, purchase := range data.Purchases {
+		purchaseIDs[purchase.ID] = purchase.PublicID
+		export.Compras = append(export.Compras, exportedPurchase{
+			ID:        purchase.PublicID,
+			Ebook:     purchase.Ebook.Title,
+			Data:      purchase.CreatedAt,
+			Status:    purchase.Status.Label(),
+			Pagamento: string(purchase.PaymentStatus),
+			ExpiraEm:  purchase.ExpiresAt,
+			Downloads: purchase.DownloadsUsed,
+		})
+	}
+	for _, transaction := range data.Transactions {
+		export.Transacoes = append(export.Transacoes, exportedTransaction{
+			ID:             transaction.PublicID,
+			Compra:         purchaseIDs[transaction.PurchaseID],
+			Data:           transaction.CreatedAt,
+			Valor:          transaction.TotalAmount,
+			Moeda:          transaction.Currency,
+			FormaPagamento: transaction.PaymentMethod,
+			Status:         string(transaction.Status),
+		})
+	}
+	for _, download := range data.DownloadLogs {
+		export.Downloads = append(export.Downloads, exportedDownload{Compra: purchaseIDs[download.PurchaseID], Data: download.CreatedAt})
+	}
+	for _, assignment := range data.Tags {
+		export.Etiquetas = append(export.Etiquetas, assignment.Tag.Name)
+	}
+	for _, note := range data.Notes {
+		export.Anotacoes = append(export.Anotacoes, exportedNote{Texto: note.Body, CriadaEm: note.CreatedAt})
+	}
+
+	deliveries := make(map[uint]*salesmodel.BroadcastDelivery, len(data.Deliveries))
+	for _, delivery := range data.Deliveries {
+		deliveries[delivery.BroadcastID] = delivery
+	}
+	for _, broadcast := range data.Broadcasts {
+		exported := exportedBroadcast{Assunto: broadcast.Subject}
+		if delivery, ok := deliveries[broadcast.ID]; ok {
+			exported.Status = delivery.GetStatusLabel()
+			exported.EnviadoEm = delivery.SentAt
+		}
+		export.Emails = append(export.Emails, exported)
+	}
+	for _, unsubscribe := range data.Unsubscribes {
+		export.Descadastros = append(export.Descadastros, unsubscribe.CreatedAt)
+	}
+	for _, attempt := range data.CheckoutAttempts {
+		export.TentativasCheckout = append(export.TentativasCheckout, exportedCheckout{
+			Data:    attempt.CreatedAt,
+			Nome:    attempt.Name,
+			CPF:     attempt.CPF,
+			Email:   attempt.Email,
+			IP:      attempt.IP,
+			Decisao: string(attempt.Decision),
+		})
+	}
+	for _, entry := range data.WaitlistEntries {
+		export.ListasEspera = append(export.ListasEspera, exportedWaitlistEntry{
+			Nome:       entry.Name,
+			Email:      entry.Email,
+			InscritoEm: entry.CreatedAt,
+			AvisadoEm:  entry.NotifiedAt,
+		})
+	}
+	for _, item := range data.Requests {
+		export.SolicitacoesTitular = append(export.SolicitacoesTitular, exportedRequest{
+			ID:           item.PublicID,
+			Tipo:         item.GetTypeLabel(),
+			Status:       item.GetStatusLabel(),
+			RegistradaEm: item.CreatedAt,
+			Prazo:        item.DueAt,
+			ConcluidaEm:  item.CompletedAt,
+		})
+	}
+	return export
+}
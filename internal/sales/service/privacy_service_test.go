@@ -0,0 +1,197 @@
+package service_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	"github.com/anglesson/simple-web-server/internal/mocks"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	salesrepogorm "github.com/anglesson/simple-web-server/internal/sales/repository/gorm"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupPrivacy(t *testing.T) (*clientCRMFixture, salesvc.PrivacyService) {
+	t.Helper()
+	f := setupClientCRM(t)
+	require.NoError(t, f.db.AutoMigrate(&salesmodel.Broadcast{}, &salesmodel.BroadcastDelivery{}, &salesmodel.EmailUnsubscribe{},
+		&salesmodel.CheckoutAttempt{}, &salesmodel.WaitlistEntry{}, &salesmodel.CPFVerification{}, &salesmodel.PrivacyRequest{}))
+
+	email := new(mocks.MockSalesEmailService)
+	email.On("SendPrivacyRequestAlert", mock.Anything).Return()
+	service := salesvc.NewPrivacyService(salesrepo.NewPrivacyRepository(f.db), salesrepogorm.NewClientGormRepository(), email, 15)
+	return f, service
+}
+
+// readExport abre o ZIP da exportação e decodifica o dados.json
+func readExport(t *testing.T, export *salesvc.DataExport) map[string]any {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(export.Content), int64(len(export.Content)))
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(archive.File))
+	var data map[string]any
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+		if file.Name != "dados.json" {
+			continue
+		}
+		reader, err := file.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(content, &data))
+	}
+	assert.ElementsMatch(t, []string{"dados.json", "LEIA-ME.txt"}, names)
+	return data
+}
+
+func TestPrivacyService_RegisterValidation(t *testing.T) {
+	f, service := setupPrivacy(t)
+
+	_, err := service.Register(f.ana, &f.creator.ID, "portability", "ana@test.com", "")
+	assert.ErrorIs(t, err, salesvc.ErrPrivacyInvalid)
+	_, err = service.Register(f.ana, &f.creator.ID, "access", "ana", "")
+	assert.ErrorIs(t, err, salesvc.ErrPrivacyInvalid)
+
+	request, err := service.Register(f.ana, &f.creator.ID, "access", " ana@test.com ", "Pedido por email")
+	require.NoError(t, err)
+	assert.Equal(t, salesmodel.PrivacyRequestOpen, request.Status)
+	assert.Equal(t, "ana@test.com", request.RequesterEmail)
+	assert.WithinDuration(t, request.CreatedAt.AddDate(0, 0, 15), request.DueAt, time.Minute)
+
+	_, err = service.Register(f.ana, nil, "access", "ana@test.com", "")
+	assert.ErrorIs(t, err, salesvc.ErrPrivacyDuplicate)
+	_, err = service.Register(f.ana, nil, "deletion", "ana@test.com", "")
+	assert.NoError(t, err)
+}
+
+// TestPrivacyService_ExportScope verifica que o criador exporta apenas os dados das próprias vendas
+func TestPrivacyService_ExportScope(t *testing.T) {
+	f, service := setupPrivacy(t)
+	other := &accountmodel.Creator{Name: "João", Email: "joao@test.com"}
+	require.NoError(t, f.db.Create(other).Error)
+	otherEbook := &librarymodel.Ebook{Title: "Outro", Value: money.FromCents(3000), Status: true, CreatorID: other.ID}
+	require.NoError(t, f.db.Create(otherEbook).Error)
+
+	f.paidPurchase(t, f.ana, f.volume1, 5000)
+	f.paidPurchase(t, f.ana, otherEbook, 3000)
+	_, err := f.service.AddNote(f.creator.ID, f.ana.ID, "Prefere pix")
+	require.NoError(t, err)
+
+	request, err := service.Register(f.ana, &f.creator.ID, "access", "ana@test.com", "")
+	require.NoError(t, err)
+
+	err = service.CompleteAccess(request, "maria@test.com")
+	assert.ErrorIs(t, err, salesvc.ErrPrivacyNotExported)
+
+	_, err = service.FindForCreator(other.ID, request.PublicID)
+	assert.ErrorIs(t, err, salesvc.ErrPrivacyRequestNotFound)
+	request, err = service.FindForCreator(f.creator.ID, request.PublicID)
+	require.NoError(t, err)
+
+	export, err := service.Export(request, f.creator.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "dados-"+f.ana.PublicID+".zip", export.FileName)
+	data := readExport(t, export)
+	assert.Equal(t, "52998224725", data["titular"].(map[string]any)["cpf"])
+	assert.Len(t, data["compras"], 1)
+	assert.Len(t, data["transacoes"], 1)
+	assert.Len(t, data["anotacoes"], 1)
+	assert.Len(t, data["solicitacoes"], 1)
+
+	full, err := service.Export(request, 0)
+	require.NoError(t, err)
+	assert.Len(t, readExport(t, full)["compras"], 2)
+
+	require.NoError(t, service.CompleteAccess(request, "maria@test.com"))
+	assert.Equal(t, salesmodel.PrivacyRequestCompleted, request.Status)
+	assert.Equal(t, "maria@test.com", request.HandledBy)
+	assert.ErrorIs(t, service.CompleteAccess(request, "maria@test.com"), salesvc.ErrPrivacyRequestClosed)
+}
+
+// TestPrivacyService_Anonymize verifica que os dados pessoais somem e os registros financeiros ficam
+func TestPrivacyService_Anonymize(t *testing.T) {
+	f, service := setupPrivacy(t)
+	purchase := f.paidPurchase(t, f.ana, f.volume1, 5000)
+	f.paidPurchase(t, f.bruno, f.volume1, 5000)
+	_, err := f.service.AddNote(f.creator.ID, f.ana.ID, "Cliente antiga")
+	require.NoError(t, err)
+	_, err = f.service.TagClient(f.creator.ID, f.ana.ID, "VIP")
+	require.NoError(t, err)
+	require.NoError(t, f.db.Create(&salesmodel.CheckoutAttempt{CreatorID: f.creator.ID, Name: "Ana", CPF: f.ana.CPF,
+		Email: f.ana.Email, IP: "10.0.0.1"}).Error)
+	require.NoError(t, f.db.Create(&salesmodel.CheckoutAttempt{CreatorID: f.creator.ID, Name: "Bruno", CPF: f.bruno.CPF,
+		Email: f.bruno.Email, IP: "10.0.0.2"}).Error)
+	require.NoError(t, f.db.Create(salesmodel.NewWaitlistEntry(f.volume2.ID, f.creator.ID, "Ana", f.ana.Email)).Error)
+	require.NoError(t, f.db.Create(&salesmodel.CPFVerification{CPF: f.ana.CPF, Name: "Ana"}).Error)
+
+	access, err := service.Register(f.ana, &f.creator.ID, "access", "ana@test.com", "")
+	require.NoError(t, err)
+	assert.ErrorIs(t, service.Anonymize(access, "op@test.com"), salesvc.ErrPrivacyInvalid)
+
+	created, err := service.Register(f.ana, &f.creator.ID, "deletion", "ana@test.com", "")
+	require.NoError(t, err)
+	request, err := service.Find(created.PublicID)
+	require.NoError(t, err)
+	require.NoError(t, service.Anonymize(request, "op@test.com"))
+	assert.Equal(t, salesmodel.PrivacyRequestCompleted, request.Status)
+	assert.Equal(t, "op@test.com", request.HandledBy)
+
+	var client salesmodel.Client
+	require.NoError(t, f.db.First(&client, f.ana.ID).Error)
+	assert.Equal(t, salesmodel.AnonymizedClientName, client.Name)
+	assert.Empty(t, client.CPF)
+	assert.Empty(t, client.Email)
+	assert.True(t, client.IsAnonymized())
+
+	var count int64
+	f.db.Model(&salesmodel.Purchase{}).Where("id = ?", purchase.ID).Count(&count)
+	assert.Equal(t, int64(1), count, "a compra é mantida")
+	f.db.Model(&salesmodel.Transaction{}).Where("purchase_id = ?", purchase.ID).Count(&count)
+	assert.Equal(t, int64(1), count, "a transação é mantida")
+	f.db.Unscoped().Model(&salesmodel.ClientNote{}).Where("client_id = ?", f.ana.ID).Count(&count)
+	assert.Zero(t, count)
+	f.db.Model(&salesmodel.ClientTagAssignment{}).Where("client_id = ?", f.ana.ID).Count(&count)
+	assert.Zero(t, count)
+	f.db.Unscoped().Model(&salesmodel.WaitlistEntry{}).Where("email = ?", f.ana.Email).Count(&count)
+	assert.Zero(t, count)
+	f.db.Unscoped().Model(&salesmodel.CPFVerification{}).Where("cpf_index = ?", salesmodel.CPFBlindIndex(f.ana.CPF)).Count(&count)
+	assert.Zero(t, count)
+	f.db.Model(&salesmodel.CheckoutAttempt{}).Where("ip <> ''").Count(&count)
+	assert.Equal(t, int64(1), count, "apenas a tentativa do Bruno mantém os dados")
+
+	_, err = service.FindClient(f.ana.CPF)
+	assert.ErrorIs(t, err, salesvc.ErrPrivacyClientNotFound)
+	_, err = service.Register(&client, nil, "access", "ana@test.com", "")
+	assert.ErrorIs(t, err, salesvc.ErrPrivacyInvalid)
+}
+
+func TestPrivacyService_FindClientAndReject(t *testing.T) {
+	f, service := setupPrivacy(t)
+
+	for _, identifier := range []string{"529.982.247-25", "bruno@test.com", f.carla.PublicID} {
+		client, err := service.FindClient(identifier)
+		require.NoError(t, err, identifier)
+		assert.NotZero(t, client.ID)
+	}
+	_, err := service.FindClient("nao@existe.com")
+	assert.ErrorIs(t, err, salesvc.ErrPrivacyClientNotFound)
+
+	request, err := service.Register(f.bruno, nil, "deletion", "bruno@test.com", "")
+	require.NoError(t, err)
+	assert.ErrorIs(t, service.Reject(request, "op@test.com", "  "), salesvc.ErrPrivacyInvalid)
+	require.NoError(t, service.Reject(request, "op@test.com", "Identidade não confirmada"))
+	assert.Equal(t, salesmodel.PrivacyRequestRejected, request.Status)
+	assert.ErrorIs(t, service.Anonymize(request, "op@test.com"), salesvc.ErrPrivacyRequestClosed)
+}
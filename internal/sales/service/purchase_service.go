@@ -2,6 +2,9 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"log/slog"
+	"time"
 
 	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
 	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
@@ -18,8 +21,17 @@ type PurchaseService interface {
 	GetPurchaseByPublicID(publicID string) (*salesmodel.Purchase, error)
 	FindExistingPurchase(ebookID uint, clientID uint) (*salesmodel.Purchase, error)
 	ConfirmPayment(purchaseID uint) error
+	SetCheckoutSession(purchaseID uint, sessionID string) error
+	ConfirmOfflinePayment(purchaseID uint, creatorID uint, reason string) error
+	ReleasePreOrders(now time.Time) (int, error)
+	ChangeStatus(purchaseID uint, to salesmodel.PurchaseStatus, change salesmodel.StatusChange) error
+	RevokeAccess(purchaseID uint, creatorID uint, reason string) error
+	GetStatusHistory(purchaseID uint) ([]*salesmodel.PurchaseStatusHistory, error)
 }
 
+var ErrInvalidStatusTransition = errors.New("mudança de status da compra não permitida")
+var ErrPurchaseNotOwned = errors.New("unauthorized: purchase does not belong to this creator")
+
 type PurchaseServiceImpl struct {
 	purchaseRepository *salesrepo.PurchaseRepository
 	mailService        IEmailService
@@ -118,17 +130,138 @@ func (ps *PurchaseServiceImpl) FindExistingPurchase(ebookID uint, clientID uint)
 	return ps.purchaseRepository.FindExistingPurchase(ebookID, clientID)
 }
 
-// ConfirmPayment atualiza o status de pagamento de uma compra para confirmado
+// SetCheckoutSession guarda a sessão de checkout aberta por último; só a expiração dela libera a compra
+func (ps *PurchaseServiceImpl) SetCheckoutSession(purchaseID uint, sessionID string) error {
+	return ps.purchaseRepository.SetCheckoutSession(purchaseID, sessionID)
+}
+
+// ConfirmPayment confirma o pagamento e passa a compra para paga. Confirmações repetidas do
+// mesmo pagamento são ignoradas.
 func (ps *PurchaseServiceImpl) ConfirmPayment(purchaseID uint) error {
 	purchase, err := ps.purchaseRepository.FindByID(purchaseID)
 	if err != nil {
 		return err
 	}
-	purchase.PaymentStatus = salesmodel.PaymentStatusConfirmed
-	return ps.purchaseRepository.Update(purchase)
+
+	change := salesmodel.StatusChange{Actor: salesmodel.StatusActorStripe, Reason: "Pagamento confirmado"}
+	return ps.confirm(purchase, change)
+}
+
+// ConfirmOfflinePayment confirma um pagamento que o criador recebeu fora da plataforma
+func (ps *PurchaseServiceImpl) ConfirmOfflinePayment(purchaseID uint, creatorID uint, reason string) error {
+	purchase, err := ps.purchaseRepository.FindByID(purchaseID)
+	if err != nil {
+		return err
+	}
+	if purchase.Ebook.CreatorID != creatorID {
+		return ErrPurchaseNotOwned
+	}
+
+	change := salesmodel.StatusChange{Actor: salesmodel.StatusActorCreator, ActorID: creatorID, Reason: reason}
+	return ps.confirm(purchase, change)
+}
+
+func (ps *PurchaseServiceImpl) confirm(purchase *salesmodel.Purchase, change salesmodel.StatusChange) error {
+	fields := map[string]interface{}{"payment_status": salesmodel.PaymentStatusConfirmed}
+	if purchase.Ebook.IsAwaitingRelease() {
+		fields["awaiting_release"] = true
+	}
+	return ps.transition(purchase, salesmodel.PurchaseStatusPaid, change, fields)
+}
+
+// ChangeStatus aplica uma mudança de status validada pela máquina de estados e a registra no histórico
+func (ps *PurchaseServiceImpl) ChangeStatus(purchaseID uint, to salesmodel.PurchaseStatus, change salesmodel.StatusChange) error {
+	purchase, err := ps.purchaseRepository.FindByID(purchaseID)
+	if err != nil {
+		return err
+	}
+	return ps.transition(purchase, to, change, nil)
+}
+
+// RevokeAccess revoga definitivamente o acesso do comprador a pedido do criador
+func (ps *PurchaseServiceImpl) RevokeAccess(purchaseID uint, creatorID uint, reason string) error {
+	purchase, err := ps.purchaseRepository.FindByID(purchaseID)
+	if err != nil {
+		return err
+	}
+	if purchase.Ebook.CreatorID != creatorID {
+		return ErrPurchaseNotOwned
+	}
+
+	if reason == "" {
+		reason = "Acesso revogado pelo criador"
+	}
+	change := salesmodel.StatusChange{Actor: salesmodel.StatusActorCreator, ActorID: creatorID, Reason: reason}
+	return ps.transition(purchase, salesmodel.PurchaseStatusRevoked, change, nil)
+}
+
+func (ps *PurchaseServiceImpl) GetStatusHistory(purchaseID uint) ([]*salesmodel.PurchaseStatusHistory, error) {
+	return ps.purchaseRepository.FindStatusHistory(purchaseID)
+}
+
+// transition valida a mudança de status e grava a compra e o histórico juntos.
+// Uma mudança para o status atual não gera histórico.
+func (ps *PurchaseServiceImpl) transition(purchase *salesmodel.Purchase, to salesmodel.PurchaseStatus, change salesmodel.StatusChange, fields map[string]interface{}) error {
+	from := purchase.Status
+	if from == to {
+		return nil
+	}
+	if !from.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s → %s", ErrInvalidStatusTransition, from.Label(), to.Label())
+	}
+
+	history := salesmodel.NewPurchaseStatusHistory(purchase.ID, from, to, change)
+	if err := ps.purchaseRepository.TransitionStatus(history, fields); err != nil {
+		return err
+	}
+
+	purchase.Status = to
+	slog.Info("Status da compra alterado", "purchaseID", purchase.ID, "from", from, "to", to, "actor", change.Actor)
+	return nil
+}
+
+// ReleasePreOrders libera o acesso das pré-vendas cujo ebook foi lançado e envia os links de download em lote
+func (ps *PurchaseServiceImpl) ReleasePreOrders(now time.Time) (int, error) {
+	purchases, err := ps.purchaseRepository.FindAwaitingReleaseReady(now)
+	if err != nil {
+		return 0, err
+	}
+
+	var released []*salesmodel.Purchase
+	for _, purchase := range purchases {
+		if err := ps.purchaseRepository.SetAwaitingRelease(purchase.ID, false); err != nil {
+			slog.Error("Erro ao liberar pré-venda", "purchaseID", purchase.ID, "error", err)
+			continue
+		}
+		purchase.AwaitingRelease = false
+		released = append(released, purchase)
+	}
+
+	if len(released) > 0 {
+		ps.mailService.SendLinkToDownload(released)
+	}
+
+	return len(released), nil
+}
+
+// StartPreOrderReleaseJob verifica periodicamente as pré-vendas prontas para liberação
+func StartPreOrderReleaseJob(purchaseService PurchaseService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for now := range ticker.C {
+			released, err := purchaseService.ReleasePreOrders(now)
+			if err != nil {
+				slog.Error("Erro ao liberar pré-vendas", "error", err)
+				continue
+			}
+			if released > 0 {
+				slog.Info("Pré-vendas liberadas", "total", released)
+			}
+		}
+	}()
 }
 
-// BlockDownload bloqueia ou desbloqueia o download de uma compra
+// BlockDownload bloqueia ou desbloqueia o acesso de uma compra paga
 func (ps *PurchaseServiceImpl) BlockDownload(purchaseID uint, creatorID uint, block bool) error {
 	purchase, err := ps.purchaseRepository.FindByID(purchaseID)
 	if err != nil {
@@ -136,14 +269,15 @@ func (ps *PurchaseServiceImpl) BlockDownload(purchaseID uint, creatorID uint, bl
 	}
 
 	if purchase.Ebook.CreatorID != creatorID {
-		return errors.New("unauthorized: purchase does not belong to this creator")
+		return ErrPurchaseNotOwned
 	}
 
-	if block {
-		purchase.DownloadLimit = purchase.DownloadsUsed
-	} else {
-		purchase.DownloadLimit = -1
+	change := salesmodel.StatusChange{Actor: salesmodel.StatusActorCreator, ActorID: creatorID, Reason: "Download bloqueado pelo criador"}
+	to := salesmodel.PurchaseStatusBlocked
+	if !block {
+		change.Reason = "Download desbloqueado pelo criador"
+		to = salesmodel.PurchaseStatusPaid
 	}
 
-	return ps.purchaseRepository.Update(purchase)
+	return ps.transition(purchase, to, change, nil)
 }
@@ -11,6 +11,7 @@ import (
 	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
 	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
 	"github.com/anglesson/simple-web-server/pkg/database"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -48,7 +49,7 @@ func TestPurchaseService_FindExistingPurchase_Found(t *testing.T) {
 	client := &salesmodel.Client{CPF: "11122233344", Email: "c@test.com", Phone: "11999999999"}
 	require.NoError(t, database.DB.Create(client).Error)
 
-	ebook := &librarymodel.Ebook{Title: "Ebook X", Value: 50, Status: true}
+	ebook := &librarymodel.Ebook{Title: "Ebook X", Value: money.FromCents(5000), Status: true}
 	require.NoError(t, database.DB.Create(ebook).Error)
 
 	purchase := &salesmodel.Purchase{EbookID: ebook.ID, ClientID: client.ID, HashID: "hash-abc", DownloadLimit: -1}
@@ -0,0 +1,107 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	authmodel "github.com/anglesson/simple-web-server/internal/auth/model"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	"github.com/anglesson/simple-web-server/internal/mocks"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/database"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupPreOrderTestDB(t *testing.T) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	err = db.AutoMigrate(
+		&authmodel.User{},
+		&accountmodel.Creator{},
+		&salesmodel.Client{},
+		&librarymodel.File{},
+		&librarymodel.Ebook{},
+		&salesmodel.Purchase{},
+		&salesmodel.PurchaseStatusHistory{},
+	)
+	require.NoError(t, err)
+	database.DB = db
+}
+
+func createPreOrderPurchase(t *testing.T, releaseAt time.Time, cpf, hash string) *salesmodel.Purchase {
+	t.Helper()
+	client := &salesmodel.Client{Name: "Leitor", CPF: cpf, Email: cpf + "@test.com", Phone: "11999999999"}
+	require.NoError(t, database.DB.Create(client).Error)
+
+	ebook := &librarymodel.Ebook{Title: "Ebook em Pré-venda", Value: money.FromCents(4000), Status: true, PreOrder: true, ReleaseAt: &releaseAt}
+	require.NoError(t, database.DB.Create(ebook).Error)
+
+	purchase := salesmodel.NewPurchase(ebook.ID, client.ID, hash)
+	require.NoError(t, database.DB.Create(purchase).Error)
+	return purchase
+}
+
+// TestPurchaseService_ConfirmPayment_PreOrder verifica que a compra de pré-venda fica aguardando o lançamento.
+func TestPurchaseService_ConfirmPayment_PreOrder(t *testing.T) {
+	setupPreOrderTestDB(t)
+	purchase := createPreOrderPurchase(t, time.Now().Add(72*time.Hour), "11122233344", "hash-pre")
+
+	svc := newPurchaseServiceForTest(t)
+	require.NoError(t, svc.ConfirmPayment(purchase.ID))
+
+	var saved salesmodel.Purchase
+	require.NoError(t, database.DB.First(&saved, purchase.ID).Error)
+	assert.True(t, saved.IsPaymentConfirmed())
+	assert.True(t, saved.IsAwaitingRelease())
+}
+
+// TestPurchaseService_ReleasePreOrders verifica que apenas pré-vendas lançadas são liberadas e notificadas em lote.
+func TestPurchaseService_ReleasePreOrders(t *testing.T) {
+	setupPreOrderTestDB(t)
+	released := createPreOrderPurchase(t, time.Now().Add(-time.Minute), "11122233344", "hash-released")
+	waiting := createPreOrderPurchase(t, time.Now().Add(24*time.Hour), "55566677788", "hash-waiting")
+	refunded := createPreOrderPurchase(t, time.Now().Add(-time.Minute), "99988877766", "hash-refunded")
+	require.NoError(t, database.DB.Model(&salesmodel.Purchase{}).
+		Where("id IN ?", []uint{released.ID, waiting.ID, refunded.ID}).
+		Updates(map[string]any{"awaiting_release": true, "payment_status": salesmodel.PaymentStatusConfirmed, "status": salesmodel.PurchaseStatusPaid}).Error)
+	// Reembolsada antes do lançamento: o acesso não pode ser liberado
+	require.NoError(t, database.DB.Model(refunded).Update("status", salesmodel.PurchaseStatusRefunded).Error)
+
+	emailMock := &mocks.MockSalesEmailService{}
+	emailMock.On("SendLinkToDownload", mock.MatchedBy(func(purchases []*salesmodel.Purchase) bool {
+		return len(purchases) == 1 && purchases[0].ID == released.ID && purchases[0].Client.Email != ""
+	})).Return().Once()
+	svc := salesvc.NewPurchaseService(salesrepo.NewPurchaseRepository(), emailMock)
+
+	count, err := svc.ReleasePreOrders(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	emailMock.AssertExpectations(t)
+
+	var reloaded salesmodel.Purchase
+	require.NoError(t, database.DB.First(&reloaded, released.ID).Error)
+	assert.False(t, reloaded.AwaitingRelease)
+
+	var stillWaiting salesmodel.Purchase
+	require.NoError(t, database.DB.First(&stillWaiting, waiting.ID).Error)
+	assert.True(t, stillWaiting.AwaitingRelease)
+
+	var notReleased salesmodel.Purchase
+	require.NoError(t, database.DB.First(&notReleased, refunded.ID).Error)
+	assert.True(t, notReleased.AwaitingRelease)
+	assert.Equal(t, salesmodel.PurchaseStatusRefunded, notReleased.Status)
+
+	// Execução seguinte não reenvia emails
+	count, err = svc.ReleasePreOrders(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
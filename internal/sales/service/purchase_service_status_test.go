@@ -0,0 +1,98 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createPaidPurchase(t *testing.T, creatorID uint) (salesvc.PurchaseService, *salesmodel.Purchase) {
+	t.Helper()
+	setupPreOrderTestDB(t)
+	purchase := createPreOrderPurchase(t, time.Now().Add(-time.Hour), "11122233344", "hash-status")
+	require.NoError(t, database.DB.Model(&librarymodel.Ebook{}).Where("id = ?", purchase.EbookID).
+		Updates(map[string]any{"creator_id": creatorID, "pre_order": false}).Error)
+
+	svc := newPurchaseServiceForTest(t)
+	require.NoError(t, svc.ConfirmPayment(purchase.ID))
+	return svc, purchase
+}
+
+// TestPurchaseService_StatusHistory verifica que cada mudança de status fica registrada com autor e motivo.
+func TestPurchaseService_StatusHistory(t *testing.T) {
+	svc, purchase := createPaidPurchase(t, 5)
+
+	require.NoError(t, svc.BlockDownload(purchase.ID, 5, true))
+	require.NoError(t, svc.BlockDownload(purchase.ID, 5, false))
+	require.NoError(t, svc.ChangeStatus(purchase.ID, salesmodel.PurchaseStatusRefunded,
+		salesmodel.StatusChange{Actor: salesmodel.StatusActorStripe, Reason: "Pagamento reembolsado no Stripe"}))
+
+	var saved salesmodel.Purchase
+	require.NoError(t, database.DB.First(&saved, purchase.ID).Error)
+	assert.Equal(t, salesmodel.PurchaseStatusRefunded, saved.Status)
+	assert.True(t, saved.IsPaymentConfirmed())
+
+	history, err := svc.GetStatusHistory(purchase.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 4)
+	assert.Equal(t, salesmodel.PurchaseStatusRefunded, history[0].ToStatus)
+	assert.Equal(t, salesmodel.StatusActorStripe, history[0].Actor)
+	assert.Equal(t, salesmodel.PurchaseStatusPaid, history[1].ToStatus)
+	assert.Equal(t, salesmodel.StatusActorCreator, history[1].Actor)
+	assert.Equal(t, salesmodel.PurchaseStatusBlocked, history[2].ToStatus)
+	assert.Equal(t, salesmodel.PurchaseStatusPending, history[3].FromStatus)
+	assert.Equal(t, salesmodel.PurchaseStatusPaid, history[3].ToStatus)
+}
+
+// TestPurchaseService_InvalidTransition verifica que mudanças fora da máquina de estados são recusadas.
+func TestPurchaseService_InvalidTransition(t *testing.T) {
+	svc, purchase := createPaidPurchase(t, 5)
+	require.NoError(t, svc.RevokeAccess(purchase.ID, 5, "Chargeback recorrente"))
+
+	err := svc.BlockDownload(purchase.ID, 5, false)
+	assert.ErrorIs(t, err, salesvc.ErrInvalidStatusTransition)
+
+	err = svc.ConfirmPayment(purchase.ID)
+	assert.ErrorIs(t, err, salesvc.ErrInvalidStatusTransition)
+
+	history, err := svc.GetStatusHistory(purchase.ID)
+	require.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, "Chargeback recorrente", history[0].Reason)
+}
+
+// TestPurchaseService_RepeatedConfirmation verifica que webhooks repetidos não duplicam o histórico.
+func TestPurchaseService_RepeatedConfirmation(t *testing.T) {
+	svc, purchase := createPaidPurchase(t, 5)
+
+	require.NoError(t, svc.ConfirmPayment(purchase.ID))
+
+	history, err := svc.GetStatusHistory(purchase.ID)
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+}
+
+// TestPurchaseService_RevokeAccess_OtherCreator verifica que apenas o dono do ebook revoga o acesso.
+func TestPurchaseService_RevokeAccess_OtherCreator(t *testing.T) {
+	svc, purchase := createPaidPurchase(t, 5)
+
+	err := svc.RevokeAccess(purchase.ID, 9, "")
+	assert.ErrorIs(t, err, salesvc.ErrPurchaseNotOwned)
+}
+
+// TestPurchaseRepository_TransitionStatus_StaleStatus verifica que a mudança só é aplicada a partir do status lido.
+func TestPurchaseRepository_TransitionStatus_StaleStatus(t *testing.T) {
+	_, purchase := createPaidPurchase(t, 5)
+
+	history := salesmodel.NewPurchaseStatusHistory(purchase.ID, salesmodel.PurchaseStatusPending, salesmodel.PurchaseStatusExpired,
+		salesmodel.StatusChange{Actor: salesmodel.StatusActorStripe})
+	err := salesrepo.NewPurchaseRepository().TransitionStatus(history, nil)
+	assert.ErrorIs(t, err, salesrepo.ErrPurchaseStatusChanged)
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	"github.com/anglesson/simple-web-server/internal/config"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	"github.com/anglesson/simple-web-server/pkg/gov"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+var ErrReceiptUnavailable = errors.New("o recibo fica disponível após a confirmação do pagamento")
+
+// Receipt é o recibo da compra em PDF, pronto para anexar ao email ou baixar
+type Receipt struct {
+	FileName string
+	Content  []byte
+}
+
+// ReceiptService gera o recibo com os dados fiscais do vendedor e do comprador.
+// O PDF é montado a cada pedido, então sempre reflete os dados atuais da venda.
+type ReceiptService interface {
+	GenerateForPurchase(purchaseID uint) (*Receipt, error)
+	GenerateForTransaction(transaction *salesmodel.Transaction) (*Receipt, error)
+}
+
+type receiptServiceImpl struct {
+	transactionRepo salesrepo.TransactionRepository
+}
+
+func NewReceiptService(transactionRepo salesrepo.TransactionRepository) ReceiptService {
+	return &receiptServiceImpl{
+		transactionRepo: transactionRepo,
+	}
+}
+
+func (s *receiptServiceImpl) GenerateForPurchase(purchaseID uint) (*Receipt, error) {
+	transaction, err := s.transactionRepo.FindByPurchaseID(purchaseID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar transação da compra: %v", err)
+	}
+	return s.GenerateForTransaction(transaction)
+}
+
+// GenerateForTransaction espera a transação com Creator, Purchase.Ebook e Purchase.Client carregados
+func (s *receiptServiceImpl) GenerateForTransaction(transaction *salesmodel.Transaction) (*Receipt, error) {
+	if transaction.Status != salesmodel.TransactionStatusCompleted {
+		return nil, ErrReceiptUnavailable
+	}
+
+	layout, err := json.Marshal(receiptLayout(transaction))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao montar o recibo: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := api.Create(nil, bytes.NewReader(layout), &out, nil); err != nil {
+		return nil, fmt.Errorf("erro ao gerar o PDF do recibo: %v", err)
+	}
+
+	return &Receipt{
+		FileName: "recibo-" + transaction.PublicID + ".pdf",
+		Content:  out.Bytes(),
+	}, nil
+}
+
+// receiptLayout descreve a página no formato JSON de criação do pdfcpu
+func receiptLayout(transaction *salesmodel.Transaction) map[string]any {
+	purchase := transaction.Purchase
+	paidAt := transaction.CreatedAt
+	if transaction.ProcessedAt != nil {
+		paidAt = *transaction.ProcessedAt
+	}
+
+	sellerName, sellerDocument := receiptSeller(&transaction.Creator)
+	seller := []string{
+		"Vendedor",
+		sellerName,
+		sellerDocument,
+		transaction.Creator.Email,
+	}
+	buyer := []string{
+		"Comprador",
+		purchase.Client.Name,
+		receiptBuyerDocument(&purchase.Client),
+		purchase.Client.Email,
+	}
+	payment := []string{
+		"Recibo nº " + transaction.PublicID,
+		"Data do pagamento: " + paidAt.Format("02/01/2006 15:04"),
+		"Forma de pagamento: " + transaction.GetPaymentMethodLabel(),
+	}
+
+	return map[string]any{
+		"paper":  "A4P",
+		"origin": "UpperLeft",
+		"margin": map[string]any{"width": 40},
+		"fonts": map[string]any{
+			"title": map[string]any{"name": "Helvetica-Bold", "size": 18},
+			"label": map[string]any{"name": "Helvetica-Bold", "size": 10},
+			"body":  map[string]any{"name": "Helvetica", "size": 10},
+			"small": map[string]any{"name": "Helvetica", "size": 8, "col": "#666666"},
+		},
+		"pages": map[string]any{
+			"1": map[string]any{
+				"content": map[string]any{
+					"text": []any{
+						receiptText("Recibo de compra", 0, 20, "$title"),
+						receiptText(strings.Join(payment, "\n"), 0, 80, "$body"),
+						receiptText(strings.Join(seller, "\n"), 0, 150, "$body"),
+						receiptText(strings.Join(buyer, "\n"), 270, 150, "$body"),
+						receiptText("Total pago: "+transaction.GetFormattedTotalAmount(), 0, 240, "$label"),
+						receiptText(fmt.Sprintf("Recibo emitido por %s em nome do vendedor. Pagamento processado pelo Stripe.",
+							config.AppConfig.AppName), 0, 270, "$small"),
+					},
+					"table": []any{
+						map[string]any{
+							"pos":        []int{0, 205},
+							"rows":       1,
+							"cols":       3,
+							"width":      515,
+							"lheight":    20,
+							"grid":       true,
+							"colWidths":  []int{70, 10, 20},
+							"colAnchors": []string{"Left", "Center", "Right"},
+							"font":       map[string]any{"name": "$body"},
+							"header": map[string]any{
+								"values":     []string{"Descrição", "Qtd", "Valor"},
+								"colAnchors": []string{"Left", "Center", "Right"},
+								"font":       map[string]any{"name": "$label"},
+							},
+							"values": [][]string{
+								{pdfText("E-book: " + purchase.Ebook.Title), "1", transaction.GetFormattedTotalAmount()},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func receiptText(value string, x, y int, font string) map[string]any {
+	return map[string]any{
+		"value": pdfText(value),
+		"pos":   []int{x, y},
+		"font":  map[string]any{"name": font},
+	}
+}
+
+// pdfText escapa o % que o pdfcpu interpreta como marcador (%p, %t...) em nomes e títulos
+func pdfText(value string) string {
+	return strings.ReplaceAll(value, "%", "%%")
+}
+
+// receiptSeller usa a razão social e o CNPJ de quem vende como pessoa jurídica
+func receiptSeller(creator *accountmodel.Creator) (string, string) {
+	if creator.IsCompany() {
+		name := creator.CompanyName
+		if name == "" {
+			name = creator.Name
+		}
+		return name, "CNPJ: " + gov.FormatCNPJ(creator.CNPJ)
+	}
+	return creator.Name, "CPF: " + gov.FormatCPF(creator.CPF)
+}
+
+func receiptBuyerDocument(client *salesmodel.Client) string {
+	if client.IsForeign() {
+		return client.DocumentLabel() + ": " + client.IdentityDocument()
+	}
+	return client.DocumentLabel() + ": " + gov.FormatCPF(client.CPF)
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	"github.com/anglesson/simple-web-server/internal/mocks"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receiptTransaction(status salesmodel.TransactionStatus) *salesmodel.Transaction {
+	processedAt := time.Date(2025, 3, 10, 14, 30, 0, 0, time.UTC)
+	return &salesmodel.Transaction{
+		PublicID:      "txn_recibo",
+		Status:        status,
+		TotalAmount:   4990,
+		Currency:      money.BRL,
+		PaymentMethod: "card",
+		ProcessedAt:   &processedAt,
+		Creator:       accountmodel.Creator{Name: "Maria Autora", CPF: "12345678909", Email: "maria@test.com"},
+		Purchase: salesmodel.Purchase{
+			Ebook:  librarymodel.Ebook{Title: "Guia 100% prático"},
+			Client: salesmodel.Client{Name: "João Comprador", CPF: "98765432100", Email: "joao@test.com"},
+		},
+	}
+}
+
+func TestReceiptService_GeneratesPDFForCompletedTransaction(t *testing.T) {
+	mockRepo := new(mocks.MockTransactionRepository)
+	mockRepo.On("FindByPurchaseID", uint(3)).Return(receiptTransaction(salesmodel.TransactionStatusCompleted), nil).Once()
+	service := NewReceiptService(mockRepo)
+
+	receipt, err := service.GenerateForPurchase(3)
+	require.NoError(t, err)
+
+	assert.Equal(t, "recibo-txn_recibo.pdf", receipt.FileName)
+	assert.True(t, bytes.HasPrefix(receipt.Content, []byte("%PDF")))
+	assert.NoError(t, api.Validate(bytes.NewReader(receipt.Content), nil))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestReceiptService_PendingTransactionHasNoReceipt(t *testing.T) {
+	service := NewReceiptService(new(mocks.MockTransactionRepository))
+
+	_, err := service.GenerateForTransaction(receiptTransaction(salesmodel.TransactionStatusPending))
+	assert.ErrorIs(t, err, ErrReceiptUnavailable)
+}
+
+func TestReceiptLayout_IncludesFiscalData(t *testing.T) {
+	transaction := receiptTransaction(salesmodel.TransactionStatusCompleted)
+	layout, err := json.Marshal(receiptLayout(transaction))
+	require.NoError(t, err)
+
+	for _, expected := range []string{"txn_recibo", "CPF: 123.456.789-09", "CPF: 987.654.321-00",
+		"Cartão de crédito", "R$ 49,90", "10/03/2025 14:30", "Guia 100%% prático"} {
+		assert.Contains(t, string(layout), expected)
+	}
+
+	transaction.Creator.PersonType = accountmodel.PersonTypeCompany
+	transaction.Creator.CompanyName = "Autora Edições LTDA"
+	transaction.Creator.CNPJ = "11222333000181"
+	layout, err = json.Marshal(receiptLayout(transaction))
+	require.NoError(t, err)
+	assert.Contains(t, string(layout), "Autora Edições LTDA")
+	assert.Contains(t, string(layout), "CNPJ: 11.222.333/0001-81")
+}
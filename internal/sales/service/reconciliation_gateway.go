@@ -0,0 +1,45 @@
+package service
+
+import (
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/config"
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/paymentintent"
+)
+
+// ReconciliationGateway lista no Stripe os pagamentos de uma conta para a conciliação
+type ReconciliationGateway interface {
+	ListPaymentIntents(accountID string, from, to time.Time) ([]*stripe.PaymentIntent, error)
+}
+
+type StripeReconciliationGateway struct{}
+
+func NewStripeReconciliationGateway() ReconciliationGateway {
+	if stripe.Key == "" {
+		stripe.Key = config.AppConfig.StripeSecretKey
+	}
+	return &StripeReconciliationGateway{}
+}
+
+// ListPaymentIntents traz os payment intents criados no intervalo com a última cobrança expandida.
+// accountID vazio consulta a conta da plataforma (vendas de criadores sem Stripe Connect).
+func (g *StripeReconciliationGateway) ListPaymentIntents(accountID string, from, to time.Time) ([]*stripe.PaymentIntent, error) {
+	params := &stripe.PaymentIntentListParams{
+		CreatedRange: &stripe.RangeQueryParams{
+			GreaterThanOrEqual: from.Unix(),
+			LesserThan:         to.Unix(),
+		},
+	}
+	params.AddExpand("data.latest_charge")
+	if accountID != "" {
+		params.SetStripeAccount(accountID)
+	}
+
+	var intents []*stripe.PaymentIntent
+	iter := paymentintent.List(params)
+	for iter.Next() {
+		intents = append(intents, iter.PaymentIntent())
+	}
+	return intents, iter.Err()
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	"github.com/stripe/stripe-go/v76"
+)
+
+// checkoutSessionMaxAge é a validade máxima de uma sessão de checkout no Stripe;
+// uma transação pendente mais antiga que isso não vai mais receber pagamento
+const checkoutSessionMaxAge = 24 * time.Hour
+
+// ReconciliationService confere as transações locais contra os pagamentos do Stripe
+type ReconciliationService interface {
+	Reconcile(from, to time.Time) (*salesmodel.ReconciliationRun, error)
+}
+
+type reconciliationServiceImpl struct {
+	reconciliationRepo  salesrepo.ReconciliationRepository
+	transactionRepo     salesrepo.TransactionRepository
+	recoveryRepo        salesrepo.CheckoutRecoveryRepository
+	gateway             ReconciliationGateway
+	paymentConfirmation PaymentConfirmationService
+	emailService        IEmailService
+}
+
+func NewReconciliationService(
+	reconciliationRepo salesrepo.ReconciliationRepository,
+	transactionRepo salesrepo.TransactionRepository,
+	recoveryRepo salesrepo.CheckoutRecoveryRepository,
+	gateway ReconciliationGateway,
+	paymentConfirmation PaymentConfirmationService,
+	emailService IEmailService,
+) ReconciliationService {
+	return &reconciliationServiceImpl{
+		reconciliationRepo:  reconciliationRepo,
+		transactionRepo:     transactionRepo,
+		recoveryRepo:        recoveryRepo,
+		gateway:             gateway,
+		paymentConfirmation: paymentConfirmation,
+		emailService:        emailService,
+	}
+}
+
+// Reconcile confere os pagamentos criados no intervalo em todas as contas (plataforma e Connect).
+// Só corrige o que o próprio Stripe confirma sem ambiguidade; o restante vai para os operadores.
+func (s *reconciliationServiceImpl) Reconcile(from, to time.Time) (*salesmodel.ReconciliationRun, error) {
+	accounts, err := s.reconciliationRepo.FindConnectedAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar contas conectadas: %v", err)
+	}
+	// Criadores sem Stripe Connect vendem pela conta da plataforma
+	accounts[""] = 0
+
+	run := &salesmodel.ReconciliationRun{From: from, To: to}
+	for accountID, creatorID := range accounts {
+		if err := s.reconcileAccount(run, accountID, creatorID); err != nil {
+			// Uma conta desconectada ou sem permissão não impede a conferência das demais
+			slog.Error("Erro ao conciliar conta Stripe", "account", accountID, "error", err)
+		}
+	}
+
+	if err := s.checkOrphanPending(run, from, to); err != nil {
+		return nil, err
+	}
+
+	for _, issue := range run.Issues {
+		if issue.AutoFixed {
+			run.AutoFixed++
+		}
+	}
+	if err := s.reconciliationRepo.CreateRun(run); err != nil {
+		return nil, fmt.Errorf("erro ao salvar conciliação: %v", err)
+	}
+
+	if len(run.PendingIssues()) > 0 {
+		s.emailService.SendReconciliationReport(run)
+	}
+	return run, nil
+}
+
+func (s *reconciliationServiceImpl) reconcileAccount(run *salesmodel.ReconciliationRun, accountID string, creatorID uint) error {
+	intents, err := s.gateway.ListPaymentIntents(accountID, run.From, run.To)
+	if err != nil {
+		return err
+	}
+
+	// Assinaturas e outros pagamentos da conta da plataforma não são vendas de ebook
+	var sales []*stripe.PaymentIntent
+	var ids []string
+	for _, intent := range intents {
+		if intent.Metadata["ebook_id"] == "" && intent.Metadata["purchase_id"] == "" {
+			continue
+		}
+		sales = append(sales, intent)
+		ids = append(ids, intent.ID)
+	}
+
+	local, err := s.reconciliationRepo.FindTransactionsByPaymentIntents(ids)
+	if err != nil {
+		return err
+	}
+
+	for _, intent := range sales {
+		transaction, ok := local[intent.ID]
+		if !ok {
+			s.checkMissingLocal(run, accountID, creatorID, intent)
+			continue
+		}
+		run.Checked++
+		s.checkTransaction(run, accountID, intent, transaction)
+	}
+	return nil
+}
+
+// checkMissingLocal trata pagamentos confirmados no Stripe sem transação com o payment intent.
+// Se a compra do metadata ainda tem a transação pendente sem payment intent, o webhook se perdeu
+// e a transação é concluída; qualquer outro caso fica para os operadores.
+func (s *reconciliationServiceImpl) checkMissingLocal(run *salesmodel.ReconciliationRun, accountID string, creatorID uint, intent *stripe.PaymentIntent) {
+	if intent.Status != stripe.PaymentIntentStatusSucceeded {
+		return
+	}
+
+	issue := salesmodel.ReconciliationIssue{
+		Kind:                  salesmodel.ReconciliationMissingLocal,
+		CreatorID:             creatorID,
+		StripeAccountID:       accountID,
+		StripePaymentIntentID: intent.ID,
+		StripeAmount:          intent.AmountReceived,
+		Currency:              strings.ToUpper(string(intent.Currency)),
+		StripeStatus:          string(intent.Status),
+		Detail:                "Pagamento confirmado no Stripe sem transação registrada",
+	}
+
+	purchaseID, err := strconv.ParseUint(intent.Metadata["purchase_id"], 10, 32)
+	if err == nil {
+		transaction, err := s.transactionRepo.FindByPurchaseID(uint(purchaseID))
+		if err == nil && transaction != nil {
+			issue.TransactionID = &transaction.ID
+			issue.CreatorID = transaction.CreatorID
+			issue.LocalAmount = transaction.TotalAmount
+			issue.LocalStatus = string(transaction.Status)
+
+			if transaction.Status == salesmodel.TransactionStatusPending && transaction.StripePaymentIntentID == "" {
+				transaction.StripePaymentIntentID = intent.ID
+				if s.complete(transaction) == nil {
+					issue.AutoFixed = true
+					issue.Detail = "Transação pendente da compra concluída com o payment intent do Stripe"
+				}
+			} else {
+				issue.Detail = fmt.Sprintf("A compra %d já tem a transação %s com outro payment intent (%s)",
+					purchaseID, transaction.PublicID, transaction.StripePaymentIntentID)
+			}
+		}
+	}
+	run.Issues = append(run.Issues, issue)
+}
+
+func (s *reconciliationServiceImpl) checkTransaction(run *salesmodel.ReconciliationRun, accountID string, intent *stripe.PaymentIntent, transaction *salesmodel.Transaction) {
+	newIssue := func(kind salesmodel.ReconciliationIssueKind, detail string) salesmodel.ReconciliationIssue {
+		return salesmodel.ReconciliationIssue{
+			Kind:                  kind,
+			CreatorID:             transaction.CreatorID,
+			TransactionID:         &transaction.ID,
+			StripeAccountID:       accountID,
+			StripePaymentIntentID: intent.ID,
+			LocalAmount:           transaction.TotalAmount,
+			StripeAmount:          intent.AmountReceived,
+			Currency:              transaction.Currency,
+			LocalStatus:           string(transaction.Status),
+			StripeStatus:          string(intent.Status),
+			Detail:                detail,
+		}
+	}
+
+	switch {
+	case intent.Status == stripe.PaymentIntentStatusSucceeded && transaction.Status == salesmodel.TransactionStatusPending:
+		issue := newIssue(salesmodel.ReconciliationStatusMismatch, "Pagamento confirmado no Stripe com a transação ainda pendente")
+		if s.complete(transaction) == nil {
+			issue.AutoFixed = true
+			issue.Detail = "Transação pendente concluída: pagamento confirmado no Stripe"
+		}
+		run.Issues = append(run.Issues, issue)
+
+	case intent.Status == stripe.PaymentIntentStatusCanceled && transaction.Status == salesmodel.TransactionStatusPending:
+		issue := newIssue(salesmodel.ReconciliationStatusMismatch, "Pagamento cancelado no Stripe com a transação ainda pendente")
+		if s.fail(transaction, "Conciliação: pagamento cancelado no Stripe") == nil {
+			issue.AutoFixed = true
+			issue.Detail = "Transação marcada como falha: pagamento cancelado no Stripe"
+		}
+		run.Issues = append(run.Issues, issue)
+
+	case intent.Status != stripe.PaymentIntentStatusSucceeded && transaction.Status == salesmodel.TransactionStatusCompleted:
+		run.Issues = append(run.Issues, newIssue(salesmodel.ReconciliationStatusMismatch,
+			"Transação concluída sem pagamento confirmado no Stripe"))
+	}
+
+	if intent.Status != stripe.PaymentIntentStatusSucceeded {
+		return
+	}
+
+	stripeCurrency := strings.ToUpper(string(intent.Currency))
+	if intent.AmountReceived != transaction.TotalAmount || stripeCurrency != transaction.Currency {
+		run.Issues = append(run.Issues, newIssue(salesmodel.ReconciliationAmountMismatch, fmt.Sprintf(
+			"Stripe recebeu %d %s e a transação registra %d %s",
+			intent.AmountReceived, stripeCurrency, transaction.TotalAmount, transaction.Currency)))
+	}
+
+	// Reembolso devolve o acesso do comprador; mexer nisso fora do webhook exige decisão humana
+	if intent.LatestCharge != nil && intent.LatestCharge.Refunded &&
+		transaction.Purchase.Status != salesmodel.PurchaseStatusRefunded {
+		run.Issues = append(run.Issues, newIssue(salesmodel.ReconciliationStatusMismatch, fmt.Sprintf(
+			"Cobrança reembolsada no Stripe com a compra em %q", transaction.Purchase.Status)))
+	}
+}
+
+// checkOrphanPending revisa as transações pendentes que já passaram da validade do checkout.
+// As que nunca receberam payment intent são de sessões expiradas e viram falha, exceto enquanto
+// o link de recuperação do checkout ainda pode reabrir a compra.
+func (s *reconciliationServiceImpl) checkOrphanPending(run *salesmodel.ReconciliationRun, from, to time.Time) error {
+	cutoff := to.Add(-checkoutSessionMaxAge)
+	if !cutoff.After(from) {
+		return nil
+	}
+
+	transactions, err := s.reconciliationRepo.FindPendingTransactions(from, cutoff)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar transações pendentes: %v", err)
+	}
+
+	for _, transaction := range transactions {
+		if transaction.Status != salesmodel.TransactionStatusPending {
+			continue
+		}
+		if transaction.StripePaymentIntentID == "" && s.isRecoverable(transaction.PurchaseID, to) {
+			continue
+		}
+		issue := salesmodel.ReconciliationIssue{
+			Kind:                  salesmodel.ReconciliationOrphanPending,
+			CreatorID:             transaction.CreatorID,
+			TransactionID:         &transaction.ID,
+			StripePaymentIntentID: transaction.StripePaymentIntentID,
+			LocalAmount:           transaction.TotalAmount,
+			Currency:              transaction.Currency,
+			LocalStatus:           string(transaction.Status),
+			Detail:                "Transação pendente com payment intent que não aparece nas contas conferidas",
+		}
+		if transaction.StripePaymentIntentID == "" {
+			issue.Detail = "Transação pendente sem pagamento após a validade do checkout"
+			if s.fail(transaction, "Conciliação: checkout expirado sem pagamento") == nil {
+				issue.AutoFixed = true
+				issue.Detail = "Transação marcada como falha: checkout expirado sem pagamento"
+			}
+		}
+		run.Issues = append(run.Issues, issue)
+	}
+	return nil
+}
+
+// isRecoverable indica se o comprador ainda pode concluir a compra pelo link de recuperação
+func (s *reconciliationServiceImpl) isRecoverable(purchaseID uint, now time.Time) bool {
+	recovery, err := s.recoveryRepo.FindByPurchaseID(purchaseID)
+	if err != nil || recovery == nil {
+		return false
+	}
+	return recovery.IsLinkValid(now)
+}
+
+// complete conclui a transação e entrega a compra pelo mesmo caminho do webhook checkout.session.completed:
+// confirma o pagamento, repassa os splits e envia o link de download
+func (s *reconciliationServiceImpl) complete(transaction *salesmodel.Transaction) error {
+	now := time.Now()
+	transaction.Status = salesmodel.TransactionStatusCompleted
+	transaction.ProcessedAt = &now
+	if err := s.save(transaction); err != nil {
+		return err
+	}
+
+	if err := s.paymentConfirmation.ConfirmEbookPayment(&transaction.Purchase, transaction.StripePaymentIntentID); err != nil {
+		slog.Error("Erro ao entregar compra na conciliação", "purchaseID", transaction.PurchaseID, "error", err)
+	}
+	return nil
+}
+
+func (s *reconciliationServiceImpl) fail(transaction *salesmodel.Transaction, reason string) error {
+	transaction.Status = salesmodel.TransactionStatusFailed
+	transaction.ErrorMessage = reason
+	return s.save(transaction)
+}
+
+func (s *reconciliationServiceImpl) save(transaction *salesmodel.Transaction) error {
+	err := s.transactionRepo.UpdateTransaction(transaction)
+	if err != nil {
+		slog.Error("Erro ao corrigir transação na conciliação", "transactionID", transaction.ID, "error", err)
+	}
+	return err
+}
+
+// StartReconciliationJob concilia diariamente a janela configurada, para pegar webhooks atrasados
+func StartReconciliationJob(reconciliationService ReconciliationService, interval, lookback time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for now := range ticker.C {
+			run, err := reconciliationService.Reconcile(now.Add(-lookback), now)
+			if err != nil {
+				slog.Error("Erro na conciliação com o Stripe", "error", err)
+				continue
+			}
+			slog.Info("Conciliação com o Stripe concluída",
+				"checked", run.Checked, "autoFixed", run.AutoFixed, "pending", len(run.PendingIssues()))
+		}
+	}()
+}
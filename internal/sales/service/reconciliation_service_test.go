@@ -0,0 +1,142 @@
+package service_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	"github.com/anglesson/simple-web-server/internal/mocks"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v76"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// fakeReconciliationGateway devolve os payment intents fixos de cada conta
+type fakeReconciliationGateway struct {
+	intents map[string][]*stripe.PaymentIntent
+}
+
+func (g *fakeReconciliationGateway) ListPaymentIntents(accountID string, from, to time.Time) ([]*stripe.PaymentIntent, error) {
+	return g.intents[accountID], nil
+}
+
+func succeededIntent(id string, amount int64, metadata map[string]string) *stripe.PaymentIntent {
+	return &stripe.PaymentIntent{
+		ID:             id,
+		Status:         stripe.PaymentIntentStatusSucceeded,
+		AmountReceived: amount,
+		Currency:       "brl",
+		Metadata:       metadata,
+		LatestCharge:   &stripe.Charge{},
+	}
+}
+
+func createReconciliationSale(t *testing.T, db *gorm.DB, hashID string, creatorID uint, paymentIntentID string, status salesmodel.TransactionStatus) *salesmodel.Transaction {
+	t.Helper()
+	purchase := &salesmodel.Purchase{HashID: hashID, Status: salesmodel.PurchaseStatusPaid}
+	require.NoError(t, db.Create(purchase).Error)
+	transaction := &salesmodel.Transaction{
+		PurchaseID:            purchase.ID,
+		CreatorID:             creatorID,
+		StripePaymentIntentID: paymentIntentID,
+		Status:                status,
+		Currency:              money.BRL,
+		TotalAmount:           5000,
+	}
+	require.NoError(t, db.Create(transaction).Error)
+	return transaction
+}
+
+func TestReconciliationService_FixesSafeMismatchesAndReportsTheRest(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&accountmodel.Creator{}, &librarymodel.Ebook{}, &salesmodel.Client{}, &salesmodel.Purchase{},
+		&salesmodel.Transaction{}, &salesmodel.TransactionSplit{}, &salesmodel.ReconciliationRun{}, &salesmodel.ReconciliationIssue{},
+		&salesmodel.CheckoutRecovery{}))
+
+	creator := &accountmodel.Creator{Name: "Maria", Email: "maria@test.com", StripeConnectAccountID: "acct_maria"}
+	require.NoError(t, db.Create(creator).Error)
+
+	pendingPaid := createReconciliationSale(t, db, "hash-pendingPaid", creator.ID, "pi_pendente", salesmodel.TransactionStatusPending)
+	wrongAmount := createReconciliationSale(t, db, "hash-wrongAmount", creator.ID, "pi_valor", salesmodel.TransactionStatusCompleted)
+	lostWebhook := createReconciliationSale(t, db, "hash-lostWebhook", creator.ID, "", salesmodel.TransactionStatusPending)
+	refunded := createReconciliationSale(t, db, "hash-refunded", creator.ID, "pi_reembolso", salesmodel.TransactionStatusCompleted)
+
+	now := time.Now()
+	expired := createReconciliationSale(t, db, "hash-expired", creator.ID, "", salesmodel.TransactionStatusPending)
+	require.NoError(t, db.Model(expired).Update("created_at", now.Add(-48*time.Hour)).Error)
+
+	// Checkout expirado cujo link de recuperação ainda pode reabrir a compra
+	recoverable := createReconciliationSale(t, db, "hash-recoverable", creator.ID, "", salesmodel.TransactionStatusPending)
+	require.NoError(t, db.Model(recoverable).Update("created_at", now.Add(-48*time.Hour)).Error)
+	recovery := salesmodel.NewCheckoutRecovery(recoverable.PurchaseID, 0, 0, creator.ID, "cs_recuperavel", now.Add(-24*time.Hour))
+	recovery.SetLinkExpiry([]time.Duration{time.Hour, 24 * time.Hour}, 72*time.Hour)
+	require.NoError(t, db.Create(recovery).Error)
+
+	refundedIntent := succeededIntent("pi_reembolso", 5000, map[string]string{"ebook_id": "1"})
+	refundedIntent.LatestCharge.Refunded = true
+	gateway := &fakeReconciliationGateway{intents: map[string][]*stripe.PaymentIntent{
+		"acct_maria": {
+			succeededIntent("pi_pendente", 5000, map[string]string{"ebook_id": "1"}),
+			succeededIntent("pi_valor", 4500, map[string]string{"ebook_id": "1"}),
+			succeededIntent("pi_perdido", 5000, map[string]string{"purchase_id": strconv.Itoa(int(lostWebhook.PurchaseID))}),
+			succeededIntent("pi_desconhecido", 5000, map[string]string{"ebook_id": "1"}),
+			refundedIntent,
+		},
+		// Assinatura da plataforma, sem metadata de ebook
+		"": {succeededIntent("pi_assinatura", 2990, nil)},
+	}}
+
+	emailService := new(mocks.MockSalesEmailService)
+	emailService.On("SendReconciliationReport", mock.Anything).Return().Once()
+
+	// Os webhooks perdidos são entregues pelo mesmo caminho do checkout.session.completed
+	paymentConfirmation := new(mocks.MockPaymentConfirmationService)
+	paymentConfirmation.On("ConfirmEbookPayment", mock.MatchedBy(func(p *salesmodel.Purchase) bool { return p.ID == pendingPaid.PurchaseID }), "pi_pendente").Return(nil).Once()
+	paymentConfirmation.On("ConfirmEbookPayment", mock.MatchedBy(func(p *salesmodel.Purchase) bool { return p.ID == lostWebhook.PurchaseID }), "pi_perdido").Return(nil).Once()
+
+	service := salesvc.NewReconciliationService(salesrepo.NewReconciliationRepository(db), salesrepo.NewTransactionRepository(db),
+		salesrepo.NewCheckoutRecoveryRepository(db), gateway, paymentConfirmation, emailService)
+	run, err := service.Reconcile(now.Add(-72*time.Hour), now)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, run.Checked)
+	assert.Equal(t, 3, run.AutoFixed)
+
+	byKind := map[salesmodel.ReconciliationIssueKind][]salesmodel.ReconciliationIssue{}
+	for _, issue := range run.PendingIssues() {
+		byKind[issue.Kind] = append(byKind[issue.Kind], issue)
+	}
+	require.Len(t, byKind[salesmodel.ReconciliationAmountMismatch], 1)
+	assert.Equal(t, wrongAmount.ID, *byKind[salesmodel.ReconciliationAmountMismatch][0].TransactionID)
+	require.Len(t, byKind[salesmodel.ReconciliationMissingLocal], 1)
+	assert.Equal(t, "pi_desconhecido", byKind[salesmodel.ReconciliationMissingLocal][0].StripePaymentIntentID)
+	require.Len(t, byKind[salesmodel.ReconciliationStatusMismatch], 1)
+	assert.Equal(t, refunded.ID, *byKind[salesmodel.ReconciliationStatusMismatch][0].TransactionID)
+
+	statusOf := func(id uint) salesmodel.Transaction {
+		var transaction salesmodel.Transaction
+		require.NoError(t, db.First(&transaction, id).Error)
+		return transaction
+	}
+	assert.Equal(t, salesmodel.TransactionStatusCompleted, statusOf(pendingPaid.ID).Status)
+	assert.Equal(t, salesmodel.TransactionStatusCompleted, statusOf(lostWebhook.ID).Status)
+	assert.Equal(t, "pi_perdido", statusOf(lostWebhook.ID).StripePaymentIntentID)
+	assert.Equal(t, salesmodel.TransactionStatusFailed, statusOf(expired.ID).Status)
+	assert.Equal(t, salesmodel.TransactionStatusPending, statusOf(recoverable.ID).Status)
+
+	var stored int64
+	require.NoError(t, db.Model(&salesmodel.ReconciliationIssue{}).Where("run_id = ?", run.ID).Count(&stored).Error)
+	assert.Equal(t, int64(6), stored)
+	emailService.AssertExpectations(t)
+	paymentConfirmation.AssertExpectations(t)
+}
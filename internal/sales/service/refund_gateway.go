@@ -0,0 +1,52 @@
+package service
+
+import (
+	"github.com/anglesson/simple-web-server/internal/config"
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/checkout/session"
+	"github.com/stripe/stripe-go/v76/refund"
+)
+
+// RefundGateway desfaz no Stripe a cobrança de uma compra recusada: estorna o pagamento recebido
+// ou encerra o checkout ainda aberto. accountID vazio usa a conta da plataforma.
+type RefundGateway interface {
+	RefundPaymentIntent(accountID, paymentIntentID string, metadata map[string]string) (string, error)
+	ExpireCheckoutSession(accountID, sessionID string) error
+}
+
+type StripeRefundGateway struct{}
+
+func NewStripeRefundGateway() RefundGateway {
+	if stripe.Key == "" {
+		stripe.Key = config.AppConfig.StripeSecretKey
+	}
+	return &StripeRefundGateway{}
+}
+
+func (g *StripeRefundGateway) RefundPaymentIntent(accountID, paymentIntentID string, metadata map[string]string) (string, error) {
+	params := &stripe.RefundParams{
+		PaymentIntent: stripe.String(paymentIntentID),
+		Reason:        stripe.String(string(stripe.RefundReasonFraudulent)),
+	}
+	for key, value := range metadata {
+		params.AddMetadata(key, value)
+	}
+	if accountID != "" {
+		params.SetStripeAccount(accountID)
+	}
+
+	r, err := refund.New(params)
+	if err != nil {
+		return "", err
+	}
+	return r.ID, nil
+}
+
+func (g *StripeRefundGateway) ExpireCheckoutSession(accountID, sessionID string) error {
+	params := &stripe.CheckoutSessionExpireParams{}
+	if accountID != "" {
+		params.SetStripeAccount(accountID)
+	}
+	_, err := session.Expire(sessionID, params)
+	return err
+}
@@ -3,6 +3,8 @@ package service
 import (
 	"fmt"
 	"log"
+	"log/slog"
+	"strings"
 
 	"github.com/anglesson/simple-web-server/internal/config"
 	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
@@ -14,6 +16,7 @@ import (
 type ResendDownloadLinkServiceInterface interface {
 	ResendDownloadLinkByTransactionID(transactionID uint) error
 	ResendDownloadLinkByPurchaseID(purchaseID uint, newEmail string) error
+	ResendAccessToBuyer(cpf, email string) error
 }
 
 // ResendDownloadLinkService gerencia o reenvio de links de download
@@ -157,3 +160,37 @@ func (s *ResendDownloadLinkService) ResendDownloadLinkByPurchaseID(purchaseID ui
 	log.Printf("✅ Reenvio de link processado com sucesso para purchaseID=%d", purchaseID)
 	return nil
 }
+
+// ResendAccessToBuyer reenvia o acesso das compras pagas do comprador que informou CPF e email.
+// O link vai sempre para o email cadastrado na compra, nunca para um endereço novo, e nenhuma
+// resposta indica se o CPF existe: sem compras correspondentes, nada é enviado e não há erro.
+func (s *ResendDownloadLinkService) ResendAccessToBuyer(cpf, email string) error {
+	cpf = onlyDigits(cpf)
+	email = strings.TrimSpace(email)
+	if cpf == "" || email == "" {
+		return nil
+	}
+
+	purchases, err := s.purchaseRepo.FindDeliverableByBuyer(cpf, email)
+	if err != nil {
+		return err
+	}
+	if len(purchases) == 0 {
+		slog.Info("Reenvio de acesso solicitado sem compras correspondentes")
+		return nil
+	}
+
+	// Envio assíncrono para que o tempo de resposta não revele se o comprador existe
+	go s.emailService.SendLinkToDownload(purchases)
+	slog.Info("Reenvio de acesso solicitado pelo comprador", "clientID", purchases[0].ClientID, "purchases", len(purchases))
+	return nil
+}
+
+func onlyDigits(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, value)
+}
@@ -0,0 +1,107 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	"github.com/anglesson/simple-web-server/internal/mocks"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/database"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupAccessRecovery(t *testing.T) (salesvc.ResendDownloadLinkServiceInterface, *mocks.MockSalesEmailService) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&salesmodel.Client{},
+		&librarymodel.File{},
+		&librarymodel.Ebook{},
+		&salesmodel.Purchase{},
+	))
+	database.DB = db
+
+	client := &salesmodel.Client{Name: "Ana", CPF: "52998224725", Email: "Ana@Test.com", Phone: "11999999999"}
+	require.NoError(t, db.Create(client).Error)
+	ebook := &librarymodel.Ebook{Title: "Ebook", Value: money.FromCents(4000), Status: true}
+	require.NoError(t, db.Create(ebook).Error)
+
+	confirmed := salesmodel.NewPurchase(ebook.ID, client.ID, "hash-confirmed")
+	confirmed.PaymentStatus = salesmodel.PaymentStatusConfirmed
+	confirmed.Status = salesmodel.PurchaseStatusPaid
+	require.NoError(t, db.Create(confirmed).Error)
+	pending := salesmodel.NewPurchase(ebook.ID, client.ID, "hash-pending")
+	require.NoError(t, db.Create(pending).Error)
+	held := salesmodel.NewPurchase(ebook.ID, client.ID, "hash-held")
+	held.PaymentStatus = salesmodel.PaymentStatusConfirmed
+	held.Status = salesmodel.PurchaseStatusPaid
+	held.HeldForReview = true
+	require.NoError(t, db.Create(held).Error)
+	refunded := salesmodel.NewPurchase(ebook.ID, client.ID, "hash-refunded")
+	refunded.PaymentStatus = salesmodel.PaymentStatusConfirmed
+	refunded.Status = salesmodel.PurchaseStatusRefunded
+	require.NoError(t, db.Create(refunded).Error)
+
+	emailService := new(mocks.MockSalesEmailService)
+	service := salesvc.NewResendDownloadLinkService(salesrepo.NewTransactionRepository(db), salesrepo.NewPurchaseRepository(), emailService)
+	return service, emailService
+}
+
+func TestResendAccessToBuyer_SendsConfirmedPurchasesToEmailOnFile(t *testing.T) {
+	service, emailService := setupAccessRecovery(t)
+
+	sent := make(chan []*salesmodel.Purchase, 1)
+	emailService.On("SendLinkToDownload", mock.Anything).Run(func(args mock.Arguments) {
+		sent <- args.Get(0).([]*salesmodel.Purchase)
+	}).Return().Once()
+
+	err := service.ResendAccessToBuyer("529.982.247-25", " ana@test.com ")
+	require.NoError(t, err)
+
+	select {
+	case purchases := <-sent:
+		require.Len(t, purchases, 1)
+		assert.Equal(t, "hash-confirmed", purchases[0].HashID)
+		assert.Equal(t, "Ana@Test.com", purchases[0].Client.Email)
+	case <-time.After(time.Second):
+		t.Fatal("o link de download não foi reenviado")
+	}
+}
+
+func TestResendAccessToBuyer_EmailMismatchSendsNothing(t *testing.T) {
+	service, emailService := setupAccessRecovery(t)
+
+	err := service.ResendAccessToBuyer("52998224725", "outro@test.com")
+
+	require.NoError(t, err)
+	emailService.AssertNotCalled(t, "SendLinkToDownload", mock.Anything)
+}
+
+func TestResendAccessToBuyer_UnknownCPFSendsNothing(t *testing.T) {
+	service, emailService := setupAccessRecovery(t)
+
+	err := service.ResendAccessToBuyer("12345678909", "ana@test.com")
+
+	require.NoError(t, err)
+	emailService.AssertNotCalled(t, "SendLinkToDownload", mock.Anything)
+}
+
+func TestResendAccessToBuyer_RefundedPurchaseIsNotResent(t *testing.T) {
+	service, emailService := setupAccessRecovery(t)
+	require.NoError(t, database.DB.Model(&salesmodel.Purchase{}).Where("hash_id = ?", "hash-confirmed").
+		Update("status", salesmodel.PurchaseStatusRefunded).Error)
+
+	err := service.ResendAccessToBuyer("52998224725", "ana@test.com")
+
+	require.NoError(t, err)
+	emailService.AssertNotCalled(t, "SendLinkToDownload", mock.Anything)
+}
@@ -0,0 +1,78 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	"github.com/anglesson/simple-web-server/internal/config"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestTransferDueSplits_RetriesFailedTransfersWithBackoff verifica que o repasse recusado logo após a venda
+// (saldo ainda indisponível) é tentado de novo pelo job quando a espera termina, até o limite de tentativas
+func TestTransferDueSplits_RetriesFailedTransfersWithBackoff(t *testing.T) {
+	previous := config.AppConfig.SplitTransferMaxAttempts
+	config.AppConfig.SplitTransferMaxAttempts = 2
+	defer func() { config.AppConfig.SplitTransferMaxAttempts = previous }()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&accountmodel.Creator{}, &salesmodel.Client{}, &librarymodel.File{}, &librarymodel.Ebook{},
+		&salesmodel.Purchase{}, &salesmodel.Transaction{}, &salesmodel.TransactionSplit{}))
+
+	owner := &accountmodel.Creator{Name: "Maria", Email: "maria@test.com"}
+	coAuthor := &accountmodel.Creator{Name: "Joana", Email: "joana@test.com", StripeConnectAccountID: "acct_coautora"}
+	require.NoError(t, db.Create(owner).Error)
+	require.NoError(t, db.Create(coAuthor).Error)
+	ebook := &librarymodel.Ebook{Title: "Ebook", Value: money.FromCents(5000), Status: true, CreatorID: owner.ID}
+	require.NoError(t, db.Create(ebook).Error)
+	client := salesmodel.NewClient("Ana", "52998224725", "", "ana@test.com", "")
+	require.NoError(t, db.Create(client).Error)
+	purchase := salesmodel.NewPurchase(ebook.ID, client.ID, "hash-split")
+	purchase.Status = salesmodel.PurchaseStatusPaid
+	require.NoError(t, db.Create(purchase).Error)
+	transaction := salesmodel.NewTransaction(purchase.ID, owner.ID, salesmodel.SplitTypePercentage)
+	transaction.AddSplit(coAuthor.ID, 0.3)
+	transaction.CalculateSplit(money.FromCents(5000))
+	transaction.Status = salesmodel.TransactionStatusCompleted
+	require.NoError(t, db.Create(transaction).Error)
+
+	gateway := new(MockTransferGateway)
+	gateway.On("CreateTransfer", "acct_coautora", mock.Anything, mock.Anything, mock.Anything).
+		Return("", assert.AnError).Once()
+	gateway.On("CreateTransfer", "acct_coautora", mock.Anything, mock.Anything, mock.Anything).
+		Return("tr_retry", nil).Once()
+	service := salesvc.NewTransactionService(salesrepo.NewTransactionRepository(db), nil, nil, nil, nil, gateway)
+
+	// Primeira tentativa no webhook: o Stripe recusa e o repasse fica agendado para 1 hora depois
+	require.Error(t, service.TransferSplits(purchase.ID))
+	var split salesmodel.TransactionSplit
+	require.NoError(t, db.First(&split).Error)
+	assert.Equal(t, salesmodel.TransactionSplitStatusFailed, split.Status)
+	assert.Equal(t, 1, split.Attempts)
+	require.NotNil(t, split.NextAttemptAt)
+
+	transferred, err := service.TransferDueSplits(time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, transferred, "a espera ainda não terminou")
+
+	transferred, err = service.TransferDueSplits(split.NextAttemptAt.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, transferred)
+	var retried salesmodel.TransactionSplit
+	require.NoError(t, db.First(&retried, split.ID).Error)
+	assert.True(t, retried.IsTransferred())
+	assert.Equal(t, "tr_retry", retried.StripeTransferID)
+	assert.Nil(t, retried.NextAttemptAt)
+	gateway.AssertExpectations(t)
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	accountsvc "github.com/anglesson/simple-web-server/internal/account/service"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// statementRowsPerPage limita as linhas da tabela em cada página do PDF (A4 paisagem)
+const statementRowsPerPage = 20
+
+// StatementService monta o extrato mensal do criador para a contabilidade (carnê-leão)
+type StatementService interface {
+	Generate(creatorID uint, month time.Time) (*salesmodel.MonthlyStatement, error)
+	RenderPDF(creator *accountmodel.Creator, statement *salesmodel.MonthlyStatement) ([]byte, error)
+	SendMonthlyStatements(now time.Time) (int, error)
+}
+
+type statementServiceImpl struct {
+	statementRepo  salesrepo.StatementRepository
+	creatorService accountsvc.CreatorService
+	emailService   IEmailService
+}
+
+func NewStatementService(statementRepo salesrepo.StatementRepository, creatorService accountsvc.CreatorService, emailService IEmailService) StatementService {
+	return &statementServiceImpl{
+		statementRepo:  statementRepo,
+		creatorService: creatorService,
+		emailService:   emailService,
+	}
+}
+
+func (s *statementServiceImpl) Generate(creatorID uint, month time.Time) (*salesmodel.MonthlyStatement, error) {
+	from := salesmodel.StatementMonth(month)
+	to := from.AddDate(0, 1, 0)
+
+	sales, err := s.statementRepo.SalesByEbook(creatorID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao somar as vendas do mês: %v", err)
+	}
+
+	received, err := s.statementRepo.SplitsReceivedByEbook(creatorID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao somar os repasses recebidos no mês: %v", err)
+	}
+
+	refunds, err := s.statementRepo.RefundsByEbook(creatorID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao somar os reembolsos do mês: %v", err)
+	}
+
+	return salesmodel.NewMonthlyStatement(creatorID, from, append(sales, received...), refunds), nil
+}
+
+// RenderPDF gera o extrato em PDF no mesmo formato do recibo, com a tabela quebrada em páginas
+func (s *statementServiceImpl) RenderPDF(creator *accountmodel.Creator, statement *salesmodel.MonthlyStatement) ([]byte, error) {
+	layout, err := json.Marshal(statementLayout(creator, statement))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao montar o extrato: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := api.Create(nil, bytes.NewReader(layout), &out, nil); err != nil {
+		return nil, fmt.Errorf("erro ao gerar o PDF do extrato: %v", err)
+	}
+	return out.Bytes(), nil
+}
+
+// SendMonthlyStatements envia o extrato do mês anterior aos criadores com movimento.
+// Cada envio fica registrado, então rodar o job várias vezes no mês não repete emails.
+func (s *statementServiceImpl) SendMonthlyStatements(now time.Time) (int, error) {
+	month := salesmodel.StatementMonth(now).AddDate(0, -1, 0)
+	creatorIDs, err := s.statementRepo.FindCreatorIDsWithActivity(month, month.AddDate(0, 1, 0))
+	if err != nil {
+		return 0, fmt.Errorf("erro ao buscar criadores com movimento: %v", err)
+	}
+
+	sent := 0
+	for _, creatorID := range creatorIDs {
+		delivered, err := s.statementRepo.IsDelivered(creatorID, month)
+		if err != nil || delivered {
+			continue
+		}
+
+		if err := s.sendStatement(creatorID, month); err != nil {
+			slog.Error("Erro ao enviar extrato mensal", "creatorID", creatorID, "month", month.Format("2006-01"), "error", err)
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+func (s *statementServiceImpl) sendStatement(creatorID uint, month time.Time) error {
+	creator, err := s.creatorService.FindByID(creatorID)
+	if err != nil {
+		return err
+	}
+
+	statement, err := s.Generate(creatorID, month)
+	if err != nil {
+		return err
+	}
+
+	pdf, err := s.RenderPDF(creator, statement)
+	if err != nil {
+		return err
+	}
+
+	// O registro vem antes do envio: numa falha do SMTP o criador ainda baixa o extrato no painel
+	if err := s.statementRepo.CreateDelivery(&salesmodel.MonthlyStatementDelivery{CreatorID: creatorID, Month: month}); err != nil {
+		return err
+	}
+
+	s.emailService.SendMonthlyStatement(creator, statement, pdf)
+	return nil
+}
+
+// StartMonthlyStatementJob verifica periodicamente se há extratos do mês anterior a enviar
+func StartMonthlyStatementJob(statementService StatementService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for now := range ticker.C {
+			sent, err := statementService.SendMonthlyStatements(now)
+			if err != nil {
+				slog.Error("Erro ao enviar extratos mensais", "error", err)
+				continue
+			}
+			if sent > 0 {
+				slog.Info("Extratos mensais enviados", "total", sent)
+			}
+		}
+	}()
+}
+
+// statementLayout descreve as páginas do extrato no formato JSON de criação do pdfcpu
+func statementLayout(creator *accountmodel.Creator, statement *salesmodel.MonthlyStatement) map[string]any {
+	rows := [][]string{}
+	for _, line := range statement.Lines {
+		rows = append(rows, statementRow(pdfText(line.EbookTitle), line))
+	}
+	for _, total := range statement.Totals {
+		rows = append(rows, statementRow("Total ("+total.Currency+")", total))
+	}
+	if len(rows) == 0 {
+		rows = append(rows, []string{"Sem vendas ou reembolsos no mês", "", "", "", "", "", "", ""})
+	}
+
+	sellerName, sellerDocument := receiptSeller(creator)
+	pages := map[string]any{}
+	for page := 0; page*statementRowsPerPage < len(rows); page++ {
+		end := min((page+1)*statementRowsPerPage, len(rows))
+		chunk := rows[page*statementRowsPerPage : end]
+
+		pages[strconv.Itoa(page+1)] = map[string]any{
+			"content": map[string]any{
+				"text": []any{
+					receiptText("Extrato mensal - "+statement.GetMonthLabel(), 0, 20, "$title"),
+					receiptText(sellerName+" - "+sellerDocument, 0, 45, "$body"),
+				},
+				"table": []any{
+					map[string]any{
+						"pos":        []int{0, 75 + 18*(len(chunk)+1)},
+						"rows":       len(chunk),
+						"cols":       8,
+						"width":      760,
+						"lheight":    18,
+						"grid":       true,
+						"colWidths":  []int{28, 6, 11, 11, 11, 11, 11, 11},
+						"colAnchors": []string{"Left", "Center", "Right", "Right", "Right", "Right", "Right", "Right"},
+						"font":       map[string]any{"name": "$small"},
+						"header": map[string]any{
+							"values":     []string{"E-book", "Vendas", "Bruto", "Taxa Stripe", "Taxa plataforma", "Repasses", "Reembolsos", "Líquido"},
+							"colAnchors": []string{"Left", "Center", "Right", "Right", "Right", "Right", "Right", "Right"},
+							"font":       map[string]any{"name": "$label"},
+						},
+						"values": chunk,
+					},
+				},
+			},
+		}
+	}
+
+	return map[string]any{
+		"paper":  "A4L",
+		"origin": "UpperLeft",
+		"margin": map[string]any{"width": 40},
+		"fonts": map[string]any{
+			"title": map[string]any{"name": "Helvetica-Bold", "size": 16},
+			"label": map[string]any{"name": "Helvetica-Bold", "size": 8},
+			"body":  map[string]any{"name": "Helvetica", "size": 10},
+			"small": map[string]any{"name": "Helvetica", "size": 8},
+		},
+		"footer": map[string]any{
+			"font":   map[string]any{"name": "Helvetica", "size": 7},
+			"left":   "Líquido = bruto - taxas - repasses a coautores e afiliados - reembolsos do mês",
+			"right":  "Página %p de %P",
+			"height": 20,
+		},
+		"pages": pages,
+	}
+}
+
+func statementRow(label string, line *salesmodel.StatementLine) []string {
+	return []string{
+		label,
+		strconv.FormatInt(line.Sales, 10),
+		line.GetFormattedGross(),
+		line.GetFormattedProcessingFee(),
+		line.GetFormattedPlatformFee(),
+		line.GetFormattedSplits(),
+		line.GetFormattedRefunds(),
+		line.GetFormattedNet(),
+	}
+}
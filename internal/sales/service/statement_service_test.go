@@ -0,0 +1,197 @@
+package service_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	"github.com/anglesson/simple-web-server/internal/mocks"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type statementFixture struct {
+	db           *gorm.DB
+	service      salesvc.StatementService
+	creators     *mocks.MockCreatorService
+	emailService *mocks.MockSalesEmailService
+	creator      *accountmodel.Creator
+	ebook        *librarymodel.Ebook
+}
+
+func setupStatementTest(t *testing.T) *statementFixture {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&accountmodel.Creator{}, &librarymodel.Ebook{}, &salesmodel.Purchase{},
+		&salesmodel.Transaction{}, &salesmodel.TransactionSplit{}, &salesmodel.PurchaseStatusHistory{}, &salesmodel.MonthlyStatementDelivery{}))
+
+	creator := &accountmodel.Creator{Name: "Maria Autora", Email: "maria@test.com", CPF: "12345678909"}
+	require.NoError(t, db.Create(creator).Error)
+	ebook := &librarymodel.Ebook{Title: "Guia Prático", CreatorID: creator.ID}
+	require.NoError(t, db.Create(ebook).Error)
+
+	creators := new(mocks.MockCreatorService)
+	emailService := new(mocks.MockSalesEmailService)
+	return &statementFixture{
+		db:           db,
+		service:      salesvc.NewStatementService(salesrepo.NewStatementRepository(db), creators, emailService),
+		creators:     creators,
+		emailService: emailService,
+		creator:      creator,
+		ebook:        ebook,
+	}
+}
+
+// createSale grava uma compra com a transação concluída em paidAt
+func (f *statementFixture) createSale(t *testing.T, paidAt time.Time, total, processing, platform, creatorAmount int64) *salesmodel.Purchase {
+	t.Helper()
+	purchase := &salesmodel.Purchase{EbookID: f.ebook.ID, HashID: paidAt.Format(time.RFC3339)}
+	require.NoError(t, f.db.Create(purchase).Error)
+	require.NoError(t, f.db.Create(&salesmodel.Transaction{
+		PurchaseID:          purchase.ID,
+		CreatorID:           f.creator.ID,
+		Status:              salesmodel.TransactionStatusCompleted,
+		Currency:            money.BRL,
+		TotalAmount:         total,
+		StripeProcessingFee: processing,
+		PlatformAmount:      platform,
+		CreatorAmount:       creatorAmount,
+		ProcessedAt:         &paidAt,
+	}).Error)
+	return purchase
+}
+
+func TestStatementService_GenerateAggregatesSalesAndRefunds(t *testing.T) {
+	f := setupStatementTest(t)
+	march := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	f.createSale(t, march.AddDate(0, 0, 4), 10000, 400, 500, 9100)
+	refunded := f.createSale(t, march.AddDate(0, 0, 9), 10000, 400, 500, 8100) // 1000 de repasse a coautor
+	f.createSale(t, march.AddDate(0, 1, 2), 10000, 400, 500, 9100)             // abril, fora do extrato
+	require.NoError(t, f.db.Create(&salesmodel.PurchaseStatusHistory{
+		PurchaseID: refunded.ID,
+		FromStatus: salesmodel.PurchaseStatusPaid,
+		ToStatus:   salesmodel.PurchaseStatusRefunded,
+		Model:      gorm.Model{CreatedAt: march.AddDate(0, 0, 20)},
+	}).Error)
+
+	statement, err := f.service.Generate(f.creator.ID, march.AddDate(0, 0, 15))
+	require.NoError(t, err)
+
+	require.Len(t, statement.Lines, 1)
+	line := statement.Lines[0]
+	assert.Equal(t, "Guia Prático", line.EbookTitle)
+	assert.Equal(t, int64(2), line.Sales)
+	assert.Equal(t, int64(1), line.Refunds)
+	assert.Equal(t, int64(20000), line.Gross)
+	assert.Equal(t, int64(800), line.ProcessingFee)
+	assert.Equal(t, int64(1000), line.PlatformFee)
+	assert.Equal(t, int64(1000), line.Splits)
+	assert.Equal(t, int64(10000), line.RefundAmount)
+	assert.Equal(t, int64(17200-10000), line.Net)
+
+	require.Len(t, statement.Totals, 1)
+	assert.Equal(t, line.Net, statement.Totals[0].Net)
+	assert.Equal(t, "março de 2025", statement.GetMonthLabel())
+}
+
+func TestStatementService_SendMonthlyStatementsOncePerMonth(t *testing.T) {
+	f := setupStatementTest(t)
+	f.createSale(t, time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC), 10000, 400, 500, 9100)
+
+	f.creators.On("FindByID", f.creator.ID).Return(f.creator, nil).Once()
+	f.emailService.On("SendMonthlyStatement", f.creator,
+		mock.MatchedBy(func(s *salesmodel.MonthlyStatement) bool { return s.GetMonthParam() == "2025-03" }),
+		mock.MatchedBy(func(pdf []byte) bool { return bytes.HasPrefix(pdf, []byte("%PDF")) }),
+	).Return().Once()
+
+	firstOfApril := time.Date(2025, 4, 1, 6, 0, 0, 0, time.UTC)
+	sent, err := f.service.SendMonthlyStatements(firstOfApril)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	sent, err = f.service.SendMonthlyStatements(firstOfApril.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	f.creators.AssertExpectations(t)
+	f.emailService.AssertExpectations(t)
+}
+
+func TestStatementService_RenderPDFPaginatesLongStatements(t *testing.T) {
+	f := setupStatementTest(t)
+	var sales []*salesmodel.StatementLine
+	for i := 0; i < 45; i++ {
+		sales = append(sales, &salesmodel.StatementLine{EbookID: uint(i + 1), EbookTitle: "Ebook 100%", Currency: money.BRL, Sales: 1, Gross: 1000, Net: 900})
+	}
+	statement := salesmodel.NewMonthlyStatement(f.creator.ID, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), sales, nil)
+
+	pdf, err := f.service.RenderPDF(f.creator, statement)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF")))
+}
+
+func TestStatementService_IncludesSplitsReceivedAsCoAuthorAndAffiliate(t *testing.T) {
+	f := setupStatementTest(t)
+	march := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	recipient := &accountmodel.Creator{Name: "Joana Coautora", Email: "joana@test.com"}
+	require.NoError(t, f.db.Create(recipient).Error)
+
+	addSplit := func(purchase *salesmodel.Purchase, kind salesmodel.TransactionSplitKind, amount int64, status salesmodel.TransactionSplitStatus) {
+		var transaction salesmodel.Transaction
+		require.NoError(t, f.db.Where("purchase_id = ?", purchase.ID).First(&transaction).Error)
+		require.NoError(t, f.db.Create(&salesmodel.TransactionSplit{
+			TransactionID: transaction.ID, Kind: kind, CreatorID: recipient.ID,
+			Amount: amount, Currency: money.BRL, Status: status,
+		}).Error)
+	}
+	addSplit(f.createSale(t, march.AddDate(0, 0, 3), 10000, 400, 500, 6300), salesmodel.TransactionSplitKindCoAuthor, 2800, salesmodel.TransactionSplitStatusTransferred)
+	addSplit(f.createSale(t, march.AddDate(0, 0, 5), 10000, 400, 500, 6300), salesmodel.TransactionSplitKindCoAuthor, 2800, salesmodel.TransactionSplitStatusPending)
+	addSplit(f.createSale(t, march.AddDate(0, 0, 7), 10000, 400, 500, 8200), salesmodel.TransactionSplitKindAffiliate, 900, salesmodel.TransactionSplitStatusTransferred)
+	addSplit(f.createSale(t, march.AddDate(0, 0, 9), 10000, 0, 0, 10000), salesmodel.TransactionSplitKindCoAuthor, 0, salesmodel.TransactionSplitStatusCanceled)
+	addSplit(f.createSale(t, march.AddDate(0, 1, 2), 10000, 400, 500, 6300), salesmodel.TransactionSplitKindCoAuthor, 2800, salesmodel.TransactionSplitStatusPending) // abril
+
+	statement, err := f.service.Generate(recipient.ID, march)
+	require.NoError(t, err)
+
+	require.Len(t, statement.Lines, 2)
+	coAuthor, affiliate := statement.Lines[0], statement.Lines[1]
+	if coAuthor.SplitKind != salesmodel.TransactionSplitKindCoAuthor {
+		coAuthor, affiliate = affiliate, coAuthor
+	}
+	assert.Equal(t, "Guia Prático (coautoria)", coAuthor.EbookTitle)
+	assert.Equal(t, int64(2), coAuthor.Sales)
+	assert.Equal(t, int64(5600), coAuthor.Gross)
+	assert.Equal(t, int64(5600), coAuthor.Net)
+	assert.Zero(t, coAuthor.PlatformFee)
+	assert.Equal(t, "Guia Prático (comissão de afiliado)", affiliate.EbookTitle)
+	assert.Equal(t, int64(900), affiliate.Net)
+	require.Len(t, statement.Totals, 1)
+	assert.Equal(t, int64(6500), statement.Totals[0].Net)
+
+	// O dono do ebook continua só com as próprias vendas
+	owner, err := f.service.Generate(f.creator.ID, march)
+	require.NoError(t, err)
+	require.Len(t, owner.Lines, 1)
+	assert.Equal(t, "Guia Prático", owner.Lines[0].EbookTitle)
+
+	// Quem só recebeu repasses também recebe o extrato do mês
+	f.creators.On("FindByID", f.creator.ID).Return(f.creator, nil).Once()
+	f.creators.On("FindByID", recipient.ID).Return(recipient, nil).Once()
+	f.emailService.On("SendMonthlyStatement", recipient, mock.Anything, mock.Anything).Return().Once()
+	f.emailService.On("SendMonthlyStatement", f.creator, mock.Anything, mock.Anything).Return().Once()
+	sent, err := f.service.SendMonthlyStatements(time.Date(2025, 4, 1, 6, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	f.emailService.AssertExpectations(t)
+}
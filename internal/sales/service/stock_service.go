@@ -0,0 +1,97 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+)
+
+var ErrEbookSoldOut = errors.New("ebook esgotado")
+var ErrSalesNotStarted = errors.New("as vendas deste ebook ainda não começaram")
+var ErrSalesEnded = errors.New("as vendas deste ebook foram encerradas")
+
+// StockService aplica a janela de vendas e o estoque das ofertas limitadas no checkout
+type StockService interface {
+	ReserveForPurchase(ebook *librarymodel.Ebook, purchase *salesmodel.Purchase) error
+	ReleaseForPurchase(purchaseID uint) error
+}
+
+type stockServiceImpl struct {
+	stockRepo       salesrepo.StockRepository
+	purchaseService PurchaseService
+}
+
+func NewStockService(stockRepo salesrepo.StockRepository, purchaseService PurchaseService) StockService {
+	return &stockServiceImpl{
+		stockRepo:       stockRepo,
+		purchaseService: purchaseService,
+	}
+}
+
+// AvailabilityError converte a disponibilidade do ebook no erro correspondente
+func AvailabilityError(availability librarymodel.EbookAvailability) error {
+	switch availability {
+	case librarymodel.EbookSoldOut:
+		return ErrEbookSoldOut
+	case librarymodel.EbookSalesNotStarted:
+		return ErrSalesNotStarted
+	case librarymodel.EbookSalesEnded:
+		return ErrSalesEnded
+	default:
+		return nil
+	}
+}
+
+// ReserveForPurchase verifica a janela de vendas e reserva uma unidade do estoque para a compra.
+// Ebooks sem limite de estoque não geram reserva.
+func (s *stockServiceImpl) ReserveForPurchase(ebook *librarymodel.Ebook, purchase *salesmodel.Purchase) error {
+	if purchase.IsPaymentConfirmed() {
+		return nil
+	}
+
+	availability := ebook.Availability(time.Now())
+	if availability == librarymodel.EbookSalesNotStarted || availability == librarymodel.EbookSalesEnded {
+		return AvailabilityError(availability)
+	}
+
+	if !ebook.HasStockLimit() || purchase.StockReserved {
+		return nil
+	}
+
+	reserved, err := s.stockRepo.Reserve(purchase.ID, ebook.ID)
+	if err != nil {
+		return fmt.Errorf("erro ao reservar estoque: %v", err)
+	}
+	if !reserved {
+		return ErrEbookSoldOut
+	}
+
+	purchase.StockReserved = true
+	return nil
+}
+
+// ReleaseForPurchase devolve ao estoque a unidade de uma compra cujo checkout expirou sem pagamento
+func (s *stockServiceImpl) ReleaseForPurchase(purchaseID uint) error {
+	purchase, err := s.purchaseService.GetPurchaseByID(purchaseID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar compra: %v", err)
+	}
+
+	if !purchase.StockReserved {
+		return nil
+	}
+
+	released, err := s.stockRepo.Release(purchase.ID, purchase.EbookID)
+	if err != nil {
+		return fmt.Errorf("erro ao liberar estoque: %v", err)
+	}
+	if released {
+		slog.Info("Unidade de estoque liberada", "purchaseID", purchase.ID, "ebookID", purchase.EbookID)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package service_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	"github.com/anglesson/simple-web-server/internal/mocks"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/database"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStockServiceForTest() salesvc.StockService {
+	purchaseService := salesvc.NewPurchaseService(salesrepo.NewPurchaseRepository(), new(mocks.MockSalesEmailService))
+	return salesvc.NewStockService(salesrepo.NewStockRepository(database.DB), purchaseService)
+}
+
+func createLimitedEbookWithPurchases(t *testing.T, stockLimit int, purchases int) (*librarymodel.Ebook, []*salesmodel.Purchase) {
+	t.Helper()
+	ebook := &librarymodel.Ebook{Title: "Ebook Limitado", Value: money.FromCents(5000), Status: true, CreatorID: 7, StockLimit: stockLimit}
+	require.NoError(t, database.DB.Create(ebook).Error)
+
+	var created []*salesmodel.Purchase
+	for i := 0; i < purchases; i++ {
+		client := &salesmodel.Client{Name: "Cliente", CPF: fmt.Sprintf("0000000000%d", i), Email: "cliente@test.com", Phone: "11999999999"}
+		require.NoError(t, database.DB.Create(client).Error)
+		purchase := salesmodel.NewPurchase(ebook.ID, client.ID, fmt.Sprintf("hash-stock-%d", i))
+		require.NoError(t, database.DB.Create(purchase).Error)
+		created = append(created, purchase)
+	}
+	return ebook, created
+}
+
+func reloadEbook(t *testing.T, id uint) *librarymodel.Ebook {
+	t.Helper()
+	var ebook librarymodel.Ebook
+	require.NoError(t, database.DB.First(&ebook, id).Error)
+	return &ebook
+}
+
+func TestStockService_ReserveForPurchase_RespectsLimit(t *testing.T) {
+	setupCheckoutRecoveryTestDB(t)
+	service := newStockServiceForTest()
+	ebook, purchases := createLimitedEbookWithPurchases(t, 2, 3)
+
+	assert.NoError(t, service.ReserveForPurchase(ebook, purchases[0]))
+	assert.NoError(t, service.ReserveForPurchase(ebook, purchases[1]))
+	assert.ErrorIs(t, service.ReserveForPurchase(ebook, purchases[2]), salesvc.ErrEbookSoldOut)
+
+	reloaded := reloadEbook(t, ebook.ID)
+	assert.Equal(t, 2, reloaded.StockUsed)
+	assert.True(t, reloaded.IsSoldOut())
+
+	var rejected salesmodel.Purchase
+	require.NoError(t, database.DB.First(&rejected, purchases[2].ID).Error)
+	assert.False(t, rejected.StockReserved)
+}
+
+func TestStockService_ReserveForPurchase_IsIdempotent(t *testing.T) {
+	setupCheckoutRecoveryTestDB(t)
+	service := newStockServiceForTest()
+	ebook, purchases := createLimitedEbookWithPurchases(t, 5, 1)
+
+	assert.NoError(t, service.ReserveForPurchase(ebook, purchases[0]))
+
+	// Mesmo com o objeto desatualizado, a compra não consome uma segunda unidade
+	stale := *purchases[0]
+	stale.StockReserved = false
+	assert.NoError(t, service.ReserveForPurchase(ebook, &stale))
+
+	assert.Equal(t, 1, reloadEbook(t, ebook.ID).StockUsed)
+}
+
+func TestStockService_ReleaseForPurchase(t *testing.T) {
+	setupCheckoutRecoveryTestDB(t)
+	service := newStockServiceForTest()
+	ebook, purchases := createLimitedEbookWithPurchases(t, 1, 2)
+
+	require.NoError(t, service.ReserveForPurchase(ebook, purchases[0]))
+	assert.ErrorIs(t, service.ReserveForPurchase(ebook, purchases[1]), salesvc.ErrEbookSoldOut)
+
+	require.NoError(t, service.ReleaseForPurchase(purchases[0].ID))
+	require.NoError(t, service.ReleaseForPurchase(purchases[0].ID))
+	assert.Equal(t, 0, reloadEbook(t, ebook.ID).StockUsed)
+
+	assert.NoError(t, service.ReserveForPurchase(ebook, purchases[1]))
+	assert.Equal(t, 1, reloadEbook(t, ebook.ID).StockUsed)
+}
+
+func TestStockService_ReleaseForPurchase_KeepsPaidUnits(t *testing.T) {
+	setupCheckoutRecoveryTestDB(t)
+	service := newStockServiceForTest()
+	ebook, purchases := createLimitedEbookWithPurchases(t, 1, 1)
+
+	require.NoError(t, service.ReserveForPurchase(ebook, purchases[0]))
+	require.NoError(t, database.DB.Model(&salesmodel.Purchase{}).Where("id = ?", purchases[0].ID).
+		Update("payment_status", salesmodel.PaymentStatusConfirmed).Error)
+
+	require.NoError(t, service.ReleaseForPurchase(purchases[0].ID))
+	assert.Equal(t, 1, reloadEbook(t, ebook.ID).StockUsed)
+}
+
+func TestStockService_ReserveForPurchase_SalesWindow(t *testing.T) {
+	setupCheckoutRecoveryTestDB(t)
+	service := newStockServiceForTest()
+	ebook, purchases := createLimitedEbookWithPurchases(t, 0, 1)
+
+	future := time.Now().Add(time.Hour)
+	ebook.SalesStartAt = &future
+	assert.ErrorIs(t, service.ReserveForPurchase(ebook, purchases[0]), salesvc.ErrSalesNotStarted)
+
+	past := time.Now().Add(-time.Hour)
+	ebook.SalesStartAt = nil
+	ebook.SalesEndAt = &past
+	assert.ErrorIs(t, service.ReserveForPurchase(ebook, purchases[0]), salesvc.ErrSalesEnded)
+
+	ebook.SalesEndAt = &future
+	assert.NoError(t, service.ReserveForPurchase(ebook, purchases[0]))
+	assert.False(t, purchases[0].StockReserved)
+}
@@ -0,0 +1,83 @@
+package service_test
+
+import (
+	"testing"
+
+	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestRepricePendingTransaction_CurrencyAndPriceChange verifica que o checkout refeito em outra moeda,
+// ou depois de o preço mudar, recalcula taxas e repasses da transação pendente da compra
+func TestRepricePendingTransaction_CurrencyAndPriceChange(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&accountmodel.Creator{}, &salesmodel.Client{}, &librarymodel.File{}, &librarymodel.Ebook{},
+		&salesmodel.Purchase{}, &salesmodel.Transaction{}, &salesmodel.TransactionSplit{}))
+
+	owner := &accountmodel.Creator{Name: "Maria", Email: "maria@test.com"}
+	coAuthor := &accountmodel.Creator{Name: "Joana", Email: "joana@test.com"}
+	require.NoError(t, db.Create(owner).Error)
+	require.NoError(t, db.Create(coAuthor).Error)
+	ebook := &librarymodel.Ebook{Title: "Ebook", Value: money.FromCents(5000), Status: true, CreatorID: owner.ID}
+	require.NoError(t, db.Create(ebook).Error)
+	client := salesmodel.NewClient("Ana", "52998224725", "", "ana@test.com", "")
+	require.NoError(t, db.Create(client).Error)
+	purchase := salesmodel.NewPurchase(ebook.ID, client.ID, "hash-reprice")
+	require.NoError(t, db.Create(purchase).Error)
+
+	plan := &salesmodel.FeePlan{Name: "Padrão", Percentage: 0.05, FixedFeeCents: 100}
+	transaction := salesmodel.NewTransaction(purchase.ID, owner.ID, salesmodel.SplitTypeFixedAmount)
+	transaction.ApplyFeePlan(plan)
+	transaction.AddSplit(coAuthor.ID, 0.3)
+	transaction.CalculateSplit(money.FromCents(5000))
+	require.NoError(t, db.Create(transaction).Error)
+
+	service := salesvc.NewTransactionService(salesrepo.NewTransactionRepository(db), nil, nil, nil, nil, nil)
+
+	usd := money.New(1000, money.USD)
+	assert.True(t, transaction.ChargesDifferently(usd))
+	repriced, err := service.RepricePendingTransaction(purchase.ID, usd, plan)
+	require.NoError(t, err)
+
+	expected := salesmodel.NewTransaction(purchase.ID, owner.ID, salesmodel.SplitTypeFixedAmount)
+	expected.ApplyFeePlan(plan)
+	expected.AddSplit(coAuthor.ID, 0.3)
+	expected.CalculateSplit(usd)
+
+	var stored salesmodel.Transaction
+	require.NoError(t, db.Preload("Splits").First(&stored, transaction.ID).Error)
+	assert.Equal(t, money.USD, stored.Currency)
+	assert.Equal(t, int64(1000), stored.TotalAmount)
+	assert.Equal(t, expected.PlatformAmount, stored.PlatformAmount)
+	assert.Equal(t, expected.CreatorAmount, stored.CreatorAmount)
+	require.Len(t, stored.Splits, 1)
+	assert.Equal(t, expected.Splits[0].Amount, stored.Splits[0].Amount)
+	assert.Equal(t, money.USD, stored.Splits[0].Currency)
+	assert.Equal(t, expected.SplitsAmount(), repriced.SplitsAmount())
+	assert.False(t, repriced.ChargesDifferently(usd))
+
+	// De volta ao real com preço novo: a parcela fixa volta a ser a do plano
+	brl := money.FromCents(7000)
+	_, err = service.RepricePendingTransaction(purchase.ID, brl, plan)
+	require.NoError(t, err)
+	var back salesmodel.Transaction
+	require.NoError(t, db.Preload("Splits").First(&back, transaction.ID).Error)
+	assert.Equal(t, money.BRL, back.Currency)
+	assert.Equal(t, int64(100), back.PlatformFixedFee)
+	assert.Equal(t, brl.Mul(0.05).Amount+100, back.PlatformAmount)
+	assert.Equal(t, money.BRL, back.Splits[0].Currency)
+
+	// Transação concluída não é recalculada
+	require.NoError(t, db.Model(&back).Update("status", salesmodel.TransactionStatusCompleted).Error)
+	_, err = service.RepricePendingTransaction(purchase.ID, usd, plan)
+	assert.Error(t, err)
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	accountsvc "github.com/anglesson/simple-web-server/internal/account/service"
@@ -10,12 +11,13 @@ import (
 	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
 	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
 	subscriptionservice "github.com/anglesson/simple-web-server/internal/subscription/service"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/stripe/stripe-go/v76"
 	"github.com/stripe/stripe-go/v76/paymentintent"
 )
 
 type TransactionService interface {
-	CreateTransaction(purchase *salesmodel.Purchase, totalAmount int64) (*salesmodel.Transaction, error)
+	CreateTransaction(purchase *salesmodel.Purchase, totalAmount money.Money) (*salesmodel.Transaction, error)
 	ProcessPaymentWithSplit(transaction *salesmodel.Transaction) error
 	GetTransactionByID(id uint) (*salesmodel.Transaction, error)
 	GetTransactionByPublicID(publicID string) (*salesmodel.Transaction, error)
@@ -24,6 +26,12 @@ type TransactionService interface {
 	CreateDirectTransaction(transaction *salesmodel.Transaction) error
 	FindTransactionByPurchaseID(purchaseID uint) (*salesmodel.Transaction, error)
 	UpdateTransactionToCompleted(purchaseID uint, stripePaymentIntentID string) error
+	RecordPaymentMethod(purchaseID uint, paymentMethod string) error
+	UpdatePendingTransactionAmount(purchaseID uint, totalAmount money.Money) error
+	RepricePendingTransaction(purchaseID uint, totalAmount money.Money, plan *salesmodel.FeePlan) (*salesmodel.Transaction, error)
+	TransferSplits(purchaseID uint) error
+	TransferDueSplits(now time.Time) (int, error)
+	GetSplitsByCreatorID(creatorID uint, kind salesmodel.TransactionSplitKind, page, limit int) ([]*salesmodel.TransactionSplit, int64, error)
 }
 
 type transactionServiceImpl struct {
@@ -31,6 +39,8 @@ type transactionServiceImpl struct {
 	purchaseService PurchaseService
 	creatorService  accountsvc.CreatorService
 	stripeService   *subscriptionservice.StripeService
+	feePlanService  FeePlanService
+	transferGateway TransferGateway
 }
 
 func NewTransactionService(
@@ -38,6 +48,8 @@ func NewTransactionService(
 	purchaseService PurchaseService,
 	creatorService accountsvc.CreatorService,
 	stripeService *subscriptionservice.StripeService,
+	feePlanService FeePlanService,
+	transferGateway TransferGateway,
 ) TransactionService {
 	if stripe.Key == "" {
 		stripe.Key = config.AppConfig.StripeSecretKey
@@ -48,15 +60,17 @@ func NewTransactionService(
 		purchaseService: purchaseService,
 		creatorService:  creatorService,
 		stripeService:   stripeService,
+		feePlanService:  feePlanService,
+		transferGateway: transferGateway,
 	}
 }
 
-func (s *transactionServiceImpl) CreateTransaction(purchase *salesmodel.Purchase, totalAmount int64) (*salesmodel.Transaction, error) {
+func (s *transactionServiceImpl) CreateTransaction(purchase *salesmodel.Purchase, totalAmount money.Money) (*salesmodel.Transaction, error) {
 	if purchase == nil || purchase.ID == 0 {
 		return nil, fmt.Errorf("compra inválida")
 	}
 
-	if totalAmount <= 0 {
+	if !totalAmount.IsPositive() {
 		return nil, fmt.Errorf("valor de transação inválido")
 	}
 
@@ -76,17 +90,18 @@ func (s *transactionServiceImpl) CreateTransaction(purchase *salesmodel.Purchase
 	}
 
 	transaction := salesmodel.NewTransaction(purchase.ID, creator.ID, salesmodel.SplitTypePercentage)
-	transaction.PlatformPercentage = config.Business.PlatformFeePercentage
-	transaction.PlatformFixedFee = config.Business.PlatformFixedFeeCents
+	transaction.ApplyFeePlan(s.feePlanService.EffectivePlan(creator, totalAmount))
 	transaction.CalculateSplit(totalAmount)
 
 	slog.Info("Split de pagamento calculado",
 		"transactionID", transaction.ID,
 		"totalAmount", transaction.TotalAmount,
+		"currency", transaction.Currency,
 		"platformAmount", transaction.PlatformAmount,
 		"creatorAmount", transaction.CreatorAmount,
 		"processingFee", transaction.StripeProcessingFee,
-		"splitType", transaction.SplitType)
+		"splitType", transaction.SplitType,
+		"feePlan", transaction.FeePlanName)
 
 	err = s.transactionRepo.CreateTransaction(transaction)
 	if err != nil {
@@ -137,6 +152,7 @@ func (s *transactionServiceImpl) ProcessPaymentWithSplit(transaction *salesmodel
 		"transactionID", transaction.ID,
 		"purchaseID", transaction.PurchaseID,
 		"totalAmount", transaction.TotalAmount,
+		"currency", transaction.Currency,
 		"platformAmount", transaction.PlatformAmount,
 		"creatorAmount", transaction.CreatorAmount,
 		"creatorConnectAccount", creator.StripeConnectAccountID,
@@ -185,6 +201,7 @@ func (s *transactionServiceImpl) ProcessPaymentWithSplit(transaction *salesmodel
 
 	slog.Debug("Pagamento com split configurado",
 		"totalAmount", transaction.TotalAmount,
+		"currency", transaction.Currency,
 		"platformAmount", transaction.PlatformAmount,
 		"creatorAmount", transaction.CreatorAmount)
 
@@ -204,6 +221,7 @@ func (s *transactionServiceImpl) ProcessPaymentWithSplit(transaction *salesmodel
 		"transactionID", transaction.ID,
 		"paymentIntentID", maskStripeID(pi.ID),
 		"totalAmount", transaction.TotalAmount,
+		"currency", transaction.Currency,
 		"platformAmount", transaction.PlatformAmount,
 		"creatorAmount", transaction.CreatorAmount,
 		"creatorID", creator.ID,
@@ -269,6 +287,204 @@ func (s *transactionServiceImpl) UpdateTransactionToCompleted(purchaseID uint, s
 	return nil
 }
 
+// RecordPaymentMethod guarda a forma de pagamento usada na cobrança, exibida no recibo do comprador
+func (s *transactionServiceImpl) RecordPaymentMethod(purchaseID uint, paymentMethod string) error {
+	transaction, err := s.transactionRepo.FindByPurchaseID(purchaseID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar transação: %v", err)
+	}
+
+	if paymentMethod == "" || transaction.PaymentMethod == paymentMethod {
+		return nil
+	}
+
+	transaction.PaymentMethod = paymentMethod
+	return s.transactionRepo.UpdateTransaction(transaction)
+}
+
+// UpdatePendingTransactionAmount recalcula o split de uma transação pendente com um novo valor total
+// (ex.: quando um cupom de recuperação de checkout é aplicado)
+func (s *transactionServiceImpl) UpdatePendingTransactionAmount(purchaseID uint, totalAmount money.Money) error {
+	if !totalAmount.IsPositive() {
+		return fmt.Errorf("valor de transação inválido")
+	}
+
+	transaction, err := s.transactionRepo.FindByPurchaseID(purchaseID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar transação: %v", err)
+	}
+
+	if transaction.Status != salesmodel.TransactionStatusPending {
+		return fmt.Errorf("transação não está pendente")
+	}
+
+	transaction.CalculateSplit(totalAmount)
+	return s.transactionRepo.UpdateTransaction(transaction)
+}
+
+// RepricePendingTransaction recalcula taxas e repasses da transação pendente quando o comprador volta
+// ao checkout em outra moeda ou depois de o preço mudar. O plano é reaplicado antes do cálculo porque a
+// parcela fixa de outra moeda substitui a do plano.
+func (s *transactionServiceImpl) RepricePendingTransaction(purchaseID uint, totalAmount money.Money, plan *salesmodel.FeePlan) (*salesmodel.Transaction, error) {
+	if !totalAmount.IsPositive() {
+		return nil, fmt.Errorf("valor de transação inválido")
+	}
+
+	transaction, err := s.transactionRepo.FindByPurchaseID(purchaseID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar transação: %v", err)
+	}
+
+	if transaction.Status != salesmodel.TransactionStatusPending {
+		return nil, fmt.Errorf("transação não está pendente")
+	}
+
+	transaction.ApplyFeePlan(plan)
+	transaction.CalculateSplit(totalAmount)
+	if err := s.transactionRepo.UpdateTransaction(transaction); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// TransferSplits transfere a participação de cada coautor e a comissão do afiliado de uma venda concluída.
+// Repasses já transferidos são ignorados, então a chamada pode ser repetida para os que falharam.
+// Comissões de afiliados com carência ficam agendadas para TransferDueSplits.
+func (s *transactionServiceImpl) TransferSplits(purchaseID uint) error {
+	transaction, err := s.transactionRepo.FindByPurchaseID(purchaseID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar transação: %v", err)
+	}
+
+	if transaction.Status != salesmodel.TransactionStatusCompleted {
+		return fmt.Errorf("transação não está concluída")
+	}
+
+	now := time.Now()
+	var failed int
+	for i := range transaction.Splits {
+		split := &transaction.Splits[i]
+		if split.IsTransferred() || split.Status == salesmodel.TransactionSplitStatusCanceled || split.Amount <= 0 {
+			continue
+		}
+
+		if split.IsAffiliate() && split.AvailableAt == nil && config.AppConfig.AffiliateHoldPeriod > 0 {
+			availableAt := now.Add(config.AppConfig.AffiliateHoldPeriod)
+			split.AvailableAt = &availableAt
+			if err := s.transactionRepo.UpdateSplit(split); err != nil {
+				slog.Error("Erro ao agendar comissão do afiliado", "splitID", split.ID, "error", err)
+			}
+			continue
+		}
+		if split.IsOnHold(now) {
+			continue
+		}
+
+		if !s.transferSplit(split, transaction.ID, purchaseID) {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d repasse(s) falharam", failed)
+	}
+	return nil
+}
+
+// TransferDueSplits transfere os repasses cuja carência terminou e tenta de novo os recusados cuja
+// espera acabou. Vendas reembolsadas ou contestadas nesse período têm o repasse cancelado.
+func (s *transactionServiceImpl) TransferDueSplits(now time.Time) (int, error) {
+	splits, err := s.transactionRepo.FindDueSplits(now)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao buscar repasses: %v", err)
+	}
+
+	var transferred int
+	for _, split := range splits {
+		purchase := split.Transaction.Purchase
+		switch purchase.Status {
+		case salesmodel.PurchaseStatusRefunded, salesmodel.PurchaseStatusChargeback:
+			split.MarkCanceled(fmt.Sprintf("Venda %s durante a carência", strings.ToLower(purchase.Status.Label())))
+			if err := s.transactionRepo.UpdateSplit(split); err != nil {
+				slog.Error("Erro ao cancelar repasse", "splitID", split.ID, "error", err)
+			}
+			continue
+		case salesmodel.PurchaseStatusPaid:
+		default:
+			// Compra bloqueada ou em revisão: aguarda a decisão antes de transferir
+			continue
+		}
+
+		if s.transferSplit(split, split.TransactionID, purchase.ID) {
+			transferred++
+		}
+	}
+	return transferred, nil
+}
+
+// transferSplit cria a transferência no Stripe e grava o resultado do repasse.
+// Recusas ficam agendadas para o job tentar de novo até SplitTransferMaxAttempts.
+func (s *transactionServiceImpl) transferSplit(split *salesmodel.TransactionSplit, transactionID, purchaseID uint) bool {
+	now := time.Now()
+	maxAttempts := config.AppConfig.SplitTransferMaxAttempts
+	if split.Creator.StripeConnectAccountID == "" {
+		split.MarkFailed("destinatário sem conta Stripe Connect", now, maxAttempts)
+	} else {
+		transferID, err := s.transferGateway.CreateTransfer(
+			split.Creator.StripeConnectAccountID,
+			split.GetAmount(),
+			fmt.Sprintf("purchase_%d", purchaseID),
+			map[string]string{
+				"transaction_id": fmt.Sprintf("%d", transactionID),
+				"split_id":       fmt.Sprintf("%d", split.ID),
+				"split_kind":     string(split.Kind),
+				"purchase_id":    fmt.Sprintf("%d", purchaseID),
+			})
+		if err != nil {
+			split.MarkFailed(fmt.Sprintf("Erro ao transferir: %v", err), now, maxAttempts)
+		} else {
+			split.MarkTransferred(transferID, now)
+		}
+	}
+
+	if !split.IsTransferred() {
+		slog.Error("Falha no repasse",
+			"transactionID", transactionID,
+			"splitID", split.ID,
+			"kind", split.Kind,
+			"creatorID", split.CreatorID,
+			"attempts", split.Attempts,
+			"nextAttemptAt", split.NextAttemptAt,
+			"error", split.ErrorMessage)
+	}
+
+	if err := s.transactionRepo.UpdateSplit(split); err != nil {
+		slog.Error("Erro ao atualizar repasse", "splitID", split.ID, "error", err)
+	}
+	return split.IsTransferred()
+}
+
+// StartSplitTransferJob transfere periodicamente as comissões que saíram da carência e os repasses recusados
+func StartSplitTransferJob(transactionService TransactionService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for now := range ticker.C {
+			transferred, err := transactionService.TransferDueSplits(now)
+			if err != nil {
+				slog.Error("Erro ao transferir repasses agendados", "error", err)
+				continue
+			}
+			if transferred > 0 {
+				slog.Info("Repasses agendados transferidos", "total", transferred)
+			}
+		}
+	}()
+}
+
+func (s *transactionServiceImpl) GetSplitsByCreatorID(creatorID uint, kind salesmodel.TransactionSplitKind, page, limit int) ([]*salesmodel.TransactionSplit, int64, error) {
+	return s.transactionRepo.FindSplitsByCreatorID(creatorID, kind, page, limit)
+}
+
 func maskStripeID(id string) string {
 	if len(id) <= 8 {
 		return "****"
@@ -2,16 +2,21 @@ package service_test
 
 import (
 	"testing"
+	"time"
 
 	accountmodel "github.com/anglesson/simple-web-server/internal/account/model"
+	"github.com/anglesson/simple-web-server/internal/config"
 	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	"github.com/anglesson/simple-web-server/internal/mocks"
 	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
 	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
 	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
 
 	subscriptionservice "github.com/anglesson/simple-web-server/internal/subscription/service"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
 )
 
@@ -69,6 +74,27 @@ func (m *MockTransactionRepository) UpdateTransactionStatus(id uint, status sale
 	return args.Error(0)
 }
 
+func (m *MockTransactionRepository) UpdateSplit(split *salesmodel.TransactionSplit) error {
+	args := m.Called(split)
+	return args.Error(0)
+}
+
+func (m *MockTransactionRepository) FindSplitsByCreatorID(creatorID uint, kind salesmodel.TransactionSplitKind, page, limit int) ([]*salesmodel.TransactionSplit, int64, error) {
+	args := m.Called(creatorID, kind, page, limit)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]*salesmodel.TransactionSplit), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockTransactionRepository) FindDueSplits(now time.Time) ([]*salesmodel.TransactionSplit, error) {
+	args := m.Called(now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*salesmodel.TransactionSplit), args.Error(1)
+}
+
 // MockCreatorService é um mock do serviço de criadores
 type MockCreatorService struct {
 	mock.Mock
@@ -154,6 +180,7 @@ func TestCreateTransaction(t *testing.T) {
 
 	// Mock do StripeService
 	mockStripeService := &subscriptionservice.StripeService{}
+	mockFeePlanService := new(mocks.MockFeePlanService)
 
 	// Serviço a ser testado
 	transactionService := salesvc.NewTransactionService(
@@ -161,6 +188,8 @@ func TestCreateTransaction(t *testing.T) {
 		purchaseService,
 		mockCreatorService,
 		mockStripeService,
+		mockFeePlanService,
+		nil,
 	)
 
 	// Configurar objetos de teste
@@ -175,7 +204,7 @@ func TestCreateTransaction(t *testing.T) {
 	ebook := &librarymodel.Ebook{
 		Model:     gorm.Model{ID: 1},
 		Title:     "Test Ebook",
-		Value:     100.00,
+		Value:     money.FromCents(10000),
 		CreatorID: 1,
 	}
 
@@ -188,10 +217,16 @@ func TestCreateTransaction(t *testing.T) {
 
 	// Configurar expectativas dos mocks
 	mockCreatorService.On("FindByID", uint(1)).Return(creator, nil)
+	mockFeePlanService.On("EffectivePlan", creator, money.FromCents(10000)).Return(&salesmodel.FeePlan{
+		Model:         gorm.Model{ID: 7},
+		Name:          "Assinante",
+		Percentage:    0.015,
+		FixedFeeCents: 50,
+	})
 	mockTransactionRepo.On("CreateTransaction", mock.AnythingOfType("*model.Transaction")).Return(nil)
 
 	// Executar teste
-	transaction, err := transactionService.CreateTransaction(purchase, 10000)
+	transaction, err := transactionService.CreateTransaction(purchase, money.FromCents(10000))
 
 	// Verificar resultados
 	assert.NoError(t, err)
@@ -200,6 +235,10 @@ func TestCreateTransaction(t *testing.T) {
 	assert.Equal(t, uint(1), transaction.CreatorID)
 	assert.Equal(t, salesmodel.SplitTypePercentage, transaction.SplitType)
 	assert.Equal(t, salesmodel.TransactionStatusPending, transaction.Status)
+	assert.Equal(t, 0.015, transaction.PlatformPercentage)
+	assert.Equal(t, int64(150+50), transaction.PlatformAmount)
+	assert.Equal(t, "Assinante", transaction.FeePlanName)
+	assert.Equal(t, uint(7), *transaction.FeePlanID)
 
 	// Verificar chamadas de mock
 	mockCreatorService.AssertExpectations(t)
@@ -218,6 +257,7 @@ func TestCreateTransactionFailureCreatorNotFound(t *testing.T) {
 
 	// Mock do StripeService
 	mockStripeService := &subscriptionservice.StripeService{}
+	mockFeePlanService := new(mocks.MockFeePlanService)
 
 	// Serviço a ser testado
 	transactionService := salesvc.NewTransactionService(
@@ -225,13 +265,15 @@ func TestCreateTransactionFailureCreatorNotFound(t *testing.T) {
 		purchaseService,
 		mockCreatorService,
 		mockStripeService,
+		mockFeePlanService,
+		nil,
 	)
 
 	// Configurar objetos de teste
 	ebook := &librarymodel.Ebook{
 		Model:     gorm.Model{ID: 1},
 		Title:     "Test Ebook",
-		Value:     100.00,
+		Value:     money.FromCents(10000),
 		CreatorID: 1,
 	}
 
@@ -246,7 +288,7 @@ func TestCreateTransactionFailureCreatorNotFound(t *testing.T) {
 	mockCreatorService.On("FindByID", uint(1)).Return(nil, assert.AnError)
 
 	// Executar teste
-	transaction, err := transactionService.CreateTransaction(purchase, 10000)
+	transaction, err := transactionService.CreateTransaction(purchase, money.FromCents(10000))
 
 	// Verificar resultados
 	assert.Error(t, err)
@@ -270,6 +312,7 @@ func TestCreateTransactionFailureCreatorNotConnected(t *testing.T) {
 
 	// Mock do StripeService
 	mockStripeService := &subscriptionservice.StripeService{}
+	mockFeePlanService := new(mocks.MockFeePlanService)
 
 	// Serviço a ser testado
 	transactionService := salesvc.NewTransactionService(
@@ -277,6 +320,8 @@ func TestCreateTransactionFailureCreatorNotConnected(t *testing.T) {
 		purchaseService,
 		mockCreatorService,
 		mockStripeService,
+		mockFeePlanService,
+		nil,
 	)
 
 	// Configurar objetos de teste - criador sem conta Stripe Connect
@@ -291,7 +336,7 @@ func TestCreateTransactionFailureCreatorNotConnected(t *testing.T) {
 	ebook := &librarymodel.Ebook{
 		Model:     gorm.Model{ID: 1},
 		Title:     "Test Ebook",
-		Value:     100.00,
+		Value:     money.FromCents(10000),
 		CreatorID: 1,
 	}
 
@@ -306,7 +351,7 @@ func TestCreateTransactionFailureCreatorNotConnected(t *testing.T) {
 	mockCreatorService.On("FindByID", uint(1)).Return(creator, nil)
 
 	// Executar teste
-	transaction, err := transactionService.CreateTransaction(purchase, 10000)
+	transaction, err := transactionService.CreateTransaction(purchase, money.FromCents(10000))
 
 	// Verificar resultados
 	assert.Error(t, err)
@@ -317,3 +362,109 @@ func TestCreateTransactionFailureCreatorNotConnected(t *testing.T) {
 	mockCreatorService.AssertExpectations(t)
 	mockTransactionRepo.AssertNotCalled(t, "CreateTransaction")
 }
+
+type MockTransferGateway struct {
+	mock.Mock
+}
+
+func (m *MockTransferGateway) CreateTransfer(destination string, amount money.Money, transferGroup string, metadata map[string]string) (string, error) {
+	args := m.Called(destination, amount, transferGroup, metadata)
+	return args.String(0), args.Error(1)
+}
+
+func TestTransferSplits_TransfersPendingAndReportsFailures(t *testing.T) {
+	mockTransactionRepo := new(MockTransactionRepository)
+	mockGateway := new(MockTransferGateway)
+	transactionService := salesvc.NewTransactionService(mockTransactionRepo, nil, nil, nil, nil, mockGateway)
+
+	transaction := &salesmodel.Transaction{
+		Model:  gorm.Model{ID: 10},
+		Status: salesmodel.TransactionStatusCompleted,
+		Splits: []salesmodel.TransactionSplit{
+			{Model: gorm.Model{ID: 1}, CreatorID: 7, Amount: 2000, Currency: money.BRL, Creator: accountmodel.Creator{StripeConnectAccountID: "acct_ok"}},
+			{Model: gorm.Model{ID: 2}, CreatorID: 8, Amount: 500, Currency: money.BRL, Creator: accountmodel.Creator{StripeConnectAccountID: "acct_fail"}},
+			{Model: gorm.Model{ID: 3}, CreatorID: 9, Amount: 700, Currency: money.BRL, Status: salesmodel.TransactionSplitStatusTransferred},
+		},
+	}
+
+	mockTransactionRepo.On("FindByPurchaseID", uint(4)).Return(transaction, nil)
+	mockTransactionRepo.On("UpdateSplit", mock.Anything).Return(nil)
+	mockGateway.On("CreateTransfer", "acct_ok", money.New(2000, money.BRL), "purchase_4", mock.Anything).Return("tr_123", nil)
+	mockGateway.On("CreateTransfer", "acct_fail", money.New(500, money.BRL), "purchase_4", mock.Anything).Return("", assert.AnError)
+
+	err := transactionService.TransferSplits(4)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "1 repasse(s)")
+	assert.True(t, transaction.Splits[0].IsTransferred())
+	assert.Equal(t, "tr_123", transaction.Splits[0].StripeTransferID)
+	assert.Equal(t, salesmodel.TransactionSplitStatusFailed, transaction.Splits[1].Status)
+	mockTransactionRepo.AssertNumberOfCalls(t, "UpdateSplit", 2)
+	mockGateway.AssertExpectations(t)
+}
+
+func TestTransferSplits_AffiliateCommissionWaitsForHoldPeriod(t *testing.T) {
+	previous := config.AppConfig.AffiliateHoldPeriod
+	config.AppConfig.AffiliateHoldPeriod = 7 * 24 * time.Hour
+	defer func() { config.AppConfig.AffiliateHoldPeriod = previous }()
+
+	mockTransactionRepo := new(MockTransactionRepository)
+	mockGateway := new(MockTransferGateway)
+	transactionService := salesvc.NewTransactionService(mockTransactionRepo, nil, nil, nil, nil, mockGateway)
+
+	transaction := &salesmodel.Transaction{Model: gorm.Model{ID: 10}, Status: salesmodel.TransactionStatusCompleted}
+	transaction.AddAffiliateSplit(8, 3, 0.2)
+	transaction.Splits[0].Amount = 1500
+	transaction.Splits[0].Currency = money.BRL
+	transaction.Splits[0].Creator = accountmodel.Creator{StripeConnectAccountID: "acct_aff"}
+
+	mockTransactionRepo.On("FindByPurchaseID", uint(4)).Return(transaction, nil)
+	mockTransactionRepo.On("UpdateSplit", mock.Anything).Return(nil)
+
+	require.NoError(t, transactionService.TransferSplits(4))
+
+	split := transaction.Splits[0]
+	require.NotNil(t, split.AvailableAt)
+	assert.True(t, split.IsOnHold(time.Now()))
+	assert.Equal(t, salesmodel.TransactionSplitStatusPending, split.Status)
+	mockGateway.AssertNotCalled(t, "CreateTransfer", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTransferDueSplits_CancelsRefundedAndTransfersPaid(t *testing.T) {
+	mockTransactionRepo := new(MockTransactionRepository)
+	mockGateway := new(MockTransferGateway)
+	transactionService := salesvc.NewTransactionService(mockTransactionRepo, nil, nil, nil, nil, mockGateway)
+
+	newDueSplit := func(id uint, status salesmodel.PurchaseStatus) *salesmodel.TransactionSplit {
+		return &salesmodel.TransactionSplit{
+			Model:         gorm.Model{ID: id},
+			Kind:          salesmodel.TransactionSplitKindAffiliate,
+			TransactionID: id,
+			Amount:        1000,
+			Currency:      money.BRL,
+			Status:        salesmodel.TransactionSplitStatusPending,
+			Creator:       accountmodel.Creator{StripeConnectAccountID: "acct_aff"},
+			Transaction: &salesmodel.Transaction{Purchase: salesmodel.Purchase{
+				Model:  gorm.Model{ID: id + 100},
+				Status: status,
+			}},
+		}
+	}
+	paid := newDueSplit(1, salesmodel.PurchaseStatusPaid)
+	refunded := newDueSplit(2, salesmodel.PurchaseStatusRefunded)
+	blocked := newDueSplit(3, salesmodel.PurchaseStatusBlocked)
+
+	now := time.Now()
+	mockTransactionRepo.On("FindDueSplits", now).Return([]*salesmodel.TransactionSplit{paid, refunded, blocked}, nil)
+	mockTransactionRepo.On("UpdateSplit", mock.Anything).Return(nil)
+	mockGateway.On("CreateTransfer", "acct_aff", money.New(1000, money.BRL), "purchase_101", mock.Anything).Return("tr_aff", nil).Once()
+
+	transferred, err := transactionService.TransferDueSplits(now)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, transferred)
+	assert.True(t, paid.IsTransferred())
+	assert.Equal(t, salesmodel.TransactionSplitStatusCanceled, refunded.Status)
+	assert.Equal(t, salesmodel.TransactionSplitStatusPending, blocked.Status)
+	mockGateway.AssertExpectations(t)
+}
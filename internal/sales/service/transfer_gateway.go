@@ -0,0 +1,38 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/transfer"
+)
+
+// TransferGateway cria transferências da conta principal da Docffy para contas Stripe Connect
+type TransferGateway interface {
+	CreateTransfer(destination string, amount money.Money, transferGroup string, metadata map[string]string) (string, error)
+}
+
+type StripeTransferGateway struct{}
+
+func NewStripeTransferGateway() TransferGateway {
+	return &StripeTransferGateway{}
+}
+
+func (g *StripeTransferGateway) CreateTransfer(destination string, amount money.Money, transferGroup string, metadata map[string]string) (string, error) {
+	params := &stripe.TransferParams{
+		Amount:        stripe.Int64(amount.Amount),
+		Currency:      stripe.String(strings.ToLower(amount.CurrencyOrDefault())),
+		Destination:   stripe.String(destination),
+		TransferGroup: stripe.String(transferGroup),
+	}
+	for key, value := range metadata {
+		params.AddMetadata(key, value)
+	}
+
+	tr, err := transfer.New(params)
+	if err != nil {
+		return "", err
+	}
+	return tr.ID, nil
+}
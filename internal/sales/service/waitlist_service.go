@@ -0,0 +1,130 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	"gorm.io/gorm"
+)
+
+var ErrWaitlistDisabled = errors.New("a lista de espera não está ativa para este ebook")
+var ErrWaitlistEbookUnavailable = errors.New("publique o ebook ou reabra as vendas antes de avisar a lista de espera")
+
+type WaitlistService interface {
+	Join(ebook *librarymodel.Ebook, name, email string) (bool, error)
+	NotifyAll(ebook *librarymodel.Ebook) (int, error)
+	RegisterClick(token string) (*salesmodel.WaitlistEntry, error)
+	AttributePurchase(token string, ebookID, purchaseID uint) error
+	MarkConverted(purchaseID uint) error
+	ListByEbookID(ebookID uint) ([]*salesmodel.WaitlistEntry, error)
+	GetStatsByEbookID(ebookID uint) (*salesmodel.WaitlistStats, error)
+}
+
+type waitlistServiceImpl struct {
+	waitlistRepo salesrepo.WaitlistRepository
+	emailService IEmailService
+}
+
+func NewWaitlistService(waitlistRepo salesrepo.WaitlistRepository, emailService IEmailService) WaitlistService {
+	return &waitlistServiceImpl{
+		waitlistRepo: waitlistRepo,
+		emailService: emailService,
+	}
+}
+
+// Join inscreve o visitante na lista de espera do ebook. Retorna false se o email já estava inscrito.
+func (s *waitlistServiceImpl) Join(ebook *librarymodel.Ebook, name, email string) (bool, error) {
+	if !ebook.WaitlistEnabled {
+		return false, ErrWaitlistDisabled
+	}
+
+	entry := salesmodel.NewWaitlistEntry(ebook.ID, ebook.CreatorID, name, email)
+	created, err := s.waitlistRepo.Create(entry)
+	if err != nil {
+		return false, fmt.Errorf("erro ao entrar na lista de espera: %v", err)
+	}
+	return created, nil
+}
+
+// NotifyAll avisa todos os inscritos que ainda não compraram que o ebook está disponível.
+// Cada email leva o link rastreado do inscrito para atribuir a venda à lista de espera.
+func (s *waitlistServiceImpl) NotifyAll(ebook *librarymodel.Ebook) (int, error) {
+	if !ebook.Status || !ebook.IsAvailableForSale() {
+		return 0, ErrWaitlistEbookUnavailable
+	}
+
+	entries, err := s.waitlistRepo.FindNotConvertedByEbookID(ebook.ID)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao buscar lista de espera: %v", err)
+	}
+
+	notified := 0
+	now := time.Now()
+	for _, entry := range entries {
+		s.emailService.SendWaitlistNotification(entry, ebook)
+		entry.MarkNotified(now)
+		if err := s.waitlistRepo.Update(entry); err != nil {
+			slog.Error("Erro ao atualizar inscrito da lista de espera", "entryID", entry.ID, "error", err)
+			continue
+		}
+		notified++
+	}
+
+	return notified, nil
+}
+
+// RegisterClick registra o clique no link rastreado do email de aviso
+func (s *waitlistServiceImpl) RegisterClick(token string) (*salesmodel.WaitlistEntry, error) {
+	if token == "" {
+		return nil, fmt.Errorf("link da lista de espera inválido")
+	}
+
+	entry, err := s.waitlistRepo.FindByToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.ClickedAt == nil {
+		entry.MarkClicked(time.Now())
+		if err := s.waitlistRepo.Update(entry); err != nil {
+			slog.Error("Erro ao registrar clique da lista de espera", "entryID", entry.ID, "error", err)
+		}
+	}
+	return entry, nil
+}
+
+// AttributePurchase vincula a compra iniciada pelo link rastreado ao inscrito da lista de espera
+func (s *waitlistServiceImpl) AttributePurchase(token string, ebookID, purchaseID uint) error {
+	entry, err := s.waitlistRepo.FindByToken(token)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if entry.EbookID != ebookID || entry.IsConverted() {
+		return nil
+	}
+
+	entry.PurchaseID = &purchaseID
+	return s.waitlistRepo.Update(entry)
+}
+
+// MarkConverted marca como convertido o inscrito vinculado à compra paga, se existir
+func (s *waitlistServiceImpl) MarkConverted(purchaseID uint) error {
+	return s.waitlistRepo.MarkConvertedByPurchaseID(purchaseID, time.Now())
+}
+
+func (s *waitlistServiceImpl) ListByEbookID(ebookID uint) ([]*salesmodel.WaitlistEntry, error) {
+	return s.waitlistRepo.FindByEbookID(ebookID)
+}
+
+func (s *waitlistServiceImpl) GetStatsByEbookID(ebookID uint) (*salesmodel.WaitlistStats, error) {
+	return s.waitlistRepo.GetStatsByEbookID(ebookID)
+}
@@ -0,0 +1,120 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
+	"github.com/anglesson/simple-web-server/internal/mocks"
+	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
+	salesrepo "github.com/anglesson/simple-web-server/internal/sales/repository"
+	salesvc "github.com/anglesson/simple-web-server/internal/sales/service"
+	"github.com/anglesson/simple-web-server/pkg/database"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupWaitlistTestDB(t *testing.T) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&librarymodel.Ebook{}, &salesmodel.WaitlistEntry{}))
+	database.DB = db
+}
+
+func createWaitlistEbook(t *testing.T, status bool) *librarymodel.Ebook {
+	t.Helper()
+	ebook := &librarymodel.Ebook{Title: "Ebook em Breve", Value: money.FromCents(4000), Status: status, CreatorID: 3, WaitlistEnabled: true}
+	require.NoError(t, database.DB.Create(ebook).Error)
+	return ebook
+}
+
+func TestWaitlistService_Join(t *testing.T) {
+	setupWaitlistTestDB(t)
+	service := salesvc.NewWaitlistService(salesrepo.NewWaitlistRepository(database.DB), new(mocks.MockSalesEmailService))
+	ebook := createWaitlistEbook(t, false)
+
+	created, err := service.Join(ebook, "Ana", "Ana@Test.com ")
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	created, err = service.Join(ebook, "Ana", "ana@test.com")
+	require.NoError(t, err)
+	assert.False(t, created, "o mesmo email não deve entrar duas vezes na lista")
+
+	entries, err := service.ListByEbookID(ebook.ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "ana@test.com", entries[0].Email)
+	assert.NotEmpty(t, entries[0].Token)
+
+	ebook.WaitlistEnabled = false
+	_, err = service.Join(ebook, "Bia", "bia@test.com")
+	assert.ErrorIs(t, err, salesvc.ErrWaitlistDisabled)
+}
+
+func TestWaitlistService_NotifyAll(t *testing.T) {
+	setupWaitlistTestDB(t)
+	emailMock := new(mocks.MockSalesEmailService)
+	service := salesvc.NewWaitlistService(salesrepo.NewWaitlistRepository(database.DB), emailMock)
+	ebook := createWaitlistEbook(t, false)
+
+	_, err := service.Join(ebook, "Ana", "ana@test.com")
+	require.NoError(t, err)
+	_, err = service.Join(ebook, "Bia", "bia@test.com")
+	require.NoError(t, err)
+
+	_, err = service.NotifyAll(ebook)
+	assert.ErrorIs(t, err, salesvc.ErrWaitlistEbookUnavailable)
+
+	ebook.Status = true
+	emailMock.On("SendWaitlistNotification", mock.AnythingOfType("*model.WaitlistEntry"), ebook).Return().Twice()
+
+	notified, err := service.NotifyAll(ebook)
+	require.NoError(t, err)
+	assert.Equal(t, 2, notified)
+	emailMock.AssertExpectations(t)
+
+	stats, err := service.GetStatsByEbookID(ebook.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stats.Total)
+	assert.Equal(t, int64(2), stats.Notified)
+}
+
+func TestWaitlistService_TracksConversion(t *testing.T) {
+	setupWaitlistTestDB(t)
+	service := salesvc.NewWaitlistService(salesrepo.NewWaitlistRepository(database.DB), new(mocks.MockSalesEmailService))
+	ebook := createWaitlistEbook(t, true)
+
+	_, err := service.Join(ebook, "Ana", "ana@test.com")
+	require.NoError(t, err)
+	entries, err := service.ListByEbookID(ebook.ID)
+	require.NoError(t, err)
+	token := entries[0].Token
+
+	entry, err := service.RegisterClick(token)
+	require.NoError(t, err)
+	require.NotNil(t, entry.ClickedAt)
+
+	// Token de outro ebook não é atribuído
+	require.NoError(t, service.AttributePurchase(token, ebook.ID+1, 99))
+	require.NoError(t, service.AttributePurchase("inexistente", ebook.ID, 99))
+	require.NoError(t, service.AttributePurchase(token, ebook.ID, 55))
+	require.NoError(t, service.MarkConverted(55))
+
+	var reloaded salesmodel.WaitlistEntry
+	require.NoError(t, database.DB.First(&reloaded, entry.ID).Error)
+	require.NotNil(t, reloaded.PurchaseID)
+	assert.Equal(t, uint(55), *reloaded.PurchaseID)
+	assert.True(t, reloaded.IsConverted())
+	assert.WithinDuration(t, time.Now(), *reloaded.ConvertedAt, time.Minute)
+
+	stats, err := service.GetStatsByEbookID(ebook.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Clicked)
+	assert.Equal(t, int64(1), stats.Converted)
+}
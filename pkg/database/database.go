@@ -10,6 +10,8 @@ import (
 	librarymodel "github.com/anglesson/simple-web-server/internal/library/model"
 	salesmodel "github.com/anglesson/simple-web-server/internal/sales/model"
 	subscriptionmodel "github.com/anglesson/simple-web-server/internal/subscription/model"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/anglesson/simple-web-server/pkg/pii"
 	"github.com/anglesson/simple-web-server/pkg/utils"
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
@@ -20,9 +22,22 @@ var DB *gorm.DB
 var err error
 
 func Connect() {
+	configurePII()
 	connectWithPostgres()
 }
 
+// configurePII carrega as chaves usadas pelo serializer "pii" antes de qualquer leitura dos modelos
+func configurePII() {
+	keyring, err := pii.NewKeyring(config.AppConfig.PIIEncryptionKeys, config.AppConfig.PIIBlindIndexKey)
+	if err != nil {
+		log.Panicf("failed to load PII keys: %v", err)
+	}
+	if !keyring.Enabled() {
+		log.Printf("Aviso: PII_ENCRYPTION_KEYS não configurada, CPF, telefone e data de nascimento serão gravados em texto puro")
+	}
+	pii.Configure(keyring)
+}
+
 func connectWithPostgres() {
 	connectGormAndMigrate(postgres.Open(config.AppConfig.DatabaseURL))
 }
@@ -40,21 +55,58 @@ func connectGormAndMigrate(dialector gorm.Dialector) {
 }
 
 func migrate() {
+	// O status das compras é preenchido só na migração que cria a coluna
+	needsPurchaseStatus := !tableColumns(&salesmodel.Purchase{})["status"]
+
 	err := DB.AutoMigrate(
 		&authmodel.User{},
 		&subscriptionmodel.Subscription{},
 		&salesmodel.Client{},
 		&accountmodel.Creator{},
 		&librarymodel.Ebook{},
+		&librarymodel.EbookPrice{},
 		&salesmodel.Purchase{},
 		&deliverymodel.DownloadLog{},
-		&salesmodel.Transaction{})
+		&salesmodel.Transaction{},
+		&salesmodel.CheckoutRecovery{},
+		&salesmodel.WaitlistEntry{},
+		&salesmodel.CPFVerification{},
+		&salesmodel.CPFLookupUsage{},
+		&salesmodel.CheckoutAttempt{},
+		&salesmodel.PurchaseStatusHistory{},
+		&salesmodel.FeePlan{},
+		&salesmodel.CreatorFeePlan{},
+		&librarymodel.EbookCoAuthor{},
+		&salesmodel.TransactionSplit{},
+		&salesmodel.Affiliate{},
+		&salesmodel.MonthlyStatementDelivery{},
+		&salesmodel.Payout{},
+		&salesmodel.PayoutItem{},
+		&salesmodel.ReconciliationRun{},
+		&salesmodel.ReconciliationIssue{},
+		&salesmodel.ClientImport{},
+		&salesmodel.ClientImportError{},
+		&salesmodel.CreatorClient{},
+		&salesmodel.ClientTag{},
+		&salesmodel.ClientTagAssignment{},
+		&salesmodel.ClientNote{},
+		&salesmodel.ClientSegment{},
+		&salesmodel.Broadcast{},
+		&salesmodel.BroadcastDelivery{},
+		&salesmodel.EmailUnsubscribe{},
+		&salesmodel.PrivacyRequest{})
 
 	if err != nil {
 		log.Panic("failed to migrate database")
 	}
 
+	dropPlaintextPIIIndexes()
 	backfillPublicIDs()
+	if needsPurchaseStatus {
+		backfillPurchaseStatus()
+	}
+	migrateEbookPricesToCents()
+	seedDefaultFeePlan()
 }
 
 func backfillPublicIDs() {
@@ -80,6 +132,116 @@ func backfill[T any](prefix string) {
 	}
 }
 
+// backfillPurchaseStatus preenche o status das compras criadas antes da máquina de estados.
+// Bloqueios antigos eram feitos igualando o limite de downloads aos downloads já usados.
+// Roda uma única vez, logo após a coluna ser criada com o padrão pending: depois disso uma compra
+// paga que atingiu o limite de downloads continua paga.
+func backfillPurchaseStatus() {
+	var paid, blocked int64
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&salesmodel.Purchase{}).
+			Where("status = ? AND payment_status = ?", salesmodel.PurchaseStatusPending, salesmodel.PaymentStatusConfirmed).
+			Update("status", salesmodel.PurchaseStatusPaid)
+		if result.Error != nil {
+			return result.Error
+		}
+		paid = result.RowsAffected
+
+		result = tx.Model(&salesmodel.Purchase{}).
+			Where("status = ? AND download_limit >= 0 AND downloads_used >= download_limit", salesmodel.PurchaseStatusPaid).
+			Updates(map[string]interface{}{"status": salesmodel.PurchaseStatusBlocked, "download_limit": -1})
+		blocked = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		log.Printf("Failed to backfill purchase status: %v", err)
+		return
+	}
+	log.Printf("Backfilled purchase status: %d paid, %d blocked", paid, blocked)
+}
+
+// dropPlaintextPIIIndexes remove os índices sobre CPF e data de nascimento em texto puro: com a
+// criptografia cada gravação gera um valor diferente e as buscas passaram para os índices cegos
+func dropPlaintextPIIIndexes() {
+	indexes := []struct {
+		model interface{}
+		name  string
+	}{
+		{&salesmodel.Client{}, "idx_clients_cpf"},
+		{&salesmodel.CheckoutAttempt{}, "idx_checkout_attempts_cpf"},
+		{&salesmodel.CPFVerification{}, "idx_cpf_verification_lookup"},
+	}
+	for _, index := range indexes {
+		if !DB.Migrator().HasIndex(index.model, index.name) {
+			continue
+		}
+		if err := DB.Migrator().DropIndex(index.model, index.name); err != nil {
+			log.Printf("Failed to drop %s: %v", index.name, err)
+		}
+	}
+}
+
+// seedDefaultFeePlan cadastra o plano padrão com as taxas de config.Business na primeira execução
+func seedDefaultFeePlan() {
+	var count int64
+	DB.Model(&salesmodel.FeePlan{}).Where("kind = ?", salesmodel.FeePlanKindDefault).Count(&count)
+	if count > 0 {
+		return
+	}
+	if err := DB.Create(salesmodel.DefaultFeePlan()).Error; err != nil {
+		log.Printf("Failed to seed default fee plan: %v", err)
+		return
+	}
+	log.Printf("Seeded default fee plan")
+}
+
+// migrateEbookPricesToCents copia os preços antigos em float (colunas value e promotional_value)
+// para as colunas em centavos e remove as colunas antigas. ROUND evita que 19.99 vire 1998.
+func migrateEbookPricesToCents() {
+	columns := tableColumns(&librarymodel.Ebook{})
+	if !columns["value"] {
+		return
+	}
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec("UPDATE ebooks SET value_amount = COALESCE(ROUND(value * 100), 0), value_currency = ? WHERE value_amount IS NULL OR value_amount = 0", money.BRL)
+		if result.Error != nil {
+			return result.Error
+		}
+		log.Printf("Migrated %d ebook prices to cents", result.RowsAffected)
+
+		if columns["promotional_value"] {
+			if err := tx.Exec("UPDATE ebooks SET promotional_value_amount = COALESCE(ROUND(promotional_value * 100), 0), promotional_value_currency = ? WHERE promotional_value_amount IS NULL OR promotional_value_amount = 0", money.BRL).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("ALTER TABLE ebooks DROP COLUMN promotional_value").Error; err != nil {
+				return err
+			}
+		}
+		return tx.Exec("ALTER TABLE ebooks DROP COLUMN value").Error
+	})
+	if err != nil {
+		log.Printf("Failed to migrate ebook prices to cents: %v", err)
+	}
+}
+
+// tableColumns lista as colunas reais da tabela do modelo (vazio se ela ainda não existe);
+// HasColumn do SQLite casa "value" com "value_amount" e "status" com "payment_status"
+func tableColumns(model interface{}) map[string]bool {
+	columns := make(map[string]bool)
+	if !DB.Migrator().HasTable(model) {
+		return columns
+	}
+	columnTypes, err := DB.Migrator().ColumnTypes(model)
+	if err != nil {
+		return columns
+	}
+	for _, column := range columnTypes {
+		columns[column.Name()] = true
+	}
+	return columns
+}
+
 func Close() {
 	sqlDB, err := DB.DB()
 	if err != nil {
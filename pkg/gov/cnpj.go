@@ -0,0 +1,49 @@
+package gov
+
+// IsValidCNPJ confere o tamanho e os dígitos verificadores do CNPJ (apenas números).
+// Assim como no CPF, a validação local evita consultas pagas com documentos inválidos.
+func IsValidCNPJ(cnpj string) bool {
+	if len(cnpj) != 14 {
+		return false
+	}
+
+	allSame := true
+	for i := 0; i < 14; i++ {
+		if cnpj[i] < '0' || cnpj[i] > '9' {
+			return false
+		}
+		if cnpj[i] != cnpj[0] {
+			allSame = false
+		}
+	}
+	if allSame {
+		return false
+	}
+
+	return cnpjCheckDigit(cnpj[:12]) == int(cnpj[12]-'0') && cnpjCheckDigit(cnpj[:13]) == int(cnpj[13]-'0')
+}
+
+func cnpjCheckDigit(digits string) int {
+	sum := 0
+	weight := len(digits) - 7
+	for i := 0; i < len(digits); i++ {
+		sum += int(digits[i]-'0') * weight
+		weight--
+		if weight < 2 {
+			weight = 9
+		}
+	}
+	remainder := sum % 11
+	if remainder < 2 {
+		return 0
+	}
+	return 11 - remainder
+}
+
+// FormatCNPJ aplica a máscara 00.000.000/0000-00; valores fora do padrão voltam sem alteração
+func FormatCNPJ(cnpj string) string {
+	if len(cnpj) != 14 {
+		return cnpj
+	}
+	return cnpj[:2] + "." + cnpj[2:5] + "." + cnpj[5:8] + "/" + cnpj[8:12] + "-" + cnpj[12:]
+}
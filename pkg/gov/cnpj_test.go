@@ -0,0 +1,35 @@
+package gov
+
+import "testing"
+
+func TestIsValidCNPJ(t *testing.T) {
+	tests := []struct {
+		cnpj     string
+		expected bool
+	}{
+		{"11222333000181", true},
+		{"11444777000161", true},
+		{"11222333000182", false},
+		{"00000000000000", false},
+		{"1122233300018", false},
+		{"11.222.333/0001-81", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cnpj, func(t *testing.T) {
+			if got := IsValidCNPJ(tt.cnpj); got != tt.expected {
+				t.Errorf("IsValidCNPJ(%q) = %v, want %v", tt.cnpj, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatCNPJ(t *testing.T) {
+	if got := FormatCNPJ("11222333000181"); got != "11.222.333/0001-81" {
+		t.Errorf("FormatCNPJ = %q", got)
+	}
+	if got := FormatCNPJ(""); got != "" {
+		t.Errorf("FormatCNPJ de valor vazio = %q", got)
+	}
+}
@@ -0,0 +1,46 @@
+package gov
+
+// IsValidCPF confere o tamanho e os dígitos verificadores do CPF (apenas números).
+// A validação é local e deve ser feita antes de qualquer consulta paga à Receita Federal.
+func IsValidCPF(cpf string) bool {
+	if len(cpf) != 11 {
+		return false
+	}
+
+	allSame := true
+	for i := 0; i < 11; i++ {
+		if cpf[i] < '0' || cpf[i] > '9' {
+			return false
+		}
+		if cpf[i] != cpf[0] {
+			allSame = false
+		}
+	}
+	if allSame {
+		return false
+	}
+
+	return cpfCheckDigit(cpf[:9]) == int(cpf[9]-'0') && cpfCheckDigit(cpf[:10]) == int(cpf[10]-'0')
+}
+
+func cpfCheckDigit(digits string) int {
+	sum := 0
+	factor := len(digits) + 1
+	for i := 0; i < len(digits); i++ {
+		sum += int(digits[i]-'0') * factor
+		factor--
+	}
+	remainder := sum % 11
+	if remainder < 2 {
+		return 0
+	}
+	return 11 - remainder
+}
+
+// FormatCPF aplica a máscara 000.000.000-00; valores fora do padrão voltam sem alteração
+func FormatCPF(cpf string) string {
+	if len(cpf) != 11 {
+		return cpf
+	}
+	return cpf[:3] + "." + cpf[3:6] + "." + cpf[6:9] + "-" + cpf[9:]
+}
@@ -0,0 +1,36 @@
+package gov
+
+import "testing"
+
+func TestIsValidCPF(t *testing.T) {
+	tests := []struct {
+		cpf      string
+		expected bool
+	}{
+		{"12345678909", true},
+		{"98765432100", true},
+		{"12345678901", false},
+		{"11111111111", false},
+		{"1234567890", false},
+		{"123.456.789-09", false},
+		{"1234567890a", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cpf, func(t *testing.T) {
+			if got := IsValidCPF(tt.cpf); got != tt.expected {
+				t.Errorf("IsValidCPF(%q) = %v, want %v", tt.cpf, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatCPF(t *testing.T) {
+	if got := FormatCPF("12345678909"); got != "123.456.789-09" {
+		t.Errorf("FormatCPF = %q", got)
+	}
+	if got := FormatCPF("123"); got != "123" {
+		t.Errorf("FormatCPF de valor inválido = %q", got)
+	}
+}
@@ -1,7 +1,25 @@
 package gov
 
+import "errors"
+
+// ErrInvalidCPF indica que o CPF não passou na validação local dos dígitos verificadores
+var ErrInvalidCPF = errors.New("CPF inválido")
+
+// ErrCPFNotFound indica que os dados informados não conferem com o cadastro da Receita Federal
+var ErrCPFNotFound = errors.New("nome não encontrado na receita federal")
+
+// ErrInvalidCNPJ indica que o CNPJ não passou na validação local dos dígitos verificadores
+var ErrInvalidCNPJ = errors.New("CNPJ inválido")
+
+// ErrCNPJNotFound indica que o CNPJ não foi encontrado no cadastro da Receita Federal
+var ErrCNPJNotFound = errors.New("CNPJ não encontrado na receita federal")
+
+// ErrProviderUnavailable indica que o provedor de consulta está fora do ar ou com o circuito aberto
+var ErrProviderUnavailable = errors.New("consulta à receita federal indisponível")
+
 type ReceitaFederalService interface {
 	ConsultaCPF(nome, cpf, dataNascimento string) (*ReceitaFederalResponse, error)
+	ConsultaCNPJ(cnpj string) (*ReceitaFederalCNPJResponse, error)
 }
 
 type ConsultaData struct {
@@ -21,3 +39,24 @@ type ReceitaFederalResponse struct {
 	Consumed int          `json:"consumed"`
 	Result   ConsultaData `json:"result"`
 }
+
+type ConsultaCNPJData struct {
+	NumeroDeCNPJ      string `json:"numero_de_inscricao"`
+	RazaoSocial       string `json:"nome"`
+	NomeFantasia      string `json:"fantasia"`
+	SituacaoCadastral string `json:"situacao"`
+	NaturezaJuridica  string `json:"natureza_juridica"`
+	DataAbertura      string `json:"abertura"`
+}
+
+type ReceitaFederalCNPJResponse struct {
+	Status   bool             `json:"status"`
+	Return   string           `json:"return"`
+	Consumed int              `json:"consumed"`
+	Result   ConsultaCNPJData `json:"result"`
+}
+
+// IsActive indica se a empresa está com a situação cadastral ativa na Receita Federal
+func (r *ReceitaFederalCNPJResponse) IsActive() bool {
+	return r.Status && r.Result.SituacaoCadastral == "ATIVA"
+}
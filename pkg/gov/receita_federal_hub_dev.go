@@ -7,15 +7,26 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/anglesson/simple-web-server/internal/config"
 )
 
+// defaultHubDevTimeout é usado quando RECEITA_FEDERAL_TIMEOUT não está configurado
+const defaultHubDevTimeout = 10 * time.Second
+
 type HubDevService struct {
+	httpClient *http.Client
 }
 
 func NewHubDevService() ReceitaFederalService {
-	return &HubDevService{}
+	timeout := config.AppConfig.ReceitaFederalTimeout
+	if timeout <= 0 {
+		timeout = defaultHubDevTimeout
+	}
+	return &HubDevService{
+		httpClient: &http.Client{Timeout: timeout},
+	}
 }
 
 func (rf *HubDevService) ConsultaCPF(nome, cpf, dataNascimento string) (*ReceitaFederalResponse, error) {
@@ -36,30 +47,11 @@ func (rf *HubDevService) ConsultaCPF(nome, cpf, dataNascimento string) (*Receita
 		}, nil
 	}
 
-	request, err := http.NewRequest(http.MethodGet, uri, nil)
-	if err != nil {
-		log.Printf("Erro ao consultar dados na receita federal. Error: %s", err.Error())
-		return nil, fmt.Errorf("erro ao preparar consulta: %w", err)
-	}
-
-	client := &http.Client{}
-	resp, err := client.Do(request)
-	if err != nil {
-		log.Printf("Erro ao fazer requisição para receita federal. Error: %s", err.Error())
-		return nil, fmt.Errorf("erro ao consultar receita federal: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Read the response body
-	bodyBytes, err := io.ReadAll(resp.Body)
+	bodyBytes, err := rf.get(uri)
 	if err != nil {
-		log.Printf("Erro ao ler resposta da receita federal. Error: %s", err.Error())
-		return nil, fmt.Errorf("erro ao ler resposta da receita federal: %w", err)
+		return nil, err
 	}
 
-	// Log the raw response
-	log.Printf("Resposta bruta da API: %s", string(bodyBytes))
-
 	// Criar um map para armazenar a resposta
 	var responseMap map[string]interface{}
 	if err := json.Unmarshal(bodyBytes, &responseMap); err != nil {
@@ -87,15 +79,17 @@ func (rf *HubDevService) ConsultaCPF(nome, cpf, dataNascimento string) (*Receita
 	// Extrair o resultado
 	resultMap, ok := responseMap["result"].(map[string]interface{})
 	if !ok {
-		log.Printf("Erro ao extrair resultado da resposta: %v", responseMap)
+		log.Printf("Erro ao extrair resultado da resposta da receita federal")
 		return nil, errors.New("formato de resposta inválido da receita federal")
 	}
 
+	consumed, _ := responseMap["consumed"].(float64)
+
 	// Criar e popular o objeto
 	response := &ReceitaFederalResponse{
 		Status:   status,
 		Return:   fmt.Sprintf("%v", responseMap["return"]),
-		Consumed: int(responseMap["consumed"].(float64)),
+		Consumed: int(consumed),
 		Result: ConsultaData{
 			NumeroDeCPF:            fmt.Sprintf("%v", resultMap["numero_de_cpf"]),
 			NomeDaPF:               fmt.Sprintf("%v", resultMap["nome_da_pf"]),
@@ -108,11 +102,83 @@ func (rf *HubDevService) ConsultaCPF(nome, cpf, dataNascimento string) (*Receita
 		},
 	}
 
-	log.Printf("Objeto populado: %+v", response)
-
 	if response.Result.NomeDaPF == "" || response.Result.DataNascimento != dataNascimento {
-		return nil, errors.New("nome não encontrado na receita federal")
+		return nil, ErrCPFNotFound
 	}
 
 	return response, nil
 }
+
+func (rf *HubDevService) ConsultaCNPJ(cnpj string) (*ReceitaFederalCNPJResponse, error) {
+	uri := fmt.Sprintf("%s/v2/cnpj/?cnpj=%s&token=%s", config.AppConfig.HubDesenvolvedorApi, cnpj, config.AppConfig.HubDesenvolvedorToken)
+
+	if !config.AppConfig.HubDesenvolvedorActive {
+		log.Println("HubDesenvolvedorActive is false. Returning mock response.")
+		return &ReceitaFederalCNPJResponse{
+			Status: true,
+			Return: "Mocked response",
+			Result: ConsultaCNPJData{
+				NumeroDeCNPJ:      cnpj,
+				RazaoSocial:       "EMPRESA DE TESTE LTDA",
+				SituacaoCadastral: "ATIVA",
+			},
+		}, nil
+	}
+
+	bodyBytes, err := rf.get(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	var response ReceitaFederalCNPJResponse
+	if err := json.Unmarshal(bodyBytes, &response); err != nil {
+		log.Printf("Erro ao fazer parse da resposta: %s", err.Error())
+		return nil, fmt.Errorf("erro ao processar resposta da receita federal: %w", err)
+	}
+
+	if !response.Status {
+		return &ReceitaFederalCNPJResponse{
+			Status: false,
+		}, nil
+	}
+
+	if response.Result.RazaoSocial == "" {
+		return nil, ErrCNPJNotFound
+	}
+
+	return &response, nil
+}
+
+// get faz a requisição ao provedor e retorna o corpo da resposta. Respostas 5xx e 429
+// são tratadas como falha transitória para que a consulta possa ser repetida.
+func (rf *HubDevService) get(uri string) ([]byte, error) {
+	request, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		log.Printf("Erro ao preparar consulta na receita federal")
+		return nil, errors.New("erro ao preparar consulta")
+	}
+
+	client := rf.httpClient
+	if client == nil {
+		client = &http.Client{Timeout: defaultHubDevTimeout}
+	}
+	resp, err := client.Do(request)
+	if err != nil {
+		// O erro do http.Client inclui a URL com o documento e o token, por isso não é registrado
+		log.Printf("Erro ao fazer requisição para receita federal")
+		return nil, errors.New("erro ao consultar receita federal")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		log.Printf("Receita federal respondeu com status %d", resp.StatusCode)
+		return nil, fmt.Errorf("receita federal respondeu com status %d", resp.StatusCode)
+	}
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("Erro ao ler resposta da receita federal. Error: %s", err.Error())
+		return nil, fmt.Errorf("erro ao ler resposta da receita federal: %w", err)
+	}
+	return bodyBytes, nil
+}
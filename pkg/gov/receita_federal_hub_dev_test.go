@@ -2,6 +2,7 @@ package gov
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -327,3 +328,132 @@ func TestHubDevService_ConsultaCPF_MockActive(t *testing.T) {
 		t.Errorf("Expected DataNascimento '%s', got '%s'", dataNascimento, response.Result.DataNascimento)
 	}
 }
+
+func TestHubDevService_ConsultaCNPJ(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockResponse   map[string]interface{}
+		expectedError  error
+		expectedActive bool
+	}{
+		{
+			name: "should return active company",
+			mockResponse: map[string]interface{}{
+				"status":   true,
+				"return":   "OK",
+				"consumed": 1,
+				"result": map[string]interface{}{
+					"numero_de_inscricao": "11.222.333/0001-81",
+					"nome":                "EMPRESA EXEMPLO LTDA",
+					"fantasia":            "EXEMPLO",
+					"situacao":            "ATIVA",
+				},
+			},
+			expectedActive: true,
+		},
+		{
+			name: "should return inactive company",
+			mockResponse: map[string]interface{}{
+				"status":   true,
+				"return":   "OK",
+				"consumed": 1,
+				"result": map[string]interface{}{
+					"nome":     "EMPRESA BAIXADA LTDA",
+					"situacao": "BAIXADA",
+				},
+			},
+			expectedActive: false,
+		},
+		{
+			name: "should return error when razao social is empty",
+			mockResponse: map[string]interface{}{
+				"status":   true,
+				"return":   "OK",
+				"consumed": 1,
+				"result":   map[string]interface{}{"nome": ""},
+			},
+			expectedError: ErrCNPJNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v2/cnpj/" || r.URL.Query().Get("cnpj") != "11222333000181" {
+					t.Errorf("Requisição inesperada: %s", r.URL.String())
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(tt.mockResponse)
+			}))
+			defer server.Close()
+
+			originalURL := config.AppConfig.HubDesenvolvedorApi
+			originalActive := config.AppConfig.HubDesenvolvedorActive
+			config.AppConfig.HubDesenvolvedorActive = true
+			config.AppConfig.HubDesenvolvedorApi = server.URL
+			defer func() {
+				config.AppConfig.HubDesenvolvedorApi = originalURL
+				config.AppConfig.HubDesenvolvedorActive = originalActive
+			}()
+
+			service := &HubDevService{}
+			response, err := service.ConsultaCNPJ("11222333000181")
+
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("Erro esperado '%v', mas recebeu '%v'", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Não esperava erro, mas recebeu: %v", err)
+			}
+			if response.IsActive() != tt.expectedActive {
+				t.Errorf("IsActive = %v, want %v", response.IsActive(), tt.expectedActive)
+			}
+			if response.Result.RazaoSocial != tt.mockResponse["result"].(map[string]interface{})["nome"] {
+				t.Errorf("Razão social inesperada: %s", response.Result.RazaoSocial)
+			}
+		})
+	}
+}
+
+func TestHubDevService_ConsultaCNPJ_ServerErrorIsTransient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	originalURL := config.AppConfig.HubDesenvolvedorApi
+	originalActive := config.AppConfig.HubDesenvolvedorActive
+	config.AppConfig.HubDesenvolvedorActive = true
+	config.AppConfig.HubDesenvolvedorApi = server.URL
+	defer func() {
+		config.AppConfig.HubDesenvolvedorApi = originalURL
+		config.AppConfig.HubDesenvolvedorActive = originalActive
+	}()
+
+	service := &HubDevService{}
+	if _, err := service.ConsultaCNPJ("11222333000181"); err == nil {
+		t.Fatal("Esperava erro para resposta 502")
+	}
+}
+
+func TestHubDevService_ConsultaCNPJ_MockActive(t *testing.T) {
+	originalActive := config.AppConfig.HubDesenvolvedorActive
+	config.AppConfig.HubDesenvolvedorActive = false
+	defer func() { config.AppConfig.HubDesenvolvedorActive = originalActive }()
+
+	service := &HubDevService{}
+	response, err := service.ConsultaCNPJ("11222333000181")
+
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !response.IsActive() {
+		t.Error("Expected mocked company to be active")
+	}
+	if response.Result.NumeroDeCNPJ != "11222333000181" {
+		t.Errorf("Expected NumeroDeCNPJ '11222333000181', got '%s'", response.Result.NumeroDeCNPJ)
+	}
+}
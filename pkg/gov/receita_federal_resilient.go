@@ -0,0 +1,154 @@
+package gov
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ResilienceOptions configura as novas tentativas e o circuit breaker das consultas de CPF e CNPJ
+type ResilienceOptions struct {
+	MaxRetries       int
+	RetryBackoff     time.Duration
+	FailureThreshold int
+	Cooldown         time.Duration
+}
+
+// resilientReceitaFederalService envolve o provedor de consulta com validação local do documento,
+// novas tentativas em falhas transitórias e um circuit breaker que evita insistir
+// (e pagar) por um provedor fora do ar.
+type resilientReceitaFederalService struct {
+	provider ReceitaFederalService
+	options  ResilienceOptions
+	breaker  *circuitBreaker
+	sleep    func(time.Duration)
+}
+
+func NewResilientReceitaFederalService(provider ReceitaFederalService, options ResilienceOptions) ReceitaFederalService {
+	if options.FailureThreshold <= 0 {
+		options.FailureThreshold = 5
+	}
+	if options.Cooldown <= 0 {
+		options.Cooldown = time.Minute
+	}
+	return &resilientReceitaFederalService{
+		provider: provider,
+		options:  options,
+		breaker:  newCircuitBreaker(options.FailureThreshold, options.Cooldown, time.Now),
+		sleep:    time.Sleep,
+	}
+}
+
+func (s *resilientReceitaFederalService) ConsultaCPF(nome, cpf, dataNascimento string) (*ReceitaFederalResponse, error) {
+	if !IsValidCPF(cpf) {
+		return nil, ErrInvalidCPF
+	}
+
+	var response *ReceitaFederalResponse
+	err := s.call(ErrCPFNotFound, func() error {
+		var err error
+		response, err = s.provider.ConsultaCPF(nome, cpf, dataNascimento)
+		return err
+	})
+	return response, err
+}
+
+func (s *resilientReceitaFederalService) ConsultaCNPJ(cnpj string) (*ReceitaFederalCNPJResponse, error) {
+	if !IsValidCNPJ(cnpj) {
+		return nil, ErrInvalidCNPJ
+	}
+
+	var response *ReceitaFederalCNPJResponse
+	err := s.call(ErrCNPJNotFound, func() error {
+		var err error
+		response, err = s.provider.ConsultaCNPJ(cnpj)
+		return err
+	})
+	return response, err
+}
+
+// call executa a consulta passando pelo circuit breaker e pelas novas tentativas.
+// notFound é o erro de documento não encontrado, que conta como resposta do provedor.
+func (s *resilientReceitaFederalService) call(notFound error, consult func() error) error {
+	if !s.breaker.Allow() {
+		return ErrProviderUnavailable
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= s.options.MaxRetries; attempt++ {
+		if attempt > 0 && s.options.RetryBackoff > 0 {
+			s.sleep(s.options.RetryBackoff * time.Duration(attempt))
+		}
+
+		err := consult()
+		if err == nil || errors.Is(err, notFound) {
+			// O provedor respondeu: dados divergentes não são falha de disponibilidade
+			s.breaker.RecordSuccess()
+			return err
+		}
+		lastErr = err
+	}
+
+	if s.breaker.RecordFailure() {
+		log.Printf("Circuito da consulta à receita federal aberto após falhas consecutivas")
+	}
+	return fmt.Errorf("%w: %v", ErrProviderUnavailable, lastErr)
+}
+
+// circuitBreaker abre após uma sequência de falhas e, passado o cooldown,
+// libera uma única chamada de teste (half-open) antes de fechar novamente.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration, now func() time.Time) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       now,
+	}
+}
+
+func (b *circuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.now().Before(b.openUntil) || b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *circuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+}
+
+// RecordFailure registra a falha e retorna true quando o circuito acabou de abrir
+func (b *circuitBreaker) RecordFailure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.failures >= b.threshold {
+		b.openUntil = b.now().Add(b.cooldown)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package gov
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type stubProvider struct {
+	calls     int
+	responses []error
+}
+
+func (p *stubProvider) ConsultaCPF(nome, cpf, dataNascimento string) (*ReceitaFederalResponse, error) {
+	p.calls++
+	var err error
+	if len(p.responses) > 0 {
+		err = p.responses[0]
+		p.responses = p.responses[1:]
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ReceitaFederalResponse{Status: true, Consumed: 1, Result: ConsultaData{NomeDaPF: nome}}, nil
+}
+
+func (p *stubProvider) ConsultaCNPJ(cnpj string) (*ReceitaFederalCNPJResponse, error) {
+	p.calls++
+	var err error
+	if len(p.responses) > 0 {
+		err = p.responses[0]
+		p.responses = p.responses[1:]
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ReceitaFederalCNPJResponse{Status: true, Consumed: 1, Result: ConsultaCNPJData{NumeroDeCNPJ: cnpj, RazaoSocial: "EMPRESA LTDA", SituacaoCadastral: "ATIVA"}}, nil
+}
+
+func newTestResilientService(provider ReceitaFederalService, retries, threshold int, now func() time.Time) *resilientReceitaFederalService {
+	return &resilientReceitaFederalService{
+		provider: provider,
+		options:  ResilienceOptions{MaxRetries: retries, FailureThreshold: threshold, Cooldown: time.Minute},
+		breaker:  newCircuitBreaker(threshold, time.Minute, now),
+		sleep:    func(time.Duration) {},
+	}
+}
+
+func TestResilientService_InvalidCPFSkipsProvider(t *testing.T) {
+	provider := &stubProvider{}
+	service := newTestResilientService(provider, 2, 3, time.Now)
+
+	_, err := service.ConsultaCPF("João", "12345678901", "01/01/1990")
+
+	if !errors.Is(err, ErrInvalidCPF) {
+		t.Fatalf("esperava ErrInvalidCPF, recebeu %v", err)
+	}
+	if provider.calls != 0 {
+		t.Errorf("provedor não deveria ser chamado, chamadas = %d", provider.calls)
+	}
+}
+
+func TestResilientService_RetriesTransientFailures(t *testing.T) {
+	provider := &stubProvider{responses: []error{errors.New("timeout"), nil}}
+	service := newTestResilientService(provider, 2, 3, time.Now)
+
+	response, err := service.ConsultaCPF("João", "12345678909", "01/01/1990")
+
+	if err != nil {
+		t.Fatalf("não esperava erro: %v", err)
+	}
+	if response == nil || !response.Status {
+		t.Fatalf("esperava resposta válida")
+	}
+	if provider.calls != 2 {
+		t.Errorf("chamadas = %d, want 2", provider.calls)
+	}
+}
+
+func TestResilientService_NotFoundIsNotRetried(t *testing.T) {
+	provider := &stubProvider{responses: []error{ErrCPFNotFound}}
+	service := newTestResilientService(provider, 2, 3, time.Now)
+
+	_, err := service.ConsultaCPF("João", "12345678909", "01/01/1990")
+
+	if !errors.Is(err, ErrCPFNotFound) {
+		t.Fatalf("esperava ErrCPFNotFound, recebeu %v", err)
+	}
+	if provider.calls != 1 {
+		t.Errorf("chamadas = %d, want 1", provider.calls)
+	}
+}
+
+func TestResilientService_CircuitOpensAndRecovers(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	down := errors.New("provedor fora do ar")
+	provider := &stubProvider{responses: []error{down, down}}
+	service := newTestResilientService(provider, 0, 2, clock)
+
+	for i := 0; i < 2; i++ {
+		if _, err := service.ConsultaCPF("João", "12345678909", "01/01/1990"); !errors.Is(err, ErrProviderUnavailable) {
+			t.Fatalf("esperava ErrProviderUnavailable, recebeu %v", err)
+		}
+	}
+
+	// Circuito aberto: nenhuma chamada ao provedor
+	if _, err := service.ConsultaCPF("João", "12345678909", "01/01/1990"); !errors.Is(err, ErrProviderUnavailable) {
+		t.Fatalf("esperava circuito aberto, recebeu %v", err)
+	}
+	if provider.calls != 2 {
+		t.Fatalf("chamadas = %d, want 2", provider.calls)
+	}
+
+	// Após o cooldown uma chamada de teste é liberada e fecha o circuito
+	now = now.Add(2 * time.Minute)
+	if _, err := service.ConsultaCPF("João", "12345678909", "01/01/1990"); err != nil {
+		t.Fatalf("não esperava erro após cooldown: %v", err)
+	}
+	if _, err := service.ConsultaCPF("João", "12345678909", "01/01/1990"); err != nil {
+		t.Fatalf("circuito deveria estar fechado: %v", err)
+	}
+	if provider.calls != 4 {
+		t.Errorf("chamadas = %d, want 4", provider.calls)
+	}
+}
+
+func TestResilientService_InvalidCNPJSkipsProvider(t *testing.T) {
+	provider := &stubProvider{}
+	service := newTestResilientService(provider, 2, 3, time.Now)
+
+	_, err := service.ConsultaCNPJ("11222333000180")
+
+	if !errors.Is(err, ErrInvalidCNPJ) {
+		t.Fatalf("esperava ErrInvalidCNPJ, recebeu %v", err)
+	}
+	if provider.calls != 0 {
+		t.Errorf("provedor não deveria ser chamado, chamadas = %d", provider.calls)
+	}
+}
+
+func TestResilientService_CNPJSharesCircuitWithCPF(t *testing.T) {
+	down := errors.New("provedor fora do ar")
+	provider := &stubProvider{responses: []error{down, down}}
+	service := newTestResilientService(provider, 0, 2, time.Now)
+
+	if _, err := service.ConsultaCPF("João", "12345678909", "01/01/1990"); !errors.Is(err, ErrProviderUnavailable) {
+		t.Fatalf("esperava ErrProviderUnavailable, recebeu %v", err)
+	}
+	if _, err := service.ConsultaCNPJ("11222333000181"); !errors.Is(err, ErrProviderUnavailable) {
+		t.Fatalf("esperava ErrProviderUnavailable, recebeu %v", err)
+	}
+
+	// O mesmo provedor atende as duas consultas, então o circuito aberto bloqueia ambas
+	if _, err := service.ConsultaCNPJ("11222333000181"); !errors.Is(err, ErrProviderUnavailable) {
+		t.Fatalf("esperava circuito aberto, recebeu %v", err)
+	}
+	if provider.calls != 2 {
+		t.Errorf("chamadas = %d, want 2", provider.calls)
+	}
+}
@@ -1,6 +1,7 @@
 package mail
 
 import (
+	"bytes"
 	"log"
 
 	"github.com/wneessen/go-mail"
@@ -48,11 +49,25 @@ func (m *GoMailMailer) Body(body string) {
 	m.msg.SetBodyString(mail.TypeTextHTML, body)
 }
 
-func (m *GoMailMailer) Send() {
-	if err := m.client.DialAndSend(m.msg); err != nil {
-		log.Printf("failed to send mail: %s", err)
+func (m *GoMailMailer) Attach(filename string, content []byte) {
+	if err := m.msg.AttachReader(filename, bytes.NewReader(content)); err != nil {
+		log.Printf("failed to attach %s: %s", filename, err)
 		return
 	}
+}
+
+func (m *GoMailMailer) Header(name string, value string) {
+	m.msg.SetGenHeader(mail.Header(name), value)
+}
 
-	m.msg.Reset()
+// Send envia a mensagem montada e limpa o estado mesmo em caso de falha,
+// para que cabeçalhos e anexos não vazem para o próximo email
+func (m *GoMailMailer) Send() error {
+	defer m.msg.Reset()
+
+	if err := m.client.DialAndSend(m.msg); err != nil {
+		log.Printf("failed to send mail: %s", err)
+		return err
+	}
+	return nil
 }
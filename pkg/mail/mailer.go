@@ -5,5 +5,8 @@ type Mailer interface {
 	To(email string)
 	Subject(subject string)
 	Body(body string)
-	Send()
+	Attach(filename string, content []byte)
+	// Header define um cabeçalho extra da mensagem (ex.: Reply-To, List-Unsubscribe)
+	Header(name string, value string)
+	Send() error
 }
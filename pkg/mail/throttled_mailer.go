@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"sync"
+	"time"
+)
+
+// ThrottledMailer limita a taxa de envio de outro Mailer, esperando um intervalo mínimo entre dois envios.
+// É usado nos envios em massa para respeitar a cota do provedor de email.
+type ThrottledMailer struct {
+	Mailer
+	interval time.Duration
+	sleep    func(time.Duration)
+
+	mu       sync.Mutex
+	lastSend time.Time
+}
+
+// NewThrottledMailer envia no máximo perMinute emails por minuto; zero desativa o limite
+func NewThrottledMailer(mailer Mailer, perMinute int) *ThrottledMailer {
+	var interval time.Duration
+	if perMinute > 0 {
+		interval = time.Minute / time.Duration(perMinute)
+	}
+	return &ThrottledMailer{Mailer: mailer, interval: interval, sleep: time.Sleep}
+}
+
+func (m *ThrottledMailer) Send() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.lastSend.IsZero() {
+		if wait := m.interval - time.Since(m.lastSend); wait > 0 {
+			m.sleep(wait)
+		}
+	}
+	err := m.Mailer.Send()
+	m.lastSend = time.Now()
+	return err
+}
@@ -0,0 +1,53 @@
+package mail
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeMailer struct {
+	sent int
+	err  error
+}
+
+func (f *fakeMailer) From(name string, email string)         {}
+func (f *fakeMailer) To(email string)                        {}
+func (f *fakeMailer) Subject(subject string)                 {}
+func (f *fakeMailer) Body(body string)                       {}
+func (f *fakeMailer) Attach(filename string, content []byte) {}
+func (f *fakeMailer) Header(name string, value string)       {}
+func (f *fakeMailer) Send() error {
+	f.sent++
+	return f.err
+}
+
+func TestThrottledMailer_WaitsBetweenSends(t *testing.T) {
+	inner := &fakeMailer{}
+	mailer := NewThrottledMailer(inner, 60)
+	var waits []time.Duration
+	mailer.sleep = func(d time.Duration) { waits = append(waits, d) }
+
+	assert.NoError(t, mailer.Send())
+	assert.NoError(t, mailer.Send())
+	assert.NoError(t, mailer.Send())
+
+	assert.Equal(t, 3, inner.sent)
+	// O primeiro envio sai na hora; os seguintes esperam até completar um segundo
+	assert.Len(t, waits, 2)
+	for _, wait := range waits {
+		assert.InDelta(t, time.Second, wait, float64(100*time.Millisecond))
+	}
+}
+
+func TestThrottledMailer_NoLimitAndErrors(t *testing.T) {
+	inner := &fakeMailer{err: errors.New("smtp indisponível")}
+	mailer := NewThrottledMailer(inner, 0)
+	mailer.sleep = func(d time.Duration) { t.Fatalf("não deveria esperar %s", d) }
+
+	assert.Error(t, mailer.Send())
+	assert.Error(t, mailer.Send())
+	assert.Equal(t, 2, inner.sent)
+}
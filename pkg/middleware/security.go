@@ -75,6 +75,8 @@ func (rl *RateLimiter) RateLimitMiddleware(next http.Handler) http.Handler {
 				var redirectPath string
 				if strings.Contains(r.URL.Path, "/forget-password") || strings.Contains(r.URL.Path, "/reset-password") {
 					redirectPath = "/forget-password?error=rate_limit_exceeded"
+				} else if strings.Contains(r.URL.Path, "/reenviar-acesso") {
+					redirectPath = "/reenviar-acesso?error=rate_limit_exceeded"
 				} else {
 					redirectPath = "/login?error=rate_limit_exceeded"
 				}
@@ -121,6 +123,11 @@ func (rl *RateLimiter) isAllowed(clientIP string) bool {
 	return true
 }
 
+// ClientIP returns the real client IP of the request, honoring proxy headers
+func ClientIP(r *http.Request) string {
+	return getClientIP(r)
+}
+
 // getClientIP extracts the real client IP from the request
 func getClientIP(r *http.Request) string {
 	// Check for forwarded headers
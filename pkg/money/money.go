@@ -0,0 +1,205 @@
+// Package money representa valores monetários em centavos (inteiros) para evitar
+// os erros de arredondamento de float64 em preços, checkout e splits de pagamento.
+package money
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// BRL é a moeda padrão da plataforma; EUR e USD atendem compradores de Portugal e dos EUA
+const (
+	BRL = "BRL"
+	EUR = "EUR"
+	USD = "USD"
+)
+
+// SupportedCurrencies são as moedas aceitas nos preços dos ebooks, na ordem de exibição
+var SupportedCurrencies = []string{BRL, EUR, USD}
+
+var ErrInvalidAmount = errors.New("valor monetário inválido")
+
+// IsSupported indica se a moeda pode ser usada em preços e checkouts
+func IsSupported(currency string) bool {
+	currency = strings.ToUpper(currency)
+	for _, supported := range SupportedCurrencies {
+		if supported == currency {
+			return true
+		}
+	}
+	return false
+}
+
+// CurrencyForLocale escolhe a moeda a partir do cabeçalho Accept-Language do comprador:
+// pt-BR fica em BRL, en-US em USD e idiomas de países da zona do euro (pt-PT, es-ES...) em EUR.
+// Sem correspondência a moeda padrão é BRL.
+func CurrencyForLocale(acceptLanguage string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag := strings.ToLower(strings.TrimSpace(strings.SplitN(part, ";", 2)[0]))
+		language, region, _ := strings.Cut(tag, "-")
+		switch {
+		case language == "pt" && (region == "" || region == "br"):
+			return BRL
+		case region == "us" || (language == "en" && region == ""):
+			return USD
+		case eurozoneRegions[region]:
+			return EUR
+		}
+	}
+	return BRL
+}
+
+var eurozoneRegions = map[string]bool{
+	"pt": true, "es": true, "fr": true, "de": true, "it": true, "nl": true,
+	"be": true, "at": true, "ie": true, "fi": true, "gr": true, "lu": true,
+}
+
+// Money é um valor em centavos acompanhado da moeda (código ISO 4217).
+// Quando embutido em um model do GORM vira as colunas <prefixo>amount e <prefixo>currency.
+type Money struct {
+	Amount   int64  `json:"amount" gorm:"default:0"`
+	Currency string `json:"currency" gorm:"type:varchar(3);default:'BRL'"`
+}
+
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// FromCents cria um valor em reais a partir de centavos
+func FromCents(cents int64) Money {
+	return New(cents, BRL)
+}
+
+// FromFloat converte um valor decimal em reais arredondando para o centavo mais próximo
+// (19.99 vira 1999, e não 1998 como em int64(19.99 * 100))
+func FromFloat(value float64) Money {
+	return FromCents(int64(math.Round(value * 100)))
+}
+
+// ParseBRL interpreta valores digitados em formulários: "1.234,56", "R$ 29,90" ou "29.90".
+// Texto vazio resulta em zero. São aceitas no máximo duas casas decimais.
+func ParseBRL(value string) (Money, error) {
+	value = strings.TrimSpace(strings.Replace(value, "R$", "", 1))
+	if value == "" {
+		return FromCents(0), nil
+	}
+
+	integer, fraction := value, ""
+	if i := strings.LastIndex(value, ","); i >= 0 {
+		integer, fraction = value[:i], value[i+1:]
+		integer = strings.ReplaceAll(integer, ".", "")
+	} else if i := strings.LastIndex(value, "."); i >= 0 && len(value)-i-1 <= 2 && strings.Count(value, ".") == 1 {
+		integer, fraction = value[:i], value[i+1:]
+	} else {
+		integer = strings.ReplaceAll(integer, ".", "")
+	}
+
+	if integer == "" {
+		integer = "0"
+	}
+	if len(fraction) > 2 || !onlyDigits(integer) || !onlyDigits(fraction) {
+		return Money{}, ErrInvalidAmount
+	}
+	fraction = (fraction + "00")[:2]
+
+	units, err := strconv.ParseInt(integer, 10, 64)
+	if err != nil {
+		return Money{}, ErrInvalidAmount
+	}
+	cents, _ := strconv.ParseInt(fraction, 10, 64)
+	return FromCents(units*100 + cents), nil
+}
+
+func onlyDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// CurrencyOrDefault retorna a moeda do valor, assumindo BRL para registros sem moeda
+func (m Money) CurrencyOrDefault() string {
+	if m.Currency == "" {
+		return BRL
+	}
+	return m.Currency
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+func (m Money) Add(other Money) Money {
+	return New(m.Amount+other.Amount, m.CurrencyOrDefault())
+}
+
+func (m Money) Sub(other Money) Money {
+	return New(m.Amount-other.Amount, m.CurrencyOrDefault())
+}
+
+// Mul multiplica o valor por um fator (ex.: 0.05 para 5%), arredondando para o centavo mais próximo
+func (m Money) Mul(factor float64) Money {
+	return New(int64(math.Round(float64(m.Amount)*factor)), m.CurrencyOrDefault())
+}
+
+// Float retorna o valor na unidade da moeda (ex.: 19.99); use apenas para exibição e metadados
+func (m Money) Float() float64 {
+	return float64(m.Amount) / 100
+}
+
+// Number formata o valor sem símbolo no padrão brasileiro ("1.234,56"), usado nos inputs com máscara
+func (m Money) Number() string {
+	return m.format(".", ",")
+}
+
+func (m Money) format(thousands, decimal string) string {
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	integer := strconv.FormatInt(amount/100, 10)
+	for i := len(integer) - 3; i > 0; i -= 3 {
+		integer = integer[:i] + thousands + integer[i:]
+	}
+
+	cents := strconv.FormatInt(amount%100, 10)
+	if len(cents) == 1 {
+		cents = "0" + cents
+	}
+	return sign + integer + decimal + cents
+}
+
+// String formata o valor com o símbolo e a pontuação da moeda
+// (ex.: "R$ 1.234,56", "€ 1.234,56", "US$ 1,234.56")
+func (m Money) String() string {
+	currency := m.CurrencyOrDefault()
+	if currency == USD {
+		return Symbol(currency) + " " + m.format(",", ".")
+	}
+	return Symbol(currency) + " " + m.Number()
+}
+
+// Symbol retorna o símbolo de exibição da moeda
+func Symbol(currency string) string {
+	switch strings.ToUpper(currency) {
+	case BRL, "":
+		return "R$"
+	case EUR:
+		return "€"
+	case USD:
+		return "US$"
+	default:
+		return strings.ToUpper(currency)
+	}
+}
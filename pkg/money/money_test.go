@@ -0,0 +1,111 @@
+package money
+
+import "testing"
+
+func TestFromFloat_RoundsToNearestCent(t *testing.T) {
+	tests := []struct {
+		value float64
+		cents int64
+	}{
+		{19.99, 1999},
+		{29.90, 2990},
+		{0.29, 29},
+		{0, 0},
+	}
+	for _, tt := range tests {
+		if got := FromFloat(tt.value).Amount; got != tt.cents {
+			t.Errorf("FromFloat(%v) = %d, want %d", tt.value, got, tt.cents)
+		}
+	}
+}
+
+func TestParseBRL(t *testing.T) {
+	tests := []struct {
+		input string
+		cents int64
+	}{
+		{"19,99", 1999},
+		{"R$ 1.234,56", 123456},
+		{"1.234.567,8", 123456780},
+		{"29.90", 2990},
+		{"1.234", 123400},
+		{"42", 4200},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		got, err := ParseBRL(tt.input)
+		if err != nil {
+			t.Errorf("ParseBRL(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if got.Amount != tt.cents || got.Currency != BRL {
+			t.Errorf("ParseBRL(%q) = %+v, want %d BRL", tt.input, got, tt.cents)
+		}
+	}
+}
+
+func TestParseBRL_Invalid(t *testing.T) {
+	for _, input := range []string{"abc", "19,999", "-5,00", "1,2,3"} {
+		if _, err := ParseBRL(input); err == nil {
+			t.Errorf("ParseBRL(%q) should fail", input)
+		}
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	tests := []struct {
+		money    Money
+		expected string
+	}{
+		{FromCents(0), "R$ 0,00"},
+		{FromCents(5), "R$ 0,05"},
+		{FromCents(1999), "R$ 19,99"},
+		{FromCents(123456789), "R$ 1.234.567,89"},
+		{FromCents(-2990), "R$ -29,90"},
+		{Money{Amount: 100}, "R$ 1,00"},
+		{New(4500, "eur"), "€ 45,00"},
+		{New(123456, USD), "US$ 1,234.56"},
+	}
+	for _, tt := range tests {
+		if got := tt.money.String(); got != tt.expected {
+			t.Errorf("%+v.String() = %q, want %q", tt.money, got, tt.expected)
+		}
+	}
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	price := FromCents(2990)
+
+	if got := price.Sub(FromCents(1990)); got.Amount != 1000 || got.Currency != BRL {
+		t.Errorf("Sub = %+v, want 1000 BRL", got)
+	}
+	if got := price.Add(FromCents(10)); got.Amount != 3000 {
+		t.Errorf("Add = %d, want 3000", got.Amount)
+	}
+	if got := FromCents(3000).Mul(0.0291); got.Amount != 87 {
+		t.Errorf("Mul = %d, want 87", got.Amount)
+	}
+	if got := FromCents(1999).Mul(0.9); got.Amount != 1799 {
+		t.Errorf("Mul discount = %d, want 1799", got.Amount)
+	}
+}
+
+func TestCurrencyForLocale(t *testing.T) {
+	tests := []struct {
+		acceptLanguage string
+		currency       string
+	}{
+		{"pt-BR,pt;q=0.9,en;q=0.8", BRL},
+		{"pt-PT,pt;q=0.9", EUR},
+		{"en-US,en;q=0.9", USD},
+		{"es-ES", EUR},
+		{"en-GB,en;q=0.9", USD},
+		{"ja-JP", BRL},
+		{"", BRL},
+	}
+	for _, tt := range tests {
+		if got := CurrencyForLocale(tt.acceptLanguage); got != tt.currency {
+			t.Errorf("CurrencyForLocale(%q) = %s, want %s", tt.acceptLanguage, got, tt.currency)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Package pii criptografa dados pessoais (CPF, telefone, data de nascimento) antes de gravá-los
+// no banco. As chaves são versionadas: o valor gravado carrega o identificador da chave usada,
+// então chaves antigas continuam decifrando enquanto o comando cmd/pii-rotate regrava as linhas
+// com a chave atual. Buscas exatas usam índices cegos (HMAC) em vez do texto original.
+package pii
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// prefix marca os valores cifrados; valores sem ele são linhas antigas ainda em texto puro
+const prefix = "enc:"
+
+var (
+	ErrInvalidKey        = errors.New("chave de criptografia inválida")
+	ErrUnknownKey        = errors.New("chave de criptografia desconhecida")
+	ErrMalformedCipher   = errors.New("valor criptografado malformado")
+	ErrMissingIndexKey   = errors.New("PII_BLIND_INDEX_KEY é obrigatória quando PII_ENCRYPTION_KEYS está configurada")
+	ErrEncryptionMissing = errors.New("nenhuma chave de criptografia configurada")
+)
+
+// Keyring guarda as chaves AES-256 versionadas e a chave do índice cego.
+// A primeira chave da lista é a atual, usada nas novas gravações.
+type Keyring struct {
+	current  string
+	keys     map[string]cipher.AEAD
+	indexKey []byte
+}
+
+// NewKeyring interpreta a lista "id:chave_base64,id:chave_base64" (chaves de 32 bytes).
+// Sem chaves o keyring apenas repassa os valores, o que serve para desenvolvimento e testes.
+func NewKeyring(encryptionKeys string, blindIndexKey string) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]cipher.AEAD), indexKey: []byte(blindIndexKey)}
+
+	for _, item := range strings.Split(encryptionKeys, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(item, ":")
+		if !ok || id == "" || strings.ContainsAny(id, ": ") {
+			return nil, fmt.Errorf("%w: esperado id:chave_base64", ErrInvalidKey)
+		}
+		if _, exists := k.keys[id]; exists {
+			return nil, fmt.Errorf("%w: id %q repetido", ErrInvalidKey, id)
+		}
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(raw) != 32 {
+			return nil, fmt.Errorf("%w: a chave %q deve ter 32 bytes em base64", ErrInvalidKey, id)
+		}
+		block, err := aes.NewCipher(raw)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		k.keys[id] = aead
+		if k.current == "" {
+			k.current = id
+		}
+	}
+
+	if k.current != "" && blindIndexKey == "" {
+		return nil, ErrMissingIndexKey
+	}
+	return k, nil
+}
+
+// Enabled indica se há chave para cifrar novas gravações
+func (k *Keyring) Enabled() bool {
+	return k.current != ""
+}
+
+// CurrentKeyID é o identificador da chave usada nas novas gravações
+func (k *Keyring) CurrentKeyID() string {
+	return k.current
+}
+
+// Encrypt cifra o valor com a chave atual no formato enc:<id>:<base64(nonce+texto cifrado)>.
+// Valores vazios continuam vazios para que as consultas por campo em branco sigam funcionando.
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" || !k.Enabled() {
+		return plaintext, nil
+	}
+	aead := k.keys[k.current]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return prefix + k.current + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decifra com a chave indicada no próprio valor. Valores sem o prefixo são
+// devolvidos como estão: são linhas gravadas antes da criptografia e ainda não rotacionadas.
+func (k *Keyring) Decrypt(value string) (string, error) {
+	if !strings.HasPrefix(value, prefix) {
+		return value, nil
+	}
+	id, encoded, ok := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	if !ok {
+		return "", ErrMalformedCipher
+	}
+	aead, exists := k.keys[id]
+	if !exists {
+		return "", fmt.Errorf("%w: %q", ErrUnknownKey, id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrMalformedCipher
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", ErrMalformedCipher
+	}
+	return string(plaintext), nil
+}
+
+// NeedsRotation indica se o valor gravado não está cifrado com a chave atual
+func (k *Keyring) NeedsRotation(value string) bool {
+	if value == "" || !k.Enabled() {
+		return false
+	}
+	return !strings.HasPrefix(value, prefix+k.current+":")
+}
+
+// BlindIndex calcula o HMAC-SHA256 do valor para buscas exatas sem guardar o texto puro.
+// O valor deve chegar normalizado (ex.: apenas dígitos); vazio gera índice vazio.
+func (k *Keyring) BlindIndex(value string) string {
+	if value == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+var (
+	mu      sync.RWMutex
+	keyring = &Keyring{keys: make(map[string]cipher.AEAD)}
+)
+
+// Configure troca o keyring usado pelo serializer e pelos índices cegos dos modelos
+func Configure(k *Keyring) {
+	mu.Lock()
+	defer mu.Unlock()
+	keyring = k
+}
+
+// Default devolve o keyring configurado para a aplicação
+func Default() *Keyring {
+	mu.RLock()
+	defer mu.RUnlock()
+	return keyring
+}
+
+// BlindIndex calcula o índice cego com o keyring da aplicação
+func BlindIndex(value string) string {
+	return Default().BlindIndex(value)
+}
+
+// Digits mantém apenas os dígitos do valor; CPF e telefone são indexados assim para que
+// "529.982.247-25" e "52998224725" encontrem o mesmo registro
+func Digits(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
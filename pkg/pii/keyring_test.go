@@ -0,0 +1,129 @@
+package pii
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const (
+	testKeyV1 = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	testKeyV2 = "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="
+)
+
+func mustKeyring(t *testing.T, keys string) *Keyring {
+	t.Helper()
+	k, err := NewKeyring(keys, "segredo-do-indice")
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	return k
+}
+
+func TestEncryptDecrypt_RoundTrip(t *testing.T) {
+	k := mustKeyring(t, "v1:"+testKeyV1)
+
+	encrypted, err := k.Encrypt("52998224725")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if !strings.HasPrefix(encrypted, "enc:v1:") || strings.Contains(encrypted, "52998224725") {
+		t.Fatalf("valor cifrado inesperado: %q", encrypted)
+	}
+	again, _ := k.Encrypt("52998224725")
+	if again == encrypted {
+		t.Error("duas cifragens do mesmo valor não devem ser iguais")
+	}
+
+	plaintext, err := k.Decrypt(encrypted)
+	if err != nil || plaintext != "52998224725" {
+		t.Errorf("Decrypt = %q, %v", plaintext, err)
+	}
+}
+
+func TestEncrypt_EmptyValueStaysEmpty(t *testing.T) {
+	k := mustKeyring(t, "v1:"+testKeyV1)
+	if got, _ := k.Encrypt(""); got != "" {
+		t.Errorf("Encrypt(\"\") = %q", got)
+	}
+}
+
+func TestDecrypt_LegacyPlaintextIsReturnedAsIs(t *testing.T) {
+	k := mustKeyring(t, "v1:"+testKeyV1)
+	if got, err := k.Decrypt("52998224725"); err != nil || got != "52998224725" {
+		t.Errorf("Decrypt = %q, %v", got, err)
+	}
+}
+
+func TestRotation_OldKeyStillDecrypts(t *testing.T) {
+	old := mustKeyring(t, "v1:"+testKeyV1)
+	encrypted, _ := old.Encrypt("(11) 98888-7777")
+
+	rotated := mustKeyring(t, "v2:"+testKeyV2+",v1:"+testKeyV1)
+	if rotated.CurrentKeyID() != "v2" {
+		t.Fatalf("chave atual = %q, want v2", rotated.CurrentKeyID())
+	}
+	if got, err := rotated.Decrypt(encrypted); err != nil || got != "(11) 98888-7777" {
+		t.Errorf("Decrypt = %q, %v", got, err)
+	}
+	if !rotated.NeedsRotation(encrypted) {
+		t.Error("valor cifrado com v1 deve precisar de rotação")
+	}
+	if !rotated.NeedsRotation("(11) 98888-7777") {
+		t.Error("texto puro deve precisar de rotação")
+	}
+	current, _ := rotated.Encrypt("(11) 98888-7777")
+	if rotated.NeedsRotation(current) {
+		t.Error("valor cifrado com a chave atual não deve precisar de rotação")
+	}
+
+	withoutOld := mustKeyring(t, "v2:"+testKeyV2)
+	if _, err := withoutOld.Decrypt(encrypted); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("err = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestNewKeyring_RejectsInvalidConfiguration(t *testing.T) {
+	tests := []struct {
+		keys, indexKey string
+		want           error
+	}{
+		{"v1", "x", ErrInvalidKey},
+		{"v1:curta", "x", ErrInvalidKey},
+		{"v1:" + testKeyV1 + ",v1:" + testKeyV2, "x", ErrInvalidKey},
+		{"v1:" + testKeyV1, "", ErrMissingIndexKey},
+	}
+	for _, tt := range tests {
+		if _, err := NewKeyring(tt.keys, tt.indexKey); !errors.Is(err, tt.want) {
+			t.Errorf("NewKeyring(%q, %q) err = %v, want %v", tt.keys, tt.indexKey, err, tt.want)
+		}
+	}
+}
+
+func TestNewKeyring_WithoutKeysPassesThrough(t *testing.T) {
+	k, err := NewKeyring("", "")
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	if k.Enabled() {
+		t.Error("keyring sem chaves não deve cifrar")
+	}
+	if got, _ := k.Encrypt("52998224725"); got != "52998224725" {
+		t.Errorf("Encrypt = %q", got)
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	k := mustKeyring(t, "v1:"+testKeyV1)
+	other, _ := NewKeyring("v1:"+testKeyV1, "outro-segredo")
+
+	if k.BlindIndex("52998224725") != k.BlindIndex(Digits("529.982.247-25")) {
+		t.Error("CPF com e sem pontuação devem gerar o mesmo índice")
+	}
+	if k.BlindIndex("52998224725") == other.BlindIndex("52998224725") {
+		t.Error("segredos diferentes devem gerar índices diferentes")
+	}
+	if k.BlindIndex("") != "" {
+		t.Error("valor vazio deve gerar índice vazio")
+	}
+}
@@ -0,0 +1,135 @@
+package pii
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// IndexTag marca os campos de índice cego recalculados pelos hooks do modelo: pii:"index"
+const IndexTag = "index"
+
+// RotateOptions controla a regravação das linhas
+type RotateOptions struct {
+	BatchSize int
+	// Force regrava todas as linhas, mesmo as já cifradas com a chave atual.
+	// Necessário depois de trocar PII_BLIND_INDEX_KEY, para recalcular os índices.
+	Force bool
+}
+
+// RotateResult resume a execução para um modelo
+type RotateResult struct {
+	Scanned   int
+	Rewritten int
+}
+
+// Rotate percorre a tabela do modelo em lotes ordenados pela chave primária e regrava, com a chave
+// atual, as linhas em texto puro ou cifradas com chaves antigas. A gravação passa pelos hooks do
+// modelo, que recalculam os índices cegos. Apenas as colunas cifradas e os índices são atualizados.
+func Rotate[T any](db *gorm.DB, opts RotateOptions) (RotateResult, error) {
+	var result RotateResult
+	k := Default()
+	if !k.Enabled() {
+		return result, ErrEncryptionMissing
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return result, err
+	}
+	primaryKey := stmt.Schema.PrioritizedPrimaryField
+	if primaryKey == nil {
+		return result, fmt.Errorf("pii: %s sem chave primária", stmt.Schema.Name)
+	}
+	encrypted, columns := rotationColumns(stmt.Schema)
+	if len(encrypted) == 0 {
+		return result, fmt.Errorf("pii: %s não tem campos com serializer:%s", stmt.Schema.Name, SerializerName)
+	}
+
+	var lastID interface{} = 0
+	for {
+		var rows []map[string]interface{}
+		err := db.Table(stmt.Schema.Table).
+			Select(append([]string{primaryKey.DBName}, encrypted...)).
+			Where(primaryKey.DBName+" > ?", lastID).
+			Order(primaryKey.DBName).
+			Limit(opts.BatchSize).
+			Find(&rows).Error
+		if err != nil {
+			return result, err
+		}
+		if len(rows) == 0 {
+			return result, nil
+		}
+		result.Scanned += len(rows)
+		lastID = rows[len(rows)-1][primaryKey.DBName]
+
+		var ids []interface{}
+		for _, row := range rows {
+			if opts.Force || rowNeedsRotation(k, row, encrypted) {
+				ids = append(ids, row[primaryKey.DBName])
+			}
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var records []T
+			if err := tx.Unscoped().Where(primaryKey.DBName+" IN ?", ids).Find(&records).Error; err != nil {
+				return err
+			}
+			for i := range records {
+				if err := tx.Unscoped().Model(&records[i]).Select(columns).Updates(&records[i]).Error; err != nil {
+					return err
+				}
+			}
+			result.Rewritten += len(records)
+			return nil
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+}
+
+// rotationColumns devolve as colunas cifradas e, junto com elas, os índices cegos a regravar
+func rotationColumns(s *schema.Schema) (encrypted []string, columns []string) {
+	for _, field := range s.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		if field.TagSettings["SERIALIZER"] == SerializerName {
+			encrypted = append(encrypted, field.DBName)
+			columns = append(columns, field.DBName)
+		} else if field.Tag.Get("pii") == IndexTag {
+			columns = append(columns, field.DBName)
+		}
+	}
+	return encrypted, columns
+}
+
+func rowNeedsRotation(k *Keyring, row map[string]interface{}, columns []string) bool {
+	for _, column := range columns {
+		var value string
+		switch v := row[column].(type) {
+		case string:
+			value = v
+		case []byte:
+			value = string(v)
+		case nil:
+			continue
+		default:
+			// Coluna ainda com o tipo antigo (ex.: timestamp), certamente em texto puro
+			return true
+		}
+		if k.NeedsRotation(value) {
+			return true
+		}
+	}
+	return false
+}
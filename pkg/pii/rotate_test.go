@@ -0,0 +1,135 @@
+package pii
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type person struct {
+	ID        uint
+	Name      string
+	CPF       string    `gorm:"serializer:pii"`
+	BirthDate time.Time `gorm:"type:text;serializer:pii"`
+	CPFIndex  string    `pii:"index"`
+}
+
+func (p *person) BeforeSave(tx *gorm.DB) error {
+	p.CPFIndex = BlindIndex(Digits(p.CPF))
+	return nil
+}
+
+func useKeyring(t *testing.T, keys string) {
+	t.Helper()
+	previous := Default()
+	Configure(mustKeyring(t, keys))
+	t.Cleanup(func() { Configure(previous) })
+}
+
+func setupPeople(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := db.AutoMigrate(&person{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func rawCPF(t *testing.T, db *gorm.DB, id uint) string {
+	t.Helper()
+	var value string
+	db.Raw("SELECT cpf FROM people WHERE id = ?", id).Scan(&value)
+	return value
+}
+
+func TestSerializer_EncryptsAtRestAndDecryptsOnRead(t *testing.T) {
+	useKeyring(t, "v1:"+testKeyV1)
+	db := setupPeople(t)
+
+	birth := time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC)
+	p := person{Name: "Ana", CPF: "52998224725", BirthDate: birth}
+	if err := db.Create(&p).Error; err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	if stored := rawCPF(t, db, p.ID); stored == "52998224725" || stored[:7] != "enc:v1:" {
+		t.Errorf("CPF gravado = %q, want cifrado com v1", stored)
+	}
+
+	var found person
+	if err := db.Where("cpf_index = ?", BlindIndex("52998224725")).First(&found).Error; err != nil {
+		t.Fatalf("busca pelo índice cego: %v", err)
+	}
+	if found.CPF != "52998224725" || !found.BirthDate.Equal(birth) {
+		t.Errorf("lido = %q %v", found.CPF, found.BirthDate)
+	}
+}
+
+func TestSerializer_ReadsLegacyPlaintext(t *testing.T) {
+	useKeyring(t, "v1:"+testKeyV1)
+	db := setupPeople(t)
+	db.Exec("INSERT INTO people (id, name, cpf, birth_date) VALUES (1, 'Ana', '52998224725', '1990-05-17 00:00:00+00')")
+
+	var found person
+	if err := db.First(&found, 1).Error; err != nil {
+		t.Fatalf("first: %v", err)
+	}
+	if found.CPF != "52998224725" || found.BirthDate.Format("2006-01-02") != "1990-05-17" {
+		t.Errorf("lido = %q %v", found.CPF, found.BirthDate)
+	}
+}
+
+func TestRotate_RewritesPlaintextAndOldKeysInBatches(t *testing.T) {
+	useKeyring(t, "v1:"+testKeyV1)
+	db := setupPeople(t)
+	old := person{Name: "Bruno", CPF: "11144477735"}
+	db.Create(&old)
+	db.Exec("INSERT INTO people (id, name, cpf, birth_date) VALUES (10, 'Ana', '52998224725', '')")
+	empty := person{Name: "Carla"}
+	db.Create(&empty)
+
+	useKeyring(t, "v2:"+testKeyV2+",v1:"+testKeyV1)
+	result, err := Rotate[person](db, RotateOptions{BatchSize: 2})
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if result.Scanned != 3 || result.Rewritten != 2 {
+		t.Errorf("result = %+v, want 3 lidos e 2 regravados", result)
+	}
+	for _, id := range []uint{old.ID, 10} {
+		if stored := rawCPF(t, db, id); stored[:7] != "enc:v2:" {
+			t.Errorf("pessoa %d gravada como %q, want v2", id, stored)
+		}
+	}
+
+	var legacy person
+	db.Where("cpf_index = ?", BlindIndex("52998224725")).First(&legacy)
+	if legacy.ID != 10 || legacy.CPF != "52998224725" {
+		t.Errorf("índice cego não recalculado: %+v", legacy)
+	}
+
+	again, _ := Rotate[person](db, RotateOptions{})
+	if again.Rewritten != 0 {
+		t.Errorf("segunda execução regravou %d linhas", again.Rewritten)
+	}
+	forced, _ := Rotate[person](db, RotateOptions{Force: true})
+	if forced.Rewritten != 3 {
+		t.Errorf("execução forçada regravou %d linhas, want 3", forced.Rewritten)
+	}
+}
+
+func TestRotate_RequiresEncryptionKey(t *testing.T) {
+	previous := Default()
+	k, _ := NewKeyring("", "")
+	Configure(k)
+	t.Cleanup(func() { Configure(previous) })
+
+	if _, err := Rotate[person](setupPeople(t), RotateOptions{}); err != ErrEncryptionMissing {
+		t.Errorf("err = %v, want ErrEncryptionMissing", err)
+	}
+}
@@ -0,0 +1,94 @@
+package pii
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// SerializerName é o nome usado na tag dos campos: gorm:"serializer:pii".
+// Campos time.Time precisam também de type:text, senão o GORM mantém a coluna como data.
+const SerializerName = "pii"
+
+// timeLayouts cobre o RFC3339 gravado pelo serializer e os formatos que o Postgres
+// devolve ao converter colunas timestamptz antigas para texto
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07",
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+func init() {
+	schema.RegisterSerializer(SerializerName, Serializer{})
+}
+
+// Serializer cifra campos string e time.Time ao gravar e decifra ao ler
+type Serializer struct{}
+
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	case time.Time:
+		// Coluna ainda no tipo antigo, sem a migração para texto
+		stored = v.UTC().Format(time.RFC3339Nano)
+	default:
+		return fmt.Errorf("pii: tipo %T não suportado no campo %s", dbValue, field.Name)
+	}
+
+	plaintext, err := Default().Decrypt(stored)
+	if err != nil {
+		return fmt.Errorf("pii: campo %s: %w", field.Name, err)
+	}
+
+	fieldValue := reflect.New(field.FieldType).Elem()
+	switch fieldValue.Interface().(type) {
+	case string:
+		fieldValue.SetString(plaintext)
+	case time.Time:
+		if plaintext != "" {
+			parsed, err := parseTime(plaintext)
+			if err != nil {
+				return fmt.Errorf("pii: campo %s: %w", field.Name, err)
+			}
+			fieldValue.Set(reflect.ValueOf(parsed))
+		}
+	default:
+		return fmt.Errorf("pii: tipo %s não suportado no campo %s", field.FieldType, field.Name)
+	}
+	field.ReflectValueOf(ctx, dst).Set(fieldValue)
+	return nil
+}
+
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	var plaintext string
+	switch v := fieldValue.(type) {
+	case string:
+		plaintext = v
+	case time.Time:
+		if !v.IsZero() {
+			plaintext = v.UTC().Format(time.RFC3339Nano)
+		}
+	default:
+		return nil, fmt.Errorf("pii: tipo %T não suportado no campo %s", fieldValue, field.Name)
+	}
+	return Default().Encrypt(plaintext)
+}
+
+func parseTime(value string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("data inválida %q", value)
+}
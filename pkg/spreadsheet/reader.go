@@ -0,0 +1,62 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+var ErrUnsupportedFormat = errors.New("formato de planilha não suportado: envie um arquivo .csv ou .xlsx")
+
+// Read lê a primeira aba de uma planilha CSV ou XLSX e devolve as linhas com as células como texto.
+// O formato é escolhido pela extensão do arquivo.
+func Read(fileName string, content []byte) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv", ".txt":
+		return readCSV(content)
+	case ".xlsx":
+		return readXLSX(content)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// readCSV aceita vírgula ou ponto e vírgula (padrão do Excel em português) como separador
+func readCSV(content []byte) ([][]string, error) {
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.Comma = detectDelimiter(content)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	return reader.ReadAll()
+}
+
+func detectDelimiter(content []byte) rune {
+	firstLine := content
+	if i := bytes.IndexByte(content, '\n'); i >= 0 {
+		firstLine = content[:i]
+	}
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		return ';'
+	}
+	return ','
+}
+
+func readXLSX(content []byte) ([][]string, error) {
+	file, err := excelize.OpenReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sheets := file.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, nil
+	}
+	return file.GetRows(sheets[0])
+}
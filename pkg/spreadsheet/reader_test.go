@@ -0,0 +1,44 @@
+package spreadsheet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestRead_CSVWithSemicolonAndBOM(t *testing.T) {
+	content := []byte("\xef\xbb\xbfNome;Email;CPF\nJoão;joao@test.com;529.982.247-25\n")
+
+	rows, err := Read("clientes.csv", content)
+
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"Nome", "Email", "CPF"}, {"João", "joao@test.com", "529.982.247-25"}}, rows)
+}
+
+func TestRead_CSVWithComma(t *testing.T) {
+	rows, err := Read("clientes.CSV", []byte("nome,email\n\"Silva, Ana\",ana@test.com\n"))
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Silva, Ana", "ana@test.com"}, rows[1])
+}
+
+func TestRead_XLSX(t *testing.T) {
+	file := excelize.NewFile()
+	require.NoError(t, file.SetSheetRow("Sheet1", "A1", &[]interface{}{"Nome", "Telefone"}))
+	require.NoError(t, file.SetSheetRow("Sheet1", "A2", &[]interface{}{"Ana", "11999998888"}))
+	buffer, err := file.WriteToBuffer()
+	require.NoError(t, err)
+
+	rows, err := Read("clientes.xlsx", buffer.Bytes())
+
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"Nome", "Telefone"}, {"Ana", "11999998888"}}, rows)
+}
+
+func TestRead_UnsupportedFormat(t *testing.T) {
+	_, err := Read("clientes.pdf", []byte("%PDF"))
+
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
@@ -2,25 +2,54 @@ document.addEventListener('DOMContentLoaded', function () {
   const form = document.getElementById('checkoutForm');
   const payButton = document.getElementById('payButton');
   const loadingSpinner = document.getElementById('loadingSpinner');
+  if (!form || form.classList.contains('hidden')) return;
+
+  const foreignBuyer = document.getElementById('foreignBuyer');
+
+  function isForeign() {
+    return foreignBuyer && foreignBuyer.checked;
+  }
+
+  function toggleBuyerType() {
+    const foreign = isForeign();
+    document.getElementById('cpfField').style.display = foreign ? 'none' : '';
+    document.getElementById('foreignFields').style.display = foreign ? 'grid' : 'none';
+    document.getElementById('phone').style.display = foreign ? 'none' : '';
+    document.getElementById('phoneIntl').style.display = foreign ? 'block' : 'none';
+    document.getElementById('cpf').required = !foreign;
+    document.getElementById('phone').required = !foreign;
+    validateForm();
+  }
 
   function validateForm() {
     const name = document.getElementById('name').value || '';
-    const cpf = document.getElementById('cpf').value || '';
     const birthdate = document.getElementById('birthdate').value || '';
     const email = document.getElementById('email').value || '';
-    const phone = document.getElementById('phone').value || '';
 
-    const isValid =
-      name.length >= 3 &&
-      cpf.replace(/\D/g, '').length === 11 &&
-      birthdate.length === 10 &&
-      email.includes('@') &&
-      phone.length === 16;
+    let isValid = name.length >= 3 && birthdate.length === 10 && email.includes('@');
+
+    if (isForeign()) {
+      const country = (document.getElementById('country').value || '').trim();
+      const doc = (document.getElementById('document').value || '').replace(/[\s.\-\/]/g, '');
+      const phoneIntl = (document.getElementById('phoneIntl').value || '').replace(/[^\d+]/g, '');
+      isValid = isValid &&
+        /^[A-Za-z]{2}$/.test(country) &&
+        doc.length >= 5 &&
+        /^\+[1-9]\d{7,14}$/.test(phoneIntl);
+    } else {
+      const cpf = document.getElementById('cpf').value || '';
+      const phone = document.getElementById('phone').value || '';
+      isValid = isValid && cpf.replace(/\D/g, '').length === 11 && phone.length === 16;
+    }
 
     payButton.disabled = !isValid;
     return isValid;
   }
 
+  if (foreignBuyer) {
+    foreignBuyer.addEventListener('change', toggleBuyerType);
+  }
+
   validateForm();
 
   form.querySelectorAll('input').forEach(function (input) {
@@ -33,14 +62,24 @@ document.addEventListener('DOMContentLoaded', function () {
 
     const formData = {
       name: document.getElementById('name').value.trim(),
-      cpf: document.getElementById('cpf').value.replace(/\D/g, ''),
       birthdate: document.getElementById('birthdate').value,
       email: document.getElementById('email').value.trim(),
-      phone: document.getElementById('phone').value.replace(/\D/g, ''),
       ebookId: document.getElementById('ebookId').value,
+      currency: document.getElementById('currency').value,
       csrfToken: document.getElementById('csrfToken').value,
     };
 
+    if (isForeign()) {
+      formData.buyerType = 'foreign';
+      formData.country = document.getElementById('country').value.trim().toUpperCase();
+      formData.document = document.getElementById('document').value.trim();
+      formData.phone = document.getElementById('phoneIntl').value.replace(/[^\d+]/g, '');
+    } else {
+      formData.buyerType = 'br';
+      formData.cpf = document.getElementById('cpf').value.replace(/\D/g, '');
+      formData.phone = document.getElementById('phone').value.replace(/\D/g, '');
+    }
+
     loadingSpinner.style.display = 'flex';
     payButton.disabled = true;
 
@@ -55,6 +94,8 @@ document.addEventListener('DOMContentLoaded', function () {
           createStripeSession(formData);
         } else if (response.already_purchased) {
           showAlreadyPurchased(response);
+        } else if (response.unavailable) {
+          showUnavailable(response);
         } else {
           showError(response.error || 'Erro na validação dos dados');
         }
@@ -72,6 +113,10 @@ document.addEventListener('DOMContentLoaded', function () {
       .then(function (response) {
         if (response.url) {
           window.location.href = response.url;
+        } else if (response.unavailable) {
+          showUnavailable(response);
+        } else if (response.blocked) {
+          showError(response.error);
         } else {
           showError('Erro ao criar sessão de pagamento');
         }
@@ -93,6 +138,14 @@ document.addEventListener('DOMContentLoaded', function () {
     }
   }
 
+  function showUnavailable(response) {
+    loadingSpinner.style.display = 'none';
+    payButton.disabled = true;
+    form.style.display = 'none';
+    document.getElementById('unavailableText').textContent = response.error;
+    document.getElementById('unavailableMessage').style.display = 'flex';
+  }
+
   function showError(message) {
     loadingSpinner.style.display = 'none';
     payButton.disabled = false;
@@ -4,6 +4,33 @@ function buyNow() {
     alert('Esta é uma visualização da página de vendas. A funcionalidade de compra não está disponível no modo preview.');
     return;
   }
-  const ebookId = document.querySelector('[data-ebook-id]').dataset.ebookId;
-  window.location.href = '/checkout/' + ebookId;
+  const container = document.querySelector('[data-ebook-id]');
+  const currency = container.dataset.currency;
+  window.location.href = '/checkout/' + container.dataset.ebookId + (currency ? '?currency=' + currency : '');
 }
+
+// Atualiza o contador de cópias restantes e troca a página para o modo esgotado quando a oferta acabar
+document.addEventListener('DOMContentLoaded', function () {
+  const container = document.querySelector('[data-ebook-id]');
+  if (!container || container.dataset.trackAvailability !== 'true') return;
+
+  const ebookId = container.dataset.ebookId;
+  const remainingStock = document.getElementById('remainingStock');
+
+  function refreshAvailability() {
+    fetch('/sales/' + ebookId + '/availability')
+      .then(function (res) { return res.json(); })
+      .then(function (response) {
+        if (response.status !== 'available') {
+          window.location.reload();
+          return;
+        }
+        if (remainingStock && response.remaining >= 0) {
+          remainingStock.textContent = response.remaining;
+        }
+      })
+      .catch(function () {});
+  }
+
+  setInterval(refreshAvailability, 15000);
+});